@@ -188,6 +188,34 @@ CREATE TABLE IF NOT EXISTS `sys_user_login_attempt` (
   KEY `idx_username` (`username`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- 项目表（租户），资源按项目隔离
+CREATE TABLE IF NOT EXISTS `sys_project` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL COMMENT '项目名称',
+  `code` varchar(50) NOT NULL COMMENT '项目编码',
+  `description` varchar(500) COMMENT '项目描述',
+  `status` tinyint DEFAULT 1 COMMENT '状态 1:启用 0:禁用',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` datetime COMMENT '删除时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_sys_project_code` (`code`),
+  KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 项目成员表，role_id 为成员在项目中的角色，与全局角色叠加生效
+CREATE TABLE IF NOT EXISTS `sys_project_member` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `project_id` bigint unsigned NOT NULL COMMENT '项目ID',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `role_id` bigint unsigned DEFAULT 0 COMMENT '项目角色ID(0表示普通成员)',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_project_user` (`project_id`, `user_id`),
+  KEY `idx_sys_project_member_user_id` (`user_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- ============================================================
 -- 2. 审计日志表
 -- ============================================================
@@ -285,11 +313,13 @@ CREATE TABLE IF NOT EXISTS `asset_group` (
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   `deleted_at` datetime COMMENT '删除时间',
+  `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
   PRIMARY KEY (`id`),
   UNIQUE KEY `uk_code` (`code`, `deleted_at`),
   KEY `idx_parent_id` (`parent_id`),
   KEY `idx_sort` (`sort`),
-  KEY `idx_deleted_at` (`deleted_at`)
+  KEY `idx_deleted_at` (`deleted_at`),
+  KEY `idx_asset_group_project_id` (`project_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- 凭证表
@@ -305,9 +335,11 @@ CREATE TABLE IF NOT EXISTS `credentials` (
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   `deleted_at` datetime COMMENT '删除时间',
+  `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
   PRIMARY KEY (`id`),
   KEY `idx_type` (`type`),
-  KEY `idx_deleted_at` (`deleted_at`)
+  KEY `idx_deleted_at` (`deleted_at`),
+  KEY `idx_credentials_project_id` (`project_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- 主机表
@@ -344,11 +376,13 @@ CREATE TABLE IF NOT EXISTS `hosts` (
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   `deleted_at` datetime COMMENT '删除时间',
+  `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
   PRIMARY KEY (`id`),
   KEY `idx_group_id` (`group_id`),
   KEY `idx_ip` (`ip`),
   KEY `idx_status` (`status`),
   KEY `idx_deleted_at` (`deleted_at`),
+  KEY `idx_hosts_project_id` (`project_id`),
   CONSTRAINT `fk_hosts_group` FOREIGN KEY (`group_id`) REFERENCES `asset_group` (`id`) ON DELETE SET NULL
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
@@ -365,29 +399,70 @@ CREATE TABLE IF NOT EXISTS `cloud_accounts` (
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   `deleted_at` datetime COMMENT '删除时间',
+  `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
   PRIMARY KEY (`id`),
   KEY `idx_provider` (`provider`),
-  KEY `idx_deleted_at` (`deleted_at`)
+  KEY `idx_deleted_at` (`deleted_at`),
+  KEY `idx_cloud_accounts_project_id` (`project_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- 角色资产权限表
 CREATE TABLE IF NOT EXISTS `sys_role_asset_permission` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
-  `role_id` bigint unsigned NOT NULL COMMENT '角色ID',
+  `role_id` bigint unsigned NOT NULL COMMENT '角色ID(个人临时授权为0)',
+  `user_id` bigint unsigned DEFAULT 0 COMMENT '用户ID，不为0时为该用户的临时授权',
   `asset_group_id` bigint unsigned NOT NULL COMMENT '资产组ID',
   `host_ids` json COMMENT '主机ID列表',
   `permissions` int unsigned DEFAULT 63 COMMENT '权限位',
+  `effect` varchar(10) DEFAULT 'allow' COMMENT '规则效果 allow:允许 deny:拒绝',
+  `conditions` json COMMENT '生效条件(主机标签/环境/时间段/来源IP)',
+  `expires_at` datetime DEFAULT NULL COMMENT '过期时间，为空表示长期有效',
+  `remark` varchar(200) DEFAULT NULL COMMENT '备注',
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   `deleted_at` datetime COMMENT '删除时间',
   PRIMARY KEY (`id`),
   UNIQUE KEY `uk_role_asset` (`role_id`, `asset_group_id`, `deleted_at`),
+  KEY `idx_user_id` (`user_id`),
   KEY `idx_asset_group_id` (`asset_group_id`),
+  KEY `idx_expires_at` (`expires_at`),
   KEY `idx_deleted_at` (`deleted_at`),
-  CONSTRAINT `fk_role_asset_perm_role` FOREIGN KEY (`role_id`) REFERENCES `sys_role` (`id`) ON DELETE CASCADE,
   CONSTRAINT `fk_role_asset_perm_group` FOREIGN KEY (`asset_group_id`) REFERENCES `asset_group` (`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- 临时权限申请表
+CREATE TABLE IF NOT EXISTS `sys_access_request` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '申请人ID',
+  `username` varchar(50) COMMENT '申请人用户名',
+  `resource_type` varchar(30) NOT NULL COMMENT '资源类型 host_group:主机分组 k8s_cluster:K8s集群',
+  `resource_name` varchar(255) COMMENT '资源名称',
+  `asset_group_id` bigint unsigned DEFAULT 0 COMMENT '资产分组ID',
+  `host_ids` json COMMENT '主机ID列表(为空表示整个分组)',
+  `permissions` int unsigned DEFAULT 0 COMMENT '操作权限位掩码',
+  `cluster_id` bigint unsigned DEFAULT 0 COMMENT '集群ID',
+  `role_name` varchar(255) COMMENT 'K8s角色名称',
+  `role_namespace` varchar(255) COMMENT 'K8s角色命名空间',
+  `role_type` varchar(50) COMMENT 'ClusterRole 或 Role',
+  `duration` bigint NOT NULL COMMENT '申请时长(分钟)',
+  `reason` varchar(500) NOT NULL COMMENT '申请原因',
+  `status` varchar(20) NOT NULL COMMENT '状态',
+  `approver_id` bigint unsigned DEFAULT 0 COMMENT '审批人ID',
+  `approver_name` varchar(50) COMMENT '审批人',
+  `comment` varchar(500) COMMENT '审批意见',
+  `approved_at` datetime DEFAULT NULL COMMENT '授权时间',
+  `expires_at` datetime DEFAULT NULL COMMENT '授权到期时间',
+  `revoked_at` datetime DEFAULT NULL COMMENT '回收时间',
+  `revoked_by` varchar(50) COMMENT '回收人，到期回收为 system',
+  `grant_id` bigint unsigned DEFAULT 0 COMMENT '生效的授权记录ID',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_status` (`status`),
+  KEY `idx_expires_at` (`expires_at`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- SSH终端会话记录表（资产管理-终端审计）
 CREATE TABLE IF NOT EXISTS `ssh_terminal_sessions` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
@@ -512,10 +587,12 @@ CREATE TABLE IF NOT EXISTS `k8s_clusters` (
   `status_synced_at` datetime COMMENT '状态同步时间',
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
   PRIMARY KEY (`id`),
   UNIQUE KEY `uk_name` (`name`),
   KEY `idx_status` (`status`),
-  KEY `idx_provider` (`provider`)
+  KEY `idx_provider` (`provider`),
+  KEY `idx_k8s_clusters_project_id` (`project_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- 用户kubeconfig表
@@ -622,10 +699,12 @@ CREATE TABLE IF NOT EXISTS `domain_monitors` (
   `ssl_expiry_days` int DEFAULT 30 COMMENT '证书过期天数阈值',
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
   PRIMARY KEY (`id`),
   UNIQUE KEY `uk_domain` (`domain`),
   KEY `idx_status` (`status`),
-  KEY `idx_next_check` (`next_check`)
+  KEY `idx_next_check` (`next_check`),
+  KEY `idx_domain_monitors_project_id` (`project_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- 告警配置表
@@ -748,12 +827,14 @@ CREATE TABLE IF NOT EXISTS `ssl_certificates` (
   `dns_provider_id` bigint unsigned DEFAULT NULL COMMENT 'DNS服务商ID',
   `last_renew_at` datetime COMMENT '最后续期时间',
   `last_error` text COMMENT '最后错误信息',
+  `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
   PRIMARY KEY (`id`),
   KEY `idx_ssl_certificates_deleted_at` (`deleted_at`),
   KEY `idx_ssl_certificates_domain` (`domain`),
   KEY `idx_ssl_certificates_not_after` (`not_after`),
   KEY `idx_ssl_certificates_cloud_account_id` (`cloud_account_id`),
-  KEY `idx_ssl_certificates_dns_provider_id` (`dns_provider_id`)
+  KEY `idx_ssl_certificates_dns_provider_id` (`dns_provider_id`),
+  KEY `idx_ssl_certificates_project_id` (`project_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- SSL DNS服务商配置表
@@ -1094,11 +1175,13 @@ CREATE TABLE IF NOT EXISTS `nginx_sources` (
   `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   `deleted_at` datetime COMMENT '删除时间',
+  `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
   PRIMARY KEY (`id`),
   KEY `idx_host_id` (`host_id`),
   KEY `idx_cluster_id` (`cluster_id`),
   KEY `idx_status` (`status`),
-  KEY `idx_deleted_at` (`deleted_at`)
+  KEY `idx_deleted_at` (`deleted_at`),
+  KEY `idx_nginx_sources_project_id` (`project_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- Nginx IP 维度表
@@ -1363,6 +1446,7 @@ VALUES
   (11, '部门信息', 'dept-info', 2, 1, '/dept-info', 'system/DeptInfo', 'OfficeBuilding', 5, 1, 1, NOW(), NOW()),
   (12, '岗位信息', 'position-info', 2, 1, '/position-info', 'system/PositionInfo', 'Avatar', 6, 1, 1, NOW(), NOW()),
   (13, '系统配置', 'system-config', 2, 1, '/system-config', 'system/SystemConfig', 'Setting', 7, 1, 1, NOW(), NOW()),
+  (67, '项目管理', 'projects', 2, 1, '/projects', 'system/Projects', 'Briefcase', 8, 1, 1, NOW(), NOW()),
 
   -- ========== 身份认证子菜单 (parent_id=90) ==========
   (91, '身份源管理', 'identity_sources', 2, 90, '/identity/sources', 'identity/IdentitySources', 'User', 1, 1, 1, NOW(), NOW()),
@@ -1379,6 +1463,7 @@ VALUES
   (27, '云账号管理', 'cloud-accounts', 2, 15, '/asset/cloud-accounts', 'asset/CloudAccounts', 'Cloudy', 5, 1, 1, NOW(), NOW()),
   (34, '终端审计', 'asset_terminal_audit', 2, 15, '/asset/terminal-audit', '', 'View', 5, 1, 1, NOW(), NOW()),
   (65, '权限配置', 'asset_permission', 2, 15, '/asset/permissions', 'views/asset/AssetPermission.vue', 'Lock', 6, 1, 1, NOW(), NOW()),
+  (66, '临时权限', 'access_request', 2, 15, '/asset/access-requests', 'asset/AccessRequests', 'Timer', 7, 1, 1, NOW(), NOW()),
 
   -- ========== 操作审计子菜单 (parent_id=23) ==========
   (24, '操作日志', 'operation-logs', 2, 23, '/audit/operation-logs', 'audit/OperationLogs', 'Document', 1, 1, 1, NOW(), NOW()),
@@ -1392,13 +1477,13 @@ VALUES
 INSERT INTO `sys_role_menu` (`role_id`, `menu_id`)
 VALUES
   (1, 1), (1, 2), (1, 3), (1, 5), (1, 10), (1, 11), (1, 12), (1, 13), (1, 15), (1, 16), (1, 17), (1, 19),
-  (1, 23), (1, 24), (1, 25), (1, 27), (1, 29), (1, 30), (1, 32), (1, 33), (1, 34), (1, 65),
+  (1, 23), (1, 24), (1, 25), (1, 27), (1, 29), (1, 30), (1, 32), (1, 33), (1, 34), (1, 65), (1, 66), (1, 67),
   (1, 90), (1, 91), (1, 92), (1, 93), (1, 94), (1, 95), (1, 96);
 
 -- 为普通用户角色分配基础菜单权限
 INSERT INTO `sys_role_menu` (`role_id`, `menu_id`)
 VALUES
-  (2, 10), (2, 15), (2, 16), (2, 17), (2, 19), (2, 27), (2, 34), (2, 65),
+  (2, 10), (2, 15), (2, 16), (2, 17), (2, 19), (2, 27), (2, 34), (2, 65), (2, 66),
   (2, 23), (2, 24), (2, 25),
   (2, 90), (2, 92), (2, 93), (2, 96);
 
@@ -1450,3 +1535,10 @@ VALUES (1, 'admin', '$2a$10$RLkgoedTSa0dYj3ujbXMcunSED3c6GLvfdKYsmpz0l0YFZbVrSBq
 
 -- 关联admin用户到admin角色
 INSERT INTO `sys_user_role` (`user_id`, `role_id`) VALUES (1, 1);
+
+-- 创建默认项目，存量和未指定项目的资源归属默认项目
+INSERT INTO `sys_project` (`id`, `name`, `code`, `description`, `status`, `created_at`, `updated_at`)
+VALUES (1, '默认项目', 'default', '未划分项目的资源', 1, NOW(), NOW());
+
+-- 将admin用户加入默认项目
+INSERT INTO `sys_project_member` (`project_id`, `user_id`, `role_id`, `created_at`, `updated_at`) VALUES (1, 1, 0, NOW(), NOW());
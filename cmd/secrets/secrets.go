@@ -0,0 +1,141 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ydcloud-dy/opshub/cmd/root"
+	"github.com/ydcloud-dy/opshub/internal/conf"
+	dataPkg "github.com/ydcloud-dy/opshub/internal/data"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
+	k8srepository "github.com/ydcloud-dy/opshub/plugins/kubernetes/data/repository"
+	sslrepository "github.com/ydcloud-dy/opshub/plugins/ssl-cert/repository"
+	taskservice "github.com/ydcloud-dy/opshub/plugins/task/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// rotator 重新加密一类敏感数据
+type rotator struct {
+	name   string
+	rotate func(ctx context.Context, db *gorm.DB, k *crypto.Keyring, dryRun bool) (int, error)
+}
+
+// rotators 所有加密保存的敏感数据，新增加密字段时需要在此登记
+var rotators = []rotator{
+	{"主机凭证、云账号密钥", assetdata.RotateSecrets},
+	{"K8s集群 kubeconfig", k8srepository.RotateSecrets},
+	{"SSL证书私钥、DNS服务商配置", sslrepository.RotateSecrets},
+	{"任务中心敏感变量、待审批任务", taskservice.RotateSecrets},
+}
+
+var dryRun bool
+
+var Cmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "敏感数据加密管理",
+	Long:  `管理敏感数据加密使用的主密钥，并使用新的主密钥重新加密已保存的数据`,
+}
+
+var genkeyCmd = &cobra.Command{
+	Use:   "genkey",
+	Short: "生成新的主密钥",
+	Long: `生成新的主密钥，输出格式为 "<密钥ID>:<base64密钥>"。
+
+轮换主密钥的步骤：
+  1. 将新密钥添加到密钥文件的第一行（或 crypto.keys 的第一项，或设置 crypto.primary_key），保留旧密钥
+  2. 重启服务，新写入的数据使用新密钥加密，旧数据仍可使用旧密钥解密
+  3. 执行 opshub secrets rotate 使用新密钥重新加密已保存的数据
+  4. 确认无误后从配置中移除旧密钥`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "生成主密钥失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s:%s\n", crypto.NewKeyID(), key)
+	},
+}
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "使用主用密钥重新加密已保存的敏感数据",
+	Long:  `使用配置中的主用密钥重新加密主机凭证、云账号密钥、证书私钥、kubeconfig、DNS服务商配置及任务中心的敏感数据，同时加密早期版本明文保存的数据`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRotate(cmd.Context()); err != nil {
+			fmt.Fprintf(os.Stderr, "重新加密失败: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	root.Cmd.AddCommand(Cmd)
+	Cmd.AddCommand(genkeyCmd)
+	Cmd.AddCommand(rotateCmd)
+	rotateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "只统计需要重新加密的数据，不写入数据库")
+}
+
+func runRotate(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cfg, err := conf.Load(root.GetConfigFile())
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	keyring, err := crypto.Load(cfg.Crypto.KeyringOptions())
+	if err != nil {
+		return fmt.Errorf("加载主密钥失败: %w", err)
+	}
+	crypto.SetDefault(keyring)
+
+	data, err := dataPkg.NewData(cfg)
+	if err != nil {
+		return fmt.Errorf("初始化数据层失败: %w", err)
+	}
+	defer data.Close()
+	// 不输出SQL日志，避免密文及更新语句刷屏
+	db := data.DB().Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+
+	fmt.Printf("主用密钥: %s\n", keyring.Primary())
+	total := 0
+	for _, r := range rotators {
+		n, err := r.rotate(ctx, db, keyring, dryRun)
+		total += n
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.name, err)
+		}
+		fmt.Printf("  %s: %d\n", r.name, n)
+	}
+	if dryRun {
+		fmt.Printf("共 %d 项需要重新加密（dry-run，未写入）\n", total)
+	} else {
+		fmt.Printf("共重新加密 %d 项\n", total)
+	}
+	return nil
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/ydcloud-dy/opshub/cmd/root"
 	"github.com/ydcloud-dy/opshub/internal/biz"
+	assetmodel "github.com/ydcloud-dy/opshub/internal/biz/asset"
 	auditmodel "github.com/ydcloud-dy/opshub/internal/biz/audit"
 	rbacmodel "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	systemmodel "github.com/ydcloud-dy/opshub/internal/biz/system"
@@ -40,6 +41,7 @@ import (
 	"github.com/ydcloud-dy/opshub/internal/server"
 	"github.com/ydcloud-dy/opshub/internal/service"
 	rbacservice "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/data/models"
 	k8smodel "github.com/ydcloud-dy/opshub/plugins/kubernetes/model"
@@ -122,6 +124,11 @@ func runServer() (*conf.Config, error) {
 		zap.String("mode", cfg.Server.Mode),
 	)
 
+	// 初始化敏感数据加密主密钥
+	if err := initCrypto(cfg); err != nil {
+		return nil, fmt.Errorf("初始化主密钥失败: %w", err)
+	}
+
 	// 初始化数据层
 	data, err := dataPkg.NewData(cfg)
 	if err != nil {
@@ -172,6 +179,29 @@ func runServer() (*conf.Config, error) {
 	return cfg, nil
 }
 
+// initCrypto 加载主密钥并设置全局密钥环，未配置主密钥时生成默认密钥文件
+func initCrypto(cfg *conf.Config) error {
+	opts := cfg.Crypto.KeyringOptions()
+	if cfg.Crypto.KeyFile == "" && len(cfg.Crypto.Keys) == 0 {
+		created, err := crypto.EnsureKeyFile(opts.KeyFile)
+		if err != nil {
+			return err
+		}
+		if created {
+			appLogger.Warn("未配置主密钥，已生成主密钥文件，请妥善备份，丢失后已加密的数据将无法恢复",
+				zap.String("keyFile", opts.KeyFile))
+		}
+	}
+
+	keyring, err := crypto.Load(opts)
+	if err != nil {
+		return err
+	}
+	crypto.SetDefault(keyring)
+	appLogger.Info("主密钥加载完成", zap.String("primary", keyring.Primary()), zap.Strings("keys", keyring.KeyIDs()))
+	return nil
+}
+
 // autoMigrate 自动迁移数据库表
 func autoMigrate(db *gorm.DB) error {
 	// 自动迁移表结构
@@ -185,6 +215,9 @@ func autoMigrate(db *gorm.DB) error {
 		&rbacmodel.SysPosition{},
 		&rbacmodel.SysUserPosition{},
 		&rbacmodel.SysRoleAssetPermission{},
+		&rbacmodel.SysAccessRequest{},
+		&rbacmodel.SysProject{},
+		&rbacmodel.SysProjectMember{},
 		// 系统配置相关表
 		&systemmodel.SysConfig{},
 		&systemmodel.SysUserLoginAttempt{},
@@ -196,10 +229,57 @@ func autoMigrate(db *gorm.DB) error {
 		&auditmodel.SysOperationLog{},
 		&auditmodel.SysLoginLog{},
 		&auditmodel.SysDataLog{},
+		// 主机密钥
+		&assetmodel.HostKey{},
+		// 主机指标
+		&assetmodel.HostMetric{},
+		// 主机清单
+		&assetmodel.HostInventory{},
+		&assetmodel.HostPackage{},
+		&assetmodel.HostListenPort{},
+		&assetmodel.HostServiceUnit{},
+		&assetmodel.HostUser{},
+		&assetmodel.HostInterface{},
+		&assetmodel.HostMount{},
+		// 配置快照与漂移检查
+		&assetmodel.HostSnapshot{},
+		&assetmodel.DriftReport{},
+		// 终端命令
+		&assetmodel.TerminalCommand{},
+		// 终端录制全文索引
+		&assetmodel.TerminalRecording{},
+		&assetmodel.TerminalRecordingLine{},
 	); err != nil {
 		return err
 	}
 
+	// 主机、资产分组表由 migrations/init.sql 创建，这里补充后续版本新增的列
+	for _, m := range []interface{}{&assetmodel.Host{}, &assetmodel.AssetGroup{}} {
+		if !db.Migrator().HasColumn(m, "GatewayIDs") {
+			if err := db.Migrator().AddColumn(m, "GatewayIDs"); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 资产归属项目，存量数据通过列默认值归入默认项目
+	for _, m := range []interface{}{&assetmodel.Host{}, &assetmodel.AssetGroup{}, &assetmodel.Credential{}, &assetmodel.CloudAccount{}} {
+		if !db.Migrator().HasColumn(m, "ProjectID") {
+			if err := db.Migrator().AddColumn(m, "ProjectID"); err != nil {
+				return err
+			}
+		}
+		if !db.Migrator().HasIndex(m, "ProjectID") {
+			if err := db.Migrator().CreateIndex(m, "ProjectID"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := initDefaultProject(db); err != nil {
+		return err
+	}
+
 	// 为用户表创建虚拟列和唯一索引
 	// 问题：MySQL 唯一索引中多个 NULL 值被认为是不同的，无法正确约束
 	// 解决：使用虚拟列 is_deleted (0=未删除, 1=已删除) 来创建唯一索引
@@ -234,6 +314,34 @@ func autoMigrate(db *gorm.DB) error {
 	return nil
 }
 
+// initDefaultProject 创建默认项目，首次创建时把已有用户都加入默认项目，保证升级后原有用户仍能看到存量资源
+func initDefaultProject(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&rbacmodel.SysProject{}).Unscoped().Where("id = ?", rbacmodel.DefaultProjectID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	project := &rbacmodel.SysProject{
+		Model:       gorm.Model{ID: rbacmodel.DefaultProjectID},
+		Name:        "默认项目",
+		Code:        "default",
+		Description: "未划分项目的资源",
+		Status:      1,
+	}
+	if err := db.Create(project).Error; err != nil {
+		return fmt.Errorf("创建默认项目失败: %w", err)
+	}
+	if err := db.Exec("INSERT IGNORE INTO sys_project_member (project_id, user_id, role_id, created_at, updated_at) "+
+		"SELECT ?, id, 0, NOW(), NOW() FROM sys_user WHERE deleted_at IS NULL", rbacmodel.DefaultProjectID).Error; err != nil {
+		return fmt.Errorf("初始化默认项目成员失败: %w", err)
+	}
+	appLogger.Info("已创建默认项目")
+	return nil
+}
+
 // initDefaultData 初始化默认数据
 func initDefaultData(db *gorm.DB) error {
 	// 检查是否已有管理员用户
@@ -20,6 +20,7 @@ require (
 	github.com/phuslu/iploc v1.0.20260115
 	github.com/pkg/sftp v1.13.10
 	github.com/redis/go-redis/v9 v9.17.2
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/spf13/cobra v1.10.2
 	github.com/spf13/viper v1.21.0
 	github.com/swaggo/files v1.0.1
@@ -37,6 +38,7 @@ require (
 	k8s.io/api v0.35.0
 	k8s.io/apimachinery v0.35.0
 	k8s.io/client-go v0.35.0
+	mvdan.cc/sh/v3 v3.12.0
 )
 
 replace github.com/ydcloud-dy/opshub/plugins/kubernetes => ./plugins/kubernetes
@@ -0,0 +1,109 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"fmt"
+
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+)
+
+// HostGroupAccessGranter 主机分组的临时权限，授权为申请人个人的、带到期时间的资产授权规则
+type HostGroupAccessGranter struct {
+	groupRepo      AssetGroupRepo
+	hostRepo       HostRepo
+	permissionRepo rbacbiz.AssetPermissionRepo
+}
+
+func NewHostGroupAccessGranter(groupRepo AssetGroupRepo, hostRepo HostRepo, permissionRepo rbacbiz.AssetPermissionRepo) *HostGroupAccessGranter {
+	return &HostGroupAccessGranter{
+		groupRepo:      groupRepo,
+		hostRepo:       hostRepo,
+		permissionRepo: permissionRepo,
+	}
+}
+
+// Describe 校验分组和主机，返回分组名称
+func (g *HostGroupAccessGranter) Describe(ctx context.Context, req *rbacbiz.SysAccessRequest) (string, error) {
+	group, err := g.groupRepo.GetByID(ctx, req.AssetGroupID)
+	if err != nil {
+		return "", fmt.Errorf("主机分组不存在: %w", err)
+	}
+	for _, hostID := range req.HostIDs {
+		host, err := g.hostRepo.GetByID(ctx, hostID)
+		if err != nil || host.GroupID != req.AssetGroupID {
+			return "", fmt.Errorf("主机 %d 不属于分组 %s", hostID, group.Name)
+		}
+	}
+	return group.Name, nil
+}
+
+// Authorize 审批人需要对申请的每台主机（未指定主机时为分组下所有主机）都拥有申请的操作权限
+func (g *HostGroupAccessGranter) Authorize(ctx context.Context, req *rbacbiz.SysAccessRequest, approverID uint) error {
+	hostIDs := []uint(req.HostIDs)
+	if len(hostIDs) == 0 {
+		hosts, err := g.hostRepo.GetByGroupID(ctx, req.AssetGroupID)
+		if err != nil {
+			return err
+		}
+		if len(hosts) == 0 {
+			return fmt.Errorf("主机分组 %s 下没有主机", req.ResourceName)
+		}
+		for _, host := range hosts {
+			hostIDs = append(hostIDs, host.ID)
+		}
+	}
+	for _, hostID := range hostIDs {
+		permissions, err := g.permissionRepo.GetUserHostPermissions(ctx, approverID, hostID)
+		if err != nil {
+			return err
+		}
+		if permissions&req.Permissions != req.Permissions {
+			return rbacbiz.ErrAccessRequestBeyondApprover
+		}
+	}
+	return nil
+}
+
+// Grant 创建申请人个人的资产授权规则，到期后规则自动失效
+func (g *HostGroupAccessGranter) Grant(ctx context.Context, req *rbacbiz.SysAccessRequest) (uint, error) {
+	rule := &rbacbiz.SysRoleAssetPermission{
+		UserID:       req.UserID,
+		AssetGroupID: req.AssetGroupID,
+		HostIDs:      req.HostIDs,
+		Permissions:  req.Permissions,
+		Effect:       rbacbiz.EffectAllow,
+		ExpiresAt:    req.ExpiresAt,
+		Remark:       fmt.Sprintf("临时权限申请#%d", req.ID),
+	}
+	if err := g.permissionRepo.CreateRule(ctx, rule); err != nil {
+		return 0, err
+	}
+	return rule.ID, nil
+}
+
+// Revoke 删除授权规则
+func (g *HostGroupAccessGranter) Revoke(ctx context.Context, req *rbacbiz.SysAccessRequest) error {
+	if req.GrantID == 0 {
+		return nil
+	}
+	return g.permissionRepo.Delete(ctx, req.GrantID)
+}
@@ -24,6 +24,7 @@ import "gorm.io/gorm"
 // AssetGroup 资产分组表（支持多级分组）
 type AssetGroup struct {
 	gorm.Model
+	ProjectID   uint          `gorm:"column:project_id;<-:create;default:1;index;comment:所属项目ID" json:"projectId"`
 	Name        string        `gorm:"type:varchar(100);not null;comment:分组名称" json:"name"`
 	Code        string        `gorm:"type:varchar(50);uniqueIndex;comment:分组编码" json:"code"`
 	ParentID    uint          `gorm:"column:parent_id;default:0;comment:父分组ID" json:"parentId"`
@@ -32,6 +33,7 @@ type AssetGroup struct {
 	Description string        `gorm:"type:varchar(500);comment:分组描述" json:"description"`
 	Sort        int           `gorm:"type:int;default:0;comment:排序" json:"sort"`
 	Status      int           `gorm:"type:tinyint;default:1;comment:状态 1:启用 0:禁用" json:"status"`
+	GatewayIDs  string        `gorm:"column:gateway_ids;type:varchar(500);comment:跳板机主机ID(JSON数组，按连接顺序)" json:"-"`
 	HostCount   int           `gorm:"-" json:"hostCount"` // 主机数量（不存储在数据库）
 }
 
@@ -44,6 +46,7 @@ type AssetGroupRequest struct {
 	Description string `json:"description"`
 	Sort        int    `json:"sort"`
 	Status      int    `json:"status" binding:"required"`
+	GatewayIDs  []uint `json:"gatewayIds"` // 跳板机主机ID，按连接顺序，为空时继承上级分组
 }
 
 // ToModel 转换为AssetGroup模型
@@ -56,6 +59,7 @@ func (r *AssetGroupRequest) ToModel() *AssetGroup {
 		Description: r.Description,
 		Sort:        r.Sort,
 		Status:      r.Status,
+		GatewayIDs:  EncodeGatewayIDs(r.GatewayIDs),
 	}
 }
 
@@ -69,6 +73,7 @@ type AssetGroupInfoVO struct {
 	Sort        int                  `json:"sort"`
 	Status      int                  `json:"status"`
 	HostCount   int                  `json:"hostCount"`
+	GatewayIDs  []uint               `json:"gatewayIds"`
 	CreateTime  string               `json:"createTime"`
 	Children    []*AssetGroupInfoVO  `json:"children,omitempty"`
 }
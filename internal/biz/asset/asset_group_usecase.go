@@ -25,19 +25,27 @@ import (
 
 type AssetGroupUseCase struct {
 	groupRepo AssetGroupRepo
+	gateways  *GatewayUseCase
 }
 
-func NewAssetGroupUseCase(groupRepo AssetGroupRepo) *AssetGroupUseCase {
+func NewAssetGroupUseCase(groupRepo AssetGroupRepo, gateways *GatewayUseCase) *AssetGroupUseCase {
 	return &AssetGroupUseCase{
 		groupRepo: groupRepo,
+		gateways:  gateways,
 	}
 }
 
 func (uc *AssetGroupUseCase) Create(ctx context.Context, group *AssetGroup) error {
+	if err := uc.gateways.Validate(ctx, 0, DecodeGatewayIDs(group.GatewayIDs)); err != nil {
+		return err
+	}
 	return uc.groupRepo.Create(ctx, group)
 }
 
 func (uc *AssetGroupUseCase) Update(ctx context.Context, group *AssetGroup) error {
+	if err := uc.gateways.Validate(ctx, 0, DecodeGatewayIDs(group.GatewayIDs)); err != nil {
+		return err
+	}
 	return uc.groupRepo.Update(ctx, group)
 }
 
@@ -95,6 +103,7 @@ func (uc *AssetGroupUseCase) ToInfoVO(group *AssetGroup) *AssetGroupInfoVO {
 		Sort:        group.Sort,
 		Status:      group.Status,
 		HostCount:   group.HostCount,
+		GatewayIDs:  DecodeGatewayIDs(group.GatewayIDs),
 		CreateTime:  group.CreatedAt.Format("2006-01-02 15:04:05"),
 	}
 	if len(group.Children) > 0 {
@@ -0,0 +1,381 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxCommandLine 单行命令、回显行保留的最大字符数
+const maxCommandLine = 4096
+
+// 回显解析状态
+const (
+	outNormal = iota
+	outEscape
+	outCSI
+	outOSC
+	outOSCEscape
+)
+
+// CommandLine 根据交互式终端的输入还原 shell 提交的命令行
+// 模拟 readline 的常用编辑键；使用了历史命令、Tab 补全等无法从输入推断结果的按键时，
+// 改用终端回显的当前行（去掉提示符）作为命令。全屏程序（vim、top 等）中和密码提示后的输入不视为命令
+type CommandLine struct {
+	line      []rune
+	cursor    int
+	uncertain bool   // 本行使用了历史命令、补全等按键
+	paste     bool   // 处于括号粘贴中，换行不提交
+	esc       []byte // 未接收完整的输入转义序列
+	partial   []byte // 未接收完整的 UTF-8 字符
+
+	output     []rune // 回显的当前行
+	outCursor  int
+	outState   int
+	outParams  []byte
+	outPartial []byte
+	altScreen  bool // 全屏程序使用的备用屏幕
+}
+
+// NewCommandLine 创建命令行还原器
+func NewCommandLine() *CommandLine {
+	return &CommandLine{}
+}
+
+// Input 处理一段用户输入，遇到回车时停止：
+// forward 为回车之前需要发送给主机的输入，submitted 表示遇到了回车，
+// command 为回车提交的命令（不是 shell 命令时为空），rest 为回车之后尚未处理的输入
+func (b *CommandLine) Input(data []byte) (forward []byte, command string, submitted bool, rest []byte) {
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if len(b.esc) > 0 || c == 0x1b {
+			b.esc = append(b.esc, c)
+			if escapeComplete(b.esc) {
+				b.handleEscape(string(b.esc[1:]))
+				b.esc = b.esc[:0]
+			}
+			continue
+		}
+		if c >= 0x80 || len(b.partial) > 0 {
+			b.partial = append(b.partial, c)
+			if utf8.FullRune(b.partial) {
+				r, _ := utf8.DecodeRune(b.partial)
+				b.partial = b.partial[:0]
+				b.insert(r)
+			}
+			continue
+		}
+
+		switch c {
+		case '\r', '\n':
+			if b.paste {
+				b.insert('\n')
+				continue
+			}
+			next := i + 1
+			if c == '\r' && next < len(data) && data[next] == '\n' {
+				next++
+			}
+			return data[:i], b.submit(), true, data[next:]
+		case 0x7f, 0x08: // 退格
+			if b.cursor > 0 {
+				b.line = append(b.line[:b.cursor-1], b.line[b.cursor:]...)
+				b.cursor--
+			}
+		case 0x01: // Ctrl-A
+			b.cursor = 0
+		case 0x05: // Ctrl-E
+			b.cursor = len(b.line)
+		case 0x02: // Ctrl-B
+			if b.cursor > 0 {
+				b.cursor--
+			}
+		case 0x06: // Ctrl-F
+			if b.cursor < len(b.line) {
+				b.cursor++
+			}
+		case 0x04: // Ctrl-D
+			b.deleteAtCursor()
+		case 0x0b: // Ctrl-K
+			b.line = b.line[:b.cursor]
+		case 0x15: // Ctrl-U
+			b.line = append([]rune{}, b.line[b.cursor:]...)
+			b.cursor = 0
+		case 0x17: // Ctrl-W
+			start := b.cursor
+			for start > 0 && b.line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && b.line[start-1] != ' ' {
+				start--
+			}
+			b.line = append(b.line[:start], b.line[b.cursor:]...)
+			b.cursor = start
+		case 0x03: // Ctrl-C 放弃当前行
+			b.reset()
+		case '\t', 0x10, 0x0e, 0x12, 0x13, 0x19: // Tab、Ctrl-P/N/R/S/Y
+			b.uncertain = true
+		default:
+			if c >= 0x20 {
+				b.insert(rune(c))
+			}
+		}
+	}
+	return data, "", false, nil
+}
+
+// Output 处理一段终端输出，跟踪回显的当前行和是否处于全屏程序中
+func (b *CommandLine) Output(data []byte) {
+	for _, c := range data {
+		switch b.outState {
+		case outEscape:
+			switch c {
+			case '[':
+				b.outState = outCSI
+				b.outParams = b.outParams[:0]
+			case ']':
+				b.outState = outOSC
+			default:
+				b.outState = outNormal
+			}
+			continue
+		case outCSI:
+			if c >= 0x40 && c <= 0x7e {
+				b.handleOutputCSI(string(b.outParams), c)
+				b.outState = outNormal
+			} else if len(b.outParams) < 32 {
+				b.outParams = append(b.outParams, c)
+			}
+			continue
+		case outOSC:
+			// 设置窗口标题等，以 BEL 或 ESC \ 结束
+			if c == 0x07 {
+				b.outState = outNormal
+			} else if c == 0x1b {
+				b.outState = outOSCEscape
+			}
+			continue
+		case outOSCEscape:
+			b.outState = outNormal
+			continue
+		}
+
+		if c >= 0x80 || len(b.outPartial) > 0 {
+			b.outPartial = append(b.outPartial, c)
+			if utf8.FullRune(b.outPartial) {
+				r, _ := utf8.DecodeRune(b.outPartial)
+				b.outPartial = b.outPartial[:0]
+				b.writeOutput(r)
+			}
+			continue
+		}
+		switch c {
+		case 0x1b:
+			b.outState = outEscape
+		case '\r':
+			b.outCursor = 0
+		case '\n':
+			b.output = b.output[:0]
+			b.outCursor = 0
+		case 0x08:
+			if b.outCursor > 0 {
+				b.outCursor--
+			}
+		default:
+			if c >= 0x20 {
+				b.writeOutput(rune(c))
+			}
+		}
+	}
+}
+
+// escapeComplete 判断输入转义序列是否完整：CSI 以 0x40-0x7E 结尾，SS3 为三个字节，其余 Alt 组合键为两个字节
+func escapeComplete(seq []byte) bool {
+	if len(seq) < 2 {
+		return false
+	}
+	switch seq[1] {
+	case '[':
+		last := seq[len(seq)-1]
+		return len(seq) > 2 && last >= 0x40 && last <= 0x7e || len(seq) > 16
+	case 'O':
+		return len(seq) >= 3
+	}
+	return true
+}
+
+func (b *CommandLine) handleEscape(seq string) {
+	switch seq {
+	case "[C", "OC":
+		if b.cursor < len(b.line) {
+			b.cursor++
+		}
+	case "[D", "OD":
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case "[H", "OH", "[1~", "[7~":
+		b.cursor = 0
+	case "[F", "OF", "[4~", "[8~":
+		b.cursor = len(b.line)
+	case "[3~":
+		b.deleteAtCursor()
+	case "[200~":
+		b.paste = true
+	case "[201~":
+		b.paste = false
+	default:
+		// 上下键（历史命令）、Alt 组合键等无法从输入推断结果
+		if strings.HasPrefix(seq, "[") && !strings.HasSuffix(seq, "~") || strings.HasPrefix(seq, "O") || len(seq) == 1 {
+			b.uncertain = true
+		}
+	}
+}
+
+func (b *CommandLine) handleOutputCSI(params string, final byte) {
+	n := 1
+	if v, err := strconv.Atoi(strings.TrimPrefix(params, "?")); err == nil && v > 0 {
+		n = v
+	}
+	switch final {
+	case 'h', 'l':
+		switch params {
+		case "?1049", "?1047", "?47":
+			b.altScreen = final == 'h'
+		}
+	case 'K':
+		if params == "" || params == "0" {
+			if b.outCursor < len(b.output) {
+				b.output = b.output[:b.outCursor]
+			}
+		} else {
+			b.output = b.output[:0]
+			b.outCursor = 0
+		}
+	case 'C':
+		b.outCursor += n
+	case 'D':
+		b.outCursor -= n
+		if b.outCursor < 0 {
+			b.outCursor = 0
+		}
+	case 'P':
+		if b.outCursor < len(b.output) {
+			end := b.outCursor + n
+			if end > len(b.output) {
+				end = len(b.output)
+			}
+			b.output = append(b.output[:b.outCursor], b.output[end:]...)
+		}
+	case '@':
+		if b.outCursor < len(b.output) {
+			blanks := []rune(strings.Repeat(" ", n))
+			b.output = append(b.output[:b.outCursor], append(blanks, b.output[b.outCursor:]...)...)
+		}
+	case 'J':
+		if params == "2" || params == "3" {
+			b.output = b.output[:0]
+			b.outCursor = 0
+		}
+	}
+}
+
+func (b *CommandLine) writeOutput(r rune) {
+	for len(b.output) < b.outCursor {
+		b.output = append(b.output, ' ')
+	}
+	if b.outCursor < len(b.output) {
+		b.output[b.outCursor] = r
+	} else if len(b.output) < maxCommandLine {
+		b.output = append(b.output, r)
+	}
+	b.outCursor++
+}
+
+func (b *CommandLine) insert(r rune) {
+	if len(b.line) >= maxCommandLine {
+		return
+	}
+	b.line = append(b.line, 0)
+	copy(b.line[b.cursor+1:], b.line[b.cursor:])
+	b.line[b.cursor] = r
+	b.cursor++
+}
+
+func (b *CommandLine) deleteAtCursor() {
+	if b.cursor < len(b.line) {
+		b.line = append(b.line[:b.cursor], b.line[b.cursor+1:]...)
+	}
+}
+
+func (b *CommandLine) reset() {
+	b.line = b.line[:0]
+	b.cursor = 0
+	b.uncertain = false
+	b.paste = false
+}
+
+// submit 结束当前行并返回提交的命令
+func (b *CommandLine) submit() string {
+	defer b.reset()
+	if b.altScreen {
+		return ""
+	}
+	typed := strings.TrimSpace(string(b.line))
+	echoed := strings.TrimRightFunc(string(b.output), unicode.IsSpace)
+	if isPasswordPrompt(echoed, typed) {
+		return ""
+	}
+	if b.uncertain {
+		if cmd := stripPrompt(echoed); cmd != "" {
+			return cmd
+		}
+	}
+	return typed
+}
+
+// isPasswordPrompt 回显行是密码提示且输入没有回显时，输入的是密码
+func isPasswordPrompt(echoed, typed string) bool {
+	if typed != "" && strings.Contains(echoed, typed) {
+		return false
+	}
+	lower := strings.ToLower(strings.TrimSpace(echoed))
+	if !strings.HasSuffix(lower, ":") && !strings.HasSuffix(lower, "：") {
+		return false
+	}
+	return strings.Contains(lower, "password") || strings.Contains(lower, "passphrase") || strings.Contains(lower, "密码")
+}
+
+// stripPrompt 去掉回显行中的 shell 提示符，提示符以 $ # > % 加空格结尾，取最先出现的一个，避免误切命令中的重定向等
+func stripPrompt(line string) string {
+	end := -1
+	for _, prompt := range []string{"$ ", "# ", "> ", "% "} {
+		if i := strings.Index(line, prompt); i >= 0 && (end < 0 || i+len(prompt) < end) {
+			end = i + len(prompt)
+		}
+	}
+	if end < 0 {
+		return strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(line[end:])
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import "testing"
+
+func TestCommandLine(t *testing.T) {
+	type step struct {
+		out string // 先处理的终端输出
+		in  string // 再处理的用户输入
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		want  string
+		skip  bool // 提交的不是 shell 命令
+	}{
+		{name: "直接输入", steps: []step{{in: "ls -l\r"}}, want: "ls -l"},
+		{name: "退格修改", steps: []step{{in: "lss\x7f -a\r"}}, want: "ls -a"},
+		{name: "左移光标插入", steps: []step{{in: "ls\x1b[D\x1b[Dsudo \r"}}, want: "sudo ls"},
+		{name: "Ctrl-U清空后重新输入", steps: []step{{in: "rm -rf /\x15pwd\r"}}, want: "pwd"},
+		{name: "Ctrl-W删除单词", steps: []step{{in: "echo hello world\x17\x17date\r"}}, want: "echo date"},
+		{name: "中文分段到达", steps: []step{{in: "echo \xe4\xbd"}, {in: "\xa0\xe5\xa5\xbd\r"}}, want: "echo 你好"},
+		{
+			name: "Tab补全取回显",
+			steps: []step{
+				{out: "[root@web ~]# ", in: "sys\t"},
+				{out: "systemctl ", in: "restart nginx"},
+				{out: "restart nginx", in: "\r"},
+			},
+			want: "systemctl restart nginx",
+		},
+		{
+			name: "历史命令取回显",
+			steps: []step{
+				{out: "ops@web:~$ ", in: "\x1b[A"},
+				{out: "cat /etc/hosts > /tmp/h", in: "\r"},
+			},
+			want: "cat /etc/hosts > /tmp/h",
+		},
+		{
+			name:  "括号粘贴多行",
+			steps: []step{{in: "\x1b[200~echo a\recho b\x1b[201~\r"}},
+			want:  "echo a\necho b",
+		},
+		{
+			name:  "sudo密码不记录",
+			steps: []step{{out: "[sudo] password for ops: ", in: "secret\r"}},
+			skip:  true,
+		},
+		{
+			name:  "全屏程序中的输入不记录",
+			steps: []step{{out: "\x1b[?1049h\x1b[H", in: ":wq\r"}},
+			skip:  true,
+		},
+		{
+			name:  "Ctrl-C放弃当前行",
+			steps: []step{{in: "rm -rf /\x03\r"}},
+			skip:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCommandLine()
+			for i, s := range tt.steps {
+				b.Output([]byte(s.out))
+				_, command, submitted, _ := b.Input([]byte(s.in))
+				if !submitted {
+					continue
+				}
+				if i != len(tt.steps)-1 {
+					t.Fatalf("第%d步提前提交了命令 %q", i+1, command)
+				}
+				if tt.skip && command != "" {
+					t.Fatalf("command = %q, want empty", command)
+				}
+				if !tt.skip && command != tt.want {
+					t.Fatalf("command = %q, want %q", command, tt.want)
+				}
+				return
+			}
+			t.Fatal("没有提交命令")
+		})
+	}
+}
+
+func TestCommandLineSplitsAtEnter(t *testing.T) {
+	b := NewCommandLine()
+	forward, command, submitted, rest := b.Input([]byte("ls\r\npwd\r"))
+	if !submitted || command != "ls" || string(forward) != "ls" || string(rest) != "pwd\r" {
+		t.Fatalf("got forward=%q command=%q submitted=%v rest=%q", forward, command, submitted, rest)
+	}
+	forward, command, submitted, rest = b.Input(rest)
+	if !submitted || command != "pwd" || string(forward) != "pwd" || len(rest) != 0 {
+		t.Fatalf("got forward=%q command=%q submitted=%v rest=%q", forward, command, submitted, rest)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHConnector 主机SSH连接器
+// 统一负责读取并解密主机凭证、解析跳板机和校验主机密钥，并通过连接池复用连接，
+// 供主机管理、任务执行、Nginx日志采集和证书部署共用
+type SSHConnector struct {
+	hostRepo       HostRepo
+	credentialRepo CredentialRepo
+	hostKeys       *HostKeyUseCase
+	gateways       *GatewayUseCase
+	pool           *sshclient.Pool
+	timeout        time.Duration
+}
+
+// NewSSHConnector 创建主机SSH连接器
+func NewSSHConnector(hostRepo HostRepo, credentialRepo CredentialRepo, hostKeys *HostKeyUseCase, gateways *GatewayUseCase, pool *sshclient.Pool) *SSHConnector {
+	return &SSHConnector{
+		hostRepo:       hostRepo,
+		credentialRepo: credentialRepo,
+		hostKeys:       hostKeys,
+		gateways:       gateways,
+		pool:           pool,
+		timeout:        30 * time.Second,
+	}
+}
+
+// HostKeys 获取主机密钥用例
+func (c *SSHConnector) HostKeys() *HostKeyUseCase {
+	return c.hostKeys
+}
+
+// Gateways 获取跳板机用例
+func (c *SSHConnector) Gateways() *GatewayUseCase {
+	return c.gateways
+}
+
+// Pool 获取连接池
+func (c *SSHConnector) Pool() *sshclient.Pool {
+	return c.pool
+}
+
+// SSHUser 登录用户：优先使用主机配置的SSH用户，未配置时使用凭证中的用户名
+func SSHUser(host *Host, credential *Credential) string {
+	if host.SSHUser != "" {
+		return host.SSHUser
+	}
+	return credential.Username
+}
+
+// Credential 读取主机关联的凭证并解密
+func (c *SSHConnector) Credential(ctx context.Context, host *Host) (*Credential, error) {
+	if host.CredentialID == 0 {
+		return nil, fmt.Errorf("主机未配置凭证")
+	}
+	credential, err := c.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
+	if err != nil {
+		return nil, fmt.Errorf("获取凭证失败: %w", err)
+	}
+	return credential, nil
+}
+
+// Endpoint 根据主机和解密后的凭证生成连接端点
+// 密钥凭证只使用私钥认证，密码凭证只使用密码认证
+func (c *SSHConnector) Endpoint(ctx context.Context, host *Host, credential *Credential) (sshclient.Endpoint, error) {
+	var (
+		auth []ssh.AuthMethod
+		err  error
+	)
+	switch credential.Type {
+	case "password":
+		if credential.Password == "" {
+			return sshclient.Endpoint{}, fmt.Errorf("凭证类型为密码认证，但未填写密码")
+		}
+		auth, err = sshclient.AuthMethods(credential.Password, nil, "")
+	case "key", "private_key":
+		if credential.PrivateKey == "" {
+			return sshclient.Endpoint{}, fmt.Errorf("凭证类型为密钥认证，但未填写私钥")
+		}
+		auth, err = sshclient.AuthMethods("", []byte(credential.PrivateKey), credential.Passphrase)
+	default:
+		return sshclient.Endpoint{}, fmt.Errorf("不支持的凭证类型: %s", credential.Type)
+	}
+	if err != nil {
+		return sshclient.Endpoint{}, err
+	}
+	return sshclient.Endpoint{
+		Name:    host.Name,
+		Host:    host.IP,
+		Port:    host.Port,
+		User:    SSHUser(host, credential),
+		Auth:    auth,
+		HostKey: c.hostKeys.Config(ctx, host.ID),
+		Timeout: c.timeout,
+	}, nil
+}
+
+// Dial 不经过连接池直接建立连接，用于交互式终端等长时间独占连接的场景
+// 校验主机密钥，首次连接时记录；配置了跳板机时依次经过跳板机
+func (c *SSHConnector) Dial(ctx context.Context, host *Host) (*ssh.Client, error) {
+	credential, err := c.Credential(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return c.dial(ctx, host, credential)
+}
+
+// DialHost 查询主机并直接建立连接，见 Dial
+func (c *SSHConnector) DialHost(ctx context.Context, hostID uint) (*Host, *ssh.Client, error) {
+	host, err := c.hostRepo.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取主机信息失败: %w", err)
+	}
+	client, err := c.Dial(ctx, host)
+	if err != nil {
+		return host, nil, fmt.Errorf("SSH连接失败: %w", err)
+	}
+	return host, client, nil
+}
+
+func (c *SSHConnector) dial(ctx context.Context, host *Host, credential *Credential) (*ssh.Client, error) {
+	target, err := c.Endpoint(ctx, host, credential)
+	if err != nil {
+		return nil, err
+	}
+	jumps, err := c.gateways.Jumps(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return sshclient.Dial(ctx, target, jumps...)
+}
+
+// Acquire 从连接池借用到主机的连接，使用完毕后调用 Release 归还
+// 池中已有可用连接时直接复用，不再读取和解密凭证
+func (c *SSHConnector) Acquire(ctx context.Context, host *Host) (*sshclient.Lease, error) {
+	if host.CredentialID == 0 {
+		return nil, fmt.Errorf("主机未配置凭证")
+	}
+	credential, err := c.credentialRepo.GetByID(ctx, host.CredentialID)
+	if err != nil {
+		return nil, fmt.Errorf("获取凭证失败: %w", err)
+	}
+	chain, err := c.gateways.Chain(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return c.pool.Acquire(ctx, poolKey(host, credential, chain), func(ctx context.Context) (*ssh.Client, error) {
+		decrypted, err := c.Credential(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		return c.dial(ctx, host, decrypted)
+	})
+}
+
+// Connect 查询主机并从连接池借用连接
+// 即使连接失败，只要主机存在也会返回主机信息，便于调用方填充结果
+func (c *SSHConnector) Connect(ctx context.Context, hostID uint) (*Host, *sshclient.Lease, error) {
+	host, err := c.hostRepo.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取主机信息失败: %w", err)
+	}
+	lease, err := c.Acquire(ctx, host)
+	if err != nil {
+		return host, nil, fmt.Errorf("SSH连接失败: %w", err)
+	}
+	return host, lease, nil
+}
+
+// EvictHost 关闭主机在连接池中的连接，主机变更或删除后调用
+func (c *SSHConnector) EvictHost(hostID uint) {
+	prefix := fmt.Sprintf("host/%d/", hostID)
+	c.pool.Evict(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// poolKey 连接池中区分连接的键
+// 地址、登录用户、凭证内容或跳板链路变化后生成新的键，旧连接空闲超时后关闭
+func poolKey(host *Host, credential *Credential, chain []uint) string {
+	return fmt.Sprintf("host/%d/%s@%s:%d/cred/%d@%d/via/%s",
+		host.ID, SSHUser(host, credential), host.IP, host.Port,
+		credential.ID, credential.UpdatedAt.UnixNano(), EncodeGatewayIDs(chain))
+}
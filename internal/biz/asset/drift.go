@@ -0,0 +1,192 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// 快照来源
+const (
+	SnapshotSourceManual   = "manual"   // 手动创建
+	SnapshotSourceBaseline = "baseline" // 批量设置分组基线时创建
+)
+
+// 漂移检查状态
+const (
+	DriftStatusClean   = "clean"   // 与基线一致
+	DriftStatusDrifted = "drifted" // 与基线不一致
+	DriftStatusFailed  = "failed"  // 采集失败
+)
+
+// 变更类型
+const (
+	DriftChangeAdded   = "added"
+	DriftChangeRemoved = "removed"
+	DriftChangeChanged = "changed"
+)
+
+// 快照比对的类别
+const (
+	DriftCategoryPackage = "package"
+	DriftCategoryService = "service"
+	DriftCategoryPort    = "port"
+	DriftCategoryFile    = "file"
+	DriftCategorySysctl  = "sysctl"
+)
+
+// HostSnapshot 主机配置快照，内容以 JSON 保存
+type HostSnapshot struct {
+	ID            uint             `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	HostID        uint             `gorm:"column:host_id;index;not null;comment:主机ID" json:"hostId"`
+	Name          string           `gorm:"type:varchar(100);not null;comment:快照名称" json:"name"`
+	Source        string           `gorm:"type:varchar(20);comment:来源 manual/baseline" json:"source"`
+	IsBaseline    bool             `gorm:"index;default:false;comment:是否为主机基线" json:"isBaseline"`
+	CreatedBy     uint             `gorm:"comment:创建人ID" json:"createdBy"`
+	CreatedByName string           `gorm:"type:varchar(100);comment:创建人" json:"createdByName"`
+	PackageCount  int              `gorm:"type:int" json:"packageCount"`
+	ServiceCount  int              `gorm:"type:int" json:"serviceCount"`
+	PortCount     int              `gorm:"type:int" json:"portCount"`
+	FileCount     int              `gorm:"type:int" json:"fileCount"`
+	Content       string           `gorm:"type:longtext;comment:快照内容(JSON)" json:"-"`
+	Data          *SnapshotContent `gorm:"-" json:"content,omitempty"`
+}
+
+// TableName 表名
+func (HostSnapshot) TableName() string {
+	return "host_snapshots"
+}
+
+// SnapshotContent 快照内容，均以名称为键便于比对
+type SnapshotContent struct {
+	FilePatterns []string `json:"filePatterns"` // 计算校验和的文件，重新采集时沿用
+	// 以下各类别采集失败时为 nil
+	Packages map[string]string `json:"packages"` // 软件包 name.arch -> 版本
+	Services map[string]string `json:"services"` // 服务 -> 运行状态/启用状态
+	Ports    map[string]string `json:"ports"`    // 协议/地址:端口 -> 进程
+	Files    map[string]string `json:"files"`    // 文件路径 -> SHA256
+	Sysctl   map[string]string `json:"sysctl"`   // 内核参数 -> 值
+}
+
+// DriftChange 一项差异
+type DriftChange struct {
+	Category string `json:"category"` // package/service/port/file/sysctl
+	Key      string `json:"key"`
+	Type     string `json:"type"` // added/removed/changed
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+}
+
+// DriftReport 主机漂移检查结果
+type DriftReport struct {
+	ID          uint          `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time     `gorm:"index" json:"createdAt"`
+	HostID      uint          `gorm:"column:host_id;index;not null;comment:主机ID" json:"hostId"`
+	BaselineID  uint          `gorm:"comment:基线快照ID" json:"baselineId"`
+	Status      string        `gorm:"type:varchar(20);index;comment:状态 clean/drifted/failed" json:"status"`
+	ChangeCount int           `gorm:"type:int;comment:差异项数" json:"changeCount"`
+	Fingerprint string        `gorm:"type:varchar(64);comment:差异内容摘要，用于判断漂移是否变化" json:"-"`
+	Changes     string        `gorm:"type:longtext;comment:差异明细(JSON)" json:"-"`
+	ErrorMsg    string        `gorm:"type:varchar(1000);comment:失败原因" json:"errorMsg,omitempty"`
+	ChangeList  []DriftChange `gorm:"-" json:"changes,omitempty"`
+	HostName    string        `gorm:"-" json:"hostName,omitempty"`
+	HostIP      string        `gorm:"-" json:"hostIp,omitempty"`
+}
+
+// TableName 表名
+func (DriftReport) TableName() string {
+	return "host_drift_reports"
+}
+
+// SnapshotDiff 两个快照的差异
+type SnapshotDiff struct {
+	From    *HostSnapshot `json:"from"`
+	To      *HostSnapshot `json:"to"`
+	Changes []DriftChange `json:"changes"`
+}
+
+// SnapshotRequest 创建快照请求
+type SnapshotRequest struct {
+	Name       string   `json:"name" binding:"required,max=100"`
+	Files      []string `json:"files"`      // 额外计算校验和的文件，支持通配符
+	IsBaseline bool     `json:"isBaseline"` // 同时设为主机基线
+}
+
+// GroupBaselineRequest 为分组内所有主机创建基线请求
+type GroupBaselineRequest struct {
+	Name  string   `json:"name" binding:"max=100"`
+	Files []string `json:"files"`
+}
+
+// GroupBaselineResult 分组基线创建结果
+type GroupBaselineResult struct {
+	Total  int             `json:"total"`
+	Failed map[uint]string `json:"failed,omitempty"` // 主机ID -> 失败原因
+}
+
+// DiffSnapshots 比对两个快照内容，结果按类别、名称排序
+// 任一快照中某类别未采集成功（为 nil）时不比对该类别，避免全部显示为新增或删除
+func DiffSnapshots(from, to *SnapshotContent) []DriftChange {
+	changes := make([]DriftChange, 0)
+	changes = append(changes, diffMap(DriftCategoryPackage, from.Packages, to.Packages)...)
+	changes = append(changes, diffMap(DriftCategoryService, from.Services, to.Services)...)
+	changes = append(changes, diffMap(DriftCategoryPort, from.Ports, to.Ports)...)
+	changes = append(changes, diffMap(DriftCategoryFile, from.Files, to.Files)...)
+	changes = append(changes, diffMap(DriftCategorySysctl, from.Sysctl, to.Sysctl)...)
+	return changes
+}
+
+func diffMap(category string, from, to map[string]string) []DriftChange {
+	if from == nil || to == nil {
+		return nil
+	}
+	var changes []DriftChange
+	for key, old := range from {
+		cur, ok := to[key]
+		switch {
+		case !ok:
+			changes = append(changes, DriftChange{Category: category, Key: key, Type: DriftChangeRemoved, Old: old})
+		case cur != old:
+			changes = append(changes, DriftChange{Category: category, Key: key, Type: DriftChangeChanged, Old: old, New: cur})
+		}
+	}
+	for key, cur := range to {
+		if _, ok := from[key]; !ok {
+			changes = append(changes, DriftChange{Category: category, Key: key, Type: DriftChangeAdded, New: cur})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// driftFingerprint 差异内容摘要，差异相同时摘要相同
+func driftFingerprint(changes []DriftChange) string {
+	if len(changes) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(changes)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	baseline := &SnapshotContent{
+		Packages: map[string]string{"nginx.x86_64": "1.20.1", "openssl.x86_64": "3.0.7"},
+		Services: map[string]string{"nginx": "active/enabled"},
+		Files:    map[string]string{"/etc/nginx/nginx.conf": "aaa", "/etc/hosts": "bbb"},
+		Sysctl:   map[string]string{"net.ipv4.ip_forward": "0"},
+	}
+
+	tests := []struct {
+		name    string
+		current *SnapshotContent
+		want    []DriftChange
+	}{
+		{
+			name:    "无变化",
+			current: baseline,
+			want:    []DriftChange{},
+		},
+		{
+			name: "文件修改与内核参数变化",
+			current: &SnapshotContent{
+				Packages: baseline.Packages,
+				Services: baseline.Services,
+				Files:    map[string]string{"/etc/nginx/nginx.conf": "ccc", "/etc/hosts": "bbb"},
+				Sysctl:   map[string]string{"net.ipv4.ip_forward": "1"},
+			},
+			want: []DriftChange{
+				{Category: DriftCategoryFile, Key: "/etc/nginx/nginx.conf", Type: DriftChangeChanged, Old: "aaa", New: "ccc"},
+				{Category: DriftCategorySysctl, Key: "net.ipv4.ip_forward", Type: DriftChangeChanged, Old: "0", New: "1"},
+			},
+		},
+		{
+			name: "软件包新增和删除按名称排序",
+			current: &SnapshotContent{
+				Packages: map[string]string{"nginx.x86_64": "1.20.1", "curl.x86_64": "7.76.1"},
+				Services: baseline.Services,
+				Files:    baseline.Files,
+				Sysctl:   baseline.Sysctl,
+			},
+			want: []DriftChange{
+				{Category: DriftCategoryPackage, Key: "curl.x86_64", Type: DriftChangeAdded, New: "7.76.1"},
+				{Category: DriftCategoryPackage, Key: "openssl.x86_64", Type: DriftChangeRemoved, Old: "3.0.7"},
+			},
+		},
+		{
+			name: "未采集的类别不比对",
+			current: &SnapshotContent{
+				Packages: baseline.Packages,
+				Files:    baseline.Files,
+			},
+			want: []DriftChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffSnapshots(baseline, tt.current)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSnapshots() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDriftFingerprint(t *testing.T) {
+	a := []DriftChange{{Category: DriftCategoryFile, Key: "/etc/hosts", Type: DriftChangeChanged, Old: "a", New: "b"}}
+	b := []DriftChange{{Category: DriftCategoryFile, Key: "/etc/hosts", Type: DriftChangeChanged, Old: "a", New: "c"}}
+
+	if driftFingerprint(nil) != "" {
+		t.Error("无差异时摘要应为空")
+	}
+	if driftFingerprint(a) != driftFingerprint(a) {
+		t.Error("相同差异的摘要应相同")
+	}
+	if driftFingerprint(a) == driftFingerprint(b) {
+		t.Error("不同差异的摘要应不同")
+	}
+}
@@ -0,0 +1,538 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/pkg/collector"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 漂移检查默认配置
+const (
+	DefaultDriftInterval        = 24 * time.Hour
+	DefaultDriftConcurrency     = 5
+	DefaultDriftReportRetention = 30 * 24 * time.Hour
+)
+
+// ErrNoBaseline 主机未设置基线
+var ErrNoBaseline = errors.New("主机未设置基线快照")
+
+// DriftAlert 配置漂移告警
+type DriftAlert struct {
+	HostID       uint
+	HostName     string
+	HostIP       string
+	BaselineName string
+	Changes      []DriftChange
+	DetectedAt   time.Time
+}
+
+// DriftAlerter 发送配置漂移告警
+type DriftAlerter func(alert DriftAlert)
+
+var (
+	driftAlerterMu sync.RWMutex
+	driftAlerter   DriftAlerter
+)
+
+// SetDriftAlerter 注册配置漂移告警的发送方式，传入 nil 取消注册
+func SetDriftAlerter(fn DriftAlerter) {
+	driftAlerterMu.Lock()
+	defer driftAlerterMu.Unlock()
+	driftAlerter = fn
+}
+
+// notifyDrift 异步发送配置漂移告警，未注册告警方式时只记录日志
+func notifyDrift(alert DriftAlert) {
+	driftAlerterMu.RLock()
+	fn := driftAlerter
+	driftAlerterMu.RUnlock()
+	if fn == nil {
+		logger.Warn("未配置告警通道，配置漂移告警未发送", zap.Uint("hostId", alert.HostID))
+		return
+	}
+	go fn(alert)
+}
+
+// DriftOptions 漂移检查配置，零值字段使用默认值
+type DriftOptions struct {
+	Interval        time.Duration // 定时检查间隔，小于 0 时关闭定时检查
+	Concurrency     int           // 同时检查的主机数
+	ReportRetention time.Duration // 检查结果保留时长
+}
+
+// WithDefaults 补全默认值
+func (o DriftOptions) WithDefaults() DriftOptions {
+	if o.Interval == 0 {
+		o.Interval = DefaultDriftInterval
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultDriftConcurrency
+	}
+	if o.ReportRetention <= 0 {
+		o.ReportRetention = DefaultDriftReportRetention
+	}
+	return o
+}
+
+// DriftUseCase 配置漂移用例
+// 快照记录主机的软件包、服务、监听端口、关键配置文件校验和与内核参数，
+// 主机设置基线后，定时重新采集并与基线比对，出现新的差异时告警
+type DriftUseCase struct {
+	repo        DriftRepo
+	hostRepo    HostRepo
+	groupRepo   AssetGroupRepo
+	hostUseCase *HostUseCase
+	opts        DriftOptions
+	task        periodicTask
+}
+
+func NewDriftUseCase(repo DriftRepo, hostRepo HostRepo, groupRepo AssetGroupRepo, hostUseCase *HostUseCase, opts DriftOptions) *DriftUseCase {
+	return &DriftUseCase{
+		repo:        repo,
+		hostRepo:    hostRepo,
+		groupRepo:   groupRepo,
+		hostUseCase: hostUseCase,
+		opts:        opts.WithDefaults(),
+	}
+}
+
+// Start 启动定时漂移检查，检查所有已设置基线的主机
+func (uc *DriftUseCase) Start() {
+	if uc.opts.Interval < 0 {
+		logger.Info("配置漂移定时检查已关闭")
+		return
+	}
+	uc.task.start(uc.opts.Interval, uc.checkAll)
+	logger.Info("配置漂移定时检查已启动", zap.Duration("interval", uc.opts.Interval))
+}
+
+// Stop 停止定时检查
+func (uc *DriftUseCase) Stop() {
+	uc.task.stop()
+}
+
+func (uc *DriftUseCase) checkAll(ctx context.Context) {
+	hostIDs, err := uc.repo.ListBaselineHostIDs(ctx, nil)
+	if err != nil {
+		logger.Error("获取已设置基线的主机失败", zap.Error(err))
+		return
+	}
+	drifted := uc.checkHosts(ctx, hostIDs)
+	logger.Info("配置漂移检查完成", zap.Int("hosts", len(hostIDs)), zap.Int("drifted", drifted))
+
+	if _, err := uc.repo.DeleteReportsBefore(ctx, time.Now().Add(-uc.opts.ReportRetention)); err != nil {
+		logger.Error("清理漂移检查结果失败", zap.Error(err))
+	}
+}
+
+// checkHosts 并发检查多台主机，返回存在漂移或检查失败的主机数
+func (uc *DriftUseCase) checkHosts(ctx context.Context, hostIDs []uint) int {
+	return forEachHost(ctx, hostIDs, uc.opts.Concurrency, func(ctx context.Context, hostID uint) error {
+		report, err := uc.CheckHost(ctx, hostID)
+		if err != nil {
+			return err
+		}
+		if report.Status != DriftStatusClean {
+			return fmt.Errorf("%s", report.Status)
+		}
+		return nil
+	})
+}
+
+// collectContent 通过SSH采集快照内容，单项失败时该项为 nil，全部失败时返回错误
+func (uc *DriftUseCase) collectContent(ctx context.Context, hostID uint, filePatterns []string) (*SnapshotContent, error) {
+	host, err := uc.hostRepo.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, fmt.Errorf("获取主机信息失败: %w", err)
+	}
+	if host.CredentialID == 0 {
+		return nil, fmt.Errorf("主机未配置凭证")
+	}
+	sshClient, err := uc.hostUseCase.createSSHClient(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("创建SSH连接失败: %w", err)
+	}
+	defer sshClient.Close()
+
+	c := collector.NewCollector(sshClient)
+	content := &SnapshotContent{FilePatterns: filePatterns}
+	var lastErr error
+
+	if _, packages, err := c.CollectPackages(); err == nil {
+		content.Packages = make(map[string]string, len(packages))
+		for _, p := range packages {
+			content.Packages[p.Name+"."+p.Arch] = p.Version
+		}
+	} else {
+		lastErr = err
+	}
+	if services, err := c.CollectServices(); err == nil {
+		content.Services = make(map[string]string, len(services))
+		for _, s := range services {
+			content.Services[s.Name] = s.ActiveState + "/" + s.Enabled
+		}
+	} else {
+		lastErr = err
+	}
+	if ports, err := c.CollectListeningPorts(); err == nil {
+		content.Ports = make(map[string]string, len(ports))
+		for _, p := range ports {
+			content.Ports[fmt.Sprintf("%s/%s:%d", p.Protocol, p.Address, p.Port)] = p.Process
+		}
+	} else {
+		lastErr = err
+	}
+	if files, err := c.CollectFileChecksums(filePatterns); err == nil {
+		content.Files = files
+	} else {
+		lastErr = err
+	}
+	// sysctl 不可用时输出为空，按采集失败处理
+	if sysctl, err := c.CollectSysctl(); err == nil && len(sysctl) > 0 {
+		content.Sysctl = sysctl
+	}
+
+	if content.Packages == nil && content.Services == nil && content.Ports == nil && content.Files == nil {
+		return nil, fmt.Errorf("采集快照失败: %w", lastErr)
+	}
+	return content, nil
+}
+
+// filePatterns 合并默认配置文件和额外指定的文件
+func filePatterns(extra []string) ([]string, error) {
+	patterns := append([]string{}, collector.DefaultConfigFiles...)
+	seen := make(map[string]bool, len(patterns))
+	for _, p := range patterns {
+		seen[p] = true
+	}
+	for _, p := range extra {
+		if err := collector.ValidateFilePattern(p); err != nil {
+			return nil, err
+		}
+		if !seen[p] {
+			seen[p] = true
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns, nil
+}
+
+// CreateSnapshot 采集并保存主机快照
+func (uc *DriftUseCase) CreateSnapshot(ctx context.Context, hostID uint, req *SnapshotRequest, source string, userID uint, username string) (*HostSnapshot, error) {
+	patterns, err := filePatterns(req.Files)
+	if err != nil {
+		return nil, err
+	}
+	content, err := uc.collectContent(ctx, hostID, patterns)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &HostSnapshot{
+		HostID:        hostID,
+		Name:          req.Name,
+		Source:        source,
+		CreatedBy:     userID,
+		CreatedByName: username,
+		PackageCount:  len(content.Packages),
+		ServiceCount:  len(content.Services),
+		PortCount:     len(content.Ports),
+		FileCount:     len(content.Files),
+		Content:       string(data),
+	}
+	if err := uc.repo.CreateSnapshot(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	if req.IsBaseline {
+		if err := uc.repo.SetBaseline(ctx, hostID, snapshot.ID); err != nil {
+			return nil, err
+		}
+		snapshot.IsBaseline = true
+	}
+	return snapshot, nil
+}
+
+// ListSnapshots 获取主机的快照列表
+func (uc *DriftUseCase) ListSnapshots(ctx context.Context, hostID uint) ([]*HostSnapshot, error) {
+	return uc.repo.ListSnapshots(ctx, hostID)
+}
+
+// GetSnapshot 获取主机快照及内容
+func (uc *DriftUseCase) GetSnapshot(ctx context.Context, hostID, id uint) (*HostSnapshot, error) {
+	snapshot, err := uc.repo.GetSnapshot(ctx, id)
+	if err != nil || snapshot.HostID != hostID {
+		return nil, fmt.Errorf("快照不存在")
+	}
+	if err := snapshot.decode(); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// DeleteSnapshot 删除主机快照
+func (uc *DriftUseCase) DeleteSnapshot(ctx context.Context, hostID, id uint) error {
+	snapshot, err := uc.repo.GetSnapshot(ctx, id)
+	if err != nil || snapshot.HostID != hostID {
+		return fmt.Errorf("快照不存在")
+	}
+	return uc.repo.DeleteSnapshot(ctx, id)
+}
+
+// SetBaseline 将快照设为主机基线
+func (uc *DriftUseCase) SetBaseline(ctx context.Context, hostID, id uint) error {
+	snapshot, err := uc.repo.GetSnapshot(ctx, id)
+	if err != nil || snapshot.HostID != hostID {
+		return fmt.Errorf("快照不存在")
+	}
+	return uc.repo.SetBaseline(ctx, hostID, id)
+}
+
+// Diff 比对同一主机的两个快照，toID 为 0 时与主机当前状态比对
+func (uc *DriftUseCase) Diff(ctx context.Context, hostID, fromID, toID uint) (*SnapshotDiff, error) {
+	from, err := uc.GetSnapshot(ctx, hostID, fromID)
+	if err != nil {
+		return nil, err
+	}
+
+	var to *HostSnapshot
+	if toID > 0 {
+		if to, err = uc.GetSnapshot(ctx, hostID, toID); err != nil {
+			return nil, err
+		}
+	} else {
+		content, err := uc.collectContent(ctx, hostID, from.Data.FilePatterns)
+		if err != nil {
+			return nil, err
+		}
+		to = &HostSnapshot{HostID: hostID, Name: "当前状态", CreatedAt: time.Now(), Data: content}
+	}
+
+	changes := DiffSnapshots(from.Data, to.Data)
+	// 列表只需快照概要
+	from.Data, to.Data = nil, nil
+	return &SnapshotDiff{From: from, To: to, Changes: changes}, nil
+}
+
+// CheckHost 采集主机当前状态并与基线比对，保存检查结果；差异与上次检查不同时告警
+func (uc *DriftUseCase) CheckHost(ctx context.Context, hostID uint) (*DriftReport, error) {
+	baseline, err := uc.repo.GetBaseline(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	if baseline == nil {
+		return nil, ErrNoBaseline
+	}
+	if err := baseline.decode(); err != nil {
+		return nil, err
+	}
+
+	report := &DriftReport{HostID: hostID, BaselineID: baseline.ID}
+	content, err := uc.collectContent(ctx, hostID, baseline.Data.FilePatterns)
+	if err != nil {
+		report.Status = DriftStatusFailed
+		report.ErrorMsg = truncate(err.Error(), 1000)
+	} else {
+		report.ChangeList = DiffSnapshots(baseline.Data, content)
+		report.ChangeCount = len(report.ChangeList)
+		report.Fingerprint = driftFingerprint(report.ChangeList)
+		report.Status = DriftStatusClean
+		if report.ChangeCount > 0 {
+			report.Status = DriftStatusDrifted
+		}
+		data, _ := json.Marshal(report.ChangeList)
+		report.Changes = string(data)
+	}
+
+	var previous *DriftReport
+	if latest, err := uc.repo.LatestReports(ctx, []uint{hostID}); err == nil && len(latest) > 0 {
+		previous = latest[0]
+	}
+	if err := uc.repo.CreateReport(ctx, report); err != nil {
+		return nil, err
+	}
+
+	if report.Status == DriftStatusDrifted && (previous == nil || previous.Fingerprint != report.Fingerprint) {
+		host, _ := uc.hostRepo.GetByID(ctx, hostID)
+		alert := DriftAlert{
+			HostID:       hostID,
+			BaselineName: baseline.Name,
+			Changes:      report.ChangeList,
+			DetectedAt:   report.CreatedAt,
+		}
+		if host != nil {
+			alert.HostName, alert.HostIP = host.Name, host.IP
+		}
+		logger.Warn("检测到主机配置漂移", zap.Uint("hostId", hostID), zap.Int("changes", report.ChangeCount))
+		notifyDrift(alert)
+	}
+	return report, nil
+}
+
+// ListReports 分页获取主机的漂移检查记录
+func (uc *DriftUseCase) ListReports(ctx context.Context, hostID uint, page, pageSize int) ([]*DriftReport, int64, error) {
+	reports, total, err := uc.repo.ListReports(ctx, hostID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, r := range reports {
+		r.decode()
+	}
+	return reports, total, nil
+}
+
+// groupHostIDs 获取分组（含子分组）内的主机，accessibleHostIDs 不为 nil 时只保留有权限的主机
+func (uc *DriftUseCase) groupHostIDs(ctx context.Context, groupID uint, accessibleHostIDs []uint) ([]uint, error) {
+	if _, err := uc.groupRepo.GetByID(ctx, groupID); err != nil {
+		return nil, fmt.Errorf("分组不存在")
+	}
+	groupIDs := []uint{groupID}
+	if descendantIDs, err := uc.groupRepo.GetDescendantIDs(ctx, groupID); err == nil {
+		groupIDs = append(groupIDs, descendantIDs...)
+	}
+	hostIDs, err := uc.hostRepo.ListIDs(ctx, groupIDs, false)
+	if err != nil || accessibleHostIDs == nil {
+		return hostIDs, err
+	}
+	allowed := make(map[uint]bool, len(accessibleHostIDs))
+	for _, id := range accessibleHostIDs {
+		allowed[id] = true
+	}
+	filtered := make([]uint, 0, len(hostIDs))
+	for _, id := range hostIDs {
+		if allowed[id] {
+			filtered = append(filtered, id)
+		}
+	}
+	return filtered, nil
+}
+
+// BaselineGroup 为分组内所有已配置凭证的主机创建快照并设为基线
+func (uc *DriftUseCase) BaselineGroup(ctx context.Context, groupID uint, req *GroupBaselineRequest, userID uint, username string) (*GroupBaselineResult, error) {
+	groupHosts, err := uc.groupHostIDs(ctx, groupID, nil)
+	if err != nil {
+		return nil, err
+	}
+	withCredential, err := uc.hostRepo.ListIDs(ctx, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	hostIDs := intersectIDs(groupHosts, withCredential)
+
+	name := req.Name
+	if name == "" {
+		name = "基线 " + time.Now().Format("2006-01-02 15:04")
+	}
+	snapshotReq := &SnapshotRequest{Name: name, Files: req.Files, IsBaseline: true}
+	if _, err := filePatterns(req.Files); err != nil {
+		return nil, err
+	}
+
+	result := &GroupBaselineResult{Total: len(hostIDs), Failed: make(map[uint]string)}
+	var mu sync.Mutex
+	forEachHost(ctx, hostIDs, uc.opts.Concurrency, func(ctx context.Context, hostID uint) error {
+		_, err := uc.CreateSnapshot(ctx, hostID, snapshotReq, SnapshotSourceBaseline, userID, username)
+		if err != nil {
+			mu.Lock()
+			result.Failed[hostID] = err.Error()
+			mu.Unlock()
+		}
+		return err
+	})
+	return result, nil
+}
+
+// CheckGroup 在后台检查分组内所有已设置基线的主机，返回待检查的主机数
+func (uc *DriftUseCase) CheckGroup(ctx context.Context, groupID uint) (int, error) {
+	groupHosts, err := uc.groupHostIDs(ctx, groupID, nil)
+	if err != nil {
+		return 0, err
+	}
+	hostIDs, err := uc.repo.ListBaselineHostIDs(ctx, groupHosts)
+	if err != nil {
+		return 0, err
+	}
+	go uc.checkHosts(context.Background(), hostIDs)
+	return len(hostIDs), nil
+}
+
+// GroupReports 获取分组内各主机最近一次漂移检查结果
+func (uc *DriftUseCase) GroupReports(ctx context.Context, groupID uint, accessibleHostIDs []uint) ([]*DriftReport, error) {
+	hostIDs, err := uc.groupHostIDs(ctx, groupID, accessibleHostIDs)
+	if err != nil {
+		return nil, err
+	}
+	reports, err := uc.repo.LatestReports(ctx, hostIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reports {
+		r.decode()
+		if host, err := uc.hostRepo.GetByID(ctx, r.HostID); err == nil {
+			r.HostName, r.HostIP = host.Name, host.IP
+		}
+	}
+	return reports, nil
+}
+
+func intersectIDs(a, b []uint) []uint {
+	set := make(map[uint]bool, len(b))
+	for _, id := range b {
+		set[id] = true
+	}
+	result := make([]uint, 0, len(a))
+	for _, id := range a {
+		if set[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// decode 解析快照内容
+func (s *HostSnapshot) decode() error {
+	if s.Data != nil {
+		return nil
+	}
+	s.Data = &SnapshotContent{}
+	if err := json.Unmarshal([]byte(s.Content), s.Data); err != nil {
+		return fmt.Errorf("解析快照内容失败: %w", err)
+	}
+	return nil
+}
+
+// decode 解析差异明细
+func (r *DriftReport) decode() {
+	if r.Changes != "" && r.ChangeList == nil {
+		_ = json.Unmarshal([]byte(r.Changes), &r.ChangeList)
+	}
+}
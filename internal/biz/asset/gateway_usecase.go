@@ -0,0 +1,190 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+)
+
+// maxGroupDepth 向上查找分组跳板机时的最大层级，防止父子关系成环时死循环
+const maxGroupDepth = 32
+
+// EncodeGatewayIDs 将跳板机ID列表编码为存储格式，空列表编码为 "[]"
+func EncodeGatewayIDs(ids []uint) string {
+	if len(ids) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(ids)
+	return string(data)
+}
+
+// DecodeGatewayIDs 解析存储的跳板机ID列表，格式错误时视为未配置
+func DecodeGatewayIDs(s string) []uint {
+	ids := []uint{}
+	if s != "" {
+		_ = json.Unmarshal([]byte(s), &ids)
+	}
+	return ids
+}
+
+// GatewayUseCase 跳板机链路解析
+// 主机配置了跳板机时使用主机自身的配置，否则使用最近一级配置了跳板机的上级分组
+type GatewayUseCase struct {
+	hostRepo       HostRepo
+	groupRepo      AssetGroupRepo
+	credentialRepo CredentialRepo
+	hostKeys       *HostKeyUseCase
+}
+
+// NewGatewayUseCase 创建跳板机用例
+func NewGatewayUseCase(hostRepo HostRepo, groupRepo AssetGroupRepo, credentialRepo CredentialRepo, hostKeys *HostKeyUseCase) *GatewayUseCase {
+	return &GatewayUseCase{
+		hostRepo:       hostRepo,
+		groupRepo:      groupRepo,
+		credentialRepo: credentialRepo,
+		hostKeys:       hostKeys,
+	}
+}
+
+// Chain 返回连接主机需要依次经过的跳板机ID
+// 跳板机本身位于继承的链路中时只保留它之前的部分，避免经过自己连接自己
+func (uc *GatewayUseCase) Chain(ctx context.Context, host *Host) ([]uint, error) {
+	ids := DecodeGatewayIDs(host.GatewayIDs)
+	if len(ids) == 0 {
+		groupID := host.GroupID
+		for depth := 0; groupID > 0 && depth < maxGroupDepth; depth++ {
+			group, err := uc.groupRepo.GetByID(ctx, groupID)
+			if err != nil {
+				return nil, fmt.Errorf("获取主机分组失败: %w", err)
+			}
+			if ids = DecodeGatewayIDs(group.GatewayIDs); len(ids) > 0 {
+				break
+			}
+			groupID = group.ParentID
+		}
+	}
+	for i, id := range ids {
+		if id == host.ID {
+			return ids[:i], nil
+		}
+	}
+	return ids, nil
+}
+
+// Jumps 返回连接主机需要经过的跳板机端点，未配置跳板机时返回空
+// 跳板机使用各自的SSH用户、凭证和主机密钥校验
+func (uc *GatewayUseCase) Jumps(ctx context.Context, host *Host) ([]sshclient.Endpoint, error) {
+	ids, err := uc.Chain(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	jumps := make([]sshclient.Endpoint, 0, len(ids))
+	for _, id := range ids {
+		gateway, credential, err := uc.load(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		var privateKey []byte
+		if credential.Type == "key" {
+			privateKey = []byte(credential.PrivateKey)
+		}
+		endpoint, err := sshclient.NewEndpoint(gateway.IP, gateway.Port, gateway.SSHUser,
+			credential.Password, privateKey, credential.Passphrase, uc.hostKeys.Config(ctx, gateway.ID))
+		if err != nil {
+			return nil, fmt.Errorf("跳板机 %s 凭证无效: %w", gateway.Name, err)
+		}
+		endpoint.Name = gateway.Name
+		jumps = append(jumps, endpoint)
+	}
+	return jumps, nil
+}
+
+// JumpsByHostID 根据主机ID返回连接主机需要经过的跳板机端点
+func (uc *GatewayUseCase) JumpsByHostID(ctx context.Context, hostID uint) ([]sshclient.Endpoint, error) {
+	host, err := uc.hostRepo.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, fmt.Errorf("获取主机信息失败: %w", err)
+	}
+	return uc.Jumps(ctx, host)
+}
+
+// Gateways 返回连接主机需要经过的跳板机及其解密后的凭证，顺序与连接顺序一致
+func (uc *GatewayUseCase) Gateways(ctx context.Context, host *Host) ([]*Host, []*Credential, error) {
+	ids, err := uc.Chain(ctx, host)
+	if err != nil {
+		return nil, nil, err
+	}
+	hosts := make([]*Host, 0, len(ids))
+	credentials := make([]*Credential, 0, len(ids))
+	for _, id := range ids {
+		gateway, credential, err := uc.load(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		hosts = append(hosts, gateway)
+		credentials = append(credentials, credential)
+	}
+	return hosts, credentials, nil
+}
+
+// Validate 校验跳板机配置，selfID 为配置跳板机的主机ID，分组配置时传0
+func (uc *GatewayUseCase) Validate(ctx context.Context, selfID uint, ids []uint) error {
+	if len(ids) > sshclient.MaxJumpHops {
+		return fmt.Errorf("跳板机不能超过 %d 跳", sshclient.MaxJumpHops)
+	}
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if selfID > 0 && id == selfID {
+			return fmt.Errorf("主机不能作为自己的跳板机")
+		}
+		if seen[id] {
+			return fmt.Errorf("跳板机 %d 重复", id)
+		}
+		seen[id] = true
+		gateway, err := uc.hostRepo.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("跳板机 %d 不存在", id)
+		}
+		if gateway.CredentialID == 0 {
+			return fmt.Errorf("跳板机 %s 未配置凭证", gateway.Name)
+		}
+	}
+	return nil
+}
+
+// load 读取跳板机主机及其解密后的凭证
+func (uc *GatewayUseCase) load(ctx context.Context, id uint) (*Host, *Credential, error) {
+	gateway, err := uc.hostRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("跳板机 %d 不存在", id)
+	}
+	if gateway.CredentialID == 0 {
+		return nil, nil, fmt.Errorf("跳板机 %s 未配置凭证", gateway.Name)
+	}
+	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, gateway.CredentialID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取跳板机 %s 凭证失败: %w", gateway.Name, err)
+	}
+	return gateway, credential, nil
+}
@@ -22,12 +22,14 @@ package asset
 import (
 	"time"
 
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	"gorm.io/gorm"
 )
 
 // Host 主机模型
 type Host struct {
 	gorm.Model
+	ProjectID        uint          `gorm:"column:project_id;<-:create;default:1;index;comment:所属项目ID" json:"projectId"`
 	Name             string        `gorm:"type:varchar(100);not null;comment:主机名称" json:"name"`
 	GroupID          uint          `gorm:"column:group_id;comment:分组ID" json:"groupId"`
 	Group            *AssetGroup   `gorm:"-" json:"group,omitempty"`
@@ -40,6 +42,7 @@ type Host struct {
 	Port             int           `gorm:"type:int;default:22;comment:SSH端口" json:"port"`
 	CredentialID     uint          `gorm:"column:credential_id;comment:凭证ID" json:"credentialId"`
 	Credential       *Credential   `gorm:"-" json:"credential,omitempty"`
+	GatewayIDs       string        `gorm:"column:gateway_ids;type:varchar(500);comment:跳板机主机ID(JSON数组，按连接顺序)" json:"-"`
 	Tags             string        `gorm:"type:varchar(500);comment:主机标签(逗号分隔)" json:"tags"`
 	Description      string        `gorm:"type:varchar(500);comment:备注" json:"description"`
 	Status           int           `gorm:"type:tinyint;default:1;comment:状态 1:在线 0:离线 -1:未知" json:"status"`
@@ -74,6 +77,7 @@ type HostRequest struct {
 	IP            string `json:"ip" binding:"required,ip"`
 	Port          int    `json:"port" binding:"required,min=1,max=65535"`
 	CredentialID  uint   `json:"credentialId"`
+	GatewayIDs    []uint `json:"gatewayIds"` // 跳板机主机ID，按连接顺序，为空时使用所属分组的跳板机
 	Tags          string `json:"tags"`
 	Description   string `json:"description"`
 }
@@ -94,6 +98,7 @@ type HostInfoVO struct {
 	Port             int            `json:"port"`
 	CredentialID     uint           `json:"credentialId"`
 	Credential       *CredentialVO  `json:"credential,omitempty"`
+	GatewayIDs       []uint         `json:"gatewayIds"`
 	Tags             []string       `json:"tags"`
 	Description      string         `json:"description"`
 	Status           int            `json:"status"`
@@ -141,6 +146,7 @@ func (req *HostRequest) ToModel() *Host {
 		IP:              req.IP,
 		Port:            req.Port,
 		CredentialID:    req.CredentialID,
+		GatewayIDs:      EncodeGatewayIDs(req.GatewayIDs),
 		Tags:            req.Tags,
 		Description:     req.Description,
 		Status:          -1, // 初始状态未知
@@ -153,12 +159,13 @@ type Credential struct {
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+	ProjectID   uint   `gorm:"column:project_id;<-:create;default:1;index;comment:所属项目ID" json:"projectId"`
 	Name        string `gorm:"type:varchar(100);not null;comment:凭证名称" json:"name"`
 	Type        string `gorm:"type:varchar(20);not null;comment:认证方式 password/key" json:"type"`
 	Username    string `gorm:"type:varchar(100);comment:用户名" json:"username"`
-	Password    string `gorm:"type:varchar(500);comment:密码(加密)" json:"password,omitempty"`
+	Password    string `gorm:"type:varchar(1000);comment:密码(加密)" json:"password,omitempty"`
 	PrivateKey  string `gorm:"type:text;comment:私钥(加密)" json:"privateKey,omitempty"`
-	Passphrase  string `gorm:"type:varchar(500);comment:私钥密码(加密)" json:"passphrase,omitempty"`
+	Passphrase  string `gorm:"type:varchar(1000);comment:私钥密码(加密)" json:"passphrase,omitempty"`
 	Description string `gorm:"type:varchar(500);comment:备注" json:"description"`
 }
 
@@ -202,10 +209,11 @@ func (req *CredentialRequest) ToModel() *Credential {
 // CloudAccount 云平台账号模型
 type CloudAccount struct {
 	gorm.Model
+	ProjectID   uint   `gorm:"column:project_id;<-:create;default:1;index;comment:所属项目ID" json:"projectId"`
 	Name        string `gorm:"type:varchar(100);not null;comment:账号名称" json:"name"`
 	Provider    string `gorm:"type:varchar(50);not null;comment:云厂商 aliyun/tencent/aws/huawei" json:"provider"`
-	AccessKey   string `gorm:"type:varchar(200);not null;comment:AccessKey" json:"-"`
-	SecretKey   string `gorm:"type:varchar(500);not null;comment:SecretKey" json:"-"`
+	AccessKey   crypto.Secret `gorm:"type:varchar(500);not null;comment:AccessKey(加密)" json:"-"`
+	SecretKey   crypto.Secret `gorm:"type:varchar(500);not null;comment:SecretKey(加密)" json:"-"`
 	Region      string `gorm:"type:varchar(100);comment:默认区域" json:"region"`
 	Description string `gorm:"type:varchar(500);comment:备注" json:"description"`
 	Status      int    `gorm:"type:tinyint;default:1;comment:状态 1:启用 0:禁用" json:"status"`
@@ -240,8 +248,8 @@ func (req *CloudAccountRequest) ToModel() *CloudAccount {
 	return &CloudAccount{
 		Name:        req.Name,
 		Provider:    req.Provider,
-		AccessKey:   req.AccessKey,
-		SecretKey:   req.SecretKey,
+		AccessKey:   crypto.Secret(req.AccessKey),
+		SecretKey:   crypto.Secret(req.SecretKey),
 		Region:      req.Region,
 		Description: req.Description,
 		Status:      req.Status,
@@ -0,0 +1,83 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"time"
+)
+
+// 主机密钥状态
+const (
+	HostKeyStatusTrusted  = "trusted"  // 已信任
+	HostKeyStatusMismatch = "mismatch" // 检测到与已信任密钥不一致的新密钥，等待管理员确认
+)
+
+// 主机密钥来源
+const (
+	HostKeySourceTOFU    = "tofu"    // 首次连接时自动记录
+	HostKeySourceManual  = "manual"  // 管理员预置
+	HostKeySourceRotated = "rotated" // 管理员确认的轮换密钥
+)
+
+// HostKey 主机SSH公钥（known_hosts），每台主机一条记录
+type HostKey struct {
+	ID                 uint       `gorm:"primarykey" json:"id"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	UpdatedAt          time.Time  `json:"updatedAt"`
+	HostID             uint       `gorm:"column:host_id;uniqueIndex;not null;comment:主机ID" json:"hostId"`
+	KeyType            string     `gorm:"type:varchar(50);comment:密钥类型" json:"keyType"`
+	PublicKey          string     `gorm:"type:text;comment:公钥(authorized_keys格式)" json:"publicKey"`
+	Fingerprint        string     `gorm:"type:varchar(100);comment:SHA256指纹" json:"fingerprint"`
+	Source             string     `gorm:"type:varchar(20);comment:来源 tofu/manual/rotated" json:"source"`
+	Status             string     `gorm:"type:varchar(20);index;default:'trusted';comment:状态 trusted/mismatch" json:"status"`
+	PendingKeyType     string     `gorm:"type:varchar(50);comment:待确认的密钥类型" json:"pendingKeyType,omitempty"`
+	PendingPublicKey   string     `gorm:"type:text;comment:待确认的公钥" json:"pendingPublicKey,omitempty"`
+	PendingFingerprint string     `gorm:"type:varchar(100);comment:待确认的公钥指纹" json:"pendingFingerprint,omitempty"`
+	PendingSeenAt      *time.Time `gorm:"comment:最近一次检测到密钥不一致的时间" json:"pendingSeenAt,omitempty"`
+	MismatchCount      int        `gorm:"type:int;default:0;comment:检测到密钥不一致的次数" json:"mismatchCount"`
+	TrustedBy          uint       `gorm:"comment:信任操作人ID，自动记录时为0" json:"trustedBy"`
+	TrustedByName      string     `gorm:"type:varchar(100);comment:信任操作人" json:"trustedByName"`
+	TrustedAt          time.Time  `gorm:"comment:信任时间" json:"trustedAt"`
+}
+
+// TableName 表名
+func (HostKey) TableName() string {
+	return "host_keys"
+}
+
+// HostKeyInfo 主机密钥VO
+type HostKeyInfo struct {
+	*HostKey
+	HostName string `json:"hostName"`
+	HostIP   string `json:"hostIp"`
+}
+
+// HostKeyRequest 预置主机公钥请求
+type HostKeyRequest struct {
+	// PublicKey 支持 authorized_keys 格式（ssh-ed25519 AAAA...）或 known_hosts 行（host ssh-ed25519 AAAA...），
+	// 可通过 ssh-keyscan 获取
+	PublicKey string `json:"publicKey" binding:"required"`
+}
+
+// AcceptHostKeyRequest 确认轮换密钥请求
+type AcceptHostKeyRequest struct {
+	// Fingerprint 待确认密钥的指纹，需与当前记录一致，防止确认期间密钥再次变化
+	Fingerprint string `json:"fingerprint" binding:"required"`
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	// ErrHostKeyMismatch 主机密钥与已信任的密钥不一致
+	ErrHostKeyMismatch = errors.New("主机密钥与已记录的不一致，可能存在中间人攻击，已拒绝连接，请管理员核实后确认新密钥")
+	// ErrHostKeyNoPending 没有待确认的主机密钥
+	ErrHostKeyNoPending = errors.New("没有待确认的主机密钥")
+	// ErrHostKeyFingerprintChanged 待确认的密钥已变化
+	ErrHostKeyFingerprintChanged = errors.New("待确认的密钥指纹已变化，请刷新后重新核实")
+)
+
+// HostKeyAlert 主机密钥变更告警
+type HostKeyAlert struct {
+	HostID     uint
+	HostName   string
+	HostIP     string
+	Trusted    string // 已信任密钥指纹
+	Presented  string // 本次连接出现的密钥指纹
+	DetectedAt time.Time
+}
+
+// HostKeyAlerter 发送主机密钥变更告警
+type HostKeyAlerter func(alert HostKeyAlert)
+
+var (
+	alerterMu sync.RWMutex
+	alerter   HostKeyAlerter
+)
+
+// SetHostKeyAlerter 注册主机密钥变更告警的发送方式，传入 nil 取消注册
+// 由提供通知通道的插件（如监控中心）在启用时注册
+func SetHostKeyAlerter(fn HostKeyAlerter) {
+	alerterMu.Lock()
+	defer alerterMu.Unlock()
+	alerter = fn
+}
+
+// notifyHostKeyMismatch 异步发送主机密钥变更告警，未注册告警方式时只记录日志
+func notifyHostKeyMismatch(alert HostKeyAlert) {
+	alerterMu.RLock()
+	fn := alerter
+	alerterMu.RUnlock()
+	if fn == nil {
+		logger.Warn("未配置告警通道，主机密钥变更告警未发送", zap.Uint("hostId", alert.HostID))
+		return
+	}
+	go fn(alert)
+}
+
+// HostKeyUseCase 主机密钥用例
+// 首次连接主机时记录服务端公钥（TOFU），之后每次连接都与记录比对，不一致时拒绝连接并告警，
+// 管理员核实后可确认新密钥，也可以预先导入公钥
+type HostKeyUseCase struct {
+	repo     HostKeyRepo
+	hostRepo HostRepo
+}
+
+// NewHostKeyUseCase 创建主机密钥用例
+func NewHostKeyUseCase(repo HostKeyRepo, hostRepo HostRepo) *HostKeyUseCase {
+	return &HostKeyUseCase{
+		repo:     repo,
+		hostRepo: hostRepo,
+	}
+}
+
+// Config 返回连接指定主机时使用的主机密钥校验配置
+func (uc *HostKeyUseCase) Config(ctx context.Context, hostID uint) sshclient.HostKeyConfig {
+	config := sshclient.HostKeyConfig{
+		Callback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return uc.Verify(ctx, hostID, key)
+		},
+	}
+	// 已记录密钥时只协商同类型的密钥；读取失败时交由 Verify 处理
+	if known, err := uc.repo.GetByHostID(ctx, hostID); err == nil && known != nil {
+		config.Algorithms = sshclient.HostKeyAlgorithms(known.KeyType)
+	}
+	return config
+}
+
+// Verify 校验主机公钥，主机尚无记录时信任并记录该公钥
+func (uc *HostKeyUseCase) Verify(ctx context.Context, hostID uint, key ssh.PublicKey) error {
+	ctx = context.WithoutCancel(ctx)
+	known, err := uc.repo.GetByHostID(ctx, hostID)
+	if err != nil {
+		return fmt.Errorf("读取主机密钥失败: %w", err)
+	}
+
+	if known == nil {
+		created, err := uc.repo.CreateIfAbsent(ctx, &HostKey{
+			HostID:      hostID,
+			KeyType:     key.Type(),
+			PublicKey:   marshalPublicKey(key),
+			Fingerprint: ssh.FingerprintSHA256(key),
+			Source:      HostKeySourceTOFU,
+			Status:      HostKeyStatusTrusted,
+			TrustedAt:   time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("记录主机密钥失败: %w", err)
+		}
+		if created {
+			logger.Info("首次连接，已记录主机密钥",
+				zap.Uint("hostId", hostID),
+				zap.String("fingerprint", ssh.FingerprintSHA256(key)))
+			return nil
+		}
+		// 并发连接时其他连接已先记录，按已记录的密钥校验
+		if known, err = uc.repo.GetByHostID(ctx, hostID); err != nil || known == nil {
+			return fmt.Errorf("读取主机密钥失败: %v", err)
+		}
+	}
+
+	trusted, err := parsePublicKey(known.PublicKey)
+	if err != nil {
+		return fmt.Errorf("已记录的主机密钥无效: %w", err)
+	}
+	if bytes.Equal(trusted.Marshal(), key.Marshal()) {
+		return nil
+	}
+
+	fingerprint := ssh.FingerprintSHA256(key)
+	logger.Warn("主机密钥不一致，已拒绝连接",
+		zap.Uint("hostId", hostID),
+		zap.String("trusted", known.Fingerprint),
+		zap.String("presented", fingerprint))
+	// 同一新密钥只在首次出现时告警，避免重复连接刷屏
+	first, err := uc.repo.RecordMismatch(ctx, hostID, key.Type(), marshalPublicKey(key), fingerprint)
+	if err != nil {
+		logger.Error("记录主机密钥变更失败", zap.Uint("hostId", hostID), zap.Error(err))
+	}
+	if first {
+		alert := HostKeyAlert{
+			HostID:     hostID,
+			Trusted:    known.Fingerprint,
+			Presented:  fingerprint,
+			DetectedAt: time.Now(),
+		}
+		if host, err := uc.hostRepo.GetByID(ctx, hostID); err == nil && host != nil {
+			alert.HostName = host.Name
+			alert.HostIP = host.IP
+		}
+		notifyHostKeyMismatch(alert)
+	}
+	return fmt.Errorf("%w（已记录 %s，当前 %s）", ErrHostKeyMismatch, known.Fingerprint, fingerprint)
+}
+
+// Get 获取主机密钥，未记录时返回 nil
+func (uc *HostKeyUseCase) Get(ctx context.Context, hostID uint) (*HostKey, error) {
+	return uc.repo.GetByHostID(ctx, hostID)
+}
+
+// List 分页获取主机密钥列表
+func (uc *HostKeyUseCase) List(ctx context.Context, page, pageSize int, keyword, status string) ([]*HostKeyInfo, int64, error) {
+	return uc.repo.List(ctx, page, pageSize, keyword, status)
+}
+
+// Pin 预置或替换主机公钥
+func (uc *HostKeyUseCase) Pin(ctx context.Context, hostID uint, publicKey string, userID uint, username string) (*HostKey, error) {
+	if _, err := uc.hostRepo.GetByID(ctx, hostID); err != nil {
+		return nil, fmt.Errorf("主机不存在")
+	}
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("公钥格式错误: %w", err)
+	}
+	hostKey := &HostKey{
+		HostID:        hostID,
+		KeyType:       key.Type(),
+		PublicKey:     marshalPublicKey(key),
+		Fingerprint:   ssh.FingerprintSHA256(key),
+		Source:        HostKeySourceManual,
+		Status:        HostKeyStatusTrusted,
+		TrustedBy:     userID,
+		TrustedByName: username,
+		TrustedAt:     time.Now(),
+	}
+	if err := uc.repo.Save(ctx, hostKey); err != nil {
+		return nil, err
+	}
+	return hostKey, nil
+}
+
+// Accept 确认检测到的新密钥，之后连接以新密钥为准
+func (uc *HostKeyUseCase) Accept(ctx context.Context, hostID uint, fingerprint string, userID uint, username string) (*HostKey, error) {
+	known, err := uc.repo.GetByHostID(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	if known == nil || known.Status != HostKeyStatusMismatch || known.PendingPublicKey == "" {
+		return nil, ErrHostKeyNoPending
+	}
+	if known.PendingFingerprint != fingerprint {
+		return nil, ErrHostKeyFingerprintChanged
+	}
+	hostKey := &HostKey{
+		HostID:        hostID,
+		KeyType:       known.PendingKeyType,
+		PublicKey:     known.PendingPublicKey,
+		Fingerprint:   known.PendingFingerprint,
+		Source:        HostKeySourceRotated,
+		Status:        HostKeyStatusTrusted,
+		TrustedBy:     userID,
+		TrustedByName: username,
+		TrustedAt:     time.Now(),
+	}
+	if err := uc.repo.Save(ctx, hostKey); err != nil {
+		return nil, err
+	}
+	return hostKey, nil
+}
+
+// Forget 删除主机密钥记录，下次连接时重新记录
+func (uc *HostKeyUseCase) Forget(ctx context.Context, hostID uint) error {
+	return uc.repo.Delete(ctx, hostID)
+}
+
+// parsePublicKey 解析 authorized_keys 格式或 known_hosts 行格式的公钥
+func parsePublicKey(text string) (ssh.PublicKey, error) {
+	data := []byte(strings.TrimSpace(text))
+	if key, _, _, _, err := ssh.ParseAuthorizedKey(data); err == nil {
+		return key, nil
+	}
+	_, _, key, _, _, err := ssh.ParseKnownHosts(data)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// marshalPublicKey 以 authorized_keys 格式输出公钥
+func marshalPublicKey(key ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
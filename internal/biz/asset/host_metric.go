@@ -0,0 +1,244 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"sort"
+	"time"
+)
+
+// 主机指标精度
+const (
+	MetricResolutionRaw = "raw" // 原始采集数据
+	MetricResolution5m  = "5m"  // 5分钟聚合
+	MetricResolution1h  = "1h"  // 1小时聚合
+)
+
+// HostMetric 主机指标时序数据，原始数据每次采集一条，聚合数据每个时间桶一条
+type HostMetric struct {
+	ID             uint64    `gorm:"primarykey" json:"-"`
+	HostID         uint      `gorm:"column:host_id;not null;uniqueIndex:uk_host_metric,priority:1;comment:主机ID" json:"hostId"`
+	Resolution     string    `gorm:"type:varchar(8);not null;uniqueIndex:uk_host_metric,priority:2;index:idx_host_metric_res_ts,priority:1;comment:精度 raw/5m/1h" json:"resolution"`
+	Timestamp      time.Time `gorm:"column:ts;not null;uniqueIndex:uk_host_metric,priority:3;index:idx_host_metric_res_ts,priority:2;comment:采集时间，聚合数据为时间桶起点" json:"timestamp"`
+	CPUUsage       float64   `gorm:"type:float;comment:CPU使用率(平均)" json:"cpuUsage"`
+	CPUUsageMax    float64   `gorm:"type:float;comment:CPU使用率(最大)" json:"cpuUsageMax"`
+	MemoryUsage    float64   `gorm:"type:float;comment:内存使用率(平均)" json:"memoryUsage"`
+	MemoryUsageMax float64   `gorm:"type:float;comment:内存使用率(最大)" json:"memoryUsageMax"`
+	MemoryUsed     uint64    `gorm:"type:bigint;comment:已用内存(字节)，聚合数据取时间桶内最后一次" json:"memoryUsed"`
+	MemoryTotal    uint64    `gorm:"type:bigint;comment:内存总容量(字节)" json:"memoryTotal"`
+	DiskUsage      float64   `gorm:"type:float;comment:磁盘使用率(平均)" json:"diskUsage"`
+	DiskUsageMax   float64   `gorm:"type:float;comment:磁盘使用率(最大)" json:"diskUsageMax"`
+	DiskUsed       uint64    `gorm:"type:bigint;comment:已用磁盘(字节)，聚合数据取时间桶内最后一次" json:"diskUsed"`
+	DiskTotal      uint64    `gorm:"type:bigint;comment:磁盘总容量(字节)" json:"diskTotal"`
+	Samples        int       `gorm:"type:int;default:1;comment:聚合的原始采样数" json:"samples"`
+}
+
+// TableName 表名
+func (HostMetric) TableName() string {
+	return "host_metrics"
+}
+
+// NewRawMetric 根据主机最近一次采集结果生成原始指标
+func NewRawMetric(host *Host, ts time.Time) *HostMetric {
+	return &HostMetric{
+		HostID:         host.ID,
+		Resolution:     MetricResolutionRaw,
+		Timestamp:      ts.Truncate(time.Second),
+		CPUUsage:       host.CPUUsage,
+		CPUUsageMax:    host.CPUUsage,
+		MemoryUsage:    host.MemoryUsage,
+		MemoryUsageMax: host.MemoryUsage,
+		MemoryUsed:     host.MemoryUsed,
+		MemoryTotal:    host.MemoryTotal,
+		DiskUsage:      host.DiskUsage,
+		DiskUsageMax:   host.DiskUsage,
+		DiskUsed:       host.DiskUsed,
+		DiskTotal:      host.DiskTotal,
+		Samples:        1,
+	}
+}
+
+// ResolutionStep 聚合精度的时间桶长度，原始数据返回 0
+func ResolutionStep(resolution string) time.Duration {
+	switch resolution {
+	case MetricResolution5m:
+		return 5 * time.Minute
+	case MetricResolution1h:
+		return time.Hour
+	}
+	return 0
+}
+
+// ValidResolution 是否为支持的指标精度
+func ValidResolution(resolution string) bool {
+	return resolution == MetricResolutionRaw || ResolutionStep(resolution) > 0
+}
+
+// Downsample 将指标按主机和时间桶聚合为指定精度
+// 平均值按采样数加权，最大值取各点最大值，容量类指标取时间桶内最后一个点，
+// 因此既可以由原始数据聚合，也可以由较细的聚合数据再次聚合
+func Downsample(points []*HostMetric, resolution string) []*HostMetric {
+	step := ResolutionStep(resolution)
+	if step == 0 {
+		return nil
+	}
+
+	type bucketKey struct {
+		hostID uint
+		ts     int64
+	}
+	type bucket struct {
+		out    *HostMetric
+		last   time.Time
+		weight float64
+		cpu    float64
+		mem    float64
+		disk   float64
+	}
+
+	buckets := make(map[bucketKey]*bucket)
+	for _, p := range points {
+		ts := p.Timestamp.Truncate(step)
+		key := bucketKey{hostID: p.HostID, ts: ts.Unix()}
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{out: &HostMetric{HostID: p.HostID, Resolution: resolution, Timestamp: ts}}
+			buckets[key] = b
+		}
+
+		samples := p.Samples
+		if samples <= 0 {
+			samples = 1
+		}
+		w := float64(samples)
+		b.weight += w
+		b.cpu += p.CPUUsage * w
+		b.mem += p.MemoryUsage * w
+		b.disk += p.DiskUsage * w
+		b.out.Samples += samples
+		b.out.CPUUsageMax = maxFloat(b.out.CPUUsageMax, p.CPUUsageMax, p.CPUUsage)
+		b.out.MemoryUsageMax = maxFloat(b.out.MemoryUsageMax, p.MemoryUsageMax, p.MemoryUsage)
+		b.out.DiskUsageMax = maxFloat(b.out.DiskUsageMax, p.DiskUsageMax, p.DiskUsage)
+		if !p.Timestamp.Before(b.last) {
+			b.last = p.Timestamp
+			b.out.MemoryUsed = p.MemoryUsed
+			b.out.MemoryTotal = p.MemoryTotal
+			b.out.DiskUsed = p.DiskUsed
+			b.out.DiskTotal = p.DiskTotal
+		}
+	}
+
+	result := make([]*HostMetric, 0, len(buckets))
+	for _, b := range buckets {
+		b.out.CPUUsage = b.cpu / b.weight
+		b.out.MemoryUsage = b.mem / b.weight
+		b.out.DiskUsage = b.disk / b.weight
+		result = append(result, b.out)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].HostID != result[j].HostID {
+			return result[i].HostID < result[j].HostID
+		}
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result
+}
+
+// GroupMetricPoint 分组指标趋势中的一个时间点，汇总分组内所有主机
+type GroupMetricPoint struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Hosts          int       `json:"hosts"`          // 该时间点有数据的主机数
+	CPUUsage       float64   `json:"cpuUsage"`       // 各主机平均CPU使用率的平均值
+	CPUUsageMax    float64   `json:"cpuUsageMax"`    // 单台主机的最大CPU使用率
+	MemoryUsage    float64   `json:"memoryUsage"`    // 已用内存之和 / 内存总容量之和
+	MemoryUsageMax float64   `json:"memoryUsageMax"` // 单台主机的最大内存使用率
+	MemoryUsed     uint64    `json:"memoryUsed"`
+	MemoryTotal    uint64    `json:"memoryTotal"`
+	DiskUsage      float64   `json:"diskUsage"`    // 已用磁盘之和 / 磁盘总容量之和
+	DiskUsageMax   float64   `json:"diskUsageMax"` // 单台主机的最大磁盘使用率
+	DiskUsed       uint64    `json:"diskUsed"`
+	DiskTotal      uint64    `json:"diskTotal"`
+}
+
+// AggregateGroup 将多台主机同一精度的指标按时间点汇总
+func AggregateGroup(points []*HostMetric) []*GroupMetricPoint {
+	byTime := make(map[int64]*GroupMetricPoint)
+	for _, p := range points {
+		g, ok := byTime[p.Timestamp.Unix()]
+		if !ok {
+			g = &GroupMetricPoint{Timestamp: p.Timestamp}
+			byTime[p.Timestamp.Unix()] = g
+		}
+		g.Hosts++
+		g.CPUUsage += p.CPUUsage
+		g.CPUUsageMax = maxFloat(g.CPUUsageMax, p.CPUUsageMax, p.CPUUsage)
+		g.MemoryUsageMax = maxFloat(g.MemoryUsageMax, p.MemoryUsageMax, p.MemoryUsage)
+		g.DiskUsageMax = maxFloat(g.DiskUsageMax, p.DiskUsageMax, p.DiskUsage)
+		g.MemoryUsed += p.MemoryUsed
+		g.MemoryTotal += p.MemoryTotal
+		g.DiskUsed += p.DiskUsed
+		g.DiskTotal += p.DiskTotal
+	}
+
+	result := make([]*GroupMetricPoint, 0, len(byTime))
+	for _, g := range byTime {
+		g.CPUUsage /= float64(g.Hosts)
+		if g.MemoryTotal > 0 {
+			g.MemoryUsage = float64(g.MemoryUsed) / float64(g.MemoryTotal) * 100
+		}
+		if g.DiskTotal > 0 {
+			g.DiskUsage = float64(g.DiskUsed) / float64(g.DiskTotal) * 100
+		}
+		result = append(result, g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result
+}
+
+// HostMetricTrend 主机指标趋势
+type HostMetricTrend struct {
+	HostID     uint          `json:"hostId"`
+	Resolution string        `json:"resolution"`
+	StartTime  time.Time     `json:"startTime"`
+	EndTime    time.Time     `json:"endTime"`
+	Points     []*HostMetric `json:"points"`
+}
+
+// GroupMetricTrend 分组指标趋势
+type GroupMetricTrend struct {
+	GroupID    uint                `json:"groupId"`
+	Resolution string              `json:"resolution"`
+	StartTime  time.Time           `json:"startTime"`
+	EndTime    time.Time           `json:"endTime"`
+	HostCount  int                 `json:"hostCount"` // 分组内（含子分组）有权限的主机数
+	Points     []*GroupMetricPoint `json:"points"`
+}
+
+func maxFloat(values ...float64) float64 {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
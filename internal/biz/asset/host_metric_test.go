@@ -0,0 +1,131 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func rawPoint(hostID uint, ts time.Time, cpu, disk float64, diskUsed uint64) *HostMetric {
+	return &HostMetric{
+		HostID: hostID, Resolution: MetricResolutionRaw, Timestamp: ts,
+		CPUUsage: cpu, CPUUsageMax: cpu, DiskUsage: disk, DiskUsageMax: disk,
+		DiskUsed: diskUsed, DiskTotal: 100, Samples: 1,
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("原始数据聚合为5分钟", func(t *testing.T) {
+		points := []*HostMetric{
+			rawPoint(1, base.Add(4*time.Minute), 30, 50, 50),
+			rawPoint(1, base.Add(1*time.Minute), 10, 40, 40),
+			rawPoint(1, base.Add(6*time.Minute), 90, 60, 60),
+			rawPoint(2, base.Add(2*time.Minute), 5, 10, 10),
+		}
+		got := Downsample(points, MetricResolution5m)
+		if len(got) != 3 {
+			t.Fatalf("len = %d, want 3", len(got))
+		}
+		first := got[0]
+		if first.HostID != 1 || !first.Timestamp.Equal(base) || first.Resolution != MetricResolution5m {
+			t.Fatalf("first bucket = %+v", first)
+		}
+		if first.CPUUsage != 20 || first.CPUUsageMax != 30 || first.Samples != 2 {
+			t.Errorf("cpu avg/max/samples = %v/%v/%d, want 20/30/2", first.CPUUsage, first.CPUUsageMax, first.Samples)
+		}
+		if first.DiskUsed != 50 {
+			t.Errorf("diskUsed = %d, want 50 (last point)", first.DiskUsed)
+		}
+		if !got[1].Timestamp.Equal(base.Add(5*time.Minute)) || got[2].HostID != 2 {
+			t.Errorf("unexpected order: %+v %+v", got[1], got[2])
+		}
+	})
+
+	t.Run("聚合数据按采样数加权再次聚合", func(t *testing.T) {
+		points := []*HostMetric{
+			{HostID: 1, Timestamp: base, CPUUsage: 10, CPUUsageMax: 50, Samples: 3},
+			{HostID: 1, Timestamp: base.Add(5 * time.Minute), CPUUsage: 50, CPUUsageMax: 80, Samples: 1},
+		}
+		got := Downsample(points, MetricResolution1h)
+		if len(got) != 1 {
+			t.Fatalf("len = %d, want 1", len(got))
+		}
+		if got[0].CPUUsage != 20 || got[0].CPUUsageMax != 80 || got[0].Samples != 4 {
+			t.Errorf("got avg/max/samples = %v/%v/%d, want 20/80/4", got[0].CPUUsage, got[0].CPUUsageMax, got[0].Samples)
+		}
+	})
+
+	t.Run("原始精度不聚合", func(t *testing.T) {
+		if got := Downsample([]*HostMetric{rawPoint(1, base, 1, 1, 1)}, MetricResolutionRaw); got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
+
+func TestAggregateGroup(t *testing.T) {
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	points := []*HostMetric{
+		{HostID: 1, Timestamp: base, CPUUsage: 10, CPUUsageMax: 20, DiskUsage: 90, DiskUsageMax: 90, DiskUsed: 90, DiskTotal: 100},
+		{HostID: 2, Timestamp: base, CPUUsage: 30, CPUUsageMax: 40, DiskUsage: 10, DiskUsageMax: 10, DiskUsed: 30, DiskTotal: 300},
+		{HostID: 1, Timestamp: base.Add(time.Hour), CPUUsage: 50, CPUUsageMax: 50},
+	}
+	got := AggregateGroup(points)
+	if len(got) != 2 {
+		t.Fatalf("len = %d, want 2", len(got))
+	}
+	g := got[0]
+	if g.Hosts != 2 || g.CPUUsage != 20 || g.CPUUsageMax != 40 || g.DiskUsageMax != 90 {
+		t.Errorf("got %+v", g)
+	}
+	if math.Abs(g.DiskUsage-30) > 1e-9 || g.DiskUsed != 120 || g.DiskTotal != 400 {
+		t.Errorf("disk usage = %v used = %d total = %d, want 30/120/400", g.DiskUsage, g.DiskUsed, g.DiskTotal)
+	}
+	if got[1].Hosts != 1 {
+		t.Errorf("second point hosts = %d, want 1", got[1].Hosts)
+	}
+}
+
+func TestChooseResolution(t *testing.T) {
+	opts := MetricsOptions{}.WithDefaults()
+	now := time.Date(2026, 1, 30, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		want  string
+	}{
+		{"最近1小时", now.Add(-time.Hour), now, MetricResolutionRaw},
+		{"最近1天", now.Add(-24 * time.Hour), now, MetricResolution5m},
+		{"超出原始数据保留时长的短区间", now.Add(-72 * time.Hour), now.Add(-70 * time.Hour), MetricResolution5m},
+		{"最近30天", now.Add(-30 * 24 * time.Hour), now, MetricResolution1h},
+		{"超出5分钟数据保留时长的短区间", now.Add(-20 * 24 * time.Hour), now.Add(-19 * 24 * time.Hour), MetricResolution1h},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := opts.ChooseResolution(tt.start, tt.end, now); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 主机指标默认配置
+const (
+	DefaultMetricsInterval            = 5 * time.Minute
+	DefaultMetricsConcurrency         = 10
+	DefaultMetricsRawRetention        = 48 * time.Hour
+	DefaultMetricsFiveMinuteRetention = 14 * 24 * time.Hour
+	DefaultMetricsHourlyRetention     = 180 * 24 * time.Hour
+)
+
+// metricRollups 聚合步骤，每次维护时重新聚合 lookback 内已结束的时间桶，
+// 写入是覆盖式的，重复执行或中途停机都不会产生重复数据
+var metricRollups = []struct {
+	from, to string
+	lookback time.Duration
+}{
+	{MetricResolutionRaw, MetricResolution5m, time.Hour},
+	{MetricResolution5m, MetricResolution1h, 6 * time.Hour},
+}
+
+// MetricsOptions 主机指标采集与保留配置，零值字段使用默认值
+type MetricsOptions struct {
+	Interval            time.Duration // 定时采集间隔，小于 0 时关闭定时采集
+	Concurrency         int           // 同时采集的主机数
+	RawRetention        time.Duration // 原始数据保留时长
+	FiveMinuteRetention time.Duration // 5分钟聚合数据保留时长
+	HourlyRetention     time.Duration // 1小时聚合数据保留时长
+}
+
+// WithDefaults 补全默认值，保留时长不短于对应的聚合回溯窗口
+func (o MetricsOptions) WithDefaults() MetricsOptions {
+	if o.Interval == 0 {
+		o.Interval = DefaultMetricsInterval
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultMetricsConcurrency
+	}
+	if o.RawRetention <= 0 {
+		o.RawRetention = DefaultMetricsRawRetention
+	}
+	if o.FiveMinuteRetention <= 0 {
+		o.FiveMinuteRetention = DefaultMetricsFiveMinuteRetention
+	}
+	if o.HourlyRetention <= 0 {
+		o.HourlyRetention = DefaultMetricsHourlyRetention
+	}
+	if o.RawRetention < 2*time.Hour {
+		o.RawRetention = 2 * time.Hour
+	}
+	if o.FiveMinuteRetention < 12*time.Hour {
+		o.FiveMinuteRetention = 12 * time.Hour
+	}
+	return o
+}
+
+// Retention 指定精度的保留时长
+func (o MetricsOptions) Retention(resolution string) time.Duration {
+	switch resolution {
+	case MetricResolution5m:
+		return o.FiveMinuteRetention
+	case MetricResolution1h:
+		return o.HourlyRetention
+	}
+	return o.RawRetention
+}
+
+// ChooseResolution 根据查询范围选择精度：6小时内用原始数据，7天内用5分钟聚合，其余用1小时聚合；
+// 起始时间超出某一精度的保留时长时改用更粗的精度
+func (o MetricsOptions) ChooseResolution(start, end, now time.Time) string {
+	span := end.Sub(start)
+	age := now.Sub(start)
+	switch {
+	case span <= 6*time.Hour && age <= o.RawRetention:
+		return MetricResolutionRaw
+	case span <= 7*24*time.Hour && age <= o.FiveMinuteRetention:
+		return MetricResolution5m
+	}
+	return MetricResolution1h
+}
+
+// HostMetricUseCase 主机指标用例，负责定时采集、降采样、过期清理和趋势查询
+type HostMetricUseCase struct {
+	metricRepo  HostMetricRepo
+	hostRepo    HostRepo
+	groupRepo   AssetGroupRepo
+	hostUseCase *HostUseCase
+	opts        MetricsOptions
+	task        periodicTask
+}
+
+func NewHostMetricUseCase(metricRepo HostMetricRepo, hostRepo HostRepo, groupRepo AssetGroupRepo, hostUseCase *HostUseCase, opts MetricsOptions) *HostMetricUseCase {
+	return &HostMetricUseCase{
+		metricRepo:  metricRepo,
+		hostRepo:    hostRepo,
+		groupRepo:   groupRepo,
+		hostUseCase: hostUseCase,
+		opts:        opts.WithDefaults(),
+	}
+}
+
+// Start 启动后台定时采集，每轮采集所有已配置凭证的主机，然后降采样并清理过期数据
+func (uc *HostMetricUseCase) Start() {
+	if uc.opts.Interval < 0 {
+		logger.Info("主机指标定时采集已关闭")
+		return
+	}
+	uc.task.start(uc.opts.Interval, uc.collect)
+	logger.Info("主机指标定时采集已启动",
+		zap.Duration("interval", uc.opts.Interval),
+		zap.Int("concurrency", uc.opts.Concurrency))
+}
+
+// Stop 停止后台采集，等待进行中的一轮结束
+func (uc *HostMetricUseCase) Stop() {
+	uc.task.stop()
+}
+
+// collect 执行一轮采集和维护
+func (uc *HostMetricUseCase) collect(ctx context.Context) {
+	start := time.Now()
+	hostIDs, err := uc.hostRepo.ListIDs(ctx, nil, true)
+	if err != nil {
+		logger.Error("获取待采集主机失败", zap.Error(err))
+		return
+	}
+	failed := uc.hostUseCase.collectHosts(ctx, hostIDs, uc.opts.Concurrency)
+	if ctx.Err() != nil {
+		return
+	}
+	logger.Info("主机指标采集完成",
+		zap.Int("hosts", len(hostIDs)),
+		zap.Int("failed", failed),
+		zap.Duration("elapsed", time.Since(start)))
+
+	if err := uc.Maintain(ctx, time.Now()); err != nil {
+		logger.Error("主机指标维护失败", zap.Error(err))
+	}
+}
+
+// Maintain 将已结束时间桶的指标聚合为更粗的精度，并删除超出保留时长的数据
+func (uc *HostMetricUseCase) Maintain(ctx context.Context, now time.Time) error {
+	for _, r := range metricRollups {
+		end := now.Truncate(ResolutionStep(r.to))
+		points, err := uc.metricRepo.List(ctx, nil, r.from, end.Add(-r.lookback), end)
+		if err != nil {
+			return fmt.Errorf("读取%s指标失败: %w", r.from, err)
+		}
+		if err := uc.metricRepo.Save(ctx, Downsample(points, r.to)); err != nil {
+			return fmt.Errorf("写入%s聚合指标失败: %w", r.to, err)
+		}
+	}
+
+	for _, resolution := range []string{MetricResolutionRaw, MetricResolution5m, MetricResolution1h} {
+		deleted, err := uc.metricRepo.DeleteBefore(ctx, resolution, now.Add(-uc.opts.Retention(resolution)))
+		if err != nil {
+			return fmt.Errorf("清理%s指标失败: %w", resolution, err)
+		}
+		if deleted > 0 {
+			logger.Info("已清理过期主机指标", zap.String("resolution", resolution), zap.Int64("count", deleted))
+		}
+	}
+	return nil
+}
+
+// HostTrend 查询主机指标趋势，resolution 为空时按时间范围自动选择
+func (uc *HostMetricUseCase) HostTrend(ctx context.Context, hostID uint, start, end time.Time, resolution string) (*HostMetricTrend, error) {
+	resolution, err := uc.resolve(start, end, resolution)
+	if err != nil {
+		return nil, err
+	}
+	points, err := uc.metricRepo.List(ctx, []uint{hostID}, resolution, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &HostMetricTrend{
+		HostID:     hostID,
+		Resolution: resolution,
+		StartTime:  start,
+		EndTime:    end,
+		Points:     points,
+	}, nil
+}
+
+// GroupTrend 查询分组（含子分组）主机的汇总指标趋势
+// accessibleHostIDs 为 nil 时不做权限筛选；各主机原始数据的采集时间不对齐，分组趋势最细使用5分钟聚合
+func (uc *HostMetricUseCase) GroupTrend(ctx context.Context, groupID uint, accessibleHostIDs []uint, start, end time.Time, resolution string) (*GroupMetricTrend, error) {
+	if _, err := uc.groupRepo.GetByID(ctx, groupID); err != nil {
+		return nil, fmt.Errorf("分组不存在")
+	}
+	resolution, err := uc.resolve(start, end, resolution)
+	if err != nil {
+		return nil, err
+	}
+	if resolution == MetricResolutionRaw {
+		resolution = MetricResolution5m
+	}
+
+	groupIDs := []uint{groupID}
+	if descendantIDs, err := uc.groupRepo.GetDescendantIDs(ctx, groupID); err == nil {
+		groupIDs = append(groupIDs, descendantIDs...)
+	}
+	hostIDs, err := uc.hostRepo.ListIDs(ctx, groupIDs, false)
+	if err != nil {
+		return nil, err
+	}
+	if accessibleHostIDs != nil {
+		allowed := make(map[uint]bool, len(accessibleHostIDs))
+		for _, id := range accessibleHostIDs {
+			allowed[id] = true
+		}
+		filtered := make([]uint, 0, len(hostIDs))
+		for _, id := range hostIDs {
+			if allowed[id] {
+				filtered = append(filtered, id)
+			}
+		}
+		hostIDs = filtered
+	}
+
+	points, err := uc.metricRepo.List(ctx, hostIDs, resolution, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &GroupMetricTrend{
+		GroupID:    groupID,
+		Resolution: resolution,
+		StartTime:  start,
+		EndTime:    end,
+		HostCount:  len(hostIDs),
+		Points:     AggregateGroup(points),
+	}, nil
+}
+
+func (uc *HostMetricUseCase) resolve(start, end time.Time, resolution string) (string, error) {
+	if !end.After(start) {
+		return "", fmt.Errorf("结束时间必须晚于开始时间")
+	}
+	if resolution == "" {
+		return uc.opts.ChooseResolution(start, end, time.Now()), nil
+	}
+	if !ValidResolution(resolution) {
+		return "", fmt.Errorf("不支持的精度: %s", resolution)
+	}
+	return resolution, nil
+}
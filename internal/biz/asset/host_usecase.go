@@ -38,8 +38,11 @@ import (
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
 	"github.com/xuri/excelize/v2"
 	"github.com/ydcloud-dy/opshub/pkg/collector"
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
 	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/pkg/utils"
+	"go.uber.org/zap"
 )
 
 type HostUseCase struct {
@@ -47,19 +50,26 @@ type HostUseCase struct {
 	credentialRepo CredentialRepo
 	groupRepo      AssetGroupRepo
 	cloudRepo      CloudAccountRepo
+	metricRepo     HostMetricRepo
+	connector      *SSHConnector
 }
 
-func NewHostUseCase(hostRepo HostRepo, credentialRepo CredentialRepo, groupRepo AssetGroupRepo, cloudRepo CloudAccountRepo) *HostUseCase {
+func NewHostUseCase(hostRepo HostRepo, credentialRepo CredentialRepo, groupRepo AssetGroupRepo, cloudRepo CloudAccountRepo, metricRepo HostMetricRepo, connector *SSHConnector) *HostUseCase {
 	return &HostUseCase{
 		hostRepo:       hostRepo,
 		credentialRepo: credentialRepo,
 		groupRepo:      groupRepo,
 		cloudRepo:      cloudRepo,
+		metricRepo:     metricRepo,
+		connector:      connector,
 	}
 }
 
 // Create 创建主机
 func (uc *HostUseCase) Create(ctx context.Context, req *HostRequest) (*Host, error) {
+	if err := uc.connector.Gateways().Validate(ctx, 0, req.GatewayIDs); err != nil {
+		return nil, err
+	}
 	host := req.ToModel()
 
 	if err := uc.hostRepo.CreateOrUpdate(ctx, host); err != nil {
@@ -82,6 +92,10 @@ func (uc *HostUseCase) Update(ctx context.Context, req *HostRequest) error {
 		return fmt.Errorf("IP地址 %s 已被其他主机使用", req.IP)
 	}
 
+	if err := uc.connector.Gateways().Validate(ctx, req.ID, req.GatewayIDs); err != nil {
+		return err
+	}
+
 	host.Name = req.Name
 	host.GroupID = req.GroupID
 	host.Type = req.Type
@@ -92,15 +106,25 @@ func (uc *HostUseCase) Update(ctx context.Context, req *HostRequest) error {
 	host.IP = req.IP
 	host.Port = req.Port
 	host.CredentialID = req.CredentialID
+	host.GatewayIDs = EncodeGatewayIDs(req.GatewayIDs)
 	host.Tags = req.Tags
 	host.Description = req.Description
 
-	return uc.hostRepo.Update(ctx, host)
+	if err := uc.hostRepo.Update(ctx, host); err != nil {
+		return err
+	}
+	// 连接参数可能已变更，关闭连接池中的旧连接
+	uc.connector.EvictHost(host.ID)
+	return nil
 }
 
 // Delete 删除主机
 func (uc *HostUseCase) Delete(ctx context.Context, id uint) error {
-	return uc.hostRepo.Delete(ctx, id)
+	if err := uc.hostRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	uc.connector.EvictHost(id)
+	return nil
 }
 
 // GetByID 根据ID获取主机详情
@@ -228,6 +252,7 @@ func (uc *HostUseCase) toInfoVO(host *Host) *HostInfoVO {
 		IP:                host.IP,
 		Port:              host.Port,
 		CredentialID:      host.CredentialID,
+		GatewayIDs:        DecodeGatewayIDs(host.GatewayIDs),
 		Tags:              tags,
 		Description:       host.Description,
 		Status:            host.Status,
@@ -264,14 +289,8 @@ func (uc *HostUseCase) CollectHostInfo(ctx context.Context, hostID uint) error {
 		return fmt.Errorf("主机未配置凭证")
 	}
 
-	// 获取凭证（解密后的）
-	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
-	if err != nil {
-		return fmt.Errorf("获取凭证失败: %w", err)
-	}
-
 	// 创建SSH客户端
-	sshClient, err := uc.createSSHClient(host, credential)
+	sshClient, err := uc.createSSHClient(ctx, host)
 	if err != nil {
 		// 连接失败，更新主机状态为离线
 		host.Status = 0
@@ -324,43 +343,24 @@ func (uc *HostUseCase) CollectHostInfo(ctx context.Context, hostID uint) error {
 		host.CPUInfo = cpuJSON
 	}
 
-	return uc.hostRepo.Update(ctx, host)
-}
-
-// createSSHClient 创建SSH客户端
-func (uc *HostUseCase) createSSHClient(host *Host, credential *Credential) (*sshclient.Client, error) {
-	var privateKey []byte
-
-	// 检查凭证信息是否完整
-	if credential.Type == "password" && credential.Password == "" {
-		return nil, fmt.Errorf("凭证类型为密码认证，但未填写密码")
-	}
-	if credential.Type == "key" && credential.PrivateKey == "" {
-		return nil, fmt.Errorf("凭证类型为密钥认证，但未填写私钥")
+	if err := uc.hostRepo.Update(ctx, host); err != nil {
+		return err
 	}
 
-	// 如果是密钥认证，需要解密私钥
-	if credential.Type == "key" && credential.PrivateKey != "" {
-		// 这里私钥是从数据库读取的加密数据，需要先解密
-		// 但在credentialRepo中已经返回了解密后的数据
-		// 实际上我们需要修改CredentialRepo的GetByID方法来返回解密后的数据
-		// 或者在这里解密
-		privateKey = []byte(credential.PrivateKey)
+	// 记录指标历史，写入失败不影响本次采集结果
+	if err := uc.metricRepo.Save(ctx, []*HostMetric{NewRawMetric(host, now)}); err != nil {
+		logger.Warn("写入主机指标失败", zap.Uint("hostId", host.ID), zap.Error(err))
 	}
+	return nil
+}
 
-	client, err := sshclient.NewClient(
-		host.IP,
-		host.Port,
-		host.SSHUser,
-		credential.Password,
-		privateKey,
-		credential.Passphrase,
-	)
+// createSSHClient 从连接池借用SSH连接，客户端 Close 时归还
+func (uc *HostUseCase) createSSHClient(ctx context.Context, host *Host) (*sshclient.Client, error) {
+	lease, err := uc.connector.Acquire(ctx, host)
 	if err != nil {
 		return nil, fmt.Errorf("创建SSH客户端失败: %w", err)
 	}
-
-	return client, nil
+	return sshclient.NewLeaseClient(lease), nil
 }
 
 func min(a, b int) int {
@@ -389,14 +389,8 @@ func (uc *HostUseCase) TestConnection(ctx context.Context, hostID uint) error {
 		return fmt.Errorf("主机未配置凭证")
 	}
 
-	// 获取凭证（解密后的）
-	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
-	if err != nil {
-		return fmt.Errorf("获取凭证失败: %w", err)
-	}
-
 	// 创建SSH客户端
-	sshClient, err := uc.createSSHClient(host, credential)
+	sshClient, err := uc.createSSHClient(ctx, host)
 	if err != nil {
 		return fmt.Errorf("创建SSH连接失败: %w", err)
 	}
@@ -410,13 +404,21 @@ func (uc *HostUseCase) TestConnection(ctx context.Context, hostID uint) error {
 	return nil
 }
 
-// BatchCollectHostInfo 批量采集主机信息
-func (uc *HostUseCase) BatchCollectHostInfo(ctx context.Context, hostIDs []uint) error {
-	for _, hostID := range hostIDs {
+// BatchCollectHostInfo 批量采集主机信息，返回采集失败的主机数
+func (uc *HostUseCase) BatchCollectHostInfo(ctx context.Context, hostIDs []uint) (int, error) {
+	return uc.collectHosts(ctx, hostIDs, DefaultMetricsConcurrency), nil
+}
+
+// collectHosts 并发采集多台主机，同时进行的采集不超过 concurrency，返回采集失败的主机数
+func (uc *HostUseCase) collectHosts(ctx context.Context, hostIDs []uint, concurrency int) int {
+	return forEachHost(ctx, hostIDs, concurrency, func(ctx context.Context, hostID uint) error {
 		// 采集失败时继续处理其他主机
-		_ = uc.CollectHostInfo(ctx, hostID)
-	}
-	return nil
+		err := uc.CollectHostInfo(ctx, hostID)
+		if err != nil {
+			logger.Debug("采集主机信息失败", zap.Uint("hostId", hostID), zap.Error(err))
+		}
+		return err
+	})
 }
 
 // BatchDelete 批量删除主机
@@ -425,6 +427,7 @@ func (uc *HostUseCase) BatchDelete(ctx context.Context, hostIDs []uint) error {
 		if err := uc.hostRepo.Delete(ctx, hostID); err != nil {
 			return fmt.Errorf("删除主机 %d 失败: %w", hostID, err)
 		}
+		uc.connector.EvictHost(hostID)
 	}
 	return nil
 }
@@ -447,6 +450,16 @@ func (uc *HostUseCase) toCredentialVO(credential *Credential) *CredentialVO {
 	}
 }
 
+// HostKeys 获取主机密钥用例（用于终端功能）
+func (uc *HostUseCase) HostKeys() *HostKeyUseCase {
+	return uc.connector.HostKeys()
+}
+
+// Connector 获取主机SSH连接器
+func (uc *HostUseCase) Connector() *SSHConnector {
+	return uc.connector
+}
+
 // GetCredentialRepo 获取凭证Repo（用于终端功能）
 func (uc *HostUseCase) GetCredentialRepo() CredentialRepo {
 	return uc.credentialRepo
@@ -627,10 +640,10 @@ func (uc *CloudAccountUseCase) Update(ctx context.Context, req *CloudAccountRequ
 	account.Provider = req.Provider
 	// 只有提供了新的值才更新 AccessKey 和 SecretKey
 	if req.AccessKey != "" {
-		account.AccessKey = req.AccessKey
+		account.AccessKey = crypto.Secret(req.AccessKey)
 	}
 	if req.SecretKey != "" {
-		account.SecretKey = req.SecretKey
+		account.SecretKey = crypto.Secret(req.SecretKey)
 	}
 	account.Region = req.Region
 	account.Description = req.Description
@@ -915,8 +928,8 @@ func (uc *CloudAccountUseCase) listAliyunRegions(account *CloudAccount) ([]Cloud
 	// 使用杭州区域创建客户端（DescribeRegions API 可以使用任意区域）
 	client, err := ecs.NewClientWithAccessKey(
 		"cn-hangzhou",
-		account.AccessKey,
-		account.SecretKey,
+		string(account.AccessKey),
+		string(account.SecretKey),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("创建阿里云客户端失败: %w", err)
@@ -950,7 +963,7 @@ func (uc *CloudAccountUseCase) listAliyunRegions(account *CloudAccount) ([]Cloud
 // listTencentRegions 获取腾讯云区域列表
 func (uc *CloudAccountUseCase) listTencentRegions(account *CloudAccount) ([]CloudRegion, error) {
 	// 创建腾讯云客户端
-	cred := common.NewCredential(account.AccessKey, account.SecretKey)
+	cred := common.NewCredential(string(account.AccessKey), string(account.SecretKey))
 	cpf := profile.NewClientProfile()
 	cpf.HttpProfile.Endpoint = "cvm.tencentcloudapi.com"
 
@@ -1010,8 +1023,8 @@ func (uc *CloudAccountUseCase) listAliyunInstances(account *CloudAccount, region
 	// 创建ECS客户端
 	client, err := ecs.NewClientWithAccessKey(
 		region,
-		account.AccessKey,
-		account.SecretKey,
+		string(account.AccessKey),
+		string(account.SecretKey),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("创建阿里云客户端失败: %w", err)
@@ -1073,7 +1086,7 @@ func (uc *CloudAccountUseCase) listAliyunInstances(account *CloudAccount, region
 // listTencentInstances 获取腾讯云实例列表
 func (uc *CloudAccountUseCase) listTencentInstances(account *CloudAccount, region string) ([]CloudInstance, error) {
 	// 创建腾讯云CVM客户端
-	cred := common.NewCredential(account.AccessKey, account.SecretKey)
+	cred := common.NewCredential(string(account.AccessKey), string(account.SecretKey))
 	cpf := profile.NewClientProfile()
 	cpf.HttpProfile.Endpoint = "cvm.tencentcloudapi.com"
 
@@ -1158,7 +1171,7 @@ func (uc *CloudAccountUseCase) listJDCloudInstances(account *CloudAccount, regio
 		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+account.SecretKey)
+	req.Header.Set("Authorization", "Bearer "+string(account.SecretKey))
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{Timeout: 30 * time.Second}
@@ -1504,12 +1517,7 @@ func (uc *HostUseCase) ListFiles(ctx context.Context, hostID uint, remotePath st
 		return nil, fmt.Errorf("主机未配置凭证")
 	}
 
-	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
-	if err != nil {
-		return nil, fmt.Errorf("获取凭证失败: %w", err)
-	}
-
-	sshClient, err := uc.createSSHClient(host, credential)
+	sshClient, err := uc.createSSHClient(ctx, host)
 	if err != nil {
 		return nil, fmt.Errorf("创建SSH连接失败: %w", err)
 	}
@@ -1554,12 +1562,7 @@ func (uc *HostUseCase) UploadFile(ctx context.Context, hostID uint, reader io.Re
 		return fmt.Errorf("主机未配置凭证")
 	}
 
-	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
-	if err != nil {
-		return fmt.Errorf("获取凭证失败: %w", err)
-	}
-
-	sshClient, err := uc.createSSHClient(host, credential)
+	sshClient, err := uc.createSSHClient(ctx, host)
 	if err != nil {
 		return fmt.Errorf("创建SSH连接失败: %w", err)
 	}
@@ -1597,12 +1600,7 @@ func (uc *HostUseCase) DownloadFile(ctx context.Context, hostID uint, remotePath
 		return fmt.Errorf("主机未配置凭证")
 	}
 
-	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
-	if err != nil {
-		return fmt.Errorf("获取凭证失败: %w", err)
-	}
-
-	sshClient, err := uc.createSSHClient(host, credential)
+	sshClient, err := uc.createSSHClient(ctx, host)
 	if err != nil {
 		return fmt.Errorf("创建SSH连接失败: %w", err)
 	}
@@ -1637,12 +1635,7 @@ func (uc *HostUseCase) DeleteFile(ctx context.Context, hostID uint, remotePath s
 		return fmt.Errorf("主机未配置凭证")
 	}
 
-	credential, err := uc.credentialRepo.GetByIDDecrypted(ctx, host.CredentialID)
-	if err != nil {
-		return fmt.Errorf("获取凭证失败: %w", err)
-	}
-
-	sshClient, err := uc.createSSHClient(host, credential)
+	sshClient, err := uc.createSSHClient(ctx, host)
 	if err != nil {
 		return fmt.Errorf("创建SSH连接失败: %w", err)
 	}
@@ -0,0 +1,187 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"time"
+)
+
+// 清单检索类型
+const (
+	InventoryKindPackage   = "package"
+	InventoryKindPort      = "port"
+	InventoryKindService   = "service"
+	InventoryKindUser      = "user"
+	InventoryKindInterface = "interface"
+	InventoryKindMount     = "mount"
+)
+
+// HostInventory 主机清单概要，每台主机一条，明细保存在各清单表中，每次采集整体替换
+type HostInventory struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+	HostID         uint      `gorm:"column:host_id;uniqueIndex;not null;comment:主机ID" json:"hostId"`
+	CollectedAt    time.Time `gorm:"comment:采集时间" json:"collectedAt"`
+	PackageManager string    `gorm:"type:varchar(20);comment:包管理器 rpm/dpkg" json:"packageManager"`
+	PortCount      int       `gorm:"type:int;comment:监听端口数" json:"portCount"`
+	ServiceCount   int       `gorm:"type:int;comment:服务数" json:"serviceCount"`
+	PackageCount   int       `gorm:"type:int;comment:软件包数" json:"packageCount"`
+	UserCount      int       `gorm:"type:int;comment:用户数" json:"userCount"`
+	InterfaceCount int       `gorm:"type:int;comment:网卡数" json:"interfaceCount"`
+	MountCount     int       `gorm:"type:int;comment:挂载点数" json:"mountCount"`
+	Errors         string    `gorm:"type:text;comment:采集失败的项(JSON)" json:"-"`
+}
+
+// TableName 表名
+func (HostInventory) TableName() string {
+	return "host_inventories"
+}
+
+// HostPackage 主机已安装软件包
+type HostPackage struct {
+	ID      uint64 `gorm:"primarykey" json:"-"`
+	HostID  uint   `gorm:"column:host_id;index;not null" json:"hostId"`
+	Name    string `gorm:"type:varchar(200);index;not null" json:"name"`
+	Version string `gorm:"type:varchar(200)" json:"version"`
+	Arch    string `gorm:"type:varchar(32)" json:"arch"`
+}
+
+// TableName 表名
+func (HostPackage) TableName() string {
+	return "host_packages"
+}
+
+// HostListenPort 主机监听端口
+type HostListenPort struct {
+	ID       uint64 `gorm:"primarykey" json:"-"`
+	HostID   uint   `gorm:"column:host_id;index;not null" json:"hostId"`
+	Protocol string `gorm:"type:varchar(10)" json:"protocol"`
+	Address  string `gorm:"type:varchar(64)" json:"address"`
+	Port     int    `gorm:"index" json:"port"`
+	Process  string `gorm:"type:varchar(100);index" json:"process"`
+	PID      int    `gorm:"column:pid" json:"pid"`
+}
+
+// TableName 表名
+func (HostListenPort) TableName() string {
+	return "host_listen_ports"
+}
+
+// HostServiceUnit 主机 systemd 服务
+type HostServiceUnit struct {
+	ID          uint64 `gorm:"primarykey" json:"-"`
+	HostID      uint   `gorm:"column:host_id;index;not null" json:"hostId"`
+	Name        string `gorm:"type:varchar(200);index;not null" json:"name"`
+	LoadState   string `gorm:"type:varchar(20)" json:"loadState"`
+	ActiveState string `gorm:"type:varchar(20);index" json:"activeState"`
+	SubState    string `gorm:"type:varchar(20)" json:"subState"`
+	Enabled     string `gorm:"type:varchar(20)" json:"enabled"`
+	Description string `gorm:"type:varchar(500)" json:"description"`
+}
+
+// TableName 表名
+func (HostServiceUnit) TableName() string {
+	return "host_services"
+}
+
+// HostUser 主机本地用户
+type HostUser struct {
+	ID     uint64 `gorm:"primarykey" json:"-"`
+	HostID uint   `gorm:"column:host_id;index;not null" json:"hostId"`
+	Name   string `gorm:"type:varchar(100);index;not null" json:"name"`
+	UID    int    `gorm:"column:uid" json:"uid"`
+	GID    int    `gorm:"column:gid" json:"gid"`
+	Home   string `gorm:"type:varchar(255)" json:"home"`
+	Shell  string `gorm:"type:varchar(100)" json:"shell"`
+	Sudo   bool   `gorm:"index" json:"sudo"`
+}
+
+// TableName 表名
+func (HostUser) TableName() string {
+	return "host_users"
+}
+
+// HostInterface 主机网卡
+type HostInterface struct {
+	ID        uint64 `gorm:"primarykey" json:"-"`
+	HostID    uint   `gorm:"column:host_id;index;not null" json:"hostId"`
+	Name      string `gorm:"type:varchar(50);not null" json:"name"`
+	MAC       string `gorm:"column:mac;type:varchar(50);index" json:"mac"`
+	State     string `gorm:"type:varchar(20)" json:"state"`
+	MTU       int    `gorm:"column:mtu" json:"mtu"`
+	Addresses string `gorm:"type:varchar(1000);comment:IP地址(CIDR，逗号分隔)" json:"addresses"`
+}
+
+// TableName 表名
+func (HostInterface) TableName() string {
+	return "host_interfaces"
+}
+
+// HostMount 主机已挂载文件系统
+type HostMount struct {
+	ID         uint64  `gorm:"primarykey" json:"-"`
+	HostID     uint    `gorm:"column:host_id;index;not null" json:"hostId"`
+	Device     string  `gorm:"type:varchar(255)" json:"device"`
+	MountPoint string  `gorm:"type:varchar(255)" json:"mountPoint"`
+	Fstype     string  `gorm:"type:varchar(50)" json:"fstype"`
+	Total      uint64  `gorm:"type:bigint" json:"total"`
+	Used       uint64  `gorm:"type:bigint" json:"used"`
+	Usage      float64 `gorm:"type:float" json:"usage"`
+}
+
+// TableName 表名
+func (HostMount) TableName() string {
+	return "host_mounts"
+}
+
+// HostInventoryDetail 主机完整清单
+type HostInventoryDetail struct {
+	*HostInventory
+	CollectErrors map[string]string  `json:"errors,omitempty"`
+	Packages      []*HostPackage     `json:"packages"`
+	Ports         []*HostListenPort  `json:"ports"`
+	Services      []*HostServiceUnit `json:"services"`
+	Users         []*HostUser        `json:"users"`
+	Interfaces    []*HostInterface   `json:"interfaces"`
+	Mounts        []*HostMount       `json:"mounts"`
+}
+
+// InventoryQuery 清单检索条件
+type InventoryQuery struct {
+	Kind     string // 检索类型，见 InventoryKind*
+	Name     string // 软件包、服务、用户、网卡名称，或挂载点；支持 * 通配符
+	Version  string // 软件包版本约束，如 "<3.0"
+	Port     int    // 监听端口
+	Protocol string // 监听协议 tcp/udp
+	Process  string // 监听端口所属进程，支持 * 通配符
+	State    string // 服务运行状态 active/inactive/failed
+	Sudo     *bool  // 只返回有/无 sudo 权限的用户
+	Address  string // 网卡IP地址（前缀匹配）
+	HostIDs  []uint // 限定主机范围，nil 表示不限
+}
+
+// InventoryMatch 清单检索结果
+type InventoryMatch struct {
+	HostID   uint        `json:"hostId"`
+	HostName string      `json:"hostName"`
+	HostIP   string      `json:"hostIp"`
+	Item     interface{} `json:"item"` // 对应类型的清单明细
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/pkg/collector"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 清单默认配置
+const (
+	DefaultInventoryInterval    = 24 * time.Hour
+	DefaultInventoryConcurrency = 5
+)
+
+// inventorySearchLimit 单次检索从数据库读取的最大条数
+const inventorySearchLimit = 10000
+
+// InventoryOptions 主机清单定时采集配置，零值字段使用默认值
+type InventoryOptions struct {
+	Interval    time.Duration // 定时采集间隔，小于 0 时关闭定时采集
+	Concurrency int           // 同时采集的主机数
+}
+
+// WithDefaults 补全默认值
+func (o InventoryOptions) WithDefaults() InventoryOptions {
+	if o.Interval == 0 {
+		o.Interval = DefaultInventoryInterval
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultInventoryConcurrency
+	}
+	return o
+}
+
+// InventoryUseCase 主机清单用例，采集监听端口、服务、软件包、用户、网卡和挂载点并支持跨主机检索
+type InventoryUseCase struct {
+	inventoryRepo HostInventoryRepo
+	hostRepo      HostRepo
+	hostUseCase   *HostUseCase
+	opts          InventoryOptions
+	task          periodicTask
+}
+
+func NewInventoryUseCase(inventoryRepo HostInventoryRepo, hostRepo HostRepo, hostUseCase *HostUseCase, opts InventoryOptions) *InventoryUseCase {
+	return &InventoryUseCase{
+		inventoryRepo: inventoryRepo,
+		hostRepo:      hostRepo,
+		hostUseCase:   hostUseCase,
+		opts:          opts.WithDefaults(),
+	}
+}
+
+// Start 启动后台定时采集所有已配置凭证主机的清单
+func (uc *InventoryUseCase) Start() {
+	if uc.opts.Interval < 0 {
+		logger.Info("主机清单定时采集已关闭")
+		return
+	}
+	uc.task.start(uc.opts.Interval, uc.collectAll)
+	logger.Info("主机清单定时采集已启动",
+		zap.Duration("interval", uc.opts.Interval),
+		zap.Int("concurrency", uc.opts.Concurrency))
+}
+
+// Stop 停止后台采集
+func (uc *InventoryUseCase) Stop() {
+	uc.task.stop()
+}
+
+func (uc *InventoryUseCase) collectAll(ctx context.Context) {
+	start := time.Now()
+	hostIDs, err := uc.hostRepo.ListIDs(ctx, nil, true)
+	if err != nil {
+		logger.Error("获取待采集主机失败", zap.Error(err))
+		return
+	}
+	failed := forEachHost(ctx, hostIDs, uc.opts.Concurrency, func(ctx context.Context, hostID uint) error {
+		_, err := uc.Collect(ctx, hostID)
+		if err != nil {
+			logger.Debug("采集主机清单失败", zap.Uint("hostId", hostID), zap.Error(err))
+		}
+		return err
+	})
+	logger.Info("主机清单采集完成",
+		zap.Int("hosts", len(hostIDs)),
+		zap.Int("failed", failed),
+		zap.Duration("elapsed", time.Since(start)))
+}
+
+// Collect 立即采集主机清单并替换已保存的清单
+func (uc *InventoryUseCase) Collect(ctx context.Context, hostID uint) (*HostInventoryDetail, error) {
+	host, err := uc.hostRepo.GetByID(ctx, hostID)
+	if err != nil {
+		return nil, fmt.Errorf("获取主机信息失败: %w", err)
+	}
+	if host.CredentialID == 0 {
+		return nil, fmt.Errorf("主机未配置凭证")
+	}
+
+	sshClient, err := uc.hostUseCase.createSSHClient(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("创建SSH连接失败: %w", err)
+	}
+	defer sshClient.Close()
+
+	inv, err := collector.NewCollector(sshClient).CollectInventory()
+	if err != nil {
+		return nil, err
+	}
+
+	detail := toInventoryDetail(hostID, inv)
+	if err := uc.inventoryRepo.Replace(ctx, detail); err != nil {
+		return nil, fmt.Errorf("保存主机清单失败: %w", err)
+	}
+	return detail, nil
+}
+
+// Get 获取主机清单，未采集过时返回 nil
+func (uc *InventoryUseCase) Get(ctx context.Context, hostID uint) (*HostInventoryDetail, error) {
+	detail, err := uc.inventoryRepo.Get(ctx, hostID)
+	if err != nil || detail == nil {
+		return detail, err
+	}
+	if detail.Errors != "" {
+		_ = json.Unmarshal([]byte(detail.Errors), &detail.CollectErrors)
+	}
+	return detail, nil
+}
+
+// Search 跨主机检索清单，如 kind=package name=openssl version=<3.0 查询 openssl 低于 3.0 的主机
+func (uc *InventoryUseCase) Search(ctx context.Context, q *InventoryQuery, page, pageSize int) ([]*InventoryMatch, int64, error) {
+	switch q.Kind {
+	case InventoryKindPackage, InventoryKindPort, InventoryKindService,
+		InventoryKindUser, InventoryKindInterface, InventoryKindMount:
+	default:
+		return nil, 0, fmt.Errorf("不支持的检索类型: %s", q.Kind)
+	}
+	if q.Version != "" {
+		if q.Kind != InventoryKindPackage {
+			return nil, 0, fmt.Errorf("只有软件包支持版本条件")
+		}
+		if _, _, err := collector.ParseVersionConstraint(q.Version); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	matches, err := uc.inventoryRepo.Search(ctx, q, inventorySearchLimit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 版本号无法在数据库中按包管理器规则比较，读取后过滤
+	if q.Version != "" {
+		filtered := matches[:0]
+		for _, m := range matches {
+			pkg, ok := m.Item.(*HostPackage)
+			if !ok {
+				continue
+			}
+			if ok, _ := collector.MatchVersion(pkg.Version, q.Version); ok {
+				filtered = append(filtered, m)
+			}
+		}
+		matches = filtered
+	}
+
+	total := int64(len(matches))
+	start := (page - 1) * pageSize
+	if start >= len(matches) {
+		return []*InventoryMatch{}, total, nil
+	}
+	end := start + pageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+	return matches[start:end], total, nil
+}
+
+// toInventoryDetail 将采集结果转换为清单模型
+func toInventoryDetail(hostID uint, inv *collector.Inventory) *HostInventoryDetail {
+	detail := &HostInventoryDetail{
+		HostInventory: &HostInventory{
+			HostID:         hostID,
+			CollectedAt:    inv.CollectedAt,
+			PackageManager: inv.PackageManager,
+			PortCount:      len(inv.Ports),
+			ServiceCount:   len(inv.Services),
+			PackageCount:   len(inv.Packages),
+			UserCount:      len(inv.Users),
+			InterfaceCount: len(inv.Interfaces),
+			MountCount:     len(inv.Mounts),
+		},
+		CollectErrors: inv.Errors,
+	}
+	if len(inv.Errors) > 0 {
+		if data, err := json.Marshal(inv.Errors); err == nil {
+			detail.Errors = string(data)
+		}
+	}
+
+	for _, p := range inv.Packages {
+		detail.Packages = append(detail.Packages, &HostPackage{HostID: hostID, Name: p.Name, Version: p.Version, Arch: p.Arch})
+	}
+	for _, p := range inv.Ports {
+		detail.Ports = append(detail.Ports, &HostListenPort{
+			HostID: hostID, Protocol: p.Protocol, Address: p.Address, Port: p.Port, Process: p.Process, PID: p.PID,
+		})
+	}
+	for _, s := range inv.Services {
+		detail.Services = append(detail.Services, &HostServiceUnit{
+			HostID: hostID, Name: s.Name, LoadState: s.LoadState, ActiveState: s.ActiveState,
+			SubState: s.SubState, Enabled: s.Enabled, Description: truncate(s.Description, 500),
+		})
+	}
+	for _, u := range inv.Users {
+		detail.Users = append(detail.Users, &HostUser{
+			HostID: hostID, Name: u.Name, UID: u.UID, GID: u.GID, Home: u.Home, Shell: u.Shell, Sudo: u.Sudo,
+		})
+	}
+	for _, i := range inv.Interfaces {
+		detail.Interfaces = append(detail.Interfaces, &HostInterface{
+			HostID: hostID, Name: i.Name, MAC: i.MAC, State: i.State, MTU: i.MTU,
+			Addresses: truncate(strings.Join(i.Addresses, ","), 1000),
+		})
+	}
+	for _, m := range inv.Mounts {
+		detail.Mounts = append(detail.Mounts, &HostMount{
+			HostID: hostID, Device: m.Device, MountPoint: m.MountPoint, Fstype: m.Fstype,
+			Total: m.Total, Used: m.Used, Usage: m.Usage,
+		})
+	}
+	return detail
+}
+
+// truncate 按字符截断过长的字段
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
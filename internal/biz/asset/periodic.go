@@ -0,0 +1,94 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// periodicTask 按固定间隔在后台执行的任务
+type periodicTask struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// start 启动后台任务，每隔 interval 执行一次 fn，同一时刻只有一轮在执行
+func (t *periodicTask) start(interval time.Duration, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.done = make(chan struct{})
+	go func() {
+		defer close(t.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	}()
+}
+
+// stop 停止后台任务，等待进行中的一轮结束
+func (t *periodicTask) stop() {
+	if t.cancel == nil {
+		return
+	}
+	t.cancel()
+	<-t.done
+}
+
+// forEachHost 并发处理多台主机，同时进行的不超过 concurrency，返回失败的主机数；
+// ctx 取消后不再开始新的主机，未开始的主机计为失败
+func forEachHost(ctx context.Context, hostIDs []uint, concurrency int, fn func(ctx context.Context, hostID uint) error) int {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+	)
+	sem := make(chan struct{}, concurrency)
+	for i, hostID := range hostIDs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return failed + len(hostIDs) - i
+		}
+		wg.Add(1)
+		go func(hostID uint) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(ctx, hostID); err != nil {
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}(hostID)
+	}
+	wg.Wait()
+	return failed
+}
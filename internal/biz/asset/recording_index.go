@@ -0,0 +1,294 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 录制来源
+const (
+	RecordingSourceHost = "host" // 主机 SSH 终端（ssh_terminal_sessions）
+	RecordingSourceK8s  = "k8s"  // Kubernetes Pod 终端（k8s_terminal_sessions）
+)
+
+// 索引行来源
+const (
+	RecordingStreamOutput = "o" // 终端输出
+	RecordingStreamInput  = "i" // 用户输入还原出的命令
+)
+
+const (
+	// maxIndexLineLength 单行索引内容保留的最大字符数
+	maxIndexLineLength = 1000
+	// maxIndexOutputLines 单个录制最多索引的输出行数，超出后只索引命令
+	maxIndexOutputLines = 100000
+)
+
+// TerminalRecording 已建立全文索引的终端录制
+type TerminalRecording struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Source    string    `gorm:"type:varchar(20);uniqueIndex:uk_recording_session;not null;comment:来源 host/k8s" json:"source"`
+	SessionID uint      `gorm:"column:session_id;uniqueIndex:uk_recording_session;not null;comment:终端会话ID" json:"sessionId"`
+	Target    string    `gorm:"type:varchar(300);comment:会话目标，主机名称(IP)或 集群/命名空间/Pod/容器" json:"target"`
+	UserID    uint      `gorm:"column:user_id;index;comment:用户ID" json:"userId"`
+	Username  string    `gorm:"type:varchar(100);index;comment:用户名" json:"username"`
+	StartedAt time.Time `gorm:"index;comment:会话开始时间" json:"startedAt"`
+	Duration  int       `gorm:"comment:会话时长(秒)" json:"duration"`
+	LineCount int       `gorm:"comment:索引行数" json:"lineCount"`
+	// 录制文件路径，用于回放不在主机终端会话表中的录制
+	RecordingPath string `gorm:"type:varchar(500);comment:录制文件路径" json:"-"`
+}
+
+// TableName 表名
+func (TerminalRecording) TableName() string {
+	return "terminal_recordings"
+}
+
+// TerminalRecordingLine 录制中的一行输出或一条命令
+type TerminalRecordingLine struct {
+	ID          uint    `gorm:"primarykey" json:"id"`
+	RecordingID uint    `gorm:"column:recording_id;index;not null;comment:录制索引ID" json:"recordingId"`
+	Stream      string  `gorm:"type:varchar(1);comment:来源 o输出 i命令" json:"stream"`
+	Offset      float64 `gorm:"comment:距会话开始的秒数" json:"offset"`
+	Content     string  `gorm:"type:varchar(1000);comment:去除控制字符后的文本" json:"content"`
+}
+
+// TableName 表名
+func (TerminalRecordingLine) TableName() string {
+	return "terminal_recording_lines"
+}
+
+// RecordingQuery 录制全文检索条件
+type RecordingQuery struct {
+	Keyword   string
+	Source    string // 为空时检索全部来源
+	Stream    string // 为空时同时检索输出和命令
+	Username  string
+	Target    string // 会话目标包含的内容
+	StartTime *time.Time
+	EndTime   *time.Time
+	Page      int
+	PageSize  int
+}
+
+// RecordingMatch 录制检索命中的一行
+type RecordingMatch struct {
+	Source    string    `json:"source"`
+	SessionID uint      `json:"sessionId"`
+	Target    string    `json:"target"`
+	Username  string    `json:"username"`
+	StartedAt time.Time `json:"startedAt"`
+	Stream    string    `json:"stream"`
+	Offset    float64   `json:"offset"` // 距会话开始的秒数，回放时从这里开始播放
+	Content   string    `json:"content"`
+}
+
+// RecordingIndexer 终端录制索引的写入，插件通过 LookupRecordingIndexer 获取，不直接依赖数据层
+type RecordingIndexer interface {
+	// Index 解析录制文件并建立索引
+	Index(ctx context.Context, recording *TerminalRecording, path string) error
+	// Delete 删除会话的录制索引
+	Delete(ctx context.Context, source string, sessionID uint) error
+}
+
+var (
+	registeredIndexerMu sync.RWMutex
+	registeredIndexer   RecordingIndexer
+)
+
+// RegisterRecordingIndexer 注册终端录制索引实现，由资产模块初始化时调用
+func RegisterRecordingIndexer(indexer RecordingIndexer) {
+	registeredIndexerMu.Lock()
+	defer registeredIndexerMu.Unlock()
+	registeredIndexer = indexer
+}
+
+// LookupRecordingIndexer 获取终端录制索引实现，资产模块未初始化时返回 nil
+func LookupRecordingIndexer() RecordingIndexer {
+	registeredIndexerMu.RLock()
+	defer registeredIndexerMu.RUnlock()
+	return registeredIndexer
+}
+
+// RecordingIndexUseCase 终端录制全文索引
+type RecordingIndexUseCase struct {
+	repo RecordingIndexRepo
+}
+
+func NewRecordingIndexUseCase(repo RecordingIndexRepo) *RecordingIndexUseCase {
+	return &RecordingIndexUseCase{repo: repo}
+}
+
+// Index 解析录制文件并建立索引，重复建立时替换原有索引
+func (uc *RecordingIndexUseCase) Index(ctx context.Context, recording *TerminalRecording, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开录制文件失败: %w", err)
+	}
+	defer file.Close()
+
+	lines, err := ParseRecording(file)
+	if err != nil {
+		return err
+	}
+	recording.LineCount = len(lines)
+	recording.RecordingPath = path
+	return uc.repo.Replace(ctx, recording, lines)
+}
+
+// Search 全文检索录制内容
+func (uc *RecordingIndexUseCase) Search(ctx context.Context, q *RecordingQuery) ([]*RecordingMatch, int64, error) {
+	q.Keyword = strings.TrimSpace(q.Keyword)
+	if q.Keyword == "" {
+		return nil, 0, errors.New("请输入检索内容")
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 || q.PageSize > 100 {
+		q.PageSize = 20
+	}
+	return uc.repo.Search(ctx, q)
+}
+
+// Get 获取会话的录制索引，未建立索引时返回 nil
+func (uc *RecordingIndexUseCase) Get(ctx context.Context, source string, sessionID uint) (*TerminalRecording, error) {
+	return uc.repo.Get(ctx, source, sessionID)
+}
+
+// Delete 删除会话的录制索引
+func (uc *RecordingIndexUseCase) Delete(ctx context.Context, source string, sessionID uint) error {
+	return uc.repo.Delete(ctx, source, sessionID)
+}
+
+// ParseRecording 解析 asciinema v2 录制，提取去除控制字符后的输出行和用户输入的命令
+// 全屏程序（vim、top 等）的画面不是按行输出的，不建立索引
+func ParseRecording(r io.Reader) ([]*TerminalRecordingLine, error) {
+	reader := bufio.NewReader(r)
+	header, err := reader.ReadBytes('\n')
+	if err != nil && len(header) == 0 {
+		return nil, fmt.Errorf("读取录制文件头部失败: %w", err)
+	}
+
+	ix := &recordingIndexer{cl: NewCommandLine()}
+	for {
+		raw, err := reader.ReadBytes('\n')
+		if len(raw) > 0 {
+			var event []interface{}
+			// 录制中断时最后一行可能不完整，跳过无法解析的事件
+			if json.Unmarshal(raw, &event) == nil && len(event) == 3 {
+				offset, _ := event[0].(float64)
+				stream, _ := event[1].(string)
+				data, _ := event[2].(string)
+				switch stream {
+				case RecordingStreamOutput:
+					ix.output(offset, data)
+				case RecordingStreamInput:
+					ix.input(offset, data)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取录制文件失败: %w", err)
+		}
+	}
+	ix.flush()
+	return ix.lines, nil
+}
+
+// recordingIndexer 按录制事件还原输出行和命令
+// 输出行借助 CommandLine 的回显跟踪处理光标移动、擦除等控制序列，遇到换行时取回显行作为一行输出
+type recordingIndexer struct {
+	cl          *CommandLine
+	lineStart   float64 // 当前输出行第一次出现内容的时间
+	lineStarted bool
+	outputLines int
+	lines       []*TerminalRecordingLine
+}
+
+func (ix *recordingIndexer) output(offset float64, data string) {
+	for {
+		i := strings.IndexByte(data, '\n')
+		chunk := data
+		if i >= 0 {
+			chunk = data[:i]
+		}
+		if chunk != "" {
+			ix.cl.Output([]byte(chunk))
+			if !ix.lineStarted && len(ix.cl.output) > 0 {
+				ix.lineStart, ix.lineStarted = offset, true
+			}
+		}
+		if i < 0 {
+			return
+		}
+		ix.flush()
+		ix.cl.Output([]byte{'\n'})
+		data = data[i+1:]
+	}
+}
+
+func (ix *recordingIndexer) input(offset float64, data string) {
+	rest := []byte(data)
+	for len(rest) > 0 {
+		_, command, submitted, next := ix.cl.Input(rest)
+		if submitted && command != "" {
+			ix.add(RecordingStreamInput, offset, command)
+		}
+		rest = next
+	}
+}
+
+// flush 结束当前输出行
+func (ix *recordingIndexer) flush() {
+	started := ix.lineStarted
+	ix.lineStarted = false
+	if !started || ix.cl.altScreen || ix.outputLines >= maxIndexOutputLines {
+		return
+	}
+	text := strings.TrimRightFunc(string(ix.cl.output), unicode.IsSpace)
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	ix.outputLines++
+	ix.add(RecordingStreamOutput, ix.lineStart, text)
+}
+
+func (ix *recordingIndexer) add(stream string, offset float64, content string) {
+	if r := []rune(content); len(r) > maxIndexLineLength {
+		content = string(r[:maxIndexLineLength])
+	}
+	ix.lines = append(ix.lines, &TerminalRecordingLine{Stream: stream, Offset: offset, Content: content})
+}
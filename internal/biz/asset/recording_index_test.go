@@ -0,0 +1,75 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRecording(t *testing.T) {
+	cast := strings.Join([]string{
+		`{"version":2,"width":80,"height":24,"timestamp":1700000000}`,
+		`[0.5,"o","[root@web ~]# "]`,
+		`[1.0,"i","iptables -L"]`,
+		`[1.1,"o","iptables -L"]`,
+		`[1.5,"i","\r"]`,
+		`[1.6,"o","\r\nChain INPUT (policy ACCEPT)\r\n"]`,
+		`[2.0,"o","\u001b[1;32mtarget\u001b[0m     prot\r\n"]`,
+		`[2.5,"o","10%\r50%\r100%\u001b[K\r\n"]`,
+		`[3.0,"o","\u001b[?1049h"]`,
+		`[3.1,"o","vim screen\r\n"]`,
+		`[3.5,"o","\u001b[?1049l[root@web ~]# "]`,
+		`[4.0,"i","sudo -i\r"]`,
+		`[4.1,"o","sudo -i\r\n[sudo] password for ops: "]`,
+		`[5.0,"i","secret\r"]`,
+		`[5.5,"o","\r\ndone"]`,
+		`[6.0,"o","trunc`, // 录制中断时不完整的事件
+	}, "\n")
+
+	lines, err := ParseRecording(strings.NewReader(cast))
+	if err != nil {
+		t.Fatalf("ParseRecording() error = %v", err)
+	}
+
+	want := []TerminalRecordingLine{
+		{Stream: RecordingStreamInput, Offset: 1.5, Content: "iptables -L"},
+		{Stream: RecordingStreamOutput, Offset: 0.5, Content: "[root@web ~]# iptables -L"},
+		{Stream: RecordingStreamOutput, Offset: 1.6, Content: "Chain INPUT (policy ACCEPT)"},
+		{Stream: RecordingStreamOutput, Offset: 2.0, Content: "target     prot"},
+		{Stream: RecordingStreamOutput, Offset: 2.5, Content: "100%"},
+		{Stream: RecordingStreamInput, Offset: 4.0, Content: "sudo -i"},
+		{Stream: RecordingStreamOutput, Offset: 3.5, Content: "[root@web ~]# sudo -i"},
+		{Stream: RecordingStreamOutput, Offset: 4.1, Content: "[sudo] password for ops:"},
+		{Stream: RecordingStreamOutput, Offset: 5.5, Content: "done"},
+	}
+	if len(lines) != len(want) {
+		for _, l := range lines {
+			t.Logf("%s %.1f %q", l.Stream, l.Offset, l.Content)
+		}
+		t.Fatalf("ParseRecording() 返回 %d 行，期望 %d 行", len(lines), len(want))
+	}
+	for i, w := range want {
+		got := *lines[i]
+		if got.Stream != w.Stream || got.Offset != w.Offset || got.Content != w.Content {
+			t.Errorf("第 %d 行 = %s %.1f %q，期望 %s %.1f %q", i, got.Stream, got.Offset, got.Content, w.Stream, w.Offset, w.Content)
+		}
+	}
+}
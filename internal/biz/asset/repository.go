@@ -19,7 +19,10 @@
 
 package asset
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type AssetGroupRepo interface {
 	Create(ctx context.Context, group *AssetGroup) error
@@ -43,6 +46,67 @@ type HostRepo interface {
 	GetByIP(ctx context.Context, ip string) (*Host, error)
 	GetByCloudInstanceID(ctx context.Context, instanceID string) (*Host, error)
 	CountByCredentialID(ctx context.Context, credentialID uint) (int64, error)
+	// ListIDs 获取主机ID，groupIDs 为空时不按分组筛选，withCredential 为 true 时只返回已配置凭证的主机
+	ListIDs(ctx context.Context, groupIDs []uint, withCredential bool) ([]uint, error)
+}
+
+type HostMetricRepo interface {
+	// Save 写入指标，主机、精度、时间相同的记录直接覆盖
+	Save(ctx context.Context, metrics []*HostMetric) error
+	// List 获取 [start, end) 内指定精度的指标，hostIDs 为 nil 时返回所有主机
+	List(ctx context.Context, hostIDs []uint, resolution string, start, end time.Time) ([]*HostMetric, error)
+	// DeleteBefore 删除指定精度早于 before 的指标
+	DeleteBefore(ctx context.Context, resolution string, before time.Time) (int64, error)
+}
+
+type HostKeyRepo interface {
+	// GetByHostID 获取主机密钥，未记录时返回 nil
+	GetByHostID(ctx context.Context, hostID uint) (*HostKey, error)
+	// CreateIfAbsent 主机尚无记录时创建，已存在时返回 false
+	CreateIfAbsent(ctx context.Context, key *HostKey) (bool, error)
+	// Save 创建或覆盖主机密钥，清除待确认的密钥
+	Save(ctx context.Context, key *HostKey) error
+	// RecordMismatch 记录与已信任密钥不一致的公钥，新出现的公钥写入操作日志并返回 true
+	RecordMismatch(ctx context.Context, hostID uint, keyType, publicKey, fingerprint string) (bool, error)
+	Delete(ctx context.Context, hostID uint) error
+	List(ctx context.Context, page, pageSize int, keyword, status string) ([]*HostKeyInfo, int64, error)
+}
+
+type HostInventoryRepo interface {
+	// Replace 用一次采集结果整体替换主机清单
+	Replace(ctx context.Context, detail *HostInventoryDetail) error
+	// Get 获取主机完整清单，未采集过时返回 nil
+	Get(ctx context.Context, hostID uint) (*HostInventoryDetail, error)
+	// Search 按条件检索清单明细，最多返回 limit 条，不处理版本约束
+	Search(ctx context.Context, q *InventoryQuery, limit int) ([]*InventoryMatch, error)
+}
+
+type DriftRepo interface {
+	CreateSnapshot(ctx context.Context, snapshot *HostSnapshot) error
+	GetSnapshot(ctx context.Context, id uint) (*HostSnapshot, error)
+	// ListSnapshots 获取主机的快照列表，不含快照内容
+	ListSnapshots(ctx context.Context, hostID uint) ([]*HostSnapshot, error)
+	DeleteSnapshot(ctx context.Context, id uint) error
+	// SetBaseline 将快照设为主机基线，取消该主机原有的基线
+	SetBaseline(ctx context.Context, hostID, snapshotID uint) error
+	// GetBaseline 获取主机基线，未设置时返回 nil
+	GetBaseline(ctx context.Context, hostID uint) (*HostSnapshot, error)
+	// ListBaselineHostIDs 获取已设置基线的主机，hostIDs 为 nil 时不限范围
+	ListBaselineHostIDs(ctx context.Context, hostIDs []uint) ([]uint, error)
+	CreateReport(ctx context.Context, report *DriftReport) error
+	// LatestReports 获取每台主机最近一次漂移检查结果
+	LatestReports(ctx context.Context, hostIDs []uint) ([]*DriftReport, error)
+	ListReports(ctx context.Context, hostID uint, page, pageSize int) ([]*DriftReport, int64, error)
+	DeleteReportsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type RecordingIndexRepo interface {
+	// Replace 保存录制索引，替换同一会话原有的索引
+	Replace(ctx context.Context, recording *TerminalRecording, lines []*TerminalRecordingLine) error
+	Search(ctx context.Context, q *RecordingQuery) ([]*RecordingMatch, int64, error)
+	// Get 获取会话的录制索引，未建立索引时返回 nil
+	Get(ctx context.Context, source string, sessionID uint) (*TerminalRecording, error)
+	Delete(ctx context.Context, source string, sessionID uint) error
 }
 
 type CredentialRepo interface {
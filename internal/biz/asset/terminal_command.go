@@ -0,0 +1,109 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 终端命令状态
+const (
+	TerminalCommandExecuted  = "executed"  // 已执行
+	TerminalCommandBlocked   = "blocked"   // 命中拦截策略，未执行
+	TerminalCommandConfirmed = "confirmed" // 命中需确认的策略，用户确认后执行
+	TerminalCommandCancelled = "cancelled" // 命中需确认的策略，用户取消
+)
+
+// 终端命令检查结果
+const (
+	CommandCheckAllow   = "allow"
+	CommandCheckDeny    = "deny"
+	CommandCheckConfirm = "confirm"
+)
+
+// TerminalCommand 从交互式终端输入中还原的命令
+type TerminalCommand struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `gorm:"index" json:"createdAt"`
+	SessionID  uint      `gorm:"column:session_id;index;not null;comment:终端会话ID(ssh_terminal_sessions)" json:"sessionId"`
+	HostID     uint      `gorm:"column:host_id;index;not null;comment:主机ID" json:"hostId"`
+	HostName   string    `gorm:"type:varchar(100);comment:主机名称" json:"hostName"`
+	HostIP     string    `gorm:"type:varchar(50);comment:主机IP" json:"hostIp"`
+	UserID     uint      `gorm:"column:user_id;index;comment:输入命令的用户ID" json:"userId"`
+	Username   string    `gorm:"type:varchar(100);index;comment:输入命令的用户名" json:"username"`
+	Command    string    `gorm:"type:text;comment:命令" json:"command"`
+	Offset     float64   `gorm:"comment:距会话开始的秒数，用于定位录制回放" json:"offset"`
+	Status     string    `gorm:"type:varchar(20);index;comment:状态 executed/blocked/confirmed/cancelled" json:"status"`
+	PolicyName string    `gorm:"type:varchar(100);comment:命中的策略" json:"policyName,omitempty"`
+	Reason     string    `gorm:"type:varchar(500);comment:拦截或确认原因" json:"reason,omitempty"`
+}
+
+// TableName 表名
+func (TerminalCommand) TableName() string {
+	return "ssh_terminal_commands"
+}
+
+// TerminalCommandQuery 终端命令检索条件
+type TerminalCommandQuery struct {
+	SessionID uint
+	HostID    uint
+	Username  string
+	Keyword   string // 命令包含的内容
+	Status    string
+	StartTime *time.Time
+	EndTime   *time.Time
+	Page      int
+	PageSize  int
+}
+
+// CommandCheck 终端命令检查结果
+type CommandCheck struct {
+	Action     string // allow/deny/confirm
+	PolicyName string
+	Reason     string
+}
+
+// TerminalCommandChecker 检查交互式终端中提交的命令，由任务中心插件按命令策略实现
+type TerminalCommandChecker func(ctx context.Context, userID, hostID uint, command string) (*CommandCheck, error)
+
+var (
+	commandCheckerMu sync.RWMutex
+	commandChecker   TerminalCommandChecker
+)
+
+// SetTerminalCommandChecker 注册终端命令检查，传入 nil 取消注册
+func SetTerminalCommandChecker(fn TerminalCommandChecker) {
+	commandCheckerMu.Lock()
+	defer commandCheckerMu.Unlock()
+	commandChecker = fn
+}
+
+// CheckTerminalCommand 检查终端命令，未注册检查时一律放行
+func CheckTerminalCommand(ctx context.Context, userID, hostID uint, command string) (*CommandCheck, error) {
+	commandCheckerMu.RLock()
+	fn := commandChecker
+	commandCheckerMu.RUnlock()
+	if fn == nil {
+		return &CommandCheck{Action: CommandCheckAllow}, nil
+	}
+	return fn(ctx, userID, hostID, command)
+}
@@ -39,7 +39,7 @@ type TerminalSession struct {
 	RecordingPath string         `gorm:"type:varchar(500);comment:录制文件路径" json:"recordingPath"`
 	Duration      int            `gorm:"type:int;comment:会话时长(秒)" json:"duration"`
 	FileSize      int64          `gorm:"type:bigint;comment:文件大小(字节)" json:"fileSize"`
-	Status        string         `gorm:"type:varchar(20);default:'recording';comment:会话状态 recording/completed/failed" json:"status"`
+	Status        string         `gorm:"type:varchar(20);default:'recording';comment:会话状态 recording/completed/failed/terminated" json:"status"`
 }
 
 // TableName 表名
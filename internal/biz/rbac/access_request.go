@@ -0,0 +1,537 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	auditbiz "github.com/ydcloud-dy/opshub/internal/biz/audit"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 临时权限申请状态
+const (
+	AccessRequestPending   = "pending"   // 待审批
+	AccessRequestApproved  = "approved"  // 已授权，生效中
+	AccessRequestRejected  = "rejected"  // 已驳回
+	AccessRequestCancelled = "cancelled" // 申请人已撤回
+	AccessRequestRevoked   = "revoked"   // 到期前已收回
+	AccessRequestExpired   = "expired"   // 已到期回收
+)
+
+// 临时权限申请的资源类型
+const (
+	AccessResourceHostGroup  = "host_group"  // 主机分组的操作权限
+	AccessResourceK8sCluster = "k8s_cluster" // K8s 集群角色
+)
+
+// MaxAccessRequestMinutes 单次申请的最长时长（7天）
+const MaxAccessRequestMinutes = 7 * 24 * 60
+
+// accessReapInterval 回收到期授权的检查间隔
+const accessReapInterval = time.Minute
+
+var (
+	// ErrAccessRequestNotFound 申请不存在
+	ErrAccessRequestNotFound = errors.New("申请不存在")
+	// ErrAccessRequestDecided 申请已处理
+	ErrAccessRequestDecided = errors.New("申请已处理，不能重复操作")
+	// ErrAccessRequestNotActive 申请未生效
+	ErrAccessRequestNotActive = errors.New("申请未生效，无需收回")
+	// ErrAccessRequestSelfApproval 不能审批自己的申请
+	ErrAccessRequestSelfApproval = errors.New("不能审批自己的申请")
+	// ErrAccessRequestNotOwner 只能撤回自己的申请
+	ErrAccessRequestNotOwner = errors.New("只能撤回自己的申请")
+	// ErrAccessRequestBeyondApprover 申请的权限超出审批人自身的权限
+	ErrAccessRequestBeyondApprover = errors.New("申请的权限超出审批人自身的权限，不能审批")
+	// ErrAccessGrantExists 申请人已拥有该授权
+	ErrAccessGrantExists = errors.New("申请人已拥有该授权，无需临时申请")
+	// ErrAccessResourceUnsupported 资源类型未注册授权实现
+	ErrAccessResourceUnsupported = errors.New("不支持该资源类型，请确认对应模块或插件已启用")
+)
+
+// SysAccessRequest 临时权限申请
+// 审批通过后按申请时长创建有期限的授权，到期后由后台任务回收
+type SysAccessRequest struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// 申请人
+	UserID   uint   `gorm:"not null;index;comment:申请人ID" json:"userId"`
+	Username string `gorm:"type:varchar(50);comment:申请人用户名" json:"username"`
+
+	// 申请的资源
+	ResourceType string `gorm:"type:varchar(30);not null;comment:资源类型 host_group:主机分组 k8s_cluster:K8s集群" json:"resourceType"`
+	ResourceName string `gorm:"type:varchar(255);comment:资源名称" json:"resourceName"`
+
+	// 主机分组授权
+	AssetGroupID uint      `gorm:"default:0;comment:资产分组ID" json:"assetGroupId"`
+	HostIDs      UintArray `gorm:"type:json;comment:主机ID列表(为空表示整个分组)" json:"hostIds"`
+	Permissions  uint      `gorm:"type:int unsigned;default:0;comment:操作权限位掩码" json:"permissions"`
+
+	// K8s 集群角色
+	ClusterID     uint   `gorm:"default:0;comment:集群ID" json:"clusterId"`
+	RoleName      string `gorm:"type:varchar(255);comment:K8s角色名称" json:"roleName"`
+	RoleNamespace string `gorm:"type:varchar(255);comment:K8s角色命名空间" json:"roleNamespace"`
+	RoleType      string `gorm:"type:varchar(50);comment:ClusterRole 或 Role" json:"roleType"`
+
+	Duration int    `gorm:"not null;comment:申请时长(分钟)" json:"duration"`
+	Reason   string `gorm:"type:varchar(500);not null;comment:申请原因" json:"reason"`
+	Status   string `gorm:"type:varchar(20);not null;index;comment:状态" json:"status"`
+
+	// 审批和回收
+	ApproverID   uint       `gorm:"default:0;comment:审批人ID" json:"approverId"`
+	ApproverName string     `gorm:"type:varchar(50);comment:审批人" json:"approverName"`
+	Comment      string     `gorm:"type:varchar(500);comment:审批意见" json:"comment"`
+	ApprovedAt   *time.Time `gorm:"comment:授权时间" json:"approvedAt"`
+	ExpiresAt    *time.Time `gorm:"index;comment:授权到期时间" json:"expiresAt"`
+	RevokedAt    *time.Time `gorm:"comment:回收时间" json:"revokedAt"`
+	RevokedBy    string     `gorm:"type:varchar(50);comment:回收人，到期回收为 system" json:"revokedBy"`
+	GrantID      uint       `gorm:"default:0;comment:生效的授权记录ID" json:"grantId"`
+}
+
+// TableName 指定表名
+func (SysAccessRequest) TableName() string {
+	return "sys_access_request"
+}
+
+// Describe 申请内容摘要，用于审计日志
+func (r *SysAccessRequest) Describe() string {
+	var target string
+	switch r.ResourceType {
+	case AccessResourceHostGroup:
+		target = fmt.Sprintf("主机分组 %s 的%s权限", r.ResourceName, strings.Join(GetAllPermissionNames(r.Permissions), "、"))
+		if len(r.HostIDs) > 0 {
+			target = fmt.Sprintf("主机分组 %s 中 %d 台主机的%s权限", r.ResourceName, len(r.HostIDs), strings.Join(GetAllPermissionNames(r.Permissions), "、"))
+		}
+	case AccessResourceK8sCluster:
+		role := r.RoleName
+		if r.RoleNamespace != "" {
+			role = r.RoleNamespace + "/" + r.RoleName
+		}
+		target = fmt.Sprintf("集群 %s 的%s %s", r.ResourceName, r.RoleType, role)
+	default:
+		target = r.ResourceName
+	}
+	return fmt.Sprintf("%s，时长 %s", target, time.Duration(r.Duration)*time.Minute)
+}
+
+// AccessRequestCreateReq 提交临时权限申请
+type AccessRequestCreateReq struct {
+	ResourceType  string `json:"resourceType" binding:"required,oneof=host_group k8s_cluster"`
+	AssetGroupID  uint   `json:"assetGroupId"`
+	HostIDs       []uint `json:"hostIds"`
+	Permissions   uint   `json:"permissions"`
+	ClusterID     uint   `json:"clusterId"`
+	RoleName      string `json:"roleName" binding:"max=255"`
+	RoleNamespace string `json:"roleNamespace" binding:"max=255"`
+	RoleType      string `json:"roleType" binding:"omitempty,oneof=ClusterRole Role"`
+	Duration      int    `json:"duration" binding:"required,min=1"` // 分钟
+	Reason        string `json:"reason" binding:"required,max=500"`
+}
+
+// AccessRequestReviewReq 审批、驳回或收回时的意见
+type AccessRequestReviewReq struct {
+	Comment string `json:"comment" binding:"max=500"`
+}
+
+// AccessRequestQuery 申请列表查询条件
+type AccessRequestQuery struct {
+	Page         int
+	PageSize     int
+	UserID       uint // 为 0 时查询所有人的申请
+	Status       string
+	ResourceType string
+	Keyword      string // 匹配申请人、资源名称和原因
+}
+
+// AccessGranter 临时权限的授予和回收，由资源所属的模块实现并注册
+type AccessGranter interface {
+	// Describe 校验申请的资源，返回资源名称
+	Describe(ctx context.Context, req *SysAccessRequest) (string, error)
+	// Authorize 校验审批人自身拥有申请的权限，超出时返回 ErrAccessRequestBeyondApprover
+	Authorize(ctx context.Context, req *SysAccessRequest, approverID uint) error
+	// Grant 创建到期时间为 req.ExpiresAt 的授权，返回授权记录ID
+	// 申请人已拥有相同授权时返回 ErrAccessGrantExists，不能接管已有的授权
+	Grant(ctx context.Context, req *SysAccessRequest) (uint, error)
+	// Revoke 删除 Grant 创建的授权，授权已不存在时不返回错误
+	Revoke(ctx context.Context, req *SysAccessRequest) error
+}
+
+var (
+	accessGrantersMu sync.RWMutex
+	accessGranters   = make(map[string]AccessGranter)
+)
+
+// RegisterAccessGranter 注册资源类型的授权实现，重复注册时覆盖
+func RegisterAccessGranter(resourceType string, granter AccessGranter) {
+	accessGrantersMu.Lock()
+	defer accessGrantersMu.Unlock()
+	accessGranters[resourceType] = granter
+}
+
+// AccessResourceTypes 返回已注册授权实现的资源类型
+func AccessResourceTypes() []string {
+	accessGrantersMu.RLock()
+	defer accessGrantersMu.RUnlock()
+	types := make([]string, 0, len(accessGranters))
+	for t := range accessGranters {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func lookupAccessGranter(resourceType string) (AccessGranter, error) {
+	accessGrantersMu.RLock()
+	defer accessGrantersMu.RUnlock()
+	granter, ok := accessGranters[resourceType]
+	if !ok {
+		return nil, ErrAccessResourceUnsupported
+	}
+	return granter, nil
+}
+
+// AccessRequestUseCase 临时权限申请用例
+// 申请经审批人通过后创建有期限的授权，后台任务定时回收到期的授权，每一步都写入操作审计
+type AccessRequestUseCase struct {
+	repo      AccessRequestRepo
+	auditRepo auditbiz.OperationLogRepo
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewAccessRequestUseCase(repo AccessRequestRepo, auditRepo auditbiz.OperationLogRepo) *AccessRequestUseCase {
+	return &AccessRequestUseCase{
+		repo:      repo,
+		auditRepo: auditRepo,
+	}
+}
+
+// Create 提交申请
+func (uc *AccessRequestUseCase) Create(ctx context.Context, userID uint, username string, in *AccessRequestCreateReq) (*SysAccessRequest, error) {
+	if in.Duration > MaxAccessRequestMinutes {
+		return nil, fmt.Errorf("申请时长不能超过 %d 天", MaxAccessRequestMinutes/(24*60))
+	}
+	req := &SysAccessRequest{
+		UserID:        userID,
+		Username:      username,
+		ResourceType:  in.ResourceType,
+		AssetGroupID:  in.AssetGroupID,
+		HostIDs:       in.HostIDs,
+		Permissions:   in.Permissions,
+		ClusterID:     in.ClusterID,
+		RoleName:      in.RoleName,
+		RoleNamespace: in.RoleNamespace,
+		RoleType:      in.RoleType,
+		Duration:      in.Duration,
+		Reason:        in.Reason,
+		Status:        AccessRequestPending,
+	}
+	switch req.ResourceType {
+	case AccessResourceHostGroup:
+		if req.AssetGroupID == 0 || req.Permissions == 0 {
+			return nil, errors.New("请选择主机分组和操作权限")
+		}
+		req.Permissions &= PermissionAll
+	case AccessResourceK8sCluster:
+		if req.ClusterID == 0 || req.RoleName == "" || req.RoleType == "" {
+			return nil, errors.New("请选择集群和角色")
+		}
+		if req.RoleType == "Role" && req.RoleNamespace == "" {
+			return nil, errors.New("命名空间角色需要指定命名空间")
+		}
+		if req.RoleType == "ClusterRole" {
+			req.RoleNamespace = ""
+		}
+	}
+
+	granter, err := lookupAccessGranter(req.ResourceType)
+	if err != nil {
+		return nil, err
+	}
+	if req.ResourceName, err = granter.Describe(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Create(ctx, req); err != nil {
+		return nil, err
+	}
+	uc.audit(ctx, req, userID, username, "申请", "申请临时权限："+req.Describe()+"，原因："+req.Reason)
+	return req, nil
+}
+
+// GetByID 获取申请
+func (uc *AccessRequestUseCase) GetByID(ctx context.Context, id uint) (*SysAccessRequest, error) {
+	return uc.repo.GetByID(ctx, id)
+}
+
+// List 分页查询申请
+func (uc *AccessRequestUseCase) List(ctx context.Context, query AccessRequestQuery) ([]*SysAccessRequest, int64, error) {
+	return uc.repo.List(ctx, query)
+}
+
+// Approve 审批通过，立即创建有期限的授权
+// 审批人只能授予自己拥有的权限
+func (uc *AccessRequestUseCase) Approve(ctx context.Context, id, approverID uint, approverName, comment string) (*SysAccessRequest, error) {
+	req, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != AccessRequestPending {
+		return nil, ErrAccessRequestDecided
+	}
+	if req.UserID == approverID {
+		return nil, ErrAccessRequestSelfApproval
+	}
+	granter, err := lookupAccessGranter(req.ResourceType)
+	if err != nil {
+		return nil, err
+	}
+	if err := granter.Authorize(ctx, req, approverID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(req.Duration) * time.Minute)
+	req.ApproverID = approverID
+	req.ApproverName = approverName
+	req.Comment = comment
+	req.ApprovedAt = &now
+	req.ExpiresAt = &expiresAt
+
+	grantID, err := granter.Grant(ctx, req)
+	if err != nil {
+		uc.audit(ctx, req, approverID, approverName, "授权失败", fmt.Sprintf("审批通过临时权限申请#%d，创建授权失败：%v", req.ID, err))
+		return nil, fmt.Errorf("创建授权失败: %w", err)
+	}
+	req.GrantID = grantID
+	req.Status = AccessRequestApproved
+
+	ok, err := uc.repo.UpdateFrom(ctx, req, AccessRequestPending)
+	if err != nil || !ok {
+		// 并发审批时只保留先完成的一次，收回本次创建的授权
+		if revokeErr := granter.Revoke(ctx, req); revokeErr != nil {
+			logger.Error("收回重复创建的临时授权失败", zap.Uint("requestId", req.ID), zap.Error(revokeErr))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrAccessRequestDecided
+	}
+
+	uc.audit(ctx, req, approverID, approverName, "审批通过",
+		fmt.Sprintf("通过 %s 的临时权限申请#%d：%s，%s 到期", req.Username, req.ID, req.Describe(), expiresAt.Format("2006-01-02 15:04")))
+	return req, nil
+}
+
+// Reject 驳回申请
+func (uc *AccessRequestUseCase) Reject(ctx context.Context, id, approverID uint, approverName, comment string) (*SysAccessRequest, error) {
+	req, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != AccessRequestPending {
+		return nil, ErrAccessRequestDecided
+	}
+	if req.UserID == approverID {
+		return nil, ErrAccessRequestSelfApproval
+	}
+
+	now := time.Now()
+	req.Status = AccessRequestRejected
+	req.ApproverID = approverID
+	req.ApproverName = approverName
+	req.Comment = comment
+	req.ApprovedAt = &now
+	if ok, err := uc.repo.UpdateFrom(ctx, req, AccessRequestPending); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrAccessRequestDecided
+	}
+
+	uc.audit(ctx, req, approverID, approverName, "审批驳回", fmt.Sprintf("驳回 %s 的临时权限申请#%d：%s", req.Username, req.ID, comment))
+	return req, nil
+}
+
+// Cancel 申请人撤回申请，已生效的申请立即收回授权
+func (uc *AccessRequestUseCase) Cancel(ctx context.Context, id, userID uint, username string) (*SysAccessRequest, error) {
+	req, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserID != userID {
+		return nil, ErrAccessRequestNotOwner
+	}
+
+	switch req.Status {
+	case AccessRequestPending:
+		req.Status = AccessRequestCancelled
+		if ok, err := uc.repo.UpdateFrom(ctx, req, AccessRequestPending); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, ErrAccessRequestDecided
+		}
+		uc.audit(ctx, req, userID, username, "撤回申请", fmt.Sprintf("撤回临时权限申请#%d", req.ID))
+		return req, nil
+	case AccessRequestApproved:
+		return req, uc.revoke(ctx, req, AccessRequestRevoked, userID, username, "提前归还", "")
+	default:
+		return nil, ErrAccessRequestDecided
+	}
+}
+
+// Revoke 审批人在到期前收回授权
+func (uc *AccessRequestUseCase) Revoke(ctx context.Context, id, operatorID uint, operatorName, comment string) (*SysAccessRequest, error) {
+	req, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != AccessRequestApproved {
+		return nil, ErrAccessRequestNotActive
+	}
+	return req, uc.revoke(ctx, req, AccessRequestRevoked, operatorID, operatorName, "收回授权", comment)
+}
+
+// revoke 删除授权并将申请置为 status
+func (uc *AccessRequestUseCase) revoke(ctx context.Context, req *SysAccessRequest, status string, operatorID uint, operatorName, action, comment string) error {
+	granter, err := lookupAccessGranter(req.ResourceType)
+	if err != nil {
+		return err
+	}
+	if err := granter.Revoke(ctx, req); err != nil {
+		uc.audit(ctx, req, operatorID, operatorName, "回收失败", fmt.Sprintf("回收 %s 的临时权限申请#%d 失败：%v", req.Username, req.ID, err))
+		return fmt.Errorf("回收授权失败: %w", err)
+	}
+
+	now := time.Now()
+	req.Status = status
+	req.RevokedAt = &now
+	req.RevokedBy = operatorName
+	if comment != "" {
+		req.Comment = comment
+	}
+	if ok, err := uc.repo.UpdateFrom(ctx, req, AccessRequestApproved); err != nil {
+		return err
+	} else if !ok {
+		return ErrAccessRequestNotActive
+	}
+
+	description := fmt.Sprintf("回收 %s 的临时权限申请#%d：%s", req.Username, req.ID, req.Describe())
+	if comment != "" {
+		description += "，原因：" + comment
+	}
+	uc.audit(ctx, req, operatorID, operatorName, action, description)
+	return nil
+}
+
+// ReapExpired 回收所有已到期的授权，回收失败的申请保持生效状态，下一轮重试
+func (uc *AccessRequestUseCase) ReapExpired(ctx context.Context) int {
+	expired, err := uc.repo.ListExpired(ctx, time.Now())
+	if err != nil {
+		logger.Error("查询到期的临时授权失败", zap.Error(err))
+		return 0
+	}
+	reaped := 0
+	for _, req := range expired {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := uc.revoke(ctx, req, AccessRequestExpired, 0, "system", "到期回收", ""); err != nil {
+			logger.Error("回收到期的临时授权失败", zap.Uint("requestId", req.ID), zap.Error(err))
+			continue
+		}
+		reaped++
+	}
+	if reaped > 0 {
+		logger.Info("已回收到期的临时授权", zap.Int("count", reaped))
+	}
+	return reaped
+}
+
+// Start 启动到期授权的定时回收
+func (uc *AccessRequestUseCase) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	uc.cancel = cancel
+	uc.done = make(chan struct{})
+	go func() {
+		defer close(uc.done)
+		ticker := time.NewTicker(accessReapInterval)
+		defer ticker.Stop()
+		// 服务停止期间到期的授权在启动时立即回收
+		uc.ReapExpired(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				uc.ReapExpired(ctx)
+			}
+		}
+	}()
+}
+
+// Stop 停止定时回收，等待进行中的一轮结束
+func (uc *AccessRequestUseCase) Stop() {
+	if uc.cancel == nil {
+		return
+	}
+	uc.cancel()
+	<-uc.done
+}
+
+// audit 写入操作审计日志，到期回收等由系统触发的动作不经过HTTP审计中间件，需要单独记录
+func (uc *AccessRequestUseCase) audit(ctx context.Context, req *SysAccessRequest, userID uint, username, action, description string) {
+	if uc.auditRepo == nil {
+		return
+	}
+	params, _ := json.Marshal(map[string]interface{}{
+		"requestId":    req.ID,
+		"userId":       req.UserID,
+		"resourceType": req.ResourceType,
+		"grantId":      req.GrantID,
+	})
+	if len([]rune(description)) > 200 {
+		description = string([]rune(description)[:197]) + "..."
+	}
+	log := &auditbiz.SysOperationLog{
+		UserID:      userID,
+		Username:    username,
+		Module:      "临时权限",
+		Action:      action,
+		Description: description,
+		Path:        fmt.Sprintf("/api/v1/access-requests/%d", req.ID),
+		Params:      string(params),
+		Status:      200,
+		IP:          ClientIPFromContext(ctx),
+	}
+	if err := uc.auditRepo.Create(context.WithoutCancel(ctx), log); err != nil {
+		logger.Error("保存临时权限审计日志失败", zap.Uint("requestId", req.ID), zap.Error(err))
+	}
+}
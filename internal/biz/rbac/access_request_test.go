@@ -0,0 +1,213 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	auditbiz "github.com/ydcloud-dy/opshub/internal/biz/audit"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+)
+
+type memAccessRequestRepo struct {
+	requests map[uint]*SysAccessRequest
+}
+
+func (r *memAccessRequestRepo) Create(_ context.Context, req *SysAccessRequest) error {
+	req.ID = uint(len(r.requests) + 1)
+	r.requests[req.ID] = req
+	return nil
+}
+
+func (r *memAccessRequestRepo) GetByID(_ context.Context, id uint) (*SysAccessRequest, error) {
+	req, ok := r.requests[id]
+	if !ok {
+		return nil, ErrAccessRequestNotFound
+	}
+	copied := *req
+	return &copied, nil
+}
+
+func (r *memAccessRequestRepo) UpdateFrom(_ context.Context, req *SysAccessRequest, fromStatus string) (bool, error) {
+	if r.requests[req.ID].Status != fromStatus {
+		return false, nil
+	}
+	copied := *req
+	r.requests[req.ID] = &copied
+	return true, nil
+}
+
+func (r *memAccessRequestRepo) List(context.Context, AccessRequestQuery) ([]*SysAccessRequest, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *memAccessRequestRepo) ListExpired(_ context.Context, now time.Time) ([]*SysAccessRequest, error) {
+	var expired []*SysAccessRequest
+	for _, req := range r.requests {
+		if req.Status == AccessRequestApproved && req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+			copied := *req
+			expired = append(expired, &copied)
+		}
+	}
+	return expired, nil
+}
+
+type memOperationLogRepo struct {
+	auditbiz.OperationLogRepo
+	actions []string
+}
+
+func (r *memOperationLogRepo) Create(_ context.Context, log *auditbiz.SysOperationLog) error {
+	r.actions = append(r.actions, log.Action)
+	return nil
+}
+
+type fakeAccessGranter struct {
+	granted   map[uint]bool
+	approvers map[uint]bool
+	revokeErr error
+}
+
+func (g *fakeAccessGranter) Describe(context.Context, *SysAccessRequest) (string, error) {
+	return "prod-db", nil
+}
+
+func (g *fakeAccessGranter) Authorize(_ context.Context, _ *SysAccessRequest, approverID uint) error {
+	if !g.approvers[approverID] {
+		return ErrAccessRequestBeyondApprover
+	}
+	return nil
+}
+
+func (g *fakeAccessGranter) Grant(_ context.Context, req *SysAccessRequest) (uint, error) {
+	g.granted[req.ID] = true
+	return req.ID + 100, nil
+}
+
+func (g *fakeAccessGranter) Revoke(_ context.Context, req *SysAccessRequest) error {
+	if g.revokeErr != nil {
+		return g.revokeErr
+	}
+	delete(g.granted, req.ID)
+	return nil
+}
+
+func TestAccessRequestLifecycle(t *testing.T) {
+	logger.Log = zap.NewNop()
+	const resourceType = "test_resource"
+	granter := &fakeAccessGranter{granted: map[uint]bool{}, approvers: map[uint]bool{2: true}}
+	RegisterAccessGranter(resourceType, granter)
+	defer func() {
+		accessGrantersMu.Lock()
+		delete(accessGranters, resourceType)
+		accessGrantersMu.Unlock()
+	}()
+
+	repo := &memAccessRequestRepo{requests: map[uint]*SysAccessRequest{}}
+	audits := &memOperationLogRepo{}
+	uc := NewAccessRequestUseCase(repo, audits)
+	ctx := context.Background()
+
+	t.Run("申请时长超过上限", func(t *testing.T) {
+		_, err := uc.Create(ctx, 1, "alice", &AccessRequestCreateReq{ResourceType: resourceType, Duration: MaxAccessRequestMinutes + 1, Reason: "排查故障"})
+		if err == nil {
+			t.Fatal("Create() 应拒绝超过上限的时长")
+		}
+	})
+
+	req, err := uc.Create(ctx, 1, "alice", &AccessRequestCreateReq{ResourceType: resourceType, Duration: 120, Reason: "排查故障"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if req.Status != AccessRequestPending || req.ResourceName != "prod-db" {
+		t.Fatalf("Create() = %+v", req)
+	}
+
+	t.Run("不能审批自己的申请", func(t *testing.T) {
+		if _, err := uc.Approve(ctx, req.ID, 1, "alice", ""); !errors.Is(err, ErrAccessRequestSelfApproval) {
+			t.Fatalf("Approve() error = %v, want %v", err, ErrAccessRequestSelfApproval)
+		}
+	})
+
+	t.Run("审批人没有申请的权限", func(t *testing.T) {
+		if _, err := uc.Approve(ctx, req.ID, 3, "carol", ""); !errors.Is(err, ErrAccessRequestBeyondApprover) {
+			t.Fatalf("Approve() error = %v, want %v", err, ErrAccessRequestBeyondApprover)
+		}
+		if granter.granted[req.ID] {
+			t.Fatal("审批被拒绝时不应创建授权")
+		}
+	})
+
+	approved, err := uc.Approve(ctx, req.ID, 2, "bob", "同意")
+	if err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if approved.Status != AccessRequestApproved || approved.GrantID != req.ID+100 || !granter.granted[req.ID] {
+		t.Fatalf("Approve() = %+v", approved)
+	}
+	if want := time.Now().Add(2 * time.Hour); approved.ExpiresAt.Sub(want).Abs() > time.Minute {
+		t.Fatalf("ExpiresAt = %v, want about %v", approved.ExpiresAt, want)
+	}
+
+	t.Run("重复审批", func(t *testing.T) {
+		if _, err := uc.Approve(ctx, req.ID, 2, "bob", ""); !errors.Is(err, ErrAccessRequestDecided) {
+			t.Fatalf("Approve() error = %v, want %v", err, ErrAccessRequestDecided)
+		}
+	})
+
+	past := time.Now().Add(-time.Minute)
+	repo.requests[req.ID].ExpiresAt = &past
+
+	t.Run("回收失败时保持生效", func(t *testing.T) {
+		granter.revokeErr = errors.New("集群不可用")
+		defer func() { granter.revokeErr = nil }()
+		if n := uc.ReapExpired(ctx); n != 0 {
+			t.Fatalf("ReapExpired() = %d, want 0", n)
+		}
+		if status := repo.requests[req.ID].Status; status != AccessRequestApproved {
+			t.Fatalf("status = %s, want %s", status, AccessRequestApproved)
+		}
+	})
+
+	t.Run("到期回收", func(t *testing.T) {
+		if n := uc.ReapExpired(ctx); n != 1 {
+			t.Fatalf("ReapExpired() = %d, want 1", n)
+		}
+		stored := repo.requests[req.ID]
+		if stored.Status != AccessRequestExpired || stored.RevokedBy != "system" || granter.granted[req.ID] {
+			t.Fatalf("reaped request = %+v", stored)
+		}
+	})
+
+	want := []string{"申请", "审批通过", "回收失败", "到期回收"}
+	if len(audits.actions) != len(want) {
+		t.Fatalf("audit actions = %v, want %v", audits.actions, want)
+	}
+	for i := range want {
+		if audits.actions[i] != want[i] {
+			t.Fatalf("audit actions = %v, want %v", audits.actions, want)
+		}
+	}
+}
@@ -0,0 +1,423 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 授权规则效果
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// AccessConditions 授权规则的生效条件，未设置的条件不做限制
+type AccessConditions struct {
+	HostTags     []string     `json:"hostTags,omitempty"`     // 主机需同时具有的标签
+	Environments []string     `json:"environments,omitempty"` // 主机环境，取自标签 env=xxx 或 env:xxx，满足任一即可
+	TimeWindows  []TimeWindow `json:"timeWindows,omitempty"`  // 生效时间段，满足任一即可
+	Timezone     string       `json:"timezone,omitempty"`     // 时间段所在时区，IANA 名称如 Asia/Shanghai，为空使用服务器时区
+	SourceCIDRs  []string     `json:"sourceCidrs,omitempty"`  // 来源IP范围，支持单个IP，满足任一即可
+}
+
+// TimeWindow 每周的生效时间段，End 小于 Start 表示跨天
+type TimeWindow struct {
+	Weekdays []int  `json:"weekdays,omitempty"` // 1-7 表示周一到周日，为空表示每天
+	Start    string `json:"start"`              // HH:MM
+	End      string `json:"end"`                // HH:MM
+}
+
+// Value 实现 driver.Valuer 接口
+func (c AccessConditions) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现 sql.Scanner 接口
+func (c *AccessConditions) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		*c = AccessConditions{}
+		return nil
+	}
+	if len(data) == 0 {
+		*c = AccessConditions{}
+		return nil
+	}
+	return json.Unmarshal(data, c)
+}
+
+// Validate 校验条件格式
+func (c AccessConditions) Validate() error {
+	for _, w := range c.TimeWindows {
+		if _, err := parseClock(w.Start); err != nil {
+			return fmt.Errorf("时间段开始时间格式错误: %s", w.Start)
+		}
+		if _, err := parseClock(w.End); err != nil {
+			return fmt.Errorf("时间段结束时间格式错误: %s", w.End)
+		}
+		for _, d := range w.Weekdays {
+			if d < 1 || d > 7 {
+				return fmt.Errorf("星期取值应为1-7: %d", d)
+			}
+		}
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("时区无效: %s", c.Timezone)
+		}
+	}
+	for _, cidr := range c.SourceCIDRs {
+		if _, err := parseCIDR(cidr); err != nil {
+			return fmt.Errorf("来源IP范围格式错误: %s", cidr)
+		}
+	}
+	return nil
+}
+
+// localTime 把访问时间换算到规则时区
+func (c AccessConditions) localTime(t time.Time) (time.Time, error) {
+	if c.Timezone == "" {
+		return t, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return t, err
+	}
+	return t.In(loc), nil
+}
+
+// Contains 判断时间是否落在时间段内
+func (w TimeWindow) Contains(t time.Time) bool {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+
+	if start <= end {
+		return now >= start && now < end && w.onWeekday(t)
+	}
+	// 跨天时间段，凌晨部分属于前一天的时间段
+	if now >= start {
+		return w.onWeekday(t)
+	}
+	return now < end && w.onWeekday(t.AddDate(0, 0, -1))
+}
+
+func (w TimeWindow) onWeekday(t time.Time) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	day := int(t.Weekday())
+	if day == 0 {
+		day = 7
+	}
+	for _, d := range w.Weekdays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func parseClock(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid clock %q", s)
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("invalid clock %q", s)
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid clock %q", s)
+	}
+	return h*60 + m, nil
+}
+
+func parseCIDR(s string) (*net.IPNet, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip %q", s)
+		}
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	return ipNet, err
+}
+
+// HostAttributes 参与规则计算的主机属性
+type HostAttributes struct {
+	ID      uint
+	GroupID uint
+	Tags    string
+}
+
+// TagList 主机标签列表
+func (h HostAttributes) TagList() []string {
+	var tags []string
+	for _, tag := range strings.Split(h.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Environment 主机环境，取自 env=xxx 或 env:xxx 标签
+func (h HostAttributes) Environment() string {
+	for _, tag := range h.TagList() {
+		for _, prefix := range []string{"env=", "env:"} {
+			if strings.HasPrefix(strings.ToLower(tag), prefix) {
+				return strings.TrimSpace(tag[len(prefix):])
+			}
+		}
+	}
+	return ""
+}
+
+// AccessRequest 一次主机操作的访问上下文
+type AccessRequest struct {
+	Operation uint // 0 表示只判断是否可访问主机
+	ClientIP  string
+	Time      time.Time
+}
+
+type clientIPKey struct{}
+
+// ContextWithClientIP 在上下文中记录请求来源IP，供来源IP条件使用
+func ContextWithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFromContext 获取上下文中的请求来源IP，后台任务没有来源IP
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// NewAccessRequest 根据上下文构建访问请求
+func NewAccessRequest(ctx context.Context, operation uint) AccessRequest {
+	return AccessRequest{
+		Operation: operation,
+		ClientIP:  ClientIPFromContext(ctx),
+		Time:      time.Now(),
+	}
+}
+
+// AccessDecision 权限判定结果，RuleID 为决定结果的授权规则
+type AccessDecision struct {
+	Allowed bool   `json:"allowed"`
+	RuleID  uint   `json:"ruleId,omitempty"`
+	RoleID  uint   `json:"roleId,omitempty"`
+	Effect  string `json:"effect,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// IsDeny 是否为拒绝规则
+func (p *SysRoleAssetPermission) IsDeny() bool {
+	return p.Effect == EffectDeny
+}
+
+// Covers 规则是否覆盖该主机（同一分组，且未指定主机或包含该主机）
+func (p *SysRoleAssetPermission) Covers(host HostAttributes) bool {
+	if p.AssetGroupID != host.GroupID {
+		return false
+	}
+	if len(p.HostIDs) == 0 {
+		return true
+	}
+	for _, id := range p.HostIDs {
+		if id == host.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Match 判断规则在本次访问中是否生效，不生效时返回原因
+func (p *SysRoleAssetPermission) Match(host HostAttributes, req AccessRequest) (bool, string) {
+	if p.ExpiresAt != nil && !req.Time.Before(*p.ExpiresAt) {
+		return false, fmt.Sprintf("授权已于 %s 过期", p.ExpiresAt.Format("2006-01-02 15:04"))
+	}
+
+	cond := p.Conditions
+	if len(cond.HostTags) > 0 {
+		tags := make(map[string]bool)
+		for _, tag := range host.TagList() {
+			tags[tag] = true
+		}
+		for _, tag := range cond.HostTags {
+			if !tags[tag] {
+				return false, "主机缺少标签 " + tag
+			}
+		}
+	}
+
+	if len(cond.Environments) > 0 {
+		env := host.Environment()
+		matched := false
+		for _, e := range cond.Environments {
+			if env != "" && strings.EqualFold(e, env) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, "主机环境不在 " + strings.Join(cond.Environments, "/") + " 内"
+		}
+	}
+
+	if len(cond.TimeWindows) > 0 {
+		at, err := cond.localTime(req.Time)
+		if err != nil {
+			return false, "时区无效: " + cond.Timezone
+		}
+		matched := false
+		for _, w := range cond.TimeWindows {
+			if w.Contains(at) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, "当前不在授权时间段内"
+		}
+	}
+
+	if len(cond.SourceCIDRs) > 0 {
+		ip := net.ParseIP(req.ClientIP)
+		matched := false
+		for _, cidr := range cond.SourceCIDRs {
+			if ipNet, err := parseCIDR(cidr); err == nil && ip != nil && ipNet.Contains(ip) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, "来源IP " + req.ClientIP + " 不在授权范围内"
+		}
+	}
+
+	return true, ""
+}
+
+// EvaluateHostAccess 按用户角色的授权规则判定对主机的操作
+// 生效的拒绝规则优先；否则取第一条生效的允许规则；都不生效时返回第一条未生效允许规则的原因
+func EvaluateHostAccess(rules []*SysRoleAssetPermission, host HostAttributes, req AccessRequest) *AccessDecision {
+	// 仅判断可访问时，拒绝规则按查看权限计算
+	denyMask := req.Operation
+	if denyMask == 0 {
+		denyMask = PermissionView
+	}
+
+	for _, rule := range rules {
+		if !rule.IsDeny() || !rule.Covers(host) || !rule.HasPermission(denyMask) {
+			continue
+		}
+		if ok, _ := rule.Match(host, req); ok {
+			return ruleDecision(rule, false, "命中拒绝规则")
+		}
+	}
+
+	var failed *SysRoleAssetPermission
+	var failedReason string
+	for _, rule := range rules {
+		if rule.IsDeny() || !rule.Covers(host) {
+			continue
+		}
+		if req.Operation != 0 && !rule.HasPermission(req.Operation) {
+			continue
+		}
+		ok, reason := rule.Match(host, req)
+		if ok {
+			return ruleDecision(rule, true, "命中授权规则")
+		}
+		if failed == nil {
+			failed, failedReason = rule, reason
+		}
+	}
+
+	if failed != nil {
+		return ruleDecision(failed, false, failedReason)
+	}
+	return &AccessDecision{Allowed: false, Reason: "没有该主机的操作授权"}
+}
+
+// EffectiveHostPermissions 计算用户对主机当前生效的操作权限位
+func EffectiveHostPermissions(rules []*SysRoleAssetPermission, host HostAttributes, req AccessRequest) uint {
+	var allowed, denied uint
+	for _, rule := range rules {
+		if !rule.Covers(host) {
+			continue
+		}
+		if ok, _ := rule.Match(host, req); !ok {
+			continue
+		}
+		if rule.IsDeny() {
+			denied |= rule.Permissions
+		} else {
+			allowed |= rule.Permissions
+		}
+	}
+	return allowed &^ denied
+}
+
+func ruleDecision(rule *SysRoleAssetPermission, allowed bool, reason string) *AccessDecision {
+	effect := rule.Effect
+	if effect == "" {
+		effect = EffectAllow
+	}
+	return &AccessDecision{
+		Allowed: allowed,
+		RuleID:  rule.ID,
+		RoleID:  rule.RoleID,
+		Effect:  effect,
+		Reason:  fmt.Sprintf("%s（规则#%d）", reason, rule.ID),
+	}
+}
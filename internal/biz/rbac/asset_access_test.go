@@ -0,0 +1,213 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeWindowContains(t *testing.T) {
+	// 2024-01-01 是周一
+	monday := func(clock string) time.Time {
+		ts, _ := time.ParseInLocation("2006-01-02 15:04", "2024-01-01 "+clock, time.Local)
+		return ts
+	}
+
+	tests := []struct {
+		name   string
+		window TimeWindow
+		at     time.Time
+		want   bool
+	}{
+		{"工作时间内", TimeWindow{Start: "09:00", End: "18:00"}, monday("10:30"), true},
+		{"结束时间不包含", TimeWindow{Start: "09:00", End: "18:00"}, monday("18:00"), false},
+		{"跨天晚间部分", TimeWindow{Start: "22:00", End: "06:00"}, monday("23:10"), true},
+		{"跨天凌晨部分", TimeWindow{Start: "22:00", End: "06:00"}, monday("05:59"), true},
+		{"跨天之外", TimeWindow{Start: "22:00", End: "06:00"}, monday("12:00"), false},
+		{"星期匹配", TimeWindow{Weekdays: []int{1}, Start: "09:00", End: "18:00"}, monday("10:00"), true},
+		{"星期不匹配", TimeWindow{Weekdays: []int{6, 7}, Start: "09:00", End: "18:00"}, monday("10:00"), false},
+		{"凌晨属于前一天的时间段", TimeWindow{Weekdays: []int{7}, Start: "22:00", End: "06:00"}, monday("01:00"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Contains(tt.at); got != tt.want {
+				t.Errorf("Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateHostAccess(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	host := HostAttributes{ID: 10, GroupID: 1, Tags: "web,env=prod"}
+
+	tests := []struct {
+		name   string
+		rules  []*SysRoleAssetPermission
+		req    AccessRequest
+		allow  bool
+		ruleID uint
+		reason string
+	}{
+		{
+			name:   "没有规则",
+			req:    AccessRequest{Operation: PermissionTerminal, Time: now},
+			reason: "没有该主机的操作授权",
+		},
+		{
+			name:   "整个分组授权",
+			rules:  []*SysRoleAssetPermission{{ID: 1, AssetGroupID: 1, Permissions: PermissionAll}},
+			req:    AccessRequest{Operation: PermissionTerminal, Time: now},
+			allow:  true,
+			ruleID: 1,
+		},
+		{
+			name:   "未授权的操作",
+			rules:  []*SysRoleAssetPermission{{ID: 1, AssetGroupID: 1, Permissions: PermissionView}},
+			req:    AccessRequest{Operation: PermissionTerminal, Time: now},
+			reason: "没有该主机的操作授权",
+		},
+		{
+			name: "拒绝规则优先",
+			rules: []*SysRoleAssetPermission{
+				{ID: 1, AssetGroupID: 1, Permissions: PermissionAll},
+				{ID: 2, AssetGroupID: 1, HostIDs: UintArray{10}, Permissions: PermissionTerminal, Effect: EffectDeny},
+			},
+			req:    AccessRequest{Operation: PermissionTerminal, Time: now},
+			ruleID: 2,
+			reason: "命中拒绝规则",
+		},
+		{
+			name: "拒绝规则不影响其他操作",
+			rules: []*SysRoleAssetPermission{
+				{ID: 1, AssetGroupID: 1, Permissions: PermissionAll},
+				{ID: 2, AssetGroupID: 1, Permissions: PermissionTerminal, Effect: EffectDeny},
+			},
+			req:    AccessRequest{Operation: PermissionFile, Time: now},
+			allow:  true,
+			ruleID: 1,
+		},
+		{
+			name:   "临时授权已过期",
+			rules:  []*SysRoleAssetPermission{{ID: 3, AssetGroupID: 1, Permissions: PermissionAll, ExpiresAt: &past}},
+			req:    AccessRequest{Operation: PermissionView, Time: now},
+			ruleID: 3,
+			reason: "过期",
+		},
+		{
+			name:   "临时授权有效期内",
+			rules:  []*SysRoleAssetPermission{{ID: 3, AssetGroupID: 1, Permissions: PermissionAll, ExpiresAt: &future}},
+			req:    AccessRequest{Operation: PermissionView, Time: now},
+			allow:  true,
+			ruleID: 3,
+		},
+		{
+			name: "来源IP在网段内",
+			rules: []*SysRoleAssetPermission{{ID: 4, AssetGroupID: 1, Permissions: PermissionAll,
+				Conditions: AccessConditions{SourceCIDRs: []string{"10.0.0.0/8"}}}},
+			req:    AccessRequest{Operation: PermissionTerminal, ClientIP: "10.1.2.3", Time: now},
+			allow:  true,
+			ruleID: 4,
+		},
+		{
+			name: "来源IP不在网段内",
+			rules: []*SysRoleAssetPermission{{ID: 4, AssetGroupID: 1, Permissions: PermissionAll,
+				Conditions: AccessConditions{SourceCIDRs: []string{"10.0.0.0/8", "192.168.1.5"}}}},
+			req:    AccessRequest{Operation: PermissionTerminal, ClientIP: "172.16.0.1", Time: now},
+			ruleID: 4,
+			reason: "来源IP",
+		},
+		{
+			name: "主机标签和环境匹配",
+			rules: []*SysRoleAssetPermission{{ID: 5, AssetGroupID: 1, Permissions: PermissionAll,
+				Conditions: AccessConditions{HostTags: []string{"web"}, Environments: []string{"PROD"}}}},
+			req:    AccessRequest{Operation: PermissionView, Time: now},
+			allow:  true,
+			ruleID: 5,
+		},
+		{
+			name: "主机环境不匹配时使用其他规则",
+			rules: []*SysRoleAssetPermission{
+				{ID: 5, AssetGroupID: 1, Permissions: PermissionAll, Conditions: AccessConditions{Environments: []string{"test"}}},
+				{ID: 6, AssetGroupID: 1, Permissions: PermissionView},
+			},
+			req:    AccessRequest{Operation: PermissionView, Time: now},
+			allow:  true,
+			ruleID: 6,
+		},
+		{
+			name: "按规则时区判断时间段",
+			rules: []*SysRoleAssetPermission{{ID: 8, AssetGroupID: 1, Permissions: PermissionAll,
+				Conditions: AccessConditions{TimeWindows: []TimeWindow{{Start: "09:00", End: "18:00"}}, Timezone: "Asia/Tokyo"}}},
+			// UTC 01:30 为东京 10:30
+			req:    AccessRequest{Operation: PermissionView, Time: time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC)},
+			allow:  true,
+			ruleID: 8,
+		},
+		{
+			name: "规则时区不在时间段内",
+			rules: []*SysRoleAssetPermission{{ID: 8, AssetGroupID: 1, Permissions: PermissionAll,
+				Conditions: AccessConditions{TimeWindows: []TimeWindow{{Start: "09:00", End: "18:00"}}, Timezone: "America/New_York"}}},
+			// UTC 01:30 为纽约前一天 20:30
+			req:    AccessRequest{Operation: PermissionView, Time: time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC)},
+			ruleID: 8,
+			reason: "当前不在授权时间段内",
+		},
+		{
+			name:   "其他分组的规则",
+			rules:  []*SysRoleAssetPermission{{ID: 7, AssetGroupID: 2, Permissions: PermissionAll}},
+			req:    AccessRequest{Operation: PermissionView, Time: now},
+			reason: "没有该主机的操作授权",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EvaluateHostAccess(tt.rules, host, tt.req)
+			if got.Allowed != tt.allow {
+				t.Errorf("Allowed = %v, want %v (%s)", got.Allowed, tt.allow, got.Reason)
+			}
+			if got.RuleID != tt.ruleID {
+				t.Errorf("RuleID = %d, want %d", got.RuleID, tt.ruleID)
+			}
+			if !strings.Contains(got.Reason, tt.reason) {
+				t.Errorf("Reason = %q, want contains %q", got.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestEffectiveHostPermissions(t *testing.T) {
+	host := HostAttributes{ID: 10, GroupID: 1}
+	rules := []*SysRoleAssetPermission{
+		{ID: 1, AssetGroupID: 1, Permissions: PermissionView | PermissionTerminal | PermissionFile},
+		{ID: 2, AssetGroupID: 1, Permissions: PermissionFile, Effect: EffectDeny},
+	}
+
+	got := EffectiveHostPermissions(rules, host, AccessRequest{Time: time.Now()})
+	if want := uint(PermissionView | PermissionTerminal); got != want {
+		t.Errorf("EffectiveHostPermissions() = %d, want %d", got, want)
+	}
+}
@@ -29,13 +29,15 @@ import (
 
 // Permission constants - using bitmask
 const (
-	PermissionView     = 1 << 0  // 1 (查看)
-	PermissionEdit     = 1 << 1  // 2 (编辑)
-	PermissionDelete   = 1 << 2  // 4 (删除)
-	PermissionTerminal = 1 << 3  // 8 (终端)
-	PermissionFile     = 1 << 4  // 16 (文件管理)
-	PermissionCollect  = 1 << 5  // 32 (采集信息)
-	PermissionAll      = 0x3F    // 63 (所有权限)
+	PermissionView       = 1 << 0 // 1 (查看)
+	PermissionEdit       = 1 << 1 // 2 (编辑)
+	PermissionDelete     = 1 << 2 // 4 (删除)
+	PermissionTerminal   = 1 << 3 // 8 (终端)
+	PermissionFile       = 1 << 4 // 16 (文件管理)
+	PermissionCollect    = 1 << 5 // 32 (采集信息)
+	PermissionExecute    = 1 << 6 // 64 (执行任务)
+	PermissionDistribute = 1 << 7 // 128 (文件分发)
+	PermissionAll        = 0xFF   // 255 (所有权限)
 )
 
 // UintArray 用于处理JSON格式的uint数组
@@ -63,9 +65,14 @@ type SysRoleAssetPermission struct {
 	UpdatedAt    time.Time      `json:"updatedAt"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 	RoleID       uint           `gorm:"not null;index:idx_role_asset" json:"roleId"`        // 角色ID
+	UserID       uint           `gorm:"default:0;index;comment:用户ID，不为0时为该用户的临时授权" json:"userId"` // 用户ID（临时权限申请创建的个人授权，此时角色ID为0）
 	AssetGroupID uint           `gorm:"not null;index:idx_role_asset" json:"assetGroupId"` // 资产分组ID
 	HostIDs      UintArray      `gorm:"type:json" json:"hostIds"`                          // 主机ID列表（为空表示整个分组）
-	Permissions  uint           `gorm:"type:int unsigned;default:1;comment:操作权限位掩码：1=查看,2=编辑,4=删除,8=终端,16=文件,32=采集;index" json:"permissions"`
+	Permissions  uint           `gorm:"type:int unsigned;default:1;comment:操作权限位掩码：1=查看,2=编辑,4=删除,8=终端,16=文件,32=采集,64=执行任务,128=文件分发;index" json:"permissions"`
+	Effect       string           `gorm:"type:varchar(10);default:'allow';comment:规则效果 allow:允许 deny:拒绝" json:"effect"`
+	Conditions   AccessConditions `gorm:"type:json;comment:生效条件(主机标签/环境/时间段/来源IP)" json:"conditions"`
+	ExpiresAt    *time.Time       `gorm:"index;comment:过期时间，为空表示长期有效" json:"expiresAt"`
+	Remark       string           `gorm:"type:varchar(200);comment:备注" json:"remark"`
 }
 
 // TableName 指定表名
@@ -79,12 +86,18 @@ type AssetPermissionInfo struct {
 	RoleID         uint      `json:"roleId"`
 	RoleName       string    `json:"roleName"`
 	RoleCode       string    `json:"roleCode"`
+	UserID         uint      `json:"userId"`
+	Username       string    `json:"username"`
 	AssetGroupID   uint      `json:"assetGroupId"`
 	AssetGroupName string    `json:"assetGroupName"`
 	HostIDs        []uint    `json:"hostIds"`        // 主机ID列表（为空表示整个分组）
 	HostNames      []string  `json:"hostNames,omitempty"` // 主机名称列表
 	IsAllHosts     bool      `json:"isAllHosts"`    // 是否授权所有主机
 	Permissions    uint      `json:"permissions"`
+	Effect         string           `json:"effect"`
+	Conditions     AccessConditions `json:"conditions"`
+	ExpiresAt      *time.Time       `json:"expiresAt"`
+	Remark         string           `json:"remark"`
 	CreatedAt      time.Time `json:"createdAt"`
 }
 
@@ -107,6 +120,28 @@ type AssetPermissionCreateReqWithPermissions struct {
 	AssetGroupID uint   `json:"assetGroupId" binding:"required"`
 	HostIDs     []uint `json:"hostIds"` // 空数组表示整个分组，非空表示指定主机
 	Permissions uint   `json:"permissions"`
+	Effect      string           `json:"effect" binding:"omitempty,oneof=allow deny"` // 默认 allow
+	Conditions  AccessConditions `json:"conditions"`
+	ExpiresAt   *time.Time       `json:"expiresAt"`
+	Remark      string           `json:"remark" binding:"max=200"`
+}
+
+// ToRule 转换为授权规则
+func (req *AssetPermissionCreateReqWithPermissions) ToRule() *SysRoleAssetPermission {
+	effect := req.Effect
+	if effect == "" {
+		effect = EffectAllow
+	}
+	return &SysRoleAssetPermission{
+		RoleID:       req.RoleID,
+		AssetGroupID: req.AssetGroupID,
+		HostIDs:      req.HostIDs,
+		Permissions:  req.Permissions,
+		Effect:       effect,
+		Conditions:   req.Conditions,
+		ExpiresAt:    req.ExpiresAt,
+		Remark:       req.Remark,
+	}
 }
 
 // AssetPermissionDetailVO 资产权限详情（用于编辑）
@@ -118,6 +153,10 @@ type AssetPermissionDetailVO struct {
 	AssetGroupName string   `json:"assetGroupName"`
 	HostIDs       []uint    `json:"hostIds"`       // 指定的主机ID列表（为空表示全部）
 	Permissions   uint      `json:"permissions"`
+	Effect        string           `json:"effect"`
+	Conditions    AccessConditions `json:"conditions"`
+	ExpiresAt     *time.Time       `json:"expiresAt"`
+	Remark        string           `json:"remark"`
 	CreatedAt     time.Time `json:"createdAt"`
 }
 
@@ -151,6 +190,10 @@ func GetPermissionName(perm uint) string {
 		return "文件管理"
 	case PermissionCollect:
 		return "采集信息"
+	case PermissionExecute:
+		return "执行任务"
+	case PermissionDistribute:
+		return "文件分发"
 	default:
 		return "未知"
 	}
@@ -177,6 +220,12 @@ func GetAllPermissionNames(permissions uint) []string {
 	if (permissions & PermissionCollect) > 0 {
 		names = append(names, "采集信息")
 	}
+	if (permissions & PermissionExecute) > 0 {
+		names = append(names, "执行任务")
+	}
+	if (permissions & PermissionDistribute) > 0 {
+		names = append(names, "文件分发")
+	}
 	return names
 }
 
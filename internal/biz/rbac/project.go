@@ -0,0 +1,371 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultProjectID 默认项目，升级前的存量资源和未选择项目时新建的资源归属该项目
+const DefaultProjectID uint = 1
+
+// ProjectHeader 前端通过该请求头传递当前项目，WebSocket 连接使用 projectId 查询参数
+const ProjectHeader = "X-Project-ID"
+
+var (
+	// ErrProjectNotFound 项目不存在
+	ErrProjectNotFound = errors.New("项目不存在")
+	// ErrProjectForbidden 用户不是项目成员
+	ErrProjectForbidden = errors.New("无权访问该项目")
+	// ErrDefaultProjectProtected 默认项目不能删除
+	ErrDefaultProjectProtected = errors.New("默认项目不能删除")
+	// ErrProjectNotEmpty 项目下仍有资源
+	ErrProjectNotEmpty = errors.New("项目下仍有资源，请先迁移到其他项目")
+	// ErrProjectResourceUnsupported 资源类型未登记
+	ErrProjectResourceUnsupported = errors.New("不支持的资源类型")
+)
+
+// SysProject 项目（租户）
+// 主机、集群、证书等资源归属于项目，成员只能看到当前项目的资源，平台管理员可以看到全部
+type SysProject struct {
+	gorm.Model
+	Name        string `gorm:"type:varchar(100);not null;comment:项目名称" json:"name"`
+	Code        string `gorm:"type:varchar(50);uniqueIndex;not null;comment:项目编码" json:"code"`
+	Description string `gorm:"type:varchar(500);comment:项目描述" json:"description"`
+	Status      int    `gorm:"type:tinyint;default:1;comment:状态 1:启用 0:禁用" json:"status"`
+	MemberCount int64  `gorm:"-" json:"memberCount"`
+}
+
+func (SysProject) TableName() string {
+	return "sys_project"
+}
+
+// SysProjectMember 项目成员
+// RoleID 为成员在该项目中的角色，与用户的全局角色叠加生效，为 0 表示只是成员
+type SysProjectMember struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	ProjectID uint      `gorm:"not null;uniqueIndex:uk_project_user;comment:项目ID" json:"projectId"`
+	UserID    uint      `gorm:"not null;uniqueIndex:uk_project_user;index;comment:用户ID" json:"userId"`
+	RoleID    uint      `gorm:"default:0;comment:项目角色ID" json:"roleId"`
+}
+
+func (SysProjectMember) TableName() string {
+	return "sys_project_member"
+}
+
+// ProjectRequest 创建/更新项目请求
+type ProjectRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=100"`
+	Code        string `json:"code" binding:"required,min=2,max=50"`
+	Description string `json:"description" binding:"max=500"`
+	Status      *int   `json:"status"`
+}
+
+// ProjectMemberRequest 添加/修改项目成员请求
+type ProjectMemberRequest struct {
+	UserIDs []uint `json:"userIds" binding:"required,min=1"`
+	RoleID  uint   `json:"roleId"`
+}
+
+// ProjectAssignRequest 迁移资源到项目的请求
+type ProjectAssignRequest struct {
+	ResourceType string `json:"resourceType" binding:"required"`
+	IDs          []uint `json:"ids" binding:"required,min=1"`
+}
+
+// ProjectMemberInfo 项目成员信息
+type ProjectMemberInfo struct {
+	ID        uint      `json:"id"`
+	ProjectID uint      `json:"projectId"`
+	UserID    uint      `json:"userId"`
+	Username  string    `json:"username"`
+	RealName  string    `json:"realName"`
+	RoleID    uint      `json:"roleId"`
+	RoleName  string    `json:"roleName"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ProjectResourceCount 项目下某类资源的数量
+type ProjectResourceCount struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// ProjectScope 当前请求可见的项目范围，由认证中间件写入请求上下文
+// 数据层据此为带 ProjectID 字段的模型自动追加过滤条件，并为新建的资源填充项目
+type ProjectScope struct {
+	All        bool   // 平台管理员未选择项目时可见全部项目
+	ProjectIDs []uint // 可见的项目
+	CurrentID  uint   // 新建资源归属的项目，为 0 时使用默认项目
+}
+
+// Allows 是否可见指定项目的资源
+func (s *ProjectScope) Allows(projectID uint) bool {
+	if s == nil || s.All {
+		return true
+	}
+	for _, id := range s.ProjectIDs {
+		if id == projectID {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveProjectScope 按请求的项目计算可见范围，requested 为 0 表示未选择项目
+// 平台管理员未选择项目时可见全部，普通用户未选择项目时使用加入的第一个项目
+func (p *UserPermissions) ResolveProjectScope(requested uint) (*ProjectScope, error) {
+	if p.Admin {
+		if requested == 0 {
+			return &ProjectScope{All: true}, nil
+		}
+		return &ProjectScope{ProjectIDs: []uint{requested}, CurrentID: requested}, nil
+	}
+	if requested == 0 {
+		if len(p.Projects) == 0 {
+			return &ProjectScope{}, nil
+		}
+		requested = p.Projects[0]
+	}
+	for _, id := range p.Projects {
+		if id == requested {
+			return &ProjectScope{ProjectIDs: []uint{requested}, CurrentID: requested}, nil
+		}
+	}
+	return nil, ErrProjectForbidden
+}
+
+type projectScopeKey struct{}
+
+// ContextWithProjectScope 在上下文中记录项目范围
+func ContextWithProjectScope(ctx context.Context, scope *ProjectScope) context.Context {
+	return context.WithValue(ctx, projectScopeKey{}, scope)
+}
+
+// ProjectScopeFromContext 获取上下文中的项目范围，后台任务等没有范围的上下文返回 nil，不做过滤
+func ProjectScopeFromContext(ctx context.Context) *ProjectScope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(projectScopeKey{}).(*ProjectScope)
+	return scope
+}
+
+// WithoutProjectScope 去掉项目范围，用于需要跨项目查询的内部逻辑
+func WithoutProjectScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, projectScopeKey{}, (*ProjectScope)(nil))
+}
+
+// ProjectResource 按项目隔离的资源
+type ProjectResource struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Table string `json:"-"`
+}
+
+var projectResources = struct {
+	sync.RWMutex
+	items map[string]ProjectResource
+}{items: make(map[string]ProjectResource)}
+
+// RegisterProjectResource 登记按项目隔离的资源，核心模块和插件在初始化时调用
+// 资源表需要有 project_id 列，登记后可以统计项目下的资源数量并在项目间迁移
+func RegisterProjectResource(resources ...ProjectResource) {
+	projectResources.Lock()
+	defer projectResources.Unlock()
+	for _, res := range resources {
+		projectResources.items[res.Type] = res
+	}
+}
+
+// ProjectResources 获取已登记的资源，按类型排序
+func ProjectResources() []ProjectResource {
+	projectResources.RLock()
+	list := make([]ProjectResource, 0, len(projectResources.items))
+	for _, res := range projectResources.items {
+		list = append(list, res)
+	}
+	projectResources.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Type < list[j].Type })
+	return list
+}
+
+func lookupProjectResource(resourceType string) (ProjectResource, error) {
+	projectResources.RLock()
+	defer projectResources.RUnlock()
+	res, ok := projectResources.items[resourceType]
+	if !ok {
+		return ProjectResource{}, ErrProjectResourceUnsupported
+	}
+	return res, nil
+}
+
+// ProjectUseCase 项目用例
+type ProjectUseCase struct {
+	projectRepo ProjectRepo
+	permCache   *PermissionCache
+}
+
+func NewProjectUseCase(projectRepo ProjectRepo) *ProjectUseCase {
+	return &ProjectUseCase{
+		projectRepo: projectRepo,
+	}
+}
+
+// SetPermissionCache 设置权限缓存，项目成员变更时清除
+func (uc *ProjectUseCase) SetPermissionCache(cache *PermissionCache) {
+	uc.permCache = cache
+}
+
+// Create 创建项目
+func (uc *ProjectUseCase) Create(ctx context.Context, req *ProjectRequest) (*SysProject, error) {
+	project := &SysProject{
+		Name:        req.Name,
+		Code:        req.Code,
+		Description: req.Description,
+		Status:      1,
+	}
+	if req.Status != nil {
+		project.Status = *req.Status
+	}
+	if err := uc.projectRepo.Create(ctx, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// Update 更新项目，禁用项目后成员不能再切换到该项目
+func (uc *ProjectUseCase) Update(ctx context.Context, id uint, req *ProjectRequest) (*SysProject, error) {
+	project, err := uc.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	project.Name = req.Name
+	project.Code = req.Code
+	project.Description = req.Description
+	if req.Status != nil {
+		project.Status = *req.Status
+	}
+	if err := uc.projectRepo.Update(ctx, project); err != nil {
+		return nil, err
+	}
+	uc.permCache.Invalidate()
+	return project, nil
+}
+
+// Delete 删除项目，项目下仍有资源时拒绝删除
+func (uc *ProjectUseCase) Delete(ctx context.Context, id uint) error {
+	if id == DefaultProjectID {
+		return ErrDefaultProjectProtected
+	}
+	counts, err := uc.ResourceCounts(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, count := range counts {
+		if count.Count > 0 {
+			return ErrProjectNotEmpty
+		}
+	}
+	if err := uc.projectRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	uc.permCache.Invalidate()
+	return nil
+}
+
+// GetByID 获取项目
+func (uc *ProjectUseCase) GetByID(ctx context.Context, id uint) (*SysProject, error) {
+	return uc.projectRepo.GetByID(ctx, id)
+}
+
+// List 获取全部项目
+func (uc *ProjectUseCase) List(ctx context.Context, keyword string) ([]*SysProject, error) {
+	return uc.projectRepo.List(ctx, keyword)
+}
+
+// ListByUserID 获取用户加入的启用项目
+func (uc *ProjectUseCase) ListByUserID(ctx context.Context, userID uint) ([]*SysProject, error) {
+	return uc.projectRepo.ListByUserID(ctx, userID)
+}
+
+// ListMembers 获取项目成员
+func (uc *ProjectUseCase) ListMembers(ctx context.Context, projectID uint) ([]*ProjectMemberInfo, error) {
+	return uc.projectRepo.ListMembers(ctx, projectID)
+}
+
+// SaveMembers 添加成员或修改成员的项目角色
+func (uc *ProjectUseCase) SaveMembers(ctx context.Context, projectID uint, req *ProjectMemberRequest) error {
+	if _, err := uc.projectRepo.GetByID(ctx, projectID); err != nil {
+		return err
+	}
+	for _, userID := range req.UserIDs {
+		member := &SysProjectMember{ProjectID: projectID, UserID: userID, RoleID: req.RoleID}
+		if err := uc.projectRepo.SaveMember(ctx, member); err != nil {
+			return err
+		}
+		uc.permCache.InvalidateUser(userID)
+	}
+	return nil
+}
+
+// RemoveMember 移除项目成员
+func (uc *ProjectUseCase) RemoveMember(ctx context.Context, projectID, userID uint) error {
+	if err := uc.projectRepo.RemoveMember(ctx, projectID, userID); err != nil {
+		return err
+	}
+	uc.permCache.InvalidateUser(userID)
+	return nil
+}
+
+// ResourceCounts 统计项目下各类资源的数量
+func (uc *ProjectUseCase) ResourceCounts(ctx context.Context, projectID uint) ([]ProjectResourceCount, error) {
+	resources := ProjectResources()
+	counts := make([]ProjectResourceCount, 0, len(resources))
+	for _, res := range resources {
+		count, err := uc.projectRepo.CountResources(ctx, projectID, res.Table)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, ProjectResourceCount{Type: res.Type, Name: res.Name, Count: count})
+	}
+	return counts, nil
+}
+
+// AssignResources 将资源迁移到项目
+func (uc *ProjectUseCase) AssignResources(ctx context.Context, projectID uint, req *ProjectAssignRequest) (int64, error) {
+	res, err := lookupProjectResource(req.ResourceType)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := uc.projectRepo.GetByID(ctx, projectID); err != nil {
+		return 0, err
+	}
+	return uc.projectRepo.AssignResources(ctx, projectID, res.Table, req.IDs)
+}
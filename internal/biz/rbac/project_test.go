@@ -0,0 +1,97 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveProjectScope(t *testing.T) {
+	admin := &UserPermissions{Admin: true}
+	member := &UserPermissions{Projects: []uint{2, 5}}
+	outsider := &UserPermissions{}
+
+	tests := []struct {
+		name      string
+		perms     *UserPermissions
+		requested uint
+		want      *ProjectScope
+		wantErr   error
+	}{
+		{"平台管理员未选择项目可见全部", admin, 0, &ProjectScope{All: true}, nil},
+		{"平台管理员选择任意项目", admin, 9, &ProjectScope{ProjectIDs: []uint{9}, CurrentID: 9}, nil},
+		{"成员未选择项目使用第一个项目", member, 0, &ProjectScope{ProjectIDs: []uint{2}, CurrentID: 2}, nil},
+		{"成员选择加入的项目", member, 5, &ProjectScope{ProjectIDs: []uint{5}, CurrentID: 5}, nil},
+		{"成员选择未加入的项目", member, 3, nil, ErrProjectForbidden},
+		{"未加入任何项目", outsider, 0, &ProjectScope{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.perms.ResolveProjectScope(tt.requested)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResolveProjectScope() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveProjectScope() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectScopeContext(t *testing.T) {
+	ctx := ContextWithProjectScope(context.Background(), &ProjectScope{ProjectIDs: []uint{2}, CurrentID: 2})
+	scope := ProjectScopeFromContext(ctx)
+	if scope == nil || !scope.Allows(2) || scope.Allows(3) {
+		t.Fatalf("ProjectScopeFromContext() = %+v, want scope of project 2", scope)
+	}
+	if got := ProjectScopeFromContext(WithoutProjectScope(ctx)); got != nil {
+		t.Errorf("WithoutProjectScope() scope = %+v, want nil", got)
+	}
+	if got := ProjectScopeFromContext(context.Background()); !got.Allows(3) {
+		t.Errorf("nil scope should allow every project")
+	}
+}
+
+func TestPermissionCacheProjectRoles(t *testing.T) {
+	menus := &fakeMenuRepo{codes: map[uint][]string{7: {"hosts"}}}
+	cache := NewPermissionCache(&fakeRoleRepo{}, menus)
+
+	ctx := ContextWithProjectScope(context.Background(), &ProjectScope{ProjectIDs: []uint{2}, CurrentID: 2})
+	if _, err := cache.Get(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Get(context.Background(), 7); err != nil {
+		t.Fatal(err)
+	}
+	if menus.loads != 2 {
+		t.Errorf("不同项目应分别缓存，加载次数 = %d, want 2", menus.loads)
+	}
+
+	cache.InvalidateUser(7)
+	if _, err := cache.Get(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	if menus.loads != 3 {
+		t.Errorf("InvalidateUser 应清除该用户所有项目的缓存，加载次数 = %d, want 3", menus.loads)
+	}
+}
@@ -19,11 +19,15 @@
 
 package rbac
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type UserRepo interface {
 	Create(ctx context.Context, user *SysUser) error
 	Update(ctx context.Context, user *SysUser) error
+	UpdateProfile(ctx context.Context, user *SysUser) error
 	Delete(ctx context.Context, id uint) error
 	GetByID(ctx context.Context, id uint) (*SysUser, error)
 	GetByUsername(ctx context.Context, username string) (*SysUser, error)
@@ -61,6 +65,8 @@ type MenuRepo interface {
 	GetTree(ctx context.Context) ([]*SysMenu, error)
 	GetByUserID(ctx context.Context, userID uint) ([]*SysMenu, error)
 	GetByRoleID(ctx context.Context, roleID uint) ([]*SysMenu, error)
+	GetByCode(ctx context.Context, code string) (*SysMenu, error)
+	GetCodesByUserID(ctx context.Context, userID uint) ([]string, error)
 }
 
 type PositionRepo interface {
@@ -78,16 +84,16 @@ type PositionRepo interface {
 type AssetPermissionRepo interface {
 	// 创建资产权限（批量）
 	CreateBatch(ctx context.Context, roleID, assetGroupID uint, hostIDs []uint) error
-	// 创建资产权限（支持操作权限）
-	CreateBatchWithPermissions(ctx context.Context, roleID, assetGroupID uint, hostIDs []uint, permissions uint) error
+	// 创建授权规则（支持操作权限、生效效果和访问条件）
+	CreateRule(ctx context.Context, rule *SysRoleAssetPermission) error
 	// 删除指定角色对指定资产分组的所有权限
 	DeleteByRoleAndGroup(ctx context.Context, roleID, assetGroupID uint) error
 	// 删除单个权限
 	Delete(ctx context.Context, id uint) error
 	// 根据ID获取权限详情（用于编辑）
 	GetDetailByID(ctx context.Context, id uint) (*AssetPermissionDetailVO, error)
-	// 更新权限配置（支持修改角色、分组、主机、权限）
-	UpdateAssetPermission(ctx context.Context, id uint, roleID, assetGroupID uint, hostIDs []uint, permissions uint) error
+	// 更新授权规则（支持修改角色、分组、主机、权限和条件）
+	UpdateRule(ctx context.Context, id uint, rule *SysRoleAssetPermission) error
 	// 获取角色的所有资产权限
 	GetByRoleID(ctx context.Context, roleID uint) ([]*AssetPermissionInfo, error)
 	// 获取资产分组的所有权限配置
@@ -96,10 +102,39 @@ type AssetPermissionRepo interface {
 	List(ctx context.Context, page, pageSize int, roleID, assetGroupID *uint) ([]*AssetPermissionInfo, int64, error)
 	// 检查用户是否有访问指定主机的权限
 	CheckHostPermission(ctx context.Context, userID, hostID uint) (bool, error)
-	// 检查用户是否有对指定主机的特定操作权限
-	CheckHostOperationPermission(ctx context.Context, userID, hostID uint, operation uint) (bool, error)
+	// 判定用户对指定主机的特定操作，返回决定结果的规则
+	CheckHostOperationPermission(ctx context.Context, userID, hostID uint, operation uint) (*AccessDecision, error)
 	// 获取用户对指定主机的所有操作权限
 	GetUserHostPermissions(ctx context.Context, userID, hostID uint) (uint, error)
 	// 获取用户有权限访问的所有主机ID列表
 	GetUserAccessibleHostIDs(ctx context.Context, userID uint) ([]uint, error)
+	// 获取用户拥有指定操作权限的所有主机ID列表
+	GetUserOperableHostIDs(ctx context.Context, userID uint, operation uint) ([]uint, error)
+}
+
+type AccessRequestRepo interface {
+	Create(ctx context.Context, req *SysAccessRequest) error
+	GetByID(ctx context.Context, id uint) (*SysAccessRequest, error)
+	// 仅当申请仍处于 fromStatus 时保存，返回是否保存成功
+	UpdateFrom(ctx context.Context, req *SysAccessRequest, fromStatus string) (bool, error)
+	List(ctx context.Context, query AccessRequestQuery) ([]*SysAccessRequest, int64, error)
+	// 获取已到期但尚未回收的申请
+	ListExpired(ctx context.Context, now time.Time) ([]*SysAccessRequest, error)
+}
+
+// ProjectRepo 项目仓储接口
+type ProjectRepo interface {
+	Create(ctx context.Context, project *SysProject) error
+	Update(ctx context.Context, project *SysProject) error
+	Delete(ctx context.Context, id uint) error
+	GetByID(ctx context.Context, id uint) (*SysProject, error)
+	List(ctx context.Context, keyword string) ([]*SysProject, error)
+	// 获取用户加入的启用项目，按项目ID排序
+	ListByUserID(ctx context.Context, userID uint) ([]*SysProject, error)
+	ListMembers(ctx context.Context, projectID uint) ([]*ProjectMemberInfo, error)
+	// 添加成员，成员已存在时更新项目角色
+	SaveMember(ctx context.Context, member *SysProjectMember) error
+	RemoveMember(ctx context.Context, projectID, userID uint) error
+	CountResources(ctx context.Context, projectID uint, table string) (int64, error)
+	AssignResources(ctx context.Context, projectID uint, table string, ids []uint) (int64, error)
 }
@@ -0,0 +1,332 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PermissionLoginOnly 登录即可访问的接口使用的权限编码
+const PermissionLoginOnly = "*"
+
+// permissionCacheTTL 用户权限编码缓存有效期，多实例部署时作为失效兜底
+const permissionCacheTTL = 5 * time.Minute
+
+// RoutePermission 接口与权限编码的映射
+// Code 对应 sys_menu.code，形如 "users:create" 的按钮编码会在启动时自动同步为父菜单下的按钮
+type RoutePermission struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Code   string `json:"code"`
+	// Name 按钮名称，自动创建按钮菜单时使用
+	Name string `json:"name,omitempty"`
+}
+
+// IsButton 是否为按钮编码（父菜单编码:操作）
+func (p RoutePermission) IsButton() bool {
+	return strings.Contains(p.Code, ":")
+}
+
+// ParentCode 按钮所属父菜单的编码
+func (p RoutePermission) ParentCode() string {
+	if i := strings.LastIndex(p.Code, ":"); i > 0 {
+		return p.Code[:i]
+	}
+	return ""
+}
+
+var routePermissions = struct {
+	sync.RWMutex
+	items map[string]RoutePermission
+}{items: make(map[string]RoutePermission)}
+
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// DeclareRoutePermissions 登记接口权限，Path 为 gin 完整路由（含路径参数占位符）
+// 核心模块和插件在注册路由时调用，同一接口重复登记时以后者为准
+func DeclareRoutePermissions(perms ...RoutePermission) {
+	routePermissions.Lock()
+	defer routePermissions.Unlock()
+	for _, p := range perms {
+		p.Method = strings.ToUpper(p.Method)
+		routePermissions.items[routeKey(p.Method, p.Path)] = p
+	}
+}
+
+// LookupRoutePermission 查询接口登记的权限
+func LookupRoutePermission(method, path string) (RoutePermission, bool) {
+	routePermissions.RLock()
+	defer routePermissions.RUnlock()
+	p, ok := routePermissions.items[routeKey(method, path)]
+	return p, ok
+}
+
+// ListRoutePermissions 获取全部已登记的接口权限，按路径排序
+func ListRoutePermissions() []RoutePermission {
+	routePermissions.RLock()
+	list := make([]RoutePermission, 0, len(routePermissions.items))
+	for _, p := range routePermissions.items {
+		list = append(list, p)
+	}
+	routePermissions.RUnlock()
+	sortRoutes(list)
+	return list
+}
+
+// UnmappedRoutes 从给定路由中筛出未登记权限的接口
+func UnmappedRoutes(routes []RoutePermission) []RoutePermission {
+	list := make([]RoutePermission, 0)
+	for _, r := range routes {
+		if _, ok := LookupRoutePermission(r.Method, r.Path); !ok {
+			list = append(list, RoutePermission{Method: strings.ToUpper(r.Method), Path: r.Path})
+		}
+	}
+	sortRoutes(list)
+	return list
+}
+
+func sortRoutes(list []RoutePermission) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Path != list[j].Path {
+			return list[i].Path < list[j].Path
+		}
+		return list[i].Method < list[j].Method
+	})
+}
+
+// UserPermissions 用户拥有的权限编码
+type UserPermissions struct {
+	Admin bool
+	// Projects 用户加入的启用项目，平台管理员不加载
+	Projects []uint
+	codes    map[string]struct{}
+}
+
+// Has 是否拥有权限编码，管理员拥有全部权限
+func (p *UserPermissions) Has(code string) bool {
+	if p.Admin || code == PermissionLoginOnly {
+		return true
+	}
+	_, ok := p.codes[code]
+	return ok
+}
+
+type permissionEntry struct {
+	perms    *UserPermissions
+	expireAt time.Time
+}
+
+// permissionKey 项目角色只在对应项目中生效，缓存按用户和当前项目区分
+type permissionKey struct {
+	userID    uint
+	projectID uint
+}
+
+// PermissionCache 用户权限编码缓存
+// 角色菜单、用户角色、项目成员或菜单编码变更时由对应用例清除
+type PermissionCache struct {
+	roleRepo    RoleRepo
+	menuRepo    MenuRepo
+	projectRepo ProjectRepo
+	ttl         time.Duration
+
+	mu         sync.RWMutex
+	entries    map[permissionKey]*permissionEntry
+	generation uint64
+}
+
+func NewPermissionCache(roleRepo RoleRepo, menuRepo MenuRepo) *PermissionCache {
+	return &PermissionCache{
+		roleRepo: roleRepo,
+		menuRepo: menuRepo,
+		ttl:      permissionCacheTTL,
+		entries:  make(map[permissionKey]*permissionEntry),
+	}
+}
+
+// SetProjectRepo 设置项目仓储，设置后加载用户加入的项目
+func (c *PermissionCache) SetProjectRepo(repo ProjectRepo) {
+	c.projectRepo = repo
+}
+
+// Get 获取用户权限编码，缓存未命中或过期时从数据库加载
+// 上下文中有当前项目时，结果包含用户在该项目中的项目角色的权限
+func (c *PermissionCache) Get(ctx context.Context, userID uint) (*UserPermissions, error) {
+	key := permissionKey{userID: userID}
+	if scope := ProjectScopeFromContext(ctx); scope != nil {
+		key.projectID = scope.CurrentID
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	generation := c.generation
+	c.mu.RUnlock()
+	if ok && time.Now().Before(entry.expireAt) {
+		return entry.perms, nil
+	}
+
+	perms, err := c.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	// 加载期间发生过失效则不写回，避免缓存旧数据
+	if c.generation == generation {
+		c.entries[key] = &permissionEntry{perms: perms, expireAt: time.Now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return perms, nil
+}
+
+func (c *PermissionCache) load(ctx context.Context, userID uint) (*UserPermissions, error) {
+	roles, err := c.roleRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	perms := &UserPermissions{codes: make(map[string]struct{})}
+	for _, role := range roles {
+		if role.Code == "admin" {
+			perms.Admin = true
+			return perms, nil
+		}
+	}
+
+	codes, err := c.menuRepo.GetCodesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		perms.codes[code] = struct{}{}
+	}
+
+	if c.projectRepo != nil {
+		projects, err := c.projectRepo.ListByUserID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range projects {
+			perms.Projects = append(perms.Projects, project.ID)
+		}
+	}
+	return perms, nil
+}
+
+// Invalidate 清除全部用户的缓存
+func (c *PermissionCache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.entries = make(map[permissionKey]*permissionEntry)
+	c.generation++
+	c.mu.Unlock()
+}
+
+// InvalidateUser 清除指定用户的缓存
+func (c *PermissionCache) InvalidateUser(userID uint) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	for key := range c.entries {
+		if key.userID == userID {
+			delete(c.entries, key)
+		}
+	}
+	c.generation++
+	c.mu.Unlock()
+}
+
+// RoutePermissionUseCase 接口权限用例
+type RoutePermissionUseCase struct {
+	menuRepo MenuRepo
+}
+
+func NewRoutePermissionUseCase(menuRepo MenuRepo) *RoutePermissionUseCase {
+	return &RoutePermissionUseCase{
+		menuRepo: menuRepo,
+	}
+}
+
+// SyncButtons 为已登记但数据库中不存在的按钮编码创建按钮菜单
+// 父菜单不存在的按钮无法挂载，通过 skipped 返回
+func (uc *RoutePermissionUseCase) SyncButtons(ctx context.Context) (created, skipped []string, err error) {
+	buttons := make(map[string]RoutePermission)
+	for _, p := range ListRoutePermissions() {
+		if !p.IsButton() {
+			continue
+		}
+		if exist, ok := buttons[p.Code]; !ok || exist.Name == "" {
+			buttons[p.Code] = p
+		}
+	}
+
+	codes := make([]string, 0, len(buttons))
+	for code := range buttons {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if _, err := uc.menuRepo.GetByCode(ctx, code); err == nil {
+			continue
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return created, skipped, err
+		}
+
+		p := buttons[code]
+		parent, err := uc.menuRepo.GetByCode(ctx, p.ParentCode())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			skipped = append(skipped, code)
+			continue
+		}
+		if err != nil {
+			return created, skipped, err
+		}
+
+		name := p.Name
+		if name == "" {
+			name = code
+		}
+		button := &SysMenu{
+			Name:     name,
+			Code:     code,
+			Type:     3,
+			ParentID: parent.ID,
+			Visible:  1,
+			Status:   1,
+		}
+		if err := uc.menuRepo.Create(ctx, button); err != nil {
+			return created, skipped, err
+		}
+		created = append(created, code)
+	}
+	return created, skipped, nil
+}
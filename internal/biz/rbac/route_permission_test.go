@@ -0,0 +1,135 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeRoleRepo struct {
+	RoleRepo
+	roles map[uint][]*SysRole
+}
+
+func (r *fakeRoleRepo) GetByUserID(ctx context.Context, userID uint) ([]*SysRole, error) {
+	return r.roles[userID], nil
+}
+
+type fakeMenuRepo struct {
+	MenuRepo
+	codes map[uint][]string
+	loads int
+}
+
+func (r *fakeMenuRepo) GetCodesByUserID(ctx context.Context, userID uint) ([]string, error) {
+	r.loads++
+	return r.codes[userID], nil
+}
+
+func TestRoutePermissionRegistry(t *testing.T) {
+	DeclareRoutePermissions(
+		RoutePermission{Method: "get", Path: "/api/v1/test-users", Code: "test-users"},
+		RoutePermission{Method: "POST", Path: "/api/v1/test-users", Code: "test-users:create"},
+	)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		code   string
+		found  bool
+	}{
+		{"方法大小写不敏感", "GET", "/api/v1/test-users", "test-users", true},
+		{"按方法区分", "POST", "/api/v1/test-users", "test-users:create", true},
+		{"未登记的接口", "DELETE", "/api/v1/test-users", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := LookupRoutePermission(tt.method, tt.path)
+			if ok != tt.found || p.Code != tt.code {
+				t.Errorf("LookupRoutePermission() = %q, %v, want %q, %v", p.Code, ok, tt.code, tt.found)
+			}
+		})
+	}
+
+	unmapped := UnmappedRoutes([]RoutePermission{
+		{Method: "GET", Path: "/api/v1/test-users"},
+		{Method: "DELETE", Path: "/api/v1/test-users"},
+	})
+	if len(unmapped) != 1 || unmapped[0].Method != "DELETE" {
+		t.Errorf("UnmappedRoutes() = %+v, want only DELETE", unmapped)
+	}
+
+	if got := (RoutePermission{Code: "test-users:create"}).ParentCode(); got != "test-users" {
+		t.Errorf("ParentCode() = %q, want test-users", got)
+	}
+}
+
+func TestPermissionCache(t *testing.T) {
+	roleRepo := &fakeRoleRepo{roles: map[uint][]*SysRole{
+		1: {{Code: "admin"}},
+		2: {{Code: "user"}},
+	}}
+	menuRepo := &fakeMenuRepo{codes: map[uint][]string{
+		2: {"users", "users:create"},
+	}}
+	cache := NewPermissionCache(roleRepo, menuRepo)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		userID uint
+		code   string
+		want   bool
+	}{
+		{"管理员拥有全部权限", 1, "roles:delete", true},
+		{"拥有角色菜单中的按钮", 2, "users:create", true},
+		{"缺少按钮权限", 2, "users:delete", false},
+		{"登录即可访问", 3, PermissionLoginOnly, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perms, err := cache.Get(ctx, tt.userID)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got := perms.Has(tt.code); got != tt.want {
+				t.Errorf("Has(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+
+	loads := menuRepo.loads
+	if _, err := cache.Get(ctx, 2); err != nil || menuRepo.loads != loads {
+		t.Fatalf("缓存未命中，loads = %d, want %d", menuRepo.loads, loads)
+	}
+
+	// 角色菜单变更后重新加载
+	menuRepo.codes[2] = []string{"users"}
+	cache.Invalidate()
+	perms, err := cache.Get(ctx, 2)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if perms.Has("users:create") {
+		t.Error("Invalidate() 后仍返回旧权限")
+	}
+}
@@ -26,7 +26,9 @@ import (
 )
 
 type UserUseCase struct {
-	userRepo UserRepo
+	userRepo    UserRepo
+	projectRepo ProjectRepo
+	permCache   *PermissionCache
 }
 
 func NewUserUseCase(userRepo UserRepo) *UserUseCase {
@@ -35,6 +37,16 @@ func NewUserUseCase(userRepo UserRepo) *UserUseCase {
 	}
 }
 
+// SetPermissionCache 设置权限缓存，用户角色变更时清除
+func (uc *UserUseCase) SetPermissionCache(cache *PermissionCache) {
+	uc.permCache = cache
+}
+
+// SetProjectRepo 设置项目仓储，设置后新用户自动加入创建时所在的项目
+func (uc *UserUseCase) SetProjectRepo(repo ProjectRepo) {
+	uc.projectRepo = repo
+}
+
 func (uc *UserUseCase) Create(ctx context.Context, user *SysUser) error {
 	// 加密密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
@@ -42,15 +54,36 @@ func (uc *UserUseCase) Create(ctx context.Context, user *SysUser) error {
 		return err
 	}
 	user.Password = string(hashedPassword)
-	return uc.userRepo.Create(ctx, user)
+	if err := uc.userRepo.Create(ctx, user); err != nil {
+		return err
+	}
+	if uc.projectRepo == nil {
+		return nil
+	}
+
+	// 管理员在某个项目下创建的用户加入该项目，注册或未选择项目时加入默认项目
+	projectID := DefaultProjectID
+	if scope := ProjectScopeFromContext(ctx); scope != nil && scope.CurrentID != 0 {
+		projectID = scope.CurrentID
+	}
+	return uc.projectRepo.SaveMember(ctx, &SysProjectMember{ProjectID: projectID, UserID: user.ID})
 }
 
 func (uc *UserUseCase) Update(ctx context.Context, user *SysUser) error {
 	return uc.userRepo.Update(ctx, user)
 }
 
+// UpdateProfile 用户修改自己的资料，只允许修改姓名、邮箱、手机号和头像
+func (uc *UserUseCase) UpdateProfile(ctx context.Context, user *SysUser) error {
+	return uc.userRepo.UpdateProfile(ctx, user)
+}
+
 func (uc *UserUseCase) Delete(ctx context.Context, id uint) error {
-	return uc.userRepo.Delete(ctx, id)
+	if err := uc.userRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	uc.permCache.InvalidateUser(id)
+	return nil
 }
 
 func (uc *UserUseCase) GetByID(ctx context.Context, id uint) (*SysUser, error) {
@@ -66,7 +99,11 @@ func (uc *UserUseCase) List(ctx context.Context, page, pageSize int, keyword str
 }
 
 func (uc *UserUseCase) AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error {
-	return uc.userRepo.AssignRoles(ctx, userID, roleIDs)
+	if err := uc.userRepo.AssignRoles(ctx, userID, roleIDs); err != nil {
+		return err
+	}
+	uc.permCache.InvalidateUser(userID)
+	return nil
 }
 
 func (uc *UserUseCase) AssignPositions(ctx context.Context, userID uint, positionIDs []uint) error {
@@ -123,7 +160,8 @@ func (uc *UserUseCase) ResetPassword(ctx context.Context, userID uint, newPasswo
 }
 
 type RoleUseCase struct {
-	roleRepo RoleRepo
+	roleRepo  RoleRepo
+	permCache *PermissionCache
 }
 
 func NewRoleUseCase(roleRepo RoleRepo) *RoleUseCase {
@@ -132,16 +170,29 @@ func NewRoleUseCase(roleRepo RoleRepo) *RoleUseCase {
 	}
 }
 
+// SetPermissionCache 设置权限缓存，角色或角色菜单变更时清除
+func (uc *RoleUseCase) SetPermissionCache(cache *PermissionCache) {
+	uc.permCache = cache
+}
+
 func (uc *RoleUseCase) Create(ctx context.Context, role *SysRole) error {
 	return uc.roleRepo.Create(ctx, role)
 }
 
 func (uc *RoleUseCase) Update(ctx context.Context, role *SysRole) error {
-	return uc.roleRepo.Update(ctx, role)
+	if err := uc.roleRepo.Update(ctx, role); err != nil {
+		return err
+	}
+	uc.permCache.Invalidate()
+	return nil
 }
 
 func (uc *RoleUseCase) Delete(ctx context.Context, id uint) error {
-	return uc.roleRepo.Delete(ctx, id)
+	if err := uc.roleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	uc.permCache.Invalidate()
+	return nil
 }
 
 func (uc *RoleUseCase) GetByID(ctx context.Context, id uint) (*SysRole, error) {
@@ -157,7 +208,11 @@ func (uc *RoleUseCase) GetAll(ctx context.Context) ([]*SysRole, error) {
 }
 
 func (uc *RoleUseCase) AssignMenus(ctx context.Context, roleID uint, menuIDs []uint) error {
-	return uc.roleRepo.AssignMenus(ctx, roleID, menuIDs)
+	if err := uc.roleRepo.AssignMenus(ctx, roleID, menuIDs); err != nil {
+		return err
+	}
+	uc.permCache.Invalidate()
+	return nil
 }
 
 func (uc *RoleUseCase) GetByUserID(ctx context.Context, userID uint) ([]*SysRole, error) {
@@ -246,7 +301,8 @@ func (uc *DepartmentUseCase) ToInfoVO(dept *SysDepartment) *DepartmentInfoVO {
 }
 
 type MenuUseCase struct {
-	menuRepo MenuRepo
+	menuRepo  MenuRepo
+	permCache *PermissionCache
 }
 
 func NewMenuUseCase(menuRepo MenuRepo) *MenuUseCase {
@@ -255,16 +311,29 @@ func NewMenuUseCase(menuRepo MenuRepo) *MenuUseCase {
 	}
 }
 
+// SetPermissionCache 设置权限缓存，菜单编码或状态变更时清除
+func (uc *MenuUseCase) SetPermissionCache(cache *PermissionCache) {
+	uc.permCache = cache
+}
+
 func (uc *MenuUseCase) Create(ctx context.Context, menu *SysMenu) error {
 	return uc.menuRepo.Create(ctx, menu)
 }
 
 func (uc *MenuUseCase) Update(ctx context.Context, menu *SysMenu) error {
-	return uc.menuRepo.Update(ctx, menu)
+	if err := uc.menuRepo.Update(ctx, menu); err != nil {
+		return err
+	}
+	uc.permCache.Invalidate()
+	return nil
 }
 
 func (uc *MenuUseCase) Delete(ctx context.Context, id uint) error {
-	return uc.menuRepo.Delete(ctx, id)
+	if err := uc.menuRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	uc.permCache.Invalidate()
+	return nil
 }
 
 func (uc *MenuUseCase) GetByID(ctx context.Context, id uint) (*SysMenu, error) {
@@ -359,9 +428,9 @@ func (uc *AssetPermissionUseCase) GetDetailByID(ctx context.Context, id uint) (*
 	return uc.assetPermissionRepo.GetDetailByID(ctx, id)
 }
 
-// UpdateAssetPermission 更新权限配置（支持修改角色、分组、主机、权限）
-func (uc *AssetPermissionUseCase) UpdateAssetPermission(ctx context.Context, id uint, roleID, assetGroupID uint, hostIDs []uint, permissions uint) error {
-	return uc.assetPermissionRepo.UpdateAssetPermission(ctx, id, roleID, assetGroupID, hostIDs, permissions)
+// UpdateAssetPermission 更新授权规则（支持修改角色、分组、主机、权限和条件）
+func (uc *AssetPermissionUseCase) UpdateAssetPermission(ctx context.Context, id uint, rule *SysRoleAssetPermission) error {
+	return uc.assetPermissionRepo.UpdateRule(ctx, id, rule)
 }
 
 // GetByRoleID 获取角色的所有资产权限
@@ -389,16 +458,43 @@ func (uc *AssetPermissionUseCase) GetUserAccessibleHostIDs(ctx context.Context,
 	return uc.assetPermissionRepo.GetUserAccessibleHostIDs(ctx, userID)
 }
 
-// CreateBatchWithPermissions 批量创建资产权限（支持指定操作权限）
-func (uc *AssetPermissionUseCase) CreateBatchWithPermissions(ctx context.Context, roleID, assetGroupID uint, hostIDs []uint, permissions uint) error {
-	return uc.assetPermissionRepo.CreateBatchWithPermissions(ctx, roleID, assetGroupID, hostIDs, permissions)
+// CreateRule 创建授权规则（支持操作权限、生效效果和访问条件）
+func (uc *AssetPermissionUseCase) CreateRule(ctx context.Context, rule *SysRoleAssetPermission) error {
+	return uc.assetPermissionRepo.CreateRule(ctx, rule)
 }
 
-// CheckHostOperationPermission 检查用户是否有对指定主机的特定操作权限
-func (uc *AssetPermissionUseCase) CheckHostOperationPermission(ctx context.Context, userID, hostID uint, operation uint) (bool, error) {
+// CheckHostOperationPermission 判定用户对指定主机的特定操作，返回允许或拒绝该请求的规则
+func (uc *AssetPermissionUseCase) CheckHostOperationPermission(ctx context.Context, userID, hostID uint, operation uint) (*AccessDecision, error) {
 	return uc.assetPermissionRepo.CheckHostOperationPermission(ctx, userID, hostID, operation)
 }
 
+// GetUserOperableHostIDs 获取用户拥有指定操作权限的所有主机ID列表
+func (uc *AssetPermissionUseCase) GetUserOperableHostIDs(ctx context.Context, userID uint, operation uint) ([]uint, error) {
+	return uc.assetPermissionRepo.GetUserOperableHostIDs(ctx, userID, operation)
+}
+
+// FilterDeniedHosts 返回 hostIDs 中用户没有指定操作权限的主机，管理员返回空
+func (uc *AssetPermissionUseCase) FilterDeniedHosts(ctx context.Context, userID uint, hostIDs []uint, operation uint) ([]uint, error) {
+	if len(hostIDs) == 0 {
+		return nil, nil
+	}
+	allowed, err := uc.assetPermissionRepo.GetUserOperableHostIDs(ctx, userID, operation)
+	if err != nil {
+		return nil, err
+	}
+	allowedSet := make(map[uint]bool, len(allowed))
+	for _, id := range allowed {
+		allowedSet[id] = true
+	}
+	var denied []uint
+	for _, id := range hostIDs {
+		if !allowedSet[id] {
+			denied = append(denied, id)
+		}
+	}
+	return denied, nil
+}
+
 // GetUserHostPermissions 获取用户对指定主机的所有操作权限
 func (uc *AssetPermissionUseCase) GetUserHostPermissions(ctx context.Context, userID, hostID uint) (uint, error) {
 	return uc.assetPermissionRepo.GetUserHostPermissions(ctx, userID, hostID)
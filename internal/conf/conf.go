@@ -24,14 +24,19 @@ import (
 	"strings"
 
 	"github.com/spf13/viper"
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 )
 
 // Config 全局配置
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Log       LogConfig       `mapstructure:"log"`
+	Crypto    CryptoConfig    `mapstructure:"crypto"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Inventory InventoryConfig `mapstructure:"inventory"`
+	Drift     DriftConfig     `mapstructure:"drift"`
 }
 
 // ServerConfig 服务器配置
@@ -44,6 +49,8 @@ type ServerConfig struct {
 	JWTSecret    string `mapstructure:"jwt_secret"`    // JWT密钥
 	ExternalURL  string `mapstructure:"external_url"`  // 外部访问URL，用于OAuth2 issuer
 	FrontendURL  string `mapstructure:"frontend_url"`  // 前端URL，用于OAuth2登录重定向
+	// TrustedProxies 可信反向代理的IP或网段，只有来自这些地址的请求才采信 X-Forwarded-For，默认不信任任何代理
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // GetOAuth2Issuer 获取OAuth2 issuer URL
@@ -102,6 +109,53 @@ type LogConfig struct {
 	Console    bool   `mapstructure:"console"`
 }
 
+// CryptoConfig 敏感数据加密配置
+type CryptoConfig struct {
+	KeyFile       string   `mapstructure:"key_file"`       // 主密钥文件，每行一个 "<密钥ID>:<base64密钥>"
+	Keys          []string `mapstructure:"keys"`           // 直接配置的主密钥，格式同密钥文件
+	PrimaryKey    string   `mapstructure:"primary_key"`    // 加密使用的密钥ID，默认第一个密钥
+	DisableLegacy bool     `mapstructure:"disable_legacy"` // 禁止解密旧版本硬编码密钥加密的数据
+}
+
+// DefaultKeyFile 未配置主密钥时使用的密钥文件
+const DefaultKeyFile = "data/master.key"
+
+// KeyringOptions 转换为主密钥环配置，未配置任何主密钥时使用 DefaultKeyFile
+func (c *CryptoConfig) KeyringOptions() crypto.Options {
+	opts := crypto.Options{
+		KeyFile:       c.KeyFile,
+		Keys:          c.Keys,
+		Primary:       c.PrimaryKey,
+		DisableLegacy: c.DisableLegacy,
+	}
+	if opts.KeyFile == "" && len(opts.Keys) == 0 {
+		opts.KeyFile = DefaultKeyFile
+	}
+	return opts
+}
+
+// MetricsConfig 主机指标采集配置，未配置的项使用默认值
+type MetricsConfig struct {
+	CollectInterval     int `mapstructure:"collect_interval"`      // 定时采集间隔(秒)，默认300，-1 关闭定时采集
+	Concurrency         int `mapstructure:"concurrency"`           // 同时采集的主机数，默认10
+	RawRetention        int `mapstructure:"raw_retention"`         // 原始数据保留时长(小时)，默认48
+	FiveMinuteRetention int `mapstructure:"five_minute_retention"` // 5分钟聚合数据保留时长(小时)，默认336
+	HourlyRetention     int `mapstructure:"hourly_retention"`      // 1小时聚合数据保留时长(小时)，默认4320
+}
+
+// InventoryConfig 主机清单采集配置，未配置的项使用默认值
+type InventoryConfig struct {
+	CollectInterval int `mapstructure:"collect_interval"` // 定时采集间隔(小时)，默认24，-1 关闭定时采集
+	Concurrency     int `mapstructure:"concurrency"`      // 同时采集的主机数，默认5
+}
+
+// DriftConfig 配置漂移检查配置，未配置的项使用默认值
+type DriftConfig struct {
+	CheckInterval   int `mapstructure:"check_interval"`   // 定时检查间隔(小时)，默认24，-1 关闭定时检查
+	Concurrency     int `mapstructure:"concurrency"`      // 同时检查的主机数，默认5
+	ReportRetention int `mapstructure:"report_retention"` // 检查结果保留天数，默认30
+}
+
 var globalConfig *Config
 
 // Load 加载配置
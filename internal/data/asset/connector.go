@@ -0,0 +1,35 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+	"gorm.io/gorm"
+)
+
+// NewSSHConnector 创建使用进程内共享连接池的主机SSH连接器，供各插件复用连接
+func NewSSHConnector(db *gorm.DB) *asset.SSHConnector {
+	hostRepo := NewHostRepo(db)
+	credentialRepo := NewCredentialRepo(db)
+	hostKeys := asset.NewHostKeyUseCase(NewHostKeyRepo(db), hostRepo)
+	gateways := asset.NewGatewayUseCase(hostRepo, NewAssetGroupRepo(db), credentialRepo, hostKeys)
+	return asset.NewSSHConnector(hostRepo, credentialRepo, hostKeys, gateways, sshclient.DefaultPool())
+}
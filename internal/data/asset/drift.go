@@ -0,0 +1,161 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"gorm.io/gorm"
+)
+
+// driftDeleteBatch 每批删除的检查结果条数
+const driftDeleteBatch = 5000
+
+type driftRepo struct {
+	db *gorm.DB
+}
+
+// NewDriftRepo 创建配置快照与漂移检查仓库
+func NewDriftRepo(db *gorm.DB) asset.DriftRepo {
+	return &driftRepo{db: db}
+}
+
+func (r *driftRepo) CreateSnapshot(ctx context.Context, snapshot *asset.HostSnapshot) error {
+	return r.db.WithContext(ctx).Create(snapshot).Error
+}
+
+func (r *driftRepo) GetSnapshot(ctx context.Context, id uint) (*asset.HostSnapshot, error) {
+	var snapshot asset.HostSnapshot
+	err := r.db.WithContext(ctx).First(&snapshot, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (r *driftRepo) ListSnapshots(ctx context.Context, hostID uint) ([]*asset.HostSnapshot, error) {
+	var snapshots []*asset.HostSnapshot
+	err := r.db.WithContext(ctx).Omit("content").
+		Where("host_id = ?", hostID).
+		Order("id DESC").
+		Find(&snapshots).Error
+	return snapshots, err
+}
+
+func (r *driftRepo) DeleteSnapshot(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&asset.HostSnapshot{}, id).Error
+}
+
+func (r *driftRepo) SetBaseline(ctx context.Context, hostID, snapshotID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&asset.HostSnapshot{}).
+			Where("host_id = ? AND is_baseline = ?", hostID, true).
+			Update("is_baseline", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&asset.HostSnapshot{}).
+			Where("id = ? AND host_id = ?", snapshotID, hostID).
+			Update("is_baseline", true).Error
+	})
+}
+
+func (r *driftRepo) GetBaseline(ctx context.Context, hostID uint) (*asset.HostSnapshot, error) {
+	var snapshot asset.HostSnapshot
+	err := r.db.WithContext(ctx).
+		Where("host_id = ? AND is_baseline = ?", hostID, true).
+		Order("id DESC").
+		First(&snapshot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (r *driftRepo) ListBaselineHostIDs(ctx context.Context, hostIDs []uint) ([]uint, error) {
+	var ids []uint
+	if hostIDs != nil && len(hostIDs) == 0 {
+		return ids, nil
+	}
+	query := r.db.WithContext(ctx).Model(&asset.HostSnapshot{}).Where("is_baseline = ?", true)
+	if hostIDs != nil {
+		query = query.Where("host_id IN ?", hostIDs)
+	}
+	err := query.Distinct().Pluck("host_id", &ids).Error
+	return ids, err
+}
+
+func (r *driftRepo) CreateReport(ctx context.Context, report *asset.DriftReport) error {
+	return r.db.WithContext(ctx).Create(report).Error
+}
+
+func (r *driftRepo) LatestReports(ctx context.Context, hostIDs []uint) ([]*asset.DriftReport, error) {
+	var reports []*asset.DriftReport
+	if len(hostIDs) == 0 {
+		return reports, nil
+	}
+	latest := r.db.Model(&asset.DriftReport{}).
+		Select("MAX(id)").
+		Where("host_id IN ?", hostIDs).
+		Group("host_id")
+	err := r.db.WithContext(ctx).
+		Where("id IN (?)", latest).
+		Order("host_id").
+		Find(&reports).Error
+	return reports, err
+}
+
+func (r *driftRepo) ListReports(ctx context.Context, hostID uint, page, pageSize int) ([]*asset.DriftReport, int64, error) {
+	var reports []*asset.DriftReport
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&asset.DriftReport{}).Where("host_id = ?", hostID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&reports).Error
+	return reports, total, err
+}
+
+// DeleteReportsBefore 分批删除过期检查结果
+func (r *driftRepo) DeleteReportsBefore(ctx context.Context, before time.Time) (int64, error) {
+	var total int64
+	for {
+		result := r.db.WithContext(ctx).
+			Where("created_at < ?", before).
+			Limit(driftDeleteBatch).
+			Delete(&asset.DriftReport{})
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < driftDeleteBatch {
+			return total, nil
+		}
+	}
+}
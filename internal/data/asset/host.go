@@ -21,15 +21,11 @@ package asset
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"encoding/base64"
 	"fmt"
-	"io"
 	"time"
 
 	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	"gorm.io/gorm"
 )
 
@@ -180,80 +176,38 @@ func (r *hostRepo) CountByCredentialID(ctx context.Context, credentialID uint) (
 	return count, err
 }
 
+// ListIDs 获取主机ID
+func (r *hostRepo) ListIDs(ctx context.Context, groupIDs []uint, withCredential bool) ([]uint, error) {
+	query := r.db.WithContext(ctx).Model(&asset.Host{})
+	if len(groupIDs) > 0 {
+		query = query.Where("group_id IN ?", groupIDs)
+	}
+	if withCredential {
+		query = query.Where("credential_id > 0")
+	}
+	var ids []uint
+	err := query.Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
 // credentialRepo 凭证仓库
 type credentialRepo struct {
-	db            *gorm.DB
-	encryptionKey []byte
+	db *gorm.DB
 }
 
 // NewCredentialRepo 创建凭证仓库
 func NewCredentialRepo(db *gorm.DB) asset.CredentialRepo {
-	// AES-256要求密钥长度必须是32字节（256位）
-	encryptionKey := []byte("opshub-enc-key-32-bytes-long!!!!")
-	return &credentialRepo{
-		db:            db,
-		encryptionKey: encryptionKey,
-	}
+	return &credentialRepo{db: db}
 }
 
 // encrypt 加密
 func (r *credentialRepo) encrypt(plaintext string) (string, error) {
-	if plaintext == "" {
-		return "", nil
-	}
-
-	block, err := aes.NewCipher(r.encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return crypto.Encrypt(plaintext)
 }
 
 // decrypt 解密
 func (r *credentialRepo) decrypt(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
-
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(r.encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, cipherData := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, cipherData, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
+	return crypto.Decrypt(ciphertext)
 }
 
 // Create 创建凭证
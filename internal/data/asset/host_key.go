@@ -0,0 +1,168 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/internal/biz/audit"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type hostKeyRepo struct {
+	db *gorm.DB
+}
+
+// NewHostKeyRepo 创建主机密钥仓库
+func NewHostKeyRepo(db *gorm.DB) asset.HostKeyRepo {
+	return &hostKeyRepo{db: db}
+}
+
+// GetByHostID 获取主机密钥，未记录时返回 nil
+func (r *hostKeyRepo) GetByHostID(ctx context.Context, hostID uint) (*asset.HostKey, error) {
+	var key asset.HostKey
+	err := r.db.WithContext(ctx).Where("host_id = ?", hostID).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// CreateIfAbsent 主机尚无记录时创建，依赖 host_id 唯一索引处理并发的首次连接
+func (r *hostKeyRepo) CreateIfAbsent(ctx context.Context, key *asset.HostKey) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Save 创建或覆盖主机密钥，清除待确认的密钥
+func (r *hostKeyRepo) Save(ctx context.Context, key *asset.HostKey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing asset.HostKey
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("host_id = ?", key.HostID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(key).Error
+		}
+		if err != nil {
+			return err
+		}
+		key.ID = existing.ID
+		key.CreatedAt = existing.CreatedAt
+		return tx.Model(&existing).Select("*").Omit("id", "created_at").Updates(key).Error
+	})
+}
+
+// RecordMismatch 记录与已信任密钥不一致的公钥
+// 同一新密钥重复出现时只累加次数，首次出现时写入操作日志并返回 true
+func (r *hostKeyRepo) RecordMismatch(ctx context.Context, hostID uint, keyType, publicKey, fingerprint string) (bool, error) {
+	first := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing asset.HostKey
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("host_id = ?", hostID).First(&existing).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := tx.Model(&existing).Updates(map[string]interface{}{
+			"status":              asset.HostKeyStatusMismatch,
+			"pending_key_type":    keyType,
+			"pending_public_key":  publicKey,
+			"pending_fingerprint": fingerprint,
+			"pending_seen_at":     now,
+			"mismatch_count":      gorm.Expr("mismatch_count + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		if existing.PendingFingerprint == fingerprint {
+			return nil
+		}
+		first = true
+
+		var host asset.Host
+		tx.Unscoped().Select("name", "ip").Where("id = ?", hostID).First(&host)
+		return tx.Create(&audit.SysOperationLog{
+			Username: "system",
+			Module:   "主机管理",
+			Action:   "主机密钥告警",
+			Description: fmt.Sprintf("主机【%s(%s)】SSH密钥变更，已拒绝连接",
+				host.Name, host.IP),
+			Status:   403,
+			ErrorMsg: fmt.Sprintf("已记录 %s，当前 %s", existing.Fingerprint, fingerprint),
+		}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return first, nil
+}
+
+// Delete 删除主机密钥
+func (r *hostKeyRepo) Delete(ctx context.Context, hostID uint) error {
+	return r.db.WithContext(ctx).Where("host_id = ?", hostID).Delete(&asset.HostKey{}).Error
+}
+
+// List 分页获取主机密钥，按关键词匹配主机名称或IP
+func (r *hostKeyRepo) List(ctx context.Context, page, pageSize int, keyword, status string) ([]*asset.HostKeyInfo, int64, error) {
+	query := r.db.WithContext(ctx).Model(&asset.HostKey{}).
+		Joins("LEFT JOIN hosts ON hosts.id = host_keys.host_id")
+	if keyword != "" {
+		query = query.Where("hosts.name LIKE ? OR hosts.ip LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if status != "" {
+		query = query.Where("host_keys.status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []struct {
+		asset.HostKey
+		HostName string
+		HostIP   string
+	}
+	err := query.Select("host_keys.*, hosts.name AS host_name, hosts.ip AS host_ip").
+		Order("host_keys.status ASC, host_keys.id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list := make([]*asset.HostKeyInfo, 0, len(rows))
+	for i := range rows {
+		list = append(list, &asset.HostKeyInfo{
+			HostKey:  &rows[i].HostKey,
+			HostName: rows[i].HostName,
+			HostIP:   rows[i].HostIP,
+		})
+	}
+	return list, total, nil
+}
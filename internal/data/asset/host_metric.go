@@ -0,0 +1,91 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 每批写入、删除的指标条数
+const (
+	metricSaveBatch   = 500
+	metricDeleteBatch = 5000
+)
+
+type hostMetricRepo struct {
+	db *gorm.DB
+}
+
+// NewHostMetricRepo 创建主机指标仓库
+func NewHostMetricRepo(db *gorm.DB) asset.HostMetricRepo {
+	return &hostMetricRepo{db: db}
+}
+
+// Save 写入指标，依赖 (host_id, resolution, ts) 唯一索引覆盖重复聚合的时间桶
+func (r *hostMetricRepo) Save(ctx context.Context, metrics []*asset.HostMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "host_id"}, {Name: "resolution"}, {Name: "ts"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"cpu_usage", "cpu_usage_max", "memory_usage", "memory_usage_max", "memory_used", "memory_total",
+			"disk_usage", "disk_usage_max", "disk_used", "disk_total", "samples",
+		}),
+	}).CreateInBatches(metrics, metricSaveBatch).Error
+}
+
+// List 获取 [start, end) 内指定精度的指标，按主机和时间排序
+func (r *hostMetricRepo) List(ctx context.Context, hostIDs []uint, resolution string, start, end time.Time) ([]*asset.HostMetric, error) {
+	var metrics []*asset.HostMetric
+	if hostIDs != nil && len(hostIDs) == 0 {
+		return metrics, nil
+	}
+	query := r.db.WithContext(ctx).
+		Where("resolution = ? AND ts >= ? AND ts < ?", resolution, start, end)
+	if hostIDs != nil {
+		query = query.Where("host_id IN ?", hostIDs)
+	}
+	err := query.Order("host_id, ts").Find(&metrics).Error
+	return metrics, err
+}
+
+// DeleteBefore 分批删除过期指标，避免长时间锁表
+func (r *hostMetricRepo) DeleteBefore(ctx context.Context, resolution string, before time.Time) (int64, error) {
+	var total int64
+	for {
+		result := r.db.WithContext(ctx).
+			Where("resolution = ? AND ts < ?", resolution, before).
+			Limit(metricDeleteBatch).
+			Delete(&asset.HostMetric{})
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < metricDeleteBatch {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"gorm.io/gorm"
+)
+
+// inventoryBatch 每批写入的清单明细条数
+const inventoryBatch = 500
+
+type hostInventoryRepo struct {
+	db *gorm.DB
+}
+
+// NewHostInventoryRepo 创建主机清单仓库
+func NewHostInventoryRepo(db *gorm.DB) asset.HostInventoryRepo {
+	return &hostInventoryRepo{db: db}
+}
+
+// Replace 在一个事务中替换主机的清单概要和全部明细
+func (r *hostInventoryRepo) Replace(ctx context.Context, detail *asset.HostInventoryDetail) error {
+	hostID := detail.HostID
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing asset.HostInventory
+		err := tx.Where("host_id = ?", hostID).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(detail.HostInventory).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			detail.ID = existing.ID
+			detail.CreatedAt = existing.CreatedAt
+			if err := tx.Model(&existing).Select("*").Omit("id", "created_at").Updates(detail.HostInventory).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, m := range []interface{}{
+			&asset.HostPackage{}, &asset.HostListenPort{}, &asset.HostServiceUnit{},
+			&asset.HostUser{}, &asset.HostInterface{}, &asset.HostMount{},
+		} {
+			if err := tx.Where("host_id = ?", hostID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(detail.Packages) > 0 {
+			if err := tx.CreateInBatches(detail.Packages, inventoryBatch).Error; err != nil {
+				return err
+			}
+		}
+		if len(detail.Ports) > 0 {
+			if err := tx.CreateInBatches(detail.Ports, inventoryBatch).Error; err != nil {
+				return err
+			}
+		}
+		if len(detail.Services) > 0 {
+			if err := tx.CreateInBatches(detail.Services, inventoryBatch).Error; err != nil {
+				return err
+			}
+		}
+		if len(detail.Users) > 0 {
+			if err := tx.CreateInBatches(detail.Users, inventoryBatch).Error; err != nil {
+				return err
+			}
+		}
+		if len(detail.Interfaces) > 0 {
+			if err := tx.CreateInBatches(detail.Interfaces, inventoryBatch).Error; err != nil {
+				return err
+			}
+		}
+		if len(detail.Mounts) > 0 {
+			if err := tx.CreateInBatches(detail.Mounts, inventoryBatch).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Get 获取主机完整清单，未采集过时返回 nil
+func (r *hostInventoryRepo) Get(ctx context.Context, hostID uint) (*asset.HostInventoryDetail, error) {
+	db := r.db.WithContext(ctx)
+	var inv asset.HostInventory
+	err := db.Where("host_id = ?", hostID).First(&inv).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &asset.HostInventoryDetail{HostInventory: &inv}
+	if err := db.Where("host_id = ?", hostID).Order("name").Find(&detail.Packages).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("host_id = ?", hostID).Order("port, protocol").Find(&detail.Ports).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("host_id = ?", hostID).Order("name").Find(&detail.Services).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("host_id = ?", hostID).Order("uid").Find(&detail.Users).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("host_id = ?", hostID).Order("id").Find(&detail.Interfaces).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("host_id = ?", hostID).Order("mount_point").Find(&detail.Mounts).Error; err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// Search 按条件检索清单明细，结果附带主机名称和IP，已删除主机的清单不返回
+func (r *hostInventoryRepo) Search(ctx context.Context, q *asset.InventoryQuery, limit int) ([]*asset.InventoryMatch, error) {
+	if q.HostIDs != nil && len(q.HostIDs) == 0 {
+		return []*asset.InventoryMatch{}, nil
+	}
+	query := r.db.WithContext(ctx).Limit(limit)
+	if q.HostIDs != nil {
+		query = query.Where("host_id IN ?", q.HostIDs)
+	}
+
+	var items []interface{}
+	var hostIDs []uint
+	collect := func(hostID uint, item interface{}) {
+		hostIDs = append(hostIDs, hostID)
+		items = append(items, item)
+	}
+
+	switch q.Kind {
+	case asset.InventoryKindPackage:
+		var rows []*asset.HostPackage
+		if err := matchName(query, "name", q.Name).Order("name, host_id").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			collect(row.HostID, row)
+		}
+	case asset.InventoryKindPort:
+		if q.Port > 0 {
+			query = query.Where("port = ?", q.Port)
+		}
+		if q.Protocol != "" {
+			query = query.Where("protocol = ?", q.Protocol)
+		}
+		var rows []*asset.HostListenPort
+		if err := matchName(query, "process", q.Process).Order("port, host_id").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			collect(row.HostID, row)
+		}
+	case asset.InventoryKindService:
+		if q.State != "" {
+			query = query.Where("active_state = ?", q.State)
+		}
+		var rows []*asset.HostServiceUnit
+		if err := matchName(query, "name", q.Name).Order("name, host_id").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			collect(row.HostID, row)
+		}
+	case asset.InventoryKindUser:
+		if q.Sudo != nil {
+			query = query.Where("sudo = ?", *q.Sudo)
+		}
+		var rows []*asset.HostUser
+		if err := matchName(query, "name", q.Name).Order("name, host_id").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			collect(row.HostID, row)
+		}
+	case asset.InventoryKindInterface:
+		if q.Address != "" {
+			// 地址以逗号分隔保存，匹配任意一个地址的前缀
+			prefix := escapeLike(q.Address) + "%"
+			query = query.Where("addresses LIKE ? OR addresses LIKE ?", prefix, "%,"+prefix)
+		}
+		var rows []*asset.HostInterface
+		if err := matchName(query, "name", q.Name).Order("host_id, name").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			collect(row.HostID, row)
+		}
+	case asset.InventoryKindMount:
+		var rows []*asset.HostMount
+		if err := matchName(query, "mount_point", q.Name).Order("host_id, mount_point").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			collect(row.HostID, row)
+		}
+	}
+
+	if len(items) == 0 {
+		return []*asset.InventoryMatch{}, nil
+	}
+
+	var hosts []*asset.Host
+	if err := r.db.WithContext(ctx).Select("id", "name", "ip").Where("id IN ?", uniqueIDs(hostIDs)).Find(&hosts).Error; err != nil {
+		return nil, err
+	}
+	hostMap := make(map[uint]*asset.Host, len(hosts))
+	for _, h := range hosts {
+		hostMap[h.ID] = h
+	}
+
+	matches := make([]*asset.InventoryMatch, 0, len(items))
+	for i, item := range items {
+		host, ok := hostMap[hostIDs[i]]
+		if !ok {
+			continue
+		}
+		matches = append(matches, &asset.InventoryMatch{
+			HostID:   host.ID,
+			HostName: host.Name,
+			HostIP:   host.IP,
+			Item:     item,
+		})
+	}
+	return matches, nil
+}
+
+// matchName 按名称筛选，包含 * 时按通配符匹配，否则精确匹配
+func matchName(query *gorm.DB, column, name string) *gorm.DB {
+	if name == "" {
+		return query
+	}
+	if strings.Contains(name, "*") {
+		return query.Where(column+" LIKE ?", strings.ReplaceAll(escapeLike(name), "*", "%"))
+	}
+	return query.Where(column+" = ?", name)
+}
+
+// escapeLike 转义 LIKE 中的特殊字符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
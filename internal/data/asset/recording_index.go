@@ -0,0 +1,131 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"gorm.io/gorm"
+)
+
+// recordingLineBatch 每批写入的索引行数
+const recordingLineBatch = 1000
+
+type recordingIndexRepo struct {
+	db *gorm.DB
+}
+
+// NewRecordingIndexRepo 创建终端录制索引仓库
+func NewRecordingIndexRepo(db *gorm.DB) asset.RecordingIndexRepo {
+	return &recordingIndexRepo{db: db}
+}
+
+func (r *recordingIndexRepo) Replace(ctx context.Context, recording *asset.TerminalRecording, lines []*asset.TerminalRecordingLine) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteRecording(tx, recording.Source, recording.SessionID); err != nil {
+			return err
+		}
+		recording.ID = 0
+		if err := tx.Create(recording).Error; err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		for _, line := range lines {
+			line.RecordingID = recording.ID
+		}
+		return tx.CreateInBatches(lines, recordingLineBatch).Error
+	})
+}
+
+func (r *recordingIndexRepo) Search(ctx context.Context, q *asset.RecordingQuery) ([]*asset.RecordingMatch, int64, error) {
+	query := r.db.WithContext(ctx).
+		Table("terminal_recording_lines AS l").
+		Joins("JOIN terminal_recordings AS r ON r.id = l.recording_id").
+		Where("l.content LIKE ?", "%"+q.Keyword+"%")
+	if q.Source != "" {
+		query = query.Where("r.source = ?", q.Source)
+	}
+	if q.Stream != "" {
+		query = query.Where("l.stream = ?", q.Stream)
+	}
+	if q.Username != "" {
+		query = query.Where("r.username = ?", q.Username)
+	}
+	if q.Target != "" {
+		query = query.Where("r.target LIKE ?", "%"+q.Target+"%")
+	}
+	if q.StartTime != nil {
+		query = query.Where("r.started_at >= ?", *q.StartTime)
+	}
+	if q.EndTime != nil {
+		query = query.Where("r.started_at <= ?", *q.EndTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var matches []*asset.RecordingMatch
+	err := query.Select("r.source, r.session_id, r.target, r.username, r.started_at, l.stream, l.`offset`, l.content").
+		Order("r.started_at DESC, l.id ASC").
+		Offset((q.Page - 1) * q.PageSize).
+		Limit(q.PageSize).
+		Scan(&matches).Error
+	return matches, total, err
+}
+
+func (r *recordingIndexRepo) Get(ctx context.Context, source string, sessionID uint) (*asset.TerminalRecording, error) {
+	var recording asset.TerminalRecording
+	err := r.db.WithContext(ctx).Where("source = ? AND session_id = ?", source, sessionID).First(&recording).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &recording, nil
+}
+
+func (r *recordingIndexRepo) Delete(ctx context.Context, source string, sessionID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteRecording(tx, source, sessionID)
+	})
+}
+
+// deleteRecording 删除会话的录制索引及索引行
+func deleteRecording(tx *gorm.DB, source string, sessionID uint) error {
+	var ids []uint
+	if err := tx.Model(&asset.TerminalRecording{}).
+		Where("source = ? AND session_id = ?", source, sessionID).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Where("recording_id IN ?", ids).Delete(&asset.TerminalRecordingLine{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&asset.TerminalRecording{}, ids).Error
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
+	"gorm.io/gorm"
+)
+
+// RotateSecrets 使用密钥环的主用密钥重新加密主机凭证及云账号密钥，返回重新加密的值的数量
+func RotateSecrets(ctx context.Context, db *gorm.DB, k *crypto.Keyring, dryRun bool) (int, error) {
+	return crypto.RotateColumns(ctx, db, k, []crypto.Column{
+		{Table: "credentials", Column: "password"},
+		{Table: "credentials", Column: "private_key"},
+		{Table: "credentials", Column: "passphrase"},
+		// 云账号密钥早期版本明文保存
+		{Table: "cloud_accounts", Column: "access_key", Plain: true},
+		{Table: "cloud_accounts", Column: "secret_key", Plain: true},
+	}, dryRun)
+}
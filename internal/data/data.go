@@ -28,6 +28,7 @@ import (
 	"gorm.io/gorm/logger"
 
 	"github.com/ydcloud-dy/opshub/internal/conf"
+	rbacdata "github.com/ydcloud-dy/opshub/internal/data/rbac"
 	// 导入 MySQL 驱动，确保 time.Time 类型正确处理
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -68,6 +69,11 @@ func newMySQL(cfg conf.DatabaseConfig) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// 按请求的项目范围隔离带 ProjectID 字段的模型
+	if err := db.Use(rbacdata.ProjectScopePlugin{}); err != nil {
+		return nil, err
+	}
+
 	// 禁用外键检查（只在当前会话）
 	db.Exec("SET FOREIGN_KEY_CHECKS = 0;")
 
@@ -0,0 +1,99 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"gorm.io/gorm"
+)
+
+type accessRequestRepo struct {
+	db *gorm.DB
+}
+
+func NewAccessRequestRepo(db *gorm.DB) rbac.AccessRequestRepo {
+	return &accessRequestRepo{db: db}
+}
+
+func (r *accessRequestRepo) Create(ctx context.Context, req *rbac.SysAccessRequest) error {
+	return r.db.WithContext(ctx).Create(req).Error
+}
+
+func (r *accessRequestRepo) GetByID(ctx context.Context, id uint) (*rbac.SysAccessRequest, error) {
+	var req rbac.SysAccessRequest
+	if err := r.db.WithContext(ctx).First(&req, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, rbac.ErrAccessRequestNotFound
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *accessRequestRepo) UpdateFrom(ctx context.Context, req *rbac.SysAccessRequest, fromStatus string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&rbac.SysAccessRequest{}).
+		Where("id = ? AND status = ?", req.ID, fromStatus).
+		Select("status", "approver_id", "approver_name", "comment", "approved_at", "expires_at", "revoked_at", "revoked_by", "grant_id").
+		Updates(req)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *accessRequestRepo) List(ctx context.Context, query rbac.AccessRequestQuery) ([]*rbac.SysAccessRequest, int64, error) {
+	var requests []*rbac.SysAccessRequest
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&rbac.SysAccessRequest{})
+	if query.UserID != 0 {
+		db = db.Where("user_id = ?", query.UserID)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.ResourceType != "" {
+		db = db.Where("resource_type = ?", query.ResourceType)
+	}
+	if query.Keyword != "" {
+		keyword := "%" + query.Keyword + "%"
+		db = db.Where("username LIKE ? OR resource_name LIKE ? OR reason LIKE ?", keyword, keyword, keyword)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Order("id DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&requests).Error
+	return requests, total, err
+}
+
+func (r *accessRequestRepo) ListExpired(ctx context.Context, now time.Time) ([]*rbac.SysAccessRequest, error) {
+	var requests []*rbac.SysAccessRequest
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", rbac.AccessRequestApproved, now).
+		Order("expires_at ASC").
+		Find(&requests).Error
+	return requests, err
+}
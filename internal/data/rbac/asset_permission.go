@@ -52,29 +52,20 @@ func (r *assetPermissionRepo) CreateBatch(ctx context.Context, roleID, assetGrou
 	return r.db.WithContext(ctx).Create(permission).Error
 }
 
-// CreateBatchWithPermissions 批量创建资产权限（支持指定操作权限）
-func (r *assetPermissionRepo) CreateBatchWithPermissions(ctx context.Context, roleID, assetGroupID uint, hostIDs []uint, permissions uint) error {
-	// 先硬删除该角色对该资产分组的所有现有权限（包括已软删除的）
-	if err := r.db.WithContext(ctx).
-		Where("role_id = ? AND asset_group_id = ?", roleID, assetGroupID).
-		Unscoped().Delete(&rbac.SysRoleAssetPermission{}).Error; err != nil {
-		return err
-	}
-
-	// 如果权限为0，默认为查看权限
-	if permissions == 0 {
-		permissions = rbac.PermissionView
-	}
-
-	// 创建单条记录，支持多个主机ID
-	permission := &rbac.SysRoleAssetPermission{
-		RoleID:       roleID,
-		AssetGroupID: assetGroupID,
-		HostIDs:      hostIDs, // 直接存储主机ID数组
-		Permissions: permissions,
-	}
-
-	return r.db.WithContext(ctx).Create(permission).Error
+// CreateRule 创建授权规则
+// 长期有效的允许规则每个角色和分组只保留一条，拒绝规则和临时授权可与之并存
+func (r *assetPermissionRepo) CreateRule(ctx context.Context, rule *rbac.SysRoleAssetPermission) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if !rule.IsDeny() && rule.ExpiresAt == nil {
+			// 硬删除该角色对该资产分组已有的长期允许规则（包括已软删除的）
+			if err := tx.Where("role_id = ? AND user_id = ? AND asset_group_id = ? AND (effect = ? OR effect = '' OR effect IS NULL) AND expires_at IS NULL",
+				rule.RoleID, rule.UserID, rule.AssetGroupID, rbac.EffectAllow).
+				Unscoped().Delete(&rbac.SysRoleAssetPermission{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(rule).Error
+	})
 }
 
 // DeleteByRoleAndGroup 删除指定角色对指定资产分组的所有权限
@@ -116,10 +107,12 @@ func (r *assetPermissionRepo) GetDetailByID(ctx context.Context, id uint) (*rbac
 		return nil, err
 	}
 
-	// 获取角色名称
+	// 获取角色名称，个人临时授权没有角色
 	var role rbac.SysRole
-	if err := r.db.WithContext(ctx).First(&role, permission.RoleID).Error; err != nil {
-		return nil, err
+	if permission.RoleID != 0 {
+		if err := r.db.WithContext(ctx).First(&role, permission.RoleID).Error; err != nil {
+			return nil, err
+		}
 	}
 
 	// 获取资产分组名称
@@ -145,33 +138,20 @@ func (r *assetPermissionRepo) GetDetailByID(ctx context.Context, id uint) (*rbac
 		AssetGroupName: group.Name,
 		HostIDs:       hostIDs,
 		Permissions:   permission.Permissions,
+		Effect:        permission.Effect,
+		Conditions:    permission.Conditions,
+		ExpiresAt:     permission.ExpiresAt,
+		Remark:        permission.Remark,
 		CreatedAt:     permission.CreatedAt,
 	}, nil
 }
 
-// UpdateAssetPermission 更新权限配置（支持修改角色、分组、主机、权限）
-func (r *assetPermissionRepo) UpdateAssetPermission(ctx context.Context, id uint, roleID, assetGroupID uint, hostIDs []uint, permissions uint) error {
-	// 首先硬删除该权限的旧记录（包括软删除的）
-	if err := r.db.WithContext(ctx).Model(&rbac.SysRoleAssetPermission{}).
+// UpdateRule 更新授权规则（支持修改角色、分组、主机、权限和条件）
+func (r *assetPermissionRepo) UpdateRule(ctx context.Context, id uint, rule *rbac.SysRoleAssetPermission) error {
+	return r.db.WithContext(ctx).Model(&rbac.SysRoleAssetPermission{}).
 		Where("id = ?", id).
-		Unscoped().Delete(&rbac.SysRoleAssetPermission{}).Error; err != nil {
-		return err
-	}
-
-	// 如果权限为0，默认为查看权限
-	if permissions == 0 {
-		permissions = rbac.PermissionView
-	}
-
-	// 创建单条新记录，支持多个主机ID
-	permission := &rbac.SysRoleAssetPermission{
-		RoleID:       roleID,
-		AssetGroupID: assetGroupID,
-		HostIDs:      hostIDs, // 直接存储主机ID数组
-		Permissions: permissions,
-	}
-
-	return r.db.WithContext(ctx).Create(permission).Error
+		Select("role_id", "asset_group_id", "host_ids", "permissions", "effect", "conditions", "expires_at", "remark").
+		Updates(rule).Error
 }
 
 // GetByRoleID 获取角色的所有资产权限
@@ -185,13 +165,20 @@ func (r *assetPermissionRepo) GetByRoleID(ctx context.Context, roleID uint) ([]*
 			p.role_id,
 			r.name AS role_name,
 			r.code AS role_code,
+			p.user_id,
+			u.username,
 			p.asset_group_id,
 			g.name AS asset_group_name,
 			p.host_ids,
 			p.permissions,
+			p.effect,
+			p.conditions,
+			p.expires_at,
+			p.remark,
 			p.created_at
 		`).
 		Joins("LEFT JOIN sys_role AS r ON p.role_id = r.id").
+		Joins("LEFT JOIN sys_user AS u ON p.user_id = u.id").
 		Joins("LEFT JOIN asset_group AS g ON p.asset_group_id = g.id").
 		Where("p.role_id = ? AND p.deleted_at IS NULL", roleID).
 		Order("p.created_at DESC").
@@ -211,13 +198,20 @@ func (r *assetPermissionRepo) GetByAssetGroupID(ctx context.Context, assetGroupI
 			p.role_id,
 			r.name AS role_name,
 			r.code AS role_code,
+			p.user_id,
+			u.username,
 			p.asset_group_id,
 			g.name AS asset_group_name,
 			p.host_ids,
 			p.permissions,
+			p.effect,
+			p.conditions,
+			p.expires_at,
+			p.remark,
 			p.created_at
 		`).
 		Joins("LEFT JOIN sys_role AS r ON p.role_id = r.id").
+		Joins("LEFT JOIN sys_user AS u ON p.user_id = u.id").
 		Joins("LEFT JOIN asset_group AS g ON p.asset_group_id = g.id").
 		Where("p.asset_group_id = ? AND p.deleted_at IS NULL", assetGroupID).
 		Order("p.created_at DESC").
@@ -238,13 +232,20 @@ func (r *assetPermissionRepo) List(ctx context.Context, page, pageSize int, role
 			p.role_id,
 			r.name AS role_name,
 			r.code AS role_code,
+			p.user_id,
+			u.username,
 			p.asset_group_id,
 			g.name AS asset_group_name,
 			p.host_ids,
 			p.permissions,
+			p.effect,
+			p.conditions,
+			p.expires_at,
+			p.remark,
 			p.created_at
 		`).
 		Joins("LEFT JOIN sys_role AS r ON p.role_id = r.id").
+		Joins("LEFT JOIN sys_user AS u ON p.user_id = u.id").
 		Joins("LEFT JOIN asset_group AS g ON p.asset_group_id = g.id").
 		Where("p.deleted_at IS NULL")
 
@@ -272,188 +273,148 @@ func (r *assetPermissionRepo) List(ctx context.Context, page, pageSize int, role
 
 // CheckHostPermission 检查用户是否有访问指定主机的权限
 func (r *assetPermissionRepo) CheckHostPermission(ctx context.Context, userID, hostID uint) (bool, error) {
-	// 首先检查用户是否是管理员
-	var adminCount int64
-	err := r.db.WithContext(ctx).
-		Table("sys_user_role AS ur").
-		Joins("JOIN sys_role AS r ON ur.role_id = r.id").
-		Where("ur.user_id = ? AND r.code = ?", userID, "admin").
-		Count(&adminCount).Error
-
-	if err != nil {
-		return false, err
-	}
-
-	// 管理员拥有所有权限
-	if adminCount > 0 {
-		return true, nil
-	}
-
-	// 获取主机所属的资产分组ID
-	var groupID uint
-	err = r.db.WithContext(ctx).
-		Table("hosts").
-		Select("group_id").
-		Where("id = ?", hostID).
-		Scan(&groupID).Error
-
+	decision, err := r.CheckHostOperationPermission(ctx, userID, hostID, 0)
 	if err != nil {
 		return false, err
 	}
-
-	// 检查用户的角色是否有权限访问该主机
-	// 1. 检查是否有整个分组的权限（host_ids 为空或 NULL）
-	// 2. 检查是否有特定主机的权限（host_ids 包含该主机ID）
-	var permCount int64
-	err = r.db.WithContext(ctx).
-		Table("sys_role_asset_permission AS p").
-		Joins("JOIN sys_user_role AS ur ON p.role_id = ur.role_id").
-		Where("ur.user_id = ? AND p.asset_group_id = ? AND p.deleted_at IS NULL", userID, groupID).
-		Where("JSON_LENGTH(COALESCE(p.host_ids, JSON_ARRAY())) = 0 OR JSON_CONTAINS(p.host_ids, CAST(? AS JSON))", hostID).
-		Count(&permCount).Error
-
-	if err != nil {
-		return false, err
-	}
-
-	return permCount > 0, nil
+	return decision.Allowed, nil
 }
 
 // GetUserAccessibleHostIDs 获取用户有权限访问的所有主机ID列表
 func (r *assetPermissionRepo) GetUserAccessibleHostIDs(ctx context.Context, userID uint) ([]uint, error) {
-	// 首先检查用户是否是管理员
-	var adminCount int64
-	err := r.db.WithContext(ctx).
-		Table("sys_user_role AS ur").
-		Joins("JOIN sys_role AS r ON ur.role_id = r.id").
-		Where("ur.user_id = ? AND r.code = ?", userID, "admin").
-		Count(&adminCount).Error
+	return r.GetUserOperableHostIDs(ctx, userID, 0)
+}
 
+// GetUserOperableHostIDs 获取用户拥有指定操作权限的所有主机ID列表
+// operation 为 0 时返回可访问的主机
+func (r *assetPermissionRepo) GetUserOperableHostIDs(ctx context.Context, userID uint, operation uint) ([]uint, error) {
+	isAdmin, err := r.isAdmin(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 管理员可以访问所有主机
-	if adminCount > 0 {
+	// 管理员拥有所有主机的所有权限
+	if isAdmin {
 		var allHostIDs []uint
 		err = r.db.WithContext(ctx).
 			Table("hosts").
 			Where("deleted_at IS NULL").
+			Scopes(ProjectScoped(ctx, "project_id")).
 			Pluck("id", &allHostIDs).Error
 		return allHostIDs, err
 	}
 
-	// 获取用户有权限的主机ID列表
-	// 1. 如果 host_ids 为空，表示有整个分组的权限
-	// 2. 如果 host_ids 包含主机ID，表示有特定主机的权限
-	var hostIDs []uint
-
-	// 通过原生SQL查询，处理 JSON 字段
-	err = r.db.WithContext(ctx).Raw(`
-		SELECT DISTINCT h.id
-		FROM hosts AS h
-		JOIN sys_role_asset_permission AS p ON p.asset_group_id = h.group_id
-		JOIN sys_user_role AS ur ON p.role_id = ur.role_id
-		WHERE ur.user_id = ?
-		AND h.deleted_at IS NULL
-		AND p.deleted_at IS NULL
-		AND (
-			JSON_LENGTH(COALESCE(p.host_ids, JSON_ARRAY())) = 0
-			OR JSON_CONTAINS(p.host_ids, CAST(h.id AS JSON))
-		)
-	`, userID).Scan(&hostIDs).Error
-
-	return hostIDs, err
-}
-
-// CheckHostOperationPermission 检查用户是否有对指定主机的特定操作权限
-func (r *assetPermissionRepo) CheckHostOperationPermission(ctx context.Context, userID, hostID uint, operation uint) (bool, error) {
-	// 首先检查用户是否是管理员
-	var adminCount int64
-	err := r.db.WithContext(ctx).
-		Table("sys_user_role AS ur").
-		Joins("JOIN sys_role AS r ON ur.role_id = r.id").
-		Where("ur.user_id = ? AND r.code = ?", userID, "admin").
-		Count(&adminCount).Error
-
+	rules, err := r.userRules(ctx, userID)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-
-	// 管理员拥有所有权限
-	if adminCount > 0 {
-		return true, nil
+	groupIDs := make([]uint, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.IsDeny() {
+			groupIDs = append(groupIDs, rule.AssetGroupID)
+		}
+	}
+	if len(groupIDs) == 0 {
+		return []uint{}, nil
 	}
 
-	// 获取主机所属的资产分组ID
-	var groupID uint
-	err = r.db.WithContext(ctx).
+	var hosts []rbac.HostAttributes
+	if err := r.db.WithContext(ctx).
 		Table("hosts").
-		Select("group_id").
-		Where("id = ?", hostID).
-		Scan(&groupID).Error
+		Select("id, group_id, tags").
+		Where("group_id IN ? AND deleted_at IS NULL", groupIDs).
+		Scopes(ProjectScoped(ctx, "project_id")).
+		Scan(&hosts).Error; err != nil {
+		return nil, err
+	}
 
+	req := rbac.NewAccessRequest(ctx, operation)
+	hostIDs := make([]uint, 0, len(hosts))
+	for _, host := range hosts {
+		if rbac.EvaluateHostAccess(rules, host, req).Allowed {
+			hostIDs = append(hostIDs, host.ID)
+		}
+	}
+	return hostIDs, nil
+}
+
+// CheckHostOperationPermission 判定用户对指定主机的特定操作，返回决定结果的规则
+func (r *assetPermissionRepo) CheckHostOperationPermission(ctx context.Context, userID, hostID uint, operation uint) (*rbac.AccessDecision, error) {
+	isAdmin, err := r.isAdmin(ctx, userID)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	// 检查用户的角色是否有该操作权限
-	// host_ids 为空表示有整个分组的权限，host_ids 包含该主机表示有特定主机的权限
-	var permCount int64
-	err = r.db.WithContext(ctx).
-		Table("sys_role_asset_permission AS p").
-		Joins("JOIN sys_user_role AS ur ON p.role_id = ur.role_id").
-		Where("ur.user_id = ? AND p.asset_group_id = ? AND p.deleted_at IS NULL", userID, groupID).
-		Where("(JSON_LENGTH(COALESCE(p.host_ids, JSON_ARRAY())) = 0 OR JSON_CONTAINS(p.host_ids, CAST(? AS JSON))) AND (p.permissions & ?) > 0", hostID, operation).
-		Count(&permCount).Error
+	// 管理员拥有所有权限
+	if isAdmin {
+		return &rbac.AccessDecision{Allowed: true, Reason: "管理员"}, nil
+	}
 
+	host, err := r.hostAttributes(ctx, hostID)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	rules, err := r.userRules(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	return permCount > 0, nil
+	return rbac.EvaluateHostAccess(rules, host, rbac.NewAccessRequest(ctx, operation)), nil
 }
 
-// GetUserHostPermissions 获取用户对指定主机的所有操作权限
+// GetUserHostPermissions 获取用户对指定主机当前生效的所有操作权限
 func (r *assetPermissionRepo) GetUserHostPermissions(ctx context.Context, userID, hostID uint) (uint, error) {
-	// 首先检查用户是否是管理员
-	var adminCount int64
-	err := r.db.WithContext(ctx).
-		Table("sys_user_role AS ur").
-		Joins("JOIN sys_role AS r ON ur.role_id = r.id").
-		Where("ur.user_id = ? AND r.code = ?", userID, "admin").
-		Count(&adminCount).Error
-
+	isAdmin, err := r.isAdmin(ctx, userID)
 	if err != nil {
 		return 0, err
 	}
 
 	// 管理员拥有所有权限
-	if adminCount > 0 {
+	if isAdmin {
 		return rbac.PermissionAll, nil
 	}
 
-	// 获取主机所属的资产分组ID
-	var groupID uint
-	err = r.db.WithContext(ctx).
-		Table("hosts").
-		Select("group_id").
-		Where("id = ?", hostID).
-		Scan(&groupID).Error
-
+	host, err := r.hostAttributes(ctx, hostID)
 	if err != nil {
 		return 0, err
 	}
+	rules, err := r.userRules(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return rbac.EffectiveHostPermissions(rules, host, rbac.NewAccessRequest(ctx, 0)), nil
+}
+
+// isAdmin 检查用户是否是管理员
+func (r *assetPermissionRepo) isAdmin(ctx context.Context, userID uint) (bool, error) {
+	var adminCount int64
+	err := r.db.WithContext(ctx).
+		Table("sys_user_role AS ur").
+		Joins("JOIN sys_role AS r ON ur.role_id = r.id").
+		Where("ur.user_id = ? AND r.code = ?", userID, "admin").
+		Count(&adminCount).Error
+	return adminCount > 0, err
+}
 
-	// 查询用户对该主机的所有权限（通过OR操作组合权限）
-	var permissions uint
-	err = r.db.WithContext(ctx).Raw(`
-		SELECT COALESCE(BIT_OR(p.permissions), 0) as permissions
-		FROM sys_role_asset_permission AS p
-		JOIN sys_user_role AS ur ON p.role_id = ur.role_id
-		WHERE ur.user_id = ? AND p.asset_group_id = ? AND p.deleted_at IS NULL
-		AND (JSON_LENGTH(COALESCE(p.host_ids, JSON_ARRAY())) = 0 OR JSON_CONTAINS(p.host_ids, CAST(? AS JSON)))
-	`, userID, groupID, hostID).Scan(&permissions).Error
+// userRules 获取用户所有角色（含当前项目的项目角色）的授权规则，以及用户本人的临时授权
+func (r *assetPermissionRepo) userRules(ctx context.Context, userID uint) ([]*rbac.SysRoleAssetPermission, error) {
+	var rules []*rbac.SysRoleAssetPermission
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? OR (user_id = 0 AND role_id IN (?))", userID, userRoleIDs(ctx, r.db, userID)).
+		Order("id ASC").
+		Find(&rules).Error
+	return rules, err
+}
 
-	return permissions, err
+// hostAttributes 获取参与规则计算的主机属性，主机不存在时返回零值
+func (r *assetPermissionRepo) hostAttributes(ctx context.Context, hostID uint) (rbac.HostAttributes, error) {
+	var host rbac.HostAttributes
+	err := r.db.WithContext(ctx).
+		Table("hosts").
+		Select("id, group_id, tags").
+		Where("id = ? AND deleted_at IS NULL", hostID).
+		Scopes(ProjectScoped(ctx, "project_id")).
+		Scan(&host).Error
+	return host, err
 }
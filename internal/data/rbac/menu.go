@@ -79,6 +79,25 @@ func (r *menuRepo) GetByID(ctx context.Context, id uint) (*rbac.SysMenu, error)
 	return &menu, err
 }
 
+func (r *menuRepo) GetByCode(ctx context.Context, code string) (*rbac.SysMenu, error) {
+	var menu rbac.SysMenu
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&menu).Error
+	return &menu, err
+}
+
+// GetCodesByUserID 获取用户所有启用角色（含当前项目的项目角色）下启用菜单（含隐藏菜单和按钮）的编码
+func (r *menuRepo) GetCodesByUserID(ctx context.Context, userID uint) ([]string, error) {
+	var codes []string
+	err := r.db.WithContext(ctx).Model(&rbac.SysMenu{}).
+		Joins("JOIN sys_role_menu ON sys_role_menu.menu_id = sys_menu.id").
+		Joins("JOIN sys_role ON sys_role.id = sys_role_menu.role_id AND sys_role.deleted_at IS NULL").
+		Where("sys_role_menu.role_id IN (?)", userRoleIDs(ctx, r.db, userID)).
+		Where("sys_menu.status = 1 AND sys_role.status = 1 AND sys_menu.code <> ''").
+		Distinct().
+		Pluck("sys_menu.code", &codes).Error
+	return codes, err
+}
+
 func (r *menuRepo) GetTree(ctx context.Context) ([]*rbac.SysMenu, error) {
 	var menus []*rbac.SysMenu
 	err := r.db.WithContext(ctx).
@@ -109,8 +128,8 @@ func (r *menuRepo) GetByUserID(ctx context.Context, userID uint) ([]*rbac.SysMen
 	var menus []*rbac.SysMenu
 	err := r.db.WithContext(ctx).
 		Joins("JOIN sys_role_menu ON sys_role_menu.menu_id = sys_menu.id").
-		Joins("JOIN sys_user_role ON sys_user_role.role_id = sys_role_menu.role_id").
-		Where("sys_user_role.user_id = ? AND sys_menu.status = 1 AND sys_menu.visible = 1", userID).
+		Where("sys_role_menu.role_id IN (?)", userRoleIDs(ctx, r.db, userID)).
+		Where("sys_menu.status = 1 AND sys_menu.visible = 1").
 		Distinct().
 		Order("sys_menu.sort ASC").
 		Find(&menus).Error
@@ -0,0 +1,171 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type projectRepo struct {
+	db *gorm.DB
+}
+
+func NewProjectRepo(db *gorm.DB) rbac.ProjectRepo {
+	return &projectRepo{db: db}
+}
+
+func (r *projectRepo) Create(ctx context.Context, project *rbac.SysProject) error {
+	return r.db.WithContext(ctx).Create(project).Error
+}
+
+func (r *projectRepo) Update(ctx context.Context, project *rbac.SysProject) error {
+	return r.db.WithContext(ctx).Model(project).
+		Select("name", "code", "description", "status").
+		Updates(project).Error
+}
+
+func (r *projectRepo) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("project_id = ?", id).Delete(&rbac.SysProjectMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&rbac.SysProject{}, id).Error
+	})
+}
+
+func (r *projectRepo) GetByID(ctx context.Context, id uint) (*rbac.SysProject, error) {
+	var project rbac.SysProject
+	if err := r.db.WithContext(ctx).First(&project, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, rbac.ErrProjectNotFound
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *projectRepo) List(ctx context.Context, keyword string) ([]*rbac.SysProject, error) {
+	var projects []*rbac.SysProject
+	query := r.db.WithContext(ctx).Model(&rbac.SysProject{})
+	if keyword != "" {
+		query = query.Where("name LIKE ? OR code LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if err := query.Order("id ASC").Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return projects, nil
+	}
+
+	// 填充成员数量
+	var counts []struct {
+		ProjectID uint
+		Count     int64
+	}
+	if err := r.db.WithContext(ctx).Model(&rbac.SysProjectMember{}).
+		Select("project_id, COUNT(*) AS count").
+		Group("project_id").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	countMap := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		countMap[c.ProjectID] = c.Count
+	}
+	for _, project := range projects {
+		project.MemberCount = countMap[project.ID]
+	}
+	return projects, nil
+}
+
+func (r *projectRepo) ListByUserID(ctx context.Context, userID uint) ([]*rbac.SysProject, error) {
+	var projects []*rbac.SysProject
+	err := r.db.WithContext(ctx).
+		Joins("JOIN sys_project_member AS m ON m.project_id = sys_project.id").
+		Where("m.user_id = ? AND sys_project.status = 1", userID).
+		Order("sys_project.id ASC").
+		Find(&projects).Error
+	return projects, err
+}
+
+func (r *projectRepo) ListMembers(ctx context.Context, projectID uint) ([]*rbac.ProjectMemberInfo, error) {
+	var members []*rbac.ProjectMemberInfo
+	err := r.db.WithContext(ctx).
+		Table("sys_project_member AS m").
+		Select("m.id, m.project_id, m.user_id, u.username, u.real_name, m.role_id, r.name AS role_name, m.created_at").
+		Joins("JOIN sys_user AS u ON u.id = m.user_id AND u.deleted_at IS NULL").
+		Joins("LEFT JOIN sys_role AS r ON r.id = m.role_id AND r.deleted_at IS NULL").
+		Where("m.project_id = ?", projectID).
+		Order("m.id ASC").
+		Scan(&members).Error
+	return members, err
+}
+
+func (r *projectRepo) SaveMember(ctx context.Context, member *rbac.SysProjectMember) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role_id", "updated_at"}),
+	}).Create(member).Error
+}
+
+func (r *projectRepo) RemoveMember(ctx context.Context, projectID, userID uint) error {
+	return r.db.WithContext(ctx).
+		Where("project_id = ? AND user_id = ?", projectID, userID).
+		Delete(&rbac.SysProjectMember{}).Error
+}
+
+func (r *projectRepo) CountResources(ctx context.Context, projectID uint, table string) (int64, error) {
+	db := r.db.WithContext(ctx)
+	// 插件未启用时资源表可能不存在
+	if !db.Migrator().HasTable(table) {
+		return 0, nil
+	}
+	query := db.Table(table).Where("project_id = ?", projectID)
+	if db.Migrator().HasColumn(table, "deleted_at") {
+		query = query.Where("deleted_at IS NULL")
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+func (r *projectRepo) AssignResources(ctx context.Context, projectID uint, table string, ids []uint) (int64, error) {
+	// 按表名更新，不经过模型的 project_id 只允许创建时写入的限制
+	result := r.db.WithContext(ctx).Table(table).
+		Where("id IN ?", ids).
+		Update("project_id", projectID)
+	return result.RowsAffected, result.Error
+}
+
+// userRoleIDs 用户当前生效的角色ID子查询：全局角色，加上用户在当前项目中的项目角色
+func userRoleIDs(ctx context.Context, db *gorm.DB, userID uint) *gorm.DB {
+	scope := rbac.ProjectScopeFromContext(ctx)
+	if scope == nil || scope.CurrentID == 0 {
+		return db.Table("sys_user_role").Select("role_id").Where("user_id = ?", userID)
+	}
+	return db.Raw("SELECT role_id FROM sys_user_role WHERE user_id = ? "+
+		"UNION SELECT role_id FROM sys_project_member WHERE user_id = ? AND project_id = ? AND role_id > 0",
+		userID, userID, scope.CurrentID)
+}
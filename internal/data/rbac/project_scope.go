@@ -0,0 +1,138 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// projectScopedKey 标记语句已追加项目过滤，链式调用中 Count 和 Find 共用语句时避免重复追加
+const projectScopedKey = "opshub:project_scoped"
+
+// ProjectScopePlugin 按请求上下文中的项目范围隔离数据
+// 模型含 ProjectID 字段时，查询、更新、删除自动追加 project_id 过滤，创建时填充当前项目
+// 上下文中没有项目范围（后台任务等）或平台管理员未选择项目时不做限制
+// 只按表名查询、不经过模型的语句不会被过滤，需要时使用 ProjectScoped 显式过滤
+type ProjectScopePlugin struct{}
+
+func (ProjectScopePlugin) Name() string {
+	return "opshub:project_scope"
+}
+
+func (p ProjectScopePlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register(p.Name(), fillProjectID); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register(p.Name(), filterProjectID); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register(p.Name(), filterProjectID); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register(p.Name(), filterProjectID); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register(p.Name(), filterProjectID)
+}
+
+func projectField(db *gorm.DB) *schema.Field {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField("ProjectID")
+}
+
+func filterProjectID(db *gorm.DB) {
+	field := projectField(db)
+	if field == nil || db.Error != nil {
+		return
+	}
+	scope := rbac.ProjectScopeFromContext(db.Statement.Context)
+	if scope == nil || scope.All {
+		return
+	}
+	if _, ok := db.Statement.Settings.Load(projectScopedKey); ok {
+		return
+	}
+	db.Statement.Settings.Store(projectScopedKey, true)
+
+	values := make([]interface{}, 0, len(scope.ProjectIDs))
+	for _, id := range scope.ProjectIDs {
+		values = append(values, id)
+	}
+	// 没有可见项目时 IN 条件为空，不返回任何数据
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{
+		Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+		Values: values,
+	}}})
+}
+
+// fillProjectID 创建时把资源归属到当前项目，请求体中传入的 projectId 会被覆盖
+// 平台管理员未选择项目时保留传入的项目，没有当前项目的普通用户不能创建资源
+func fillProjectID(db *gorm.DB) {
+	field := projectField(db)
+	if field == nil || db.Error != nil {
+		return
+	}
+	scope := rbac.ProjectScopeFromContext(db.Statement.Context)
+	if scope == nil || (scope.All && scope.CurrentID == 0) {
+		return
+	}
+	if scope.CurrentID == 0 {
+		db.AddError(rbac.ErrProjectForbidden)
+		return
+	}
+
+	ctx := db.Statement.Context
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			setProjectID(ctx, field, reflect.Indirect(rv.Index(i)), scope.CurrentID)
+		}
+	case reflect.Struct:
+		setProjectID(ctx, field, rv, scope.CurrentID)
+	}
+}
+
+func setProjectID(ctx context.Context, field *schema.Field, rv reflect.Value, projectID uint) {
+	_ = field.Set(ctx, rv, projectID)
+}
+
+// ProjectScoped 按上下文中的项目范围过滤指定列，用于只按表名查询的语句
+func ProjectScoped(ctx context.Context, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		scope := rbac.ProjectScopeFromContext(ctx)
+		if scope == nil || scope.All {
+			return db
+		}
+		if len(scope.ProjectIDs) == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where(column+" IN ?", scope.ProjectIDs)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type scopedResource struct {
+	ID        uint
+	Name      string
+	ProjectID uint `gorm:"<-:create"`
+}
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/opshub",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := db.Use(ProjectScopePlugin{}); err != nil {
+		t.Fatalf("use plugin: %v", err)
+	}
+	return db
+}
+
+func TestProjectScopeCreate(t *testing.T) {
+	db := newDryRunDB(t)
+
+	tests := []struct {
+		name      string
+		scope     *rbac.ProjectScope
+		projectID uint
+		want      uint
+		wantErr   error
+	}{
+		{"请求体传入其他项目时覆盖为当前项目", &rbac.ProjectScope{ProjectIDs: []uint{2}, CurrentID: 2}, 99, 2, nil},
+		{"未传项目时填充当前项目", &rbac.ProjectScope{ProjectIDs: []uint{2}, CurrentID: 2}, 0, 2, nil},
+		{"平台管理员未选择项目时保留传入的项目", &rbac.ProjectScope{All: true}, 5, 5, nil},
+		{"没有项目的普通用户不能创建", &rbac.ProjectScope{}, 99, 99, rbac.ErrProjectForbidden},
+		{"后台任务不处理", nil, 7, 7, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.scope != nil {
+				ctx = rbac.ContextWithProjectScope(ctx, tt.scope)
+			}
+			res := &scopedResource{Name: "demo", ProjectID: tt.projectID}
+			err := db.WithContext(ctx).Create(res).Error
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if res.ProjectID != tt.want {
+				t.Errorf("ProjectID = %d, want %d", res.ProjectID, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectScopeQuery(t *testing.T) {
+	db := newDryRunDB(t)
+
+	ctx := rbac.ContextWithProjectScope(context.Background(), &rbac.ProjectScope{ProjectIDs: []uint{2}, CurrentID: 2})
+	stmt := db.WithContext(ctx).Find(&[]scopedResource{}).Statement
+	if !strings.Contains(stmt.SQL.String(), "`scoped_resources`.`project_id`") {
+		t.Errorf("SQL = %s, want project filter", stmt.SQL.String())
+	}
+
+	stmt = db.WithContext(context.Background()).Find(&[]scopedResource{}).Statement
+	if strings.Contains(stmt.SQL.String(), "project_id") {
+		t.Errorf("SQL = %s, want no project filter", stmt.SQL.String())
+	}
+}
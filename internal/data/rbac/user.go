@@ -23,6 +23,7 @@ import (
 	"context"
 	"github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userRepo struct {
@@ -43,7 +44,14 @@ func (r *userRepo) Create(ctx context.Context, user *rbac.SysUser) error {
 }
 
 func (r *userRepo) Update(ctx context.Context, user *rbac.SysUser) error {
-	return r.db.WithContext(ctx).Model(user).Omit("created_at").Updates(user).Error
+	// 角色和岗位走各自的分配接口，这里不随基本信息一起写入
+	return r.db.WithContext(ctx).Model(user).Omit("created_at", clause.Associations).Updates(user).Error
+}
+
+// UpdateProfile 只更新个人资料字段，空值也会写入
+func (r *userRepo) UpdateProfile(ctx context.Context, user *rbac.SysUser) error {
+	return r.db.WithContext(ctx).Model(&rbac.SysUser{}).Where("id = ?", user.ID).
+		Select("real_name", "email", "phone", "avatar").Omit(clause.Associations).Updates(user).Error
 }
 
 func (r *userRepo) Delete(ctx context.Context, id uint) error {
@@ -274,7 +274,7 @@ func (m *Manager) syncPluginMenus(pluginName string, menus []MenuConfig) error {
 	}
 
 	// 生成菜单code前缀（将连字符替换为下划线）
-	codePrefix := "_" + strings.ReplaceAll(pluginName, "-", "_")
+	codePrefix := RootMenuCode(pluginName)
 	// 原始前缀（保留连字符，用于清理旧格式记录）
 	originalPrefix := "_" + pluginName
 
@@ -343,7 +343,7 @@ func (m *Manager) syncPluginMenus(pluginName string, menus []MenuConfig) error {
 	// 第七步：创建子菜单
 	for _, menu := range childMenus {
 		// 简化code生成：_pluginName_childPath
-		menuCode := codePrefix + pathToCode(menu.Path)
+		menuCode := MenuCode(pluginName, menu.Path)
 		visible := 1
 		if menu.Hidden {
 			visible = 0
@@ -381,7 +381,7 @@ func (m *Manager) syncPluginMenus(pluginName string, menus []MenuConfig) error {
 
 // removePluginMenus 从数据库移除插件菜单
 func (m *Manager) removePluginMenus(pluginName string) error {
-	codePrefix := "_" + strings.ReplaceAll(pluginName, "-", "_")
+	codePrefix := RootMenuCode(pluginName)
 	originalPrefix := "_" + pluginName
 
 	// 硬删除所有以此前缀开头的菜单
@@ -399,6 +399,17 @@ func (m *Manager) removePluginMenus(pluginName string) error {
 	return nil
 }
 
+// RootMenuCode 插件顶级菜单的code，例如 task -> _task
+// 插件登记接口权限时使用，与同步到数据库的菜单code保持一致
+func RootMenuCode(pluginName string) string {
+	return "_" + strings.ReplaceAll(pluginName, "-", "_")
+}
+
+// MenuCode 插件子菜单的code，例如 task、/task/execute -> _task_task_execute
+func MenuCode(pluginName, path string) string {
+	return RootMenuCode(pluginName) + pathToCode(path)
+}
+
 // pathToCode 将路径转换为菜单code
 // 例如: /task/execute -> _task_execute
 func pathToCode(path string) string {
@@ -20,13 +20,18 @@
 package asset
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/conf"
 	assetService "github.com/ydcloud-dy/opshub/internal/service/asset"
 	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
 	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
 	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
 	rbacdata "github.com/ydcloud-dy/opshub/internal/data/rbac"
 	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"gorm.io/gorm"
 )
 
@@ -64,7 +69,26 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 		groups.GET("/:id", s.assetGroupService.GetGroup)
 		groups.PUT("/:id", s.assetGroupService.UpdateGroup)
 		groups.DELETE("/:id", s.assetGroupService.DeleteGroup)
+		// 分组指标趋势，只汇总有权限的主机
+		groups.GET("/:id/metrics", s.hostService.GetGroupMetrics)
+		// 分组配置漂移，查看只返回有权限的主机，设置基线和立即检查仅限管理员
+		groups.GET("/:id/drift", s.hostService.GetGroupDrift)
+		groups.POST("/:id/baseline", s.authMiddleware.RequireAdmin(), s.hostService.BaselineGroup)
+		groups.POST("/:id/drift/check", s.authMiddleware.RequireAdmin(), s.hostService.CheckGroupDrift)
 	}
+	// 分组树用于主机页筛选，登录即可读取
+	rbacService.DeclareRoutes(groups,
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/tree", Code: rbacbiz.PermissionLoginOnly},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/parent-options", Code: rbacbiz.PermissionLoginOnly},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "business-group:create", Name: "新增分组"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "business-group"},
+		rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "business-group:update", Name: "编辑分组"},
+		rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "business-group:delete", Name: "删除分组"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/metrics", Code: "business-group"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/drift", Code: "business-group"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/baseline", Code: "business-group"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/drift/check", Code: "business-group"},
+	)
 
 	// 主机管理
 	hosts := r.Group("/hosts")
@@ -99,6 +123,53 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 			s.hostService.CollectHostInfo)
 		hosts.POST("/:id/test", s.hostService.TestHostConnection)
 
+		// 指标趋势 - 查看权限
+		hosts.GET("/:id/metrics",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionView),
+			s.hostService.GetHostMetrics)
+
+		// 主机清单 - 查看需要查看权限，立即采集需要采集权限
+		hosts.GET("/:id/inventory",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionView),
+			s.hostService.GetHostInventory)
+		hosts.POST("/:id/inventory/collect",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionCollect),
+			s.hostService.CollectHostInventory)
+
+		// 配置快照与漂移 - 查看需要查看权限，采集需要采集权限，删除和设置基线需要编辑权限
+		hosts.GET("/:id/snapshots",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionView),
+			s.hostService.ListHostSnapshots)
+		hosts.POST("/:id/snapshots",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionCollect),
+			s.hostService.CreateHostSnapshot)
+		hosts.GET("/:id/snapshots/diff",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionView),
+			s.hostService.DiffHostSnapshots)
+		hosts.GET("/:id/snapshots/:sid",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionView),
+			s.hostService.GetHostSnapshot)
+		hosts.DELETE("/:id/snapshots/:sid",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionEdit),
+			s.hostService.DeleteHostSnapshot)
+		hosts.PUT("/:id/snapshots/:sid/baseline",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionEdit),
+			s.hostService.SetHostBaseline)
+		hosts.POST("/:id/drift/check",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionCollect),
+			s.hostService.CheckHostDrift)
+		hosts.GET("/:id/drift/reports",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionView),
+			s.hostService.ListDriftReports)
+
+		// 主机密钥 - 查看需要查看权限，预置、确认、删除仅限管理员
+		hosts.GET("/:id/host-key",
+			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionView),
+			s.hostService.GetHostKey)
+		hosts.PUT("/:id/host-key", s.authMiddleware.RequireAdmin(), s.hostService.PinHostKey)
+		hosts.POST("/:id/host-key/accept", s.authMiddleware.RequireAdmin(), s.hostService.AcceptHostKey)
+		hosts.DELETE("/:id/host-key", s.authMiddleware.RequireAdmin(), s.hostService.DeleteHostKey)
+
 		// 文件管理权限 - 文件上传、下载、删除
 		hosts.GET("/:id/files",
 			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
@@ -113,6 +184,53 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionFile),
 			s.hostService.DeleteHostFile)
 	}
+	// 单台主机的操作另由主机权限校验，批量导入和删除没有逐台校验，单独设置按钮权限
+	rbacService.DeclareRoutes(hosts,
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/template/download", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/import", Code: "host-management:import", Name: "导入主机"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/batch-collect", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/batch-delete", Code: "host-management:batch-delete", Name: "批量删除主机"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/collect", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/test", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/metrics", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/inventory", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/inventory/collect", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/snapshots", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/snapshots", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/snapshots/diff", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/snapshots/:sid", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id/snapshots/:sid", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id/snapshots/:sid/baseline", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/drift/check", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/drift/reports", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/host-key", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id/host-key", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/host-key/accept", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id/host-key", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/files", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/files/upload", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/files/download", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id/files", Code: "host-management"},
+	)
+
+	// 主机清单检索，只返回有权限的主机
+	r.GET("/inventory/search", s.hostService.SearchInventory)
+
+	// 主机密钥（known_hosts）列表，仅限管理员
+	r.GET("/host-keys", s.authMiddleware.RequireAdmin(), s.hostService.ListHostKeys)
+
+	// SSH连接池状态，仅限管理员
+	r.GET("/ssh-pool/stats", s.authMiddleware.RequireAdmin(), s.hostService.GetSSHPoolStats)
+	rbacService.DeclareRoutes(r,
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/inventory/search", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/host-keys", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/ssh-pool/stats", Code: "host-management"},
+	)
 
 	// 凭证管理
 	credentials := r.Group("/credentials")
@@ -124,6 +242,15 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 		credentials.PUT("/:id", s.hostService.UpdateCredential)
 		credentials.DELETE("/:id", s.hostService.DeleteCredential)
 	}
+	// 凭证下拉列表在主机表单中使用，登录即可读取
+	rbacService.DeclareRoutes(credentials,
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "asset:credentials"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/all", Code: rbacbiz.PermissionLoginOnly},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "asset:credentials"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "asset:credentials"},
+		rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "asset:credentials"},
+		rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "asset:credentials"},
+	)
 
 	// 云平台账号管理
 	cloudAccounts := r.Group("/cloud-accounts")
@@ -138,6 +265,17 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 		cloudAccounts.DELETE("/:id", s.hostService.DeleteCloudAccount)
 		cloudAccounts.POST("/import", s.hostService.ImportFromCloud)
 	}
+	rbacService.DeclareRoutes(cloudAccounts,
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "cloud-accounts"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/all", Code: rbacbiz.PermissionLoginOnly},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "cloud-accounts"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/regions", Code: "cloud-accounts"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/instances", Code: "cloud-accounts"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "cloud-accounts:create", Name: "新增云账号"},
+		rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "cloud-accounts:update", Name: "编辑云账号"},
+		rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "cloud-accounts:delete", Name: "删除云账号"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/import", Code: "cloud-accounts:import", Name: "导入云主机"},
+	)
 
 	// SSH终端 - 终端权限
 	terminal := r.Group("/asset/terminal")
@@ -146,22 +284,67 @@ func (s *HTTPServer) RegisterRoutes(r *gin.RouterGroup) {
 			s.authMiddleware.RequireHostPermission(rbacbiz.PermissionTerminal),
 			s.HandleSSHConnection)
 		terminal.POST("/:id/resize", s.ResizeTerminal)
+
+		// 在线会话 - 加入需要该主机的终端权限，强制终止仅限管理员
+		terminal.GET("/sessions", s.ListLiveSessions)
+		terminal.GET("/sessions/:sid/join", s.JoinTerminalSession)
+		terminal.DELETE("/sessions/:sid", s.authMiddleware.RequireAdmin(), s.TerminateTerminalSession)
 	}
+	rbacService.DeclareRoutes(terminal,
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/resize", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/sessions", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/sessions/:sid/join", Code: "host-management"},
+		rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/sessions/:sid", Code: "host-management"},
+	)
 
 	// 终端审计
 	terminalSessions := r.Group("/terminal-sessions")
 	{
 		terminalSessions.GET("", s.terminalAuditHandler.ListTerminalSessions)
+		terminalSessions.GET("/commands", s.terminalAuditHandler.ListTerminalCommands)
+		terminalSessions.GET("/search", s.terminalAuditHandler.SearchRecordings)
+		terminalSessions.GET("/:id/commands", s.terminalAuditHandler.ListSessionCommands)
 		terminalSessions.GET("/:id/play", s.terminalAuditHandler.PlayTerminalSession)
 		terminalSessions.DELETE("/:id", s.terminalAuditHandler.DeleteTerminalSession)
 	}
+	rbacService.DeclareRoutes(terminalSessions,
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "asset_terminal_audit"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/commands", Code: "asset_terminal_audit"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/search", Code: "asset_terminal_audit"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/commands", Code: "asset_terminal_audit"},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/play", Code: "asset_terminal_audit"},
+		rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "asset_terminal_audit:delete", Name: "删除会话"},
+	)
+}
+
+// Workers 资产模块的后台定时任务
+type Workers struct {
+	Metrics   *assetbiz.HostMetricUseCase
+	Inventory *assetbiz.InventoryUseCase
+	Drift     *assetbiz.DriftUseCase
+}
+
+// Start 启动主机指标、主机清单的定时采集和配置漂移的定时检查
+func (w *Workers) Start() {
+	w.Metrics.Start()
+	w.Inventory.Start()
+	w.Drift.Start()
 }
 
-// NewAssetServices 创建asset相关的服务
-func NewAssetServices(db *gorm.DB) (
+// Stop 停止所有后台任务
+func (w *Workers) Stop() {
+	w.Metrics.Stop()
+	w.Inventory.Stop()
+	w.Drift.Stop()
+}
+
+// NewAssetServices 创建asset相关的服务，返回的后台任务需调用 Start 启动
+func NewAssetServices(db *gorm.DB, cfg *conf.Config) (
 	*assetService.AssetGroupService,
 	*assetService.HostService,
 	*TerminalManager,
+	*Workers,
 ) {
 	// 初始化Repository
 	assetGroupRepo := assetdata.NewAssetGroupRepo(db)
@@ -169,20 +352,74 @@ func NewAssetServices(db *gorm.DB) (
 	credentialRepo := assetdata.NewCredentialRepo(db)
 	cloudAccountRepo := assetdata.NewCloudAccountRepo(db)
 	assetPermissionRepo := rbacdata.NewAssetPermissionRepo(db)
+	hostKeyRepo := assetdata.NewHostKeyRepo(db)
+	hostMetricRepo := assetdata.NewHostMetricRepo(db)
+	hostInventoryRepo := assetdata.NewHostInventoryRepo(db)
+	driftRepo := assetdata.NewDriftRepo(db)
 
 	// 初始化UseCase
-	assetGroupUseCase := assetbiz.NewAssetGroupUseCase(assetGroupRepo)
+	hostKeyUseCase := assetbiz.NewHostKeyUseCase(hostKeyRepo, hostRepo)
+	gatewayUseCase := assetbiz.NewGatewayUseCase(hostRepo, assetGroupRepo, credentialRepo, hostKeyUseCase)
+	assetGroupUseCase := assetbiz.NewAssetGroupUseCase(assetGroupRepo, gatewayUseCase)
 	credentialUseCase := assetbiz.NewCredentialUseCase(credentialRepo, hostRepo)
 	cloudAccountUseCase := assetbiz.NewCloudAccountUseCase(cloudAccountRepo)
-	hostUseCase := assetbiz.NewHostUseCase(hostRepo, credentialRepo, assetGroupRepo, cloudAccountRepo)
+	sshConnector := assetbiz.NewSSHConnector(hostRepo, credentialRepo, hostKeyUseCase, gatewayUseCase, sshclient.DefaultPool())
+	hostUseCase := assetbiz.NewHostUseCase(hostRepo, credentialRepo, assetGroupRepo, cloudAccountRepo, hostMetricRepo, sshConnector)
+	hostMetricUseCase := assetbiz.NewHostMetricUseCase(hostMetricRepo, hostRepo, assetGroupRepo, hostUseCase, metricsOptions(cfg.Metrics))
+	inventoryUseCase := assetbiz.NewInventoryUseCase(hostInventoryRepo, hostRepo, hostUseCase, inventoryOptions(cfg.Inventory))
+	driftUseCase := assetbiz.NewDriftUseCase(driftRepo, hostRepo, assetGroupRepo, hostUseCase, driftOptions(cfg.Drift))
 	assetPermissionUseCase := rbacbiz.NewAssetPermissionUseCase(assetPermissionRepo)
 
+	// 主机分组的临时权限申请审批通过后创建个人授权规则
+	rbacbiz.RegisterAccessGranter(rbacbiz.AccessResourceHostGroup, assetbiz.NewHostGroupAccessGranter(assetGroupRepo, hostRepo, assetPermissionRepo))
+
+	// Pod 终端等插件的录制与主机终端共用全文索引
+	assetbiz.RegisterRecordingIndexer(assetbiz.NewRecordingIndexUseCase(assetdata.NewRecordingIndexRepo(db)))
+
+	// 按项目隔离的资产
+	rbacbiz.RegisterProjectResource(
+		rbacbiz.ProjectResource{Type: "host", Name: "主机", Table: "hosts"},
+		rbacbiz.ProjectResource{Type: "asset_group", Name: "业务分组", Table: "asset_group"},
+		rbacbiz.ProjectResource{Type: "credential", Name: "凭据", Table: "credentials"},
+		rbacbiz.ProjectResource{Type: "cloud_account", Name: "云账号", Table: "cloud_accounts"},
+	)
+
 	// 初始化Service
 	assetGroupService := assetService.NewAssetGroupService(assetGroupUseCase)
-	hostService := assetService.NewHostService(hostUseCase, credentialUseCase, cloudAccountUseCase, assetPermissionUseCase)
+	hostService := assetService.NewHostService(hostUseCase, credentialUseCase, cloudAccountUseCase, hostMetricUseCase, inventoryUseCase, driftUseCase, assetPermissionUseCase)
 
 	// 初始化TerminalManager
-	terminalManager := NewTerminalManager(hostUseCase, db)
+	terminalManager := NewTerminalManager(hostUseCase, assetPermissionUseCase, db)
 
-	return assetGroupService, hostService, terminalManager
+	workers := &Workers{Metrics: hostMetricUseCase, Inventory: inventoryUseCase, Drift: driftUseCase}
+
+	return assetGroupService, hostService, terminalManager, workers
+}
+
+// metricsOptions 将配置文件中的秒、小时转换为主机指标配置
+func metricsOptions(cfg conf.MetricsConfig) assetbiz.MetricsOptions {
+	return assetbiz.MetricsOptions{
+		Interval:            time.Duration(cfg.CollectInterval) * time.Second,
+		Concurrency:         cfg.Concurrency,
+		RawRetention:        time.Duration(cfg.RawRetention) * time.Hour,
+		FiveMinuteRetention: time.Duration(cfg.FiveMinuteRetention) * time.Hour,
+		HourlyRetention:     time.Duration(cfg.HourlyRetention) * time.Hour,
+	}
+}
+
+// inventoryOptions 将配置文件中的小时转换为主机清单配置
+func inventoryOptions(cfg conf.InventoryConfig) assetbiz.InventoryOptions {
+	return assetbiz.InventoryOptions{
+		Interval:    time.Duration(cfg.CollectInterval) * time.Hour,
+		Concurrency: cfg.Concurrency,
+	}
+}
+
+// driftOptions 将配置文件中的小时、天转换为漂移检查配置
+func driftOptions(cfg conf.DriftConfig) assetbiz.DriftOptions {
+	return assetbiz.DriftOptions{
+		Interval:        time.Duration(cfg.CheckInterval) * time.Hour,
+		Concurrency:     cfg.Concurrency,
+		ReportRetention: time.Duration(cfg.ReportRetention) * 24 * time.Hour,
+	}
 }
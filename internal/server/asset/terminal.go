@@ -33,6 +33,8 @@ import (
 	"github.com/gorilla/websocket"
 	"golang.org/x/crypto/ssh"
 	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -79,78 +81,47 @@ type TerminalSession struct {
 	StderrPipe  io.Reader
 	Recorder    *AsciinemaRecorder // 录制器
 	CreatedAt   time.Time
+
+	participants map[string]*terminalParticipant // 发起人、观察者和协同操作者的连接
+	partMu       sync.RWMutex
+	stdinMu      sync.Mutex
+	closed       bool   // 会话已结束，不再接受加入
+	terminatedBy string // 被管理员强制终止时记录操作人
+
+	RecordID    uint                    // 会话记录ID（ssh_terminal_sessions）
+	cmdLine     *assetbiz.CommandLine   // 从输入还原命令行
+	lineMu      sync.Mutex              // 输入和输出分别在不同 goroutine 中更新命令行
+	pending     *pendingCommand         // 等待用户确认的命令
+	saveCommand func(*assetbiz.TerminalCommand)
 }
 
 // TerminalManager 终端管理器
 type TerminalManager struct {
-	sessions    map[string]*TerminalSession
-	mu          sync.RWMutex
-	hostUseCase *assetbiz.HostUseCase
-	db          *gorm.DB
+	sessions               map[string]*TerminalSession
+	mu                     sync.RWMutex
+	hostUseCase            *assetbiz.HostUseCase
+	assetPermissionUseCase *rbacbiz.AssetPermissionUseCase
+	recordings             *assetbiz.RecordingIndexUseCase
+	db                     *gorm.DB
 }
 
 // NewTerminalManager 创建终端管理器
-func NewTerminalManager(hostUseCase *assetbiz.HostUseCase, db *gorm.DB) *TerminalManager {
+func NewTerminalManager(hostUseCase *assetbiz.HostUseCase, assetPermissionUseCase *rbacbiz.AssetPermissionUseCase, db *gorm.DB) *TerminalManager {
 	return &TerminalManager{
-		sessions:    make(map[string]*TerminalSession),
-		hostUseCase: hostUseCase,
-		db:          db,
+		sessions:               make(map[string]*TerminalSession),
+		hostUseCase:            hostUseCase,
+		assetPermissionUseCase: assetPermissionUseCase,
+		recordings:             assetbiz.NewRecordingIndexUseCase(assetdata.NewRecordingIndexRepo(db)),
+		db:                     db,
 	}
 }
 
 // CreateSession 创建SSH会话
 func (tm *TerminalManager) CreateSession(ctx context.Context, hostID uint, userID uint, username string, cols, rows uint16) (*TerminalSession, error) {
-	// 获取主机信息
-	hostVO, err := tm.hostUseCase.GetByID(ctx, hostID)
-	if err != nil {
-		return nil, fmt.Errorf("获取主机信息失败: %w", err)
-	}
-
-	// 获取凭证（需要解密后的凭证）
-	var credential *assetbiz.Credential
-	if hostVO.CredentialID > 0 {
-		credentialRepo := tm.hostUseCase.GetCredentialRepo()
-		credential, err = credentialRepo.GetByIDDecrypted(ctx, hostVO.CredentialID)
-		if err != nil {
-			return nil, fmt.Errorf("获取凭证信息失败: %w", err)
-		}
-	} else {
-		return nil, fmt.Errorf("主机未配置凭证")
-	}
-
-	// 解析私钥
-	var signer ssh.Signer
-	var authMethod ssh.AuthMethod
-
-	if credential.Type == "key" {
-		// 使用私钥认证
-		if credential.Password != "" {
-			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(credential.PrivateKey), []byte(credential.Password))
-		} else {
-			signer, err = ssh.ParsePrivateKey([]byte(credential.PrivateKey))
-		}
-		if err != nil {
-			return nil, fmt.Errorf("解析私钥失败: %w", err)
-		}
-		authMethod = ssh.PublicKeys(signer)
-	} else {
-		// 使用密码认证
-		authMethod = ssh.Password(credential.Password)
-	}
-
-	// SSH配置
-	config := &ssh.ClientConfig{
-		User:            hostVO.SSHUser,
-		Auth:            []ssh.AuthMethod{authMethod},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         10 * time.Second,
-	}
-
-	// 连接SSH
-	address := fmt.Sprintf("%s:%d", hostVO.IP, hostVO.Port)
-	client, err := ssh.Dial("tcp", address, config)
+	// 交互式终端长时间占用连接，不从连接池借用；校验主机密钥，配置了跳板机时依次经过跳板机
+	host, client, err := tm.hostUseCase.Connector().DialHost(ctx, hostID)
 	if err != nil {
-		return nil, fmt.Errorf("SSH连接失败: %w", err)
+		return nil, err
 	}
 
 	// 创建会话
@@ -212,10 +183,10 @@ func (tm *TerminalManager) CreateSession(ctx context.Context, hostID uint, userI
 
 	// 创建会话对象
 	terminalSession := &TerminalSession{
-		ID:         fmt.Sprintf("%d-%d", hostID, time.Now().Unix()),
+		ID:         fmt.Sprintf("%d-%s", hostID, randomID()),
 		HostID:     hostID,
-		HostName:   hostVO.Name,
-		HostIP:     hostVO.IP,
+		HostName:   host.Name,
+		HostIP:     host.IP,
 		UserID:     userID,
 		Username:   username,
 		SSHClient:  client,
@@ -225,6 +196,28 @@ func (tm *TerminalManager) CreateSession(ctx context.Context, hostID uint, userI
 		StderrPipe: stderrPipe,
 		Recorder:   recorder,
 		CreatedAt:  time.Now(),
+
+		participants: make(map[string]*terminalParticipant),
+		cmdLine:      assetbiz.NewCommandLine(),
+		saveCommand:  tm.saveCommand,
+	}
+
+	// 会话开始时即写入会话记录，终端命令关联到该记录，会话结束时更新时长和状态
+	record := &assetbiz.TerminalSession{
+		HostID:   hostID,
+		HostName: host.Name,
+		HostIP:   host.IP,
+		UserID:   userID,
+		Username: username,
+		Status:   "recording",
+	}
+	if recorder != nil {
+		record.RecordingPath = recorder.GetRecordingPath()
+	}
+	if err := tm.db.Create(record).Error; err != nil {
+		appLogger.Error("保存终端会话记录失败", zap.Error(err), zap.Uint("hostID", hostID))
+	} else {
+		terminalSession.RecordID = record.ID
 	}
 
 	// 保存会话
@@ -261,7 +254,13 @@ func (tm *TerminalManager) CloseSession(sessionID string) error {
 		zap.Uint("userID", session.UserID),
 		zap.String("username", session.Username))
 
-	// 关闭录制器并保存会话信息
+	// 关闭录制器并更新会话记录
+	status := "completed"
+	if session.terminatedBy != "" {
+		status = "terminated"
+	}
+	var duration int
+	var fileSize int64
 	if session.Recorder != nil {
 		// 关闭录制器
 		if err := session.Recorder.Close(); err != nil {
@@ -269,49 +268,55 @@ func (tm *TerminalManager) CloseSession(sessionID string) error {
 		}
 
 		// 获取录制信息
-		duration := session.Recorder.GetDuration()
-		fileSize := session.Recorder.GetFileSize()
-		recordingPath := session.Recorder.GetRecordingPath()
+		duration = session.Recorder.GetDuration()
+		fileSize = session.Recorder.GetFileSize()
 
 		appLogger.Info("录制信息",
-			zap.String("recordingPath", recordingPath),
+			zap.String("recordingPath", session.Recorder.GetRecordingPath()),
 			zap.Int("duration", duration),
 			zap.Int64("fileSize", fileSize))
+	} else {
+		appLogger.Warn("会话没有录制器", zap.String("sessionID", sessionID))
+		duration = int(time.Since(session.CreatedAt).Seconds())
+		status = "failed"
+	}
 
-		// 保存会话记录到数据库
-		terminalSession := &assetbiz.TerminalSession{
-			HostID:        session.HostID,
-			HostName:      session.HostName,
-			HostIP:        session.HostIP,
-			UserID:        session.UserID,
-			Username:      session.Username,
-			RecordingPath: recordingPath,
-			Duration:      duration,
-			FileSize:      fileSize,
-			Status:        "completed",
+	recordID := session.RecordID
+	if recordID > 0 {
+		if err := tm.db.Model(&assetbiz.TerminalSession{}).Where("id = ?", session.RecordID).Updates(map[string]interface{}{
+			"duration":  duration,
+			"file_size": fileSize,
+			"status":    status,
+		}).Error; err != nil {
+			appLogger.Error("更新终端会话记录失败", zap.Error(err), zap.Uint("recordID", session.RecordID))
 		}
-
-		appLogger.Info("准备保存终端会话记录到数据库",
-			zap.Uint("hostID", terminalSession.HostID),
-			zap.String("hostName", terminalSession.HostName),
-			zap.Uint("userID", terminalSession.UserID),
-			zap.String("username", terminalSession.Username))
-
-		if err := tm.db.Create(terminalSession).Error; err != nil {
+	} else {
+		// 会话开始时写入记录失败，结束时补写
+		record := &assetbiz.TerminalSession{
+			HostID:   session.HostID,
+			HostName: session.HostName,
+			HostIP:   session.HostIP,
+			UserID:   session.UserID,
+			Username: session.Username,
+			Duration: duration,
+			FileSize: fileSize,
+			Status:   status,
+		}
+		if session.Recorder != nil {
+			record.RecordingPath = session.Recorder.GetRecordingPath()
+		}
+		if err := tm.db.Create(record).Error; err != nil {
 			appLogger.Error("保存终端会话记录失败",
 				zap.Error(err),
 				zap.Uint("hostID", session.HostID),
-				zap.Uint("userID", session.UserID),
-				zap.String("recordingPath", recordingPath))
-		} else {
-			appLogger.Info("终端会话记录已成功保存到数据库",
-				zap.Uint("sessionID", terminalSession.ID),
-				zap.String("username", terminalSession.Username),
-				zap.String("hostName", terminalSession.HostName),
-				zap.Int("duration", duration))
+				zap.Uint("userID", session.UserID))
 		}
-	} else {
-		appLogger.Warn("会话没有录制器", zap.String("sessionID", sessionID))
+		recordID = record.ID
+	}
+
+	// 录制结束后建立全文索引
+	if session.Recorder != nil && recordID > 0 {
+		go tm.indexRecording(session, recordID, duration, session.Recorder.GetRecordingPath())
 	}
 
 	// 关闭SSH连接
@@ -321,12 +326,29 @@ func (tm *TerminalManager) CloseSession(sessionID string) error {
 	if session.SSHClient != nil {
 		session.SSHClient.Close()
 	}
+	session.closeParticipants("会话已结束")
 
 	delete(tm.sessions, sessionID)
 	appLogger.Info("终端会话已关闭", zap.String("sessionID", sessionID))
 	return nil
 }
 
+// indexRecording 为结束的会话录制建立全文索引
+func (tm *TerminalManager) indexRecording(session *TerminalSession, recordID uint, duration int, path string) {
+	recording := &assetbiz.TerminalRecording{
+		Source:    assetbiz.RecordingSourceHost,
+		SessionID: recordID,
+		Target:    fmt.Sprintf("%s(%s)", session.HostName, session.HostIP),
+		UserID:    session.UserID,
+		Username:  session.Username,
+		StartedAt: session.CreatedAt,
+		Duration:  duration,
+	}
+	if err := tm.recordings.Index(context.Background(), recording, path); err != nil {
+		appLogger.Error("建立终端录制索引失败", zap.Uint("recordID", recordID), zap.Error(err))
+	}
+}
+
 // HandleSSHConnection 处理SSH WebSocket连接
 func (s *HTTPServer) HandleSSHConnection(c *gin.Context) {
 	hostIdStr := c.Param("id")
@@ -388,13 +410,6 @@ func (s *HTTPServer) HandleSSHConnection(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	// 设置读取超时，确保连接断开时能及时检测
-	conn.SetReadDeadline(time.Now().Add(5 * time.Minute))
-	conn.SetPongHandler(func(string) error {
-		conn.SetReadDeadline(time.Now().Add(5 * time.Minute))
-		return nil
-	})
-
 	// 创建SSH会话
 	session, err := s.terminalManager.CreateSession(c.Request.Context(), uint(hostId), uid, uname, uint16(cols), uint16(rows))
 	if err != nil {
@@ -415,66 +430,72 @@ func (s *HTTPServer) HandleSSHConnection(c *gin.Context) {
 
 	appLogger.Info("SSH会话创建成功", zap.String("sessionID", session.ID), zap.Int("hostId", hostId))
 
-	// 启动goroutine从SSH读取输出并发送到WebSocket
+	owner := newTerminalParticipant(conn, uid, uname, ParticipantOwner)
+	session.addParticipant(owner)
+
+	// 启动goroutine从SSH读取输出并发送给所有参与者
 	var wg sync.WaitGroup
 	wg.Add(2)
+	go s.pumpOutput(&wg, session, session.StdoutPipe)
+	go s.pumpOutput(&wg, session, session.StderrPipe)
+
+	// 处理发起人的输入，连接断开后结束会话
+	s.serveParticipant(session, owner)
+	session.removeParticipant(owner.ID)
+	appLogger.Info("WebSocket连接关闭", zap.String("sessionID", session.ID))
+	// 立即关闭SSH连接，让所有阻塞的Read操作返回
+	if session.SSHSession != nil {
+		session.SSHSession.Close()
+	}
+	if session.SSHClient != nil {
+		session.SSHClient.Close()
+	}
 
-	// 读取stdout
-	go func() {
-		defer wg.Done()
-		buf := make([]byte, 1024)
-		for {
-			n, err := session.StdoutPipe.Read(buf)
-			if n > 0 {
-				// 录制输出
-				if session.Recorder != nil {
-					session.Recorder.RecordOutput(buf[:n])
-				}
-				// 使用二进制消息以保留原始字节（包括CR/LF控制字符）
-				conn.WriteMessage(websocket.BinaryMessage, buf[:n])
-			}
-			if err != nil {
-				return
+	wg.Wait()
+	appLogger.Info("终端会话结束", zap.String("sessionID", session.ID))
+}
+
+// pumpOutput 读取SSH输出，录制后发送给所有参与者
+func (s *HTTPServer) pumpOutput(wg *sync.WaitGroup, session *TerminalSession, r io.Reader) {
+	defer wg.Done()
+	buf := make([]byte, 1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			// 录制输出
+			if session.Recorder != nil {
+				session.Recorder.RecordOutput(buf[:n])
 			}
+			session.observeOutput(buf[:n])
+			// 使用二进制消息以保留原始字节（包括CR/LF控制字符）
+			session.broadcast(buf[:n])
 		}
-	}()
-
-	// 读取stderr
-	go func() {
-		defer wg.Done()
-		buf := make([]byte, 1024)
-		for {
-			n, err := session.StderrPipe.Read(buf)
-			if n > 0 {
-				// 录制输出
-				if session.Recorder != nil {
-					session.Recorder.RecordOutput(buf[:n])
-				}
-				// 使用二进制消息以保留原始字节（包括CR/LF控制字符）
-				conn.WriteMessage(websocket.BinaryMessage, buf[:n])
-			}
-			if err != nil {
-				return
-			}
+		if err != nil {
+			return
 		}
-	}()
+	}
+}
+
+// serveParticipant 处理参与者发来的消息直到连接断开，只读观察者的输入被丢弃，只有发起人可以调整窗口大小
+func (s *HTTPServer) serveParticipant(session *TerminalSession, p *terminalParticipant) {
+	conn := p.conn
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(participantReadTimeout))
+		return nil
+	})
+	done := make(chan struct{})
+	defer close(done)
+	go p.keepAlive(done)
 
-	// 处理来自WebSocket的消息并发送到SSH
 	for {
 		// 每次读取前更新超时时间
-		conn.SetReadDeadline(time.Now().Add(5 * time.Minute))
+		conn.SetReadDeadline(time.Now().Add(participantReadTimeout))
 
 		messageType, data, err := conn.ReadMessage()
 		if err != nil {
-			appLogger.Info("WebSocket连接关闭", zap.String("sessionID", session.ID), zap.Error(err))
-			// 立即关闭SSH连接，让所有阻塞的Read操作返回
-			if session.SSHSession != nil {
-				session.SSHSession.Close()
-			}
-			if session.SSHClient != nil {
-				session.SSHClient.Close()
-			}
-			break
+			appLogger.Debug("终端参与者连接关闭",
+				zap.String("sessionID", session.ID), zap.String("username", p.Username), zap.Error(err))
+			return
 		}
 
 		if messageType == websocket.TextMessage {
@@ -485,31 +506,22 @@ func (s *HTTPServer) HandleSSHConnection(c *gin.Context) {
 					cols, colsOk := msg["cols"].(float64)
 					rows, rowsOk := msg["rows"].(float64)
 					if colsOk && rowsOk {
-						// 调整SSH会话窗口大小
-						if err := session.SSHSession.WindowChange(int(rows), int(cols)); err != nil {
-							appLogger.Error("调整窗口大小失败", zap.Error(err))
+						if p.Role == ParticipantOwner {
+							// 调整SSH会话窗口大小
+							if err := session.SSHSession.WindowChange(int(rows), int(cols)); err != nil {
+								appLogger.Error("调整窗口大小失败", zap.Error(err))
+							}
 						}
 						continue
 					}
 				}
 			}
 			// 如果不是resize命令，当作普通输入发送到SSH
-			// 录制输入
-			if session.Recorder != nil {
-				session.Recorder.RecordInput(data)
-			}
-			session.StdinPipe.Write(data)
+			session.writeInput(p, data)
 		} else if messageType == websocket.BinaryMessage {
-			// 录制输入
-			if session.Recorder != nil {
-				session.Recorder.RecordInput(data)
-			}
-			session.StdinPipe.Write(data)
+			session.writeInput(p, data)
 		}
 	}
-
-	wg.Wait()
-	appLogger.Info("终端会话结束", zap.String("sessionID", session.ID))
 }
 
 // ResizeTerminal 调整终端大小
@@ -24,21 +24,28 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
 	"github.com/ydcloud-dy/opshub/pkg/response"
 	"gorm.io/gorm"
 )
 
 // TerminalAuditHandler 终端审计处理器
 type TerminalAuditHandler struct {
-	db *gorm.DB
+	db         *gorm.DB
+	recordings *assetbiz.RecordingIndexUseCase
 }
 
 // NewTerminalAuditHandler 创建终端审计处理器
 func NewTerminalAuditHandler(db *gorm.DB) *TerminalAuditHandler {
-	return &TerminalAuditHandler{db: db}
+	return &TerminalAuditHandler{
+		db:         db,
+		recordings: assetbiz.NewRecordingIndexUseCase(assetdata.NewRecordingIndexRepo(db)),
+	}
 }
 
 // ListTerminalSessions 获取终端会话列表
@@ -131,6 +138,7 @@ func (h *TerminalAuditHandler) ListTerminalSessions(c *gin.Context) {
 // @Produce json
 // @Security Bearer
 // @Param id path int true "会话ID"
+// @Param source query string false "录制来源 host/k8s，检索结果中的 Pod 终端录制按索引中的路径回放" default(host)
 // @Success 200 {string} string "录制文件内容"
 // @Failure 404 {object} response.Response "会话不存在"
 // @Router /api/v1/terminal-sessions/{id}/play [get]
@@ -142,19 +150,35 @@ func (h *TerminalAuditHandler) PlayTerminalSession(c *gin.Context) {
 		return
 	}
 
-	// 查询会话
-	var session assetbiz.TerminalSession
-	if err := h.db.First(&session, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			response.ErrorCode(c, http.StatusNotFound, "会话不存在")
-		} else {
+	var recordingPath string
+	if source := c.DefaultQuery("source", assetbiz.RecordingSourceHost); source != assetbiz.RecordingSourceHost {
+		// 其他来源的会话记录不在本模块，使用建立索引时保存的录制路径
+		recording, err := h.recordings.Get(c.Request.Context(), source, uint(id))
+		if err != nil {
 			response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+			return
 		}
-		return
+		if recording == nil {
+			response.ErrorCode(c, http.StatusNotFound, "会话不存在")
+			return
+		}
+		recordingPath = recording.RecordingPath
+	} else {
+		// 查询会话
+		var session assetbiz.TerminalSession
+		if err := h.db.First(&session, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				response.ErrorCode(c, http.StatusNotFound, "会话不存在")
+			} else {
+				response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+			}
+			return
+		}
+		recordingPath = session.RecordingPath
 	}
 
 	// 读取录制文件
-	content, err := os.ReadFile(session.RecordingPath)
+	content, err := os.ReadFile(recordingPath)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "读取录制文件失败")
 		return
@@ -199,15 +223,212 @@ func (h *TerminalAuditHandler) DeleteTerminalSession(c *gin.Context) {
 	// 即使文件删除失败，仍然继续删除数据库记录
 	_ = os.Remove(session.RecordingPath)
 
-	// 删除数据库记录
+	// 删除数据库记录及会话中的命令
+	if err := h.db.Where("session_id = ?", session.ID).Delete(&assetbiz.TerminalCommand{}).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "删除失败")
+		return
+	}
 	if err := h.db.Delete(&session).Error; err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "删除失败")
 		return
 	}
+	if err := h.recordings.Delete(c.Request.Context(), assetbiz.RecordingSourceHost, session.ID); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "删除录制索引失败")
+		return
+	}
 
 	response.SuccessWithMessage(c, "删除成功", nil)
 }
 
+// ListTerminalCommands 检索终端命令
+// @Summary 检索终端命令
+// @Description 按命令内容、用户、主机、状态和时间范围分页检索交互式终端中执行的命令
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param keyword query string false "命令包含的内容"
+// @Param username query string false "用户名"
+// @Param hostId query int false "主机ID"
+// @Param status query string false "状态 executed/blocked/confirmed/cancelled"
+// @Param startTime query string false "开始时间 2006-01-02 15:04:05"
+// @Param endTime query string false "结束时间 2006-01-02 15:04:05"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/terminal-sessions/commands [get]
+func (h *TerminalAuditHandler) ListTerminalCommands(c *gin.Context) {
+	q := assetbiz.TerminalCommandQuery{
+		Keyword:  c.Query("keyword"),
+		Username: c.Query("username"),
+		Status:   c.Query("status"),
+	}
+	q.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	q.PageSize, _ = strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if v := c.Query("hostId"); v != "" {
+		hostID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+			return
+		}
+		q.HostID = uint(hostID)
+	}
+	var err error
+	if q.StartTime, q.EndTime, err = parseTimeRange(c); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	list, total, err := h.searchCommands(q)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+		return
+	}
+	response.Success(c, gin.H{
+		"total": total,
+		"list":  list,
+	})
+}
+
+// ListSessionCommands 获取终端会话中执行的命令
+// @Summary 获取会话命令
+// @Description 按执行顺序获取终端会话中的全部命令，offset 为距会话开始的秒数，可用于定位回放
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "会话ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/terminal-sessions/{id}/commands [get]
+func (h *TerminalAuditHandler) ListSessionCommands(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的会话ID")
+		return
+	}
+
+	var commands []*assetbiz.TerminalCommand
+	if err := h.db.Where("session_id = ?", id).Order("id ASC").Find(&commands).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败")
+		return
+	}
+	response.Success(c, commands)
+}
+
+// SearchRecordings 全文检索终端录制
+// @Summary 检索终端录制内容
+// @Description 在主机终端和 Pod 终端的录制中检索输出内容和执行的命令，offset 为命中行距会话开始的秒数，回放时从该时间点开始播放
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param keyword query string true "检索内容"
+// @Param source query string false "来源 host/k8s"
+// @Param stream query string false "检索范围 o输出 i命令"
+// @Param username query string false "用户名"
+// @Param target query string false "主机或 Pod"
+// @Param startTime query string false "会话开始时间下限 2006-01-02 15:04:05"
+// @Param endTime query string false "会话开始时间上限 2006-01-02 15:04:05"
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(20)
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/terminal-sessions/search [get]
+func (h *TerminalAuditHandler) SearchRecordings(c *gin.Context) {
+	q := &assetbiz.RecordingQuery{
+		Keyword:  c.Query("keyword"),
+		Source:   c.Query("source"),
+		Stream:   c.Query("stream"),
+		Username: c.Query("username"),
+		Target:   c.Query("target"),
+	}
+	q.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	q.PageSize, _ = strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	var err error
+	if q.StartTime, q.EndTime, err = parseTimeRange(c); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(q.Keyword) == "" {
+		response.ErrorCode(c, http.StatusBadRequest, "请输入检索内容")
+		return
+	}
+
+	list, total, err := h.recordings.Search(c.Request.Context(), q)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "检索失败")
+		return
+	}
+	response.Success(c, gin.H{
+		"total": total,
+		"list":  list,
+	})
+}
+
+// parseTimeRange 解析查询参数中的 startTime、endTime
+func parseTimeRange(c *gin.Context) (start, end *time.Time, err error) {
+	for _, t := range []struct {
+		name string
+		dst  **time.Time
+	}{{"startTime", &start}, {"endTime", &end}} {
+		v := c.Query(t.name)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.ParseInLocation("2006-01-02 15:04:05", v, time.Local)
+		if err != nil {
+			return nil, nil, fmt.Errorf("无效的时间格式: %s", v)
+		}
+		*t.dst = &parsed
+	}
+	return start, end, nil
+}
+
+// searchCommands 按条件分页查询终端命令
+func (h *TerminalAuditHandler) searchCommands(q assetbiz.TerminalCommandQuery) ([]*assetbiz.TerminalCommand, int64, error) {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = 10
+	}
+
+	query := h.db.Model(&assetbiz.TerminalCommand{})
+	if q.SessionID > 0 {
+		query = query.Where("session_id = ?", q.SessionID)
+	}
+	if q.HostID > 0 {
+		query = query.Where("host_id = ?", q.HostID)
+	}
+	if q.Username != "" {
+		query = query.Where("username = ?", q.Username)
+	}
+	if q.Keyword != "" {
+		query = query.Where("command LIKE ?", "%"+q.Keyword+"%")
+	}
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.StartTime != nil {
+		query = query.Where("created_at >= ?", *q.StartTime)
+	}
+	if q.EndTime != nil {
+		query = query.Where("created_at <= ?", *q.EndTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var commands []*assetbiz.TerminalCommand
+	if err := query.Order("created_at DESC, id DESC").
+		Offset((q.Page - 1) * q.PageSize).
+		Limit(q.PageSize).
+		Find(&commands).Error; err != nil {
+		return nil, 0, err
+	}
+	return commands, total, nil
+}
+
 // formatDuration 格式化时长
 func formatDuration(seconds int) string {
 	if seconds < 60 {
@@ -243,6 +464,7 @@ func getStatusText(status string) string {
 		"recording":  "录制中",
 		"completed":  "已完成",
 		"failed":     "失败",
+		"terminated": "已终止",
 	}
 	if text, ok := statusMap[status]; ok {
 		return text
@@ -0,0 +1,173 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// commandCheckTimeout 检查单条命令的超时时间
+const commandCheckTimeout = 5 * time.Second
+
+// 发送给 shell 的控制字符
+var (
+	keyEnter     = []byte("\r")
+	keyInterrupt = []byte{0x03} // Ctrl-C，放弃 shell 中已输入的当前行
+)
+
+// pendingCommand 命中需确认策略、等待输入者确认的命令
+type pendingCommand struct {
+	participantID string
+	record        *assetbiz.TerminalCommand
+}
+
+// writeInput 将参与者的输入写入终端，多人输入时串行写入
+// 回车提交的命令先按命令策略检查：拦截的命令不发送回车并放弃当前行，需确认的命令等待输入者确认
+func (s *TerminalSession) writeInput(p *terminalParticipant, data []byte) {
+	if !p.canInput() || len(data) == 0 {
+		return
+	}
+	s.stdinMu.Lock()
+	defer s.stdinMu.Unlock()
+
+	if s.pending != nil {
+		s.resolvePending(p, data)
+		return
+	}
+	for len(data) > 0 {
+		s.lineMu.Lock()
+		forward, command, submitted, rest := s.cmdLine.Input(data)
+		s.lineMu.Unlock()
+
+		s.sendInput(forward)
+		if !submitted || !s.submitCommand(p, command) {
+			// 命令被拦截或等待确认时，同一次输入中回车之后的内容一并丢弃
+			return
+		}
+		data = rest
+	}
+}
+
+// observeOutput 根据终端输出更新回显行，用于补全、历史命令的还原和密码提示的识别
+func (s *TerminalSession) observeOutput(data []byte) {
+	s.lineMu.Lock()
+	s.cmdLine.Output(data)
+	s.lineMu.Unlock()
+}
+
+func (s *TerminalSession) sendInput(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	if s.Recorder != nil {
+		s.Recorder.RecordInput(data)
+	}
+	s.StdinPipe.Write(data)
+}
+
+// submitCommand 检查回车提交的命令，返回是否已发送回车
+func (s *TerminalSession) submitCommand(p *terminalParticipant, command string) bool {
+	if command == "" {
+		s.sendInput(keyEnter)
+		return true
+	}
+
+	record := &assetbiz.TerminalCommand{
+		SessionID: s.RecordID,
+		HostID:    s.HostID,
+		HostName:  s.HostName,
+		HostIP:    s.HostIP,
+		UserID:    p.UserID,
+		Username:  p.Username,
+		Command:   command,
+		Offset:    time.Since(s.CreatedAt).Seconds(),
+		Status:    assetbiz.TerminalCommandExecuted,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandCheckTimeout)
+	check, err := assetbiz.CheckTerminalCommand(ctx, p.UserID, s.HostID, command)
+	cancel()
+	if err != nil {
+		appLogger.Error("终端命令检查失败", zap.String("sessionID", s.ID), zap.Error(err))
+		check = &assetbiz.CommandCheck{Action: assetbiz.CommandCheckConfirm, Reason: "命令检查失败"}
+	}
+	record.PolicyName = check.PolicyName
+	record.Reason = truncateRunes(check.Reason, 500)
+
+	switch check.Action {
+	case assetbiz.CommandCheckDeny:
+		record.Status = assetbiz.TerminalCommandBlocked
+		s.sendInput(keyInterrupt)
+		s.notice(fmt.Sprintf("命令已被拦截：%s", check.Reason))
+		s.saveCommand(record)
+		return false
+	case assetbiz.CommandCheckConfirm:
+		s.pending = &pendingCommand{participantID: p.ID, record: record}
+		p.writeControl(noticeMessage{Type: "notice", Message: fmt.Sprintf("%s，输入 y 确认执行，其他键取消", check.Reason)})
+		return false
+	}
+
+	s.sendInput(keyEnter)
+	s.saveCommand(record)
+	return true
+}
+
+// resolvePending 处理等待确认期间的输入，只有输入命令的参与者可以确认
+func (s *TerminalSession) resolvePending(p *terminalParticipant, data []byte) {
+	pending := s.pending
+	if p.ID != pending.participantID {
+		return
+	}
+	s.pending = nil
+	record := pending.record
+	if data[0] == 'y' || data[0] == 'Y' {
+		record.Status = assetbiz.TerminalCommandConfirmed
+		s.sendInput(keyEnter)
+		s.notice(fmt.Sprintf("用户 %s 已确认执行", p.Username))
+	} else {
+		record.Status = assetbiz.TerminalCommandCancelled
+		s.sendInput(keyInterrupt)
+		p.writeControl(noticeMessage{Type: "notice", Message: "已取消执行"})
+	}
+	s.saveCommand(record)
+}
+
+// saveCommand 异步保存终端命令，不阻塞终端输入
+func (tm *TerminalManager) saveCommand(record *assetbiz.TerminalCommand) {
+	go func() {
+		if err := tm.db.Create(record).Error; err != nil {
+			appLogger.Error("保存终端命令失败", zap.Uint("sessionID", record.SessionID), zap.Error(err))
+		}
+	}()
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
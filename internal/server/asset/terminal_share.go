@@ -0,0 +1,430 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	auditbiz "github.com/ydcloud-dy/opshub/internal/biz/audit"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	"go.uber.org/zap"
+)
+
+// 终端会话参与者角色
+const (
+	ParticipantOwner    = "owner"     // 会话发起人
+	ParticipantObserver = "observer"  // 只读观察者
+	ParticipantCoDriver = "co-driver" // 协同操作者，可以输入
+)
+
+const (
+	// participantWriteTimeout 向参与者写入的超时时间，避免网络慢的观察者拖住终端输出
+	participantWriteTimeout = 10 * time.Second
+	// participantPingInterval 心跳间隔，只读观察者不发送消息，依靠心跳维持连接
+	participantPingInterval = 30 * time.Second
+	// participantReadTimeout 未收到任何消息（含心跳响应）时断开连接
+	participantReadTimeout = 5 * time.Minute
+)
+
+// terminalParticipant 终端会话的一个WebSocket连接
+type terminalParticipant struct {
+	ID       string
+	UserID   uint
+	Username string
+	Role     string
+	JoinedAt time.Time
+	conn     *websocket.Conn
+	writeMu  sync.Mutex // gorilla/websocket 不支持并发写
+}
+
+func newTerminalParticipant(conn *websocket.Conn, userID uint, username, role string) *terminalParticipant {
+	return &terminalParticipant{
+		ID:       randomID(),
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		JoinedAt: time.Now(),
+		conn:     conn,
+	}
+}
+
+func (p *terminalParticipant) write(messageType int, data []byte) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	p.conn.SetWriteDeadline(time.Now().Add(participantWriteTimeout))
+	return p.conn.WriteMessage(messageType, data)
+}
+
+// writeControl 发送控制消息，以文本消息发送JSON，终端输出使用二进制消息，前端据此区分
+func (p *terminalParticipant) writeControl(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return p.write(websocket.TextMessage, data)
+}
+
+// canInput 是否允许向终端输入
+func (p *terminalParticipant) canInput() bool {
+	return p.Role != ParticipantObserver
+}
+
+// ParticipantInfo 终端会话参与者信息
+type ParticipantInfo struct {
+	UserID   uint      `json:"userId"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+// LiveSessionInfo 在线终端会话信息
+type LiveSessionInfo struct {
+	ID           string            `json:"id"`
+	HostID       uint              `json:"hostId"`
+	HostName     string            `json:"hostName"`
+	HostIP       string            `json:"hostIp"`
+	UserID       uint              `json:"userId"`
+	Username     string            `json:"username"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	Participants []ParticipantInfo `json:"participants"`
+}
+
+// participantsMessage 参与者变化通知，会话发起人据此显示正在观看的用户
+type participantsMessage struct {
+	Type         string            `json:"type"` // participants
+	Participants []ParticipantInfo `json:"participants"`
+}
+
+// noticeMessage 会话提示，前端直接显示在终端中
+type noticeMessage struct {
+	Type    string `json:"type"` // notice
+	Message string `json:"message"`
+}
+
+// addParticipant 加入会话并通知所有参与者，会话已结束时返回 false
+func (s *TerminalSession) addParticipant(p *terminalParticipant) bool {
+	s.partMu.Lock()
+	if s.closed {
+		s.partMu.Unlock()
+		return false
+	}
+	s.participants[p.ID] = p
+	s.partMu.Unlock()
+	s.notifyParticipants()
+	return true
+}
+
+// removeParticipant 离开会话并通知其余参与者
+func (s *TerminalSession) removeParticipant(id string) {
+	s.partMu.Lock()
+	_, ok := s.participants[id]
+	delete(s.participants, id)
+	s.partMu.Unlock()
+	if ok {
+		s.notifyParticipants()
+	}
+}
+
+// snapshotParticipants 获取当前参与者，按加入时间排序
+func (s *TerminalSession) snapshotParticipants() []*terminalParticipant {
+	s.partMu.RLock()
+	list := make([]*terminalParticipant, 0, len(s.participants))
+	for _, p := range s.participants {
+		list = append(list, p)
+	}
+	s.partMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].JoinedAt.Before(list[j].JoinedAt) })
+	return list
+}
+
+// Participants 获取参与者信息
+func (s *TerminalSession) Participants() []ParticipantInfo {
+	list := s.snapshotParticipants()
+	infos := make([]ParticipantInfo, 0, len(list))
+	for _, p := range list {
+		infos = append(infos, ParticipantInfo{UserID: p.UserID, Username: p.Username, Role: p.Role, JoinedAt: p.JoinedAt})
+	}
+	return infos
+}
+
+func (s *TerminalSession) notifyParticipants() {
+	msg := participantsMessage{Type: "participants", Participants: s.Participants()}
+	for _, p := range s.snapshotParticipants() {
+		p.writeControl(msg)
+	}
+}
+
+// notice 向所有参与者发送提示
+func (s *TerminalSession) notice(message string) {
+	for _, p := range s.snapshotParticipants() {
+		p.writeControl(noticeMessage{Type: "notice", Message: message})
+	}
+}
+
+// broadcast 将终端输出发送给所有参与者，写入失败的连接直接关闭，由其读循环负责退出
+func (s *TerminalSession) broadcast(data []byte) {
+	for _, p := range s.snapshotParticipants() {
+		if err := p.write(websocket.BinaryMessage, data); err != nil {
+			appLogger.Debug("终端输出发送失败，断开参与者",
+				zap.String("sessionID", s.ID), zap.String("username", p.Username), zap.Error(err))
+			p.conn.Close()
+		}
+	}
+}
+
+// closeParticipants 会话结束时断开所有参与者
+func (s *TerminalSession) closeParticipants(reason string) {
+	s.partMu.Lock()
+	s.closed = true
+	s.partMu.Unlock()
+	for _, p := range s.snapshotParticipants() {
+		if reason != "" {
+			p.writeControl(noticeMessage{Type: "notice", Message: reason})
+		}
+		p.conn.Close()
+	}
+}
+
+// info 在线会话信息
+func (s *TerminalSession) info() *LiveSessionInfo {
+	return &LiveSessionInfo{
+		ID:           s.ID,
+		HostID:       s.HostID,
+		HostName:     s.HostName,
+		HostIP:       s.HostIP,
+		UserID:       s.UserID,
+		Username:     s.Username,
+		CreatedAt:    s.CreatedAt,
+		Participants: s.Participants(),
+	}
+}
+
+// keepAlive 定时发送心跳直到 done 关闭，浏览器自动回复 pong 并延长读超时
+func (p *terminalParticipant) keepAlive(done <-chan struct{}) {
+	ticker := time.NewTicker(participantPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := p.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(participantWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// randomID 生成不可猜测的会话、参与者ID，加入会话只凭会话ID定位
+func randomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ListSessions 获取在线会话，按创建时间排序
+func (tm *TerminalManager) ListSessions() []*TerminalSession {
+	tm.mu.RLock()
+	list := make([]*TerminalSession, 0, len(tm.sessions))
+	for _, session := range tm.sessions {
+		list = append(list, session)
+	}
+	tm.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// CanJoin 判断用户能否加入会话，需要该主机的终端权限，管理员可以加入所有会话
+func (tm *TerminalManager) CanJoin(ctx context.Context, userID uint, session *TerminalSession) (bool, error) {
+	if userID == 0 {
+		return false, nil
+	}
+	decision, err := tm.assetPermissionUseCase.CheckHostOperationPermission(ctx, userID, session.HostID, rbacbiz.PermissionTerminal)
+	if err != nil {
+		return false, err
+	}
+	return decision.Allowed, nil
+}
+
+// TerminateSession 强制终止会话，断开SSH连接和所有参与者，会话记录状态为已终止
+func (tm *TerminalManager) TerminateSession(sessionID, operator string) (*TerminalSession, error) {
+	tm.mu.Lock()
+	session, ok := tm.sessions[sessionID]
+	if ok {
+		session.terminatedBy = operator
+	}
+	tm.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("会话不存在或已结束")
+	}
+
+	session.notice(fmt.Sprintf("会话已被管理员 %s 强制终止", operator))
+	// 关闭SSH连接后输出读取结束，发起人连接关闭后由其处理流程保存录制并清理会话
+	if session.SSHSession != nil {
+		session.SSHSession.Close()
+	}
+	if session.SSHClient != nil {
+		session.SSHClient.Close()
+	}
+	session.closeParticipants("")
+	return session, nil
+}
+
+// audit 写入终端审计日志，WebSocket连接和强制终止需要记录会话详情
+func (tm *TerminalManager) audit(c *gin.Context, action, description string, status int) {
+	if len([]rune(description)) > 200 {
+		description = string([]rune(description)[:197]) + "..."
+	}
+	log := &auditbiz.SysOperationLog{
+		UserID:      rbacService.GetUserID(c),
+		Username:    rbacService.GetUsername(c),
+		Module:      "终端审计",
+		Action:      action,
+		Description: description,
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		IP:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		Status:      status,
+	}
+	if err := tm.db.Create(log).Error; err != nil {
+		appLogger.Error("保存终端审计日志失败", zap.String("action", action), zap.Error(err))
+	}
+}
+
+// ListLiveSessions 获取在线终端会话
+// @Summary 获取在线终端会话
+// @Description 获取当前可以加入的在线终端会话及参与者，管理员可以看到所有会话，其他用户只能看到有终端权限的主机上的会话
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=[]LiveSessionInfo} "获取成功"
+// @Router /api/v1/asset/terminal/sessions [get]
+func (s *HTTPServer) ListLiveSessions(c *gin.Context) {
+	userID := rbacService.GetUserID(c)
+	list := make([]*LiveSessionInfo, 0)
+	for _, session := range s.terminalManager.ListSessions() {
+		ok, err := s.terminalManager.CanJoin(c.Request.Context(), userID, session)
+		if err != nil {
+			response.ErrorCode(c, http.StatusInternalServerError, "权限检查失败")
+			return
+		}
+		if ok {
+			list = append(list, session.info())
+		}
+	}
+	response.Success(c, list)
+}
+
+// JoinTerminalSession 加入在线终端会话
+// @Summary 加入在线终端会话
+// @Description 通过WebSocket加入其他用户的在线终端会话，mode=observe 只读观察，mode=drive 协同操作，需要该主机的终端权限。
+// @Description 加入和离开会通知会话的所有参与者，并记录审计日志
+// @Tags 终端审计
+// @Security Bearer
+// @Param sid path string true "会话ID"
+// @Param mode query string false "加入方式 observe/drive" default(observe)
+// @Router /api/v1/asset/terminal/sessions/{sid}/join [get]
+func (s *HTTPServer) JoinTerminalSession(c *gin.Context) {
+	session, ok := s.terminalManager.GetSession(c.Param("sid"))
+	if !ok {
+		response.ErrorCode(c, http.StatusNotFound, "会话不存在或已结束")
+		return
+	}
+	role, roleText := ParticipantObserver, "只读观察者"
+	if c.Query("mode") == "drive" {
+		role, roleText = ParticipantCoDriver, "协同操作者"
+	}
+
+	userID, username := rbacService.GetUserID(c), rbacService.GetUsername(c)
+	allowed, err := s.terminalManager.CanJoin(c.Request.Context(), userID, session)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "权限检查失败")
+		return
+	}
+	description := fmt.Sprintf("以%s身份加入用户【%s】在主机【%s(%s)】上的终端会话",
+		roleText, session.Username, session.HostName, session.HostIP)
+	if !allowed {
+		s.terminalManager.audit(c, "加入终端会话", description, http.StatusForbidden)
+		response.ErrorCode(c, http.StatusForbidden, "权限不足")
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		appLogger.Error("WebSocket升级失败", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	p := newTerminalParticipant(conn, userID, username, role)
+	session.notice(fmt.Sprintf("用户 %s 以%s身份加入了会话", username, roleText))
+	if !session.addParticipant(p) {
+		p.writeControl(noticeMessage{Type: "notice", Message: "会话已结束"})
+		return
+	}
+	s.terminalManager.audit(c, "加入终端会话", description, http.StatusOK)
+	appLogger.Info("用户加入终端会话",
+		zap.String("sessionID", session.ID),
+		zap.String("username", username),
+		zap.String("role", role))
+
+	s.serveParticipant(session, p)
+	session.removeParticipant(p.ID)
+	session.notice(fmt.Sprintf("用户 %s 离开了会话", username))
+}
+
+// TerminateTerminalSession 强制终止在线终端会话
+// @Summary 强制终止在线终端会话
+// @Description 断开在线终端会话的SSH连接和所有参与者，会话录制照常保存，状态为已终止，仅限管理员
+// @Tags 终端审计
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param sid path string true "会话ID"
+// @Success 200 {object} response.Response "已终止"
+// @Failure 404 {object} response.Response "会话不存在"
+// @Router /api/v1/asset/terminal/sessions/{sid} [delete]
+func (s *HTTPServer) TerminateTerminalSession(c *gin.Context) {
+	session, err := s.terminalManager.TerminateSession(c.Param("sid"), rbacService.GetUsername(c))
+	if err != nil {
+		response.ErrorCode(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	s.terminalManager.audit(c, "强制终止终端会话",
+		fmt.Sprintf("强制终止用户【%s】在主机【%s(%s)】上的终端会话", session.Username, session.HostName, session.HostIP),
+		http.StatusOK)
+	response.SuccessWithMessage(c, "已终止", nil)
+}
@@ -20,8 +20,12 @@
 package audit
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	"github.com/ydcloud-dy/opshub/internal/service/audit"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
 )
 
 type HTTPService struct {
@@ -55,6 +59,12 @@ func (s *HTTPService) RegisterRoutes(r *gin.RouterGroup) {
 			operationLogs.DELETE("/:id", s.operationLogService.DeleteOperationLog)
 			operationLogs.POST("/batch-delete", s.operationLogService.DeleteOperationLogsBatch)
 		}
+		rbacService.DeclareRoutes(operationLogs,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "operation-logs"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "operation-logs"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "operation-logs:delete", Name: "删除日志"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/batch-delete", Code: "operation-logs:delete", Name: "删除日志"},
+		)
 
 		// 登录日志路由
 		loginLogs := audit.Group("/login-logs")
@@ -64,6 +74,12 @@ func (s *HTTPService) RegisterRoutes(r *gin.RouterGroup) {
 			loginLogs.DELETE("/:id", s.loginLogService.DeleteLoginLog)
 			loginLogs.POST("/batch-delete", s.loginLogService.DeleteLoginLogsBatch)
 		}
+		rbacService.DeclareRoutes(loginLogs,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "login-logs"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "login-logs"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "login-logs:delete", Name: "删除日志"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/batch-delete", Code: "login-logs:delete", Name: "删除日志"},
+		)
 
 		// 数据日志路由
 		dataLogs := audit.Group("/data-logs")
@@ -73,5 +89,12 @@ func (s *HTTPService) RegisterRoutes(r *gin.RouterGroup) {
 			dataLogs.DELETE("/:id", s.dataLogService.DeleteDataLog)
 			dataLogs.POST("/batch-delete", s.dataLogService.DeleteDataLogsBatch)
 		}
+		// 数据日志没有独立菜单，沿用操作日志的权限
+		rbacService.DeclareRoutes(dataLogs,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "operation-logs"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "operation-logs"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "operation-logs:delete", Name: "删除日志"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/batch-delete", Code: "operation-logs:delete", Name: "删除日志"},
+		)
 	}
 }
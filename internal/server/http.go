@@ -28,6 +28,7 @@ import (
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	"github.com/ydcloud-dy/opshub/internal/conf"
 	rbacdata "github.com/ydcloud-dy/opshub/internal/data/rbac"
 	"github.com/ydcloud-dy/opshub/internal/plugin"
@@ -37,6 +38,7 @@ import (
 	"github.com/ydcloud-dy/opshub/internal/server/rbac"
 	systemserver "github.com/ydcloud-dy/opshub/internal/server/system"
 	"github.com/ydcloud-dy/opshub/internal/service"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
 	appLogger "github.com/ydcloud-dy/opshub/pkg/logger"
 	"github.com/ydcloud-dy/opshub/pkg/middleware"
 	k8splugin "github.com/ydcloud-dy/opshub/plugins/kubernetes"
@@ -56,6 +58,9 @@ type HTTPServer struct {
 	db        *gorm.DB
 	pluginMgr *plugin.Manager
 	uploadSrv *UploadServer
+
+	assetWorkers   *assetserver.Workers
+	accessRequests *rbacbiz.AccessRequestUseCase
 }
 
 // NewHTTPServer 创建HTTP服务器
@@ -65,6 +70,7 @@ func NewHTTPServer(conf *conf.Config, svc *service.Service, db *gorm.DB) *HTTPSe
 
 	// 创建路由
 	router := gin.New()
+	setTrustedProxies(router, conf.Server.TrustedProxies)
 
 	// 使用中间件
 	router.Use(middleware.Logger())
@@ -120,6 +126,9 @@ func NewHTTPServer(conf *conf.Config, svc *service.Service, db *gorm.DB) *HTTPSe
 	// 注册路由（插件启用后才能注册路由）
 	s.registerRoutes(router, conf.Server.JWTSecret)
 
+	// 为路由登记的按钮编码补齐按钮菜单
+	s.syncRouteButtons()
+
 	// 创建HTTP服务器
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", conf.Server.HttpPort),
@@ -143,10 +152,14 @@ func (s *HTTPServer) registerRoutes(router *gin.Engine, jwtSecret string) {
 	router.Static("/uploads", "./web/public/uploads")
 
 	// 创建 RBAC 服务
-	userService, roleService, departmentService, menuService, positionService, captchaService, assetPermissionService, authMiddleware := rbac.NewRBACServices(s.db, jwtSecret)
+	userService, roleService, departmentService, menuService, positionService, captchaService, assetPermissionService, projectService, authMiddleware := rbac.NewRBACServices(s.db, jwtSecret)
+
+	// 临时权限申请
+	accessRequestService, accessRequestUseCase := rbac.NewAccessRequestServices(s.db)
+	s.accessRequests = accessRequestUseCase
 
 	// RBAC 路由
-	rbacServer := rbac.NewHTTPServer(userService, roleService, departmentService, menuService, positionService, captchaService, assetPermissionService, authMiddleware)
+	rbacServer := rbac.NewHTTPServer(userService, roleService, departmentService, menuService, positionService, captchaService, assetPermissionService, accessRequestService, projectService, authMiddleware)
 	rbacServer.RegisterRoutes(router)
 
 	// 创建 System 服务
@@ -160,7 +173,9 @@ func (s *HTTPServer) registerRoutes(router *gin.Engine, jwtSecret string) {
 	operationLogService, loginLogService, dataLogService := auditserver.NewAuditServices(s.db)
 
 	// 创建 Asset 服务
-	assetGroupService, hostService, terminalManager := assetserver.NewAssetServices(s.db)
+	assetGroupService, hostService, terminalManager, assetWorkers := assetserver.NewAssetServices(s.db, s.conf)
+	assetWorkers.Start()
+	s.assetWorkers = assetWorkers
 
 	// 设置authMiddleware的assetPermissionRepo
 	assetPermissionRepo := rbacdata.NewAssetPermissionRepo(s.db)
@@ -186,7 +201,7 @@ func (s *HTTPServer) registerRoutes(router *gin.Engine, jwtSecret string) {
 
 	// API v1 - 需要认证的接口
 	v1 := router.Group("/api/v1")
-	v1.Use(authMiddleware.AuthRequired())
+	v1.Use(authMiddleware.AuthRequired(), authMiddleware.RequireRoutePermission())
 	{
 		// Audit 路由
 		auditHTTPServer := auditserver.NewHTTPService(operationLogService, loginLogService, dataLogService)
@@ -205,6 +220,10 @@ func (s *HTTPServer) registerRoutes(router *gin.Engine, jwtSecret string) {
 		// 上传接口
 		v1.POST("/upload/avatar", s.uploadSrv.UploadAvatar)
 		v1.PUT("/profile/avatar", s.uploadSrv.UpdateUserAvatar)
+		rbacService.DeclareRoutes(v1,
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/upload/avatar", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/profile/avatar", Code: rbacbiz.PermissionLoginOnly},
+		)
 
 		// 系统配置路由
 		systemHTTPServer := systemserver.NewHTTPServer(configService)
@@ -213,12 +232,15 @@ func (s *HTTPServer) registerRoutes(router *gin.Engine, jwtSecret string) {
 
 	// 插件路由
 	pluginsGroup := router.Group("/api/v1/plugins")
-	pluginsGroup.Use(authMiddleware.AuthRequired())
+	pluginsGroup.Use(authMiddleware.AuthRequired(), authMiddleware.RequireRoutePermission())
 	s.pluginMgr.RegisterAllRoutes(pluginsGroup)
 
+	// 插件注册路由时注册各自的临时权限授权实现，之后再启动到期回收
+	s.accessRequests.Start()
+
 	// 插件管理接口
 	pluginInfoGroup := router.Group("/api/v1/plugins")
-	pluginInfoGroup.Use(authMiddleware.AuthRequired())
+	pluginInfoGroup.Use(authMiddleware.AuthRequired(), authMiddleware.RequireRoutePermission())
 	{
 		pluginInfoGroup.GET("", s.listPlugins)
 		pluginInfoGroup.GET("/:name", s.getPlugin)
@@ -228,6 +250,16 @@ func (s *HTTPServer) registerRoutes(router *gin.Engine, jwtSecret string) {
 		pluginInfoGroup.POST("/upload", s.uploadSrv.UploadPlugin)
 		pluginInfoGroup.DELETE("/:name/uninstall", s.uploadSrv.UninstallPlugin)
 	}
+	// 插件列表和菜单用于前端构建导航，登录即可访问
+	rbacService.DeclareRoutes(pluginInfoGroup,
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: rbacbiz.PermissionLoginOnly},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:name", Code: rbacbiz.PermissionLoginOnly},
+		rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:name/menus", Code: rbacbiz.PermissionLoginOnly},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:name/enable", Code: "plugin-list:enable", Name: "启用插件"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:name/disable", Code: "plugin-list:disable", Name: "禁用插件"},
+		rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/upload", Code: "plugin-install:upload", Name: "上传插件"},
+		rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:name/uninstall", Code: "plugin-install:uninstall", Name: "卸载插件"},
+	)
 
 	// 前端静态文件服务（后面会用到）
 	// router.Static("/assets", "./web/dist/assets")
@@ -236,6 +268,21 @@ func (s *HTTPServer) registerRoutes(router *gin.Engine, jwtSecret string) {
 	// })
 }
 
+// syncRouteButtons 同步接口权限登记的按钮菜单
+func (s *HTTPServer) syncRouteButtons() {
+	created, skipped, err := rbacbiz.NewRoutePermissionUseCase(rbacdata.NewMenuRepo(s.db)).SyncButtons(context.Background())
+	if err != nil {
+		appLogger.Error("同步接口权限按钮失败", zap.Error(err))
+		return
+	}
+	if len(created) > 0 {
+		appLogger.Info("已创建接口权限按钮", zap.Strings("codes", created))
+	}
+	if len(skipped) > 0 {
+		appLogger.Warn("接口权限按钮的父菜单不存在，未创建", zap.Strings("codes", skipped))
+	}
+}
+
 // enablePlugins 启用所有已注册的插件
 func (s *HTTPServer) enablePlugins() {
 	for _, p := range s.pluginMgr.GetAllPlugins() {
@@ -440,6 +487,21 @@ func (s *HTTPServer) Stop(ctx context.Context) error {
 	if err := s.server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("HTTP服务器停止失败: %w", err)
 	}
+	if s.assetWorkers != nil {
+		s.assetWorkers.Stop()
+	}
+	if s.accessRequests != nil {
+		s.accessRequests.Stop()
+	}
 	appLogger.Info("HTTP服务器已停止")
 	return nil
 }
+
+// setTrustedProxies 只采信可信代理传入的 X-Forwarded-For，客户端IP用于审计和资产授权的来源网段判断
+// 配置错误时不信任任何代理，避免回退到 gin 默认信任全部来源
+func setTrustedProxies(router *gin.Engine, proxies []string) {
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		appLogger.Error("可信代理配置错误，不信任任何代理", zap.Error(err))
+		_ = router.SetTrustedProxies(nil)
+	}
+}
@@ -20,6 +20,8 @@
 package rbac
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	auditbiz "github.com/ydcloud-dy/opshub/internal/biz/audit"
 	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
@@ -37,7 +39,10 @@ type HTTPServer struct {
 	positionService        *rbacService.PositionService
 	captchaService         *rbacService.CaptchaService
 	assetPermissionService *rbacService.AssetPermissionService
+	accessRequestService   *rbacService.AccessRequestService
+	projectService         *rbacService.ProjectService
 	authMiddleware         *rbacService.AuthMiddleware
+	routePermissionService *rbacService.RoutePermissionService
 }
 
 func NewHTTPServer(
@@ -48,6 +53,8 @@ func NewHTTPServer(
 	positionService *rbacService.PositionService,
 	captchaService *rbacService.CaptchaService,
 	assetPermissionService *rbacService.AssetPermissionService,
+	accessRequestService *rbacService.AccessRequestService,
+	projectService *rbacService.ProjectService,
 	authMiddleware *rbacService.AuthMiddleware,
 ) *HTTPServer {
 	return &HTTPServer{
@@ -58,11 +65,15 @@ func NewHTTPServer(
 		positionService:        positionService,
 		captchaService:         captchaService,
 		assetPermissionService: assetPermissionService,
+		accessRequestService:   accessRequestService,
+		projectService:         projectService,
 		authMiddleware:         authMiddleware,
 	}
 }
 
 func (s *HTTPServer) RegisterRoutes(r *gin.Engine) {
+	s.routePermissionService = rbacService.NewRoutePermissionService(r.Routes)
+
 	// 公开路由
 	public := r.Group("/api/v1/public")
 	{
@@ -78,11 +89,17 @@ func (s *HTTPServer) RegisterRoutes(r *gin.Engine) {
 
 	// 需要认证的路由
 	auth := r.Group("/api/v1")
-	auth.Use(s.authMiddleware.AuthRequired())
+	auth.Use(s.authMiddleware.AuthRequired(), s.authMiddleware.RequireRoutePermission())
 	{
 		// 用户相关
 		auth.GET("/profile", s.userService.GetProfile)
+		auth.PUT("/profile", s.userService.UpdateProfile)
 		auth.PUT("/profile/password", s.userService.ChangePassword)
+		rbacService.DeclareRoutes(auth,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/profile", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/profile", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/profile/password", Code: rbacbiz.PermissionLoginOnly},
+		)
 
 		// 用户管理
 		users := auth.Group("/users")
@@ -97,6 +114,17 @@ func (s *HTTPServer) RegisterRoutes(r *gin.Engine) {
 			users.PUT("/:id/reset-password", s.userService.ResetPassword)
 			users.POST("/:id/unlock", s.userService.UnlockUser)
 		}
+		rbacService.DeclareRoutes(users,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "users"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "users"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "users:create", Name: "新增用户"},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "users:update", Name: "编辑用户"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "users:delete", Name: "删除用户"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/roles", Code: "users:assign-roles", Name: "分配角色"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/positions", Code: "users:assign-positions", Name: "分配岗位"},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id/reset-password", Code: "users:reset-password", Name: "重置密码"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/unlock", Code: "users:unlock", Name: "解锁用户"},
+		)
 
 		// 角色管理
 		roles := auth.Group("/roles")
@@ -109,6 +137,15 @@ func (s *HTTPServer) RegisterRoutes(r *gin.Engine) {
 			roles.DELETE("/:id", s.roleService.DeleteRole)
 			roles.POST("/:id/menus", s.roleService.AssignRoleMenus)
 		}
+		rbacService.DeclareRoutes(roles,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "roles"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/all", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "roles"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "roles:create", Name: "新增角色"},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "roles:update", Name: "编辑角色"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "roles:delete", Name: "删除角色"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/menus", Code: "roles:assign-menus", Name: "分配菜单"},
+		)
 
 		// 部门管理
 		departments := auth.Group("/departments")
@@ -120,6 +157,14 @@ func (s *HTTPServer) RegisterRoutes(r *gin.Engine) {
 			departments.PUT("/:id", s.departmentService.UpdateDepartment)
 			departments.DELETE("/:id", s.departmentService.DeleteDepartment)
 		}
+		rbacService.DeclareRoutes(departments,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/tree", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/parent-options", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "dept-info:create", Name: "新增部门"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "dept-info"},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "dept-info:update", Name: "编辑部门"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "dept-info:delete", Name: "删除部门"},
+		)
 
 		// 菜单管理
 		menus := auth.Group("/menus")
@@ -131,6 +176,14 @@ func (s *HTTPServer) RegisterRoutes(r *gin.Engine) {
 			menus.PUT("/:id", s.menuService.UpdateMenu)
 			menus.DELETE("/:id", s.menuService.DeleteMenu)
 		}
+		rbacService.DeclareRoutes(menus,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/tree", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/user", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "menus"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "menus:create", Name: "新增菜单"},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "menus:update", Name: "编辑菜单"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "menus:delete", Name: "删除菜单"},
+		)
 
 		// 岗位管理
 		positions := auth.Group("/positions")
@@ -144,6 +197,16 @@ func (s *HTTPServer) RegisterRoutes(r *gin.Engine) {
 			positions.POST("/:id/users", s.positionService.AssignUsersToPosition)
 			positions.DELETE("/:id/users/:userId", s.positionService.RemoveUserFromPosition)
 		}
+		rbacService.DeclareRoutes(positions,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "position-info"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "position-info:create", Name: "新增岗位"},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "position-info:update", Name: "编辑岗位"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "position-info:delete", Name: "删除岗位"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/users", Code: "position-info"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/users", Code: "position-info:assign-users", Name: "分配人员"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id/users/:userId", Code: "position-info:assign-users", Name: "分配人员"},
+		)
 
 		// 资产权限管理
 		assetPermissions := auth.Group("/asset-permissions")
@@ -161,6 +224,87 @@ func (s *HTTPServer) RegisterRoutes(r *gin.Engine) {
 			// 删除分组权限用空路径（没有 :id）
 			assetPermissions.DELETE("", s.assetPermissionService.DeleteAssetPermissionByRoleAndGroup)
 		}
+		rbacService.DeclareRoutes(assetPermissions,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "asset_permission"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "asset_permission:create", Name: "新增授权"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/role/:roleId", Code: "asset_permission"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/group/:assetGroupId", Code: "asset_permission"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/user/host", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "asset_permission"},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "asset_permission:update", Name: "编辑授权"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "asset_permission:delete", Name: "删除授权"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "", Code: "asset_permission:delete", Name: "删除授权"},
+		)
+
+		// 临时权限申请，所有登录用户都可以申请
+		accessRequests := auth.Group("/access-requests")
+		{
+			accessRequests.GET("", s.accessRequestService.ListMyAccessRequests)
+			accessRequests.POST("", s.accessRequestService.CreateAccessRequest)
+			accessRequests.GET("/resource-types", s.accessRequestService.ListAccessResourceTypes)
+			accessRequests.GET("/review", s.accessRequestService.ListAllAccessRequests)
+			accessRequests.POST("/:id/cancel", s.accessRequestService.CancelAccessRequest)
+			accessRequests.POST("/:id/approve", s.accessRequestService.ApproveAccessRequest)
+			accessRequests.POST("/:id/reject", s.accessRequestService.RejectAccessRequest)
+			accessRequests.POST("/:id/revoke", s.accessRequestService.RevokeAccessRequest)
+		}
+		rbacService.DeclareRoutes(accessRequests,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/resource-types", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/review", Code: "access_request:approve", Name: "审批申请"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/cancel", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/approve", Code: "access_request:approve", Name: "审批申请"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/reject", Code: "access_request:approve", Name: "审批申请"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/revoke", Code: "access_request:revoke", Name: "收回授权"},
+		)
+
+		// 当前用户可切换的项目
+		auth.GET("/projects/mine", s.projectService.ListMyProjects)
+		rbacService.DeclareRoutes(auth,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/projects/mine", Code: rbacbiz.PermissionLoginOnly},
+		)
+
+		// 项目管理，仅平台管理员可以管理项目和成员
+		projects := auth.Group("/projects")
+		projects.Use(s.authMiddleware.RequireAdmin())
+		{
+			projects.GET("", s.projectService.ListProjects)
+			projects.POST("", s.projectService.CreateProject)
+			// 具体路由必须放在通用 /:id 路由之前
+			projects.GET("/resource-types", s.projectService.ListProjectResourceTypes)
+			projects.GET("/:id", s.projectService.GetProject)
+			projects.PUT("/:id", s.projectService.UpdateProject)
+			projects.DELETE("/:id", s.projectService.DeleteProject)
+			projects.GET("/:id/members", s.projectService.ListProjectMembers)
+			projects.POST("/:id/members", s.projectService.SaveProjectMembers)
+			projects.DELETE("/:id/members/:userId", s.projectService.RemoveProjectMember)
+			projects.POST("/:id/resources", s.projectService.AssignProjectResources)
+		}
+		rbacService.DeclareRoutes(projects,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "projects"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: "projects:create", Name: "新增项目"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/resource-types", Code: "projects"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: "projects"},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: "projects:update", Name: "编辑项目"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: "projects:delete", Name: "删除项目"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/members", Code: "projects"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/members", Code: "projects:members", Name: "管理成员"},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id/members/:userId", Code: "projects:members", Name: "管理成员"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/resources", Code: "projects:assign", Name: "迁移资源"},
+		)
+
+		// 接口权限
+		routePermissions := auth.Group("/route-permissions")
+		routePermissions.Use(s.authMiddleware.RequireAdmin())
+		{
+			routePermissions.GET("", s.routePermissionService.ListRoutePermissions)
+			routePermissions.GET("/unmapped", s.routePermissionService.ListUnmappedRoutes)
+		}
+		rbacService.DeclareRoutes(routePermissions,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: "menus"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/unmapped", Code: "menus"},
+		)
 	}
 }
 
@@ -173,6 +317,7 @@ func NewRBACServices(db *gorm.DB, jwtSecret string) (
 	*rbacService.PositionService,
 	*rbacService.CaptchaService,
 	*rbacService.AssetPermissionService,
+	*rbacService.ProjectService,
 	*rbacService.AuthMiddleware,
 ) {
 	// 初始化Repository
@@ -182,6 +327,7 @@ func NewRBACServices(db *gorm.DB, jwtSecret string) (
 	menuRepo := rbacdata.NewMenuRepo(db)
 	positionRepo := rbacdata.NewPositionRepo(db)
 	assetPermissionRepo := rbacdata.NewAssetPermissionRepo(db)
+	projectRepo := rbacdata.NewProjectRepo(db)
 
 	// 初始化Audit Repository
 	loginLogRepo := auditdata.NewLoginLogRepo(db)
@@ -193,6 +339,17 @@ func NewRBACServices(db *gorm.DB, jwtSecret string) (
 	menuUseCase := rbacbiz.NewMenuUseCase(menuRepo)
 	positionUseCase := rbacbiz.NewPositionUseCase(positionRepo)
 	assetPermissionUseCase := rbacbiz.NewAssetPermissionUseCase(assetPermissionRepo)
+	projectUseCase := rbacbiz.NewProjectUseCase(projectRepo)
+	userUseCase.SetProjectRepo(projectRepo)
+
+	// 角色菜单、用户角色、菜单变更时清除接口权限缓存
+	permissionCache := rbacbiz.NewPermissionCache(roleRepo, menuRepo)
+	userUseCase.SetPermissionCache(permissionCache)
+	roleUseCase.SetPermissionCache(permissionCache)
+	menuUseCase.SetPermissionCache(permissionCache)
+	// 项目成员变更时清除，项目角色只在当前项目中生效
+	permissionCache.SetProjectRepo(projectRepo)
+	projectUseCase.SetPermissionCache(permissionCache)
 
 	// 初始化Audit UseCase
 	loginLogUseCase := auditbiz.NewLoginLogUseCase(loginLogRepo)
@@ -206,7 +363,9 @@ func NewRBACServices(db *gorm.DB, jwtSecret string) (
 	positionService := rbacService.NewPositionService(positionUseCase)
 	captchaService := rbacService.NewCaptchaService()
 	assetPermissionService := rbacService.NewAssetPermissionService(assetPermissionUseCase)
+	projectService := rbacService.NewProjectService(projectUseCase, permissionCache)
 	authMiddleware := rbacService.NewAuthMiddleware(authService)
+	authMiddleware.SetPermissionCache(permissionCache)
 
 	// 设置验证码服务到用户服务
 	userService.SetCaptchaService(captchaService)
@@ -214,5 +373,11 @@ func NewRBACServices(db *gorm.DB, jwtSecret string) (
 	// 设置登录日志用例到用户服务
 	userService.SetLoginLogUseCase(loginLogUseCase)
 
-	return userService, roleService, departmentService, menuService, positionService, captchaService, assetPermissionService, authMiddleware
+	return userService, roleService, departmentService, menuService, positionService, captchaService, assetPermissionService, projectService, authMiddleware
+}
+
+// NewAccessRequestServices 创建临时权限申请服务，返回的用例需调用 Start 启动到期回收
+func NewAccessRequestServices(db *gorm.DB) (*rbacService.AccessRequestService, *rbacbiz.AccessRequestUseCase) {
+	accessRequestUseCase := rbacbiz.NewAccessRequestUseCase(rbacdata.NewAccessRequestRepo(db), auditdata.NewOperationLogRepo(db))
+	return rbacService.NewAccessRequestService(accessRequestUseCase), accessRequestUseCase
 }
@@ -20,9 +20,13 @@
 package system
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	systembiz "github.com/ydcloud-dy/opshub/internal/biz/system"
 	systemdata "github.com/ydcloud-dy/opshub/internal/data/system"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
 	systemservice "github.com/ydcloud-dy/opshub/internal/service/system"
 	"gorm.io/gorm"
 )
@@ -53,6 +57,15 @@ func (s *HTTPServer) RegisterRoutes(auth *gin.RouterGroup, public *gin.RouterGro
 			config.PUT("/security", s.configService.SaveSecurityConfig)
 			config.POST("/logo", s.configService.UploadLogo)
 		}
+		// 基础配置用于页面展示系统名称和Logo，登录即可读取
+		rbacService.DeclareRoutes(config,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/basic", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/basic", Code: "system-config:update", Name: "保存配置"},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/security", Code: "system-config"},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/security", Code: "system-config:update", Name: "保存配置"},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/logo", Code: "system-config:update", Name: "保存配置"},
+		)
 	}
 
 	// 公开路由（无需认证）
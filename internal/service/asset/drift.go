@@ -0,0 +1,396 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+// ListHostSnapshots 获取主机配置快照列表
+// @Summary 获取主机配置快照列表
+// @Description 获取主机的配置快照列表，不含快照内容，按创建时间倒序
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Success 200 {object} response.Response{data=[]asset.HostSnapshot} "获取成功"
+// @Router /api/v1/hosts/{id}/snapshots [get]
+func (s *HostService) ListHostSnapshots(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	list, err := s.driftUseCase.ListSnapshots(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, list)
+}
+
+// CreateHostSnapshot 创建主机配置快照
+// @Summary 创建主机配置快照
+// @Description 通过SSH采集软件包、服务、监听端口、关键配置文件校验和与内核参数并保存为快照，
+// @Description files 为额外计算校验和的文件，支持 * ? 通配符，isBaseline 为 true 时同时设为主机基线
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param body body asset.SnapshotRequest true "快照信息"
+// @Success 200 {object} response.Response{data=asset.HostSnapshot} "创建成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/snapshots [post]
+func (s *HostService) CreateHostSnapshot(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	var req asset.SnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	snapshot, err := s.driftUseCase.CreateSnapshot(c.Request.Context(), uint(id), &req, asset.SnapshotSourceManual,
+		rbacService.GetUserID(c), rbacService.GetUsername(c))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "创建快照失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "创建成功", snapshot)
+}
+
+// GetHostSnapshot 获取主机配置快照详情
+// @Summary 获取主机配置快照详情
+// @Description 获取快照及其完整内容
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param sid path int true "快照ID"
+// @Success 200 {object} response.Response{data=asset.HostSnapshot} "获取成功"
+// @Failure 404 {object} response.Response "快照不存在"
+// @Router /api/v1/hosts/{id}/snapshots/{sid} [get]
+func (s *HostService) GetHostSnapshot(c *gin.Context) {
+	id, sid, ok := parseSnapshotIDs(c)
+	if !ok {
+		return
+	}
+
+	snapshot, err := s.driftUseCase.GetSnapshot(c.Request.Context(), id, sid)
+	if err != nil {
+		response.ErrorCode(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	response.Success(c, snapshot)
+}
+
+// DeleteHostSnapshot 删除主机配置快照
+// @Summary 删除主机配置快照
+// @Description 删除快照，删除基线快照后该主机不再参与漂移检查
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param sid path int true "快照ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Router /api/v1/hosts/{id}/snapshots/{sid} [delete]
+func (s *HostService) DeleteHostSnapshot(c *gin.Context) {
+	id, sid, ok := parseSnapshotIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := s.driftUseCase.DeleteSnapshot(c.Request.Context(), id, sid); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// SetHostBaseline 设置主机基线
+// @Summary 设置主机基线
+// @Description 将快照设为主机基线，取消原有基线，之后的漂移检查与该快照比对
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param sid path int true "快照ID"
+// @Success 200 {object} response.Response "设置成功"
+// @Router /api/v1/hosts/{id}/snapshots/{sid}/baseline [put]
+func (s *HostService) SetHostBaseline(c *gin.Context) {
+	id, sid, ok := parseSnapshotIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := s.driftUseCase.SetBaseline(c.Request.Context(), id, sid); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "设置成功", nil)
+}
+
+// DiffHostSnapshots 比对主机配置快照
+// @Summary 比对主机配置快照
+// @Description 比对同一主机的两个快照，未指定 to 时采集主机当前状态与 from 比对
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param from query int true "起始快照ID"
+// @Param to query int false "目标快照ID"
+// @Success 200 {object} response.Response{data=asset.SnapshotDiff} "比对成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/snapshots/diff [get]
+func (s *HostService) DiffHostSnapshots(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+	from, err := strconv.ParseUint(c.Query("from"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的起始快照ID")
+		return
+	}
+	var to uint64
+	if v := c.Query("to"); v != "" {
+		if to, err = strconv.ParseUint(v, 10, 32); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "无效的目标快照ID")
+			return
+		}
+	}
+
+	diff, err := s.driftUseCase.Diff(c.Request.Context(), uint(id), uint(from), uint(to))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, diff)
+}
+
+// CheckHostDrift 立即检查主机配置漂移
+// @Summary 立即检查主机配置漂移
+// @Description 采集主机当前状态并与基线比对，保存检查结果
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Success 200 {object} response.Response{data=asset.DriftReport} "检查完成"
+// @Failure 400 {object} response.Response "主机未设置基线"
+// @Router /api/v1/hosts/{id}/drift/check [post]
+func (s *HostService) CheckHostDrift(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	report, err := s.driftUseCase.CheckHost(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, asset.ErrNoBaseline) {
+			response.ErrorCode(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusInternalServerError, "检查失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "检查完成", report)
+}
+
+// ListDriftReports 获取主机漂移检查记录
+// @Summary 获取主机漂移检查记录
+// @Description 分页获取主机的漂移检查记录及差异明细
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(20)
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/hosts/{id}/drift/reports [get]
+func (s *HostService) ListDriftReports(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	list, total, err := s.driftUseCase.ListReports(c.Request.Context(), uint(id), page, pageSize)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"list":     list,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetGroupDrift 获取分组配置漂移概况
+// @Summary 获取分组配置漂移概况
+// @Description 获取分组（含子分组）内当前用户有权限的主机最近一次漂移检查结果
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "分组ID"
+// @Success 200 {object} response.Response{data=[]asset.DriftReport} "获取成功"
+// @Router /api/v1/asset-groups/{id}/drift [get]
+func (s *HostService) GetGroupDrift(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的分组ID")
+		return
+	}
+
+	var accessibleHostIDs []uint
+	if userID := rbacService.GetUserID(c); userID > 0 {
+		hostIDs, err := s.assetPermissionUseCase.GetUserAccessibleHostIDs(c.Request.Context(), userID)
+		if err != nil || hostIDs == nil {
+			// 获取权限出错或没有任何主机权限时不返回数据
+			hostIDs = []uint{}
+		}
+		accessibleHostIDs = hostIDs
+	}
+
+	reports, err := s.driftUseCase.GroupReports(c.Request.Context(), uint(id), accessibleHostIDs)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, reports)
+}
+
+// BaselineGroup 为分组设置基线
+// @Summary 为分组设置基线
+// @Description 为分组（含子分组）内所有已配置凭证的主机创建快照并设为基线，仅限管理员
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "分组ID"
+// @Param body body asset.GroupBaselineRequest false "基线信息"
+// @Success 200 {object} response.Response{data=asset.GroupBaselineResult} "设置完成"
+// @Router /api/v1/asset-groups/{id}/baseline [post]
+func (s *HostService) BaselineGroup(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的分组ID")
+		return
+	}
+
+	var req asset.GroupBaselineRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+			return
+		}
+	}
+
+	result, err := s.driftUseCase.BaselineGroup(c.Request.Context(), uint(id), &req,
+		rbacService.GetUserID(c), rbacService.GetUsername(c))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "设置完成", result)
+}
+
+// CheckGroupDrift 检查分组配置漂移
+// @Summary 检查分组配置漂移
+// @Description 在后台检查分组（含子分组）内所有已设置基线的主机，仅限管理员
+// @Tags 资产管理-配置漂移
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "分组ID"
+// @Success 200 {object} response.Response "已开始检查"
+// @Router /api/v1/asset-groups/{id}/drift/check [post]
+func (s *HostService) CheckGroupDrift(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的分组ID")
+		return
+	}
+
+	count, err := s.driftUseCase.CheckGroup(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "已开始检查", gin.H{"total": count})
+}
+
+// parseSnapshotIDs 解析主机ID和快照ID，参数错误时已写入响应
+func parseSnapshotIDs(c *gin.Context) (uint, uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return 0, 0, false
+	}
+	sid, err := strconv.ParseUint(c.Param("sid"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的快照ID")
+		return 0, 0, false
+	}
+	return uint(id), uint(sid), true
+}
@@ -38,14 +38,20 @@ type HostService struct {
 	hostUseCase            *asset.HostUseCase
 	credentialUseCase      *asset.CredentialUseCase
 	cloudUseCase           *asset.CloudAccountUseCase
+	metricUseCase          *asset.HostMetricUseCase
+	inventoryUseCase       *asset.InventoryUseCase
+	driftUseCase           *asset.DriftUseCase
 	assetPermissionUseCase *rbac.AssetPermissionUseCase
 }
 
-func NewHostService(hostUseCase *asset.HostUseCase, credentialUseCase *asset.CredentialUseCase, cloudUseCase *asset.CloudAccountUseCase, assetPermissionUseCase *rbac.AssetPermissionUseCase) *HostService {
+func NewHostService(hostUseCase *asset.HostUseCase, credentialUseCase *asset.CredentialUseCase, cloudUseCase *asset.CloudAccountUseCase, metricUseCase *asset.HostMetricUseCase, inventoryUseCase *asset.InventoryUseCase, driftUseCase *asset.DriftUseCase, assetPermissionUseCase *rbac.AssetPermissionUseCase) *HostService {
 	return &HostService{
 		hostUseCase:            hostUseCase,
 		credentialUseCase:      credentialUseCase,
 		cloudUseCase:           cloudUseCase,
+		metricUseCase:          metricUseCase,
+		inventoryUseCase:       inventoryUseCase,
+		driftUseCase:           driftUseCase,
 		assetPermissionUseCase: assetPermissionUseCase,
 	}
 }
@@ -704,7 +710,7 @@ func (s *HostService) TestHostConnection(c *gin.Context) {
 
 // BatchCollectHostInfo 批量采集主机信息
 // @Summary 批量采集主机信息
-// @Description 批量采集多个主机的系统信息
+// @Description 立即并发采集多个主机的系统信息并记录指标，返回采集失败的主机数；主机指标另由后台定时采集
 // @Tags 资产管理-主机
 // @Accept json
 // @Produce json
@@ -722,12 +728,16 @@ func (s *HostService) BatchCollectHostInfo(c *gin.Context) {
 		return
 	}
 
-	if err := s.hostUseCase.BatchCollectHostInfo(c.Request.Context(), req.HostIDs); err != nil {
+	failed, err := s.hostUseCase.BatchCollectHostInfo(c.Request.Context(), req.HostIDs)
+	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "批量采集失败: "+err.Error())
 		return
 	}
 
-	response.SuccessWithMessage(c, "批量采集完成", nil)
+	response.SuccessWithMessage(c, "批量采集完成", gin.H{
+		"total":  len(req.HostIDs),
+		"failed": failed,
+	})
 }
 
 // BatchDeleteHosts 批量删除主机
@@ -0,0 +1,208 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+// ListHostKeys 获取主机密钥列表
+// @Summary 获取主机密钥列表
+// @Description 分页获取已记录的主机SSH公钥，status=mismatch 时只返回检测到密钥变更、等待确认的主机
+// @Tags 资产管理-主机密钥
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param keyword query string false "主机名称或IP"
+// @Param status query string false "状态 trusted/mismatch"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/host-keys [get]
+func (s *HostService) ListHostKeys(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	list, total, err := s.hostUseCase.HostKeys().List(c.Request.Context(), page, pageSize, c.Query("keyword"), c.Query("status"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"list":     list,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetHostKey 获取主机密钥
+// @Summary 获取主机密钥
+// @Description 获取主机已信任的SSH公钥及待确认的新公钥，未记录时返回空
+// @Tags 资产管理-主机密钥
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/hosts/{id}/host-key [get]
+func (s *HostService) GetHostKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	key, err := s.hostUseCase.HostKeys().Get(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, key)
+}
+
+// PinHostKey 预置主机密钥
+// @Summary 预置主机密钥
+// @Description 导入主机SSH公钥（如 ssh-keyscan 的输出），替换已记录的公钥并清除待确认的密钥
+// @Tags 资产管理-主机密钥
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param body body asset.HostKeyRequest true "公钥"
+// @Success 200 {object} response.Response "保存成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/host-key [put]
+func (s *HostService) PinHostKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	var req asset.HostKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	key, err := s.hostUseCase.HostKeys().Pin(c.Request.Context(), uint(id), req.PublicKey, rbacService.GetUserID(c), rbacService.GetUsername(c))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "保存成功", key)
+}
+
+// AcceptHostKey 确认主机新密钥
+// @Summary 确认主机新密钥
+// @Description 核实主机密钥轮换后确认检测到的新公钥，之后的连接以新公钥为准
+// @Tags 资产管理-主机密钥
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param body body asset.AcceptHostKeyRequest true "待确认密钥的指纹"
+// @Success 200 {object} response.Response "确认成功"
+// @Failure 400 {object} response.Response "没有待确认的密钥"
+// @Failure 409 {object} response.Response "待确认的密钥已变化"
+// @Router /api/v1/hosts/{id}/host-key/accept [post]
+func (s *HostService) AcceptHostKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	var req asset.AcceptHostKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	key, err := s.hostUseCase.HostKeys().Accept(c.Request.Context(), uint(id), req.Fingerprint, rbacService.GetUserID(c), rbacService.GetUsername(c))
+	switch {
+	case errors.Is(err, asset.ErrHostKeyNoPending):
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, asset.ErrHostKeyFingerprintChanged):
+		response.ErrorCode(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		response.ErrorCode(c, http.StatusInternalServerError, "确认失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "确认成功", key)
+}
+
+// DeleteHostKey 删除主机密钥
+// @Summary 删除主机密钥
+// @Description 删除主机已记录的SSH公钥，下次连接时重新记录（TOFU）
+// @Tags 资产管理-主机密钥
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Router /api/v1/hosts/{id}/host-key [delete]
+func (s *HostService) DeleteHostKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	if err := s.hostUseCase.HostKeys().Forget(c.Request.Context(), uint(id)); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "删除失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// GetSSHPoolStats 获取SSH连接池状态
+// @Summary 获取SSH连接池状态
+// @Description 返回共享SSH连接池的累计拨号、复用、驱逐次数以及各主机当前的连接与会话占用情况
+// @Tags 资产管理-主机
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/ssh-pool/stats [get]
+func (s *HostService) GetSSHPoolStats(c *gin.Context) {
+	response.Success(c, s.hostUseCase.Connector().Pool().Stats())
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+const metricTimeLayout = "2006-01-02 15:04:05"
+
+// GetHostMetrics 获取主机指标趋势
+// @Summary 获取主机指标趋势
+// @Description 获取主机CPU、内存、磁盘使用率的历史数据，默认最近24小时；未指定精度时6小时内返回原始数据，7天内返回5分钟聚合，其余返回1小时聚合
+// @Tags 资产管理-主机
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Param startTime query string false "开始时间 2006-01-02 15:04:05"
+// @Param endTime query string false "结束时间 2006-01-02 15:04:05"
+// @Param resolution query string false "精度 raw/5m/1h"
+// @Success 200 {object} response.Response{data=asset.HostMetricTrend} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/hosts/{id}/metrics [get]
+func (s *HostService) GetHostMetrics(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+	start, end, ok := parseMetricRange(c)
+	if !ok {
+		return
+	}
+
+	trend, err := s.metricUseCase.HostTrend(c.Request.Context(), uint(id), start, end, c.Query("resolution"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, trend)
+}
+
+// GetGroupMetrics 获取分组指标趋势
+// @Summary 获取分组指标趋势
+// @Description 汇总分组（含子分组）内当前用户有权限的主机指标，默认最近24小时，最细为5分钟聚合
+// @Tags 资产管理-分组
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "分组ID"
+// @Param startTime query string false "开始时间 2006-01-02 15:04:05"
+// @Param endTime query string false "结束时间 2006-01-02 15:04:05"
+// @Param resolution query string false "精度 5m/1h"
+// @Success 200 {object} response.Response{data=asset.GroupMetricTrend} "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/asset-groups/{id}/metrics [get]
+func (s *HostService) GetGroupMetrics(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的分组ID")
+		return
+	}
+	start, end, ok := parseMetricRange(c)
+	if !ok {
+		return
+	}
+
+	var accessibleHostIDs []uint
+	if userID := rbacService.GetUserID(c); userID > 0 {
+		hostIDs, err := s.assetPermissionUseCase.GetUserAccessibleHostIDs(c.Request.Context(), userID)
+		if err != nil || hostIDs == nil {
+			// 获取权限出错或没有任何主机权限时不返回数据
+			hostIDs = []uint{}
+		}
+		accessibleHostIDs = hostIDs
+	}
+
+	trend, err := s.metricUseCase.GroupTrend(c.Request.Context(), uint(id), accessibleHostIDs, start, end, c.Query("resolution"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, trend)
+}
+
+// parseMetricRange 解析查询时间范围，默认最近24小时，参数错误时已写入响应
+func parseMetricRange(c *gin.Context) (time.Time, time.Time, bool) {
+	end := time.Now()
+	if v := c.Query("endTime"); v != "" {
+		t, err := time.ParseInLocation(metricTimeLayout, v, time.Local)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "结束时间格式错误")
+			return time.Time{}, time.Time{}, false
+		}
+		end = t
+	}
+	start := end.Add(-24 * time.Hour)
+	if v := c.Query("startTime"); v != "" {
+		t, err := time.ParseInLocation(metricTimeLayout, v, time.Local)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "开始时间格式错误")
+			return time.Time{}, time.Time{}, false
+		}
+		start = t
+	}
+	return start, end, true
+}
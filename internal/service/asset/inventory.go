@@ -0,0 +1,163 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package asset
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+// GetHostInventory 获取主机清单
+// @Summary 获取主机清单
+// @Description 获取最近一次采集的监听端口、systemd服务、软件包、本地用户、网卡和挂载点，未采集过时返回空
+// @Tags 资产管理-主机清单
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Success 200 {object} response.Response{data=asset.HostInventoryDetail} "获取成功"
+// @Router /api/v1/hosts/{id}/inventory [get]
+func (s *HostService) GetHostInventory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	detail, err := s.inventoryUseCase.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, detail)
+}
+
+// CollectHostInventory 采集主机清单
+// @Summary 采集主机清单
+// @Description 立即通过SSH采集主机清单并替换已保存的清单，部分项采集失败时在 errors 中返回原因
+// @Tags 资产管理-主机清单
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "主机ID"
+// @Success 200 {object} response.Response{data=asset.HostInventoryDetail} "采集成功"
+// @Router /api/v1/hosts/{id}/inventory/collect [post]
+func (s *HostService) CollectHostInventory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的主机ID")
+		return
+	}
+
+	detail, err := s.inventoryUseCase.Collect(c.Request.Context(), uint(id))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "采集失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "采集成功", detail)
+}
+
+// SearchInventory 检索主机清单
+// @Summary 检索主机清单
+// @Description 跨主机检索清单明细，只返回当前用户有权限的主机。名称支持 * 通配符，软件包支持版本条件，
+// @Description 例如 kind=package&name=openssl&version=<3.0 查询 openssl 版本低于 3.0 的主机
+// @Tags 资产管理-主机清单
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param kind query string true "检索类型 package/port/service/user/interface/mount"
+// @Param name query string false "软件包、服务、用户、网卡名称或挂载点"
+// @Param version query string false "软件包版本条件，支持 < <= > >= = !="
+// @Param port query int false "监听端口"
+// @Param protocol query string false "监听协议 tcp/udp"
+// @Param process query string false "监听进程"
+// @Param state query string false "服务状态 active/inactive/failed"
+// @Param sudo query bool false "是否有sudo权限"
+// @Param address query string false "IP地址前缀"
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(20)
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/inventory/search [get]
+func (s *HostService) SearchInventory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 500 {
+		pageSize = 20
+	}
+
+	q := &asset.InventoryQuery{
+		Kind:     c.Query("kind"),
+		Name:     c.Query("name"),
+		Version:  c.Query("version"),
+		Protocol: c.Query("protocol"),
+		Process:  c.Query("process"),
+		State:    c.Query("state"),
+		Address:  c.Query("address"),
+	}
+	if v := c.Query("port"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "无效的端口")
+			return
+		}
+		q.Port = port
+	}
+	if v := c.Query("sudo"); v != "" {
+		sudo, err := strconv.ParseBool(v)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "无效的sudo参数")
+			return
+		}
+		q.Sudo = &sudo
+	}
+
+	if userID := rbacService.GetUserID(c); userID > 0 {
+		hostIDs, err := s.assetPermissionUseCase.GetUserAccessibleHostIDs(c.Request.Context(), userID)
+		if err != nil || hostIDs == nil {
+			// 获取权限出错或没有任何主机权限时不返回数据
+			hostIDs = []uint{}
+		}
+		q.HostIDs = hostIDs
+	}
+
+	list, total, err := s.inventoryUseCase.Search(c.Request.Context(), q, page, pageSize)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"list":     list,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
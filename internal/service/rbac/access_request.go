@@ -0,0 +1,284 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+type AccessRequestService struct {
+	accessRequestUseCase *rbac.AccessRequestUseCase
+}
+
+func NewAccessRequestService(accessRequestUseCase *rbac.AccessRequestUseCase) *AccessRequestService {
+	return &AccessRequestService{
+		accessRequestUseCase: accessRequestUseCase,
+	}
+}
+
+// CreateAccessRequest 提交临时权限申请
+// @Summary 提交临时权限申请
+// @Description 申请一段时间内的主机分组操作权限或K8s集群角色，审批通过后生效，到期自动回收
+// @Tags 临时权限
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body rbac.AccessRequestCreateReq true "申请内容"
+// @Success 200 {object} response.Response "提交成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Router /api/v1/access-requests [post]
+func (s *AccessRequestService) CreateAccessRequest(c *gin.Context) {
+	var req rbac.AccessRequestCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	created, err := s.accessRequestUseCase.Create(c.Request.Context(), GetUserID(c), GetUsername(c), &req)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "提交失败: "+err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "提交成功，等待审批", created)
+}
+
+// ListMyAccessRequests 获取我的临时权限申请
+// @Summary 获取我的临时权限申请
+// @Description 分页获取当前用户提交的临时权限申请
+// @Tags 临时权限
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param status query string false "状态"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/access-requests [get]
+func (s *AccessRequestService) ListMyAccessRequests(c *gin.Context) {
+	s.list(c, GetUserID(c))
+}
+
+// ListAllAccessRequests 获取所有临时权限申请
+// @Summary 获取所有临时权限申请
+// @Description 审批人分页获取所有用户的临时权限申请
+// @Tags 临时权限
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param status query string false "状态"
+// @Param resourceType query string false "资源类型"
+// @Param keyword query string false "申请人、资源或原因"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/access-requests/review [get]
+func (s *AccessRequestService) ListAllAccessRequests(c *gin.Context) {
+	s.list(c, 0)
+}
+
+func (s *AccessRequestService) list(c *gin.Context, userID uint) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	requests, total, err := s.accessRequestUseCase.List(c.Request.Context(), rbac.AccessRequestQuery{
+		Page:         page,
+		PageSize:     pageSize,
+		UserID:       userID,
+		Status:       c.Query("status"),
+		ResourceType: c.Query("resourceType"),
+		Keyword:      c.Query("keyword"),
+	})
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	response.Success(c, gin.H{
+		"list":     requests,
+		"page":     page,
+		"pageSize": pageSize,
+		"total":    total,
+	})
+}
+
+// ListAccessResourceTypes 获取可申请的资源类型
+// @Summary 获取可申请的资源类型
+// @Description 返回已启用授权实现的资源类型，K8s集群需要启用Kubernetes插件
+// @Tags 临时权限
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/access-requests/resource-types [get]
+func (s *AccessRequestService) ListAccessResourceTypes(c *gin.Context) {
+	response.Success(c, gin.H{
+		"types":      rbac.AccessResourceTypes(),
+		"maxMinutes": rbac.MaxAccessRequestMinutes,
+	})
+}
+
+// ApproveAccessRequest 审批通过临时权限申请
+// @Summary 审批通过临时权限申请
+// @Description 通过申请并立即创建有期限的授权
+// @Tags 临时权限
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "申请ID"
+// @Param body body rbac.AccessRequestReviewReq false "审批意见"
+// @Success 200 {object} response.Response "审批成功"
+// @Router /api/v1/access-requests/{id}/approve [post]
+func (s *AccessRequestService) ApproveAccessRequest(c *gin.Context) {
+	id, comment, ok := parseAccessReview(c)
+	if !ok {
+		return
+	}
+
+	req, err := s.accessRequestUseCase.Approve(c.Request.Context(), id, GetUserID(c), GetUsername(c), comment)
+	if err != nil {
+		accessRequestError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "已授权", req)
+}
+
+// RejectAccessRequest 驳回临时权限申请
+// @Summary 驳回临时权限申请
+// @Description 驳回待审批的申请
+// @Tags 临时权限
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "申请ID"
+// @Param body body rbac.AccessRequestReviewReq false "驳回原因"
+// @Success 200 {object} response.Response "驳回成功"
+// @Router /api/v1/access-requests/{id}/reject [post]
+func (s *AccessRequestService) RejectAccessRequest(c *gin.Context) {
+	id, comment, ok := parseAccessReview(c)
+	if !ok {
+		return
+	}
+
+	req, err := s.accessRequestUseCase.Reject(c.Request.Context(), id, GetUserID(c), GetUsername(c), comment)
+	if err != nil {
+		accessRequestError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "已驳回", req)
+}
+
+// RevokeAccessRequest 提前收回临时权限
+// @Summary 提前收回临时权限
+// @Description 审批人在到期前收回已生效的授权
+// @Tags 临时权限
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "申请ID"
+// @Param body body rbac.AccessRequestReviewReq false "收回原因"
+// @Success 200 {object} response.Response "收回成功"
+// @Router /api/v1/access-requests/{id}/revoke [post]
+func (s *AccessRequestService) RevokeAccessRequest(c *gin.Context) {
+	id, comment, ok := parseAccessReview(c)
+	if !ok {
+		return
+	}
+
+	req, err := s.accessRequestUseCase.Revoke(c.Request.Context(), id, GetUserID(c), GetUsername(c), comment)
+	if err != nil {
+		accessRequestError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "已收回", req)
+}
+
+// CancelAccessRequest 撤回自己的申请
+// @Summary 撤回临时权限申请
+// @Description 申请人撤回待审批的申请，或提前归还已生效的权限
+// @Tags 临时权限
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "申请ID"
+// @Success 200 {object} response.Response "撤回成功"
+// @Router /api/v1/access-requests/{id}/cancel [post]
+func (s *AccessRequestService) CancelAccessRequest(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的申请ID")
+		return
+	}
+
+	req, err := s.accessRequestUseCase.Cancel(c.Request.Context(), uint(id), GetUserID(c), GetUsername(c))
+	if err != nil {
+		accessRequestError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "已撤回", req)
+}
+
+// parseAccessReview 解析申请ID和审批意见，失败时已写入响应
+func parseAccessReview(c *gin.Context) (uint, string, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的申请ID")
+		return 0, "", false
+	}
+	var req rbac.AccessRequestReviewReq
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+			return 0, "", false
+		}
+	}
+	return uint(id), req.Comment, true
+}
+
+func accessRequestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, rbac.ErrAccessRequestNotFound):
+		response.ErrorCode(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, rbac.ErrAccessRequestSelfApproval), errors.Is(err, rbac.ErrAccessRequestNotOwner),
+		errors.Is(err, rbac.ErrAccessRequestBeyondApprover):
+		response.ErrorCode(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, rbac.ErrAccessRequestDecided), errors.Is(err, rbac.ErrAccessRequestNotActive),
+		errors.Is(err, rbac.ErrAccessResourceUnsupported), errors.Is(err, rbac.ErrAccessGrantExists):
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+	}
+}
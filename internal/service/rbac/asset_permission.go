@@ -61,8 +61,13 @@ func (s *AssetPermissionService) CreateAssetPermission(c *gin.Context) {
 		req.Permissions = rbac.PermissionView
 	}
 
-	// 批量创建权限（带操作权限）
-	if err := s.assetPermissionUseCase.CreateBatchWithPermissions(c.Request.Context(), req.RoleID, req.AssetGroupID, req.HostIDs, req.Permissions); err != nil {
+	if err := req.Conditions.Validate(); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	// 创建授权规则（带操作权限和访问条件）
+	if err := s.assetPermissionUseCase.CreateRule(c.Request.Context(), req.ToRule()); err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "创建失败: "+err.Error())
 		return
 	}
@@ -156,14 +161,12 @@ func (s *AssetPermissionService) UpdateAssetPermission(c *gin.Context) {
 		req.Permissions = rbac.PermissionView
 	}
 
-	if err := s.assetPermissionUseCase.UpdateAssetPermission(
-		c.Request.Context(),
-		uint(id),
-		req.RoleID,
-		req.AssetGroupID,
-		req.HostIDs,
-		req.Permissions,
-	); err != nil {
+	if err := req.Conditions.Validate(); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := s.assetPermissionUseCase.UpdateAssetPermission(c.Request.Context(), uint(id), req.ToRule()); err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "更新失败: "+err.Error())
 		return
 	}
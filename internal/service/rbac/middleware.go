@@ -58,6 +58,7 @@ func GetUsername(c *gin.Context) string {
 type AuthMiddleware struct {
 	authService        *AuthService
 	assetPermissionRepo rbac.AssetPermissionRepo
+	permissionCache     *rbac.PermissionCache
 }
 
 func NewAuthMiddleware(authService *AuthService) *AuthMiddleware {
@@ -71,6 +72,11 @@ func (m *AuthMiddleware) SetAssetPermissionRepo(repo rbac.AssetPermissionRepo) {
 	m.assetPermissionRepo = repo
 }
 
+// SetPermissionCache 设置用户权限编码缓存
+func (m *AuthMiddleware) SetPermissionCache(cache *rbac.PermissionCache) {
+	m.permissionCache = cache
+}
+
 // AuthRequired JWT认证
 func (m *AuthMiddleware) AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -114,6 +120,13 @@ func (m *AuthMiddleware) AuthRequired() gin.HandlerFunc {
 		c.Set(UserIdKey, claims.UserID)
 		c.Set(UsernameKey, claims.Username)
 		c.Set("userID", claims.UserID) // 兼容 OAuth2 使用的 key
+		// 记录来源IP，供资产授权规则匹配来源网段
+		c.Request = c.Request.WithContext(rbac.ContextWithClientIP(c.Request.Context(), c.ClientIP()))
+		// 记录当前项目，数据层据此按项目过滤资源
+		if !m.resolveProjectScope(c, claims.UserID) {
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -228,7 +241,7 @@ func (m *AuthMiddleware) RequireHostPermission(operation uint) gin.HandlerFunc {
 		}
 
 		// 检查权限
-		hasPermission, err := m.assetPermissionRepo.CheckHostOperationPermission(
+		decision, err := m.assetPermissionRepo.CheckHostOperationPermission(
 			c.Request.Context(),
 			userID,
 			uint(hostID),
@@ -241,8 +254,8 @@ func (m *AuthMiddleware) RequireHostPermission(operation uint) gin.HandlerFunc {
 			return
 		}
 
-		if !hasPermission {
-			response.ErrorCode(c, http.StatusForbidden, "权限不足")
+		if !decision.Allowed {
+			response.ErrorCode(c, http.StatusForbidden, "权限不足："+decision.Reason)
 			c.Abort()
 			return
 		}
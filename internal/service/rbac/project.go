@@ -0,0 +1,403 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+type ProjectService struct {
+	projectUseCase  *rbac.ProjectUseCase
+	permissionCache *rbac.PermissionCache
+}
+
+func NewProjectService(projectUseCase *rbac.ProjectUseCase, permissionCache *rbac.PermissionCache) *ProjectService {
+	return &ProjectService{
+		projectUseCase:  projectUseCase,
+		permissionCache: permissionCache,
+	}
+}
+
+// ListProjects 获取项目列表
+// @Summary 获取项目列表
+// @Description 获取全部项目及成员数量
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param keyword query string false "项目名称或编码"
+// @Success 200 {object} response.Response{data=[]rbac.SysProject} "获取成功"
+// @Router /api/v1/projects [get]
+func (s *ProjectService) ListProjects(c *gin.Context) {
+	projects, err := s.projectUseCase.List(c.Request.Context(), c.Query("keyword"))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+	response.Success(c, projects)
+}
+
+// ListMyProjects 获取当前用户可切换的项目
+// @Summary 获取我的项目
+// @Description 平台管理员返回全部启用的项目，普通用户返回加入的项目
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/projects/mine [get]
+func (s *ProjectService) ListMyProjects(c *gin.Context) {
+	ctx := rbac.WithoutProjectScope(c.Request.Context())
+	perms, err := s.permissionCache.Get(ctx, GetUserID(c))
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+		return
+	}
+
+	var projects []*rbac.SysProject
+	if perms.Admin {
+		all, err := s.projectUseCase.List(ctx, "")
+		if err != nil {
+			response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+			return
+		}
+		for _, project := range all {
+			if project.Status == 1 {
+				projects = append(projects, project)
+			}
+		}
+	} else {
+		projects, err = s.projectUseCase.ListByUserID(ctx, GetUserID(c))
+		if err != nil {
+			response.ErrorCode(c, http.StatusInternalServerError, "查询失败: "+err.Error())
+			return
+		}
+	}
+	if projects == nil {
+		projects = []*rbac.SysProject{}
+	}
+
+	response.Success(c, gin.H{
+		"list":            projects,
+		"isPlatformAdmin": perms.Admin,
+	})
+}
+
+// GetProject 获取项目详情
+// @Summary 获取项目详情
+// @Description 获取项目信息及各类资源数量
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "项目ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /api/v1/projects/{id} [get]
+func (s *ProjectService) GetProject(c *gin.Context) {
+	id, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	project, err := s.projectUseCase.GetByID(c.Request.Context(), id)
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+	counts, err := s.projectUseCase.ResourceCounts(c.Request.Context(), id)
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"project":   project,
+		"resources": counts,
+	})
+}
+
+// CreateProject 创建项目
+// @Summary 创建项目
+// @Description 创建项目（租户），资源和成员按项目隔离
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body rbac.ProjectRequest true "项目信息"
+// @Success 200 {object} response.Response{data=rbac.SysProject} "创建成功"
+// @Router /api/v1/projects [post]
+func (s *ProjectService) CreateProject(c *gin.Context) {
+	var req rbac.ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	project, err := s.projectUseCase.Create(c.Request.Context(), &req)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "创建失败: "+err.Error())
+		return
+	}
+	response.SuccessWithMessage(c, "创建成功", project)
+}
+
+// UpdateProject 更新项目
+// @Summary 更新项目
+// @Description 更新项目信息，禁用后成员不能再切换到该项目
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "项目ID"
+// @Param body body rbac.ProjectRequest true "项目信息"
+// @Success 200 {object} response.Response{data=rbac.SysProject} "更新成功"
+// @Router /api/v1/projects/{id} [put]
+func (s *ProjectService) UpdateProject(c *gin.Context) {
+	id, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+	var req rbac.ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	project, err := s.projectUseCase.Update(c.Request.Context(), id, &req)
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+	response.SuccessWithMessage(c, "更新成功", project)
+}
+
+// DeleteProject 删除项目
+// @Summary 删除项目
+// @Description 删除项目及其成员关系，项目下仍有资源时不能删除
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "项目ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Router /api/v1/projects/{id} [delete]
+func (s *ProjectService) DeleteProject(c *gin.Context) {
+	id, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	if err := s.projectUseCase.Delete(c.Request.Context(), id); err != nil {
+		projectError(c, err)
+		return
+	}
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// ListProjectMembers 获取项目成员
+// @Summary 获取项目成员
+// @Description 获取项目成员及其项目角色
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "项目ID"
+// @Success 200 {object} response.Response{data=[]rbac.ProjectMemberInfo} "获取成功"
+// @Router /api/v1/projects/{id}/members [get]
+func (s *ProjectService) ListProjectMembers(c *gin.Context) {
+	id, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+
+	members, err := s.projectUseCase.ListMembers(c.Request.Context(), id)
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+	response.Success(c, members)
+}
+
+// SaveProjectMembers 添加项目成员
+// @Summary 添加项目成员
+// @Description 添加成员并设置项目角色，成员已存在时修改其项目角色
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "项目ID"
+// @Param body body rbac.ProjectMemberRequest true "成员和项目角色"
+// @Success 200 {object} response.Response "保存成功"
+// @Router /api/v1/projects/{id}/members [post]
+func (s *ProjectService) SaveProjectMembers(c *gin.Context) {
+	id, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+	var req rbac.ProjectMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := s.projectUseCase.SaveMembers(c.Request.Context(), id, &req); err != nil {
+		projectError(c, err)
+		return
+	}
+	response.SuccessWithMessage(c, "保存成功", nil)
+}
+
+// RemoveProjectMember 移除项目成员
+// @Summary 移除项目成员
+// @Description 将用户移出项目
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "项目ID"
+// @Param userId path int true "用户ID"
+// @Success 200 {object} response.Response "移除成功"
+// @Router /api/v1/projects/{id}/members/{userId} [delete]
+func (s *ProjectService) RemoveProjectMember(c *gin.Context) {
+	id, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的用户ID")
+		return
+	}
+
+	if err := s.projectUseCase.RemoveMember(c.Request.Context(), id, uint(userID)); err != nil {
+		projectError(c, err)
+		return
+	}
+	response.SuccessWithMessage(c, "移除成功", nil)
+}
+
+// ListProjectResourceTypes 获取按项目隔离的资源类型
+// @Summary 获取项目资源类型
+// @Description 返回核心模块和已启用插件登记的按项目隔离的资源类型
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=[]rbac.ProjectResource} "获取成功"
+// @Router /api/v1/projects/resource-types [get]
+func (s *ProjectService) ListProjectResourceTypes(c *gin.Context) {
+	response.Success(c, rbac.ProjectResources())
+}
+
+// AssignProjectResources 迁移资源到项目
+// @Summary 迁移资源到项目
+// @Description 将指定类型的资源迁移到项目
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "目标项目ID"
+// @Param body body rbac.ProjectAssignRequest true "资源类型和资源ID"
+// @Success 200 {object} response.Response "迁移成功"
+// @Router /api/v1/projects/{id}/resources [post]
+func (s *ProjectService) AssignProjectResources(c *gin.Context) {
+	id, ok := parseProjectID(c)
+	if !ok {
+		return
+	}
+	var req rbac.ProjectAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	affected, err := s.projectUseCase.AssignResources(c.Request.Context(), id, &req)
+	if err != nil {
+		projectError(c, err)
+		return
+	}
+	response.SuccessWithMessage(c, "迁移成功", gin.H{"affected": affected})
+}
+
+// resolveProjectScope 按请求头或 projectId 查询参数计算当前请求的项目范围并写入请求上下文
+// 失败时已写入响应
+func (m *AuthMiddleware) resolveProjectScope(c *gin.Context, userID uint) bool {
+	if m.permissionCache == nil {
+		return true
+	}
+
+	raw := c.GetHeader(rbac.ProjectHeader)
+	if raw == "" {
+		raw = c.Query("projectId")
+	}
+	var requested uint
+	if raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "无效的项目ID")
+			return false
+		}
+		requested = uint(id)
+	}
+
+	perms, err := m.permissionCache.Get(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "权限检查失败")
+		return false
+	}
+	scope, err := perms.ResolveProjectScope(requested)
+	if err != nil {
+		response.ErrorCode(c, http.StatusForbidden, err.Error())
+		return false
+	}
+
+	c.Request = c.Request.WithContext(rbac.ContextWithProjectScope(c.Request.Context(), scope))
+	return true
+}
+
+// parseProjectID 解析路径中的项目ID，失败时已写入响应
+func parseProjectID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的项目ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func projectError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, rbac.ErrProjectNotFound):
+		response.ErrorCode(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, rbac.ErrProjectForbidden):
+		response.ErrorCode(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, rbac.ErrDefaultProjectProtected), errors.Is(err, rbac.ErrProjectNotEmpty),
+		errors.Is(err, rbac.ErrProjectResourceUnsupported):
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+	}
+}
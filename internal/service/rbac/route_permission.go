@@ -0,0 +1,152 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rbac
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+)
+
+// unguardedRoutePrefixes 无需登录的接口前缀，不参与未登记接口统计
+var unguardedRoutePrefixes = []string{
+	"/api/v1/public",
+	"/api/v1/captcha",
+}
+
+// DeclareRoutes 按路由组登记接口权限，Path 为相对路由组的路径
+func DeclareRoutes(group *gin.RouterGroup, perms ...rbac.RoutePermission) {
+	for i := range perms {
+		perms[i].Path = joinRoutePath(group.BasePath(), perms[i].Path)
+	}
+	rbac.DeclareRoutePermissions(perms...)
+}
+
+// joinRoutePath 与 gin 拼接路由组路径的规则保持一致
+func joinRoutePath(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+	return joined
+}
+
+// RequireRoutePermission 按接口登记的权限编码校验当前用户的角色菜单
+// 未登记的接口直接放行，可通过未登记接口列表排查遗漏
+func (m *AuthMiddleware) RequireRoutePermission() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		perm, ok := rbac.LookupRoutePermission(c.Request.Method, c.FullPath())
+		if !ok || perm.Code == rbac.PermissionLoginOnly {
+			c.Next()
+			return
+		}
+
+		if m.permissionCache == nil {
+			response.ErrorCode(c, http.StatusInternalServerError, "权限检查未初始化")
+			c.Abort()
+			return
+		}
+
+		userID := GetUserID(c)
+		if userID == 0 {
+			response.ErrorCode(c, http.StatusUnauthorized, "未登录")
+			c.Abort()
+			return
+		}
+
+		perms, err := m.permissionCache.Get(c.Request.Context(), userID)
+		if err != nil {
+			response.ErrorCode(c, http.StatusInternalServerError, "权限检查失败")
+			c.Abort()
+			return
+		}
+
+		if !perms.Has(perm.Code) {
+			response.ErrorCode(c, http.StatusForbidden, "权限不足：缺少权限 "+perm.Code)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RoutePermissionService 接口权限服务
+type RoutePermissionService struct {
+	routes func() gin.RoutesInfo
+}
+
+func NewRoutePermissionService(routes func() gin.RoutesInfo) *RoutePermissionService {
+	return &RoutePermissionService{
+		routes: routes,
+	}
+}
+
+// ListRoutePermissions 获取已登记的接口权限
+// @Summary 获取接口权限列表
+// @Description 获取核心模块和插件登记的接口与权限编码映射
+// @Tags 菜单管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=[]rbac.RoutePermission} "获取成功"
+// @Router /api/v1/route-permissions [get]
+func (s *RoutePermissionService) ListRoutePermissions(c *gin.Context) {
+	response.Success(c, rbac.ListRoutePermissions())
+}
+
+// ListUnmappedRoutes 获取未登记权限的接口
+// @Summary 获取未登记权限的接口
+// @Description 列出需要登录但没有登记权限编码的接口，这些接口只校验登录状态
+// @Tags 菜单管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response{data=[]rbac.RoutePermission} "获取成功"
+// @Router /api/v1/route-permissions/unmapped [get]
+func (s *RoutePermissionService) ListUnmappedRoutes(c *gin.Context) {
+	routes := make([]rbac.RoutePermission, 0)
+	for _, r := range s.routes() {
+		if !isGuardedRoute(r.Path) {
+			continue
+		}
+		routes = append(routes, rbac.RoutePermission{Method: r.Method, Path: r.Path})
+	}
+	response.Success(c, rbac.UnmappedRoutes(routes))
+}
+
+func isGuardedRoute(p string) bool {
+	if !strings.HasPrefix(p, "/api/") {
+		return false
+	}
+	for _, prefix := range unguardedRoutePrefixes {
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return false
+		}
+	}
+	return true
+}
@@ -337,6 +337,69 @@ func (s *UserService) GetProfile(c *gin.Context) {
 	response.Success(c, user)
 }
 
+// UpdateProfileRequest 修改个人资料请求
+type UpdateProfileRequest struct {
+	RealName string `json:"realName" binding:"max=50"`
+	Email    string `json:"email" binding:"omitempty,email,max=100"`
+	Phone    string `json:"phone" binding:"max=20"`
+	Avatar   string `json:"avatar" binding:"max=255"`
+}
+
+// UpdateProfile 修改自己的资料
+// @Summary 修改个人资料
+// @Description 用户修改自己的姓名、邮箱、手机号和头像，状态、部门、角色等由管理员在用户管理中修改
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body UpdateProfileRequest true "个人资料"
+// @Success 200 {object} response.Response{} "保存成功"
+// @Router /api/v1/profile [put]
+func (s *UserService) UpdateProfile(c *gin.Context) {
+	userID := GetUserID(c)
+	if userID == 0 {
+		response.ErrorCode(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	user, err := s.userUseCase.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "用户不存在")
+		return
+	}
+	// 未传头像时保留原头像
+	if req.Avatar == "" {
+		req.Avatar = user.Avatar
+	}
+
+	profile := &rbac.SysUser{
+		RealName: req.RealName,
+		Email:    req.Email,
+		Phone:    req.Phone,
+		Avatar:   req.Avatar,
+	}
+	profile.ID = userID
+	if err := s.userUseCase.UpdateProfile(c.Request.Context(), profile); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "保存失败: "+err.Error())
+		return
+	}
+
+	user, err = s.userUseCase.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "获取用户信息失败")
+		return
+	}
+	user.Password = ""
+
+	response.SuccessWithMessage(c, "保存成功", user)
+}
+
 // ChangePasswordRequest 修改密码请求
 type ChangePasswordRequest struct {
 	OldPassword string `json:"oldPassword" binding:"required"`
@@ -449,6 +512,8 @@ func (s *UserService) UpdateUser(c *gin.Context) {
 	}
 
 	req.ID = uint(id)
+	// 密码通过重置密码接口修改，避免明文写入
+	req.Password = ""
 	if err := s.userUseCase.Update(c.Request.Context(), &req); err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "更新失败: "+err.Error())
 		return
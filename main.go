@@ -25,6 +25,7 @@ import (
 
 	"github.com/ydcloud-dy/opshub/cmd/root"
 	_ "github.com/ydcloud-dy/opshub/cmd/config"  // 注册配置命令
+	_ "github.com/ydcloud-dy/opshub/cmd/secrets" // 注册敏感数据加密管理命令
 	_ "github.com/ydcloud-dy/opshub/cmd/server"  // 注册服务命令
 	_ "github.com/ydcloud-dy/opshub/cmd/version" // 注册版本命令
 	_ "github.com/ydcloud-dy/opshub/docs"        // 导入 Swagger 生成的文档
@@ -0,0 +1,63 @@
+-- Access Request Migration
+-- 临时权限申请：个人临时授权字段、申请表和菜单初始化
+-- 执行时间: 2026
+
+SET NAMES utf8mb4;
+
+-- ============================================================
+-- 资产权限支持按用户的临时授权
+-- ============================================================
+
+-- 个人临时授权的 role_id 为 0，不能再受角色外键约束
+ALTER TABLE `sys_role_asset_permission` DROP FOREIGN KEY `fk_role_asset_perm_role`;
+
+ALTER TABLE `sys_role_asset_permission`
+  MODIFY COLUMN `role_id` bigint unsigned NOT NULL COMMENT '角色ID(个人临时授权为0)',
+  ADD COLUMN `user_id` bigint unsigned DEFAULT 0 COMMENT '用户ID，不为0时为该用户的临时授权' AFTER `role_id`,
+  ADD KEY `idx_user_id` (`user_id`);
+
+-- ============================================================
+-- 临时权限申请表
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS `sys_access_request` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL COMMENT '申请人ID',
+  `username` varchar(50) COMMENT '申请人用户名',
+  `resource_type` varchar(30) NOT NULL COMMENT '资源类型 host_group:主机分组 k8s_cluster:K8s集群',
+  `resource_name` varchar(255) COMMENT '资源名称',
+  `asset_group_id` bigint unsigned DEFAULT 0 COMMENT '资产分组ID',
+  `host_ids` json COMMENT '主机ID列表(为空表示整个分组)',
+  `permissions` int unsigned DEFAULT 0 COMMENT '操作权限位掩码',
+  `cluster_id` bigint unsigned DEFAULT 0 COMMENT '集群ID',
+  `role_name` varchar(255) COMMENT 'K8s角色名称',
+  `role_namespace` varchar(255) COMMENT 'K8s角色命名空间',
+  `role_type` varchar(50) COMMENT 'ClusterRole 或 Role',
+  `duration` bigint NOT NULL COMMENT '申请时长(分钟)',
+  `reason` varchar(500) NOT NULL COMMENT '申请原因',
+  `status` varchar(20) NOT NULL COMMENT '状态',
+  `approver_id` bigint unsigned DEFAULT 0 COMMENT '审批人ID',
+  `approver_name` varchar(50) COMMENT '审批人',
+  `comment` varchar(500) COMMENT '审批意见',
+  `approved_at` datetime DEFAULT NULL COMMENT '授权时间',
+  `expires_at` datetime DEFAULT NULL COMMENT '授权到期时间',
+  `revoked_at` datetime DEFAULT NULL COMMENT '回收时间',
+  `revoked_by` varchar(50) COMMENT '回收人，到期回收为 system',
+  `grant_id` bigint unsigned DEFAULT 0 COMMENT '生效的授权记录ID',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_id` (`user_id`),
+  KEY `idx_status` (`status`),
+  KEY `idx_expires_at` (`expires_at`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ============================================================
+-- 菜单
+-- ============================================================
+
+INSERT IGNORE INTO `sys_menu` (`id`, `name`, `code`, `type`, `parent_id`, `path`, `component`, `icon`, `sort`, `visible`, `status`, `created_at`, `updated_at`) VALUES
+  (66, '临时权限', 'access_request', 2, 15, '/asset/access-requests', 'asset/AccessRequests', 'Timer', 7, 1, 1, NOW(), NOW());
+
+INSERT IGNORE INTO `sys_role_menu` (`role_id`, `menu_id`) VALUES
+  (1, 66), (2, 66);
@@ -0,0 +1,74 @@
+-- Project Migration
+-- 多项目隔离：项目表、项目成员表、资源所属项目和菜单初始化
+-- 执行时间: 2026
+
+SET NAMES utf8mb4;
+
+-- ============================================================
+-- 项目和项目成员
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS `sys_project` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL COMMENT '项目名称',
+  `code` varchar(50) NOT NULL COMMENT '项目编码',
+  `description` varchar(500) COMMENT '项目描述',
+  `status` tinyint DEFAULT 1 COMMENT '状态 1:启用 0:禁用',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` datetime COMMENT '删除时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_sys_project_code` (`code`),
+  KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `sys_project_member` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `project_id` bigint unsigned NOT NULL COMMENT '项目ID',
+  `user_id` bigint unsigned NOT NULL COMMENT '用户ID',
+  `role_id` bigint unsigned DEFAULT 0 COMMENT '项目角色ID(0表示普通成员)',
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_project_user` (`project_id`, `user_id`),
+  KEY `idx_sys_project_member_user_id` (`user_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 默认项目，已有用户全部加入，保证升级后仍能看到原有资源
+INSERT IGNORE INTO `sys_project` (`id`, `name`, `code`, `description`, `status`, `created_at`, `updated_at`)
+VALUES (1, '默认项目', 'default', '未划分项目的资源', 1, NOW(), NOW());
+
+INSERT IGNORE INTO `sys_project_member` (`project_id`, `user_id`, `role_id`, `created_at`, `updated_at`)
+SELECT 1, `id`, 0, NOW(), NOW() FROM `sys_user` WHERE `deleted_at` IS NULL;
+
+-- ============================================================
+-- 资源所属项目，存量资源归属默认项目
+-- ============================================================
+
+ALTER TABLE `asset_group`
+  ADD COLUMN `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
+  ADD KEY `idx_asset_group_project_id` (`project_id`);
+
+ALTER TABLE `credentials`
+  ADD COLUMN `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
+  ADD KEY `idx_credentials_project_id` (`project_id`);
+
+ALTER TABLE `hosts`
+  ADD COLUMN `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
+  ADD KEY `idx_hosts_project_id` (`project_id`);
+
+ALTER TABLE `cloud_accounts`
+  ADD COLUMN `project_id` bigint unsigned NOT NULL DEFAULT 1 COMMENT '所属项目ID',
+  ADD KEY `idx_cloud_accounts_project_id` (`project_id`);
+
+-- 插件表在插件启用时由 AutoMigrate 补齐 project_id，Kubernetes 插件见插件目录下的迁移脚本
+
+-- ============================================================
+-- 菜单
+-- ============================================================
+
+INSERT IGNORE INTO `sys_menu` (`id`, `name`, `code`, `type`, `parent_id`, `path`, `component`, `icon`, `sort`, `visible`, `status`, `created_at`, `updated_at`) VALUES
+  (67, '项目管理', 'projects', 2, 1, '/projects', 'system/Projects', 'Briefcase', 8, 1, 1, NOW(), NOW());
+
+INSERT IGNORE INTO `sys_role_menu` (`role_id`, `menu_id`) VALUES
+  (1, 67);
@@ -0,0 +1,506 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 清单采集项
+const (
+	SectionPorts      = "ports"
+	SectionServices   = "services"
+	SectionPackages   = "packages"
+	SectionUsers      = "users"
+	SectionInterfaces = "interfaces"
+	SectionMounts     = "mounts"
+)
+
+// Inventory 主机软硬件清单
+type Inventory struct {
+	Ports          []ListeningPort    `json:"ports"`          // 监听端口
+	Services       []ServiceUnit      `json:"services"`       // systemd 服务
+	PackageManager string             `json:"packageManager"` // rpm / dpkg
+	Packages       []Package          `json:"packages"`       // 已安装软件包
+	Users          []LocalUser        `json:"users"`          // 本地用户
+	Interfaces     []NetworkInterface `json:"interfaces"`     // 网卡
+	Mounts         []Mount            `json:"mounts"`         // 已挂载文件系统
+	CollectedAt    time.Time          `json:"collectedAt"`
+	Errors         map[string]string  `json:"errors,omitempty"` // 采集失败的项及原因
+}
+
+// ListeningPort 监听中的套接字
+type ListeningPort struct {
+	Protocol string `json:"protocol"` // tcp / udp
+	Address  string `json:"address"`  // 监听地址
+	Port     int    `json:"port"`
+	Process  string `json:"process"` // 所属进程，非 root 用户采集时可能为空
+	PID      int    `json:"pid"`
+}
+
+// ServiceUnit systemd 服务单元
+type ServiceUnit struct {
+	Name        string `json:"name"`
+	LoadState   string `json:"loadState"`   // loaded / not-found
+	ActiveState string `json:"activeState"` // active / inactive / failed
+	SubState    string `json:"subState"`    // running / exited / dead
+	Enabled     string `json:"enabled"`     // enabled / disabled / static / masked
+	Description string `json:"description"`
+}
+
+// Package 已安装软件包
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"` // rpm 为 [epoch:]version-release，dpkg 为完整版本号
+	Arch    string `json:"arch"`
+}
+
+// LocalUser 本地用户
+type LocalUser struct {
+	Name  string `json:"name"`
+	UID   int    `json:"uid"`
+	GID   int    `json:"gid"`
+	Home  string `json:"home"`
+	Shell string `json:"shell"`
+	Sudo  bool   `json:"sudo"` // 属于 sudo/wheel/admin 组或在 sudoers 中有授权
+}
+
+// NetworkInterface 网卡
+type NetworkInterface struct {
+	Name      string   `json:"name"`
+	MAC       string   `json:"mac"`
+	State     string   `json:"state"` // UP / DOWN / UNKNOWN
+	MTU       int      `json:"mtu"`
+	Addresses []string `json:"addresses"` // CIDR 格式的 IPv4/IPv6 地址
+}
+
+// Mount 已挂载文件系统
+type Mount struct {
+	Device     string  `json:"device"`
+	MountPoint string  `json:"mountPoint"`
+	Fstype     string  `json:"fstype"`
+	Total      uint64  `json:"total"`
+	Used       uint64  `json:"used"`
+	Usage      float64 `json:"usage"`
+}
+
+// 清单采集命令，均兼容非 root 用户，缺少权限时部分字段为空
+const (
+	cmdListenPorts  = "ss -tulnp 2>/dev/null || ss -tuln"
+	cmdServiceUnits = "systemctl list-units --type=service --all --no-legend --no-pager --plain"
+	cmdServiceFiles = "systemctl list-unit-files --type=service --no-legend --no-pager"
+	cmdPackages     = `if [ -n "$(rpm -qa 2>/dev/null | head -1)" ]; then echo rpm; rpm -qa --qf '%{NAME}\t%{EPOCH}:%{VERSION}-%{RELEASE}\t%{ARCH}\n'; elif command -v dpkg-query >/dev/null 2>&1; then echo dpkg; dpkg-query -W -f='${Package}\t${Version}\t${Architecture}\t${Status}\n'; else echo none; fi`
+	cmdPasswd       = "getent passwd 2>/dev/null || cat /etc/passwd"
+	cmdSudoGroups   = "getent group sudo wheel admin 2>/dev/null; true"
+	cmdSudoers      = "cat /etc/sudoers /etc/sudoers.d/* 2>/dev/null; true"
+	cmdLinks        = "ip -o link show"
+	cmdAddresses    = "ip -o addr show"
+	cmdMounts       = "df -PT -B1 -x tmpfs -x devtmpfs -x overlay -x squashfs 2>/dev/null; true"
+)
+
+// inventorySections 清单采集项数量
+const inventorySections = 6
+
+// CollectInventory 采集主机清单，单项失败不影响其他项，失败原因记录在 Errors 中
+func (c *Collector) CollectInventory() (*Inventory, error) {
+	inv := &Inventory{Errors: make(map[string]string)}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	collect := func(section string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				mu.Lock()
+				inv.Errors[section] = err.Error()
+				mu.Unlock()
+			}
+		}()
+	}
+
+	collect(SectionPorts, func() (err error) {
+		inv.Ports, err = c.CollectListeningPorts()
+		return
+	})
+	collect(SectionServices, func() (err error) {
+		inv.Services, err = c.CollectServices()
+		return
+	})
+	collect(SectionPackages, func() (err error) {
+		inv.PackageManager, inv.Packages, err = c.CollectPackages()
+		return
+	})
+	collect(SectionUsers, func() (err error) {
+		inv.Users, err = c.CollectUsers()
+		return
+	})
+	collect(SectionInterfaces, func() (err error) {
+		inv.Interfaces, err = c.CollectInterfaces()
+		return
+	})
+	collect(SectionMounts, func() (err error) {
+		inv.Mounts, err = c.CollectMounts()
+		return
+	})
+	wg.Wait()
+
+	if len(inv.Errors) == inventorySections {
+		return nil, fmt.Errorf("采集清单失败: %s", inv.Errors[SectionPorts])
+	}
+	inv.CollectedAt = time.Now()
+	return inv, nil
+}
+
+// CollectListeningPorts 采集监听端口
+func (c *Collector) CollectListeningPorts() ([]ListeningPort, error) {
+	output, err := c.sshClient.ExecuteWithTimeout(cmdListenPorts, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("获取监听端口失败: %w", err)
+	}
+	return ParseListeningPorts(output), nil
+}
+
+// CollectServices 采集 systemd 服务
+func (c *Collector) CollectServices() ([]ServiceUnit, error) {
+	units, err := c.sshClient.ExecuteWithTimeout(cmdServiceUnits, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("获取服务列表失败: %w", err)
+	}
+	// 单元文件状态获取失败时只缺少 enabled 字段
+	files, _ := c.sshClient.ExecuteWithTimeout(cmdServiceFiles, c.timeout)
+	return ParseServiceUnits(units, files), nil
+}
+
+// CollectPackages 采集已安装软件包，返回包管理器和软件包列表
+func (c *Collector) CollectPackages() (string, []Package, error) {
+	output, err := c.sshClient.ExecuteWithTimeout(cmdPackages, c.timeout)
+	if err != nil {
+		return "", nil, fmt.Errorf("获取软件包失败: %w", err)
+	}
+	manager, packages := ParsePackages(output)
+	if manager == "" {
+		return "", nil, fmt.Errorf("未找到 rpm 或 dpkg")
+	}
+	return manager, packages, nil
+}
+
+// CollectUsers 采集本地用户及 sudo 权限，读取 sudoers 需要 root 权限，否则只能识别 sudo 组成员
+func (c *Collector) CollectUsers() ([]LocalUser, error) {
+	passwd, err := c.sshClient.ExecuteWithTimeout(cmdPasswd, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户列表失败: %w", err)
+	}
+	groups, _ := c.sshClient.ExecuteWithTimeout(cmdSudoGroups, c.timeout)
+	sudoers, _ := c.sshClient.ExecuteWithTimeout(cmdSudoers, c.timeout)
+	return ParseUsers(passwd, groups, sudoers), nil
+}
+
+// CollectInterfaces 采集网卡及IP地址
+func (c *Collector) CollectInterfaces() ([]NetworkInterface, error) {
+	links, err := c.sshClient.ExecuteWithTimeout(cmdLinks, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("获取网卡列表失败: %w", err)
+	}
+	addrs, _ := c.sshClient.ExecuteWithTimeout(cmdAddresses, c.timeout)
+	return ParseInterfaces(links, addrs), nil
+}
+
+// CollectMounts 采集已挂载的文件系统，忽略 tmpfs、overlay 等临时文件系统
+func (c *Collector) CollectMounts() ([]Mount, error) {
+	output, err := c.sshClient.ExecuteWithTimeout(cmdMounts, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("获取挂载信息失败: %w", err)
+	}
+	return ParseMounts(output), nil
+}
+
+// ParseListeningPorts 解析 ss -tulnp 的输出
+//
+//	Netid State  Recv-Q Send-Q Local Address:Port Peer Address:Port Process
+//	tcp   LISTEN 0      128    0.0.0.0:22         0.0.0.0:*         users:(("sshd",pid=812,fd=3))
+func ParseListeningPorts(output string) []ListeningPort {
+	var ports []ListeningPort
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] == "Netid" {
+			continue
+		}
+		proto := fields[0]
+		if proto != "tcp" && proto != "udp" {
+			continue
+		}
+		addr, port, ok := splitHostPort(fields[4])
+		if !ok {
+			continue
+		}
+		p := ListeningPort{Protocol: proto, Address: addr, Port: port}
+		if len(fields) >= 7 {
+			p.Process, p.PID = parseSSProcess(strings.Join(fields[6:], " "))
+		}
+		key := fmt.Sprintf("%s|%s|%d", p.Protocol, p.Address, p.Port)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		ports = append(ports, p)
+	}
+	return ports
+}
+
+// splitHostPort 拆分 ss 输出的本地地址，兼容 [::]:22、*:22、127.0.0.53%lo:53
+func splitHostPort(s string) (string, int, bool) {
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return "", 0, false
+	}
+	port, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	host := strings.Trim(s[:i], "[]")
+	if j := strings.Index(host, "%"); j >= 0 {
+		host = host[:j]
+	}
+	return host, port, true
+}
+
+// parseSSProcess 解析 users:(("sshd",pid=812,fd=3),...)，取第一个进程
+func parseSSProcess(s string) (string, int) {
+	start := strings.Index(s, "((\"")
+	if start < 0 {
+		return "", 0
+	}
+	rest := s[start+3:]
+	end := strings.Index(rest, "\"")
+	if end < 0 {
+		return "", 0
+	}
+	name := rest[:end]
+	pid := 0
+	if i := strings.Index(rest, "pid="); i >= 0 {
+		num := rest[i+4:]
+		if j := strings.IndexAny(num, ",)"); j >= 0 {
+			num = num[:j]
+		}
+		pid, _ = strconv.Atoi(num)
+	}
+	return name, pid
+}
+
+// ParseServiceUnits 解析 systemctl list-units 与 list-unit-files 的输出
+func ParseServiceUnits(units, files string) []ServiceUnit {
+	enabled := make(map[string]string)
+	for _, line := range strings.Split(files, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && strings.HasSuffix(fields[0], ".service") {
+			enabled[fields[0]] = fields[1]
+		}
+	}
+
+	var services []ServiceUnit
+	for _, line := range strings.Split(units, "\n") {
+		fields := strings.Fields(strings.TrimLeft(line, "● *"))
+		if len(fields) < 4 || !strings.HasSuffix(fields[0], ".service") {
+			continue
+		}
+		services = append(services, ServiceUnit{
+			Name:        fields[0],
+			LoadState:   fields[1],
+			ActiveState: fields[2],
+			SubState:    fields[3],
+			Enabled:     enabled[fields[0]],
+			Description: strings.Join(fields[4:], " "),
+		})
+	}
+	return services
+}
+
+// ParsePackages 解析软件包命令输出，第一行为包管理器名称
+func ParsePackages(output string) (string, []Package) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) == 0 {
+		return "", nil
+	}
+	manager := strings.TrimSpace(lines[0])
+	if manager != "rpm" && manager != "dpkg" {
+		return "", nil
+	}
+
+	var packages []Package
+	for _, line := range lines[1:] {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		version := fields[1]
+		if manager == "rpm" {
+			// 未设置 epoch 时 rpm 输出 (none)
+			version = strings.TrimPrefix(version, "(none):")
+			version = strings.TrimPrefix(version, "0:")
+			if fields[0] == "gpg-pubkey" {
+				continue
+			}
+		} else if len(fields) >= 4 && !strings.HasSuffix(fields[3], " installed") {
+			// dpkg 中已卸载但保留配置的软件包
+			continue
+		}
+		packages = append(packages, Package{Name: fields[0], Version: version, Arch: fields[2]})
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+	return manager, packages
+}
+
+// ParseUsers 解析 passwd，并根据 sudo 相关组成员和 sudoers 规则标记有 sudo 权限的用户
+func ParseUsers(passwd, groups, sudoers string) []LocalUser {
+	sudoUsers := make(map[string]bool)
+	sudoGroups := map[string]bool{"sudo": true, "wheel": true, "admin": true}
+
+	// sudoers 中的用户规则和 %组 规则
+	for _, line := range strings.Split(sudoers, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "Defaults") ||
+			strings.Contains(strings.SplitN(line, " ", 2)[0], "_Alias") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.Contains(line, "=") {
+			continue
+		}
+		if strings.HasPrefix(fields[0], "%") {
+			sudoGroups[strings.TrimPrefix(fields[0], "%")] = true
+		} else {
+			sudoUsers[fields[0]] = true
+		}
+	}
+
+	// getent group 输出: sudo:x:27:alice,bob
+	primaryGroups := make(map[int]bool)
+	for _, line := range strings.Split(groups, "\n") {
+		parts := strings.Split(strings.TrimSpace(line), ":")
+		if len(parts) < 4 || !sudoGroups[parts[0]] {
+			continue
+		}
+		if gid, err := strconv.Atoi(parts[2]); err == nil {
+			primaryGroups[gid] = true
+		}
+		for _, member := range strings.Split(parts[3], ",") {
+			if member = strings.TrimSpace(member); member != "" {
+				sudoUsers[member] = true
+			}
+		}
+	}
+
+	var users []LocalUser
+	for _, line := range strings.Split(passwd, "\n") {
+		parts := strings.Split(strings.TrimSpace(line), ":")
+		if len(parts) < 7 {
+			continue
+		}
+		uid, err := strconv.Atoi(parts[2])
+		if err != nil {
+			continue
+		}
+		gid, _ := strconv.Atoi(parts[3])
+		users = append(users, LocalUser{
+			Name:  parts[0],
+			UID:   uid,
+			GID:   gid,
+			Home:  parts[5],
+			Shell: parts[6],
+			Sudo:  sudoUsers[parts[0]] || primaryGroups[gid],
+		})
+	}
+	return users
+}
+
+// ParseInterfaces 解析 ip -o link show 与 ip -o addr show 的输出
+func ParseInterfaces(links, addrs string) []NetworkInterface {
+	var ifaces []NetworkInterface
+	index := make(map[string]int)
+	for _, line := range strings.Split(links, "\n") {
+		// 2: eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 ... state UP ... link/ether 52:54:00:12:34:56 brd ...
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasSuffix(fields[0], ":") {
+			continue
+		}
+		name := strings.TrimSuffix(fields[1], ":")
+		if i := strings.Index(name, "@"); i >= 0 {
+			name = name[:i]
+		}
+		iface := NetworkInterface{Name: name}
+		for i := 2; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "mtu":
+				iface.MTU, _ = strconv.Atoi(fields[i+1])
+			case "state":
+				iface.State = fields[i+1]
+			case "link/ether":
+				iface.MAC = fields[i+1]
+			}
+		}
+		index[name] = len(ifaces)
+		ifaces = append(ifaces, iface)
+	}
+
+	for _, line := range strings.Split(addrs, "\n") {
+		// 2: eth0    inet 10.0.0.5/24 brd 10.0.0.255 scope global eth0\       valid_lft forever ...
+		fields := strings.Fields(line)
+		if len(fields) < 4 || (fields[2] != "inet" && fields[2] != "inet6") {
+			continue
+		}
+		i, ok := index[fields[1]]
+		if !ok {
+			continue
+		}
+		ifaces[i].Addresses = append(ifaces[i].Addresses, fields[3])
+	}
+	return ifaces
+}
+
+// ParseMounts 解析 df -PT -B1 的输出
+func ParseMounts(output string) []Mount {
+	var mounts []Mount
+	for i, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		// 第一行为表头
+		if i == 0 || len(fields) < 7 {
+			continue
+		}
+		m := Mount{
+			Device:     fields[0],
+			Fstype:     fields[1],
+			MountPoint: strings.Join(fields[6:], " "),
+		}
+		m.Total, _ = strconv.ParseUint(fields[2], 10, 64)
+		m.Used, _ = strconv.ParseUint(fields[3], 10, 64)
+		if m.Total > 0 {
+			m.Usage = float64(m.Used) / float64(m.Total) * 100
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{"相同版本", "3.0.7-27.el9", "3.0.7-27.el9", 0},
+		{"主版本较小", "1.1.1k-9.el8", "3.0", -1},
+		{"多出的段更新", "3.0.7-27.el9", "3.0", 1},
+		{"数字按数值比较", "1.10", "1.9", 1},
+		{"忽略前导零", "1.010", "1.10", 0},
+		{"数字段大于字母段", "1.0.1", "1.0a", 1},
+		{"字母后缀", "1.1.1k", "1.1.1f", 1},
+		{"epoch 优先", "1:1.0", "2.0", 1},
+		{"预发布版本较小", "2.0~rc1", "2.0", -1},
+		{"dpkg 版本", "3.0.2-0ubuntu1.10", "3.0.2-0ubuntu1.9", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareVersions(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := CompareVersions(tt.b, tt.a); got != -tt.want {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchVersion(t *testing.T) {
+	tests := []struct {
+		version, constraint string
+		want                bool
+		wantErr             bool
+	}{
+		{"1.1.1k-9.el8", "<3.0", true, false},
+		{"3.0.7-27.el9", "<3.0", false, false},
+		{"3.0.7-27.el9", ">= 3.0", true, false},
+		{"3.0", "3.0", true, false},
+		{"3.0", "!=3.0", false, false},
+		{"3.0", "<", false, true},
+		{"3.0", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version+tt.constraint, func(t *testing.T) {
+			got, err := MatchVersion(tt.version, tt.constraint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("MatchVersion(%q, %q) = %v, want %v", tt.version, tt.constraint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseListeningPorts(t *testing.T) {
+	output := `Netid State  Recv-Q Send-Q Local Address:Port  Peer Address:Port Process
+udp   UNCONN 0      0      127.0.0.53%lo:53     0.0.0.0:*         users:(("systemd-resolve",pid=601,fd=13))
+tcp   LISTEN 0      128    0.0.0.0:22           0.0.0.0:*         users:(("sshd",pid=812,fd=3))
+tcp   LISTEN 0      128    [::]:22              [::]:*            users:(("sshd",pid=812,fd=4))
+tcp   LISTEN 0      511    *:80                 *:*
+`
+	want := []ListeningPort{
+		{Protocol: "udp", Address: "127.0.0.53", Port: 53, Process: "systemd-resolve", PID: 601},
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 22, Process: "sshd", PID: 812},
+		{Protocol: "tcp", Address: "::", Port: 22, Process: "sshd", PID: 812},
+		{Protocol: "tcp", Address: "*", Port: 80},
+	}
+	if got := ParseListeningPorts(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseServiceUnits(t *testing.T) {
+	units := `sshd.service   loaded active   running OpenSSH server daemon
+● kdump.service loaded failed   failed  Crash recovery kernel arming
+nginx.service  loaded inactive dead    The nginx HTTP server
+`
+	files := `sshd.service enabled enabled
+kdump.service enabled enabled
+nginx.service disabled disabled
+`
+	got := ParseServiceUnits(units, files)
+	if len(got) != 3 {
+		t.Fatalf("len = %d, want 3", len(got))
+	}
+	want := ServiceUnit{Name: "kdump.service", LoadState: "loaded", ActiveState: "failed", SubState: "failed", Enabled: "enabled", Description: "Crash recovery kernel arming"}
+	if got[1] != want {
+		t.Errorf("got %+v, want %+v", got[1], want)
+	}
+	if got[2].Enabled != "disabled" || got[2].Description != "The nginx HTTP server" {
+		t.Errorf("got %+v", got[2])
+	}
+}
+
+func TestParsePackages(t *testing.T) {
+	t.Run("rpm", func(t *testing.T) {
+		manager, pkgs := ParsePackages("rpm\nopenssl\t1:3.0.7-27.el9\tx86_64\nbash\t(none):5.1.8-9.el9\tx86_64\ngpg-pubkey\t(none):fd431d51-4ae0493b\t(none)\n")
+		want := []Package{
+			{Name: "bash", Version: "5.1.8-9.el9", Arch: "x86_64"},
+			{Name: "openssl", Version: "1:3.0.7-27.el9", Arch: "x86_64"},
+		}
+		if manager != "rpm" || !reflect.DeepEqual(pkgs, want) {
+			t.Errorf("got %s %+v", manager, pkgs)
+		}
+	})
+
+	t.Run("dpkg 忽略已卸载的软件包", func(t *testing.T) {
+		manager, pkgs := ParsePackages("dpkg\nopenssl\t3.0.2-0ubuntu1.10\tamd64\tinstall ok installed\nold\t1.0\tamd64\tdeinstall ok config-files\n")
+		if manager != "dpkg" || len(pkgs) != 1 || pkgs[0].Version != "3.0.2-0ubuntu1.10" {
+			t.Errorf("got %s %+v", manager, pkgs)
+		}
+	})
+
+	t.Run("没有包管理器", func(t *testing.T) {
+		if manager, pkgs := ParsePackages("none\n"); manager != "" || pkgs != nil {
+			t.Errorf("got %s %+v", manager, pkgs)
+		}
+	})
+}
+
+func TestParseUsers(t *testing.T) {
+	passwd := `root:x:0:0:root:/root:/bin/bash
+alice:x:1000:1000::/home/alice:/bin/bash
+bob:x:1001:10::/home/bob:/bin/zsh
+carol:x:1002:1002::/home/carol:/bin/bash
+dave:x:1003:1003::/home/dave:/sbin/nologin
+`
+	groups := "wheel:x:10:alice\n"
+	sudoers := `# comment
+Defaults    env_reset
+User_Alias ADMINS = dave
+root    ALL=(ALL)       ALL
+carol   ALL=(ALL)       NOPASSWD: ALL
+%ops    ALL=(ALL)       ALL
+@includedir /etc/sudoers.d
+`
+	got := ParseUsers(passwd, groups, sudoers)
+	sudo := make(map[string]bool)
+	for _, u := range got {
+		sudo[u.Name] = u.Sudo
+	}
+	want := map[string]bool{"root": true, "alice": true, "bob": true, "carol": true, "dave": false}
+	if !reflect.DeepEqual(sudo, want) {
+		t.Errorf("got %v, want %v", sudo, want)
+	}
+}
+
+func TestParseInterfaces(t *testing.T) {
+	links := `1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000\    link/loopback 00:00:00:00:00:00 brd 00:00:00:00:00:00
+2: eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc fq_codel state UP mode DEFAULT group default qlen 1000\    link/ether 52:54:00:12:34:56 brd ff:ff:ff:ff:ff:ff
+3: veth1@if2: <BROADCAST,MULTICAST> mtu 1450 qdisc noop state DOWN mode DEFAULT group default\    link/ether 2a:00:00:00:00:01 brd ff:ff:ff:ff:ff:ff link-netnsid 0
+`
+	addrs := `1: lo    inet 127.0.0.1/8 scope host lo\       valid_lft forever preferred_lft forever
+2: eth0    inet 10.0.0.5/24 brd 10.0.0.255 scope global eth0\       valid_lft forever preferred_lft forever
+2: eth0    inet6 fe80::5054:ff:fe12:3456/64 scope link \       valid_lft forever preferred_lft forever
+`
+	got := ParseInterfaces(links, addrs)
+	if len(got) != 3 {
+		t.Fatalf("len = %d, want 3", len(got))
+	}
+	want := NetworkInterface{Name: "eth0", MAC: "52:54:00:12:34:56", State: "UP", MTU: 1500,
+		Addresses: []string{"10.0.0.5/24", "fe80::5054:ff:fe12:3456/64"}}
+	if !reflect.DeepEqual(got[1], want) {
+		t.Errorf("got %+v, want %+v", got[1], want)
+	}
+	if got[2].Name != "veth1" || got[2].State != "DOWN" {
+		t.Errorf("got %+v", got[2])
+	}
+}
+
+func TestParseMounts(t *testing.T) {
+	output := `Filesystem     Type 1-blocks        Used   Available Capacity Mounted on
+/dev/vda1      xfs  53675536384 10737418240 42938118144      21% /
+/dev/vdb1      ext4 107374182400 53687091200 53687091200      50% /data dir
+`
+	got := ParseMounts(output)
+	if len(got) != 2 {
+		t.Fatalf("len = %d, want 2", len(got))
+	}
+	if got[1].MountPoint != "/data dir" || got[1].Fstype != "ext4" || got[1].Usage != 50 {
+		t.Errorf("got %+v", got[1])
+	}
+}
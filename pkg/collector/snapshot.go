@@ -0,0 +1,137 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultConfigFiles 快照默认计算校验和的配置文件，支持通配符
+var DefaultConfigFiles = []string{
+	"/etc/ssh/sshd_config",
+	"/etc/sysctl.conf",
+	"/etc/sysctl.d/*.conf",
+	"/etc/security/limits.conf",
+	"/etc/fstab",
+	"/etc/hosts",
+	"/etc/resolv.conf",
+	"/etc/crontab",
+	"/etc/sudoers",
+	"/etc/nginx/nginx.conf",
+	"/etc/nginx/conf.d/*.conf",
+}
+
+// filePatternRegexp 允许的文件路径，只能是绝对路径，可包含 * ? 通配符，不允许空格和 shell 元字符
+var filePatternRegexp = regexp.MustCompile(`^/[A-Za-z0-9._+@/*?\-]+$`)
+
+// volatileSysctlPrefixes 随系统运行持续变化的内核参数，不参与比对
+var volatileSysctlPrefixes = []string{
+	"fs.aio-nr",
+	"fs.dentry-state",
+	"fs.file-nr",
+	"fs.inode-nr",
+	"fs.inode-state",
+	"fs.quota.",
+	"kernel.ns_last_pid",
+	"kernel.perf_event_max_sample_rate",
+	"kernel.pty.nr",
+	"kernel.random.",
+	"kernel.sched_domain.",
+	"net.netfilter.nf_conntrack_count",
+	"dev.cdrom.",
+}
+
+// ValidateFilePattern 校验配置文件路径
+func ValidateFilePattern(pattern string) error {
+	if !filePatternRegexp.MatchString(pattern) || strings.Contains(pattern, "..") {
+		return fmt.Errorf("无效的文件路径: %s", pattern)
+	}
+	return nil
+}
+
+// CollectFileChecksums 计算文件的 SHA256 校验和，不存在或无权限读取的文件不返回
+func (c *Collector) CollectFileChecksums(patterns []string) (map[string]string, error) {
+	if len(patterns) == 0 {
+		return map[string]string{}, nil
+	}
+	for _, p := range patterns {
+		if err := ValidateFilePattern(p); err != nil {
+			return nil, err
+		}
+	}
+	// 路径已校验不含 shell 元字符，不加引号以便展开通配符
+	cmd := fmt.Sprintf(`for f in %s; do [ -f "$f" ] && sha256sum "$f" 2>/dev/null; done; true`, strings.Join(patterns, " "))
+	output, err := c.sshClient.ExecuteWithTimeout(cmd, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("计算文件校验和失败: %w", err)
+	}
+	return ParseChecksums(output), nil
+}
+
+// CollectSysctl 采集内核参数，忽略持续变化的计数类参数
+func (c *Collector) CollectSysctl() (map[string]string, error) {
+	output, err := c.sshClient.ExecuteWithTimeout("sysctl -a 2>/dev/null; true", c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("获取内核参数失败: %w", err)
+	}
+	return ParseSysctl(output), nil
+}
+
+// ParseChecksums 解析 sha256sum 的输出
+func ParseChecksums(output string) map[string]string {
+	sums := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), "  ", 2)
+		if len(fields) != 2 || len(fields[0]) != 64 {
+			continue
+		}
+		sums[fields[1]] = fields[0]
+	}
+	return sums
+}
+
+// ParseSysctl 解析 sysctl -a 的输出
+func ParseSysctl(output string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		if key == "" || isVolatileSysctl(key) {
+			continue
+		}
+		// 多个值以制表符分隔，统一为空格
+		values[key] = strings.Join(strings.Fields(parts[1]), " ")
+	}
+	return values
+}
+
+func isVolatileSysctl(key string) bool {
+	for _, prefix := range volatileSysctlPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
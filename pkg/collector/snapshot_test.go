@@ -0,0 +1,74 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateFilePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		valid   bool
+	}{
+		{"/etc/nginx/nginx.conf", true},
+		{"/etc/sysctl.d/*.conf", true},
+		{"etc/hosts", false},
+		{"/etc/hosts; rm -rf /", false},
+		{"/etc/$(id)", false},
+		{"/etc/../root/.ssh/id_rsa", false},
+		{"/etc/my file.conf", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if err := ValidateFilePattern(tt.pattern); (err == nil) != tt.valid {
+				t.Errorf("ValidateFilePattern(%q) = %v, want valid %v", tt.pattern, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	output := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  /etc/hosts\n" +
+		"sha256sum: /etc/sudoers: Permission denied\n" +
+		"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  /etc/nginx/conf.d/a b.conf\n"
+	want := map[string]string{
+		"/etc/hosts":                 "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"/etc/nginx/conf.d/a b.conf": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+	}
+	if got := ParseChecksums(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseSysctl(t *testing.T) {
+	output := "net.ipv4.ip_forward = 1\n" +
+		"net.ipv4.tcp_rmem = 4096\t131072\t6291456\n" +
+		"fs.file-nr = 1024\t0\t9223372036854775807\n" +
+		"kernel.random.uuid = 0b7e8c1c-8d5a-4b3e-9a53-6f2f1a3c9e11\n"
+	want := map[string]string{
+		"net.ipv4.ip_forward": "1",
+		"net.ipv4.tcp_rmem":   "4096 131072 6291456",
+	}
+	if got := ParseSysctl(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
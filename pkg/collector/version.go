@@ -0,0 +1,173 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CompareVersions 比较软件包版本号，a<b 返回 -1，相等返回 0，a>b 返回 1
+// 规则与 rpmvercmp 一致：先比较 epoch，再将版本号按数字段、字母段逐段比较，
+// 数字段大于字母段，"~" 表示预发布版本，小于任何后续内容
+func CompareVersions(a, b string) int {
+	ea, va := splitEpoch(a)
+	eb, vb := splitEpoch(b)
+	if ea != eb {
+		if ea < eb {
+			return -1
+		}
+		return 1
+	}
+	return compareSegments(va, vb)
+}
+
+func splitEpoch(v string) (int, string) {
+	if i := strings.Index(v, ":"); i > 0 {
+		if epoch, err := strconv.Atoi(v[:i]); err == nil {
+			return epoch, v[i+1:]
+		}
+	}
+	return 0, v
+}
+
+func isVersionChar(r byte) bool {
+	return r < unicode.MaxASCII && (unicode.IsDigit(rune(r)) || unicode.IsLetter(rune(r))) || r == '~'
+}
+
+func compareSegments(a, b string) int {
+	for {
+		// 跳过分隔符
+		for len(a) > 0 && !isVersionChar(a[0]) {
+			a = a[1:]
+		}
+		for len(b) > 0 && !isVersionChar(b[0]) {
+			b = b[1:]
+		}
+
+		// 预发布标记
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		numeric := unicode.IsDigit(rune(a[0]))
+		segA, restA := takeSegment(a, numeric)
+		segB, restB := takeSegment(b, numeric)
+		if segB == "" {
+			// 类型不同时数字段更大
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		if numeric {
+			segA = strings.TrimLeft(segA, "0")
+			segB = strings.TrimLeft(segB, "0")
+			if len(segA) != len(segB) {
+				if len(segA) < len(segB) {
+					return -1
+				}
+				return 1
+			}
+		}
+		if c := strings.Compare(segA, segB); c != 0 {
+			return c
+		}
+		a, b = restA, restB
+	}
+
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+func takeSegment(s string, numeric bool) (string, string) {
+	i := 0
+	for i < len(s) && s[i] < unicode.MaxASCII {
+		r := rune(s[i])
+		if numeric && !unicode.IsDigit(r) || !numeric && !unicode.IsLetter(r) {
+			break
+		}
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// MatchVersion 判断版本号是否满足约束，约束格式为运算符加版本号，如 "<3.0"、">=1.1.1k"，
+// 支持 < <= > >= = !=，省略运算符时表示等于
+func MatchVersion(version, constraint string) (bool, error) {
+	op, want, err := ParseVersionConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	c := CompareVersions(version, want)
+	switch op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	case "!=":
+		return c != 0, nil
+	}
+	return c == 0, nil
+}
+
+// ParseVersionConstraint 拆分版本约束中的运算符和版本号
+func ParseVersionConstraint(constraint string) (string, string, error) {
+	constraint = strings.TrimSpace(constraint)
+	for _, op := range []string{"<=", ">=", "!=", "==", "<", ">", "="} {
+		if strings.HasPrefix(constraint, op) {
+			version := strings.TrimSpace(constraint[len(op):])
+			if version == "" {
+				return "", "", fmt.Errorf("版本约束缺少版本号: %s", constraint)
+			}
+			if op == "==" {
+				op = "="
+			}
+			return op, version, nil
+		}
+	}
+	if constraint == "" {
+		return "", "", fmt.Errorf("版本约束不能为空")
+	}
+	return "=", constraint, nil
+}
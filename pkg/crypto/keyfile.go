@@ -0,0 +1,209 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package crypto
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options 主密钥配置
+type Options struct {
+	KeyFile       string   // 密钥文件，每行一个 "<密钥ID>:<base64密钥>"，# 开头为注释
+	Keys          []string // 直接配置的主密钥，格式同密钥文件，排在密钥文件之后
+	Primary       string   // 加密使用的密钥ID，未配置时使用第一个密钥
+	DisableLegacy bool     // 禁止解密历史版本硬编码密钥加密的数据，全部数据重新加密后开启
+}
+
+// Load 按配置加载主密钥环
+func Load(opts Options) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	var order []string
+	add := func(id string, key []byte) error {
+		if _, ok := keys[id]; ok {
+			return fmt.Errorf("主密钥 %s 重复配置", id)
+		}
+		keys[id] = key
+		order = append(order, id)
+		return nil
+	}
+
+	if opts.KeyFile != "" {
+		if err := readKeyFile(opts.KeyFile, add); err != nil {
+			return nil, err
+		}
+	}
+	for i, entry := range opts.Keys {
+		id, key, err := parseKey(entry)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个主密钥配置错误: %w", i+1, err)
+		}
+		if err := add(id, key); err != nil {
+			return nil, err
+		}
+	}
+
+	primary := opts.Primary
+	if primary == "" && len(order) > 0 {
+		primary = order[0]
+	}
+	if primary == "" {
+		return nil, ErrNoPrimaryKey
+	}
+	return NewKeyring(primary, keys, !opts.DisableLegacy)
+}
+
+// GenerateKey 生成随机主密钥，返回 base64 编码
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// NewKeyID 生成以日期命名的密钥ID
+func NewKeyID() string {
+	return "k" + time.Now().Format("20060102150405")
+}
+
+// EnsureKeyFile 密钥文件不存在时生成包含一个新主密钥的文件，返回是否新建
+func EnsureKeyFile(path string) (bool, error) {
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+
+	key, err := GenerateKey()
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return false, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	content := "# OpsHub 主密钥，第一行为加密使用的主用密钥，丢失后已加密的数据将无法恢复\n" +
+		NewKeyID() + ":" + key + "\n"
+	if _, err := f.WriteString(content); err != nil {
+		return false, err
+	}
+	return true, f.Sync()
+}
+
+// readKeyFile 读取密钥文件，按出现顺序回调每个密钥
+func readKeyFile(path string, add func(id string, key []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, key, err := parseKey(line)
+		if err == nil {
+			err = add(id, key)
+		}
+		if err != nil {
+			return fmt.Errorf("密钥文件第 %d 行: %w", n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	return nil
+}
+
+// parseKey 解析 "<密钥ID>:<base64密钥>"
+func parseKey(entry string) (string, []byte, error) {
+	id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+	if !ok {
+		return "", nil, errors.New("格式应为 <密钥ID>:<base64密钥>")
+	}
+	id = strings.TrimSpace(id)
+	if err := validateKeyID(id); err != nil {
+		return "", nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", nil, errors.New("密钥必须为 base64 编码")
+	}
+	if len(key) != KeySize {
+		return "", nil, fmt.Errorf("密钥长度必须为 %d 字节", KeySize)
+	}
+	return id, key, nil
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultKeyring *Keyring
+)
+
+// SetDefault 设置全局密钥环，服务启动时根据配置调用
+func SetDefault(k *Keyring) {
+	defaultMu.Lock()
+	defaultKeyring = k
+	defaultMu.Unlock()
+}
+
+// Default 返回全局密钥环，未设置时只能解密历史版本的数据
+func Default() *Keyring {
+	defaultMu.RLock()
+	k := defaultKeyring
+	defaultMu.RUnlock()
+	if k != nil {
+		return k
+	}
+	k, _ = NewKeyring("", nil, true)
+	return k
+}
+
+// Encrypt 使用全局密钥环加密
+func Encrypt(plaintext string) (string, error) {
+	return Default().Encrypt(plaintext)
+}
+
+// Decrypt 使用全局密钥环解密
+func Decrypt(ciphertext string) (string, error) {
+	return Default().Decrypt(ciphertext)
+}
+
+// Open 使用全局密钥环解密可能保存有历史明文的字段
+func Open(value string) (string, error) {
+	return Default().Open(value)
+}
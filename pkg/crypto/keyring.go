@@ -0,0 +1,292 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// Package crypto 提供敏感数据的信封加密。
+//
+// 每次加密生成随机的数据密钥加密明文，再用主密钥加密数据密钥，两者与主密钥ID一起编码为
+// "ohe1:<密钥ID>:<加密的数据密钥>:<密文>"。主密钥来自配置或密钥文件，可同时配置多个，
+// 加密使用主用密钥，解密按密文中的密钥ID选择，轮换主密钥后旧数据仍可解密，直到重新加密完成。
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// envelopePrefix 信封密文的格式标识
+const envelopePrefix = "ohe1:"
+
+// KeySize 主密钥及数据密钥长度（AES-256）
+const KeySize = 32
+
+var (
+	// ErrNoPrimaryKey 未配置加密使用的主密钥
+	ErrNoPrimaryKey = errors.New("未配置主密钥")
+	// ErrUnknownKey 密文使用的主密钥不在当前密钥环中
+	ErrUnknownKey = errors.New("密文使用的主密钥未配置")
+	// ErrInvalidCiphertext 密文格式错误或已损坏
+	ErrInvalidCiphertext = errors.New("密文格式错误或已损坏")
+)
+
+// legacyKeys 历史版本硬编码在代码中的密钥，只用于解密尚未重新加密的存量数据
+var legacyKeys = [][]byte{
+	[]byte("opshub-enc-key-32-bytes-long!!!!"), // 主机凭证、任务中心敏感变量
+	[]byte("opshub-k8s-encrypt-key-32bytes!!"), // kubeconfig
+}
+
+// Keyring 主密钥环
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+	legacy  []cipher.AEAD
+}
+
+// NewKeyring 创建主密钥环，primary 为加密使用的密钥ID，为空时密钥环只能解密。
+// legacy 为 true 时可以解密历史版本使用硬编码密钥加密的数据
+func NewKeyring(primary string, keys map[string][]byte, legacy bool) (*Keyring, error) {
+	k := &Keyring{primary: primary, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if err := validateKeyID(id); err != nil {
+			return nil, err
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("主密钥 %s 无效: %w", id, err)
+		}
+		k.keys[id] = aead
+	}
+	if primary != "" && k.keys[primary] == nil {
+		return nil, fmt.Errorf("主用密钥 %s 未配置", primary)
+	}
+	if legacy {
+		for _, key := range legacyKeys {
+			aead, err := newAEAD(key)
+			if err != nil {
+				return nil, err
+			}
+			k.legacy = append(k.legacy, aead)
+		}
+	}
+	return k, nil
+}
+
+// Primary 返回加密使用的主密钥ID
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// KeyIDs 返回已配置的主密钥ID
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Encrypt 使用主用密钥加密，空字符串原样返回
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	kek := k.keys[k.primary]
+	if kek == nil {
+		return "", ErrNoPrimaryKey
+	}
+
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	dek, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := seal(kek, dataKey, []byte(k.primary))
+	if err != nil {
+		return "", err
+	}
+	data, err := seal(dek, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return envelopePrefix + k.primary + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(data), nil
+}
+
+// Decrypt 解密 Encrypt 的结果，也可以解密历史版本的密文，空字符串原样返回
+func (k *Keyring) Decrypt(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	if !IsEnvelope(ciphertext) {
+		return k.decryptLegacy(ciphertext)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(ciphertext, envelopePrefix), ":")
+	if len(parts) != 3 {
+		return "", ErrInvalidCiphertext
+	}
+	kek := k.keys[parts[0]]
+	if kek == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, parts[0])
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	data, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	dataKey, err := open(kek, wrapped, []byte(parts[0]))
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	dek, err := newAEAD(dataKey)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	plaintext, err := open(dek, data, nil)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plaintext), nil
+}
+
+// Open 解密可能保存有历史明文的字段：信封密文及历史密文解密，其它内容视为明文原样返回
+func (k *Keyring) Open(value string) (string, error) {
+	if IsEnvelope(value) {
+		return k.Decrypt(value)
+	}
+	if plaintext, err := k.decryptLegacy(value); err == nil {
+		return plaintext, nil
+	}
+	return value, nil
+}
+
+// Rotate 将不是由主用密钥加密的值重新加密，返回新值及是否发生变化。
+// allowPlain 为 true 时无法解密的非信封值视为历史明文直接加密，否则返回错误
+func (k *Keyring) Rotate(value string, allowPlain bool) (string, bool, error) {
+	if k.primary == "" {
+		return "", false, ErrNoPrimaryKey
+	}
+	if value == "" || KeyID(value) == k.primary {
+		return value, false, nil
+	}
+
+	var plaintext string
+	var err error
+	if allowPlain {
+		plaintext, err = k.Open(value)
+	} else {
+		plaintext, err = k.Decrypt(value)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	rotated, err := k.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return rotated, true, nil
+}
+
+// decryptLegacy 解密历史版本的密文：base64 编码的 nonce+AES-GCM 密文
+func (k *Keyring) decryptLegacy(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	for _, aead := range k.legacy {
+		if plaintext, err := open(aead, data, nil); err == nil {
+			return string(plaintext), nil
+		}
+	}
+	return "", ErrInvalidCiphertext
+}
+
+// IsEnvelope 是否为信封密文
+func IsEnvelope(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+// KeyID 返回信封密文使用的主密钥ID，不是信封密文时返回空字符串
+func KeyID(value string) string {
+	if !IsEnvelope(value) {
+		return ""
+	}
+	id, _, ok := strings.Cut(strings.TrimPrefix(value, envelopePrefix), ":")
+	if !ok {
+		return ""
+	}
+	return id
+}
+
+// validateKeyID 密钥ID只允许小写字母、数字、- 和 _
+func validateKeyID(id string) error {
+	if id == "" || len(id) > 32 {
+		return fmt.Errorf("主密钥ID %q 长度必须为 1-32", id)
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("主密钥ID %q 只能包含小写字母、数字、- 和 _", id)
+		}
+	}
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("密钥长度必须为 %d 字节", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal 加密并返回 nonce+密文
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+// open 解密 seal 的结果
+func open(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], additional)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package crypto
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Secret 加密存储的字符串字段，写入数据库时使用全局密钥环加密，读取时解密，
+// 读取到历史明文时原样返回，可直接替换原有的明文 string 字段
+type Secret string
+
+// Value 实现 driver.Valuer 接口，用于数据库存储
+func (s Secret) Value() (driver.Value, error) {
+	return Encrypt(string(s))
+}
+
+// Scan 实现 sql.Scanner 接口，用于从数据库读取
+func (s *Secret) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return fmt.Errorf("不支持的加密字段类型 %T", value)
+	}
+	plaintext, err := Open(raw)
+	if err != nil {
+		return err
+	}
+	*s = Secret(plaintext)
+	return nil
+}
+
+// Column 保存密文的数据库列
+type Column struct {
+	Table  string // 表名
+	Column string // 列名
+	Prefix string // 密文前缀，设置后只处理带该前缀的值
+	Plain  bool   // 列中可能保存有历史明文，无法解密的值按明文加密
+}
+
+// rotateBatchSize 重新加密时每批读取的行数
+const rotateBatchSize = 200
+
+// RotateColumn 使用密钥环的主用密钥重新加密数据库列中的所有值（包括已软删除的行），
+// 返回重新加密的值的数量，dryRun 为 true 时只统计不写入
+func RotateColumn(ctx context.Context, db *gorm.DB, k *Keyring, col Column, dryRun bool) (int, error) {
+	var lastID uint
+	rotated := 0
+	for {
+		rows, err := db.WithContext(ctx).Table(col.Table).
+			Select("id, "+col.Column).
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(rotateBatchSize).
+			Rows()
+		if err != nil {
+			return rotated, fmt.Errorf("读取 %s.%s 失败: %w", col.Table, col.Column, err)
+		}
+
+		type item struct {
+			id    uint
+			value string
+		}
+		var batch []item
+		for rows.Next() {
+			var id uint
+			var value sql.NullString
+			if err := rows.Scan(&id, &value); err != nil {
+				rows.Close()
+				return rotated, err
+			}
+			batch = append(batch, item{id: id, value: value.String})
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return rotated, err
+		}
+		if len(batch) == 0 {
+			return rotated, nil
+		}
+
+		for _, it := range batch {
+			lastID = it.id
+			value := it.value
+			if col.Prefix != "" {
+				if !strings.HasPrefix(value, col.Prefix) {
+					continue
+				}
+				value = strings.TrimPrefix(value, col.Prefix)
+			}
+			next, changed, err := k.Rotate(value, col.Plain)
+			if err != nil {
+				return rotated, fmt.Errorf("重新加密 %s.%s (id=%d) 失败: %w", col.Table, col.Column, it.id, err)
+			}
+			if !changed {
+				continue
+			}
+			rotated++
+			if dryRun {
+				continue
+			}
+			// 条件更新，期间被修改过的行保留新值
+			if err := db.WithContext(ctx).Table(col.Table).
+				Where("id = ? AND "+col.Column+" = ?", it.id, it.value).
+				Update(col.Column, col.Prefix+next).Error; err != nil {
+				return rotated, fmt.Errorf("更新 %s.%s (id=%d) 失败: %w", col.Table, col.Column, it.id, err)
+			}
+		}
+	}
+}
+
+// RotateColumns 依次重新加密多个数据库列，返回重新加密的值的总数
+func RotateColumns(ctx context.Context, db *gorm.DB, k *Keyring, columns []Column, dryRun bool) (int, error) {
+	total := 0
+	for _, col := range columns {
+		n, err := RotateColumn(ctx, db, k, col, dryRun)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
@@ -21,6 +21,7 @@ package sshclient
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -34,48 +35,23 @@ import (
 // Client SSH客户端
 type Client struct {
 	client *ssh.Client
+	lease  *Lease // 从连接池借用时非空，Close 时归还而不关闭连接
 }
 
-// NewClient 创建SSH客户端
-func NewClient(host string, port int, username, password string, privateKey []byte, passphrase string) (*Client, error) {
-	var authMethods []ssh.AuthMethod
-
-	// 优先使用私钥认证
-	if len(privateKey) > 0 {
-		var signer ssh.Signer
-		var err error
-
-		if passphrase != "" {
-			signer, err = ssh.ParsePrivateKeyWithPassphrase(privateKey, []byte(passphrase))
-		} else {
-			signer, err = ssh.ParsePrivateKey(privateKey)
-		}
-
-		if err != nil {
-			return nil, fmt.Errorf("解析私钥失败: %w", err)
-		}
-
-		authMethods = append(authMethods, ssh.PublicKeys(signer))
-	}
-
-	// 密码认证
-	if password != "" {
-		authMethods = append(authMethods, ssh.Password(password))
-	}
-
-	if len(authMethods) == 0 {
-		return nil, fmt.Errorf("至少需要一种认证方式")
-	}
+// NewLeaseClient 使用连接池借出的连接创建客户端，Close 时归还连接
+func NewLeaseClient(lease *Lease) *Client {
+	return &Client{client: lease.Client(), lease: lease}
+}
 
-	config := &ssh.ClientConfig{
-		User:            username,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 生产环境应该验证主机密钥
-		Timeout:         10 * time.Second,
+// NewClient 创建SSH客户端，hostKey 用于校验服务端公钥
+// 指定 jumps 时依次经过跳板机连接，见 Dial
+func NewClient(host string, port int, username, password string, privateKey []byte, passphrase string, hostKey HostKeyConfig, jumps ...Endpoint) (*Client, error) {
+	target, err := NewEndpoint(host, port, username, password, privateKey, passphrase, hostKey)
+	if err != nil {
+		return nil, err
 	}
 
-	address := fmt.Sprintf("%s:%d", host, port)
-	client, err := ssh.Dial("tcp", address, config)
+	client, err := Dial(context.Background(), target, jumps...)
 	if err != nil {
 		return nil, fmt.Errorf("SSH连接失败: %w", err)
 	}
@@ -83,8 +59,12 @@ func NewClient(host string, port int, username, password string, privateKey []by
 	return &Client{client: client}, nil
 }
 
-// Close 关闭连接
+// Close 关闭连接，借用的连接归还到连接池
 func (c *Client) Close() error {
+	if c.lease != nil {
+		c.lease.Release()
+		return nil
+	}
 	if c.client != nil {
 		return c.client.Close()
 	}
@@ -0,0 +1,59 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sshclient
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrHostKeyUnverified 未配置主机密钥校验
+var ErrHostKeyUnverified = errors.New("未配置主机密钥校验，拒绝连接")
+
+// HostKeyConfig 主机密钥校验配置
+type HostKeyConfig struct {
+	// Callback 校验服务端公钥
+	Callback ssh.HostKeyCallback
+	// Algorithms 已记录密钥对应的算法，避免与服务端协商出其他类型的密钥而误判为密钥变更
+	Algorithms []string
+}
+
+// Apply 将主机密钥校验配置写入 ClientConfig
+func (c HostKeyConfig) Apply(config *ssh.ClientConfig) error {
+	if c.Callback == nil {
+		return ErrHostKeyUnverified
+	}
+	config.HostKeyCallback = c.Callback
+	config.HostKeyAlgorithms = c.Algorithms
+	return nil
+}
+
+// HostKeyAlgorithms 返回与公钥类型对应的主机密钥算法
+// RSA 密钥可通过 rsa-sha2-512、rsa-sha2-256 及 ssh-rsa 签名，其余类型算法名与密钥类型一致
+func HostKeyAlgorithms(keyType string) []string {
+	if keyType == "" {
+		return nil
+	}
+	if keyType == ssh.KeyAlgoRSA {
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	return []string{keyType}
+}
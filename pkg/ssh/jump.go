@@ -0,0 +1,208 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sshclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// MaxJumpHops 跳板链路的最大跳数
+const MaxJumpHops = 5
+
+// defaultDialTimeout 每一跳建立连接及完成握手的默认超时时间
+const defaultDialTimeout = 10 * time.Second
+
+// Endpoint SSH连接端点，可以是目标主机也可以是跳板机
+type Endpoint struct {
+	Name    string // 用于错误提示，为空时使用地址
+	Host    string
+	Port    int
+	User    string
+	Auth    []ssh.AuthMethod
+	HostKey HostKeyConfig
+	Timeout time.Duration // 建立连接及握手的超时时间，默认10秒
+}
+
+// NewEndpoint 根据密码或私钥创建连接端点
+func NewEndpoint(host string, port int, username, password string, privateKey []byte, passphrase string, hostKey HostKeyConfig) (Endpoint, error) {
+	auth, err := AuthMethods(password, privateKey, passphrase)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	return Endpoint{Host: host, Port: port, User: username, Auth: auth, HostKey: hostKey}, nil
+}
+
+// AuthMethods 生成认证方式，同时提供私钥和密码时优先使用私钥
+func AuthMethods(password string, privateKey []byte, passphrase string) ([]ssh.AuthMethod, error) {
+	var authMethods []ssh.AuthMethod
+	if len(privateKey) > 0 {
+		var signer ssh.Signer
+		var err error
+		if passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(privateKey, []byte(passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(privateKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析私钥失败: %w", err)
+		}
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+	if password != "" {
+		authMethods = append(authMethods, ssh.Password(password))
+	}
+	if len(authMethods) == 0 {
+		return nil, fmt.Errorf("至少需要一种认证方式")
+	}
+	return authMethods, nil
+}
+
+// Addr 返回 host:port 形式的地址，端口为空时使用22
+func (e Endpoint) Addr() string {
+	port := e.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(e.Host, strconv.Itoa(port))
+}
+
+func (e Endpoint) label() string {
+	if e.Name != "" {
+		return fmt.Sprintf("%s(%s)", e.Name, e.Addr())
+	}
+	return e.Addr()
+}
+
+// clientConfig 生成握手配置，未配置主机密钥校验时拒绝连接
+func (e Endpoint) clientConfig() (*ssh.ClientConfig, error) {
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+	config := &ssh.ClientConfig{
+		User:    e.User,
+		Auth:    e.Auth,
+		Timeout: timeout,
+	}
+	if err := e.HostKey.Apply(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Dial 依次经过跳板机连接目标主机（等同于 OpenSSH 的 ProxyJump）
+// 第一跳直接建立 TCP 连接，之后每一跳都通过上一跳的 direct-tcpip 通道转发，并各自校验主机密钥和认证；
+// 目标主机连接关闭后沿途的跳板连接随之关闭。ctx 取消时中断尚未完成的握手
+func Dial(ctx context.Context, target Endpoint, jumps ...Endpoint) (*ssh.Client, error) {
+	if len(jumps) > MaxJumpHops {
+		return nil, fmt.Errorf("跳板机不能超过 %d 跳", MaxJumpHops)
+	}
+	hops := make([]Endpoint, 0, len(jumps)+1)
+	hops = append(hops, jumps...)
+	hops = append(hops, target)
+
+	clients := make([]*ssh.Client, 0, len(hops))
+	closeAll := func() {
+		for i := len(clients) - 1; i >= 0; i-- {
+			clients[i].Close()
+		}
+	}
+	for i, hop := range hops {
+		var via *ssh.Client
+		if i > 0 {
+			via = clients[i-1]
+		}
+		client, err := dialHop(ctx, via, hop)
+		if err != nil {
+			closeAll()
+			if i < len(jumps) {
+				return nil, fmt.Errorf("连接跳板机 %s 失败: %w", hop.label(), err)
+			}
+			if len(jumps) > 0 {
+				return nil, fmt.Errorf("经跳板机连接 %s 失败: %w", hop.label(), err)
+			}
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	client := clients[len(clients)-1]
+	if len(clients) > 1 {
+		go func() {
+			client.Wait()
+			for i := len(clients) - 2; i >= 0; i-- {
+				clients[i].Close()
+			}
+		}()
+	}
+	return client, nil
+}
+
+// dialHop 建立一跳连接，via 为空时直接连接
+func dialHop(ctx context.Context, via *ssh.Client, hop Endpoint) (*ssh.Client, error) {
+	config, err := hop.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+	addr := hop.Addr()
+
+	dialCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	var conn net.Conn
+	if via == nil {
+		conn, err = (&net.Dialer{}).DialContext(dialCtx, "tcp", addr)
+	} else {
+		conn, err = via.DialContext(dialCtx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 转发通道不支持设置超时，握手期间由上下文控制：超时或取消时关闭连接以中断握手
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-dialCtx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	close(done)
+	<-stopped
+	if err != nil {
+		conn.Close()
+		if errors.Is(dialCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return nil, fmt.Errorf("SSH握手超时: %w", err)
+		}
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
@@ -0,0 +1,482 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sshclient
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// DefaultMaxSessionsPerHost 每台主机默认同时借出的连接数，需小于 sshd 的 MaxSessions（默认10）
+	DefaultMaxSessionsPerHost = 8
+	// DefaultIdleTimeout 空闲连接默认保留时间
+	DefaultIdleTimeout = 5 * time.Minute
+	// DefaultKeepAliveInterval 空闲连接默认保活检查间隔
+	DefaultKeepAliveInterval = 30 * time.Second
+	// DefaultKeepAliveTimeout 保活请求默认超时时间
+	DefaultKeepAliveTimeout = 10 * time.Second
+)
+
+// ErrPoolClosed 连接池已关闭
+var ErrPoolClosed = errors.New("SSH连接池已关闭")
+
+// DialFunc 建立到目标主机的连接，只在池中没有可用连接时调用
+type DialFunc func(ctx context.Context) (*ssh.Client, error)
+
+// PoolOptions 连接池配置，零值使用默认配置
+type PoolOptions struct {
+	MaxSessionsPerHost int           // 每台主机同时借出的连接数上限，超出时等待
+	IdleTimeout        time.Duration // 连接空闲超过该时间后关闭
+	KeepAliveInterval  time.Duration // 空闲连接的保活检查间隔，借出空闲超过该间隔的连接前也会先检查
+	KeepAliveTimeout   time.Duration // 保活请求超时时间
+}
+
+func (o *PoolOptions) normalize() {
+	if o.MaxSessionsPerHost <= 0 {
+		o.MaxSessionsPerHost = DefaultMaxSessionsPerHost
+	}
+	if o.IdleTimeout <= 0 {
+		o.IdleTimeout = DefaultIdleTimeout
+	}
+	if o.KeepAliveInterval <= 0 {
+		o.KeepAliveInterval = DefaultKeepAliveInterval
+	}
+	if o.KeepAliveTimeout <= 0 {
+		o.KeepAliveTimeout = DefaultKeepAliveTimeout
+	}
+}
+
+// Pool SSH连接池
+// 同一目标（由调用方给出的 key 区分）复用一条SSH连接，借出的各方在该连接上各自打开会话；
+// 每个目标同时借出的数量受 MaxSessionsPerHost 限制。后台定期向空闲连接发送保活请求，
+// 失效或空闲超时的连接被关闭，下次借用时重新建立
+type Pool struct {
+	opts PoolOptions
+
+	mu     sync.Mutex
+	hosts  map[string]*poolHost
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+
+	dials             atomic.Uint64
+	dialErrors        atomic.Uint64
+	reuses            atomic.Uint64
+	waits             atomic.Uint64
+	evictions         atomic.Uint64
+	keepAliveFailures atomic.Uint64 // 保活失败而关闭的连接数
+	discards          atomic.Uint64
+}
+
+// poolHost 一个目标的连接及并发名额
+type poolHost struct {
+	key string
+	sem chan struct{}
+
+	users   int // 正在借用或等待的调用方数量，由 Pool.mu 保护
+	waiting atomic.Int32
+
+	mu       sync.Mutex // 保护以下字段，同时保证同一目标同一时刻只建立一条连接
+	conn     *pooledConn
+	lastUsed time.Time
+	dials    uint64
+	reuses   uint64
+}
+
+// pooledConn 池中的一条连接
+type pooledConn struct {
+	client  *ssh.Client
+	dead    chan struct{} // 连接断开后关闭
+	leases  int           // 借出数量，由 poolHost.mu 保护
+	retired bool          // 已从池中移除，归还最后一个租约时关闭
+}
+
+func newPooledConn(client *ssh.Client) *pooledConn {
+	c := &pooledConn{client: client, dead: make(chan struct{})}
+	go func() {
+		client.Wait()
+		close(c.dead)
+	}()
+	return c
+}
+
+func (c *pooledConn) alive() bool {
+	select {
+	case <-c.dead:
+		return false
+	default:
+		return true
+	}
+}
+
+// NewPool 创建连接池并启动后台保活检查
+func NewPool(opts PoolOptions) *Pool {
+	opts.normalize()
+	p := &Pool{
+		opts:  opts,
+		hosts: make(map[string]*poolHost),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go p.maintain()
+	return p
+}
+
+var (
+	defaultPool     *Pool
+	defaultPoolOnce sync.Once
+)
+
+// DefaultPool 返回进程内共享的连接池
+func DefaultPool() *Pool {
+	defaultPoolOnce.Do(func() {
+		defaultPool = NewPool(PoolOptions{})
+	})
+	return defaultPool
+}
+
+// Lease 借出的连接，使用完毕后必须调用 Release 或 Discard
+type Lease struct {
+	pool *Pool
+	host *poolHost
+	conn *pooledConn
+	once sync.Once
+}
+
+// Client 返回底层SSH连接，调用方在其上打开会话或SFTP，但不应关闭连接
+func (l *Lease) Client() *ssh.Client {
+	return l.conn.client
+}
+
+// Release 归还连接
+func (l *Lease) Release() {
+	l.once.Do(func() { l.pool.release(l, false) })
+}
+
+// Discard 连接出现异常时归还并关闭连接，其它借用方的会话也会随之中断
+func (l *Lease) Discard() {
+	l.once.Do(func() { l.pool.release(l, true) })
+}
+
+// Acquire 借用到 key 对应目标的连接，池中没有可用连接时调用 dial 建立
+// 达到并发上限时等待其它借用方归还，ctx 取消时返回
+func (p *Pool) Acquire(ctx context.Context, key string, dial DialFunc) (*Lease, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	h := p.hosts[key]
+	if h == nil {
+		h = &poolHost{key: key, sem: make(chan struct{}, p.opts.MaxSessionsPerHost)}
+		p.hosts[key] = h
+	}
+	h.users++
+	p.mu.Unlock()
+
+	lease, err := p.acquire(ctx, h, dial)
+	if err != nil {
+		p.leave(h)
+		return nil, err
+	}
+	return lease, nil
+}
+
+func (p *Pool) acquire(ctx context.Context, h *poolHost, dial DialFunc) (*Lease, error) {
+	select {
+	case h.sem <- struct{}{}:
+	default:
+		p.waits.Add(1)
+		h.waiting.Add(1)
+		select {
+		case h.sem <- struct{}{}:
+			h.waiting.Add(-1)
+		case <-ctx.Done():
+			h.waiting.Add(-1)
+			return nil, ctx.Err()
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if c := h.conn; c != nil {
+		// 空闲较久的连接先确认仍然可用，避免借出已被对端或中间设备断开的连接
+		if c.alive() && c.leases == 0 && time.Since(h.lastUsed) > p.opts.KeepAliveInterval {
+			if err := keepAlive(c.client, p.opts.KeepAliveTimeout); err != nil {
+				p.keepAliveFailures.Add(1)
+				c.client.Close()
+			}
+		}
+		if c.alive() {
+			c.leases++
+			h.reuses++
+			p.reuses.Add(1)
+			return &Lease{pool: p, host: h, conn: c}, nil
+		}
+		h.retire(c)
+	}
+
+	client, err := dial(ctx)
+	h.dials++
+	p.dials.Add(1)
+	if err != nil {
+		p.dialErrors.Add(1)
+		<-h.sem
+		return nil, err
+	}
+	c := newPooledConn(client)
+	c.leases = 1
+	h.conn = c
+	return &Lease{pool: p, host: h, conn: c}, nil
+}
+
+func (p *Pool) release(l *Lease, discard bool) {
+	h, c := l.host, l.conn
+	h.mu.Lock()
+	c.leases--
+	h.lastUsed = time.Now()
+	if discard {
+		p.discards.Add(1)
+		if h.conn == c {
+			h.conn = nil
+		}
+		c.retired = true
+	}
+	if c.retired && c.leases == 0 {
+		c.client.Close()
+	}
+	h.mu.Unlock()
+	<-h.sem
+	p.leave(h)
+}
+
+// leave 借用方离开，目标没有连接且无人使用时从池中移除
+func (p *Pool) leave(h *poolHost) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h.users--
+	if h.users > 0 {
+		return
+	}
+	h.mu.Lock()
+	empty := h.conn == nil
+	h.mu.Unlock()
+	if empty && p.hosts[h.key] == h {
+		delete(p.hosts, h.key)
+	}
+}
+
+// retire 将连接移出池，仍有借用方时等归还后再关闭，调用方持有 h.mu
+func (h *poolHost) retire(c *pooledConn) {
+	if h.conn == c {
+		h.conn = nil
+	}
+	c.retired = true
+	if c.leases == 0 {
+		c.client.Close()
+	}
+}
+
+// Evict 关闭 key 满足 match 的连接，例如主机信息或凭证变更后；正在使用的连接在归还后关闭
+func (p *Pool) Evict(match func(key string) bool) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for key, h := range p.hosts {
+		if !match(key) {
+			continue
+		}
+		h.mu.Lock()
+		if h.conn != nil {
+			h.retire(h.conn)
+			n++
+		}
+		h.mu.Unlock()
+		if h.users == 0 {
+			delete(p.hosts, key)
+		}
+	}
+	p.evictions.Add(uint64(n))
+	return n
+}
+
+// Close 停止后台检查并关闭所有连接，之后的借用返回 ErrPoolClosed
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stop)
+	p.mu.Unlock()
+	<-p.done
+	p.Evict(func(string) bool { return true })
+}
+
+// maintain 定期检查空闲连接：空闲超时的关闭，其余发送保活请求，失败的关闭
+func (p *Pool) maintain() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.opts.KeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.check()
+		}
+	}
+}
+
+func (p *Pool) check() {
+	p.mu.Lock()
+	hosts := make([]*poolHost, 0, len(p.hosts))
+	for _, h := range p.hosts {
+		hosts = append(hosts, h)
+	}
+	p.mu.Unlock()
+
+	for _, h := range hosts {
+		h.mu.Lock()
+		c := h.conn
+		if c == nil || c.leases > 0 {
+			h.mu.Unlock()
+			continue
+		}
+		switch {
+		case !c.alive():
+			h.retire(c)
+		case time.Since(h.lastUsed) > p.opts.IdleTimeout:
+			h.retire(c)
+			p.evictions.Add(1)
+		default:
+			// 保活请求可能阻塞，期间不持有锁；借出的连接若随后被判定失效，由借用方的会话报错
+			h.mu.Unlock()
+			err := keepAlive(c.client, p.opts.KeepAliveTimeout)
+			h.mu.Lock()
+			if err != nil && h.conn == c {
+				p.keepAliveFailures.Add(1)
+				h.retire(c)
+			}
+		}
+		h.mu.Unlock()
+	}
+
+	// 移除没有连接且无人使用的目标
+	p.mu.Lock()
+	for key, h := range p.hosts {
+		h.mu.Lock()
+		if h.users == 0 && h.conn == nil {
+			delete(p.hosts, key)
+		}
+		h.mu.Unlock()
+	}
+	p.mu.Unlock()
+}
+
+// keepAlive 发送 OpenSSH 保活请求，服务端回复（包括拒绝）即认为连接可用
+func keepAlive(client *ssh.Client, timeout time.Duration) error {
+	errc := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		errc <- err
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(timeout):
+		return errors.New("SSH保活请求超时")
+	}
+}
+
+// PoolStats 连接池统计
+type PoolStats struct {
+	Targets           int         `json:"targets"`           // 池中的目标数
+	Conns             int         `json:"conns"`             // 打开的连接数
+	Leases            int         `json:"leases"`            // 借出中的连接数
+	Waiting           int         `json:"waiting"`           // 等待并发名额的借用方数量
+	Dials             uint64      `json:"dials"`             // 累计建立连接次数
+	DialErrors        uint64      `json:"dialErrors"`        // 累计建立连接失败次数
+	Reuses            uint64      `json:"reuses"`            // 累计复用连接次数
+	Waits             uint64      `json:"waits"`             // 累计因并发上限等待的次数
+	Evictions         uint64      `json:"evictions"`         // 累计因空闲超时或主动清理关闭的连接数
+	KeepAliveFailures uint64      `json:"keepAliveFailures"` // 累计因保活失败关闭的连接数
+	Discards          uint64      `json:"discards"`          // 累计因借用方报告异常关闭的连接数
+	Hosts             []HostStats `json:"hosts"`
+}
+
+// HostStats 单个目标的连接统计
+type HostStats struct {
+	Key       string `json:"key"`
+	Connected bool   `json:"connected"`
+	Leases    int    `json:"leases"`
+	Waiting   int    `json:"waiting"`
+	IdleSec   int64  `json:"idleSeconds"` // 无借用时的空闲时长
+	Dials     uint64 `json:"dials"`
+	Reuses    uint64 `json:"reuses"`
+}
+
+// Stats 返回连接池当前状态及累计计数
+func (p *Pool) Stats() PoolStats {
+	stats := PoolStats{
+		Dials:             p.dials.Load(),
+		DialErrors:        p.dialErrors.Load(),
+		Reuses:            p.reuses.Load(),
+		Waits:             p.waits.Load(),
+		Evictions:         p.evictions.Load(),
+		KeepAliveFailures: p.keepAliveFailures.Load(),
+		Discards:          p.discards.Load(),
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, h := range p.hosts {
+		h.mu.Lock()
+		hs := HostStats{
+			Key:     key,
+			Waiting: int(h.waiting.Load()),
+			Dials:   h.dials,
+			Reuses:  h.reuses,
+		}
+		if c := h.conn; c != nil && c.alive() {
+			hs.Connected = true
+			hs.Leases = c.leases
+			if c.leases == 0 {
+				hs.IdleSec = int64(time.Since(h.lastUsed).Seconds())
+			}
+			stats.Conns++
+		}
+		h.mu.Unlock()
+		stats.Leases += hs.Leases
+		stats.Waiting += hs.Waiting
+		stats.Hosts = append(stats.Hosts, hs)
+	}
+	stats.Targets = len(stats.Hosts)
+	sort.Slice(stats.Hosts, func(i, j int) bool { return stats.Hosts[i].Key < stats.Hosts[j].Key })
+	return stats
+}
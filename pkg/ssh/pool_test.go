@@ -0,0 +1,241 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+package sshclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testServer 本地监听的最小SSH服务端，拒绝所有会话，记录建立连接的次数
+type testServer struct {
+	addr  string
+	dials atomic.Int32
+}
+
+func newTestServer(t *testing.T) *testServer {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for ch := range chans {
+					ch.Reject(ssh.Prohibited, "no sessions")
+				}
+			}()
+		}
+	}()
+	return &testServer{addr: listener.Addr().String()}
+}
+
+func (s *testServer) dial(ctx context.Context) (*ssh.Client, error) {
+	s.dials.Add(1)
+	return ssh.Dial("tcp", s.addr, &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+}
+
+func TestPoolReuse(t *testing.T) {
+	p := NewPool(PoolOptions{})
+	defer p.Close()
+	d := newTestServer(t)
+	ctx := context.Background()
+
+	first, err := p.Acquire(ctx, "a", d.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := p.Acquire(ctx, "a", d.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Client() != second.Client() {
+		t.Error("同一目标应复用同一条连接")
+	}
+	first.Release()
+	second.Release()
+	second.Release() // 重复归还无副作用
+
+	other, err := p.Acquire(ctx, "b", d.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Release()
+
+	stats := p.Stats()
+	if d.dials.Load() != 2 || stats.Dials != 2 || stats.Reuses != 1 {
+		t.Errorf("dials = %d, stats = %+v", d.dials.Load(), stats)
+	}
+	if stats.Conns != 2 || stats.Leases != 0 {
+		t.Errorf("conns = %d, leases = %d, want 2, 0", stats.Conns, stats.Leases)
+	}
+}
+
+func TestPoolConcurrencyLimit(t *testing.T) {
+	p := NewPool(PoolOptions{MaxSessionsPerHost: 2})
+	defer p.Close()
+	d := newTestServer(t)
+	ctx := context.Background()
+
+	var leases []*Lease
+	for i := 0; i < 2; i++ {
+		l, err := p.Acquire(ctx, "a", d.dial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leases = append(leases, l)
+	}
+
+	t.Run("达到上限时等待直到超时", func(t *testing.T) {
+		timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if _, err := p.Acquire(timeout, "a", d.dial); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("err = %v, want deadline exceeded", err)
+		}
+	})
+
+	t.Run("归还后等待方获得连接", func(t *testing.T) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		var got *Lease
+		var err error
+		go func() {
+			defer wg.Done()
+			got, err = p.Acquire(ctx, "a", d.dial)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		leases[0].Release()
+		wg.Wait()
+		if err != nil || got.Client() != leases[1].Client() {
+			t.Fatalf("err = %v, want reuse of the pooled connection", err)
+		}
+		got.Release()
+	})
+	leases[1].Release()
+
+	if stats := p.Stats(); stats.Waits != 2 || d.dials.Load() != 1 {
+		t.Errorf("waits = %d, dials = %d, want 2, 1", stats.Waits, d.dials.Load())
+	}
+}
+
+func TestPoolDiscardAndEvict(t *testing.T) {
+	p := NewPool(PoolOptions{})
+	defer p.Close()
+	d := newTestServer(t)
+	ctx := context.Background()
+
+	t.Run("丢弃后重新建立连接", func(t *testing.T) {
+		l, err := p.Acquire(ctx, "a", d.dial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		old := l.Client()
+		l.Discard()
+		l, err = p.Acquire(ctx, "a", d.dial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Release()
+		if l.Client() == old {
+			t.Error("丢弃的连接不应再被借出")
+		}
+	})
+
+	t.Run("清理时正在使用的连接归还后关闭", func(t *testing.T) {
+		l, err := p.Acquire(ctx, "b", d.dial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := p.Evict(func(key string) bool { return key == "b" }); n != 1 {
+			t.Errorf("Evict() = %d, want 1", n)
+		}
+		if _, _, err := l.Client().SendRequest("keepalive@openssh.com", true, nil); err != nil {
+			t.Errorf("连接在归还前不应关闭: %v", err)
+		}
+		l.Release()
+		if _, _, err := l.Client().SendRequest("keepalive@openssh.com", true, nil); err == nil {
+			t.Error("归还后连接应已关闭")
+		}
+	})
+
+	t.Run("建立连接失败", func(t *testing.T) {
+		fail := func(context.Context) (*ssh.Client, error) { return nil, errors.New("refused") }
+		if _, err := p.Acquire(ctx, "c", fail); err == nil {
+			t.Error("Acquire() should fail")
+		}
+		if stats := p.Stats(); stats.DialErrors != 1 {
+			t.Errorf("dialErrors = %d, want 1", stats.DialErrors)
+		}
+	})
+
+	p.Close()
+	if _, err := p.Acquire(ctx, "a", d.dial); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("err = %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestPoolIdleEviction(t *testing.T) {
+	p := NewPool(PoolOptions{IdleTimeout: time.Millisecond, KeepAliveInterval: time.Hour})
+	defer p.Close()
+	d := newTestServer(t)
+
+	l, err := p.Acquire(context.Background(), "a", d.dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Release()
+	time.Sleep(5 * time.Millisecond)
+	p.check()
+
+	stats := p.Stats()
+	if stats.Targets != 0 || stats.Evictions != 1 {
+		t.Errorf("stats = %+v, want idle connection evicted", stats)
+	}
+}
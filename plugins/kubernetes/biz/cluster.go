@@ -21,9 +21,6 @@ package biz
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
 	"errors"
 	"fmt"
 
@@ -32,6 +29,8 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/data/models"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/data/repository"
 )
@@ -75,8 +74,8 @@ type UpdateClusterRequest struct {
 
 // CreateCluster 创建集群
 func (b *ClusterBiz) CreateCluster(ctx context.Context, req *CreateClusterRequest) (*models.Cluster, error) {
-	// 检查集群名称是否已存在
-	existCluster, err := b.repo.GetByName(req.Name)
+	// 检查集群名称是否已存在，集群名称全局唯一，不按项目过滤
+	existCluster, err := b.repo.WithContext(rbacbiz.WithoutProjectScope(ctx)).GetByName(req.Name)
 	if err == nil && existCluster != nil {
 		return nil, errors.New("集群名称已存在")
 	}
@@ -114,7 +113,7 @@ func (b *ClusterBiz) CreateCluster(ctx context.Context, req *CreateClusterReques
 	}
 
 	// 保存到数据库
-	if err := b.repo.Create(cluster); err != nil {
+	if err := b.repo.WithContext(ctx).Create(cluster); err != nil {
 		return nil, fmt.Errorf("保存集群失败: %w", err)
 	}
 
@@ -123,14 +122,14 @@ func (b *ClusterBiz) CreateCluster(ctx context.Context, req *CreateClusterReques
 
 // UpdateCluster 更新集群
 func (b *ClusterBiz) UpdateCluster(ctx context.Context, id uint, req *UpdateClusterRequest) (*models.Cluster, error) {
-	cluster, err := b.repo.GetByID(id)
+	cluster, err := b.repo.WithContext(ctx).GetByID(id)
 	if err != nil {
 		return nil, errors.New("集群不存在")
 	}
 
 	// 如果要更新名称，检查新名称是否已被其他集群使用
 	if req.Name != "" && req.Name != cluster.Name {
-		existCluster, err := b.repo.GetByName(req.Name)
+		existCluster, err := b.repo.WithContext(rbacbiz.WithoutProjectScope(ctx)).GetByName(req.Name)
 		if err == nil && existCluster != nil && existCluster.ID != id {
 			return nil, errors.New("集群名称已存在")
 		}
@@ -171,7 +170,7 @@ func (b *ClusterBiz) UpdateCluster(ctx context.Context, id uint, req *UpdateClus
 	clientset, version, err := b.repo.TestConnection(cluster)
 	if err != nil {
 		// 连接失败，更新状态为失败
-		b.repo.UpdateStatus(id, models.ClusterStatusFailed)
+		b.repo.WithContext(ctx).UpdateStatus(id, models.ClusterStatusFailed)
 		return nil, fmt.Errorf("测试集群连接失败: %w", err)
 	}
 	_ = clientset
@@ -180,7 +179,7 @@ func (b *ClusterBiz) UpdateCluster(ctx context.Context, id uint, req *UpdateClus
 	cluster.Status = models.ClusterStatusNormal
 
 	// 更新数据库
-	if err := b.repo.Update(cluster); err != nil {
+	if err := b.repo.WithContext(ctx).Update(cluster); err != nil {
 		return nil, fmt.Errorf("更新集群失败: %w", err)
 	}
 
@@ -190,17 +189,17 @@ func (b *ClusterBiz) UpdateCluster(ctx context.Context, id uint, req *UpdateClus
 // DeleteCluster 删除集群
 func (b *ClusterBiz) DeleteCluster(ctx context.Context, id uint) error {
 	// 检查集群是否存在
-	_, err := b.repo.GetByID(id)
+	_, err := b.repo.WithContext(ctx).GetByID(id)
 	if err != nil {
 		return errors.New("集群不存在")
 	}
 
-	return b.repo.Delete(id)
+	return b.repo.WithContext(ctx).Delete(id)
 }
 
 // GetCluster 获取集群详情
 func (b *ClusterBiz) GetCluster(ctx context.Context, id uint) (*models.Cluster, error) {
-	cluster, err := b.repo.GetByID(id)
+	cluster, err := b.repo.WithContext(ctx).GetByID(id)
 	if err != nil {
 		return nil, errors.New("集群不存在")
 	}
@@ -212,12 +211,12 @@ func (b *ClusterBiz) GetCluster(ctx context.Context, id uint) (*models.Cluster,
 
 // ListClusters 获取集群列表
 func (b *ClusterBiz) ListClusters(ctx context.Context) ([]models.Cluster, error) {
-	return b.repo.List()
+	return b.repo.WithContext(ctx).List()
 }
 
 // TestClusterConnection 测试集群连接
 func (b *ClusterBiz) TestClusterConnection(ctx context.Context, id uint) (string, error) {
-	cluster, err := b.repo.GetByID(id)
+	cluster, err := b.repo.WithContext(ctx).GetByID(id)
 	if err != nil {
 		return "", errors.New("集群不存在")
 	}
@@ -225,20 +224,20 @@ func (b *ClusterBiz) TestClusterConnection(ctx context.Context, id uint) (string
 	_, version, err := b.repo.TestConnection(cluster)
 	if err != nil {
 		// 更新状态为失败
-		b.repo.UpdateStatus(id, models.ClusterStatusFailed)
+		b.repo.WithContext(ctx).UpdateStatus(id, models.ClusterStatusFailed)
 		return "", fmt.Errorf("连接失败: %w", err)
 	}
 
 	// 更新状态和版本
-	b.repo.UpdateStatus(id, models.ClusterStatusNormal)
-	b.repo.UpdateVersion(id, version)
+	b.repo.WithContext(ctx).UpdateStatus(id, models.ClusterStatusNormal)
+	b.repo.WithContext(ctx).UpdateVersion(id, version)
 
 	return version, nil
 }
 
 // GetClusterClientset 获取集群的 Kubernetes clientset
 func (b *ClusterBiz) GetClusterClientset(ctx context.Context, id uint) (*kubernetes.Clientset, error) {
-	cluster, err := b.repo.GetByID(id)
+	cluster, err := b.repo.WithContext(ctx).GetByID(id)
 	if err != nil {
 		return nil, errors.New("集群不存在")
 	}
@@ -251,60 +250,14 @@ func (b *ClusterBiz) GetClusterClientset(ctx context.Context, id uint) (*kuberne
 	return clientset, nil
 }
 
-// 加密密钥（实际生产环境应该从配置中心获取）
-const encryptionKey = "opshub-k8s-encrypt-key-32bytes!!"
-
 // encryptKubeConfig 加密 kubeconfig
 func encryptKubeConfig(plainText string) (string, error) {
-	key := []byte(encryptionKey)
-	plaintext := []byte(plainText)
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
-
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return crypto.Encrypt(plainText)
 }
 
 // DecryptKubeConfig 解密 kubeconfig（导出供其他包使用）
 func DecryptKubeConfig(cipherText string) (string, error) {
-	key := []byte(encryptionKey)
-	ciphertext, err := base64.StdEncoding.DecodeString(cipherText)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", errors.New("ciphertext too short")
-	}
-
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
+	return crypto.Decrypt(cipherText)
 }
 
 // GetRepo 获取 repository 实例
@@ -332,7 +285,7 @@ func CreateClientsetFromKubeConfig(kubeConfigContent string) (*kubernetes.Client
 
 // GetClusterRESTConfig 获取集群的 REST Config
 func (b *ClusterBiz) GetClusterRESTConfig(ctx context.Context, id uint) (*rest.Config, error) {
-	cluster, err := b.repo.GetByID(id)
+	cluster, err := b.repo.WithContext(ctx).GetByID(id)
 	if err != nil {
 		return nil, errors.New("集群不存在")
 	}
@@ -39,6 +39,7 @@ type Cluster struct {
 	NodeCount      int        `gorm:"default:0" json:"nodeCount"`                         // 节点数量（缓存）
 	PodCount       int        `gorm:"default:0" json:"podCount"`                          // Pod数量（缓存）
 	StatusSyncedAt *time.Time `gorm:"type:datetime" json:"statusSyncedAt"`               // 状态最后同步时间
+	ProjectID      uint       `gorm:"column:project_id;<-:create;default:1;index" json:"projectId"` // 所属项目ID
 }
 
 // TableName 指定表名
@@ -20,16 +20,14 @@
 package repository
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
-	"errors"
+	"context"
 
 	"gorm.io/gorm"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/rest"
 
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/data/models"
 )
 
@@ -43,6 +41,11 @@ func NewClusterRepository(db *gorm.DB) *ClusterRepository {
 	return &ClusterRepository{db: db}
 }
 
+// WithContext 返回使用指定上下文的仓储，请求上下文中的当前项目会用于过滤集群
+func (r *ClusterRepository) WithContext(ctx context.Context) *ClusterRepository {
+	return &ClusterRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create 创建集群
 func (r *ClusterRepository) Create(cluster *models.Cluster) error {
 	return r.db.Create(cluster).Error
@@ -173,37 +176,17 @@ func (r *ClusterRepository) GetClientset(cluster *models.Cluster) (*kubernetes.C
 	return clientset, config, nil
 }
 
-// 加密密钥（必须与 biz 包中的密钥相同）
-const encryptionKey = "opshub-k8s-encrypt-key-32bytes!!"
-
 // decryptKubeConfig 解密 kubeconfig（内部使用）
 func decryptKubeConfig(cipherText string) (string, error) {
-	key := []byte(encryptionKey)
-	ciphertext, err := base64.StdEncoding.DecodeString(cipherText)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", errors.New("ciphertext too short")
-	}
-
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
-	}
+	return crypto.Decrypt(cipherText)
+}
 
-	return string(plaintext), nil
+// RotateSecrets 使用密钥环的主用密钥重新加密集群 kubeconfig，返回重新加密的数量
+func RotateSecrets(ctx context.Context, db *gorm.DB, k *crypto.Keyring, dryRun bool) (int, error) {
+	// 早期导入的集群可能保存了未加密的 kubeconfig
+	return crypto.RotateColumn(ctx, db, k, crypto.Column{
+		Table:  models.Cluster{}.TableName(),
+		Column: "kube_config",
+		Plain:  true,
+	}, dryRun)
 }
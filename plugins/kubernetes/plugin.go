@@ -84,6 +84,16 @@ func (p *Plugin) Enable(db *gorm.DB) error {
 		}
 	}
 
+	// 升级前创建的集群表没有所属项目，存量集群归属默认项目
+	if !db.Migrator().HasColumn(&Cluster{}, "ProjectID") {
+		if err := db.Migrator().AddColumn(&Cluster{}, "ProjectID"); err != nil {
+			return err
+		}
+		if err := db.Migrator().CreateIndex(&Cluster{}, "ProjectID"); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -228,6 +238,7 @@ type Cluster struct {
 	Description string `gorm:"size:500"`
 	CreatedBy   uint
 	IsDeleted   bool `gorm:"default:false;index"`
+	ProjectID   uint `gorm:"default:1;index"`
 }
 
 // TableName 指定表名
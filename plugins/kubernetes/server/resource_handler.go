@@ -57,6 +57,7 @@ import (
 	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	"sigs.k8s.io/yaml"
 
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/data/models"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/model"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/service"
@@ -66,6 +67,7 @@ import (
 type ResourceHandler struct {
 	clusterService *service.ClusterService
 	db             *gorm.DB
+	recordings     assetbiz.RecordingIndexer // 终端录制全文索引，与主机终端共用，资产模块未初始化时为 nil
 }
 
 // NewResourceHandler 创建资源处理器
@@ -73,6 +75,7 @@ func NewResourceHandler(clusterService *service.ClusterService, db *gorm.DB) *Re
 	return &ResourceHandler{
 		clusterService: clusterService,
 		db:             db,
+		recordings:     assetbiz.LookupRecordingIndexer(),
 	}
 }
 
@@ -9147,7 +9150,29 @@ func (h *ResourceHandler) PodShellWebSocket(c *gin.Context) {
 			Status:        model.SessionStatusCompleted,
 		}
 
-		h.db.Create(&session)
+		if err := h.db.Create(&session).Error; err == nil {
+			// 建立录制全文索引，供终端审计检索
+			go h.indexRecording(&session)
+		}
+	}
+}
+
+// indexRecording 为 Pod 终端录制建立全文索引
+func (h *ResourceHandler) indexRecording(session *model.TerminalSession) {
+	if h.recordings == nil {
+		return
+	}
+	recording := &assetbiz.TerminalRecording{
+		Source:    assetbiz.RecordingSourceK8s,
+		SessionID: session.ID,
+		Target:    fmt.Sprintf("%s/%s/%s/%s", session.ClusterName, session.Namespace, session.PodName, session.ContainerName),
+		UserID:    session.UserID,
+		Username:  session.Username,
+		StartedAt: session.CreatedAt.Add(-time.Duration(session.Duration) * time.Second),
+		Duration:  session.Duration,
+	}
+	if err := h.recordings.Index(context.Background(), recording, session.RecordingPath); err != nil {
+		fmt.Printf("建立终端录制索引失败: sessionID=%d, err=%v\n", session.ID, err)
 	}
 }
 
@@ -12875,6 +12900,15 @@ func (h *ResourceHandler) DeleteTerminalSession(c *gin.Context) {
 		})
 		return
 	}
+	if h.recordings != nil {
+		if err := h.recordings.Delete(c.Request.Context(), assetbiz.RecordingSourceK8s, session.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "删除录制索引失败: " + err.Error(),
+			})
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    0,
@@ -23,6 +23,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	"github.com/ydcloud-dy/opshub/plugins/kubernetes/service"
 )
 
@@ -36,6 +37,12 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
 	arthasHandler := NewArthasHandler(clusterService, db)
 	inspectionHandler := NewInspectionHandler(clusterService, db)
 
+	// 集群角色的临时权限申请审批通过后创建带到期时间的角色绑定
+	rbacbiz.RegisterAccessGranter(rbacbiz.AccessResourceK8sCluster, service.NewClusterRoleAccessGranter(db))
+
+	// 集群按项目隔离
+	rbacbiz.RegisterProjectResource(rbacbiz.ProjectResource{Type: "k8s_cluster", Name: "K8s集群", Table: "k8s_clusters"})
+
 	clusters := router.Group("/kubernetes")
 	{
 		// 集群管理
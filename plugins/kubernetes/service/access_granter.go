@@ -0,0 +1,121 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/plugins/kubernetes/biz"
+	"github.com/ydcloud-dy/opshub/plugins/kubernetes/model"
+)
+
+// ClusterRoleAccessGranter K8s 集群角色的临时权限，授权为带到期时间的用户角色绑定
+type ClusterRoleAccessGranter struct {
+	db         *gorm.DB
+	clusterBiz *biz.ClusterBiz
+	clusters   *ClusterService
+	bindings   *RoleBindingService
+}
+
+// NewClusterRoleAccessGranter 创建集群角色临时授权
+func NewClusterRoleAccessGranter(db *gorm.DB) *ClusterRoleAccessGranter {
+	return &ClusterRoleAccessGranter{
+		db:         db,
+		clusterBiz: biz.NewClusterBiz(db),
+		clusters:   NewClusterService(db),
+		bindings:   NewRoleBindingService(db),
+	}
+}
+
+// Describe 校验集群，返回集群名称
+func (g *ClusterRoleAccessGranter) Describe(ctx context.Context, req *rbacbiz.SysAccessRequest) (string, error) {
+	cluster, err := g.clusterBiz.GetCluster(ctx, req.ClusterID)
+	if err != nil {
+		return "", err
+	}
+	return cluster.Name, nil
+}
+
+// Authorize 集群角色只能由平台管理员审批
+func (g *ClusterRoleAccessGranter) Authorize(ctx context.Context, req *rbacbiz.SysAccessRequest, approverID uint) error {
+	admin, err := g.clusters.isPlatformAdmin(ctx, approverID)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		return rbacbiz.ErrAccessRequestBeyondApprover
+	}
+	return nil
+}
+
+// Grant 在集群中创建角色绑定，并记录到期时间
+// 申请人已有相同的角色绑定时拒绝授权，避免到期时解除不是本次申请创建的绑定
+func (g *ClusterRoleAccessGranter) Grant(ctx context.Context, req *rbacbiz.SysAccessRequest) (uint, error) {
+	var count int64
+	if err := g.db.Model(&model.K8sUserRoleBinding{}).
+		Where("cluster_id = ? AND user_id = ? AND role_name = ? AND role_namespace = ?",
+			req.ClusterID, req.UserID, req.RoleName, req.RoleNamespace).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, rbacbiz.ErrAccessGrantExists
+	}
+
+	if err := g.bindings.BindUserRole(ctx, uint64(req.ClusterID), uint64(req.UserID),
+		req.RoleName, req.RoleNamespace, req.RoleType, uint64(req.ApproverID)); err != nil {
+		return 0, err
+	}
+
+	var binding model.K8sUserRoleBinding
+	if err := g.db.Where("cluster_id = ? AND user_id = ? AND role_name = ? AND role_namespace = ?",
+		req.ClusterID, req.UserID, req.RoleName, req.RoleNamespace).First(&binding).Error; err != nil {
+		return 0, fmt.Errorf("获取角色绑定失败: %w", err)
+	}
+	if err := g.db.Model(&binding).Update("expires_at", req.ExpiresAt).Error; err != nil {
+		_ = g.bindings.UnbindUserRole(ctx, binding.ClusterID, binding.UserID, binding.RoleName, binding.RoleNamespace)
+		return 0, fmt.Errorf("记录到期时间失败: %w", err)
+	}
+	return uint(binding.ID), nil
+}
+
+// Revoke 删除本次申请创建的角色绑定
+// 绑定已被手动解除，或已被改为长期有效时直接返回
+func (g *ClusterRoleAccessGranter) Revoke(ctx context.Context, req *rbacbiz.SysAccessRequest) error {
+	if req.GrantID == 0 {
+		return nil
+	}
+	var binding model.K8sUserRoleBinding
+	if err := g.db.First(&binding, req.GrantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if binding.ExpiresAt == nil {
+		return nil
+	}
+	return g.bindings.UnbindUserRole(ctx, binding.ClusterID, binding.UserID, binding.RoleName, binding.RoleNamespace)
+}
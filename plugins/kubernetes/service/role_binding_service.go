@@ -222,13 +222,14 @@ func (s *RoleBindingService) GetRoleBoundUsers(ctx context.Context, clusterID ui
 		Username  string `json:"username"`
 		RealName  string `json:"realName"`
 		BoundAt   string `json:"boundAt"`
+		ExpiresAt *string `json:"expiresAt"`
 	}
 
 	var results []Result
 
 	// 查询绑定关系及用户信息
 	err := s.db.Table("k8s_user_role_bindings as b").
-		Select("b.user_id as user_id, u.username, u.real_name, b.created_at as bound_at").
+		Select("b.user_id as user_id, u.username, u.real_name, b.created_at as bound_at, b.expires_at").
 		Joins("LEFT JOIN sys_user u ON u.id = b.user_id").
 		Where("b.cluster_id = ? AND b.role_name = ? AND b.role_namespace = ?", clusterID, roleName, roleNamespace).
 		Scan(&results).Error
@@ -245,6 +246,7 @@ func (s *RoleBindingService) GetRoleBoundUsers(ctx context.Context, clusterID ui
 			"username":  r.Username,
 			"realName":  r.RealName,
 			"boundAt":   r.BoundAt,
+			"expiresAt": r.ExpiresAt,
 		})
 	}
 
@@ -42,6 +42,8 @@ type DomainMonitor struct {
 	ResponseThreshold *int  `gorm:"type:int;default:1000" json:"responseThreshold"`              // 响应时间阈值(ms)
 	SSLExpiryDays   *int  `gorm:"type:int;default:30" json:"sslExpiryDays"`                    // SSL过期提前告警天数
 
+	ProjectID     uint      `gorm:"column:project_id;<-:create;default:1;index" json:"projectId"` // 所属项目ID
+
 	CreatedAt     time.Time `json:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt"`
 }
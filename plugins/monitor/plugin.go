@@ -21,13 +21,19 @@ package monitor
 
 import (
 	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"strings"
 	"time"
 
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
 	"github.com/ydcloud-dy/opshub/internal/plugin"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
 	"github.com/ydcloud-dy/opshub/plugins/monitor/model"
 	"github.com/ydcloud-dy/opshub/plugins/monitor/server"
+	"github.com/ydcloud-dy/opshub/plugins/monitor/service"
+	"go.uber.org/zap"
 )
 
 // Plugin 监控中心插件实现
@@ -92,6 +98,10 @@ func (p *Plugin) Enable(db *gorm.DB) error {
 	p.ctx, p.cancelCtx = context.WithCancel(context.Background())
 	go p.startMonitorScheduler()
 
+	// 主机密钥变更告警通过监控中心的告警通道发送
+	assetbiz.SetHostKeyAlerter(p.sendHostKeyAlert)
+	assetbiz.SetDriftAlerter(p.sendDriftAlert)
+
 	return nil
 }
 
@@ -101,9 +111,67 @@ func (p *Plugin) Disable(db *gorm.DB) error {
 	if p.cancelCtx != nil {
 		p.cancelCtx()
 	}
+	assetbiz.SetHostKeyAlerter(nil)
+	assetbiz.SetDriftAlerter(nil)
 	return nil
 }
 
+// sendHostKeyAlert 发送主机密钥变更告警
+func (p *Plugin) sendHostKeyAlert(alert assetbiz.HostKeyAlert) {
+	message := service.AlertMessage{
+		AlertType: "host_key_mismatch",
+		Domain:    fmt.Sprintf("%s(%s)", alert.HostName, alert.HostIP),
+		Status:    "abnormal",
+		Message: fmt.Sprintf("主机SSH密钥与已记录的不一致，已拒绝连接，可能存在中间人攻击。已记录 %s，当前 %s，请核实后在主机密钥管理中确认",
+			alert.Trusted, alert.Presented),
+		Timestamp: alert.DetectedAt.Format("2006-01-02 15:04:05"),
+	}
+	p.sendAssetAlert(message, alert.HostID)
+}
+
+// sendDriftAlert 发送主机配置漂移告警
+func (p *Plugin) sendDriftAlert(alert assetbiz.DriftAlert) {
+	// 告警消息只列出前几项差异，完整明细在漂移检查记录中查看
+	const maxListed = 5
+	items := make([]string, 0, maxListed)
+	for i, change := range alert.Changes {
+		if i == maxListed {
+			items = append(items, fmt.Sprintf("等共 %d 项", len(alert.Changes)))
+			break
+		}
+		items = append(items, fmt.Sprintf("%s %s %s", change.Category, change.Key, change.Type))
+	}
+
+	message := service.AlertMessage{
+		AlertType: "config_drift",
+		Domain:    fmt.Sprintf("%s(%s)", alert.HostName, alert.HostIP),
+		Status:    "abnormal",
+		Message: fmt.Sprintf("主机配置与基线「%s」不一致：%s，请在配置漂移中核实",
+			alert.BaselineName, strings.Join(items, "，")),
+		Timestamp: alert.DetectedAt.Format("2006-01-02 15:04:05"),
+	}
+	p.sendAssetAlert(message, alert.HostID)
+}
+
+// sendAssetAlert 通过告警通道发送资产告警并记录告警日志
+func (p *Plugin) sendAssetAlert(message service.AlertMessage, hostID uint) {
+	channelType, err := server.NewHandler(p.db).Notify(message)
+	status, errMsg := "success", ""
+	if err != nil {
+		logger.Error("发送告警失败", zap.String("alertType", message.AlertType), zap.Uint("hostId", hostID), zap.Error(err))
+		status, errMsg = "failed", err.Error()
+	}
+	p.db.Create(&model.AlertLog{
+		AlertType:   message.AlertType,
+		Domain:      message.Domain,
+		Status:      status,
+		Message:     message.Message,
+		ChannelType: channelType,
+		ErrorMsg:    errMsg,
+		SentAt:      time.Now(),
+	})
+}
+
 // startMonitorScheduler 启动监控调度器
 func (p *Plugin) startMonitorScheduler() {
 	ticker := time.NewTicker(1 * time.Minute)
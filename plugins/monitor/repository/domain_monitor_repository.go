@@ -20,6 +20,8 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/ydcloud-dy/opshub/plugins/monitor/model"
 	"gorm.io/gorm"
 )
@@ -34,6 +36,11 @@ func NewDomainMonitorRepository(db *gorm.DB) *DomainMonitorRepository {
 	return &DomainMonitorRepository{db: db}
 }
 
+// WithContext 返回使用指定上下文的仓储，请求上下文中的当前项目会用于过滤域名监控
+func (r *DomainMonitorRepository) WithContext(ctx context.Context) *DomainMonitorRepository {
+	return &DomainMonitorRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create 创建域名监控
 func (r *DomainMonitorRepository) Create(monitor *model.DomainMonitor) error {
 	return r.db.Create(monitor).Error
@@ -36,6 +36,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	"github.com/ydcloud-dy/opshub/plugins/monitor/model"
 	"github.com/ydcloud-dy/opshub/plugins/monitor/repository"
 	"github.com/ydcloud-dy/opshub/plugins/monitor/service"
@@ -66,7 +67,7 @@ func NewHandler(db *gorm.DB) *Handler {
 // @Success 200 {array} model.DomainMonitor
 // @Router /monitor/domains [get]
 func (h *Handler) ListDomains(c *gin.Context) {
-	monitors, err := h.repo.GetAll()
+	monitors, err := h.repo.WithContext(c.Request.Context()).GetAll()
 	if err != nil {
 		c.JSON(500, gin.H{
 			"code":    500,
@@ -101,7 +102,7 @@ func (h *Handler) GetDomain(c *gin.Context) {
 		return
 	}
 
-	monitor, err := h.repo.GetByID(id)
+	monitor, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
 	if err != nil {
 		c.JSON(404, gin.H{
 			"code":    404,
@@ -137,7 +138,7 @@ func (h *Handler) CreateDomain(c *gin.Context) {
 	}
 
 	// 检查域名是否已存在
-	existing, err := h.repo.GetByDomain(req.Domain)
+	existing, err := h.repo.WithContext(rbacbiz.WithoutProjectScope(c.Request.Context())).GetByDomain(req.Domain)
 	if err == nil && existing != nil {
 		c.JSON(400, gin.H{
 			"code":    400,
@@ -153,7 +154,7 @@ func (h *Handler) CreateDomain(c *gin.Context) {
 	req.LastCheck = &now
 
 	// 创建监控记录
-	if err := h.repo.Create(&req); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).Create(&req); err != nil {
 		c.JSON(500, gin.H{
 			"code":    500,
 			"message": "创建域名监控失败",
@@ -184,7 +185,7 @@ func (h *Handler) CreateDomain(c *gin.Context) {
 		req.NextCheck = &nextCheck
 
 		// 保存更新后的状态
-		h.repo.Update(&req)
+		h.repo.WithContext(c.Request.Context()).Update(&req)
 	}
 
 	c.JSON(200, gin.H{
@@ -214,7 +215,7 @@ func (h *Handler) UpdateDomain(c *gin.Context) {
 	}
 
 	// 获取现有记录
-	monitor, err := h.repo.GetByID(id)
+	monitor, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
 	if err != nil {
 		c.JSON(404, gin.H{
 			"code":    404,
@@ -242,7 +243,7 @@ func (h *Handler) UpdateDomain(c *gin.Context) {
 
 	// 如果域名改变，检查新域名是否已存在
 	if req.Domain != monitor.Domain {
-		existing, err := h.repo.GetByDomain(req.Domain)
+		existing, err := h.repo.WithContext(rbacbiz.WithoutProjectScope(c.Request.Context())).GetByDomain(req.Domain)
 		if err == nil && existing != nil && existing.ID != id {
 			c.JSON(400, gin.H{
 				"code":    400,
@@ -254,7 +255,7 @@ func (h *Handler) UpdateDomain(c *gin.Context) {
 	}
 
 	// 保存更新
-	if err := h.repo.Update(monitor); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).Update(monitor); err != nil {
 		c.JSON(500, gin.H{
 			"code":    500,
 			"message": "更新域名监控失败",
@@ -288,7 +289,7 @@ func (h *Handler) DeleteDomain(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.Delete(id); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).Delete(id); err != nil {
 		c.JSON(500, gin.H{
 			"code":    500,
 			"message": "删除域名监控失败",
@@ -322,7 +323,7 @@ func (h *Handler) CheckDomain(c *gin.Context) {
 	}
 
 	// 获取监控记录
-	monitor, err := h.repo.GetByID(id)
+	monitor, err := h.repo.WithContext(c.Request.Context()).GetByID(id)
 	if err != nil {
 		c.JSON(404, gin.H{
 			"code":    404,
@@ -357,7 +358,7 @@ func (h *Handler) CheckDomain(c *gin.Context) {
 	nextCheck := now.Add(time.Duration(monitor.CheckInterval) * time.Second)
 	monitor.NextCheck = &nextCheck
 
-	if err := h.repo.Update(monitor); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).Update(monitor); err != nil {
 		c.JSON(500, gin.H{
 			"code":    500,
 			"message": "更新域名监控失败",
@@ -384,7 +385,7 @@ func (h *Handler) CheckDomain(c *gin.Context) {
 // @Success 200 {object} map[string]int64
 // @Router /monitor/domains/stats [get]
 func (h *Handler) GetStats(c *gin.Context) {
-	stats, err := h.repo.GetStats()
+	stats, err := h.repo.WithContext(c.Request.Context()).GetStats()
 	if err != nil {
 		c.JSON(500, gin.H{
 			"code":    500,
@@ -592,28 +593,35 @@ func (h *Handler) checkAlertConditions(monitor *model.DomainMonitor, result *Che
 
 // sendAlert 发送告警
 func (h *Handler) sendAlert(monitor *model.DomainMonitor, alert service.AlertMessage) {
+	channelType, err := h.Notify(alert)
+	if err != nil {
+		h.logAlert(monitor.ID, alert, "failed", channelType, err.Error())
+		return
+	}
+	h.logAlert(monitor.ID, alert, "success", channelType, "")
+}
+
+// Notify 通过已启用的告警通道向告警接收人发送消息，返回首个通道类型
+// 除域名监控外，其他模块的告警（如主机密钥变更）也通过该方法发送
+func (h *Handler) Notify(alert service.AlertMessage) (string, error) {
 	// 1. 获取启用的告警通道
 	var channels []model.AlertChannel
 	if err := h.db.Where("enabled = ?", true).Find(&channels).Error; err != nil {
-		h.logAlert(monitor.ID, alert, "failed", "", fmt.Sprintf("获取告警通道失败: %v", err))
-		return
+		return "", fmt.Errorf("获取告警通道失败: %v", err)
 	}
 
 	// 2. 获取启用的告警接收人
 	var receivers []model.AlertReceiver
 	if err := h.db.Find(&receivers).Error; err != nil {
-		h.logAlert(monitor.ID, alert, "failed", "", fmt.Sprintf("获取告警接收人失败: %v", err))
-		return
+		return "", fmt.Errorf("获取告警接收人失败: %v", err)
 	}
 
 	// 如果没有配置通道或接收人，记录失败日志
 	if len(channels) == 0 {
-		h.logAlert(monitor.ID, alert, "failed", "", "未配置启用的告警通道")
-		return
+		return "", errors.New("未配置启用的告警通道")
 	}
 	if len(receivers) == 0 {
-		h.logAlert(monitor.ID, alert, "failed", "", "未配置告警接收人")
-		return
+		return "", errors.New("未配置告警接收人")
 	}
 
 	// 3. 构建告警通道配置
@@ -738,12 +746,7 @@ func (h *Handler) sendAlert(monitor *model.DomainMonitor, alert service.AlertMes
 		err = h.alertService.SendAlert(alert, channelConfig, emailReceivers)
 	}
 
-	// 7. 记录发送结果
-	if err != nil {
-		h.logAlert(monitor.ID, alert, "failed", channels[0].ChannelType, err.Error())
-	} else {
-		h.logAlert(monitor.ID, alert, "success", channels[0].ChannelType, "")
-	}
+	return channels[0].ChannelType, err
 }
 
 // logAlert 记录告警日志
@@ -21,6 +21,7 @@ package server
 
 import (
 	"github.com/gin-gonic/gin"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	"github.com/ydcloud-dy/opshub/plugins/monitor/model"
 	"gorm.io/gorm"
 )
@@ -30,6 +31,9 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
 	handler := NewHandler(db)
 	alertHandler := NewAlertHandler(db)
 
+	// 域名监控按项目隔离
+	rbacbiz.RegisterProjectResource(rbacbiz.ProjectResource{Type: "domain_monitor", Name: "域名监控", Table: "domain_monitors"})
+
 	// 监控插件路由组 - 使用 /monitor 前缀
 	monitorGroup := router.Group("/monitor")
 	{
@@ -534,6 +534,8 @@ func (m AlertMessage) getAlertTitle() string {
 		return "SSL证书已过期"
 	case "ssl_invalid":
 		return "SSL证书无效"
+	case "host_key_mismatch":
+		return "主机SSH密钥变更"
 	default:
 		return "域名监控告警"
 	}
@@ -49,6 +49,7 @@ type NginxSource struct {
 	Type        NginxSourceType `gorm:"type:varchar(20);not null" json:"type"`
 	Description string          `gorm:"type:varchar(500)" json:"description"`
 	Status      int             `gorm:"type:tinyint;default:1" json:"status"`
+	ProjectID   uint            `gorm:"column:project_id;<-:create;default:1;index" json:"projectId"` // 所属项目ID
 
 	// 主机类型配置
 	HostID    *uint  `gorm:"index" json:"hostId"`
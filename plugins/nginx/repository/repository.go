@@ -20,6 +20,7 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"net/url"
@@ -50,6 +51,12 @@ func NewNginxRepository(db *gorm.DB) *NginxRepository {
 	return &NginxRepository{db: db}
 }
 
+// WithContext 返回使用指定上下文的仓储，请求上下文中的当前项目会用于过滤数据源
+// 返回的仓储不共享维度缓存，只用于数据源的增删改查
+func (r *NginxRepository) WithContext(ctx context.Context) *NginxRepository {
+	return &NginxRepository{db: r.db.WithContext(ctx)}
+}
+
 // tableExists 检查表是否存在
 func (r *NginxRepository) tableExists(tableName string) bool {
 	// 先从缓存查找
@@ -79,6 +86,10 @@ func (r *NginxRepository) UpdateSource(source *model.NginxSource) error {
 
 // DeleteSource 删除数据源及其关联数据
 func (r *NginxRepository) DeleteSource(id uint) error {
+	// 先确认数据源可见，避免删除其他项目数据源的日志
+	if err := r.db.First(&model.NginxSource{}, id).Error; err != nil {
+		return err
+	}
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		// 删除关联的访问日志 (旧表)
 		if r.tableExists("nginx_access_logs") {
@@ -111,6 +122,13 @@ func (r *NginxRepository) DeleteSource(id uint) error {
 	})
 }
 
+// ListSourceIDs 获取可见数据源的ID，用于限定统计范围
+func (r *NginxRepository) ListSourceIDs() ([]uint, error) {
+	ids := []uint{}
+	err := r.db.Model(&model.NginxSource{}).Pluck("id", &ids).Error
+	return ids, err
+}
+
 // GetSourceByID 根据ID获取数据源
 func (r *NginxRepository) GetSourceByID(id uint) (*model.NginxSource, error) {
 	var source model.NginxSource
@@ -808,15 +826,24 @@ func (r *NginxRepository) DeleteOldHourlyStats(sourceID uint, beforeTime time.Ti
 
 // ============== 统计查询 ==============
 
-// GetTodayOverview 获取今日概况
-func (r *NginxRepository) GetTodayOverview() (*model.OverviewStats, error) {
+// GetTodayOverview 获取今日概况，sourceIDs 为 nil 时统计全部数据源
+func (r *NginxRepository) GetTodayOverview(sourceIDs []uint) (*model.OverviewStats, error) {
 	overview := &model.OverviewStats{
 		StatusDistribution: make(map[string]int64),
 	}
+	// bySource 限定统计的数据源
+	bySource := func(column string) func(*gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB {
+			if sourceIDs == nil {
+				return db
+			}
+			return db.Where(column+" IN ?", sourceIDs)
+		}
+	}
 
 	// 获取数据源统计
-	r.db.Model(&model.NginxSource{}).Count(&overview.TotalSources)
-	r.db.Model(&model.NginxSource{}).Where("status = ?", 1).Count(&overview.ActiveSources)
+	r.db.Model(&model.NginxSource{}).Scopes(bySource("id")).Count(&overview.TotalSources)
+	r.db.Model(&model.NginxSource{}).Scopes(bySource("id")).Where("status = ?", 1).Count(&overview.ActiveSources)
 
 	// 获取今日聚合数据 (优先使用新表)
 	// 使用本地时区的今天日期字符串，避免时区问题
@@ -826,7 +853,7 @@ func (r *NginxRepository) GetTodayOverview() (*model.OverviewStats, error) {
 
 	if r.tableExists("nginx_agg_daily") {
 		var aggDaily []model.NginxAggDaily
-		err := r.db.Where("DATE(date) = ?", todayStr).Find(&aggDaily).Error
+		err := r.db.Scopes(bySource("source_id")).Where("DATE(date) = ?", todayStr).Find(&aggDaily).Error
 		if err == nil && len(aggDaily) > 0 {
 			usedNewTable = true
 			foundData = true
@@ -855,7 +882,7 @@ func (r *NginxRepository) GetTodayOverview() (*model.OverviewStats, error) {
 	if !usedNewTable && r.tableExists("nginx_daily_stats") {
 		// 回退到旧表
 		var dailyStats []model.NginxDailyStats
-		r.db.Where("DATE(date) = ?", todayStr).Find(&dailyStats)
+		r.db.Scopes(bySource("source_id")).Where("DATE(date) = ?", todayStr).Find(&dailyStats)
 		if len(dailyStats) > 0 {
 			foundData = true
 			for _, stats := range dailyStats {
@@ -876,7 +903,7 @@ func (r *NginxRepository) GetTodayOverview() (*model.OverviewStats, error) {
 
 		if r.tableExists("nginx_daily_stats") {
 			var dailyStats []model.NginxDailyStats
-			r.db.Where("DATE(date) >= ?", sevenDaysAgo).Find(&dailyStats)
+			r.db.Scopes(bySource("source_id")).Where("DATE(date) >= ?", sevenDaysAgo).Find(&dailyStats)
 			for _, stats := range dailyStats {
 				overview.TodayRequests += stats.TotalRequests
 				overview.TodayVisitors += stats.UniqueVisitors
@@ -900,8 +927,8 @@ func (r *NginxRepository) GetTodayOverview() (*model.OverviewStats, error) {
 	return overview, nil
 }
 
-// GetRequestsTrend 获取请求趋势
-func (r *NginxRepository) GetRequestsTrend(sourceID *uint, hours int) ([]model.TrendPoint, error) {
+// GetRequestsTrend 获取请求趋势，sourceIDs 为 nil 时统计全部数据源
+func (r *NginxRepository) GetRequestsTrend(sourceIDs []uint, hours int) ([]model.TrendPoint, error) {
 	var trend []model.TrendPoint
 
 	endTime := time.Now().Local()
@@ -915,8 +942,8 @@ func (r *NginxRepository) GetRequestsTrend(sourceID *uint, hours int) ([]model.T
 			Group("hour").
 			Order("hour ASC")
 
-		if sourceID != nil {
-			query = query.Where("source_id = ?", *sourceID)
+		if sourceIDs != nil {
+			query = query.Where("source_id IN ?", sourceIDs)
 		}
 
 		type Result struct {
@@ -944,8 +971,8 @@ func (r *NginxRepository) GetRequestsTrend(sourceID *uint, hours int) ([]model.T
 			Group("hour").
 			Order("hour ASC")
 
-		if sourceID != nil {
-			query = query.Where("source_id = ?", *sourceID)
+		if sourceIDs != nil {
+			query = query.Where("source_id IN ?", sourceIDs)
 		}
 
 		type Result struct {
@@ -972,8 +999,8 @@ func (r *NginxRepository) GetRequestsTrend(sourceID *uint, hours int) ([]model.T
 			Order("hour ASC").
 			Limit(24) // 只取最近24个有数据的小时
 
-		if sourceID != nil {
-			query2 = query2.Where("source_id = ?", *sourceID)
+		if sourceIDs != nil {
+			query2 = query2.Where("source_id IN ?", sourceIDs)
 		}
 
 		var results2 []Result
@@ -1149,7 +1176,7 @@ func (r *NginxRepository) GetTopIPsWithGeo(sourceID uint, startTime, endTime tim
 }
 
 // GetGeoDistribution 获取地理分布统计
-func (r *NginxRepository) GetGeoDistribution(sourceID *uint, startTime, endTime time.Time, level string) ([]model.GeoStats, error) {
+func (r *NginxRepository) GetGeoDistribution(sourceIDs []uint, startTime, endTime time.Time, level string) ([]model.GeoStats, error) {
 	var stats []model.GeoStats
 
 	// 优先使用新表
@@ -1169,8 +1196,8 @@ func (r *NginxRepository) GetGeoDistribution(sourceID *uint, startTime, endTime
 			Joins("LEFT JOIN nginx_dim_ip i ON f.ip_id = i.id").
 			Where("f.timestamp >= ? AND f.timestamp <= ?", startTime, endTime)
 
-		if sourceID != nil {
-			query = query.Where("f.source_id = ?", *sourceID)
+		if sourceIDs != nil {
+			query = query.Where("f.source_id IN ?", sourceIDs)
 		}
 
 		query = query.Group(selectField).Order("count DESC")
@@ -1226,8 +1253,8 @@ func (r *NginxRepository) GetGeoDistribution(sourceID *uint, startTime, endTime
 			Where("timestamp >= ? AND timestamp <= ?", startTime, endTime).
 			Where("country != '' AND country IS NOT NULL")
 
-		if sourceID != nil {
-			query = query.Where("source_id = ?", *sourceID)
+		if sourceIDs != nil {
+			query = query.Where("source_id IN ?", sourceIDs)
 		}
 
 		query = query.Group(groupField).Order("count DESC").Limit(50)
@@ -1265,7 +1292,7 @@ func (r *NginxRepository) GetGeoDistribution(sourceID *uint, startTime, endTime
 }
 
 // GetBrowserDistribution 获取浏览器分布统计
-func (r *NginxRepository) GetBrowserDistribution(sourceID *uint, startTime, endTime time.Time) ([]model.BrowserStats, error) {
+func (r *NginxRepository) GetBrowserDistribution(sourceIDs []uint, startTime, endTime time.Time) ([]model.BrowserStats, error) {
 	var stats []model.BrowserStats
 
 	// 优先使用新表
@@ -1276,8 +1303,8 @@ func (r *NginxRepository) GetBrowserDistribution(sourceID *uint, startTime, endT
 			Where("f.timestamp >= ? AND f.timestamp <= ?", startTime, endTime).
 			Where("ua.is_bot = ?", false)
 
-		if sourceID != nil {
-			query = query.Where("f.source_id = ?", *sourceID)
+		if sourceIDs != nil {
+			query = query.Where("f.source_id IN ?", sourceIDs)
 		}
 
 		query = query.Group("ua.browser").Order("count DESC")
@@ -1315,8 +1342,8 @@ func (r *NginxRepository) GetBrowserDistribution(sourceID *uint, startTime, endT
 			Where("timestamp >= ? AND timestamp <= ?", startTime, endTime).
 			Where("browser != '' AND browser IS NOT NULL")
 
-		if sourceID != nil {
-			query = query.Where("source_id = ?", *sourceID)
+		if sourceIDs != nil {
+			query = query.Where("source_id IN ?", sourceIDs)
 		}
 
 		query = query.Group("browser").Order("count DESC").Limit(20)
@@ -1354,7 +1381,7 @@ func (r *NginxRepository) GetBrowserDistribution(sourceID *uint, startTime, endT
 }
 
 // GetDeviceDistribution 获取设备分布统计
-func (r *NginxRepository) GetDeviceDistribution(sourceID *uint, startTime, endTime time.Time) ([]model.DeviceStats, error) {
+func (r *NginxRepository) GetDeviceDistribution(sourceIDs []uint, startTime, endTime time.Time) ([]model.DeviceStats, error) {
 	var stats []model.DeviceStats
 
 	// 优先使用新表
@@ -1365,8 +1392,8 @@ func (r *NginxRepository) GetDeviceDistribution(sourceID *uint, startTime, endTi
 			Where("f.timestamp >= ? AND f.timestamp <= ?", startTime, endTime).
 			Where("ua.is_bot = ?", false)
 
-		if sourceID != nil {
-			query = query.Where("f.source_id = ?", *sourceID)
+		if sourceIDs != nil {
+			query = query.Where("f.source_id IN ?", sourceIDs)
 		}
 
 		query = query.Group("ua.device_type").Order("count DESC")
@@ -1404,8 +1431,8 @@ func (r *NginxRepository) GetDeviceDistribution(sourceID *uint, startTime, endTi
 			Where("timestamp >= ? AND timestamp <= ?", startTime, endTime).
 			Where("device_type != '' AND device_type IS NOT NULL")
 
-		if sourceID != nil {
-			query = query.Where("source_id = ?", *sourceID)
+		if sourceIDs != nil {
+			query = query.Where("source_id IN ?", sourceIDs)
 		}
 
 		query = query.Group("device_type").Order("count DESC")
@@ -1452,7 +1479,7 @@ func (r *NginxRepository) GetDeviceDistribution(sourceID *uint, startTime, endTi
 }
 
 // GetTimeSeries 获取时间序列数据
-func (r *NginxRepository) GetTimeSeries(sourceID *uint, startTime, endTime time.Time, interval string) ([]model.TimeSeriesPoint, error) {
+func (r *NginxRepository) GetTimeSeries(sourceIDs []uint, startTime, endTime time.Time, interval string) ([]model.TimeSeriesPoint, error) {
 	var points []model.TimeSeriesPoint
 
 	// 根据间隔选择聚合表
@@ -1471,8 +1498,8 @@ func (r *NginxRepository) GetTimeSeries(sourceID *uint, startTime, endTime time.
 				Group("hour").
 				Order("hour ASC")
 
-			if sourceID != nil {
-				query = query.Where("source_id = ?", *sourceID)
+			if sourceIDs != nil {
+				query = query.Where("source_id IN ?", sourceIDs)
 			}
 
 			type Result struct {
@@ -1518,8 +1545,8 @@ func (r *NginxRepository) GetTimeSeries(sourceID *uint, startTime, endTime time.
 				Group("hour").
 				Order("hour ASC")
 
-			if sourceID != nil {
-				query = query.Where("source_id = ?", *sourceID)
+			if sourceIDs != nil {
+				query = query.Where("source_id IN ?", sourceIDs)
 			}
 
 			type Result struct {
@@ -1565,8 +1592,8 @@ func (r *NginxRepository) GetTimeSeries(sourceID *uint, startTime, endTime time.
 				Group("date").
 				Order("date ASC")
 
-			if sourceID != nil {
-				query = query.Where("source_id = ?", *sourceID)
+			if sourceIDs != nil {
+				query = query.Where("source_id IN ?", sourceIDs)
 			}
 
 			type Result struct {
@@ -1612,8 +1639,8 @@ func (r *NginxRepository) GetTimeSeries(sourceID *uint, startTime, endTime time.
 				Group("date").
 				Order("date ASC")
 
-			if sourceID != nil {
-				query = query.Where("source_id = ?", *sourceID)
+			if sourceIDs != nil {
+				query = query.Where("source_id IN ?", sourceIDs)
 			}
 
 			type Result struct {
@@ -2476,18 +2503,16 @@ func (r *NginxRepository) GetTopEntryPages(sourceID uint, start, end time.Time,
 
 // GetOverviewGeo 获取概况页地域分布（复用现有逻辑，加sourceID和date过滤）
 func (r *NginxRepository) GetOverviewGeo(sourceID uint, start, end time.Time, scope string) ([]model.GeoStats, error) {
-	sid := sourceID
 	level := "province"
 	if scope == "global" {
 		level = "country"
 	}
-	return r.GetGeoDistribution(&sid, start, end, level)
+	return r.GetGeoDistribution([]uint{sourceID}, start, end, level)
 }
 
 // GetOverviewDevices 获取概况页终端设备分布
 func (r *NginxRepository) GetOverviewDevices(sourceID uint, start, end time.Time) ([]model.DeviceStats, error) {
-	sid := sourceID
-	return r.GetDeviceDistribution(&sid, start, end)
+	return r.GetDeviceDistribution([]uint{sourceID}, start, end)
 }
 
 // extractDomain 从URL中提取域名
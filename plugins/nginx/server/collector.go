@@ -21,9 +21,7 @@ package server
 
 import (
 	"bufio"
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -36,12 +34,8 @@ import (
 	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
 	"github.com/ydcloud-dy/opshub/pkg/response"
 	"github.com/ydcloud-dy/opshub/plugins/nginx/model"
-	"golang.org/x/crypto/ssh"
 )
 
-// 加密密钥（与凭证仓库相同）
-var encryptionKey = []byte("opshub-enc-key-32-bytes-long!!!!")
-
 // NginxLogEntry 解析后的日志条目
 type NginxLogEntry struct {
 	Timestamp     time.Time
@@ -78,7 +72,7 @@ func (h *Handler) CollectLogs(c *gin.Context) {
 	if sourceIDStr != "" {
 		// 采集指定数据源
 		id, _ := strconv.ParseUint(sourceIDStr, 10, 32)
-		source, err := h.repo.GetSourceByID(uint(id))
+		source, err := h.repo.WithContext(c.Request.Context()).GetSourceByID(uint(id))
 		if err != nil {
 			response.ErrorCode(c, http.StatusNotFound, "数据源不存在")
 			return
@@ -86,7 +80,7 @@ func (h *Handler) CollectLogs(c *gin.Context) {
 		sources = []model.NginxSource{*source}
 	} else {
 		// 采集所有活跃数据源
-		sources, err = h.repo.GetActiveSources()
+		sources, err = h.repo.WithContext(c.Request.Context()).GetActiveSources()
 		if err != nil {
 			response.ErrorCode(c, http.StatusInternalServerError, "获取数据源列表失败")
 			return
@@ -162,23 +156,13 @@ func (h *Handler) collectHostLogs(source *model.NginxSource) (int, error) {
 		return 0, fmt.Errorf("获取主机信息失败: %w", err)
 	}
 
-	// 获取凭证信息
-	var credential assetbiz.Credential
-	if err := h.db.First(&credential, host.CredentialID).Error; err != nil {
-		return 0, fmt.Errorf("获取凭证信息失败: %w", err)
-	}
-
-	// 解密凭证
-	if err := decryptCredential(&credential); err != nil {
-		return 0, fmt.Errorf("解密凭证失败: %w", err)
-	}
-
-	// 创建SSH连接
-	sshClient, err := createSSHClient(&host, &credential)
+	// 从连接池借用SSH连接
+	lease, err := h.connector.Acquire(context.Background(), &host)
 	if err != nil {
 		return 0, fmt.Errorf("SSH连接失败: %w", err)
 	}
-	defer sshClient.Close()
+	defer lease.Release()
+	sshClient := lease.Client()
 
 	// 读取日志文件
 	logPath := source.LogPath
@@ -853,87 +837,3 @@ func extractHost(uri, referer string) string {
 	}
 	return ""
 }
-
-// createSSHClient 创建SSH客户端
-func createSSHClient(host *assetbiz.Host, credential *assetbiz.Credential) (*ssh.Client, error) {
-	var authMethods []ssh.AuthMethod
-
-	switch credential.Type {
-	case "password":
-		authMethods = append(authMethods, ssh.Password(credential.Password))
-	case "key", "private_key":
-		signer, err := ssh.ParsePrivateKey([]byte(credential.PrivateKey))
-		if err != nil {
-			return nil, fmt.Errorf("解析私钥失败: %w", err)
-		}
-		authMethods = append(authMethods, ssh.PublicKeys(signer))
-	default:
-		return nil, fmt.Errorf("不支持的凭证类型: %s", credential.Type)
-	}
-
-	config := &ssh.ClientConfig{
-		User:            credential.Username,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         30 * time.Second,
-	}
-
-	addr := fmt.Sprintf("%s:%d", host.IP, host.Port)
-	return ssh.Dial("tcp", addr, config)
-}
-
-// decryptCredential 解密凭证
-func decryptCredential(credential *assetbiz.Credential) error {
-	if credential.Password != "" {
-		decrypted, err := decrypt(credential.Password)
-		if err != nil {
-			return fmt.Errorf("解密密码失败: %w", err)
-		}
-		credential.Password = decrypted
-	}
-
-	if credential.PrivateKey != "" {
-		decrypted, err := decrypt(credential.PrivateKey)
-		if err != nil {
-			return fmt.Errorf("解密私钥失败: %w", err)
-		}
-		credential.PrivateKey = decrypted
-	}
-
-	return nil
-}
-
-// decrypt AES-GCM 解密
-func decrypt(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
-
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, cipherData := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, cipherData, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
-}
@@ -21,6 +21,7 @@ package server
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -35,6 +36,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
 	"github.com/ydcloud-dy/opshub/pkg/response"
 	"github.com/ydcloud-dy/opshub/plugins/nginx/model"
 	"github.com/ydcloud-dy/opshub/plugins/nginx/repository"
@@ -88,20 +91,22 @@ func (c *overviewCache) set(key string, data interface{}, ttl time.Duration) {
 }
 
 type Handler struct {
-	db       *gorm.DB
-	repo     *repository.NginxRepository
-	geoSvc   *service.GeolocationService
-	uaParser *service.UAParser
-	cache    *overviewCache // 概况数据缓存
+	db        *gorm.DB
+	repo      *repository.NginxRepository
+	geoSvc    *service.GeolocationService
+	uaParser  *service.UAParser
+	cache     *overviewCache // 概况数据缓存
+	connector *assetbiz.SSHConnector
 }
 
 func NewHandler(db *gorm.DB) *Handler {
 	return &Handler{
-		db:       db,
-		repo:     repository.NewNginxRepository(db),
-		geoSvc:   service.NewGeolocationService(),
-		uaParser: service.NewUAParser(),
-		cache:    newOverviewCache(100), // 最多缓存100个数据源的概况
+		db:        db,
+		repo:      repository.NewNginxRepository(db),
+		geoSvc:    service.NewGeolocationService(),
+		uaParser:  service.NewUAParser(),
+		cache:     newOverviewCache(100), // 最多缓存100个数据源的概况
+		connector: assetdata.NewSSHConnector(db),
 	}
 }
 
@@ -132,7 +137,7 @@ func (h *Handler) ListSources(c *gin.Context) {
 		status = &s
 	}
 
-	sources, total, err := h.repo.ListSources(page, pageSize, sourceType, status)
+	sources, total, err := h.repo.WithContext(c.Request.Context()).ListSources(page, pageSize, sourceType, status)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "获取数据源列表失败")
 		return
@@ -159,7 +164,7 @@ func (h *Handler) ListSources(c *gin.Context) {
 // @Router /nginx/sources/{id} [get]
 func (h *Handler) GetSource(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
-	source, err := h.repo.GetSourceByID(uint(id))
+	source, err := h.repo.WithContext(c.Request.Context()).GetSourceByID(uint(id))
 	if err != nil {
 		response.ErrorCode(c, http.StatusNotFound, "数据源不存在")
 		return
@@ -185,7 +190,7 @@ func (h *Handler) CreateSource(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.CreateSource(&source); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).CreateSource(&source); err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "创建数据源失败: "+err.Error())
 		return
 	}
@@ -206,7 +211,7 @@ func (h *Handler) CreateSource(c *gin.Context) {
 // @Router /nginx/sources/{id} [put]
 func (h *Handler) UpdateSource(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
-	source, err := h.repo.GetSourceByID(uint(id))
+	source, err := h.repo.WithContext(c.Request.Context()).GetSourceByID(uint(id))
 	if err != nil {
 		response.ErrorCode(c, http.StatusNotFound, "数据源不存在")
 		return
@@ -217,7 +222,7 @@ func (h *Handler) UpdateSource(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.UpdateSource(source); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).UpdateSource(source); err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "更新数据源失败: "+err.Error())
 		return
 	}
@@ -236,13 +241,45 @@ func (h *Handler) UpdateSource(c *gin.Context) {
 // @Router /nginx/sources/{id} [delete]
 func (h *Handler) DeleteSource(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err := h.repo.DeleteSource(uint(id)); err != nil {
+	if err := h.repo.WithContext(c.Request.Context()).DeleteSource(uint(id)); err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "删除数据源失败")
 		return
 	}
 	response.Success(c, nil)
 }
 
+// requireSource 确认数据源在当前项目中可见，不可见时返回 404
+func (h *Handler) requireSource(c *gin.Context, sourceID uint) bool {
+	if _, err := h.repo.WithContext(c.Request.Context()).GetSourceByID(sourceID); err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "数据源不存在")
+		return false
+	}
+	return true
+}
+
+// scopedSourceIDs 解析可选的 sourceId 参数，返回统计查询限定的数据源
+// 指定数据源时校验其可见性；未指定时限定为当前项目可见的数据源，不限项目时返回 nil
+func (h *Handler) scopedSourceIDs(c *gin.Context) ([]uint, bool) {
+	if raw := c.Query("sourceId"); raw != "" {
+		id, _ := strconv.ParseUint(raw, 10, 32)
+		if !h.requireSource(c, uint(id)) {
+			return nil, false
+		}
+		return []uint{uint(id)}, true
+	}
+
+	scope := rbacbiz.ProjectScopeFromContext(c.Request.Context())
+	if scope == nil || scope.All {
+		return nil, true
+	}
+	ids, err := h.repo.WithContext(c.Request.Context()).ListSourceIDs()
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "获取数据源失败")
+		return nil, false
+	}
+	return ids, true
+}
+
 // ==================== 概况统计 ====================
 
 // GetOverview 获取概况统计
@@ -255,14 +292,19 @@ func (h *Handler) DeleteSource(c *gin.Context) {
 // @Success 200 {object} response.Response "获取成功"
 // @Router /nginx/overview [get]
 func (h *Handler) GetOverview(c *gin.Context) {
-	overview, err := h.repo.GetTodayOverview()
+	sourceIDs, ok := h.scopedSourceIDs(c)
+	if !ok {
+		return
+	}
+
+	overview, err := h.repo.GetTodayOverview(sourceIDs)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "获取概况失败")
 		return
 	}
 
 	// 获取请求趋势（最近24小时）
-	trend, err := h.repo.GetRequestsTrend(nil, 24)
+	trend, err := h.repo.GetRequestsTrend(sourceIDs, 24)
 	if err == nil {
 		overview.RequestsTrend = trend
 	}
@@ -283,16 +325,13 @@ func (h *Handler) GetOverview(c *gin.Context) {
 // @Router /nginx/overview/trend [get]
 func (h *Handler) GetRequestsTrend(c *gin.Context) {
 	hours, _ := strconv.Atoi(c.DefaultQuery("hours", "24"))
-	sourceIDStr := c.Query("sourceId")
 
-	var sourceID *uint
-	if sourceIDStr != "" {
-		id, _ := strconv.ParseUint(sourceIDStr, 10, 32)
-		sid := uint(id)
-		sourceID = &sid
+	sourceIDs, ok := h.scopedSourceIDs(c)
+	if !ok {
+		return
 	}
 
-	trend, err := h.repo.GetRequestsTrend(sourceID, hours)
+	trend, err := h.repo.GetRequestsTrend(sourceIDs, hours)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "获取趋势数据失败")
 		return
@@ -326,7 +365,7 @@ func (h *Handler) GetDailyReport(c *gin.Context) {
 	}
 
 	sourceID, _ := strconv.ParseUint(sourceIDStr, 10, 32)
-	_, err := h.repo.GetSourceByID(uint(sourceID))
+	_, err := h.repo.WithContext(c.Request.Context()).GetSourceByID(uint(sourceID))
 	if err != nil {
 		response.ErrorCode(c, http.StatusNotFound, "数据源不存在")
 		return
@@ -404,6 +443,9 @@ func (h *Handler) ListAccessLogs(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	// 解析时间参数
 	var startTime, endTime *time.Time
@@ -473,6 +515,9 @@ func (h *Handler) GetTopURIs(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	// 默认最近24小时
 	endTime := time.Now()
@@ -518,6 +563,9 @@ func (h *Handler) GetTopIPs(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	// 默认最近24小时
 	endTime := time.Now()
@@ -554,23 +602,13 @@ func (h *Handler) fetchNginxStatsFromHost(source *model.NginxSource, startDate,
 		return nil, fmt.Errorf("获取主机信息失败: %w", err)
 	}
 
-	// 获取凭证信息
-	var credential assetbiz.Credential
-	if err := h.db.First(&credential, host.CredentialID).Error; err != nil {
-		return nil, fmt.Errorf("获取凭证信息失败: %w", err)
-	}
-
-	// 解密凭证
-	if err := decryptCredential(&credential); err != nil {
-		return nil, fmt.Errorf("解密凭证失败: %w", err)
-	}
-
-	// 创建SSH连接
-	sshClient, err := createSSHClient(&host, &credential)
+	// 从连接池借用SSH连接
+	lease, err := h.connector.Acquire(context.Background(), &host)
 	if err != nil {
 		return nil, fmt.Errorf("SSH连接失败: %w", err)
 	}
-	defer sshClient.Close()
+	defer lease.Release()
+	sshClient := lease.Client()
 
 	// 获取日志路径
 	logPath := source.LogPath
@@ -905,14 +943,11 @@ type LogEntry struct {
 // @Success 200 {object} response.Response "获取成功"
 // @Router /nginx/stats/timeseries [get]
 func (h *Handler) GetTimeSeries(c *gin.Context) {
-	sourceIDStr := c.Query("sourceId")
 	interval := c.DefaultQuery("interval", "hour")
 
-	var sourceID *uint
-	if sourceIDStr != "" {
-		id, _ := strconv.ParseUint(sourceIDStr, 10, 32)
-		sid := uint(id)
-		sourceID = &sid
+	sourceIDs, ok := h.scopedSourceIDs(c)
+	if !ok {
+		return
 	}
 
 	// 默认时间范围
@@ -937,7 +972,7 @@ func (h *Handler) GetTimeSeries(c *gin.Context) {
 		}
 	}
 
-	results, err := h.repo.GetTimeSeries(sourceID, startTime, endTime, interval)
+	results, err := h.repo.GetTimeSeries(sourceIDs, startTime, endTime, interval)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "获取时间序列数据失败")
 		return
@@ -959,14 +994,11 @@ func (h *Handler) GetTimeSeries(c *gin.Context) {
 // @Success 200 {object} response.Response "获取成功"
 // @Router /nginx/stats/geo [get]
 func (h *Handler) GetGeoDistribution(c *gin.Context) {
-	sourceIDStr := c.Query("sourceId")
 	level := c.DefaultQuery("level", "country")
 
-	var sourceID *uint
-	if sourceIDStr != "" {
-		id, _ := strconv.ParseUint(sourceIDStr, 10, 32)
-		sid := uint(id)
-		sourceID = &sid
+	sourceIDs, ok := h.scopedSourceIDs(c)
+	if !ok {
+		return
 	}
 
 	// 默认最近7天
@@ -988,7 +1020,7 @@ func (h *Handler) GetGeoDistribution(c *gin.Context) {
 		}
 	}
 
-	results, err := h.repo.GetGeoDistribution(sourceID, startTime, endTime, level)
+	results, err := h.repo.GetGeoDistribution(sourceIDs, startTime, endTime, level)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "获取地理分布数据失败")
 		return
@@ -1009,13 +1041,10 @@ func (h *Handler) GetGeoDistribution(c *gin.Context) {
 // @Success 200 {object} response.Response "获取成功"
 // @Router /nginx/stats/browsers [get]
 func (h *Handler) GetBrowserDistribution(c *gin.Context) {
-	sourceIDStr := c.Query("sourceId")
 
-	var sourceID *uint
-	if sourceIDStr != "" {
-		id, _ := strconv.ParseUint(sourceIDStr, 10, 32)
-		sid := uint(id)
-		sourceID = &sid
+	sourceIDs, ok := h.scopedSourceIDs(c)
+	if !ok {
+		return
 	}
 
 	// 默认最近7天
@@ -1037,7 +1066,7 @@ func (h *Handler) GetBrowserDistribution(c *gin.Context) {
 		}
 	}
 
-	results, err := h.repo.GetBrowserDistribution(sourceID, startTime, endTime)
+	results, err := h.repo.GetBrowserDistribution(sourceIDs, startTime, endTime)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "获取浏览器分布数据失败")
 		return
@@ -1058,13 +1087,10 @@ func (h *Handler) GetBrowserDistribution(c *gin.Context) {
 // @Success 200 {object} response.Response "获取成功"
 // @Router /nginx/stats/devices [get]
 func (h *Handler) GetDeviceDistribution(c *gin.Context) {
-	sourceIDStr := c.Query("sourceId")
 
-	var sourceID *uint
-	if sourceIDStr != "" {
-		id, _ := strconv.ParseUint(sourceIDStr, 10, 32)
-		sid := uint(id)
-		sourceID = &sid
+	sourceIDs, ok := h.scopedSourceIDs(c)
+	if !ok {
+		return
 	}
 
 	// 默认最近7天
@@ -1086,7 +1112,7 @@ func (h *Handler) GetDeviceDistribution(c *gin.Context) {
 		}
 	}
 
-	results, err := h.repo.GetDeviceDistribution(sourceID, startTime, endTime)
+	results, err := h.repo.GetDeviceDistribution(sourceIDs, startTime, endTime)
 	if err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "获取设备分布数据失败")
 		return
@@ -1115,6 +1141,9 @@ func (h *Handler) GetTopIPsWithGeo(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	// 默认最近24小时
 	endTime := time.Now()
@@ -1160,6 +1189,9 @@ func (h *Handler) ListAccessLogsWithDimensions(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	// 解析时间参数
 	var startTime, endTime *time.Time
@@ -1220,7 +1252,10 @@ func (h *Handler) ListAccessLogsWithDimensions(c *gin.Context) {
 // @Success 200 {object} response.Response "回填成功"
 // @Router /nginx/backfill-geo [post]
 func (h *Handler) BackfillGeoData(c *gin.Context) {
-	sourceIDStr := c.Query("sourceId")
+	sourceIDs, ok := h.scopedSourceIDs(c)
+	if !ok {
+		return
+	}
 	batchSize, _ := strconv.Atoi(c.DefaultQuery("batchSize", "1000"))
 	if batchSize <= 0 || batchSize > 5000 {
 		batchSize = 1000
@@ -1240,9 +1275,8 @@ func (h *Handler) BackfillGeoData(c *gin.Context) {
 			Order("id ASC").
 			Limit(batchSize)
 
-		if sourceIDStr != "" {
-			sourceID, _ := strconv.ParseUint(sourceIDStr, 10, 32)
-			query = query.Where("source_id = ?", sourceID)
+		if sourceIDs != nil {
+			query = query.Where("source_id IN ?", sourceIDs)
 		}
 
 		if err := query.Find(&logs).Error; err != nil {
@@ -1333,6 +1367,9 @@ func (h *Handler) GetActiveVisitors(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	count, err := h.repo.GetActiveVisitors(uint(sourceID), 15)
 	if err != nil {
@@ -1358,6 +1395,9 @@ func (h *Handler) GetCoreMetrics(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	metrics, err := h.repo.GetCoreMetrics(uint(sourceID))
 	if err != nil {
@@ -1385,6 +1425,9 @@ func (h *Handler) GetOverviewTrend(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	mode := c.DefaultQuery("mode", "hour")
 	date := c.DefaultQuery("date", time.Now().Local().Format("2006-01-02"))
@@ -1413,6 +1456,9 @@ func (h *Handler) GetNewVsReturning(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	vc, err := h.repo.GetNewVsReturningVisitors(uint(sourceID))
 	if err != nil {
@@ -1440,6 +1486,9 @@ func (h *Handler) GetTopReferers(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	// 默认今日
 	now := time.Now().Local()
@@ -1472,6 +1521,9 @@ func (h *Handler) GetTopPages(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	now := time.Now().Local()
 	start, _ := time.ParseInLocation("2006-01-02", now.Format("2006-01-02"), time.Local)
@@ -1503,6 +1555,9 @@ func (h *Handler) GetTopEntryPages(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	now := time.Now().Local()
 	start, _ := time.ParseInLocation("2006-01-02", now.Format("2006-01-02"), time.Local)
@@ -1534,6 +1589,9 @@ func (h *Handler) GetOverviewGeo(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	now := time.Now().Local()
 	start, _ := time.ParseInLocation("2006-01-02", now.Format("2006-01-02"), time.Local)
@@ -1563,6 +1621,9 @@ func (h *Handler) GetOverviewDevices(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	now := time.Now().Local()
 	start, _ := time.ParseInLocation("2006-01-02", now.Format("2006-01-02"), time.Local)
@@ -1595,6 +1656,9 @@ func (h *Handler) GetOverviewAll(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请指定数据源ID")
 		return
 	}
+	if !h.requireSource(c, uint(sourceID)) {
+		return
+	}
 
 	trendMode := c.DefaultQuery("trendMode", "hour")
 	trendDate := c.DefaultQuery("trendDate", time.Now().Local().Format("2006-01-02"))
@@ -21,6 +21,7 @@ package server
 
 import (
 	"github.com/gin-gonic/gin"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	"github.com/ydcloud-dy/opshub/plugins/nginx/model"
 	"gorm.io/gorm"
 )
@@ -29,6 +30,9 @@ import (
 func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
 	handler := NewHandler(db)
 
+	// 数据源按项目隔离
+	rbacbiz.RegisterProjectResource(rbacbiz.ProjectResource{Type: "nginx_source", Name: "Nginx数据源", Table: "nginx_sources"})
+
 	// Nginx 统计插件路由组 - 使用 /nginx 前缀
 	nginxGroup := router.Group("/nginx")
 	{
@@ -3,6 +3,7 @@ package deployer
 import (
 	"context"
 
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/model"
 )
 
@@ -42,9 +43,10 @@ type Dependencies struct {
 	ClusterGetter ClusterGetter
 }
 
-// HostGetter 主机信息获取接口
+// HostGetter 主机连接获取接口
 type HostGetter interface {
-	GetHost(ctx context.Context, hostID uint) (*HostInfo, error)
+	// Connect 获取到主机的SSH客户端，客户端 Close 时归还连接
+	Connect(ctx context.Context, hostID uint) (*sshclient.Client, error)
 }
 
 // ClusterGetter K8s集群信息获取接口
@@ -52,17 +54,6 @@ type ClusterGetter interface {
 	GetClusterClient(ctx context.Context, clusterID uint) (K8sClient, error)
 }
 
-// HostInfo 主机信息
-type HostInfo struct {
-	ID         uint
-	Host       string
-	Port       int
-	Username   string
-	Password   string
-	PrivateKey []byte
-	Passphrase string
-}
-
 // K8sClient K8s客户端接口
 type K8sClient interface {
 	CreateOrUpdateSecret(ctx context.Context, namespace, name string, data map[string][]byte, labels map[string]string) error
@@ -8,7 +8,6 @@ import (
 	"strings"
 	"time"
 
-	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/model"
 )
 
@@ -35,21 +34,8 @@ func (d *NginxSSHDeployer) Deploy(ctx context.Context, cert *model.CertBundle, c
 		return fmt.Errorf("parse nginx config failed: %w", err)
 	}
 
-	// 获取主机信息
-	hostInfo, err := d.deps.HostGetter.GetHost(ctx, nginxConfig.HostID)
-	if err != nil {
-		return fmt.Errorf("get host info failed: %w", err)
-	}
-
 	// 创建SSH客户端
-	client, err := sshclient.NewClient(
-		hostInfo.Host,
-		hostInfo.Port,
-		hostInfo.Username,
-		hostInfo.Password,
-		hostInfo.PrivateKey,
-		hostInfo.Passphrase,
-	)
+	client, err := d.deps.HostGetter.Connect(ctx, nginxConfig.HostID)
 	if err != nil {
 		return fmt.Errorf("create ssh client failed: %w", err)
 	}
@@ -147,21 +133,8 @@ func (d *NginxSSHDeployer) Test(ctx context.Context, config *model.DeployConfig)
 		return fmt.Errorf("parse nginx config failed: %w", err)
 	}
 
-	// 获取主机信息
-	hostInfo, err := d.deps.HostGetter.GetHost(ctx, nginxConfig.HostID)
-	if err != nil {
-		return fmt.Errorf("get host info failed: %w", err)
-	}
-
 	// 创建SSH客户端并测试连接
-	client, err := sshclient.NewClient(
-		hostInfo.Host,
-		hostInfo.Port,
-		hostInfo.Username,
-		hostInfo.Password,
-		hostInfo.PrivateKey,
-		hostInfo.Passphrase,
-	)
+	client, err := d.deps.HostGetter.Connect(ctx, nginxConfig.HostID)
 	if err != nil {
 		return fmt.Errorf("create ssh client failed: %w", err)
 	}
@@ -3,6 +3,7 @@ package model
 import (
 	"time"
 
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	"gorm.io/gorm"
 )
 
@@ -12,6 +13,7 @@ type SSLCertificate struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ProjectID uint           `gorm:"column:project_id;<-:create;default:1;index" json:"project_id"` // 所属项目ID
 
 	Name       string `gorm:"type:varchar(100);not null" json:"name"`         // 证书名称
 	Domain     string `gorm:"type:varchar(255);not null;index" json:"domain"` // 主域名
@@ -28,9 +30,9 @@ type SSLCertificate struct {
 	CloudCertID    string `gorm:"type:varchar(100)" json:"cloud_cert_id"` // 云厂商证书ID
 
 	// 证书内容(加密存储)
-	Certificate string `gorm:"type:text" json:"-"` // 证书PEM
-	PrivateKey  string `gorm:"type:text" json:"-"` // 私钥PEM(加密)
-	CertChain   string `gorm:"type:text" json:"-"` // 证书链
+	Certificate string        `gorm:"type:text" json:"-"` // 证书PEM
+	PrivateKey  crypto.Secret `gorm:"type:text" json:"-"` // 私钥PEM(加密)
+	CertChain   string        `gorm:"type:text" json:"-"` // 证书链
 
 	// 证书信息
 	Issuer      string     `gorm:"type:varchar(255)" json:"issuer"`
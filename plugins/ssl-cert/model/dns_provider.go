@@ -3,6 +3,7 @@ package model
 import (
 	"time"
 
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	"gorm.io/gorm"
 )
 
@@ -13,12 +14,12 @@ type DNSProvider struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	Name     string `gorm:"type:varchar(100);not null" json:"name"`
-	Provider string `gorm:"type:varchar(50);not null" json:"provider"` // aliyun/cloudflare/huawei/aws_route53
-	Config   string `gorm:"type:text;not null" json:"-"`               // 配置JSON(加密) - 列表不返回
-	Email    string `gorm:"type:varchar(255)" json:"email"`            // 联系邮箱
-	Phone    string `gorm:"type:varchar(50)" json:"phone"`             // 联系电话
-	Enabled  bool   `gorm:"default:true" json:"enabled"`
+	Name     string        `gorm:"type:varchar(100);not null" json:"name"`
+	Provider string        `gorm:"type:varchar(50);not null" json:"provider"` // aliyun/cloudflare/huawei/aws_route53
+	Config   crypto.Secret `gorm:"type:text;not null" json:"-"`               // 配置JSON(加密) - 列表不返回
+	Email    string        `gorm:"type:varchar(255)" json:"email"`            // 联系邮箱
+	Phone    string        `gorm:"type:varchar(50)" json:"phone"`             // 联系电话
+	Enabled  bool          `gorm:"default:true" json:"enabled"`
 
 	LastTestAt *time.Time `json:"last_test_at"`
 	LastTestOK bool       `json:"last_test_ok"`
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	"github.com/ydcloud-dy/opshub/internal/plugin"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/deployer"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/model"
@@ -140,6 +141,9 @@ func (p *Plugin) RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
 	deploySvc := service.NewDeployService(db, deployerDeps)
 	taskSvc := service.NewTaskService(db)
 
+	// 证书按项目隔离
+	rbacbiz.RegisterProjectResource(rbacbiz.ProjectResource{Type: "ssl_certificate", Name: "SSL证书", Table: "ssl_certificates"})
+
 	// 注册路由
 	sslCertGroup := router.Group("/ssl-cert")
 	server.RegisterRoutes(sslCertGroup, certSvc, dnsSvc, deploySvc, taskSvc)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/model"
 	"gorm.io/gorm"
 )
@@ -144,7 +145,7 @@ func (r *CertificateRepository) UpdateStatus(ctx context.Context, id uint, statu
 func (r *CertificateRepository) UpdateCertContent(ctx context.Context, id uint, cert, key, chain string, notBefore, notAfter *time.Time, fingerprint string) error {
 	updates := map[string]interface{}{
 		"certificate":   cert,
-		"private_key":   key,
+		"private_key":   crypto.Secret(key),
 		"cert_chain":    chain,
 		"not_before":    notBefore,
 		"not_after":     notAfter,
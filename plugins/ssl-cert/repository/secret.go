@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
+	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/model"
+	"gorm.io/gorm"
+)
+
+// RotateSecrets 使用密钥环的主用密钥重新加密证书私钥及DNS服务商配置，返回重新加密的值的数量
+func RotateSecrets(ctx context.Context, db *gorm.DB, k *crypto.Keyring, dryRun bool) (int, error) {
+	// 早期版本明文保存，无法解密的值按明文加密
+	return crypto.RotateColumns(ctx, db, k, []crypto.Column{
+		{Table: model.SSLCertificate{}.TableName(), Column: "private_key", Plain: true},
+		{Table: model.DNSProvider{}.TableName(), Column: "config", Plain: true},
+	}, dryRun)
+}
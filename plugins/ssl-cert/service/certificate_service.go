@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	rbacdata "github.com/ydcloud-dy/opshub/internal/data/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	"github.com/ydcloud-dy/opshub/pkg/logger"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/deployer"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/model"
@@ -168,8 +170,8 @@ type CloudAccount struct {
 	ID        uint
 	Name      string
 	Provider  string
-	AccessKey string
-	SecretKey string
+	AccessKey crypto.Secret
+	SecretKey crypto.Secret
 }
 
 // createCloudCertificate 云厂商证书申请
@@ -190,7 +192,7 @@ func (s *CertificateService) createCloudCertificate(ctx context.Context, req *Cr
 	}
 
 	// 创建云证书Provider
-	cloudProvider, err := s.cloudFactory.Create(req.SourceType, cloudAccount.AccessKey, cloudAccount.SecretKey)
+	cloudProvider, err := s.cloudFactory.Create(req.SourceType, string(cloudAccount.AccessKey), string(cloudAccount.SecretKey))
 	if err != nil {
 		return nil, fmt.Errorf("create cloud provider failed: %w", err)
 	}
@@ -347,7 +349,7 @@ func (s *CertificateService) SyncCloudCertificate(ctx context.Context, id uint)
 	}
 
 	// 创建云证书Provider
-	cloudProvider, err := s.cloudFactory.Create(cert.SourceType, cloudAccount.AccessKey, cloudAccount.SecretKey)
+	cloudProvider, err := s.cloudFactory.Create(cert.SourceType, string(cloudAccount.AccessKey), string(cloudAccount.SecretKey))
 	if err != nil {
 		return fmt.Errorf("create cloud provider failed: %w", err)
 	}
@@ -436,7 +438,7 @@ func (s *CertificateService) ImportCertificate(ctx context.Context, req *ImportC
 		SANDomains:      acme.SANDomainsToJSON(req.SANDomains),
 		SourceType:      model.SourceTypeManual,
 		Certificate:     req.Certificate,
-		PrivateKey:      req.PrivateKey,
+		PrivateKey:      crypto.Secret(req.PrivateKey),
 		CertChain:       req.CertChain,
 		Issuer:          certInfo.Issuer,
 		NotBefore:       &certInfo.NotBefore,
@@ -575,7 +577,7 @@ func (s *CertificateService) GetCertificateContent(ctx context.Context, id uint)
 	}
 	return &model.CertBundle{
 		Certificate: cert.Certificate,
-		PrivateKey:  cert.PrivateKey,
+		PrivateKey:  string(cert.PrivateKey),
 		CertChain:   cert.CertChain,
 	}, nil
 }
@@ -595,7 +597,8 @@ type CloudAccountVO struct {
 // GetCloudAccounts 获取云账号列表(用于证书申请)
 func (s *CertificateService) GetCloudAccounts(ctx context.Context, provider string) ([]CloudAccountVO, error) {
 	var accounts []CloudAccountVO
-	query := s.db.Table("cloud_accounts").
+	query := s.db.WithContext(ctx).Table("cloud_accounts").
+		Scopes(rbacdata.ProjectScoped(ctx, "project_id")). // 只能选择当前项目的云账号
 		Select("id, name, provider").
 		Where("status = ?", 1).     // 只获取启用的账号
 		Where("deleted_at IS NULL") // 排除已删除的账号
@@ -796,7 +799,7 @@ func (s *CertificateService) executeAutoDeploy(ctx context.Context, certID uint)
 
 	bundle := &model.CertBundle{
 		Certificate: cert.Certificate,
-		PrivateKey:  cert.PrivateKey,
+		PrivateKey:  string(cert.PrivateKey),
 		CertChain:   cert.CertChain,
 	}
 
@@ -147,7 +147,7 @@ func (s *DeployService) ExecuteDeploy(ctx context.Context, configID uint) error
 
 	bundle := &model.CertBundle{
 		Certificate: cert.Certificate,
-		PrivateKey:  cert.PrivateKey,
+		PrivateKey:  string(cert.PrivateKey),
 		CertChain:   cert.CertChain,
 	}
 
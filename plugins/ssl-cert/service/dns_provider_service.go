@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/model"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/provider/dns"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/repository"
@@ -49,7 +50,7 @@ func (s *DNSProviderService) CreateDNSProvider(ctx context.Context, req *CreateD
 	provider := &model.DNSProvider{
 		Name:     req.Name,
 		Provider: req.Provider,
-		Config:   string(configJSON),
+		Config:   crypto.Secret(configJSON),
 		Email:    req.Email,
 		Phone:    req.Phone,
 		Enabled:  req.Enabled,
@@ -103,7 +104,7 @@ func (s *DNSProviderService) UpdateDNSProvider(ctx context.Context, id uint, upd
 			return fmt.Errorf("marshal config failed: %w", err)
 		}
 		if string(configJSON) != "{}" && string(configJSON) != "null" {
-			provider.Config = string(configJSON)
+			provider.Config = crypto.Secret(configJSON)
 		}
 	}
 
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
 	"github.com/ydcloud-dy/opshub/pkg/logger"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/deployer"
 	"github.com/ydcloud-dy/opshub/plugins/ssl-cert/model"
@@ -228,8 +229,8 @@ func (s *Scheduler) syncCloudCertificate(ctx context.Context, cert *model.SSLCer
 	var cloudAccount struct {
 		ID        uint
 		Provider  string
-		AccessKey string
-		SecretKey string
+		AccessKey crypto.Secret
+		SecretKey crypto.Secret
 	}
 	if err := s.db.Table("cloud_accounts").Where("id = ?", *cert.CloudAccountID).First(&cloudAccount).Error; err != nil {
 		logger.Error("获取云账号失败", zap.Uint("cert_id", cert.ID), zap.Error(err))
@@ -237,7 +238,7 @@ func (s *Scheduler) syncCloudCertificate(ctx context.Context, cert *model.SSLCer
 	}
 
 	// 创建云证书Provider
-	cloudProvider, err := s.cloudFactory.Create(cert.SourceType, cloudAccount.AccessKey, cloudAccount.SecretKey)
+	cloudProvider, err := s.cloudFactory.Create(cert.SourceType, string(cloudAccount.AccessKey), string(cloudAccount.SecretKey))
 	if err != nil {
 		logger.Error("创建云Provider失败", zap.Uint("cert_id", cert.ID), zap.Error(err))
 		return
@@ -432,7 +433,7 @@ func (s *Scheduler) executeAutoDeploy(ctx context.Context, certID uint) {
 
 	bundle := &model.CertBundle{
 		Certificate: cert.Certificate,
-		PrivateKey:  cert.PrivateKey,
+		PrivateKey:  string(cert.PrivateKey),
 		CertChain:   cert.CertChain,
 	}
 
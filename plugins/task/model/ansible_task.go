@@ -31,13 +31,14 @@ type AnsibleTask struct {
 	PlaybookPath    string     `json:"playbookPath,omitempty" gorm:"size:500"`
 	Inventory       string     `json:"inventory,omitempty" gorm:"type:text"` // JSON字符串
 	ExtraVars       string     `json:"extraVars,omitempty" gorm:"type:json"` // JSON
-	Tags            string     `json:"tags,omitempty" gorm:"size:500"` // 逗号分隔
+	Tags            string     `json:"tags,omitempty" gorm:"size:500"`       // 逗号分隔
 	Fork            int        `json:"fork" gorm:"default:5"`
-	Timeout         int        `json:"timeout" gorm:"default:600"` // 秒
-	Verbose         string     `json:"verbose" gorm:"size:20;default:v"` // v, vv, vvv
-	Status          string     `json:"status" gorm:"size:50;not null;default:pending;index"` // pending, running, success, failed, cancelled
+	Timeout         int        `json:"timeout" gorm:"default:600"`                           // 秒
+	Verbose         string     `json:"verbose" gorm:"size:20;default:v"`                     // v, vv, vvv
+	Status          string     `json:"status" gorm:"size:50;not null;default:pending;index"` // pending, pending_approval, running, success, failed, cancelled, rejected
 	LastRunTime     *time.Time `json:"lastRunTime,omitempty"`
-	LastRunResult   string     `json:"lastRunResult,omitempty" gorm:"type:json"` // JSON
+	LastRunResult   string     `json:"lastRunResult,omitempty" gorm:"type:json"` // JSON，AnsibleRunResult
+	LastJobID       *uint      `json:"lastJobId,omitempty"`                      // 最近一次执行对应的任务ID，可用于订阅实时输出
 	CreatedBy       uint       `json:"createdBy" gorm:"not null"`
 	CreatedAt       time.Time  `json:"createdAt"`
 	UpdatedAt       time.Time  `json:"updatedAt"`
@@ -47,3 +48,38 @@ type AnsibleTask struct {
 func (AnsibleTask) TableName() string {
 	return "ansible_tasks"
 }
+
+// AnsibleInventory Ansible任务的目标主机（AnsibleTask.Inventory）
+// 分组会包含其所有子孙分组下的主机，并在清单中生成同名的主机组
+type AnsibleInventory struct {
+	HostIDs  []uint `json:"hostIds"`
+	GroupIDs []uint `json:"groupIds"`
+}
+
+// AnsibleHostRecap PLAY RECAP 中单台主机的统计
+type AnsibleHostRecap struct {
+	HostID      uint   `json:"hostId"`
+	HostName    string `json:"hostName"`
+	HostIP      string `json:"hostIp"`
+	Alias       string `json:"alias"`  // 清单中的主机名
+	Status      string `json:"status"` // success, failed, unreachable
+	Ok          int    `json:"ok"`
+	Changed     int    `json:"changed"`
+	Unreachable int    `json:"unreachable"`
+	Failed      int    `json:"failed"`
+	Skipped     int    `json:"skipped"`
+	Rescued     int    `json:"rescued"`
+	Ignored     int    `json:"ignored"`
+}
+
+// AnsibleRunResult 一次 Playbook 执行的结果（AnsibleTask.LastRunResult）
+type AnsibleRunResult struct {
+	JobID     uint               `json:"jobId"`
+	Status    string             `json:"status"`
+	ExitCode  int                `json:"exitCode"`
+	Error     string             `json:"error,omitempty"`
+	Hosts     []AnsibleHostRecap `json:"hosts"`
+	Output    string             `json:"output,omitempty"` // 执行输出（仅保留末尾部分）
+	StartTime time.Time          `json:"startTime"`
+	EndTime   time.Time          `json:"endTime"`
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package model
+
+import (
+	"time"
+)
+
+// CommandPolicy 命令策略
+// 执行前将脚本解析为语法树，逐条命令按优先级匹配策略，命中的第一条策略决定该命令放行、拦截还是需要审批
+type CommandPolicy struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Name          string     `json:"name" gorm:"size:100;not null" binding:"required"`
+	Description   string     `json:"description" gorm:"type:text"`
+	Action        string     `json:"action" gorm:"size:20;not null" binding:"required"` // allow, deny, require_approval
+	MatchType     string     `json:"matchType" gorm:"size:20;not null;default:command"` // command, redirect, pipe_shell, dynamic, env, script
+	Command       string     `json:"command" gorm:"size:500"`                           // 命令名/变量名，支持 * ? 通配符，多个以逗号分隔，为空表示任意
+	Pattern       string     `json:"pattern" gorm:"size:500"`                           // 正则表达式：command 匹配参数，redirect 匹配目标路径，script 匹配脚本原文
+	RoleIDs       string     `json:"roleIds,omitempty" gorm:"type:text"`                // JSON数组，为空表示对所有角色生效
+	AssetGroupIDs string     `json:"assetGroupIds,omitempty" gorm:"type:text"`          // JSON数组，为空表示对所有分组生效，包含子分组
+	Priority      int        `json:"priority" gorm:"default:100;index"`                 // 数值越小越优先
+	Status        int        `json:"status" gorm:"default:1;index"`                     // 0-禁用, 1-启用
+	Builtin       bool       `json:"builtin" gorm:"default:false"`                      // 系统内置策略
+	CreatedBy     uint       `json:"createdBy"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	DeletedAt     *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+}
+
+func (CommandPolicy) TableName() string {
+	return "command_policies"
+}
+
+// 策略动作常量
+const (
+	PolicyActionAllow           = "allow"
+	PolicyActionDeny            = "deny"
+	PolicyActionRequireApproval = "require_approval"
+)
+
+// 策略匹配类型常量
+const (
+	PolicyMatchCommand   = "command"    // 命令名及参数
+	PolicyMatchRedirect  = "redirect"   // 输出重定向的目标路径
+	PolicyMatchPipeShell = "pipe_shell" // 管道输出交给 shell 解释器执行，如 curl ... | sh
+	PolicyMatchDynamic   = "dynamic"    // 无法静态确定的命令，如 $cmd、$(...)、eval "$x"
+	PolicyMatchEnv       = "env"        // 环境变量赋值
+	PolicyMatchScript    = "script"     // 脚本原文正则匹配
+)
@@ -0,0 +1,113 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package model
+
+import (
+	"time"
+)
+
+// JobApproval 任务审批单
+// 命中需审批的命令策略或目标主机属于需审批的分组时，任务以 pending_approval 状态创建并生成审批单，
+// 审批通过后按审批单中保存的执行参数自动执行
+type JobApproval struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	JobID           uint       `json:"jobId" gorm:"not null;uniqueIndex"`
+	Status          string     `json:"status" gorm:"size:20;not null;default:pending;index"` // pending, approved, rejected, cancelled
+	Reason          string     `json:"reason" gorm:"type:text"`                              // 需要审批的原因
+	PolicyMatches   string     `json:"policyMatches,omitempty" gorm:"type:text"`             // JSON，命中的需审批策略
+	RuleIDs         string     `json:"ruleIds,omitempty" gorm:"type:text"`                   // JSON数组，匹配的审批规则
+	ApproverRoleIDs string     `json:"approverRoleIds,omitempty" gorm:"type:text"`           // JSON数组，可审批的角色
+	ApproverDeptIDs string     `json:"approverDeptIds,omitempty" gorm:"type:text"`           // JSON数组，可审批的部门（含下级部门）
+	ScriptType      string     `json:"scriptType" gorm:"size:20"`
+	Content         string     `json:"-" gorm:"type:longtext"`       // 加密保存的待执行脚本，包含 secret 变量取值；Ansible 任务为执行快照
+	DisplayContent  string     `json:"content" gorm:"type:longtext"` // 脱敏后的脚本，供审批人查看
+	Secrets         string     `json:"-" gorm:"type:text"`           // 加密保存的JSON数组，执行时需要在输出中脱敏的值
+	Fork            int        `json:"fork"`
+	Timeout         int        `json:"timeout"`                            // 单台主机超时（秒）
+	BatchTimeout    int        `json:"batchTimeout"`                       // 整批任务超时（秒）
+	Rolling         string     `json:"rolling,omitempty" gorm:"type:text"` // JSON，滚动执行策略
+	RequestedBy     uint       `json:"requestedBy" gorm:"not null;index"`
+	RequestedByName string     `json:"requestedByName" gorm:"size:100"`
+	ApprovedBy      *uint      `json:"approvedBy,omitempty"` // 审批人ID（通过或拒绝）
+	ApprovedByName  string     `json:"approvedByName,omitempty" gorm:"size:100"`
+	Comment         string     `json:"comment,omitempty" gorm:"type:text"` // 审批意见
+	DecidedAt       *time.Time `json:"decidedAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+func (JobApproval) TableName() string {
+	return "job_approvals"
+}
+
+// JobApprovalRecord 审批流转记录
+type JobApprovalRecord struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ApprovalID uint      `json:"approvalId" gorm:"not null;index"`
+	JobID      uint      `json:"jobId" gorm:"not null;index"`
+	Action     string    `json:"action" gorm:"size:20;not null"` // submit, approve, reject, comment, cancel, execute
+	UserID     uint      `json:"userId"`
+	Username   string    `json:"username" gorm:"size:100"`
+	Comment    string    `json:"comment,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (JobApprovalRecord) TableName() string {
+	return "job_approval_records"
+}
+
+// ApprovalRule 审批规则
+// 指定分组的规则在目标主机属于这些分组（含子分组）时触发审批；未指定分组的规则不主动触发，
+// 只为命中需审批策略的任务提供审批人。未匹配到任何审批人时由管理员审批
+type ApprovalRule struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	Name            string     `json:"name" gorm:"size:100;not null" binding:"required"`
+	Description     string     `json:"description" gorm:"type:text"`
+	AssetGroupIDs   string     `json:"assetGroupIds,omitempty" gorm:"type:text"`   // JSON数组，需要审批的分组，如生产环境
+	ApproverRoleIDs string     `json:"approverRoleIds,omitempty" gorm:"type:text"` // JSON数组
+	ApproverDeptIDs string     `json:"approverDeptIds,omitempty" gorm:"type:text"` // JSON数组
+	Status          int        `json:"status" gorm:"default:1;index"`              // 0-禁用, 1-启用
+	CreatedBy       uint       `json:"createdBy"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+}
+
+func (ApprovalRule) TableName() string {
+	return "job_approval_rules"
+}
+
+// 审批单状态常量
+const (
+	ApprovalStatusPending   = "pending"   // 待审批
+	ApprovalStatusApproved  = "approved"  // 已通过
+	ApprovalStatusRejected  = "rejected"  // 已拒绝
+	ApprovalStatusCancelled = "cancelled" // 已撤销
+)
+
+// 审批流转动作常量
+const (
+	ApprovalActionSubmit  = "submit"  // 提交审批
+	ApprovalActionApprove = "approve" // 审批通过
+	ApprovalActionReject  = "reject"  // 审批拒绝
+	ApprovalActionComment = "comment" // 评论
+	ApprovalActionCancel  = "cancel"  // 撤销
+	ApprovalActionExecute = "execute" // 审批通过后自动执行
+)
@@ -0,0 +1,67 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package model
+
+import (
+	"time"
+)
+
+// JobSchedule 定时任务
+// 按 cron 表达式周期性地使用模板内容在目标主机上执行，每次触发都会生成一条 JobTask 记录
+type JobSchedule struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Name          string     `json:"name" gorm:"size:255;not null" binding:"required"`
+	Description   string     `json:"description" gorm:"type:text"`
+	CronExpr      string     `json:"cronExpr" gorm:"size:100;not null" binding:"required"` // 标准5段cron表达式，支持 @every/@daily 等描述符
+	Timezone      string     `json:"timezone" gorm:"size:64;default:Asia/Shanghai"`        // IANA时区，如 Asia/Shanghai、UTC
+	TemplateID    uint       `json:"templateId" gorm:"not null;index" binding:"required"`
+	HostIDs       string     `json:"hostIds,omitempty" gorm:"type:text"`        // JSON数组
+	GroupIDs      string     `json:"groupIds,omitempty" gorm:"type:text"`       // JSON数组，包含子分组下的主机
+	Variables     string     `json:"variables,omitempty" gorm:"type:text"`      // JSON对象，模板变量取值
+	ScriptType    string     `json:"scriptType" gorm:"size:20;default:Shell"`   // Shell, Python
+	OverlapPolicy string     `json:"overlapPolicy" gorm:"size:20;default:skip"` // skip, queue, parallel
+	Fork          int        `json:"fork" gorm:"default:10"`
+	Timeout       int        `json:"timeout" gorm:"default:300"`         // 单台主机超时（秒）
+	BatchTimeout  int        `json:"batchTimeout" gorm:"default:3600"`   // 整批任务超时（秒）
+	Rolling       string     `json:"rolling,omitempty" gorm:"type:text"` // JSON，滚动执行策略，为空时所有主机一次执行
+	Status        int        `json:"status" gorm:"default:1;index"`      // 0-禁用, 1-启用
+	LastRunTime   *time.Time `json:"lastRunTime,omitempty"`
+	LastJobID     *uint      `json:"lastJobId,omitempty"`
+	LastStatus    string     `json:"lastStatus,omitempty" gorm:"size:50"` // 最近一次触发结果：任务状态或 skipped
+	NextRunTime   *time.Time `json:"nextRunTime,omitempty"`
+	CreatedBy     uint       `json:"createdBy" gorm:"not null"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	DeletedAt     *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+}
+
+func (JobSchedule) TableName() string {
+	return "job_schedules"
+}
+
+// 重叠策略常量：上一次触发的任务仍在执行时如何处理本次触发
+const (
+	OverlapSkip     = "skip"     // 跳过本次触发
+	OverlapQueue    = "queue"    // 排队，上一次结束后立即补执行一次
+	OverlapParallel = "parallel" // 允许并行执行
+)
+
+// ScheduleStatusSkipped 触发因重叠策略被跳过
+const ScheduleStatusSkipped = "skipped"
@@ -25,22 +25,86 @@ import (
 
 // JobTask 任务作业
 type JobTask struct {
-	ID           uint       `json:"id" gorm:"primaryKey"`
-	Name         string     `json:"name" gorm:"size:255;not null" binding:"required"`
-	TemplateID   *uint      `json:"templateId,omitempty" gorm:"index"`
-	TaskType     string     `json:"taskType" gorm:"size:50;not null;index" binding:"required"` // manual, ansible, cron
-	Status       string     `json:"status" gorm:"size:50;not null;default:pending;index"` // pending, running, success, failed
-	TargetHosts  string     `json:"targetHosts,omitempty" gorm:"type:text"` // JSON字符串
-	Parameters   string     `json:"parameters,omitempty" gorm:"type:text"` // JSON
-	ExecuteTime  *time.Time `json:"executeTime,omitempty"`
-	Result       string     `json:"result,omitempty" gorm:"type:text"` // JSON
-	ErrorMessage string     `json:"errorMessage,omitempty" gorm:"type:text"`
-	CreatedBy    uint       `json:"createdBy" gorm:"not null"`
-	CreatedAt    time.Time  `json:"createdAt"`
-	UpdatedAt    time.Time  `json:"updatedAt"`
-	DeletedAt    *time.Time `json:"deletedAt,omitempty" gorm:"index"`
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	Name            string     `json:"name" gorm:"size:255;not null" binding:"required"`
+	TemplateID      *uint      `json:"templateId,omitempty" gorm:"index"`
+	ScheduleID      *uint      `json:"scheduleId,omitempty" gorm:"index"`                         // 定时任务触发时关联的定时任务ID
+	TaskType        string     `json:"taskType" gorm:"size:50;not null;index" binding:"required"` // manual, ansible, cron
+	Status          string     `json:"status" gorm:"size:50;not null;default:pending;index"`      // pending, pending_approval, running, success, failed, cancelled, rejected
+	TargetHosts     string     `json:"targetHosts,omitempty" gorm:"type:text"`                    // JSON字符串
+	Parameters      string     `json:"parameters,omitempty" gorm:"type:text"`                     // JSON
+	ExecuteTime     *time.Time `json:"executeTime,omitempty"`
+	Result          string     `json:"result,omitempty" gorm:"type:text"`  // JSON
+	Rollout         string     `json:"rollout,omitempty" gorm:"type:text"` // JSON，滚动执行时各批次的进度及停止原因
+	OutputEvents    string     `json:"-" gorm:"type:longtext"`             // JSON，任务结束时保存的输出事件，用于按 seq 回放
+	ErrorMessage    string     `json:"errorMessage,omitempty" gorm:"type:text"`
+	CancelledBy     *uint      `json:"cancelledBy,omitempty"`                     // 取消人ID
+	CancelledByName string     `json:"cancelledByName,omitempty" gorm:"size:100"` // 取消人用户名
+	CancelledAt     *time.Time `json:"cancelledAt,omitempty"`
+	CreatedBy       uint       `json:"createdBy" gorm:"not null"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	DeletedAt       *time.Time `json:"deletedAt,omitempty" gorm:"index"`
 }
 
 func (JobTask) TableName() string {
 	return "job_tasks"
 }
+
+// 任务状态常量
+const (
+	JobStatusPending         = "pending"          // 待执行
+	JobStatusPendingApproval = "pending_approval" // 待审批
+	JobStatusRunning         = "running"          // 执行中
+	JobStatusSuccess         = "success"          // 成功
+	JobStatusFailed          = "failed"           // 失败
+	JobStatusCancelled       = "cancelled"        // 已取消
+	JobStatusRejected        = "rejected"         // 审批被拒绝
+)
+
+// 主机执行状态常量
+const (
+	HostStatusPending   = "pending"   // 等待执行
+	HostStatusRunning   = "running"   // 执行中
+	HostStatusSuccess   = "success"   // 成功
+	HostStatusFailed    = "failed"    // 失败
+	HostStatusTimeout   = "timeout"   // 超时
+	HostStatusCancelled = "cancelled" // 已取消
+	HostStatusSkipped   = "skipped"   // 滚动执行提前停止，未执行
+)
+
+// HostExecutionResult 主机执行结果（JobTask.Result 中的单项）
+type HostExecutionResult struct {
+	HostID    uint       `json:"hostId"`
+	HostName  string     `json:"hostName"`
+	HostIP    string     `json:"hostIp"`
+	Status    string     `json:"status"`          // pending, running, success, failed, timeout, cancelled, skipped
+	Batch     int        `json:"batch,omitempty"` // 滚动执行时所在批次，从1开始
+	Output    string     `json:"output"`
+	Error     string     `json:"error,omitempty"`
+	StartTime *time.Time `json:"startTime,omitempty"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+}
+
+// RolloutStatus 滚动执行进度（JobTask.Rollout）
+type RolloutStatus struct {
+	BatchSize         string         `json:"batchSize"`                   // 每批主机数，如 "5"、"20%"、"1,25%,50%"
+	PauseSeconds      int            `json:"pauseSeconds"`                // 批次间暂停（秒）
+	MaxFailPercentage *int           `json:"maxFailPercentage,omitempty"` // 单批失败率超过该值时停止，未设置时整批失败才停止
+	Batches           []RolloutBatch `json:"batches"`
+	CurrentBatch      int            `json:"currentBatch"`          // 正在执行或最后执行的批次
+	Halted            bool           `json:"halted"`                // 是否因失败率超限提前停止
+	HaltedBatch       int            `json:"haltedBatch,omitempty"` // 触发停止的批次
+	HaltReason        string         `json:"haltReason,omitempty"`
+}
+
+// RolloutBatch 单个批次的执行情况
+type RolloutBatch struct {
+	Index       int        `json:"index"` // 从1开始
+	HostIDs     []uint     `json:"hostIds"`
+	Status      string     `json:"status"` // pending, running, success, failed, skipped, cancelled
+	Failed      int        `json:"failed"`
+	FailureRate float64    `json:"failureRate"` // 百分比
+	StartTime   *time.Time `json:"startTime,omitempty"`
+	EndTime     *time.Time `json:"endTime,omitempty"`
+}
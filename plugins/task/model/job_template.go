@@ -45,3 +45,40 @@ type JobTemplate struct {
 func (JobTemplate) TableName() string {
 	return "job_templates"
 }
+
+// TemplateVariable 模板变量定义（JobTemplate.Variables 中的单项）
+// 模板内容中以 {{varName}} 形式引用，执行时按类型校验取值并按脚本类型转义后替换；
+// secret 变量的默认值加密保存，读取时脱敏
+type TemplateVariable struct {
+	Name         string   `json:"name"`
+	VarName      string   `json:"varName"`
+	Type         string   `json:"type"` // string, int, enum, secret, host-list
+	Required     bool     `json:"required"`
+	DefaultValue string   `json:"defaultValue"`
+	HelpText     string   `json:"helpText,omitempty"`
+	Options      []string `json:"options,omitempty"` // enum 可选值
+	Min          *int64   `json:"min,omitempty"`     // int 最小值
+	Max          *int64   `json:"max,omitempty"`     // int 最大值
+}
+
+// 模板变量类型常量
+const (
+	VarTypeString   = "string"    // 字符串
+	VarTypeInt      = "int"       // 整数
+	VarTypeEnum     = "enum"      // 枚举
+	VarTypeSecret   = "secret"    // 敏感信息，记录时脱敏
+	VarTypeHostList = "host-list" // 主机列表，取值为主机ID，渲染为主机IP列表
+)
+
+// NormalizedType 返回变量类型，兼容旧版的 text/password/select
+func (v TemplateVariable) NormalizedType() string {
+	switch v.Type {
+	case "", "text":
+		return VarTypeString
+	case "password":
+		return VarTypeSecret
+	case "select":
+		return VarTypeEnum
+	}
+	return v.Type
+}
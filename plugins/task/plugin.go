@@ -20,18 +20,29 @@
 package task
 
 import (
+	"sync"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
 	"github.com/ydcloud-dy/opshub/internal/plugin"
 	"github.com/ydcloud-dy/opshub/plugins/task/model"
 	"github.com/ydcloud-dy/opshub/plugins/task/server"
+	"github.com/ydcloud-dy/opshub/plugins/task/service"
 )
 
 // Plugin 任务中心插件实现
 type Plugin struct {
-	db   *gorm.DB
-	name string
+	db        *gorm.DB
+	name      string
+	executor  *service.Executor
+	scheduler *service.Scheduler
+	ansible   *service.AnsibleRunner
+
+	// 执行器等组件在进程内只创建一次，RegisterRoutes 注册的处理器与之后的启停共用同一实例
+	initOnce    sync.Once
+	recoverOnce sync.Once
 }
 
 // New 创建插件实例
@@ -69,29 +80,76 @@ func (p *Plugin) Enable(db *gorm.DB) error {
 	models := []interface{}{
 		&model.JobTask{},
 		&model.JobTemplate{},
+		&model.JobSchedule{},
 		&model.AnsibleTask{},
+		&model.CommandPolicy{},
+		&model.JobApproval{},
+		&model.JobApprovalRecord{},
+		&model.ApprovalRule{},
 	}
 
 	for _, m := range models {
-		if !db.Migrator().HasTable(m) {
-			if err := db.AutoMigrate(m); err != nil {
-				return err
-			}
+		if err := db.AutoMigrate(m); err != nil {
+			return err
 		}
 	}
 
+	// 写入内置命令策略
+	if err := service.SeedDefaultPolicies(db); err != nil {
+		return err
+	}
+
+	// 加密历史模板中明文保存的 secret 变量默认值
+	if err := service.EncryptTemplateSecrets(db); err != nil {
+		return err
+	}
+
+	// 加密历史审批单中明文保存的待执行脚本
+	if err := service.EncryptApprovalSecrets(db); err != nil {
+		return err
+	}
+
+	p.init(db)
+
+	// 交互式终端中提交的命令同样按命令策略检查
+	assetbiz.SetTerminalCommandChecker(service.NewPolicyEngine(db).CheckTerminalCommand)
+
+	// 只在进程内首次启用时清理服务重启前未完成的任务；
+	// 运行期间通过插件管理接口重复启用时，执行中的任务仍由当前进程持有，不能被标记为失败
+	p.recoverOnce.Do(func() {
+		p.executor.RecoverStuckTasks()
+		p.ansible.RecoverStuckTasks()
+	})
+
+	// 启动定时任务调度器，已启动时为空操作
+	p.scheduler.Start()
+
 	return nil
 }
 
 // Disable 禁用插件
+// 只停止定时任务调度，已提交的任务继续执行完成；再次启用时复用同一调度器
 func (p *Plugin) Disable(db *gorm.DB) error {
-	// 清理插件资源（如果需要）
+	assetbiz.SetTerminalCommandChecker(nil)
+	if p.scheduler != nil {
+		p.scheduler.Stop()
+	}
 	return nil
 }
 
 // RegisterRoutes 注册路由
 func (p *Plugin) RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
-	server.RegisterRoutes(router, db)
+	p.init(db)
+	server.RegisterRoutes(router, db, p.executor, p.scheduler, p.ansible)
+}
+
+// init 创建任务执行器、Ansible执行器和定时任务调度器
+func (p *Plugin) init(db *gorm.DB) {
+	p.initOnce.Do(func() {
+		p.executor = service.NewExecutor(db)
+		p.ansible = service.NewAnsibleRunner(db, p.executor)
+		p.scheduler = service.NewScheduler(db, p.executor)
+	})
 }
 
 // GetMenus 获取插件菜单配置
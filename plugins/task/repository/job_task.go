@@ -80,7 +80,7 @@ func (r *JobTaskRepository) List(ctx context.Context, page, pageSize int, keywor
 	}
 
 	offset := (page - 1) * pageSize
-	err = query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&jobTasks).Error
+	err = query.Omit("output_events").Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&jobTasks).Error
 
 	return jobTasks, total, err
 }
@@ -0,0 +1,395 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"github.com/ydcloud-dy/opshub/plugins/task/service"
+)
+
+// ==================== 任务审批 ====================
+
+// ApprovalDecisionRequest 审批/评论请求
+type ApprovalDecisionRequest struct {
+	Comment string `json:"comment"`
+}
+
+// ApprovalRuleRequest 创建/更新审批规则请求
+type ApprovalRuleRequest struct {
+	Name            string `json:"name" binding:"required"`
+	Description     string `json:"description"`
+	AssetGroupIDs   []uint `json:"assetGroupIds"`   // 需要审批的分组，为空时只为命中需审批策略的任务提供审批人
+	ApproverRoleIDs []uint `json:"approverRoleIds"` // 可审批的角色
+	ApproverDeptIDs []uint `json:"approverDeptIds"` // 可审批的部门（含下级部门）
+	Status          *int   `json:"status"`          // 0-禁用, 1-启用，默认启用
+}
+
+// JobApprovalDetail 审批单详情
+type JobApprovalDetail struct {
+	model.JobApproval
+	JobName    string                    `json:"jobName"`
+	JobStatus  string                    `json:"jobStatus"`
+	CanApprove bool                      `json:"canApprove"` // 当前用户是否可以审批
+	Records    []model.JobApprovalRecord `json:"records"`
+}
+
+// ListJobApprovals 获取审批单列表
+// @Summary 获取审批单列表
+// @Description 分页获取任务审批单，scope=todo 时只返回当前用户可以审批的待审批单，scope=mine 时只返回当前用户提交的审批单
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param status query string false "审批状态"
+// @Param scope query string false "范围：todo, mine"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /task/approvals [get]
+func (h *Handler) ListJobApprovals(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	status := c.Query("status")
+	scope := c.Query("scope")
+	userID, _ := currentUser(c)
+
+	query := h.db.Model(&model.JobApproval{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if scope == "mine" {
+		query = query.Where("requested_by = ?", userID)
+	}
+
+	var approvals []model.JobApproval
+	var total int64
+	if scope == "todo" {
+		// 审批人由角色、部门和管理员身份共同决定，待审批单数量有限，逐条判断后再分页
+		var pending []model.JobApproval
+		query.Where("status = ?", model.ApprovalStatusPending).Order("created_at DESC").Find(&pending)
+		for i := range pending {
+			if ok, _ := h.approval.CanApprove(c.Request.Context(), &pending[i], userID); ok {
+				approvals = append(approvals, pending[i])
+			}
+		}
+		total = int64(len(approvals))
+		start := (page - 1) * pageSize
+		if start > len(approvals) {
+			start = len(approvals)
+		}
+		end := start + pageSize
+		if end > len(approvals) {
+			end = len(approvals)
+		}
+		approvals = approvals[start:end]
+	} else {
+		query.Count(&total)
+		offset := (page - 1) * pageSize
+		query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&approvals)
+	}
+	if approvals == nil {
+		approvals = []model.JobApproval{}
+	}
+
+	response.Success(c, gin.H{
+		"list":     approvals,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetJobApproval 获取审批单详情
+// @Summary 获取审批单详情
+// @Description 获取审批单详情，包含脱敏后的脚本、需要审批的原因、流转记录以及当前用户是否可以审批
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "审批单ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 404 {object} response.Response "审批单不存在"
+// @Router /task/approvals/{id} [get]
+func (h *Handler) GetJobApproval(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var approval model.JobApproval
+	if err := h.db.First(&approval, id).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "审批单不存在")
+		return
+	}
+
+	detail := JobApprovalDetail{JobApproval: approval, Records: []model.JobApprovalRecord{}}
+	var jobTask model.JobTask
+	if err := h.db.Select("id, name, status").First(&jobTask, approval.JobID).Error; err == nil {
+		detail.JobName = jobTask.Name
+		detail.JobStatus = jobTask.Status
+	}
+	h.db.Where("approval_id = ?", approval.ID).Order("id ASC").Find(&detail.Records)
+	if approval.Status == model.ApprovalStatusPending {
+		userID, _ := currentUser(c)
+		detail.CanApprove, _ = h.approval.CanApprove(c.Request.Context(), &approval, userID)
+	}
+	response.Success(c, detail)
+}
+
+// ApproveJobApproval 审批通过
+// @Summary 审批通过
+// @Description 审批通过后任务按提交时的参数自动执行，审批和执行均记录到审计日志
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "审批单ID"
+// @Param body body ApprovalDecisionRequest false "审批意见"
+// @Success 200 {object} response.Response "审批成功"
+// @Failure 400 {object} response.Response "审批单已处理"
+// @Failure 403 {object} response.Response "不是审批人"
+// @Router /task/approvals/{id}/approve [post]
+func (h *Handler) ApproveJobApproval(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req ApprovalDecisionRequest
+	c.ShouldBindJSON(&req)
+
+	userID, username := currentUser(c)
+	approval, err := h.approval.Approve(c.Request.Context(), uint(id), userID, username, req.Comment)
+	if err != nil {
+		approvalError(c, err)
+		return
+	}
+	response.SuccessWithMessage(c, "审批通过，任务已开始执行", approval)
+}
+
+// RejectJobApproval 审批拒绝
+// @Summary 审批拒绝
+// @Description 拒绝后任务状态置为 rejected，不会执行，拒绝时必须填写意见
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "审批单ID"
+// @Param body body ApprovalDecisionRequest true "拒绝原因"
+// @Success 200 {object} response.Response "操作成功"
+// @Failure 400 {object} response.Response "审批单已处理"
+// @Failure 403 {object} response.Response "不是审批人"
+// @Router /task/approvals/{id}/reject [post]
+func (h *Handler) RejectJobApproval(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req ApprovalDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Comment == "" {
+		response.ErrorCode(c, http.StatusBadRequest, "请填写拒绝原因")
+		return
+	}
+
+	userID, username := currentUser(c)
+	approval, err := h.approval.Reject(c.Request.Context(), uint(id), userID, username, req.Comment)
+	if err != nil {
+		approvalError(c, err)
+		return
+	}
+	response.SuccessWithMessage(c, "已拒绝", approval)
+}
+
+// CommentJobApproval 添加审批评论
+// @Summary 添加审批评论
+// @Description 提交人和审批人可以在审批单上添加评论
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "审批单ID"
+// @Param body body ApprovalDecisionRequest true "评论内容"
+// @Success 200 {object} response.Response "评论成功"
+// @Failure 403 {object} response.Response "无权评论"
+// @Router /task/approvals/{id}/comments [post]
+func (h *Handler) CommentJobApproval(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req ApprovalDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Comment == "" {
+		response.ErrorCode(c, http.StatusBadRequest, "评论内容不能为空")
+		return
+	}
+
+	userID, username := currentUser(c)
+	record, err := h.approval.Comment(c.Request.Context(), uint(id), userID, username, req.Comment)
+	if err != nil {
+		approvalError(c, err)
+		return
+	}
+	response.Success(c, record)
+}
+
+// approvalError 将审批服务的错误转换为响应
+func approvalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrApprovalNotFound):
+		response.ErrorCode(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrNotApprover), errors.Is(err, service.ErrSelfApproval):
+		response.ErrorCode(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrApprovalDecided):
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+	default:
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// ==================== 审批规则 ====================
+
+// ListApprovalRules 获取审批规则列表
+// @Summary 获取审批规则列表
+// @Description 获取全部审批规则
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} response.Response "获取成功"
+// @Router /task/approval-rules [get]
+func (h *Handler) ListApprovalRules(c *gin.Context) {
+	var rules []model.ApprovalRule
+	h.db.Where("deleted_at IS NULL").Order("id ASC").Find(&rules)
+	response.Success(c, rules)
+}
+
+// CreateApprovalRule 创建审批规则
+// @Summary 创建审批规则
+// @Description 指定需要审批的分组（如生产环境）及可审批的角色、部门
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body ApprovalRuleRequest true "审批规则"
+// @Success 200 {object} response.Response "创建成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 403 {object} response.Response "权限不足"
+// @Router /task/approval-rules [post]
+func (h *Handler) CreateApprovalRule(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	var req ApprovalRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	rule := model.ApprovalRule{Status: 1}
+	applyApprovalRuleRequest(&rule, &req)
+	rule.CreatedBy, _ = currentUser(c)
+	if err := h.db.Create(&rule).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "创建失败: "+err.Error())
+		return
+	}
+	response.Success(c, rule)
+}
+
+// UpdateApprovalRule 更新审批规则
+// @Summary 更新审批规则
+// @Description 更新审批规则，只影响之后提交的任务
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规则ID"
+// @Param body body ApprovalRuleRequest true "审批规则"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 404 {object} response.Response "规则不存在"
+// @Failure 403 {object} response.Response "权限不足"
+// @Router /task/approval-rules/{id} [put]
+func (h *Handler) UpdateApprovalRule(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var rule model.ApprovalRule
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&rule).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "审批规则不存在")
+		return
+	}
+
+	var req ApprovalRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	applyApprovalRuleRequest(&rule, &req)
+	if err := h.db.Save(&rule).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "更新失败: "+err.Error())
+		return
+	}
+	response.Success(c, rule)
+}
+
+// DeleteApprovalRule 删除审批规则
+// @Summary 删除审批规则
+// @Description 删除审批规则，已提交的审批单不受影响
+// @Tags 任务管理-任务审批
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规则ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "规则不存在"
+// @Failure 403 {object} response.Response "权限不足"
+// @Router /task/approval-rules/{id} [delete]
+func (h *Handler) DeleteApprovalRule(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	now := time.Now()
+	result := h.db.Model(&model.ApprovalRule{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{"deleted_at": &now, "status": 0})
+	if result.Error != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "删除失败: "+result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.ErrorCode(c, http.StatusNotFound, "审批规则不存在")
+		return
+	}
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// applyApprovalRuleRequest 填充审批规则字段
+func applyApprovalRuleRequest(rule *model.ApprovalRule, req *ApprovalRuleRequest) {
+	marshalIDs := func(ids []uint) string {
+		if ids == nil {
+			ids = []uint{}
+		}
+		data, _ := json.Marshal(ids)
+		return string(data)
+	}
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.AssetGroupIDs = marshalIDs(req.AssetGroupIDs)
+	rule.ApproverRoleIDs = marshalIDs(req.ApproverRoleIDs)
+	rule.ApproverDeptIDs = marshalIDs(req.ApproverDeptIDs)
+	if req.Status != nil {
+		rule.Status = *req.Status
+	}
+}
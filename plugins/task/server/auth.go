@@ -0,0 +1,84 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"github.com/ydcloud-dy/opshub/plugins/task/service"
+	"gorm.io/gorm"
+)
+
+// isAdmin 判断当前用户是否为管理员，查询失败时按非管理员处理
+func (h *Handler) isAdmin(c *gin.Context) bool {
+	userID, _ := currentUser(c)
+	admin, err := service.IsAdmin(c.Request.Context(), h.db, userID)
+	return err == nil && admin
+}
+
+// requireAdmin 检查当前用户是否为管理员，不是时写入 403 响应并返回 false
+func (h *Handler) requireAdmin(c *gin.Context) bool {
+	if h.isAdmin(c) {
+		return true
+	}
+	response.ErrorCode(c, http.StatusForbidden, "权限不足：此操作仅限管理员执行")
+	return false
+}
+
+// requireJobOwner 检查当前用户是否为任务创建人或管理员，不是时写入 403 响应并返回 false
+func (h *Handler) requireJobOwner(c *gin.Context, jobTask *model.JobTask) bool {
+	if userID, _ := currentUser(c); userID != 0 && userID == jobTask.CreatedBy {
+		return true
+	}
+	if h.isAdmin(c) {
+		return true
+	}
+	response.ErrorCode(c, http.StatusForbidden, "无权操作该任务")
+	return false
+}
+
+// requireHostPermission 检查用户对全部目标主机拥有指定资产操作权限，缺少权限时写入 403 响应并返回 false
+func (h *Handler) requireHostPermission(c *gin.Context, userID uint, hostIDs []uint, operation uint) bool {
+	err := h.hostPerms.Check(c.Request.Context(), userID, hostIDs, operation)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, service.ErrHostPermissionDenied) {
+		response.ErrorCode(c, http.StatusForbidden, err.Error())
+	} else {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+	}
+	return false
+}
+
+// jobScope 返回当前用户可见的任务记录查询条件，查询权限失败时写入 500 响应并返回 nil
+func (h *Handler) jobScope(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	userID, _ := currentUser(c)
+	scope, err := h.hostPerms.JobScope(c.Request.Context(), userID)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+		return nil
+	}
+	return scope
+}
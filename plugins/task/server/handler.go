@@ -20,39 +20,43 @@
 package server
 
 import (
-	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/pkg/sftp"
-	"golang.org/x/crypto/ssh"
-	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
 	"github.com/ydcloud-dy/opshub/pkg/response"
 	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"github.com/ydcloud-dy/opshub/plugins/task/service"
 	"gorm.io/gorm"
 )
 
 type Handler struct {
-	db            *gorm.DB
-	encryptionKey []byte
+	db          *gorm.DB
+	executor    *service.Executor
+	scheduler   *service.Scheduler
+	ansible     *service.AnsibleRunner
+	policy      *service.PolicyEngine
+	approval    *service.ApprovalService
+	distributor *service.Distributor
+	hostPerms   *service.HostPermission
 }
 
-func NewHandler(db *gorm.DB) *Handler {
-	// 使用与凭证仓库相同的加密密钥
-	encryptionKey := []byte("opshub-enc-key-32-bytes-long!!!!")
+func NewHandler(db *gorm.DB, executor *service.Executor, scheduler *service.Scheduler, ansible *service.AnsibleRunner) *Handler {
 	return &Handler{
-		db:            db,
-		encryptionKey: encryptionKey,
+		db:          db,
+		executor:    executor,
+		scheduler:   scheduler,
+		ansible:     ansible,
+		policy:      service.NewPolicyEngine(db),
+		approval:    service.NewApprovalService(db, executor),
+		distributor: service.NewDistributor(db, executor),
+		hostPerms:   service.NewHostPermission(db),
 	}
 }
 
@@ -82,7 +86,11 @@ func (h *Handler) ListJobTasks(c *gin.Context) {
 	var jobTasks []*model.JobTask
 	var total int64
 
-	query := h.db.Model(&model.JobTask{}).Where("deleted_at IS NULL")
+	scope := h.jobScope(c)
+	if scope == nil {
+		return
+	}
+	query := h.db.Model(&model.JobTask{}).Where("deleted_at IS NULL").Scopes(scope)
 
 	if keyword != "" {
 		query = query.Where("name LIKE ?", "%"+keyword+"%")
@@ -96,7 +104,7 @@ func (h *Handler) ListJobTasks(c *gin.Context) {
 
 	query.Count(&total)
 	offset := (page - 1) * pageSize
-	query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&jobTasks)
+	query.Omit("output_events").Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&jobTasks)
 
 	response.Success(c, gin.H{
 		"list":     jobTasks,
@@ -119,8 +127,12 @@ func (h *Handler) ListJobTasks(c *gin.Context) {
 // @Router /task/job-tasks/{id} [get]
 func (h *Handler) GetJobTask(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	scope := h.jobScope(c)
+	if scope == nil {
+		return
+	}
 	var jobTask model.JobTask
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&jobTask).Error; err != nil {
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).Scopes(scope).First(&jobTask).Error; err != nil {
 		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
 		return
 	}
@@ -153,33 +165,94 @@ func (h *Handler) CreateJobTask(c *gin.Context) {
 	response.Success(c, jobTask)
 }
 
+// UpdateJobTaskRequest 更新任务作业请求，执行参数、目标主机和结果由执行流程维护，不能修改
+type UpdateJobTaskRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
 // UpdateJobTask 更新任务作业
 // @Summary 更新任务作业
-// @Description 更新指定任务作业的信息
+// @Description 修改任务作业名称，仅任务创建人或管理员可修改
 // @Tags 任务管理-任务作业
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param id path int true "任务ID"
-// @Param body body model.JobTask true "任务信息"
+// @Param body body UpdateJobTaskRequest true "任务信息"
 // @Success 200 {object} response.Response "更新成功"
+// @Failure 403 {object} response.Response "无权操作该任务"
 // @Failure 404 {object} response.Response "任务不存在"
 // @Router /task/job-tasks/{id} [put]
 func (h *Handler) UpdateJobTask(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	scope := h.jobScope(c)
+	if scope == nil {
+		return
+	}
 	var jobTask model.JobTask
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&jobTask).Error; err != nil {
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).Scopes(scope).First(&jobTask).Error; err != nil {
 		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
 		return
 	}
-	if err := c.ShouldBindJSON(&jobTask); err != nil {
+	if !h.requireJobOwner(c, &jobTask) {
+		return
+	}
+
+	var req UpdateJobTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		response.ErrorCode(c, http.StatusBadRequest, "参数错误")
 		return
 	}
-	h.db.Save(&jobTask)
+	if err := h.db.Model(&jobTask).Update("name", req.Name).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "更新失败")
+		return
+	}
 	response.Success(c, jobTask)
 }
 
+// CancelJobTask 取消任务作业
+// @Summary 取消任务作业
+// @Description 取消正在执行的任务：向远程命令发送SIGTERM，超过宽限期后发送SIGKILL，仍在执行及尚未开始的主机标记为已取消，并记录取消人；待审批的任务取消后同时撤销审批单。仅任务创建人或管理员可取消
+// @Tags 任务管理-任务作业
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response "取消成功"
+// @Failure 400 {object} response.Response "任务已结束"
+// @Failure 403 {object} response.Response "无权操作该任务"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /task/jobs/{id}/cancel [post]
+func (h *Handler) CancelJobTask(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var jobTask model.JobTask
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&jobTask).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
+		return
+	}
+	if !h.requireJobOwner(c, &jobTask) {
+		return
+	}
+
+	userID, username := currentUser(c)
+	if jobTask.TaskType == "file" {
+		// 中断正在进行的传输，任务状态由下面统一更新为已取消
+		h.distributor.Cancel(jobTask.ID)
+	}
+	if err := h.executor.Cancel(jobTask.ID, userID, username); err != nil {
+		if errors.Is(err, service.ErrJobFinished) {
+			response.ErrorCode(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusInternalServerError, "取消任务失败: "+err.Error())
+		return
+	}
+	if jobTask.Status == model.JobStatusPendingApproval {
+		h.approval.Cancel(c.Request.Context(), jobTask.ID, userID, username)
+	}
+	response.SuccessWithMessage(c, "任务正在取消", nil)
+}
+
 // DeleteJobTask 删除任务作业
 // @Summary 删除任务作业
 // @Description 删除指定的任务作业记录（已禁用）
@@ -231,6 +304,9 @@ func (h *Handler) ListJobTemplates(c *gin.Context) {
 	query.Count(&total)
 	offset := (page - 1) * pageSize
 	query.Order("sort ASC, created_at DESC").Limit(pageSize).Offset(offset).Find(&templates)
+	for _, template := range templates {
+		service.MaskTemplateSecrets(template)
+	}
 
 	response.Success(c, gin.H{
 		"list":     templates,
@@ -258,6 +334,9 @@ func (h *Handler) GetAllJobTemplates(c *gin.Context) {
 		query = query.Where("category = ?", category)
 	}
 	query.Order("sort ASC").Find(&templates)
+	for _, template := range templates {
+		service.MaskTemplateSecrets(template)
+	}
 	response.Success(c, templates)
 }
 
@@ -279,6 +358,7 @@ func (h *Handler) GetJobTemplate(c *gin.Context) {
 		response.ErrorCode(c, http.StatusNotFound, "模板不存在")
 		return
 	}
+	service.MaskTemplateSecrets(&template)
 	response.Success(c, template)
 }
 
@@ -305,10 +385,21 @@ func (h *Handler) CreateJobTemplate(c *gin.Context) {
 	if template.Variables == "" {
 		template.Variables = "[]"
 	}
+	if err := service.ValidateTemplateVariables(template.Variables); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	variables, err := service.ProtectTemplateSecrets(template.Variables, "")
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	template.Variables = variables
 	if err := h.db.Create(&template).Error; err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "创建失败: "+err.Error())
 		return
 	}
+	service.MaskTemplateSecrets(&template)
 	response.Success(c, template)
 }
 
@@ -331,11 +422,24 @@ func (h *Handler) UpdateJobTemplate(c *gin.Context) {
 		response.ErrorCode(c, http.StatusNotFound, "模板不存在")
 		return
 	}
+	saved := template.Variables
 	if err := c.ShouldBindJSON(&template); err != nil {
 		response.ErrorCode(c, http.StatusBadRequest, "参数错误")
 		return
 	}
+	if err := service.ValidateTemplateVariables(template.Variables); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// secret 变量回传脱敏的默认值时沿用已加密保存的原值
+	variables, err := service.ProtectTemplateSecrets(template.Variables, saved)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	template.Variables = variables
 	h.db.Save(&template)
+	service.MaskTemplateSecrets(&template)
 	response.Success(c, template)
 }
 
@@ -448,6 +552,19 @@ func (h *Handler) CreateAnsibleTask(c *gin.Context) {
 		ansibleTask.Verbose = "v"
 	}
 	ansibleTask.CreatedBy = 1
+	if userID, _ := currentUser(c); userID != 0 {
+		ansibleTask.CreatedBy = userID
+	}
+	// MySQL JSON 字段不能为空字符串
+	if ansibleTask.ExtraVars == "" {
+		ansibleTask.ExtraVars = "{}"
+	}
+	ansibleTask.LastRunResult = "{}"
+	ansibleTask.LastJobID = nil
+	if err := h.ansible.Validate(&ansibleTask); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err := h.db.Create(&ansibleTask).Error; err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "创建失败")
 		return
@@ -474,14 +591,81 @@ func (h *Handler) UpdateAnsibleTask(c *gin.Context) {
 		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
 		return
 	}
+	if ansibleTask.Status == model.JobStatusRunning {
+		response.ErrorCode(c, http.StatusBadRequest, "任务正在执行中，无法修改")
+		return
+	}
+	lastRunResult, lastJobID := ansibleTask.LastRunResult, ansibleTask.LastJobID
 	if err := c.ShouldBindJSON(&ansibleTask); err != nil {
 		response.ErrorCode(c, http.StatusBadRequest, "参数错误")
 		return
 	}
+	// 执行结果只能由执行器写入
+	ansibleTask.LastRunResult, ansibleTask.LastJobID = lastRunResult, lastJobID
+	if ansibleTask.LastRunResult == "" {
+		ansibleTask.LastRunResult = "{}"
+	}
+	if ansibleTask.ExtraVars == "" {
+		ansibleTask.ExtraVars = "{}"
+	}
+	if err := h.ansible.Validate(&ansibleTask); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
 	h.db.Save(&ansibleTask)
 	response.Success(c, ansibleTask)
 }
 
+// RunAnsibleTask 执行Ansible任务
+// @Summary 执行Ansible任务
+// @Description 根据主机清单（主机及分组，含子分组）和解密后的凭证生成inventory，在独立工作目录中运行ansible-playbook。Playbook中shell、command等模块的命令经过命令策略评估，命中需审批的策略或分组时任务进入待审批状态。立即返回任务ID，可通过 /task/jobs/{id}/output 订阅实时输出、/task/jobs/{id}/cancel 取消执行，结束后PLAY RECAP写入lastRunResult
+// @Tags 任务管理-Ansible任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response "已开始执行"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 403 {object} response.Response "命令被拦截"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "任务正在执行"
+// @Router /task/ansible/{id}/run [post]
+func (h *Handler) RunAnsibleTask(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var ansibleTask model.AnsibleTask
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&ansibleTask).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
+		return
+	}
+
+	userID, _ := currentUser(c)
+	jobTask, err := h.ansible.Run(&ansibleTask, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrAnsibleTaskRunning) {
+			response.ErrorCode(c, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrCommandDenied) || errors.Is(err, service.ErrHostPermissionDenied) {
+			response.ErrorCode(c, http.StatusForbidden, err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if jobTask.Status == model.JobStatusPendingApproval {
+		response.SuccessWithMessage(c, "任务需要审批，已提交审批", ExecuteTaskResponse{
+			TaskID: jobTask.ID,
+			Status: jobTask.Status,
+		})
+		return
+	}
+	response.Success(c, ExecuteTaskResponse{
+		TaskID: jobTask.ID,
+		Status: jobTask.Status,
+	})
+}
+
 // DeleteAnsibleTask 删除Ansible任务
 // @Summary 删除Ansible任务
 // @Description 删除指定的Ansible任务（已禁用）
@@ -501,31 +685,30 @@ func (h *Handler) DeleteAnsibleTask(c *gin.Context) {
 
 // ExecuteTaskRequest 执行任务请求
 type ExecuteTaskRequest struct {
-	HostIDs     []uint `json:"hostIds" binding:"required"`
-	ScriptType  string `json:"scriptType" binding:"required"` // Shell, Python
-	Content     string `json:"content" binding:"required"`
-	Name        string `json:"name"`
+	HostIDs      []uint                   `json:"hostIds" binding:"required"`
+	ScriptType   string                   `json:"scriptType" binding:"required"` // Shell, Python
+	Content      string                   `json:"content"`                       // 脚本内容，指定模板时忽略
+	TemplateID   *uint                    `json:"templateId"`                    // 任务模板ID，指定后使用模板内容并按变量取值渲染
+	Variables    map[string]interface{}   `json:"variables"`                     // 模板变量取值
+	Name         string                   `json:"name"`
+	Fork         int                      `json:"fork"`         // 并发数，默认10，最大100
+	Timeout      int                      `json:"timeout"`      // 单台主机超时（秒），默认300
+	BatchTimeout int                      `json:"batchTimeout"` // 整批任务超时（秒），默认3600
+	Rolling      *service.RollingStrategy `json:"rolling"`      // 滚动执行策略，为空时所有主机按并发数一次执行
 }
 
 // ExecuteTaskResponse 执行任务响应
 type ExecuteTaskResponse struct {
-	TaskID  uint                    `json:"taskId"`
-	Results []HostExecutionResult   `json:"results"`
-}
-
-// HostExecutionResult 主机执行结果
-type HostExecutionResult struct {
-	HostID   uint   `json:"hostId"`
-	HostName string `json:"hostName"`
-	HostIP   string `json:"hostIp"`
-	Status   string `json:"status"` // success, failed
-	Output   string `json:"output"`
-	Error    string `json:"error,omitempty"`
+	TaskID     uint                        `json:"taskId"`
+	Status     string                      `json:"status"`
+	Results    []model.HostExecutionResult `json:"results"`
+	ApprovalID uint                        `json:"approvalId,omitempty"` // 需要审批时的审批单ID
+	Reason     string                      `json:"reason,omitempty"`     // 需要审批的原因
 }
 
 // ExecuteTask 执行任务
 // @Summary 执行任务
-// @Description 在指定主机上执行Shell或Python脚本
+// @Description 在指定主机上并发执行Shell或Python脚本，立即返回任务ID，各主机结果在执行完成后写入任务记录。指定模板时按模板变量类型校验取值并转义后渲染，secret 变量在任务参数和输出中脱敏。命中需审批策略或目标主机属于需审批的分组时任务进入 pending_approval 状态，审批通过后自动执行。指定滚动策略时按批次依次执行，单批失败率超过阈值后停止后续批次
 // @Tags 任务管理-任务执行
 // @Accept json
 // @Produce json
@@ -542,18 +725,32 @@ func (h *Handler) ExecuteTask(c *gin.Context) {
 		return
 	}
 
-	// 安全检查：检查命令是否包含危险操作
-	if err := h.checkCommandSafety(req.Content); err != nil {
-		response.ErrorCode(c, http.StatusForbidden, err.Error())
+	// 使用模板时按变量取值渲染脚本内容
+	content := req.Content
+	var rendered *service.RenderedTemplate
+	if req.TemplateID != nil {
+		var template model.JobTemplate
+		if err := h.db.Where("id = ? AND deleted_at IS NULL", *req.TemplateID).First(&template).Error; err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "任务模板不存在")
+			return
+		}
+		var err error
+		rendered, err = service.RenderTemplate(c.Request.Context(), h.db, &template, req.ScriptType, req.Variables)
+		if err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		content = rendered.Content
+	}
+	if strings.TrimSpace(content) == "" {
+		response.ErrorCode(c, http.StatusBadRequest, "执行内容不能为空")
 		return
 	}
-
-	ctx := c.Request.Context()
-
-	// 创建任务记录
-	taskName := req.Name
-	if taskName == "" {
-		taskName = fmt.Sprintf("手动执行任务 - %s", time.Now().Format("2006-01-02 15:04:05"))
+	if req.Rolling != nil {
+		if err := service.ValidateRollingStrategy(req.Rolling); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 
 	// 从context获取当前用户ID
@@ -564,561 +761,127 @@ func (h *Handler) ExecuteTask(c *gin.Context) {
 		}
 	}
 
-	hostIDsJSON, _ := json.Marshal(req.HostIDs)
-	jobTask := model.JobTask{
-		Name:        taskName,
-		TaskType:    "manual",
-		Status:      "running",
-		TargetHosts: string(hostIDsJSON),
-		Parameters:  "", // 空字符串而不是nil
-		CreatedBy:   createdBy,
-		ExecuteTime: ptrTime(time.Now()),
-	}
-
-	if err := h.db.Create(&jobTask).Error; err != nil {
-		response.ErrorCode(c, http.StatusInternalServerError, fmt.Sprintf("创建任务记录失败: %v", err))
+	// 权限检查：执行人需要拥有全部目标主机的执行任务权限
+	if !h.requireHostPermission(c, createdBy, req.HostIDs, rbacbiz.PermissionExecute) {
 		return
 	}
 
-	// 执行任务
-	results := make([]HostExecutionResult, 0, len(req.HostIDs))
-	allSuccess := true
-
-	for _, hostID := range req.HostIDs {
-		result := h.executeOnHost(ctx, hostID, req.ScriptType, req.Content)
-		results = append(results, result)
-		if result.Status != "success" {
-			allSuccess = false
-		}
-	}
-
-	// 更新任务状态
-	resultJSON, _ := json.Marshal(results)
-	if allSuccess {
-		jobTask.Status = "success"
-	} else {
-		jobTask.Status = "failed"
-	}
-	jobTask.Result = string(resultJSON)
-	h.db.Save(&jobTask)
-
-	response.Success(c, ExecuteTaskResponse{
-		TaskID:  jobTask.ID,
-		Results: results,
-	})
-}
-
-// checkCommandSafety 检查命令安全性
-func (h *Handler) checkCommandSafety(content string) error {
-	// 转换为小写以便不区分大小写检查
-	contentLower := strings.ToLower(content)
-
-	// 移除空格和特殊字符，防止绕过检测
-	contentCompact := strings.ReplaceAll(contentLower, " ", "")
-	contentCompact = strings.ReplaceAll(contentCompact, "\t", "")
-	contentCompact = strings.ReplaceAll(contentCompact, "\n", "")
-
-	// ============ 完全禁止的命令（一刀切） ============
-	absoluteBannedCommands := []string{
-		"rm", "unlink", "shred",  // 任何形式的删除
-	}
-
-	for _, cmd := range absoluteBannedCommands {
-		// 检查命令是否作为独立词出现
-		if strings.Contains(contentLower, cmd+" ") ||
-		   strings.Contains(contentLower, cmd+"\t") ||
-		   strings.Contains(contentLower, cmd+"\n") ||
-		   strings.HasPrefix(contentLower, cmd+" ") ||
-		   strings.HasSuffix(contentLower, " "+cmd) ||
-		   contentLower == cmd {
-			return fmt.Errorf("命令【%s】已被完全禁用，系统不允许执行任何删除操作", cmd)
-		}
-	}
-
-	// ============ 危险命令黑名单 ============
-	dangerousPatterns := []struct {
-		pattern string
-		desc    string
-	}{
-		// === 磁盘和文件系统操作 ===
-		{"dd", "磁盘数据复制命令"},
-		{"mkfs", "磁盘格式化命令"},
-		{"fdisk", "磁盘分区命令"},
-		{"parted", "磁盘分区命令"},
-		{"gdisk", "GPT磁盘分区"},
-		{"cfdisk", "磁盘分区工具"},
-		{"sfdisk", "脚本化分区工具"},
-		{"mkswap", "创建交换空间"},
-		{"swapoff", "关闭交换空间"},
-		{"fsck", "文件系统检查"},
-		{"e2fsck", "ext文件系统检查"},
-		{"xfs_repair", "XFS文件系统修复"},
-		{"tune2fs", "调整文件系统参数"},
-		{"resize2fs", "调整文件系统大小"},
-
-		// === 系统控制命令 ===
-		{"shutdown", "关机命令"},
-		{"reboot", "重启命令"},
-		{"halt", "停机命令"},
-		{"poweroff", "关机命令"},
-		{"init 0", "关机命令"},
-		{"init 6", "重启命令"},
-		{"telinit", "改变运行级别"},
-		{"systemctl reboot", "系统重启"},
-		{"systemctl poweroff", "系统关机"},
-		{"systemctl halt", "系统停机"},
-
-		// === 进程管理 ===
-		{"kill -9", "强制终止进程"},
-		{"kill -kill", "强制终止进程"},
-		{"killall", "批量终止进程"},
-		{"pkill", "批量终止进程"},
-		{"killall5", "终止所有进程"},
-		{"skill", "发送信号到进程"},
-
-		// === 权限和所有权修改 ===
-		{"chmod 777", "最宽松权限设置"},
-		{"chmod 666", "危险权限设置"},
-		{"chmod 000", "拒绝所有权限"},
-		{"chmod -r 777", "递归最宽松权限"},
-		{"chmod -r 666", "递归危险权限"},
-		{"chmod a+w", "添加写权限"},
-		{"chmod u+s", "设置SUID"},
-		{"chmod g+s", "设置SGID"},
-		{"chown -r", "递归修改所有者"},
-		{"chgrp -r", "递归修改组"},
-		{"chattr", "修改文件属性"},
-		{"setfacl", "设置ACL权限"},
-
-		// === 服务管理 ===
-		{"systemctl stop", "停止服务"},
-		{"systemctl disable", "禁用服务"},
-		{"systemctl mask", "屏蔽服务"},
-		{"systemctl kill", "终止服务"},
-		{"service stop", "停止服务"},
-		{"service restart", "重启服务"},
-		{"systemctl restart", "重启服务"},
-
-		// === 网络和防火墙 ===
-		{"iptables -f", "清空防火墙规则"},
-		{"iptables -x", "删除防火墙链"},
-		{"iptables -d", "删除防火墙规则"},
-		{"ip6tables -f", "清空IPv6防火墙"},
-		{"firewall-cmd --reload", "重载防火墙"},
-		{"ufw disable", "禁用防火墙"},
-		{"ufw reset", "重置防火墙"},
-		{"ifconfig down", "关闭网络接口"},
-		{"ip link set down", "关闭网络链路"},
-		{"ip addr del", "删除IP地址"},
-		{"ip route del", "删除路由"},
-
-		// === 用户和组管理 ===
-		{"userdel", "删除用户"},
-		{"groupdel", "删除用户组"},
-		{"passwd -d", "删除用户密码"},
-		{"passwd -l", "锁定用户"},
-		{"usermod -l", "修改用户"},
-		{"usermod -u 0", "修改用户UID为root"},
-		{"visudo", "编辑sudo配置"},
-
-		// === 定时任务 ===
-		{"crontab -r", "删除定时任务"},
-		{"crontab -e", "编辑定时任务"},
-		{"at -r", "删除at任务"},
-
-		// === 系统配置文件 ===
-		{"/etc/passwd", "系统密码文件"},
-		{"/etc/shadow", "系统影子文件"},
-		{"/etc/sudoers", "sudo配置文件"},
-		{"/etc/ssh/sshd_config", "SSH配置文件"},
-		{"/etc/fstab", "文件系统挂载表"},
-		{"/etc/hosts", "主机名解析文件"},
-		{"/etc/resolv.conf", "DNS解析配置"},
-		{"/etc/profile", "系统环境变量"},
-		{"/etc/bashrc", "bash配置"},
-		{"/root/.ssh", "root SSH配置"},
-		{"/root/.bashrc", "root bash配置"},
-		{"> /etc/", "重定向到系统配置目录"},
-		{">> /etc/", "追加到系统配置目录"},
-
-		// === 远程脚本执行 ===
-		{"curl | bash", "通过curl执行远程脚本"},
-		{"curl | sh", "通过curl执行远程脚本"},
-		{"curl|bash", "通过curl执行远程脚本（无空格）"},
-		{"curl|sh", "通过curl执行远程脚本（无空格）"},
-		{"wget | bash", "通过wget执行远程脚本"},
-		{"wget | sh", "通过wget执行远程脚本"},
-		{"wget|bash", "通过wget执行远程脚本（无空格）"},
-		{"wget|sh", "通过wget执行远程脚本（无空格）"},
-		{"wget -o- | sh", "通过wget执行远程脚本"},
-		{"curl -s | bash", "通过curl静默执行脚本"},
-		{"curl -fssl | sh", "通过curl执行脚本"},
-
-		// === 恶意代码模式 ===
-		{":(){ :|:& };:", "fork炸弹"},
-		{"fork", "fork炸弹相关"},
-		{".() { .", "bash函数炸弹"},
-		{"while true", "无限循环"},
-		{"for((;;))", "无限循环"},
-
-		// === 设备文件操作 ===
-		{"> /dev/sd", "写入磁盘设备"},
-		{"> /dev/hd", "写入磁盘设备"},
-		{"> /dev/vd", "写入虚拟磁盘"},
-		{"> /dev/nvme", "写入NVMe设备"},
-		{"> /dev/xvd", "写入Xen虚拟磁盘"},
-		{"> /dev/null", "重定向到空设备"},
-		{"< /dev/zero", "从zero设备读取"},
-		{"< /dev/random", "从随机设备读取"},
-
-		// === 内核和模块操作 ===
-		{"insmod", "加载内核模块"},
-		{"rmmod", "卸载内核模块"},
-		{"modprobe -r", "移除内核模块"},
-		{"depmod", "生成模块依赖"},
-		{"sysctl -w", "修改内核参数"},
-		{"echo > /proc/", "修改proc文件系统"},
-		{"echo > /sys/", "修改sys文件系统"},
-
-		// === 挂载操作 ===
-		{"mount -o remount", "重新挂载"},
-		{"mount --bind", "绑定挂载"},
-		{"umount -f", "强制卸载"},
-		{"umount -l", "懒卸载"},
-
-		// === 历史和日志清理 ===
-		{"history -c", "清除命令历史"},
-		{"history -w", "写入历史文件"},
-		{"> ~/.bash_history", "清空bash历史"},
-		{"> /var/log/", "清空日志文件"},
-		{"truncate", "截断文件"},
-		{"echo > /var/log", "清空日志"},
-		{"cat /dev/null >", "清空文件"},
-
-		// === 包管理器删除操作 ===
-		{"apt remove", "APT删除软件包"},
-		{"apt purge", "APT清除软件包"},
-		{"apt autoremove", "APT自动删除"},
-		{"yum remove", "YUM删除软件包"},
-		{"yum erase", "YUM删除软件包"},
-		{"dnf remove", "DNF删除软件包"},
-		{"rpm -e", "RPM删除软件包"},
-		{"dpkg -r", "DPKG删除软件包"},
-		{"dpkg --purge", "DPKG清除软件包"},
-		{"pip uninstall", "PIP卸载包"},
-		{"npm uninstall", "NPM卸载包"},
-		{"gem uninstall", "GEM卸载包"},
-
-		// === 容器和虚拟化 ===
-		{"docker rm", "删除Docker容器"},
-		{"docker rmi", "删除Docker镜像"},
-		{"docker system prune", "清理Docker系统"},
-		{"docker volume rm", "删除Docker卷"},
-		{"docker network rm", "删除Docker网络"},
-		{"kubectl delete", "删除Kubernetes资源"},
-		{"kubectl drain", "驱逐节点"},
-		{"virsh destroy", "销毁虚拟机"},
-		{"virsh undefine", "取消定义虚拟机"},
-		{"lxc delete", "删除LXC容器"},
-
-		// === 数据库危险操作 ===
-		{"drop database", "删除数据库"},
-		{"drop table", "删除数据表"},
-		{"truncate table", "清空数据表"},
-		{"delete from", "删除数据"},
-		{"drop user", "删除数据库用户"},
-
-		// === 编译和代码执行 ===
-		{"gcc -o", "编译C代码"},
-		{"g++ -o", "编译C++代码"},
-		{"python -c", "执行Python代码"},
-		{"python3 -c", "执行Python3代码"},
-		{"perl -e", "执行Perl代码"},
-		{"ruby -e", "执行Ruby代码"},
-		{"php -r", "执行PHP代码"},
-		{"node -e", "执行Node.js代码"},
-		{"eval", "执行动态代码"},
-		{"exec", "执行命令"},
-		{"source /dev/", "source设备文件"},
-		{". /dev/", "点命令执行设备文件"},
-
-		// === SELinux ===
-		{"setenforce 0", "禁用SELinux"},
-		{"setenforce permissive", "SELinux宽容模式"},
-		{"semanage", "SELinux策略管理"},
-
-		// === 加密和压缩 ===
-		{"openssl enc", "OpenSSL加密"},
-		{"gpg --encrypt", "GPG加密"},
-		{"7z a -p", "7z密码压缩"},
-		{"zip -P", "ZIP密码压缩"},
-
-		// === 网络扫描和攻击工具 ===
-		{"nmap", "网络扫描"},
-		{"masscan", "大规模扫描"},
-		{"nc -l", "监听端口"},
-		{"netcat -l", "监听端口"},
-		{"tcpdump", "抓包工具"},
-		{"wireshark", "抓包工具"},
-		{"tshark", "命令行抓包"},
-		{"arpspoof", "ARP欺骗"},
-		{"ettercap", "中间人攻击"},
-		{"hydra", "暴力破解"},
-		{"john", "密码破解"},
-		{"hashcat", "哈希破解"},
-		{"metasploit", "渗透测试框架"},
-		{"sqlmap", "SQL注入工具"},
-
-		// === 其他危险操作 ===
-		{"wget --no-check-certificate", "忽略证书验证"},
-		{"curl -k", "忽略SSL证书"},
-		{"ldconfig", "更新动态链接库缓存"},
-		{"updatedb", "更新文件数据库"},
-		{"grub-install", "安装GRUB"},
-		{"lilo", "LILO引导加载器"},
-		{"dd bs=", "指定块大小的dd"},
-		{"shred -n", "多次覆盖删除"},
-		{"wipe", "安全擦除"},
-		{"srm", "安全删除"},
-		{"dban", "磁盘擦除"},
-		{"badblocks -w", "写入测试坏块"},
-		{"hdparm", "硬盘参数设置"},
-		{"sdparm", "SCSI磁盘参数"},
-	}
-
-	// 检查是否包含危险命令
-	for _, dp := range dangerousPatterns {
-		if strings.Contains(contentLower, dp.pattern) {
-			return fmt.Errorf("命令包含危险操作【%s】，已被系统拦截", dp.desc)
-		}
-	}
-
-	// ============ 检查危险路径模式 ============
-	dangerousPaths := []string{
-		"/boot", "/bin", "/sbin", "/lib", "/lib64",
-		"/usr/bin", "/usr/sbin", "/usr/lib",
-		"/var/lib", "/var/run", "/var/lock",
-		"/dev/sd", "/dev/hd", "/dev/vd", "/dev/nvme",
-		"/proc/sys", "/sys/class", "/sys/module",
-	}
-
-	for _, path := range dangerousPaths {
-		if strings.Contains(contentLower, path) {
-			return fmt.Errorf("命令涉及系统关键目录【%s】，已被系统拦截", path)
-		}
-	}
-
-	// ============ 检查根目录操作 ============
-	rootPatterns := []string{
-		"/*", "/ ", "/\t", "/\n", "/;", "/|", "/&",
-	}
-
-	for _, pattern := range rootPatterns {
-		if strings.Contains(contentLower, pattern) {
-			return fmt.Errorf("命令涉及根目录操作，已被系统拦截")
-		}
-	}
-
-	// ============ 检查管道和重定向到系统目录 ============
-	if strings.Contains(contentCompact, ">/etc") ||
-	   strings.Contains(contentCompact, ">>/etc") ||
-	   strings.Contains(contentCompact, ">/usr") ||
-	   strings.Contains(contentCompact, ">/var") ||
-	   strings.Contains(contentCompact, ">/boot") {
-		return fmt.Errorf("命令包含重定向到系统目录的操作，已被系统拦截")
-	}
-
-	// ============ 检查别名劫持 ============
-	if strings.Contains(contentLower, "alias ") {
-		return fmt.Errorf("命令包含别名定义，可能导致命令劫持，已被系统拦截")
-	}
-
-	// ============ 检查环境变量污染 ============
-	dangerousEnvPatterns := []string{
-		"export path=",
-		"export ld_library_path=",
-		"export ld_preload=",
+	// 安全检查：按执行人角色和目标主机分组评估命令策略
+	decision, err := h.policy.Evaluate(c.Request.Context(), req.ScriptType, content, service.PolicySubject{UserID: createdBy, HostIDs: req.HostIDs})
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+		return
 	}
-
-	for _, pattern := range dangerousEnvPatterns {
-		if strings.Contains(contentCompact, pattern) {
-			return fmt.Errorf("命令包含环境变量修改，可能导致系统异常，已被系统拦截")
-		}
+	if decision.Action == model.PolicyActionDeny {
+		response.ErrorCode(c, http.StatusForbidden, decision.Reason)
+		return
 	}
-
-	return nil
-}
-
-// executeOnHost 在单个主机上执行任务
-func (h *Handler) executeOnHost(ctx context.Context, hostID uint, scriptType, content string) HostExecutionResult {
-	result := HostExecutionResult{
-		HostID: hostID,
-		Status: "failed",
+	// 命中需审批策略或目标主机属于需审批的分组时，任务进入待审批状态
+	requirement, err := h.approval.Requirement(c.Request.Context(), decision)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	// 获取主机信息
-	var host assetbiz.Host
-	if err := h.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", hostID).First(&host).Error; err != nil {
-		result.Error = fmt.Sprintf("获取主机信息失败: %v", err)
-		return result
+	// 创建任务记录
+	taskName := req.Name
+	if taskName == "" {
+		taskName = fmt.Sprintf("手动执行任务 - %s", time.Now().Format("2006-01-02 15:04:05"))
 	}
 
-	result.HostName = host.Name
-	result.HostIP = host.IP
-
-	// 获取凭证
-	if host.CredentialID == 0 {
-		result.Error = "主机未配置凭证"
-		return result
+	opts := service.ExecuteOptions{
+		ScriptType:   req.ScriptType,
+		Content:      content,
+		Fork:         req.Fork,
+		HostTimeout:  time.Duration(req.Timeout) * time.Second,
+		BatchTimeout: time.Duration(req.BatchTimeout) * time.Second,
+		Rolling:      req.Rolling,
 	}
 
-	var credential assetbiz.Credential
-	if err := h.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", host.CredentialID).First(&credential).Error; err != nil {
-		result.Error = fmt.Sprintf("获取凭证失败: %v", err)
-		return result
+	hostIDsJSON, _ := json.Marshal(req.HostIDs)
+	params := map[string]interface{}{
+		"scriptType":   req.ScriptType,
+		"fork":         req.Fork,
+		"timeout":      req.Timeout,
+		"batchTimeout": req.BatchTimeout,
 	}
-
-	// 解密凭证
-	if err := h.decryptCredential(&credential); err != nil {
-		result.Error = fmt.Sprintf("解密凭证失败: %v", err)
-		return result
+	if req.Rolling != nil {
+		params["rolling"] = req.Rolling
 	}
-
-	// 建立SSH连接
-	sshClient, err := h.createSSHClient(&host, &credential)
-	if err != nil {
-		result.Error = fmt.Sprintf("SSH连接失败: %v", err)
-		return result
+	if rendered != nil {
+		rendered.RecordParameters(params, *req.TemplateID)
+		opts.Secrets = rendered.Secrets
 	}
-	defer sshClient.Close()
-
-	// 创建SSH会话
-	session, err := sshClient.NewSession()
-	if err != nil {
-		result.Error = fmt.Sprintf("创建SSH会话失败: %v", err)
-		return result
+	paramsJSON, _ := json.Marshal(params)
+	jobTask := model.JobTask{
+		Name:        taskName,
+		TemplateID:  req.TemplateID,
+		TaskType:    "manual",
+		Status:      model.JobStatusRunning,
+		TargetHosts: string(hostIDsJSON),
+		Parameters:  string(paramsJSON),
+		CreatedBy:   createdBy,
+		ExecuteTime: ptrTime(time.Now()),
 	}
-	defer session.Close()
-
-	// 根据脚本类型构造执行命令
-	var cmd string
-	if scriptType == "Python" {
-		cmd = fmt.Sprintf("python3 -c %s", shellescape(content))
-	} else {
-		// Shell 脚本
-		cmd = content
+	if requirement != nil {
+		jobTask.Status = model.JobStatusPendingApproval
+		jobTask.ExecuteTime = nil
 	}
 
-	// 执行命令
-	output, err := session.CombinedOutput(cmd)
-	if err != nil {
-		result.Error = fmt.Sprintf("执行失败: %v", err)
-		result.Output = string(output)
-		return result
+	if err := h.db.Create(&jobTask).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, fmt.Sprintf("创建任务记录失败: %v", err))
+		return
 	}
 
-	result.Status = "success"
-	result.Output = string(output)
-	return result
-}
-
-// createSSHClient 创建SSH客户端
-func (h *Handler) createSSHClient(host *assetbiz.Host, credential *assetbiz.Credential) (*ssh.Client, error) {
-	var authMethods []ssh.AuthMethod
-
-	// 根据凭证类型选择认证方式
-	switch credential.Type {
-	case "password":
-		authMethods = append(authMethods, ssh.Password(credential.Password))
-	case "private_key":
-		signer, err := ssh.ParsePrivateKey([]byte(credential.PrivateKey))
+	if requirement != nil {
+		approval, err := h.approval.Submit(c.Request.Context(), &jobTask, requirement, service.PendingExecution{
+			ScriptType:   req.ScriptType,
+			Content:      content,
+			Fork:         req.Fork,
+			Timeout:      req.Timeout,
+			BatchTimeout: req.BatchTimeout,
+			Rolling:      req.Rolling,
+			Secrets:      opts.Secrets,
+		})
 		if err != nil {
-			return nil, fmt.Errorf("解析私钥失败: %w", err)
+			h.db.Model(&jobTask).Updates(map[string]interface{}{"status": model.JobStatusFailed, "error_message": err.Error()})
+			response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+			return
 		}
-		authMethods = append(authMethods, ssh.PublicKeys(signer))
-	default:
-		return nil, fmt.Errorf("不支持的凭证类型: %s", credential.Type)
-	}
-
-	// SSH 配置
-	config := &ssh.ClientConfig{
-		User:            credential.Username,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 生产环境应该验证host key
-		Timeout:         30 * time.Second,
+		response.SuccessWithMessage(c, "任务需要审批，已提交审批", ExecuteTaskResponse{
+			TaskID:     jobTask.ID,
+			Status:     jobTask.Status,
+			ApprovalID: approval.ID,
+			Reason:     approval.Reason,
+		})
+		return
 	}
 
-	// 连接
-	addr := fmt.Sprintf("%s:%d", host.IP, host.Port)
-	client, err := ssh.Dial("tcp", addr, config)
-	if err != nil {
-		return nil, err
-	}
+	// 后台并发执行，结果随各主机完成写入任务记录
+	results := h.executor.Submit(&jobTask, req.HostIDs, opts)
 
-	return client, nil
+	response.Success(c, ExecuteTaskResponse{
+		TaskID:  jobTask.ID,
+		Status:  jobTask.Status,
+		Results: results,
+	})
 }
 
-// decryptCredential 解密凭证
-func (h *Handler) decryptCredential(credential *assetbiz.Credential) error {
-	// 解密密码
-	if credential.Password != "" {
-		decrypted, err := h.decrypt(credential.Password)
-		if err != nil {
-			return fmt.Errorf("解密密码失败: %w", err)
+// currentUser 从context获取当前用户ID和用户名
+func currentUser(c *gin.Context) (uint, string) {
+	var userID uint
+	var username string
+	if v, exists := c.Get("user_id"); exists {
+		if uid, ok := v.(uint); ok {
+			userID = uid
 		}
-		credential.Password = decrypted
 	}
-
-	// 解密私钥
-	if credential.PrivateKey != "" {
-		decrypted, err := h.decrypt(credential.PrivateKey)
-		if err != nil {
-			return fmt.Errorf("解密私钥失败: %w", err)
+	if v, exists := c.Get("username"); exists {
+		if name, ok := v.(string); ok {
+			username = name
 		}
-		credential.PrivateKey = decrypted
 	}
-
-	return nil
-}
-
-// decrypt 解密
-func (h *Handler) decrypt(ciphertext string) (string, error) {
-	if ciphertext == "" {
-		return "", nil
-	}
-
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(h.encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, cipherData := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, cipherData, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
-}
-
-// shellescape 转义shell命令
-func shellescape(s string) string {
-	return "'" + strings.ReplaceAll(s, "'", "'\"'\"'") + "'"
+	return userID, username
 }
 
 // ptrTime 返回时间指针
@@ -1156,7 +919,12 @@ func (h *Handler) ListExecutionHistory(c *gin.Context) {
 	var jobTasks []model.JobTask
 	var total int64
 
-	query := h.db.Model(&model.JobTask{}).Where("deleted_at IS NULL")
+	// 只返回自己创建或目标主机均有查看权限的记录
+	scope := h.jobScope(c)
+	if scope == nil {
+		return
+	}
+	query := h.db.Model(&model.JobTask{}).Where("deleted_at IS NULL").Scopes(scope)
 
 	// 关键词搜索
 	if keyword != "" {
@@ -1180,7 +948,7 @@ func (h *Handler) ListExecutionHistory(c *gin.Context) {
 
 	query.Count(&total)
 	offset := (page - 1) * pageSize
-	query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&jobTasks)
+	query.Omit("output_events").Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&jobTasks)
 
 	// 获取用户信息
 	type UserInfo struct {
@@ -1286,8 +1054,12 @@ func (h *Handler) ListExecutionHistory(c *gin.Context) {
 // @Router /task/execution-history/{id} [get]
 func (h *Handler) GetExecutionHistory(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	scope := h.jobScope(c)
+	if scope == nil {
+		return
+	}
 	var jobTask model.JobTask
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&jobTask).Error; err != nil {
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).Scopes(scope).First(&jobTask).Error; err != nil {
 		response.ErrorCode(c, http.StatusNotFound, "执行记录不存在")
 		return
 	}
@@ -1353,7 +1125,11 @@ func (h *Handler) GetExecutionHistory(c *gin.Context) {
 // @Router /task/execution-history/{id} [delete]
 func (h *Handler) DeleteExecutionHistory(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
-	if err := h.db.Delete(&model.JobTask{}, id).Error; err != nil {
+	scope := h.jobScope(c)
+	if scope == nil {
+		return
+	}
+	if err := h.db.Scopes(scope).Delete(&model.JobTask{}, id).Error; err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "删除失败")
 		return
 	}
@@ -1383,7 +1159,11 @@ func (h *Handler) BatchDeleteExecutionHistory(c *gin.Context) {
 		response.ErrorCode(c, http.StatusBadRequest, "请选择要删除的记录")
 		return
 	}
-	if err := h.db.Delete(&model.JobTask{}, req.IDs).Error; err != nil {
+	scope := h.jobScope(c)
+	if scope == nil {
+		return
+	}
+	if err := h.db.Scopes(scope).Delete(&model.JobTask{}, req.IDs).Error; err != nil {
 		response.ErrorCode(c, http.StatusInternalServerError, "删除失败")
 		return
 	}
@@ -1406,13 +1186,17 @@ func (h *Handler) ExportExecutionHistory(c *gin.Context) {
 	}
 	c.ShouldBindJSON(&req)
 
+	scope := h.jobScope(c)
+	if scope == nil {
+		return
+	}
 	var jobTasks []model.JobTask
-	query := h.db.Model(&model.JobTask{}).Where("deleted_at IS NULL")
+	query := h.db.Model(&model.JobTask{}).Where("deleted_at IS NULL").Scopes(scope)
 
 	if len(req.IDs) > 0 {
 		query = query.Where("id IN ?", req.IDs)
 	}
-	query.Order("created_at DESC").Find(&jobTasks)
+	query.Omit("output_events").Order("created_at DESC").Find(&jobTasks)
 
 	// 获取用户信息
 	type UserInfo struct {
@@ -1467,6 +1251,7 @@ func (h *Handler) ExportExecutionHistory(c *gin.Context) {
 		TargetHostsDisplay string `json:"targetHostsDisplay"`
 		CreatedByName      string `json:"createdByName"`
 		CreatedAt          string `json:"createdAt"`
+		Parameters         string `json:"parameters"`
 		Result             string `json:"result"`
 	}
 
@@ -1479,6 +1264,7 @@ func (h *Handler) ExportExecutionHistory(c *gin.Context) {
 			Status:        task.Status,
 			CreatedByName: userMap[task.CreatedBy],
 			CreatedAt:     task.CreatedAt.Format("2006-01-02 15:04:05"),
+			Parameters:    service.MaskParameters(task.Parameters),
 			Result:        task.Result,
 		}
 
@@ -1510,18 +1296,9 @@ func (h *Handler) ExportExecutionHistory(c *gin.Context) {
 
 // ==================== 文件分发 ====================
 
-// FileDistributionResult 文件分发结果
-type FileDistributionResult struct {
-	HostID   uint   `json:"hostId"`
-	HostName string `json:"hostName"`
-	HostIP   string `json:"hostIp"`
-	Status   string `json:"status"` // success, failed
-	Error    string `json:"error,omitempty"`
-}
-
 // DistributeFiles 文件分发
 // @Summary 文件分发
-// @Description 将文件分发到指定的目标主机
+// @Description 将文件并发分发到指定的目标主机，分发在后台进行，立即返回任务ID，通过任务详情查询各主机结果。文件先写入目标目录下的临时文件，远程校验 SHA-256 并设置属主、权限后原子重命名，未指定属主、权限时沿用被覆盖文件的设置；远程文件校验值一致时跳过上传
 // @Tags 任务管理-文件分发
 // @Accept multipart/form-data
 // @Produce json
@@ -1529,9 +1306,13 @@ type FileDistributionResult struct {
 // @Param files formData file true "上传的文件"
 // @Param targetPath formData string true "目标路径"
 // @Param hostIds formData string true "主机ID列表(JSON数组)"
-// @Success 200 {object} response.Response "分发成功"
+// @Param owner formData string false "属主，user 或 user:group"
+// @Param mode formData string false "八进制权限，如 0644"
+// @Param fork formData int false "并发数，默认10，最大100"
+// @Param timeout formData int false "单台主机超时（秒），默认1800"
+// @Success 200 {object} response.Response "已开始分发"
 // @Failure 400 {object} response.Response "参数错误"
-// @Router /task/distribute-files [post]
+// @Router /task/distribute [post]
 func (h *Handler) DistributeFiles(c *gin.Context) {
 	// 解析表单
 	form, err := c.MultipartForm()
@@ -1546,12 +1327,6 @@ func (h *Handler) DistributeFiles(c *gin.Context) {
 		return
 	}
 
-	targetPath := c.PostForm("targetPath")
-	if targetPath == "" {
-		response.ErrorCode(c, http.StatusBadRequest, "请指定目标路径")
-		return
-	}
-
 	hostIdsStr := c.PostForm("hostIds")
 	if hostIdsStr == "" {
 		response.ErrorCode(c, http.StatusBadRequest, "请选择目标主机")
@@ -1569,6 +1344,18 @@ func (h *Handler) DistributeFiles(c *gin.Context) {
 		return
 	}
 
+	params := service.DistributeParams{
+		TargetPath: c.PostForm("targetPath"),
+		Owner:      strings.TrimSpace(c.PostForm("owner")),
+		Mode:       strings.TrimSpace(c.PostForm("mode")),
+	}
+	params.Fork, _ = strconv.Atoi(c.DefaultPostForm("fork", "0"))
+	params.Timeout, _ = strconv.Atoi(c.DefaultPostForm("timeout", "0"))
+	if err := service.ValidateDistributeParams(&params); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// 从context获取当前用户ID
 	var createdBy uint = 1
 	if userID, exists := c.Get("user_id"); exists {
@@ -1577,25 +1364,27 @@ func (h *Handler) DistributeFiles(c *gin.Context) {
 		}
 	}
 
-	ctx := c.Request.Context()
+	// 权限检查：分发人需要拥有全部目标主机的文件分发权限
+	if !h.requireHostPermission(c, createdBy, hostIDs, rbacbiz.PermissionDistribute) {
+		return
+	}
 
-	// 创建任务记录
-	fileNames := make([]string, 0, len(files))
-	for _, f := range files {
-		fileNames = append(fileNames, f.Filename)
+	// 暂存文件并计算校验值，重试时复用
+	params.Stage, params.Files, err = h.distributor.Stage(files)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
 	}
-	taskName := fmt.Sprintf("文件分发 - %s", time.Now().Format("2006-01-02 15:04:05"))
 
+	// 创建任务记录
+	taskName := fmt.Sprintf("文件分发 - %s", time.Now().Format("2006-01-02 15:04:05"))
 	hostIDsJSON, _ := json.Marshal(hostIDs)
-	paramsJSON, _ := json.Marshal(map[string]interface{}{
-		"files":      fileNames,
-		"targetPath": targetPath,
-	})
+	paramsJSON, _ := json.Marshal(params)
 
 	jobTask := model.JobTask{
 		Name:        taskName,
 		TaskType:    "file",
-		Status:      "running",
+		Status:      model.JobStatusRunning,
 		TargetHosts: string(hostIDsJSON),
 		Parameters:  string(paramsJSON),
 		CreatedBy:   createdBy,
@@ -1603,120 +1392,109 @@ func (h *Handler) DistributeFiles(c *gin.Context) {
 	}
 
 	if err := h.db.Create(&jobTask).Error; err != nil {
+		h.distributor.Cleanup(params.Stage)
 		response.ErrorCode(c, http.StatusInternalServerError, "创建任务记录失败: "+err.Error())
 		return
 	}
 
-	// 执行分发
-	results := make([]FileDistributionResult, 0, len(hostIDs))
-	allSuccess := true
-
-	for _, hostID := range hostIDs {
-		result := h.distributeToHost(ctx, hostID, files, targetPath)
-		results = append(results, result)
-		if result.Status != "success" {
-			allSuccess = false
-		}
-	}
-
-	// 更新任务状态
-	resultJSON, _ := json.Marshal(results)
-	if allSuccess {
-		jobTask.Status = "success"
-	} else {
-		jobTask.Status = "failed"
-	}
-	jobTask.Result = string(resultJSON)
-	h.db.Save(&jobTask)
+	// 分发在后台进行，不受请求超时或客户端断开影响
+	h.distributor.Submit(&jobTask, &params, hostIDs, nil)
 
 	response.Success(c, gin.H{
-		"taskId":  jobTask.ID,
-		"results": results,
+		"taskId": jobTask.ID,
+		"status": jobTask.Status,
 	})
 }
 
-// distributeToHost 分发文件到单个主机
-func (h *Handler) distributeToHost(ctx context.Context, hostID uint, files []*multipart.FileHeader, targetPath string) FileDistributionResult {
-	result := FileDistributionResult{
-		HostID: hostID,
-		Status: "failed",
+// RetryDistribution 重试文件分发
+// @Summary 重试文件分发
+// @Description 使用暂存的文件在后台向上次分发失败的主机重新分发，已成功的主机不会重复上传。暂存文件保留24小时，仅任务创建人或管理员可重试
+// @Tags 任务管理-文件分发
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "分发任务ID"
+// @Success 200 {object} response.Response "已开始重试"
+// @Failure 400 {object} response.Response "没有失败的主机或暂存文件已过期"
+// @Failure 403 {object} response.Response "无权操作该任务"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "任务正在执行"
+// @Router /task/distribute/{id}/retry [post]
+func (h *Handler) RetryDistribution(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的任务ID")
+		return
 	}
 
-	// 获取主机信息
-	var host assetbiz.Host
-	if err := h.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", hostID).First(&host).Error; err != nil {
-		result.Error = fmt.Sprintf("获取主机信息失败: %v", err)
-		return result
+	scope := h.jobScope(c)
+	if scope == nil {
+		return
 	}
-
-	result.HostName = host.Name
-	result.HostIP = host.IP
-
-	// 获取凭证
-	if host.CredentialID == 0 {
-		result.Error = "主机未配置凭证"
-		return result
+	var jobTask model.JobTask
+	if err := h.db.Where("id = ? AND task_type = ? AND deleted_at IS NULL", id, "file").Scopes(scope).First(&jobTask).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "分发任务不存在")
+		return
 	}
-
-	var credential assetbiz.Credential
-	if err := h.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", host.CredentialID).First(&credential).Error; err != nil {
-		result.Error = fmt.Sprintf("获取凭证失败: %v", err)
-		return result
+	if !h.requireJobOwner(c, &jobTask) {
+		return
 	}
 
-	// 解密凭证
-	if err := h.decryptCredential(&credential); err != nil {
-		result.Error = fmt.Sprintf("解密凭证失败: %v", err)
-		return result
+	var params service.DistributeParams
+	var previous []service.FileDistributionResult
+	if err := json.Unmarshal([]byte(jobTask.Parameters), &params); err != nil || params.Stage == "" {
+		response.ErrorCode(c, http.StatusBadRequest, "该任务不支持重试，请重新分发")
+		return
 	}
+	json.Unmarshal([]byte(jobTask.Result), &previous)
 
-	// 建立SSH连接
-	sshClient, err := h.createSSHClient(&host, &credential)
-	if err != nil {
-		result.Error = fmt.Sprintf("SSH连接失败: %v", err)
-		return result
+	// 目标主机中没有成功结果的都需要重试（包括服务中断前尚未写入结果的主机）
+	var hostIDs []uint
+	json.Unmarshal([]byte(jobTask.TargetHosts), &hostIDs)
+	done := make(map[uint]bool, len(previous))
+	for _, r := range previous {
+		if r.Status != service.DistributeStatusFailed {
+			done[r.HostID] = true
+		}
 	}
-	defer sshClient.Close()
-
-	// 创建SFTP客户端
-	sftpClient, err := sftp.NewClient(sshClient)
-	if err != nil {
-		result.Error = fmt.Sprintf("创建SFTP客户端失败: %v", err)
-		return result
+	retryIDs := make([]uint, 0, len(hostIDs))
+	for _, hostID := range hostIDs {
+		if !done[hostID] {
+			retryIDs = append(retryIDs, hostID)
+		}
 	}
-	defer sftpClient.Close()
-
-	// 确保目标目录存在
-	if err := sftpClient.MkdirAll(targetPath); err != nil {
-		// 目录可能已存在，忽略错误
+	if len(retryIDs) == 0 {
+		response.ErrorCode(c, http.StatusBadRequest, "没有需要重试的主机")
+		return
+	}
+	if userID, _ := currentUser(c); !h.requireHostPermission(c, userID, retryIDs, rbacbiz.PermissionDistribute) {
+		return
+	}
+	if !h.distributor.StageExists(params.Stage) {
+		response.ErrorCode(c, http.StatusBadRequest, service.ErrStageExpired.Error())
+		return
 	}
 
-	// 上传每个文件
-	for _, fileHeader := range files {
-		srcFile, err := fileHeader.Open()
-		if err != nil {
-			result.Error = fmt.Sprintf("打开文件 %s 失败: %v", fileHeader.Filename, err)
-			return result
-		}
-
-		remotePath := targetPath + "/" + fileHeader.Filename
-		dstFile, err := sftpClient.Create(remotePath)
-		if err != nil {
-			srcFile.Close()
-			result.Error = fmt.Sprintf("创建远程文件 %s 失败: %v", remotePath, err)
-			return result
-		}
-
-		_, err = io.Copy(dstFile, srcFile)
-		srcFile.Close()
-		dstFile.Close()
+	// 只有已结束的任务可以重试，状态原子切换防止并发重试
+	result := h.db.Model(&model.JobTask{}).
+		Where("id = ? AND status <> ?", jobTask.ID, model.JobStatusRunning).
+		Updates(map[string]interface{}{"status": model.JobStatusRunning, "error_message": ""})
+	if result.Error != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.ErrorCode(c, http.StatusConflict, "任务正在执行")
+		return
+	}
 
-		if err != nil {
-			result.Error = fmt.Sprintf("传输文件 %s 失败: %v", fileHeader.Filename, err)
-			return result
-		}
+	if previous == nil {
+		previous = []service.FileDistributionResult{}
 	}
+	h.distributor.Submit(&jobTask, &params, retryIDs, previous)
 
-	result.Status = "success"
-	return result
+	response.Success(c, gin.H{
+		"taskId": jobTask.ID,
+		"status": model.JobStatusRunning,
+	})
 }
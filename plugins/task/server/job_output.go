@@ -0,0 +1,234 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/spf13/viper"
+	"github.com/ydcloud-dy/opshub/internal/conf"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"github.com/ydcloud-dy/opshub/plugins/task/service"
+	"go.uber.org/zap"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     checkOrigin,
+}
+
+// checkOrigin 校验WebSocket请求来源，防止跨站劫持
+// 只允许同源请求，以及配置的外部访问地址（server.external_url）和前端地址（server.frontend_url）；
+// 不携带 Origin 的非浏览器客户端不受跨站请求影响，直接放行
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	var cfg conf.ServerConfig
+	if err := viper.UnmarshalKey("server", &cfg); err != nil {
+		return false
+	}
+	for _, allowed := range []string{cfg.ExternalURL, cfg.GetFrontendURL()} {
+		if a, err := url.Parse(allowed); err == nil && a.Host != "" && strings.EqualFold(u.Host, a.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// outputWriteWait 单次写入超时
+	outputWriteWait = 10 * time.Second
+	// outputPingPeriod 心跳间隔
+	outputPingPeriod = 30 * time.Second
+)
+
+// StreamJobOutput 实时订阅任务输出
+// @Summary 订阅任务输出
+// @Description 通过WebSocket实时推送各主机的stdout/stderr（逐行）及状态变化。连接建立后先回放已缓存的输出，重连时可通过since参数只获取之后的事件。仅任务创建人或管理员可以订阅
+// @Tags 任务管理-任务作业
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Param since query int false "只推送seq大于该值的事件" default(0)
+// @Param token query string false "认证token（WebSocket无法携带Header时使用）"
+// @Router /task/jobs/{id}/output [get]
+func (h *Handler) StreamJobOutput(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "无效的任务ID")
+		return
+	}
+	since, _ := strconv.ParseUint(c.DefaultQuery("since", "0"), 10, 64)
+
+	var jobTask model.JobTask
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&jobTask).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "任务不存在")
+		return
+	}
+	if !h.requireJobOwner(c, &jobTask) {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.Error("WebSocket升级失败", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	replay, events, unsubscribe, ok := h.executor.Subscribe(jobTask.ID, since)
+	defer unsubscribe()
+
+	if !ok {
+		// 输出流已不在内存中，回放任务结束时保存的输出事件
+		replay = replayFromResult(&jobTask, since)
+	}
+
+	for _, ev := range replay {
+		if err := writeOutputEvent(conn, ev); err != nil {
+			return
+		}
+	}
+	if events == nil {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(outputWriteWait))
+		return
+	}
+
+	// 读取客户端消息以感知连接断开
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(outputPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev, open := <-events:
+			if !open {
+				// 任务结束，或消费过慢被断开（客户端可携带最后的seq重连补齐）
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(outputWriteWait))
+				return
+			}
+			if err := writeOutputEvent(conn, ev); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(outputWriteWait)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// writeOutputEvent 发送一条输出事件
+func writeOutputEvent(conn *websocket.Conn, ev service.OutputEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(outputWriteWait))
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// replayFromResult 根据任务记录构造回放事件
+// 优先使用任务结束时保存的输出事件，seq 与实时推送时一致；
+// 未保存输出事件（如服务重启中断的任务）时根据各主机结果重新生成
+func replayFromResult(jobTask *model.JobTask, since uint64) []service.OutputEvent {
+	events := make([]service.OutputEvent, 0)
+	if jobTask.OutputEvents != "" {
+		var saved []service.OutputEvent
+		if err := json.Unmarshal([]byte(jobTask.OutputEvents), &saved); err == nil && len(saved) > 0 {
+			for _, ev := range saved {
+				// 结束事件总是发送，避免客户端携带较大的 seq 重连时无法感知任务已结束
+				if ev.Seq > since || ev.Type == service.EventDone {
+					events = append(events, ev)
+				}
+			}
+			return events
+		}
+	}
+
+	var results []model.HostExecutionResult
+	if jobTask.Result != "" {
+		json.Unmarshal([]byte(jobTask.Result), &results)
+	}
+
+	var seq uint64
+	add := func(ev service.OutputEvent) {
+		seq++
+		ev.Seq = seq
+		ev.JobID = jobTask.ID
+		if ev.Seq > since {
+			events = append(events, ev)
+		}
+	}
+
+	for _, r := range results {
+		if r.Output != "" {
+			for _, line := range strings.Split(strings.TrimRight(r.Output, "\n"), "\n") {
+				add(service.OutputEvent{HostID: r.HostID, HostName: r.HostName, Type: service.EventStdout, Data: line})
+			}
+		}
+		if r.Error != "" {
+			add(service.OutputEvent{HostID: r.HostID, HostName: r.HostName, Type: service.EventStderr, Data: r.Error})
+		}
+		add(service.OutputEvent{HostID: r.HostID, HostName: r.HostName, Type: service.EventStatus, Data: r.Status})
+	}
+	// 结束事件总是发送，避免客户端携带较大的 seq 重连时无法感知任务已结束
+	if jobTask.Status != model.JobStatusRunning && jobTask.Status != model.JobStatusPending && jobTask.Status != model.JobStatusPendingApproval {
+		events = append(events, service.OutputEvent{
+			Seq:   seq + 1,
+			JobID: jobTask.ID,
+			Type:  service.EventDone,
+			Data:  jobTask.Status,
+		})
+	}
+	return events
+}
@@ -0,0 +1,317 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"github.com/ydcloud-dy/opshub/plugins/task/service"
+)
+
+// ==================== 命令策略 ====================
+
+// CommandPolicyRequest 创建/更新命令策略请求
+type CommandPolicyRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Description   string `json:"description"`
+	Action        string `json:"action" binding:"required"` // allow, deny, require_approval
+	MatchType     string `json:"matchType"`                 // command, redirect, pipe_shell, dynamic, env, script，默认 command
+	Command       string `json:"command"`                   // 命令名/变量名，支持通配符，多个以逗号分隔
+	Pattern       string `json:"pattern"`                   // 正则表达式
+	RoleIDs       []uint `json:"roleIds"`                   // 为空表示所有角色
+	AssetGroupIDs []uint `json:"assetGroupIds"`             // 为空表示所有分组
+	Priority      *int   `json:"priority"`                  // 数值越小越优先，默认100
+	Status        *int   `json:"status"`                    // 0-禁用, 1-启用，默认启用
+}
+
+// PolicyEvaluateRequest 策略评估请求
+type PolicyEvaluateRequest struct {
+	Content    string `json:"content" binding:"required"`
+	ScriptType string `json:"scriptType"` // Shell, Python，默认 Shell
+	HostIDs    []uint `json:"hostIds"`
+	UserID     uint   `json:"userId"` // 按指定用户的角色评估，默认当前用户，仅管理员可指定其他用户
+}
+
+// ListCommandPolicies 获取命令策略列表
+// @Summary 获取命令策略列表
+// @Description 分页获取命令策略列表，按优先级排序，支持按关键词、动作、匹配类型和状态筛选
+// @Tags 任务管理-命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param keyword query string false "搜索关键词"
+// @Param action query string false "动作"
+// @Param matchType query string false "匹配类型"
+// @Param status query int false "状态"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /task/policies [get]
+func (h *Handler) ListCommandPolicies(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	keyword := c.Query("keyword")
+	action := c.Query("action")
+	matchType := c.Query("matchType")
+	status := c.Query("status")
+
+	var policies []model.CommandPolicy
+	var total int64
+
+	query := h.db.Model(&model.CommandPolicy{}).Where("deleted_at IS NULL")
+	if keyword != "" {
+		query = query.Where("name LIKE ? OR command LIKE ? OR description LIKE ?", "%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if matchType != "" {
+		query = query.Where("match_type = ?", matchType)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+	offset := (page - 1) * pageSize
+	query.Order("priority ASC, id ASC").Limit(pageSize).Offset(offset).Find(&policies)
+
+	response.Success(c, gin.H{
+		"list":     policies,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetCommandPolicy 获取命令策略详情
+// @Summary 获取命令策略详情
+// @Description 获取指定命令策略的详细信息
+// @Tags 任务管理-命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 404 {object} response.Response "策略不存在"
+// @Router /task/policies/{id} [get]
+func (h *Handler) GetCommandPolicy(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var policy model.CommandPolicy
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&policy).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "策略不存在")
+		return
+	}
+	response.Success(c, policy)
+}
+
+// CreateCommandPolicy 创建命令策略
+// @Summary 创建命令策略
+// @Description 创建放行、拦截或需要审批的命令策略，可限定生效的角色和资产分组
+// @Tags 任务管理-命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body CommandPolicyRequest true "策略信息"
+// @Success 200 {object} response.Response "创建成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 403 {object} response.Response "权限不足"
+// @Router /task/policies [post]
+func (h *Handler) CreateCommandPolicy(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	var req CommandPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	policy := model.CommandPolicy{Priority: 100, Status: 1}
+	if !applyPolicyRequest(c, &policy, &req) {
+		return
+	}
+	policy.CreatedBy, _ = currentUser(c)
+
+	if err := h.db.Create(&policy).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "创建失败: "+err.Error())
+		return
+	}
+	response.Success(c, policy)
+}
+
+// UpdateCommandPolicy 更新命令策略
+// @Summary 更新命令策略
+// @Description 更新指定命令策略，保存后立即生效；内置策略只能修改名称、描述和优先级，不能禁用
+// @Tags 任务管理-命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Param body body CommandPolicyRequest true "策略信息"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "策略不存在"
+// @Router /task/policies/{id} [put]
+func (h *Handler) UpdateCommandPolicy(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var policy model.CommandPolicy
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&policy).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "策略不存在")
+		return
+	}
+
+	var req CommandPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	saved := policy
+	if !applyPolicyRequest(c, &policy, &req) {
+		return
+	}
+	if err := service.CheckBuiltinPolicyUpdate(&saved, &policy); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.db.Save(&policy).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "更新失败: "+err.Error())
+		return
+	}
+	response.Success(c, policy)
+}
+
+// DeleteCommandPolicy 删除命令策略
+// @Summary 删除命令策略
+// @Description 删除自定义命令策略，内置策略不能删除
+// @Tags 任务管理-命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 400 {object} response.Response "内置策略不能删除"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "策略不存在"
+// @Router /task/policies/{id} [delete]
+func (h *Handler) DeleteCommandPolicy(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var policy model.CommandPolicy
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&policy).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "策略不存在")
+		return
+	}
+	if policy.Builtin {
+		response.ErrorCode(c, http.StatusBadRequest, "内置策略不能删除，如需放行请创建优先级更高的自定义策略")
+		return
+	}
+
+	now := time.Now()
+	if err := h.db.Model(&policy).Updates(map[string]interface{}{"deleted_at": &now, "status": 0}).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "删除失败: "+err.Error())
+		return
+	}
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// EvaluateCommandPolicy 评估脚本命中的策略
+// @Summary 评估命令策略
+// @Description 解析脚本并按用户角色和目标主机分组评估策略，返回最终结果、命中的策略及解析出的全部命令，不执行脚本；仅管理员可按其他用户评估
+// @Tags 任务管理-命令策略
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body PolicyEvaluateRequest true "评估参数"
+// @Success 200 {object} response.Response "评估成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 403 {object} response.Response "权限不足"
+// @Router /task/policies/evaluate [post]
+func (h *Handler) EvaluateCommandPolicy(c *gin.Context) {
+	var req PolicyEvaluateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	userID, _ := currentUser(c)
+	if req.UserID == 0 {
+		req.UserID = userID
+	}
+	// 按其他用户评估会暴露其角色及可绕过的策略，仅限管理员
+	if req.UserID != userID && !h.requireAdmin(c) {
+		return
+	}
+	if req.ScriptType == "" {
+		req.ScriptType = "Shell"
+	}
+
+	decision, err := h.policy.Evaluate(c.Request.Context(), req.ScriptType, req.Content, service.PolicySubject{UserID: req.UserID, HostIDs: req.HostIDs})
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.Success(c, decision)
+}
+
+// applyPolicyRequest 校验请求并填充策略字段，校验失败时写入错误响应并返回 false
+func applyPolicyRequest(c *gin.Context, policy *model.CommandPolicy, req *CommandPolicyRequest) bool {
+	if req.RoleIDs == nil {
+		req.RoleIDs = []uint{}
+	}
+	if req.AssetGroupIDs == nil {
+		req.AssetGroupIDs = []uint{}
+	}
+	roleIDsJSON, _ := json.Marshal(req.RoleIDs)
+	groupIDsJSON, _ := json.Marshal(req.AssetGroupIDs)
+
+	policy.Name = req.Name
+	policy.Description = req.Description
+	policy.Action = req.Action
+	policy.MatchType = req.MatchType
+	policy.Command = req.Command
+	policy.Pattern = req.Pattern
+	policy.RoleIDs = string(roleIDsJSON)
+	policy.AssetGroupIDs = string(groupIDsJSON)
+	if req.Priority != nil {
+		policy.Priority = *req.Priority
+	}
+	if req.Status != nil {
+		policy.Status = *req.Status
+	}
+
+	if err := service.ValidatePolicy(policy); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
@@ -20,13 +20,25 @@
 package server
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/internal/plugin"
+	rbacService "github.com/ydcloud-dy/opshub/internal/service/rbac"
 	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"github.com/ydcloud-dy/opshub/plugins/task/service"
 	"gorm.io/gorm"
 )
 
-func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
-	handler := NewHandler(db)
+func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB, executor *service.Executor, scheduler *service.Scheduler, ansibleRunner *service.AnsibleRunner) {
+	handler := NewHandler(db, executor, scheduler, ansibleRunner)
+
+	// 执行、模板、文件分发对应各自菜单，其余功能归属任务中心顶级菜单
+	executeCode := plugin.MenuCode("task", "/task/execute")
+	distributeCode := plugin.MenuCode("task", "/task/file-distribution")
+	templateCode := plugin.MenuCode("task", "/task/templates")
+	rootCode := plugin.RootMenuCode("task")
 
 	// 任务插件路由组 - 使用 /task 前缀
 	taskGroup := router.Group("/task")
@@ -36,16 +48,33 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
 
 		// 文件分发
 		taskGroup.POST("/distribute", handler.DistributeFiles)
+		taskGroup.POST("/distribute/:id/retry", handler.RetryDistribution)
+		rbacService.DeclareRoutes(taskGroup,
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/execute", Code: executeCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/distribute", Code: distributeCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/distribute/:id/retry", Code: distributeCode},
+		)
 
 		// 任务作业
 		jobs := taskGroup.Group("/jobs")
 		{
 			jobs.GET("", handler.ListJobTasks)
 			jobs.GET("/:id", handler.GetJobTask)
+			jobs.GET("/:id/output", handler.StreamJobOutput)
+			jobs.POST("/:id/cancel", handler.CancelJobTask)
 			jobs.POST("", handler.CreateJobTask)
 			jobs.PUT("/:id", handler.UpdateJobTask)
 			jobs.DELETE("/:id", handler.DeleteJobTask)
 		}
+		rbacService.DeclareRoutes(jobs,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: executeCode},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: executeCode},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/output", Code: executeCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/cancel", Code: executeCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: executeCode},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: executeCode},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: executeCode},
+		)
 
 		// 任务模板
 		templates := taskGroup.Group("/templates")
@@ -57,16 +86,108 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
 			templates.PUT("/:id", handler.UpdateJobTemplate)
 			templates.DELETE("/:id", handler.DeleteJobTemplate)
 		}
+		rbacService.DeclareRoutes(templates,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: templateCode},
+			// 模板下拉列表在执行页使用，登录即可读取
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/all", Code: rbacbiz.PermissionLoginOnly},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: templateCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: templateCode},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: templateCode},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: templateCode},
+		)
+
+		// 定时任务
+		schedules := taskGroup.Group("/schedules")
+		{
+			schedules.GET("", handler.ListJobSchedules)
+			schedules.POST("/preview", handler.PreviewJobSchedule)
+			schedules.GET("/:id", handler.GetJobSchedule)
+			schedules.GET("/:id/next-runs", handler.GetJobScheduleNextRuns)
+			schedules.POST("/:id/run", handler.RunJobSchedule)
+			schedules.POST("", handler.CreateJobSchedule)
+			schedules.PUT("/:id", handler.UpdateJobSchedule)
+			schedules.DELETE("/:id", handler.DeleteJobSchedule)
+		}
+		rbacService.DeclareRoutes(schedules,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/preview", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id/next-runs", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/run", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: rootCode},
+		)
+
+		// 命令策略
+		policies := taskGroup.Group("/policies")
+		{
+			policies.GET("", handler.ListCommandPolicies)
+			policies.POST("/evaluate", handler.EvaluateCommandPolicy)
+			policies.GET("/:id", handler.GetCommandPolicy)
+			policies.POST("", handler.CreateCommandPolicy)
+			policies.PUT("/:id", handler.UpdateCommandPolicy)
+			policies.DELETE("/:id", handler.DeleteCommandPolicy)
+		}
+		rbacService.DeclareRoutes(policies,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/evaluate", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: rootCode},
+		)
+
+		// 任务审批
+		approvals := taskGroup.Group("/approvals")
+		{
+			approvals.GET("", handler.ListJobApprovals)
+			approvals.GET("/:id", handler.GetJobApproval)
+			approvals.POST("/:id/approve", handler.ApproveJobApproval)
+			approvals.POST("/:id/reject", handler.RejectJobApproval)
+			approvals.POST("/:id/comments", handler.CommentJobApproval)
+		}
+		rbacService.DeclareRoutes(approvals,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/approve", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/reject", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/comments", Code: rootCode},
+		)
+
+		// 审批规则
+		approvalRules := taskGroup.Group("/approval-rules")
+		{
+			approvalRules.GET("", handler.ListApprovalRules)
+			approvalRules.POST("", handler.CreateApprovalRule)
+			approvalRules.PUT("/:id", handler.UpdateApprovalRule)
+			approvalRules.DELETE("/:id", handler.DeleteApprovalRule)
+		}
+		rbacService.DeclareRoutes(approvalRules,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: rootCode},
+		)
 
 		// Ansible任务
 		ansible := taskGroup.Group("/ansible")
 		{
 			ansible.GET("", handler.ListAnsibleTasks)
 			ansible.GET("/:id", handler.GetAnsibleTask)
+			ansible.POST("/:id/run", handler.RunAnsibleTask)
 			ansible.POST("", handler.CreateAnsibleTask)
 			ansible.PUT("/:id", handler.UpdateAnsibleTask)
 			ansible.DELETE("/:id", handler.DeleteAnsibleTask)
 		}
+		rbacService.DeclareRoutes(ansible,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/:id/run", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPut, Path: "/:id", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: rootCode},
+		)
 
 		// 执行记录
 		executionHistory := taskGroup.Group("/execution-history")
@@ -77,6 +198,13 @@ func RegisterRoutes(router *gin.RouterGroup, db *gorm.DB) {
 			executionHistory.POST("/batch-delete", handler.BatchDeleteExecutionHistory)
 			executionHistory.POST("/export", handler.ExportExecutionHistory)
 		}
+		rbacService.DeclareRoutes(executionHistory,
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodGet, Path: "/:id", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodDelete, Path: "/:id", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/batch-delete", Code: rootCode},
+			rbacbiz.RoutePermission{Method: http.MethodPost, Path: "/export", Code: rootCode},
+		)
 	}
 }
 
@@ -85,6 +213,11 @@ func AutoMigrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&model.JobTask{},
 		&model.JobTemplate{},
+		&model.JobSchedule{},
 		&model.AnsibleTask{},
+		&model.CommandPolicy{},
+		&model.JobApproval{},
+		&model.JobApprovalRecord{},
+		&model.ApprovalRule{},
 	)
 }
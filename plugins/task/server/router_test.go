@@ -0,0 +1,45 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+)
+
+func TestRegisterRoutesDeclarePermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	RegisterRoutes(engine.Group("/api/v1/plugins"), nil, nil, nil, nil)
+
+	routes := make([]rbacbiz.RoutePermission, 0)
+	for _, r := range engine.Routes() {
+		routes = append(routes, rbacbiz.RoutePermission{Method: r.Method, Path: r.Path})
+	}
+	if len(routes) == 0 {
+		t.Fatal("未注册任何路由")
+	}
+	if unmapped := rbacbiz.UnmappedRoutes(routes); len(unmapped) != 0 {
+		t.Errorf("未登记权限的接口: %+v", unmapped)
+	}
+}
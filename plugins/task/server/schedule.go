@@ -0,0 +1,469 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/response"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"github.com/ydcloud-dy/opshub/plugins/task/service"
+)
+
+// ==================== 定时任务 ====================
+
+// JobScheduleRequest 创建/更新定时任务请求
+type JobScheduleRequest struct {
+	Name          string                   `json:"name" binding:"required"`
+	Description   string                   `json:"description"`
+	CronExpr      string                   `json:"cronExpr" binding:"required"`
+	Timezone      string                   `json:"timezone"` // 默认 Asia/Shanghai
+	TemplateID    uint                     `json:"templateId" binding:"required"`
+	HostIDs       []uint                   `json:"hostIds"`
+	GroupIDs      []uint                   `json:"groupIds"`
+	Variables     map[string]interface{}   `json:"variables"`     // secret 变量传入脱敏值时保留原值
+	ScriptType    string                   `json:"scriptType"`    // Shell, Python，默认 Shell
+	OverlapPolicy string                   `json:"overlapPolicy"` // skip, queue, parallel，默认 skip
+	Fork          int                      `json:"fork"`
+	Timeout       int                      `json:"timeout"`
+	BatchTimeout  int                      `json:"batchTimeout"` // 整批任务超时（秒），默认3600
+	Rolling       *service.RollingStrategy `json:"rolling"`      // 滚动执行策略，为空时所有主机按并发数一次执行
+	Status        *int                     `json:"status"`       // 0-禁用, 1-启用，默认启用
+}
+
+// SchedulePreviewRequest 执行时间预览请求
+type SchedulePreviewRequest struct {
+	CronExpr string `json:"cronExpr" binding:"required"`
+	Timezone string `json:"timezone"`
+	Count    int    `json:"count"` // 预览条数，默认5，最大50
+}
+
+// ListJobSchedules 获取定时任务列表
+// @Summary 获取定时任务列表
+// @Description 分页获取定时任务列表，支持按关键词、状态筛选
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param pageSize query int false "每页数量" default(10)
+// @Param keyword query string false "搜索关键词"
+// @Param status query int false "状态"
+// @Success 200 {object} response.Response "获取成功"
+// @Router /task/schedules [get]
+func (h *Handler) ListJobSchedules(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	keyword := c.Query("keyword")
+	status := c.Query("status")
+
+	var schedules []*model.JobSchedule
+	var total int64
+
+	query := h.db.Model(&model.JobSchedule{}).Where("deleted_at IS NULL")
+	if keyword != "" {
+		query = query.Where("name LIKE ?", "%"+keyword+"%")
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	query.Count(&total)
+	offset := (page - 1) * pageSize
+	query.Order("created_at DESC").Limit(pageSize).Offset(offset).Find(&schedules)
+	for _, schedule := range schedules {
+		h.maskScheduleVariables(schedule)
+	}
+
+	response.Success(c, gin.H{
+		"list":     schedules,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetJobSchedule 获取定时任务详情
+// @Summary 获取定时任务详情
+// @Description 获取指定定时任务的详细信息
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "定时任务ID"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 404 {object} response.Response "定时任务不存在"
+// @Router /task/schedules/{id} [get]
+func (h *Handler) GetJobSchedule(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var schedule model.JobSchedule
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&schedule).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "定时任务不存在")
+		return
+	}
+	h.maskScheduleVariables(&schedule)
+	response.Success(c, schedule)
+}
+
+// CreateJobSchedule 创建定时任务
+// @Summary 创建定时任务
+// @Description 基于任务模板创建定时任务，按cron表达式（支持时区）在目标主机/分组上周期执行
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body JobScheduleRequest true "定时任务信息"
+// @Success 200 {object} response.Response "创建成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 403 {object} response.Response "命令被拦截"
+// @Router /task/schedules [post]
+func (h *Handler) CreateJobSchedule(c *gin.Context) {
+	var req JobScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	var schedule model.JobSchedule
+	if !h.applyScheduleRequest(c, &schedule, &req) {
+		return
+	}
+	schedule.CreatedBy, _ = currentUser(c)
+
+	if err := h.db.Create(&schedule).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "创建失败: "+err.Error())
+		return
+	}
+	if err := h.scheduler.Reload(schedule.ID); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "注册定时任务失败: "+err.Error())
+		return
+	}
+	h.db.First(&schedule, schedule.ID)
+	h.maskScheduleVariables(&schedule)
+	response.Success(c, schedule)
+}
+
+// UpdateJobSchedule 更新定时任务
+// @Summary 更新定时任务
+// @Description 更新指定定时任务，修改后立即按新的cron表达式重新调度
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "定时任务ID"
+// @Param body body JobScheduleRequest true "定时任务信息"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 404 {object} response.Response "定时任务不存在"
+// @Router /task/schedules/{id} [put]
+func (h *Handler) UpdateJobSchedule(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var schedule model.JobSchedule
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&schedule).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "定时任务不存在")
+		return
+	}
+
+	var req JobScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+	if !h.applyScheduleRequest(c, &schedule, &req) {
+		return
+	}
+
+	if err := h.db.Save(&schedule).Error; err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "更新失败: "+err.Error())
+		return
+	}
+	if err := h.scheduler.Reload(schedule.ID); err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "注册定时任务失败: "+err.Error())
+		return
+	}
+	h.db.First(&schedule, schedule.ID)
+	h.maskScheduleVariables(&schedule)
+	response.Success(c, schedule)
+}
+
+// DeleteJobSchedule 删除定时任务
+// @Summary 删除定时任务
+// @Description 删除定时任务并停止调度，已生成的任务记录保留
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "定时任务ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "定时任务不存在"
+// @Router /task/schedules/{id} [delete]
+func (h *Handler) DeleteJobSchedule(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	now := time.Now()
+	result := h.db.Model(&model.JobSchedule{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{"deleted_at": &now, "status": 0})
+	if result.Error != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, "删除失败: "+result.Error.Error())
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.ErrorCode(c, http.StatusNotFound, "定时任务不存在")
+		return
+	}
+	h.scheduler.Remove(uint(id))
+	response.SuccessWithMessage(c, "删除成功", nil)
+}
+
+// RunJobSchedule 立即执行一次定时任务
+// @Summary 立即执行定时任务
+// @Description 立即触发一次定时任务，同样遵循重叠策略；被跳过或排队时不返回任务ID
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "定时任务ID"
+// @Success 200 {object} response.Response "触发成功"
+// @Failure 404 {object} response.Response "定时任务不存在"
+// @Router /task/schedules/{id}/run [post]
+func (h *Handler) RunJobSchedule(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var schedule model.JobSchedule
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&schedule).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "定时任务不存在")
+		return
+	}
+
+	jobTask, err := h.scheduler.Trigger(schedule.ID)
+	if err != nil {
+		if jobTask != nil {
+			response.ErrorCode(c, http.StatusBadRequest, "触发失败: "+err.Error())
+			return
+		}
+		response.ErrorCode(c, http.StatusInternalServerError, "触发失败: "+err.Error())
+		return
+	}
+	if jobTask == nil {
+		response.SuccessWithMessage(c, "上一次任务仍在执行，本次触发已按重叠策略处理", nil)
+		return
+	}
+	response.Success(c, ExecuteTaskResponse{
+		TaskID: jobTask.ID,
+		Status: jobTask.Status,
+	})
+}
+
+// GetJobScheduleNextRuns 获取定时任务接下来的执行时间
+// @Summary 获取定时任务下次执行时间
+// @Description 按定时任务的cron表达式与时区计算接下来的执行时间
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "定时任务ID"
+// @Param count query int false "预览条数" default(5)
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 404 {object} response.Response "定时任务不存在"
+// @Router /task/schedules/{id}/next-runs [get]
+func (h *Handler) GetJobScheduleNextRuns(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	count, _ := strconv.Atoi(c.DefaultQuery("count", "5"))
+	var schedule model.JobSchedule
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&schedule).Error; err != nil {
+		response.ErrorCode(c, http.StatusNotFound, "定时任务不存在")
+		return
+	}
+
+	runs, err := service.PreviewSchedule(schedule.CronExpr, schedule.Timezone, count)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.Success(c, runs)
+}
+
+// PreviewJobSchedule 预览cron表达式的执行时间
+// @Summary 预览执行时间
+// @Description 保存前校验cron表达式与时区，并返回接下来的执行时间
+// @Tags 任务管理-定时任务
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param body body SchedulePreviewRequest true "cron表达式与时区"
+// @Success 200 {object} response.Response "获取成功"
+// @Failure 400 {object} response.Response "表达式无效"
+// @Router /task/schedules/preview [post]
+func (h *Handler) PreviewJobSchedule(c *gin.Context) {
+	var req SchedulePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "参数错误: "+err.Error())
+		return
+	}
+
+	runs, err := service.PreviewSchedule(req.CronExpr, req.Timezone, req.Count)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	response.Success(c, runs)
+}
+
+// applyScheduleRequest 校验请求并填充定时任务字段，校验失败时写入错误响应并返回 false
+func (h *Handler) applyScheduleRequest(c *gin.Context, schedule *model.JobSchedule, req *JobScheduleRequest) bool {
+	if req.Timezone == "" {
+		req.Timezone = service.DefaultTimezone
+	}
+	if _, err := service.ParseSchedule(req.CronExpr, req.Timezone); err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	if req.ScriptType == "" {
+		req.ScriptType = "Shell"
+	}
+	if req.ScriptType != "Shell" && req.ScriptType != "Python" {
+		response.ErrorCode(c, http.StatusBadRequest, "不支持的脚本类型: "+req.ScriptType)
+		return false
+	}
+
+	if req.OverlapPolicy == "" {
+		req.OverlapPolicy = model.OverlapSkip
+	}
+	switch req.OverlapPolicy {
+	case model.OverlapSkip, model.OverlapQueue, model.OverlapParallel:
+	default:
+		response.ErrorCode(c, http.StatusBadRequest, "不支持的重叠策略: "+req.OverlapPolicy)
+		return false
+	}
+
+	if len(req.HostIDs) == 0 && len(req.GroupIDs) == 0 {
+		response.ErrorCode(c, http.StatusBadRequest, "请选择目标主机或分组")
+		return false
+	}
+
+	if req.BatchTimeout < 0 {
+		response.ErrorCode(c, http.StatusBadRequest, "整批超时时间不能为负数")
+		return false
+	}
+	if req.BatchTimeout == 0 {
+		req.BatchTimeout = int(service.DefaultBatchTimeout / time.Second)
+	}
+	rolling := ""
+	if req.Rolling != nil {
+		if err := service.ValidateRollingStrategy(req.Rolling); err != nil {
+			response.ErrorCode(c, http.StatusBadRequest, err.Error())
+			return false
+		}
+		data, _ := json.Marshal(req.Rolling)
+		rolling = string(data)
+	}
+
+	var template model.JobTemplate
+	if err := h.db.Where("id = ? AND deleted_at IS NULL", req.TemplateID).First(&template).Error; err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, "任务模板不存在")
+		return false
+	}
+
+	// 编辑时 secret 变量回传的是脱敏值，沿用已保存的原值
+	if schedule.Variables != "" && schedule.TemplateID == req.TemplateID {
+		saved := make(map[string]interface{})
+		json.Unmarshal([]byte(schedule.Variables), &saved)
+		for name, value := range req.Variables {
+			if value == service.MaskedValue {
+				req.Variables[name] = saved[name]
+			}
+		}
+	}
+
+	rendered, err := service.RenderTemplate(c.Request.Context(), h.db, &template, req.ScriptType, req.Variables)
+	if err != nil {
+		response.ErrorCode(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	if req.HostIDs == nil {
+		req.HostIDs = []uint{}
+	}
+	if req.GroupIDs == nil {
+		req.GroupIDs = []uint{}
+	}
+	targets, err := service.ResolveTargetHosts(c.Request.Context(), h.db, req.HostIDs, req.GroupIDs)
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	userID, _ := currentUser(c)
+	if !h.requireHostPermission(c, userID, targets, rbacbiz.PermissionExecute) {
+		return false
+	}
+	// 命中需审批的策略时允许保存，每次触发生成的任务都需要审批后才执行
+	decision, err := h.policy.Evaluate(c.Request.Context(), req.ScriptType, rendered.Content, service.PolicySubject{UserID: userID, HostIDs: targets})
+	if err != nil {
+		response.ErrorCode(c, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	if decision.Action == model.PolicyActionDeny {
+		response.ErrorCode(c, http.StatusForbidden, decision.Reason)
+		return false
+	}
+	if req.Variables == nil {
+		req.Variables = map[string]interface{}{}
+	}
+	hostIDsJSON, _ := json.Marshal(req.HostIDs)
+	groupIDsJSON, _ := json.Marshal(req.GroupIDs)
+	variablesJSON, _ := json.Marshal(req.Variables)
+
+	schedule.Name = req.Name
+	schedule.Description = req.Description
+	schedule.CronExpr = req.CronExpr
+	schedule.Timezone = req.Timezone
+	schedule.TemplateID = req.TemplateID
+	schedule.HostIDs = string(hostIDsJSON)
+	schedule.GroupIDs = string(groupIDsJSON)
+	schedule.Variables = string(variablesJSON)
+	schedule.ScriptType = req.ScriptType
+	schedule.OverlapPolicy = req.OverlapPolicy
+	schedule.Fork = req.Fork
+	schedule.Timeout = req.Timeout
+	schedule.BatchTimeout = req.BatchTimeout
+	schedule.Rolling = rolling
+	schedule.Status = 1
+	if req.Status != nil {
+		schedule.Status = *req.Status
+	}
+	return true
+}
+
+// maskScheduleVariables 对定时任务中 secret 变量的取值脱敏
+func (h *Handler) maskScheduleVariables(schedule *model.JobSchedule) {
+	if schedule.Variables == "" {
+		return
+	}
+	var template model.JobTemplate
+	if err := h.db.Where("id = ?", schedule.TemplateID).First(&template).Error; err != nil {
+		return
+	}
+	schedule.Variables = service.MaskVariableValues(&template, schedule.Variables)
+}
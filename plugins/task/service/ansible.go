@@ -0,0 +1,908 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultAnsiblePlaybook 默认的 ansible-playbook 可执行文件
+	defaultAnsiblePlaybook = "ansible-playbook"
+	// defaultAnsibleTimeout Playbook 默认超时时间
+	defaultAnsibleTimeout = 600 * time.Second
+	// maxAnsibleOutput LastRunResult 中保留的输出长度上限
+	maxAnsibleOutput = 256 * 1024
+)
+
+// ErrAnsibleTaskRunning Ansible任务正在执行
+var ErrAnsibleTaskRunning = errors.New("该Ansible任务正在执行中")
+
+var (
+	// recapLineRe 匹配 PLAY RECAP 中的主机统计行
+	recapLineRe = regexp.MustCompile(`^(\S+)\s*:\s*ok=(\d+)\s+changed=(\d+)\s+unreachable=(\d+)\s+failed=(\d+)(?:\s+skipped=(\d+))?(?:\s+rescued=(\d+))?(?:\s+ignored=(\d+))?`)
+	// ansiEscapeRe 匹配终端颜色控制符
+	ansiEscapeRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	// inventoryNameRe 清单中主机名、组名不允许出现的字符
+	inventoryNameRe = regexp.MustCompile(`[^A-Za-z0-9_.\-]+`)
+	// verboseRe 合法的 verbose 级别
+	verboseRe = regexp.MustCompile(`^v{1,4}$`)
+)
+
+// AnsibleRunner Ansible Playbook 执行器
+// 根据 OpsHub 的主机、分组及解密后的凭证生成清单，在独立的临时工作目录中运行 ansible-playbook，
+// 输出按行推送到任务输出流，结束后解析 PLAY RECAP 写入 AnsibleTask.LastRunResult。
+// 执行前与脚本任务一样经过命令策略评估和审批流程
+type AnsibleRunner struct {
+	db           *gorm.DB
+	executor     *Executor
+	policy       *PolicyEngine
+	approvals    *ApprovalService
+	hostPerms    *HostPermission
+	binary       string
+	workRoot     string
+	playbookRoot string
+
+	mu      sync.Mutex
+	running map[uint]uint // AnsibleTask ID -> JobTask ID
+}
+
+// NewAnsibleRunner 创建Ansible执行器
+// 可通过环境变量 OPSHUB_ANSIBLE_PLAYBOOK 指定 ansible-playbook 路径，
+// OPSHUB_ANSIBLE_WORKDIR 指定临时工作目录的父目录，
+// OPSHUB_ANSIBLE_PLAYBOOK_ROOT 指定服务器上 Playbook 文件的根目录，未配置时只能使用内联的 Playbook 内容
+func NewAnsibleRunner(db *gorm.DB, executor *Executor) *AnsibleRunner {
+	binary := os.Getenv("OPSHUB_ANSIBLE_PLAYBOOK")
+	if binary == "" {
+		binary = defaultAnsiblePlaybook
+	}
+	r := &AnsibleRunner{
+		db:           db,
+		executor:     executor,
+		policy:       NewPolicyEngine(db),
+		approvals:    NewApprovalService(db, executor),
+		hostPerms:    NewHostPermission(db),
+		binary:       binary,
+		workRoot:     os.Getenv("OPSHUB_ANSIBLE_WORKDIR"),
+		playbookRoot: os.Getenv("OPSHUB_ANSIBLE_PLAYBOOK_ROOT"),
+		running:      make(map[uint]uint),
+	}
+	// 审批通过后由审批服务通过执行器找回Ansible执行器
+	executor.ansible = r
+	return r
+}
+
+// RecoverStuckTasks 清理因服务重启而中断的Ansible任务
+func (r *AnsibleRunner) RecoverStuckTasks() {
+	result := r.db.Model(&model.AnsibleTask{}).
+		Where("status = ? AND deleted_at IS NULL", model.JobStatusRunning).
+		Update("status", model.JobStatusFailed)
+	if result.Error != nil {
+		logger.Error("清理中断的Ansible任务失败", zap.Error(result.Error))
+	}
+}
+
+// ansibleHost 清单中的一台主机
+type ansibleHost struct {
+	host       assetbiz.Host
+	alias      string
+	credential *assetbiz.Credential
+	knownHost  string // 平台记录的主机密钥（known_hosts 行）
+	gateways   []*ansibleGateway
+	err        error
+}
+
+// ansibleGateway 跳板机及其凭证和主机密钥
+type ansibleGateway struct {
+	host       *assetbiz.Host
+	credential *assetbiz.Credential
+	knownHost  string
+}
+
+// ansibleSnapshot 提交审批时的Ansible任务快照，审批通过后按快照执行
+type ansibleSnapshot struct {
+	Task           model.AnsibleTask `json:"task"`
+	PlaybookSHA256 string            `json:"playbookSha256"`
+}
+
+// Validate 校验Ansible任务的 Playbook 及额外变量
+func (r *AnsibleRunner) Validate(task *model.AnsibleTask) error {
+	_, err := r.load(task)
+	return err
+}
+
+// load 读取并校验 Playbook
+// 服务器上的 Playbook 必须位于 Playbook 根目录下；Playbook 不能以控制节点本机为目标、不能委派执行或改写连接参数，
+// 额外变量不能覆盖 ansible_ 开头的变量
+func (r *AnsibleRunner) load(task *model.AnsibleTask) (*ansiblePlaybook, error) {
+	pb := &ansiblePlaybook{}
+	switch {
+	case strings.TrimSpace(task.PlaybookContent) != "":
+		pb.content = []byte(task.PlaybookContent)
+	case task.PlaybookPath != "":
+		path, err := resolvePlaybookPath(r.playbookRoot, task.PlaybookPath)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取Playbook失败: %w", err)
+		}
+		pb.path, pb.content = path, data
+	default:
+		return nil, errors.New("Playbook内容不能为空")
+	}
+
+	commands, err := checkPlaybook(pb.content)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExtraVars(task.ExtraVars); err != nil {
+		return nil, err
+	}
+	pb.commands = commands
+	return pb, nil
+}
+
+// Run 执行Ansible任务，返回本次执行对应的 JobTask
+// Playbook 中 shell、command 等模块执行的命令按执行人角色和目标主机分组评估命令策略，命中拦截策略时返回错误，
+// 需要审批时任务以 pending_approval 状态创建并生成审批单，审批通过后按提交时的快照执行。
+// 执行过程与脚本任务共用输出流和取消机制，可通过任务ID订阅输出或取消执行
+func (r *AnsibleRunner) Run(task *model.AnsibleTask, userID uint) (*model.JobTask, error) {
+	ctx := context.Background()
+	pb, err := r.load(task)
+	if err != nil {
+		return nil, err
+	}
+
+	var inventory model.AnsibleInventory
+	if strings.TrimSpace(task.Inventory) != "" {
+		if err := json.Unmarshal([]byte(task.Inventory), &inventory); err != nil {
+			return nil, fmt.Errorf("主机清单格式错误: %w", err)
+		}
+	}
+	hostIDs, err := ResolveTargetHosts(ctx, r.db, inventory.HostIDs, inventory.GroupIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(hostIDs) == 0 {
+		return nil, errors.New("没有可执行的目标主机")
+	}
+	if err := r.hostPerms.Check(ctx, userID, hostIDs, rbacbiz.PermissionExecute); err != nil {
+		return nil, err
+	}
+
+	decision, err := r.policy.Evaluate(ctx, "Shell", strings.Join(pb.commands, "\n"), PolicySubject{UserID: userID, HostIDs: hostIDs})
+	if err != nil {
+		return nil, err
+	}
+	if decision.Action == model.PolicyActionDeny {
+		return nil, fmt.Errorf("%w: %s", ErrCommandDenied, decision.Reason)
+	}
+	requirement, err := r.approvals.Requirement(ctx, decision)
+	if err != nil {
+		return nil, err
+	}
+
+	// 需要审批时不占用执行名额，审批通过后再检查
+	if requirement == nil {
+		r.mu.Lock()
+		if _, ok := r.running[task.ID]; ok {
+			r.mu.Unlock()
+			return nil, ErrAnsibleTaskRunning
+		}
+		r.running[task.ID] = 0
+		r.mu.Unlock()
+	}
+
+	now := time.Now()
+	hostIDsJSON, _ := json.Marshal(hostIDs)
+	paramsJSON, _ := json.Marshal(map[string]interface{}{
+		"ansibleTaskId": task.ID,
+		"fork":          task.Fork,
+		"timeout":       task.Timeout,
+		"tags":          task.Tags,
+		"verbose":       task.Verbose,
+	})
+	jobTask := &model.JobTask{
+		Name:        fmt.Sprintf("Ansible[%s] - %s", task.Name, now.Format("2006-01-02 15:04:05")),
+		TaskType:    "ansible",
+		Status:      model.JobStatusRunning,
+		TargetHosts: string(hostIDsJSON),
+		Parameters:  string(paramsJSON),
+		CreatedBy:   userID,
+		ExecuteTime: &now,
+	}
+	if requirement != nil {
+		jobTask.Status = model.JobStatusPendingApproval
+		jobTask.ExecuteTime = nil
+	}
+	if err := r.db.Create(jobTask).Error; err != nil {
+		if requirement == nil {
+			r.release(task.ID)
+		}
+		return nil, fmt.Errorf("创建任务记录失败: %w", err)
+	}
+
+	if requirement != nil {
+		snapshot, _ := json.Marshal(ansibleSnapshot{Task: *task, PlaybookSHA256: playbookDigest(pb.content)})
+		if _, err := r.approvals.Submit(ctx, jobTask, requirement, PendingExecution{
+			ScriptType: AnsibleScriptType,
+			Content:    string(snapshot),
+			Display:    ansibleDisplay(task, pb),
+			Fork:       task.Fork,
+			Timeout:    task.Timeout,
+		}); err != nil {
+			r.db.Model(jobTask).Updates(map[string]interface{}{"status": model.JobStatusFailed, "error_message": err.Error()})
+			return nil, err
+		}
+		r.db.Model(&model.AnsibleTask{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+			"status":        model.JobStatusPendingApproval,
+			"last_run_time": &now,
+			"last_job_id":   jobTask.ID,
+		})
+		return jobTask, nil
+	}
+
+	r.start(jobTask, task, pb, hostIDs)
+	return jobTask, nil
+}
+
+// resume 审批通过后按提交审批时的快照执行
+// 服务器上的 Playbook 文件在审批后被修改时拒绝执行
+func (r *AnsibleRunner) resume(jobTask *model.JobTask, content string) {
+	fail := func(message string) {
+		r.db.Model(&model.JobTask{}).Where("id = ?", jobTask.ID).Updates(map[string]interface{}{
+			"status":        model.JobStatusFailed,
+			"error_message": message,
+		})
+		r.db.Model(&model.AnsibleTask{}).Where("last_job_id = ?", jobTask.ID).Update("status", model.JobStatusFailed)
+	}
+
+	var snapshot ansibleSnapshot
+	if err := json.Unmarshal([]byte(content), &snapshot); err != nil {
+		fail("审批单中的Ansible任务无效")
+		return
+	}
+	task := snapshot.Task
+	pb, err := r.load(&task)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+	if playbookDigest(pb.content) != snapshot.PlaybookSHA256 {
+		fail("Playbook在提交审批后已被修改，已拒绝执行，请重新提交")
+		return
+	}
+	var hostIDs []uint
+	json.Unmarshal([]byte(jobTask.TargetHosts), &hostIDs)
+
+	r.mu.Lock()
+	if _, ok := r.running[task.ID]; ok {
+		r.mu.Unlock()
+		fail(ErrAnsibleTaskRunning.Error())
+		return
+	}
+	r.running[task.ID] = 0
+	r.mu.Unlock()
+
+	r.start(jobTask, &task, pb, hostIDs)
+}
+
+// start 登记执行并在后台运行 ansible-playbook，调用前需已占用执行名额
+func (r *AnsibleRunner) start(jobTask *model.JobTask, task *model.AnsibleTask, pb *ansiblePlaybook, hostIDs []uint) {
+	now := time.Now()
+	r.mu.Lock()
+	r.running[task.ID] = jobTask.ID
+	r.mu.Unlock()
+
+	r.db.Model(&model.AnsibleTask{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+		"status":        model.JobStatusRunning,
+		"last_run_time": &now,
+		"last_job_id":   jobTask.ID,
+	})
+
+	timeout := time.Duration(task.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultAnsibleTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	run := &jobRun{
+		db:      r.db,
+		jobID:   jobTask.ID,
+		stream:  r.executor.openStream(jobTask.ID),
+		cancel:  cancel,
+		results: r.executor.initialResults(hostIDs),
+	}
+	run.persist()
+	r.executor.registerRun(run)
+
+	go r.execute(ctx, run, *task, pb, hostIDs)
+}
+
+// release 标记Ansible任务执行结束
+func (r *AnsibleRunner) release(taskID uint) {
+	r.mu.Lock()
+	delete(r.running, taskID)
+	r.mu.Unlock()
+}
+
+// execute 准备工作目录并运行 ansible-playbook
+func (r *AnsibleRunner) execute(ctx context.Context, run *jobRun, task model.AnsibleTask, pb *ansiblePlaybook, hostIDs []uint) {
+	runResult := model.AnsibleRunResult{JobID: run.jobID, ExitCode: -1, StartTime: time.Now()}
+	output := &combinedOutput{}
+	var hosts []*ansibleHost
+	ws := &ansibleWorkspace{}
+
+	defer func() {
+		if p := recover(); p != nil {
+			logger.Error("Ansible任务执行异常", zap.Uint("jobId", run.jobID), zap.Any("panic", p))
+			runResult.Error = fmt.Sprintf("执行异常: %v", p)
+		}
+		timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+		run.cancel()
+		r.executor.unregisterRun(run)
+
+		status := r.finish(run, &runResult, hosts, RedactSecrets(output.String(), ws.secrets), timedOut)
+		r.executor.closeStream(run.stream, status)
+		r.saveResult(task.ID, status, &runResult)
+		r.release(task.ID)
+	}()
+
+	for i := range run.results {
+		run.start(i)
+	}
+
+	workDir, err := os.MkdirTemp(r.workRoot, fmt.Sprintf("opshub-ansible-%d-", run.jobID))
+	if err != nil {
+		runResult.Error = fmt.Sprintf("创建工作目录失败: %v", err)
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	hosts = r.loadHosts(ctx, hostIDs)
+
+	ws, err = r.prepareWorkDir(ctx, workDir, &task, pb, hosts)
+	if err != nil {
+		runResult.Error = err.Error()
+		return
+	}
+	defer ws.Close()
+
+	stdout := &lineWriter{stream: run.stream, typ: EventStdout, combined: output, secrets: ws.secrets}
+	stderr := &lineWriter{stream: run.stream, typ: EventStderr, combined: output, secrets: ws.secrets}
+
+	cmd := r.command(ctx, workDir, ws)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+
+	if cmd.ProcessState != nil {
+		runResult.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	// 退出码 2、4 分别表示有主机执行失败、不可达，具体结果以 PLAY RECAP 为准
+	var exitErr *exec.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || ctx.Err() != nil) {
+		runResult.Error = fmt.Sprintf("ansible-playbook 执行失败: %v", err)
+	}
+}
+
+// command 构造 ansible-playbook 命令，主机密码和 ssh-agent 通过环境变量传入
+func (r *AnsibleRunner) command(ctx context.Context, workDir string, ws *ansibleWorkspace) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, r.binary, ws.args...)
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(),
+		"ANSIBLE_HOST_KEY_CHECKING=True",
+		"ANSIBLE_NOCOLOR=1",
+		"ANSIBLE_FORCE_COLOR=0",
+		"ANSIBLE_RETRY_FILES_ENABLED=False",
+		"ANSIBLE_LOCAL_TEMP="+filepath.Join(workDir, ".ansible", "tmp"),
+		"ANSIBLE_SSH_CONTROL_PATH_DIR="+filepath.Join(workDir, ".ansible", "cp"),
+		"PYTHONUNBUFFERED=1",
+	)
+	cmd.Env = append(cmd.Env, ws.env...)
+	// 取消或超时时先发送 SIGTERM，宽限期后由 exec 包强制结束进程
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = killGracePeriod
+	return cmd
+}
+
+// loadHosts 查询主机及其凭证和主机密钥，生成清单中唯一的主机名
+func (r *AnsibleRunner) loadHosts(ctx context.Context, hostIDs []uint) []*ansibleHost {
+	var list []assetbiz.Host
+	r.db.WithContext(ctx).Where("id IN ?", hostIDs).Find(&list)
+	byID := make(map[uint]assetbiz.Host, len(list))
+	for _, h := range list {
+		byID[h.ID] = h
+	}
+
+	used := make(map[string]bool)
+	hosts := make([]*ansibleHost, 0, len(hostIDs))
+	for _, id := range hostIDs {
+		h, ok := byID[id]
+		if !ok {
+			continue
+		}
+
+		alias := inventoryName(h.Name)
+		if alias == "" || used[alias] {
+			alias = fmt.Sprintf("%s_%d", alias, h.ID)
+			alias = strings.TrimPrefix(alias, "_")
+		}
+		used[alias] = true
+
+		ah := &ansibleHost{host: h, alias: alias}
+		ah.credential, ah.err = r.executor.LoadCredential(ctx, &h)
+		if ah.err == nil {
+			ah.knownHost, ah.err = r.executor.knownHostsLine(ctx, &h)
+		}
+		if ah.err == nil {
+			ah.gateways, ah.err = r.loadGateways(ctx, &h)
+		}
+		hosts = append(hosts, ah)
+	}
+	return hosts
+}
+
+// loadGateways 查询连接主机需要经过的跳板机及其凭证和主机密钥
+// 跳板机的密码无法通过 ProxyJump 传递，只支持密钥认证的跳板机
+func (r *AnsibleRunner) loadGateways(ctx context.Context, host *assetbiz.Host) ([]*ansibleGateway, error) {
+	hosts, credentials, err := r.executor.connector.Gateways().Gateways(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	gateways := make([]*ansibleGateway, 0, len(hosts))
+	for i, gw := range hosts {
+		if credentials[i].Type == "password" {
+			return nil, fmt.Errorf("Ansible 任务暂不支持使用密码认证的跳板机: %s", gw.Name)
+		}
+		knownHost, err := r.executor.knownHostsLine(ctx, gw)
+		if err != nil {
+			return nil, fmt.Errorf("跳板机 %s: %w", gw.Name, err)
+		}
+		gateways = append(gateways, &ansibleGateway{host: gw, credential: credentials[i], knownHost: knownHost})
+	}
+	return gateways, nil
+}
+
+// ansibleWorkspace 一次执行的清单、参数及通过环境变量传递的凭证
+type ansibleWorkspace struct {
+	args    []string
+	env     []string  // 主机密码及 SSH_AUTH_SOCK
+	secrets []string  // 需要在输出中脱敏的值
+	agent   *sshAgent // 持有私钥的 ssh-agent，执行结束后关闭
+}
+
+// startAgent 首次需要加载私钥时启动 ssh-agent
+func (w *ansibleWorkspace) startAgent(workDir string) error {
+	if w.agent != nil {
+		return nil
+	}
+	agent, err := startSSHAgent(filepath.Join(workDir, "agent"))
+	if err != nil {
+		return err
+	}
+	w.agent = agent
+	w.env = append(w.env, "SSH_AUTH_SOCK="+agent.socket)
+	return nil
+}
+
+// ansibleJumps 跳板机的 ssh_config 条目，ansible 通过 ProxyJump 经跳板机连接目标主机
+// 命令行上的 -o 选项不会传递给跳板连接，因此每个条目各自指定私钥和 known_hosts
+type ansibleJumps struct {
+	entries    map[string]bool
+	config     strings.Builder
+	knownHosts []string
+}
+
+// add 为跳板链路生成 ssh_config 条目，返回最后一跳的名称
+// 条目按链路前缀命名，同一跳板机在不同链路中的上一跳可能不同
+func (j *ansibleJumps) add(workDir, keyDir, knownHostsFile string, gateways []*ansibleGateway, ws *ansibleWorkspace) (string, error) {
+	name, prev := "opshub-gw", ""
+	for _, gw := range gateways {
+		name = fmt.Sprintf("%s-%d", name, gw.host.ID)
+		if !j.entries[name] {
+			if err := ws.startAgent(workDir); err != nil {
+				return "", err
+			}
+			pubFile, err := ws.agent.add(keyDir, gw.host.ID, gw.credential)
+			if err != nil {
+				return "", fmt.Errorf("跳板机 %s: %w", gw.host.Name, err)
+			}
+			port := gw.host.Port
+			if port == 0 {
+				port = 22
+			}
+			fmt.Fprintf(&j.config, "Host %s\n  HostName %s\n  Port %d\n  User %s\n", name, gw.host.IP, port, assetbiz.SSHUser(gw.host, gw.credential))
+			fmt.Fprintf(&j.config, "  IdentityFile \"%s\"\n  IdentitiesOnly yes\n", pubFile)
+			fmt.Fprintf(&j.config, "  UserKnownHostsFile \"%s\"\n  StrictHostKeyChecking yes\n", knownHostsFile)
+			if prev != "" {
+				fmt.Fprintf(&j.config, "  ProxyJump %s\n", prev)
+			}
+			j.entries[name] = true
+			j.knownHosts = append(j.knownHosts, gw.knownHost)
+		}
+		prev = name
+	}
+	return name, nil
+}
+
+// Close 关闭 ssh-agent
+func (w *ansibleWorkspace) Close() {
+	if w.agent != nil {
+		w.agent.Close()
+		w.agent = nil
+	}
+}
+
+// prepareWorkDir 写入 Playbook、清单及额外变量，返回 ansible-playbook 参数
+// 主机密码和私钥不写入工作目录：密码通过环境变量传入并在清单中以 env 查找引用，私钥加载到进程内的 ssh-agent
+func (r *AnsibleRunner) prepareWorkDir(ctx context.Context, workDir string, task *model.AnsibleTask, pb *ansiblePlaybook, hosts []*ansibleHost) (*ansibleWorkspace, error) {
+	ws := &ansibleWorkspace{}
+
+	// Playbook
+	playbook := pb.path
+	if playbook == "" {
+		playbook = filepath.Join(workDir, "playbook.yml")
+		if err := os.WriteFile(playbook, pb.content, 0600); err != nil {
+			return nil, fmt.Errorf("写入Playbook失败: %w", err)
+		}
+	}
+
+	// 清单
+	inventory, err := r.buildInventory(ctx, workDir, task, hosts, ws)
+	if err != nil {
+		ws.Close()
+		return nil, err
+	}
+	inventoryJSON, _ := json.MarshalIndent(inventory, "", "  ")
+	inventoryFile := filepath.Join(workDir, "inventory.json")
+	if err := os.WriteFile(inventoryFile, inventoryJSON, 0600); err != nil {
+		ws.Close()
+		return nil, fmt.Errorf("写入主机清单失败: %w", err)
+	}
+
+	args := []string{"-i", inventoryFile}
+
+	// 额外变量
+	extraVars := strings.TrimSpace(task.ExtraVars)
+	if extraVars != "" && extraVars != "null" && extraVars != "{}" {
+		varsFile := filepath.Join(workDir, "extra_vars.json")
+		if err := os.WriteFile(varsFile, []byte(extraVars), 0600); err != nil {
+			ws.Close()
+			return nil, fmt.Errorf("写入额外变量失败: %w", err)
+		}
+		args = append(args, "-e", "@"+varsFile)
+	}
+
+	if task.Fork > 0 {
+		args = append(args, "--forks", strconv.Itoa(task.Fork))
+	}
+	if tags := strings.TrimSpace(task.Tags); tags != "" {
+		args = append(args, "--tags", tags)
+	}
+	if verboseRe.MatchString(task.Verbose) {
+		args = append(args, "-"+task.Verbose)
+	}
+	ws.args = append(args, playbook)
+	return ws, nil
+}
+
+// buildInventory 生成YAML（JSON）格式的清单
+// 所有主机位于 all 组，选择的分组各自生成一个主机组，包含其子孙分组下的主机
+func (r *AnsibleRunner) buildInventory(ctx context.Context, workDir string, task *model.AnsibleTask, hosts []*ansibleHost, ws *ansibleWorkspace) (map[string]interface{}, error) {
+	keyDir := filepath.Join(workDir, "keys")
+	allHosts := make(map[string]interface{})
+	aliasByID := make(map[uint]string)
+	// 只信任平台记录的主机密钥
+	knownHostsFile := filepath.Join(workDir, "known_hosts")
+	var knownHosts []string
+	sshArgs := "-o UserKnownHostsFile=" + knownHostsFile + " -o StrictHostKeyChecking=yes"
+	sshConfigFile := filepath.Join(workDir, "ssh_config")
+	jumps := &ansibleJumps{entries: make(map[string]bool)}
+
+	for _, h := range hosts {
+		if h.err != nil {
+			continue
+		}
+		port := h.host.Port
+		if port == 0 {
+			port = 22
+		}
+		vars := map[string]interface{}{
+			"ansible_host":            h.host.IP,
+			"ansible_port":            port,
+			"ansible_user":            assetbiz.SSHUser(&h.host, h.credential),
+			"ansible_ssh_common_args": sshArgs,
+		}
+
+		switch h.credential.Type {
+		case "password":
+			env := fmt.Sprintf("OPSHUB_ANSIBLE_PASSWORD_%d", h.host.ID)
+			ws.env = append(ws.env, env+"="+h.credential.Password)
+			ws.secrets = append(ws.secrets, h.credential.Password)
+			vars["ansible_password"] = fmt.Sprintf("{{ lookup('env', '%s') }}", env)
+		case "key", "private_key":
+			if err := ws.startAgent(workDir); err != nil {
+				return nil, err
+			}
+			pubFile, err := ws.agent.add(keyDir, h.host.ID, h.credential)
+			if err != nil {
+				h.err = err
+				continue
+			}
+			vars["ansible_ssh_private_key_file"] = pubFile
+			vars["ansible_ssh_common_args"] = sshArgs + " -o IdentitiesOnly=yes"
+		default:
+			h.err = fmt.Errorf("不支持的凭证类型: %s", h.credential.Type)
+			continue
+		}
+
+		// 配置了跳板机时经 ProxyJump 连接，跳板条目写入独立的 ssh_config
+		if len(h.gateways) > 0 {
+			jump, err := jumps.add(workDir, keyDir, knownHostsFile, h.gateways, ws)
+			if err != nil {
+				h.err = err
+				continue
+			}
+			vars["ansible_ssh_common_args"] = vars["ansible_ssh_common_args"].(string) + " -F " + sshConfigFile + " -o ProxyJump=" + jump
+		}
+
+		knownHosts = append(knownHosts, h.knownHost)
+		allHosts[h.alias] = vars
+		aliasByID[h.host.ID] = h.alias
+	}
+	if len(allHosts) == 0 {
+		return nil, errors.New("没有可用的目标主机（主机不存在、凭证不可用或主机密钥校验失败）")
+	}
+	knownHosts = append(knownHosts, jumps.knownHosts...)
+	if err := os.WriteFile(knownHostsFile, []byte(strings.Join(knownHosts, "\n")+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("写入known_hosts失败: %w", err)
+	}
+	if len(jumps.entries) > 0 {
+		if err := os.WriteFile(sshConfigFile, []byte(jumps.config.String()), 0600); err != nil {
+			return nil, fmt.Errorf("写入ssh_config失败: %w", err)
+		}
+	}
+
+	children := make(map[string]interface{})
+	var inventory model.AnsibleInventory
+	json.Unmarshal([]byte(task.Inventory), &inventory)
+	for _, groupID := range inventory.GroupIDs {
+		var group assetbiz.AssetGroup
+		if err := r.db.WithContext(ctx).First(&group, groupID).Error; err != nil {
+			continue
+		}
+		groupHosts, err := ResolveTargetHosts(ctx, r.db, nil, []uint{groupID})
+		if err != nil {
+			return nil, err
+		}
+		members := make(map[string]interface{})
+		for _, id := range groupHosts {
+			if alias, ok := aliasByID[id]; ok {
+				members[alias] = map[string]interface{}{}
+			}
+		}
+		name := inventoryName(group.Code)
+		if name == "" {
+			name = inventoryName(group.Name)
+		}
+		if name == "" || name == "all" || name == "ungrouped" {
+			name = fmt.Sprintf("group_%d", group.ID)
+		}
+		children[name] = map[string]interface{}{"hosts": members}
+	}
+
+	all := map[string]interface{}{"hosts": allHosts}
+	if len(children) > 0 {
+		all["children"] = children
+	}
+	return map[string]interface{}{"all": all}, nil
+}
+
+// playbookDigest Playbook 内容的 SHA-256 摘要
+func playbookDigest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ansibleDisplay 提交审批时展示给审批人的内容
+func ansibleDisplay(task *model.AnsibleTask, pb *ansiblePlaybook) string {
+	var b strings.Builder
+	if pb.path != "" {
+		fmt.Fprintf(&b, "# Playbook: %s\n", pb.path)
+	}
+	b.Write(pb.content)
+	if extraVars := strings.TrimSpace(task.ExtraVars); extraVars != "" && extraVars != "{}" && extraVars != "null" {
+		fmt.Fprintf(&b, "\n# 额外变量: %s\n", extraVars)
+	}
+	if tags := strings.TrimSpace(task.Tags); tags != "" {
+		fmt.Fprintf(&b, "# Tags: %s\n", tags)
+	}
+	return b.String()
+}
+
+// finish 根据 PLAY RECAP 更新各主机结果并汇总任务状态
+func (r *AnsibleRunner) finish(run *jobRun, runResult *model.AnsibleRunResult, hosts []*ansibleHost, output string, timedOut bool) string {
+	runResult.EndTime = time.Now()
+	recaps := parseRecap(output)
+	lines := strings.Split(ansiEscapeRe.ReplaceAllString(output, ""), "\n")
+
+	byID := make(map[uint]*ansibleHost, len(hosts))
+	for _, h := range hosts {
+		byID[h.host.ID] = h
+	}
+
+	run.mu.Lock()
+	results := make([]model.HostExecutionResult, len(run.results))
+	copy(results, run.results)
+	cancelled := run.cancelled
+	run.mu.Unlock()
+
+	runResult.Hosts = make([]model.AnsibleHostRecap, 0, len(results))
+	for idx, res := range results {
+		res.Status = model.HostStatusFailed
+		recap := model.AnsibleHostRecap{HostID: res.HostID, HostName: res.HostName, HostIP: res.HostIP}
+
+		h := byID[res.HostID]
+		if h != nil {
+			recap.Alias = h.alias
+			res.Output = hostLines(lines, h.alias)
+		}
+
+		parsed, ok := recaps[recap.Alias]
+		switch {
+		case h == nil:
+			res.Error = "主机不存在"
+		case h.err != nil:
+			res.Error = h.err.Error()
+		case ok && recap.Alias != "":
+			parsed.HostID, parsed.HostName, parsed.HostIP = recap.HostID, recap.HostName, recap.HostIP
+			recap = parsed
+			switch {
+			case recap.Unreachable > 0:
+				res.Error = "主机不可达"
+			case recap.Failed > 0:
+				res.Error = fmt.Sprintf("%d 个任务执行失败", recap.Failed)
+			default:
+				res.Status = model.HostStatusSuccess
+			}
+		case cancelled:
+			res.Status = model.HostStatusCancelled
+			res.Error = "任务已取消"
+		case timedOut:
+			res.Status = model.HostStatusTimeout
+			res.Error = "执行超时"
+		case runResult.Error != "":
+			res.Error = runResult.Error
+		default:
+			res.Error = "未出现在 PLAY RECAP 中"
+		}
+
+		recap.Status = res.Status
+		if recap.Unreachable > 0 {
+			recap.Status = "unreachable"
+		}
+		runResult.Hosts = append(runResult.Hosts, recap)
+		run.finish(idx, res)
+	}
+
+	if len(output) > maxAnsibleOutput {
+		output = output[len(output)-maxAnsibleOutput:]
+	}
+	runResult.Output = output
+
+	status := run.complete()
+	runResult.Status = status
+	return status
+}
+
+// saveResult 更新Ansible任务状态及最近一次执行结果
+func (r *AnsibleRunner) saveResult(taskID uint, status string, runResult *model.AnsibleRunResult) {
+	resultJSON, _ := json.Marshal(runResult)
+	if err := r.db.Model(&model.AnsibleTask{}).Where("id = ?", taskID).Updates(map[string]interface{}{
+		"status":          status,
+		"last_run_result": string(resultJSON),
+	}).Error; err != nil {
+		logger.Error("更新Ansible任务结果失败", zap.Uint("ansibleTaskId", taskID), zap.Error(err))
+	}
+}
+
+// parseRecap 解析 PLAY RECAP 中各主机的统计
+func parseRecap(output string) map[string]model.AnsibleHostRecap {
+	recaps := make(map[string]model.AnsibleHostRecap)
+	inRecap := false
+	for _, line := range strings.Split(ansiEscapeRe.ReplaceAllString(output, ""), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "PLAY RECAP") {
+			inRecap = true
+			continue
+		}
+		if !inRecap {
+			continue
+		}
+		m := recapLineRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		atoi := func(s string) int {
+			n, _ := strconv.Atoi(s)
+			return n
+		}
+		recaps[m[1]] = model.AnsibleHostRecap{
+			Alias:       m[1],
+			Ok:          atoi(m[2]),
+			Changed:     atoi(m[3]),
+			Unreachable: atoi(m[4]),
+			Failed:      atoi(m[5]),
+			Skipped:     atoi(m[6]),
+			Rescued:     atoi(m[7]),
+			Ignored:     atoi(m[8]),
+		}
+	}
+	return recaps
+}
+
+// hostLines 提取输出中与指定主机相关的行
+func hostLines(lines []string, alias string) string {
+	if alias == "" {
+		return ""
+	}
+	marker := "[" + alias + "]"
+	var b strings.Builder
+	for _, line := range lines {
+		if strings.Contains(line, marker) || strings.HasPrefix(strings.TrimSpace(line), alias+" ") {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// inventoryName 将名称转换为清单中合法的主机名或组名
+func inventoryName(name string) string {
+	return strings.Trim(inventoryNameRe.ReplaceAllString(strings.TrimSpace(name), "_"), "_")
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// sshAgent 进程内的 ssh-agent
+// 私钥只保存在内存中，通过工作目录下的 Unix socket 提供给 ansible 调用的 ssh，工作目录中只写入公钥
+type sshAgent struct {
+	keyring  agent.Agent
+	listener net.Listener
+	socket   string
+
+	mu    sync.Mutex
+	conns map[net.Conn]bool
+}
+
+// startSSHAgent 在指定目录下创建 agent socket 并开始监听
+func startSSHAgent(dir string) (*sshAgent, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建ssh-agent目录失败: %w", err)
+	}
+	socket := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("启动ssh-agent失败: %w", err)
+	}
+	a := &sshAgent{
+		keyring:  agent.NewKeyring(),
+		listener: listener,
+		socket:   socket,
+		conns:    make(map[net.Conn]bool),
+	}
+	go a.serve()
+	return a, nil
+}
+
+func (a *sshAgent) serve() {
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			return
+		}
+		a.mu.Lock()
+		a.conns[conn] = true
+		a.mu.Unlock()
+
+		go func() {
+			agent.ServeAgent(a.keyring, conn)
+			conn.Close()
+			a.mu.Lock()
+			delete(a.conns, conn)
+			a.mu.Unlock()
+		}()
+	}
+}
+
+// add 将凭证中的私钥加入 agent，并在 dir 下写入对应的公钥文件
+// ssh 的 IdentityFile 指向公钥文件时会使用 agent 中匹配的私钥
+func (a *sshAgent) add(dir string, hostID uint, credential *assetbiz.Credential) (string, error) {
+	var (
+		raw interface{}
+		err error
+	)
+	if credential.Passphrase != "" {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(credential.PrivateKey), []byte(credential.Passphrase))
+	} else {
+		raw, err = ssh.ParseRawPrivateKey([]byte(credential.PrivateKey))
+	}
+	if err != nil {
+		return "", fmt.Errorf("解析私钥失败: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(raw)
+	if err != nil {
+		return "", fmt.Errorf("解析私钥失败: %w", err)
+	}
+	if err := a.keyring.Add(agent.AddedKey{PrivateKey: raw, Comment: fmt.Sprintf("opshub-host-%d", hostID)}); err != nil {
+		return "", fmt.Errorf("加载私钥失败: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("创建公钥目录失败: %w", err)
+	}
+	pubFile := filepath.Join(dir, fmt.Sprintf("host_%d.pub", hostID))
+	if err := os.WriteFile(pubFile, ssh.MarshalAuthorizedKey(signer.PublicKey()), 0600); err != nil {
+		return "", fmt.Errorf("写入公钥失败: %w", err)
+	}
+	return pubFile, nil
+}
+
+// Close 停止监听、断开所有连接并清空私钥
+func (a *sshAgent) Close() {
+	a.listener.Close()
+	a.mu.Lock()
+	for conn := range a.conns {
+		conn.Close()
+	}
+	a.mu.Unlock()
+	a.keyring.RemoveAll()
+	os.Remove(a.socket)
+}
@@ -0,0 +1,321 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+	"sigs.k8s.io/yaml"
+)
+
+// AnsibleScriptType 审批单中 Ansible 任务的脚本类型
+const AnsibleScriptType = "Ansible"
+
+// 不允许出现在 Playbook 中的键：在控制节点本机执行、委派执行或动态修改清单
+var forbiddenPlaybookKeys = map[string]string{
+	"delegate_to":              "不允许使用 delegate_to 委派执行",
+	"local_action":             "不允许使用 local_action 在控制节点执行",
+	"add_host":                 "不允许使用 add_host 动态添加主机",
+	"ansible.builtin.add_host": "不允许使用 add_host 动态添加主机",
+	"fetch":                    "不允许使用 fetch 将文件写入控制节点",
+	"ansible.builtin.fetch":    "不允许使用 fetch 将文件写入控制节点",
+}
+
+// 不允许在 Playbook 或额外变量中设置的连接变量，由平台根据主机和凭证生成
+var forbiddenConnectionVars = []string{
+	"ansible_connection", "ansible_host", "ansible_port", "ansible_user", "ansible_password",
+	"ansible_private_key_file", "ansible_ssh_", "ansible_sftp_", "ansible_scp_",
+}
+
+// 引用外部文件的键，只能使用相对路径且不能跳出 Playbook 所在目录
+var playbookFileKeys = map[string]bool{
+	"import_playbook": true, "include": true, "include_tasks": true, "import_tasks": true,
+	"include_vars": true, "vars_files": true, "src": true,
+	"ansible.builtin.import_playbook": true, "ansible.builtin.include_tasks": true,
+	"ansible.builtin.import_tasks": true, "ansible.builtin.include_vars": true,
+}
+
+// 在目标主机上执行命令的模块
+var ansibleCommandModules = map[string]bool{
+	"shell": true, "command": true, "raw": true, "script": true,
+	"ansible.builtin.shell": true, "ansible.builtin.command": true,
+	"ansible.builtin.raw": true, "ansible.builtin.script": true,
+	"ansible.legacy.shell": true, "ansible.legacy.command": true,
+}
+
+var (
+	// controllerLookupRe 在控制节点执行命令或读取环境变量的查找插件
+	controllerLookupRe = regexp.MustCompile(`\b(?:lookup|query|q)\s*\(\s*['"](?:ansible\.builtin\.)?(pipe|lines|env)['"]`)
+	// connectionSecretRe 引用主机连接密码的变量
+	connectionSecretRe = regexp.MustCompile(`\bansible_(?:ssh_)?pass(?:word)?\b`)
+	// freeFormSrcRe 自由格式模块参数中的 src= 路径
+	freeFormSrcRe = regexp.MustCompile(`(?:^|\s)src=['"]?(\S+?)['"]?(?:\s|$)`)
+	// jinjaExprRe Jinja2 表达式、语句及注释
+	jinjaExprRe = regexp.MustCompile(`(?s)\{\{.*?\}\}|\{%.*?%\}|\{#.*?#\}`)
+)
+
+// ansiblePlaybook 校验通过的 Playbook
+type ansiblePlaybook struct {
+	path     string   // Playbook 根目录下的文件，使用内联内容时为空
+	content  []byte   // Playbook 内容
+	commands []string // shell、command 等模块在目标主机上执行的命令
+}
+
+// resolvePlaybookPath 将 Playbook 路径解析为 Playbook 根目录下的真实路径
+// 相对路径相对于根目录，解析符号链接后仍必须位于根目录内
+func resolvePlaybookPath(root, playbook string) (string, error) {
+	if root == "" {
+		return "", errors.New("未配置Playbook根目录（OPSHUB_ANSIBLE_PLAYBOOK_ROOT），不能使用服务器上的Playbook文件")
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("Playbook根目录不可用: %w", err)
+	}
+	if !filepath.IsAbs(playbook) {
+		playbook = filepath.Join(realRoot, playbook)
+	}
+	realPath, err := filepath.EvalSymlinks(filepath.Clean(playbook))
+	if err != nil {
+		return "", fmt.Errorf("Playbook文件不存在: %s", playbook)
+	}
+	rel, err := filepath.Rel(realRoot, realPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Playbook文件必须位于Playbook根目录 %s 下", root)
+	}
+	info, err := os.Stat(realPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("Playbook文件不存在: %s", playbook)
+	}
+	return realPath, nil
+}
+
+// checkPlaybook 校验 Playbook 并提取在目标主机上执行的命令
+// Playbook 只能作用于清单中的主机：不能以 localhost 为目标、不能委派到其他主机或改用本地连接，
+// 也不能通过查找插件在控制节点执行命令
+func checkPlaybook(content []byte) ([]string, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("Playbook格式错误: %w", err)
+	}
+	plays, ok := doc.([]interface{})
+	if !ok {
+		return nil, errors.New("Playbook格式错误：顶层必须为play列表")
+	}
+
+	var commands []string
+	for _, item := range plays {
+		play, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("Playbook格式错误：play必须为对象")
+		}
+		if hosts, ok := play["hosts"]; ok {
+			if err := checkHostPattern(hosts); err != nil {
+				return nil, err
+			}
+		}
+		if err := walkPlaybook(play, &commands); err != nil {
+			return nil, err
+		}
+	}
+	return commands, nil
+}
+
+// checkExtraVars 校验额外变量，额外变量优先级最高，不能用来覆盖连接变量
+func checkExtraVars(extraVars string) error {
+	extraVars = strings.TrimSpace(extraVars)
+	if extraVars == "" || extraVars == "null" || extraVars == "{}" {
+		return nil
+	}
+	var vars map[string]interface{}
+	if err := json.Unmarshal([]byte(extraVars), &vars); err != nil {
+		return fmt.Errorf("额外变量格式错误: %w", err)
+	}
+	for name := range vars {
+		if strings.HasPrefix(name, "ansible_") {
+			return fmt.Errorf("额外变量不能设置 %s", name)
+		}
+	}
+	return walkPlaybook(vars, nil)
+}
+
+// checkHostPattern 校验 play 的目标主机，只允许匹配清单中的主机
+func checkHostPattern(hosts interface{}) error {
+	var patterns []string
+	switch v := hosts.(type) {
+	case string:
+		patterns = []string{v}
+	case []interface{}:
+		for _, p := range v {
+			patterns = append(patterns, fmt.Sprint(p))
+		}
+	default:
+		return errors.New("Playbook格式错误：hosts 必须为字符串或列表")
+	}
+	for _, pattern := range patterns {
+		lower := strings.ToLower(pattern)
+		switch {
+		case strings.Contains(lower, "{{") || strings.Contains(lower, "{%"):
+			return fmt.Errorf("hosts 不能使用模板表达式: %s", pattern)
+		case strings.Contains(lower, "localhost") || strings.Contains(lower, "127.") || strings.Contains(lower, "::1"):
+			return fmt.Errorf("不允许在控制节点本机执行（hosts: %s）", pattern)
+		}
+	}
+	return nil
+}
+
+// walkPlaybook 递归检查 Playbook 节点，commands 不为空时收集命令模块执行的命令
+func walkPlaybook(node interface{}, commands *[]string) error {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if reason, ok := forbiddenPlaybookKeys[key]; ok {
+				return errors.New(reason)
+			}
+			if isConnectionVar(key) {
+				return fmt.Errorf("不允许修改连接变量 %s", key)
+			}
+			if key == "connection" || key == "ansible.builtin.connection" {
+				if conn := fmt.Sprint(value); conn != "ssh" && conn != "smart" {
+					return fmt.Errorf("不允许使用 %s 连接方式", conn)
+				}
+			}
+			if playbookFileKeys[key] {
+				if err := checkPlaybookFile(key, value); err != nil {
+					return err
+				}
+			}
+			if commands != nil && ansibleCommandModules[key] {
+				if cmd := moduleCommand(value, v["args"]); cmd != "" {
+					*commands = append(*commands, cmd)
+				}
+			}
+			if err := walkPlaybook(value, commands); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := walkPlaybook(item, commands); err != nil {
+				return err
+			}
+		}
+	case string:
+		if m := controllerLookupRe.FindStringSubmatch(v); m != nil {
+			return fmt.Errorf("不允许使用 %s 查找插件", m[1])
+		}
+		if connectionSecretRe.MatchString(v) {
+			return errors.New("不允许引用主机连接密码")
+		}
+		if m := freeFormSrcRe.FindStringSubmatch(v); m != nil {
+			if err := checkPlaybookFile("src", m[1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isConnectionVar 是否为平台管理的连接变量
+func isConnectionVar(name string) bool {
+	for _, v := range forbiddenConnectionVars {
+		if name == v || (strings.HasSuffix(v, "_") && strings.HasPrefix(name, v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPlaybookFile 校验引用的文件路径，不能读取控制节点上 Playbook 目录以外的文件
+func checkPlaybookFile(key string, value interface{}) error {
+	var paths []string
+	switch v := value.(type) {
+	case string:
+		paths = []string{v}
+	case []interface{}:
+		for _, p := range v {
+			if s, ok := p.(string); ok {
+				paths = append(paths, s)
+			}
+		}
+	case map[string]interface{}:
+		for _, k := range []string{"file", "dir"} {
+			if p, ok := v[k].(string); ok {
+				paths = append(paths, p)
+			}
+		}
+	}
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if f := strings.Fields(p); len(f) > 0 {
+			p = f[0]
+		}
+		if filepath.IsAbs(p) || strings.HasPrefix(p, "~") || p == ".." ||
+			strings.HasPrefix(p, "../") || strings.Contains(p, "/../") || strings.HasSuffix(p, "/..") {
+			return fmt.Errorf("%s 只能引用Playbook目录下的相对路径: %s", key, p)
+		}
+	}
+	return nil
+}
+
+// moduleCommand 提取命令模块执行的命令，Jinja2 表达式替换为变量引用，按动态内容参与策略评估
+func moduleCommand(value, args interface{}) string {
+	var cmd string
+	switch v := value.(type) {
+	case string:
+		cmd = v
+	case map[string]interface{}:
+		cmd = commandArgs(v)
+	}
+	if cmd == "" {
+		if m, ok := args.(map[string]interface{}); ok {
+			cmd = commandArgs(m)
+		}
+	}
+	return jinjaExprRe.ReplaceAllLiteralString(strings.TrimSpace(cmd), "${ansible_var}")
+}
+
+// commandArgs 从模块参数中提取命令，支持 cmd、_raw_params 及 argv 列表
+func commandArgs(args map[string]interface{}) string {
+	for _, key := range []string{"cmd", "_raw_params"} {
+		if s, ok := args[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	argv, ok := args["argv"].([]interface{})
+	if !ok {
+		return ""
+	}
+	words := make([]string, 0, len(argv))
+	for _, a := range argv {
+		quoted, err := syntax.Quote(fmt.Sprint(a), syntax.LangBash)
+		if err != nil {
+			return ""
+		}
+		words = append(words, quoted)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,379 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"gorm.io/gorm"
+)
+
+func TestResolvePlaybookPath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "site"), 0700)
+	os.WriteFile(filepath.Join(root, "site", "deploy.yml"), []byte("- hosts: all\n"), 0600)
+	os.WriteFile(filepath.Join(outside, "evil.yml"), []byte("- hosts: all\n"), 0600)
+	os.Symlink(filepath.Join(outside, "evil.yml"), filepath.Join(root, "link.yml"))
+
+	tests := []struct {
+		name     string
+		root     string
+		playbook string
+		wantErr  string
+	}{
+		{"相对路径", root, "site/deploy.yml", ""},
+		{"根目录下的绝对路径", root, filepath.Join(root, "site", "deploy.yml"), ""},
+		{"未配置根目录", "", filepath.Join(root, "site", "deploy.yml"), "未配置Playbook根目录"},
+		{"路径穿越", root, "../" + filepath.Base(outside) + "/evil.yml", "必须位于Playbook根目录"},
+		{"根目录外的绝对路径", root, filepath.Join(outside, "evil.yml"), "必须位于Playbook根目录"},
+		{"指向根目录外的符号链接", root, "link.yml", "必须位于Playbook根目录"},
+		{"目录", root, "site", "Playbook文件不存在"},
+		{"文件不存在", root, "missing.yml", "Playbook文件不存在"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := resolvePlaybookPath(tt.root, tt.playbook)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.HasSuffix(path, filepath.Join("site", "deploy.yml")) {
+					t.Errorf("path = %s", path)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPlaybook(t *testing.T) {
+	tests := []struct {
+		name     string
+		playbook string
+		commands []string
+		wantErr  string
+	}{
+		{
+			name: "提取命令模块",
+			playbook: `
+- hosts: web
+  tasks:
+    - shell: systemctl restart nginx
+    - ansible.builtin.command:
+        cmd: uptime
+    - command:
+        argv: [rm, -f, "/tmp/a b"]
+    - name: templated
+      shell: "echo {{ item | quote }}"
+    - copy:
+        src: files/app.conf
+        dest: /etc/app.conf
+`,
+			commands: []string{"systemctl restart nginx", "uptime", "rm -f '/tmp/a b'", "echo ${ansible_var}"},
+		},
+		{name: "目标为localhost", playbook: "- hosts: localhost\n  tasks: []\n", wantErr: "控制节点本机"},
+		{name: "目标为回环地址", playbook: "- hosts: [web, 127.0.0.1]\n", wantErr: "控制节点本机"},
+		{name: "模板化的目标", playbook: "- hosts: \"{{ target }}\"\n", wantErr: "模板表达式"},
+		{name: "委派执行", playbook: "- hosts: all\n  tasks:\n    - shell: id\n      delegate_to: localhost\n", wantErr: "delegate_to"},
+		{name: "local_action", playbook: "- hosts: all\n  tasks:\n    - local_action: command id\n", wantErr: "local_action"},
+		{name: "本地连接", playbook: "- hosts: all\n  connection: local\n", wantErr: "local 连接方式"},
+		{name: "连接变量", playbook: "- hosts: all\n  vars:\n    ansible_connection: local\n", wantErr: "ansible_connection"},
+		{name: "SSH参数", playbook: "- hosts: all\n  vars:\n    ansible_ssh_common_args: -o ProxyCommand=id\n", wantErr: "ansible_ssh_common_args"},
+		{name: "pipe查找", playbook: "- hosts: all\n  tasks:\n    - debug:\n        msg: \"{{ lookup('pipe', 'id') }}\"\n", wantErr: "pipe"},
+		{name: "env查找", playbook: "- hosts: all\n  tasks:\n    - debug:\n        msg: \"{{ lookup('ansible.builtin.env', 'HOME') }}\"\n", wantErr: "env"},
+		{name: "引用连接密码", playbook: "- hosts: all\n  tasks:\n    - debug:\n        msg: \"{{ hostvars[inventory_hostname].ansible_password }}\"\n", wantErr: "连接密码"},
+		{name: "fetch写入控制节点", playbook: "- hosts: all\n  tasks:\n    - fetch:\n        src: /etc/passwd\n        dest: /tmp\n", wantErr: "fetch"},
+		{name: "读取控制节点文件", playbook: "- hosts: all\n  tasks:\n    - copy: src=/etc/shadow dest=/tmp/x\n", wantErr: "相对路径"},
+		{name: "包含绝对路径", playbook: "- hosts: all\n  tasks:\n    - include_tasks: /etc/tasks.yml\n", wantErr: "相对路径"},
+		{name: "导入上级目录", playbook: "- import_playbook: ../other.yml\n", wantErr: "相对路径"},
+		{name: "顶层不是列表", playbook: "hosts: all\n", wantErr: "play列表"},
+		{name: "YAML错误", playbook: "- hosts: [\n", wantErr: "Playbook格式错误"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, err := checkPlaybook([]byte(tt.playbook))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(commands, "\n") != strings.Join(tt.commands, "\n") {
+				t.Errorf("commands = %q, want %q", commands, tt.commands)
+			}
+		})
+	}
+}
+
+func TestCheckExtraVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    string
+		wantErr string
+	}{
+		{"空", "", ""},
+		{"空对象", "{}", ""},
+		{"普通变量", `{"version": "1.2.3", "replicas": 3}`, ""},
+		{"覆盖连接方式", `{"ansible_connection": "local"}`, "ansible_connection"},
+		{"覆盖主机", `{"ansible_host": "127.0.0.1"}`, "ansible_host"},
+		{"变量值中的pipe查找", `{"v": "{{ lookup('pipe', 'id') }}"}`, "pipe"},
+		{"格式错误", `[1, 2]`, "额外变量格式错误"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkExtraVars(tt.vars)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRecap(t *testing.T) {
+	output := "PLAY [web] ****\n" +
+		"TASK [Gathering Facts] ****\n" +
+		"ok: [web1]\n" +
+		"web1 : ok=1 changed=0 unreachable=0 failed=0\n" + // RECAP 之前的行不解析
+		"\n" +
+		"PLAY RECAP *********************************************************************\n" +
+		"web1                       : ok=5    changed=2    unreachable=0    failed=0    skipped=1    rescued=0    ignored=0\n" +
+		"\x1b[0;31mdb_2\x1b[0m                       : ok=1    changed=0    unreachable=1    failed=0    skipped=0    rescued=0    ignored=0\n" +
+		"old-host : ok=3 changed=1 unreachable=0 failed=2\n" +
+		"garbage line\n"
+
+	recaps := parseRecap(output)
+	tests := []struct {
+		alias string
+		want  model.AnsibleHostRecap
+	}{
+		{"web1", model.AnsibleHostRecap{Alias: "web1", Ok: 5, Changed: 2, Skipped: 1}},
+		{"db_2", model.AnsibleHostRecap{Alias: "db_2", Ok: 1, Unreachable: 1}},
+		{"old-host", model.AnsibleHostRecap{Alias: "old-host", Ok: 3, Changed: 1, Failed: 2}},
+	}
+	if len(recaps) != len(tests) {
+		t.Fatalf("recaps = %+v, want %d hosts", recaps, len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			if got := recaps[tt.alias]; got != tt.want {
+				t.Errorf("recap = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := parseRecap("no recap here"); len(got) != 0 {
+		t.Errorf("recaps without PLAY RECAP = %+v, want empty", got)
+	}
+}
+
+// fakeAnsiblePlaybook 模拟 ansible-playbook：检查工作目录中是否有明文凭证，输出连接相关环境变量和 PLAY RECAP
+const fakeAnsiblePlaybook = `#!/bin/sh
+if grep -rqs "s3cret-pass" .; then echo "LEAK password"; fi
+if grep -rqs "PRIVATE KEY" .; then echo "LEAK private key"; fi
+echo "args: $*"
+echo "password=$OPSHUB_ANSIBLE_PASSWORD_1"
+if [ -S "$SSH_AUTH_SOCK" ]; then echo "agent ready"; fi
+echo "PLAY RECAP *****"
+echo "web1 : ok=2 changed=1 unreachable=0 failed=0 skipped=0 rescued=0 ignored=0"
+echo "db1 : ok=1 changed=0 unreachable=0 failed=1 skipped=0 rescued=0 ignored=0"
+exit 2
+`
+
+func TestAnsibleRunnerFakeBinary(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "ansible-playbook")
+	if err := os.WriteFile(binary, []byte(fakeAnsiblePlaybook), 0700); err != nil {
+		t.Fatal(err)
+	}
+	workDir, err := os.MkdirTemp("", "opshub-ansible-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := []*ansibleHost{
+		{
+			host:       assetbiz.Host{Model: gorm.Model{ID: 1}, Name: "web1", IP: "10.0.0.1"},
+			alias:      "web1",
+			credential: &assetbiz.Credential{Type: "password", Username: "root", Password: "s3cret-pass"},
+			knownHost:  "10.0.0.1 ssh-ed25519 AAAA",
+		},
+		{
+			host:       assetbiz.Host{Model: gorm.Model{ID: 2}, Name: "db1", IP: "10.0.0.2", Port: 2222},
+			alias:      "db1",
+			credential: &assetbiz.Credential{Type: "key", Username: "deploy", PrivateKey: string(pem.EncodeToMemory(block))},
+			knownHost:  "[10.0.0.2]:2222 ssh-ed25519 AAAA",
+		},
+	}
+	task := &model.AnsibleTask{Inventory: `{"hostIds":[1,2]}`, Fork: 5, Verbose: "vv"}
+	pb := &ansiblePlaybook{content: []byte("- hosts: all\n  tasks:\n    - shell: uptime\n")}
+
+	r := &AnsibleRunner{binary: binary}
+	ws, err := r.prepareWorkDir(context.Background(), workDir, task, pb, hosts)
+	if err != nil {
+		t.Fatalf("prepareWorkDir: %v", err)
+	}
+	defer ws.Close()
+
+	// 私钥只存在于 agent 中
+	conn, err := net.Dial("unix", ws.agent.socket)
+	if err != nil {
+		t.Fatalf("dial agent: %v", err)
+	}
+	keys, err := agent.NewClient(conn).List()
+	conn.Close()
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("agent keys = %d, err = %v, want 1", len(keys), err)
+	}
+	pub, err := os.ReadFile(filepath.Join(workDir, "keys", "host_2.pub"))
+	if want := ssh.MarshalAuthorizedKey(keys[0]); err != nil || string(pub) != string(want) {
+		t.Errorf("public key file = %q, want %q", pub, want)
+	}
+
+	stream := newJobStream(1)
+	output := &combinedOutput{}
+	stdout := &lineWriter{stream: stream, typ: EventStdout, combined: output, secrets: ws.secrets}
+	cmd := r.command(context.Background(), workDir, ws)
+	cmd.Stdout = stdout
+	cmd.Stderr = stdout
+	err = cmd.Run()
+	stdout.Flush()
+	if cmd.ProcessState == nil || cmd.ProcessState.ExitCode() != 2 {
+		t.Fatalf("exit = %v, want exit code 2", err)
+	}
+
+	raw := output.String()
+	checks := []struct {
+		name string
+		ok   bool
+	}{
+		{"工作目录中没有明文密码或私钥", !strings.Contains(raw, "LEAK")},
+		{"密码通过环境变量传入", strings.Contains(raw, "password=s3cret-pass")},
+		{"ssh-agent 可用", strings.Contains(raw, "agent ready")},
+		{"参数包含清单和并发数", strings.Contains(raw, "-i "+filepath.Join(workDir, "inventory.json")+" --forks 5 -vv")},
+	}
+	for _, c := range checks {
+		if !c.ok {
+			t.Errorf("%s: output = %q", c.name, raw)
+		}
+	}
+	for _, ev := range stream.snapshot() {
+		if strings.Contains(ev.Data, "s3cret-pass") {
+			t.Errorf("password not redacted in stream: %q", ev.Data)
+		}
+	}
+
+	recaps := parseRecap(raw)
+	if recaps["web1"].Ok != 2 || recaps["db1"].Failed != 1 {
+		t.Errorf("recaps = %+v", recaps)
+	}
+}
+
+func TestAnsibleJumps(t *testing.T) {
+	workDir := t.TempDir()
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential := &assetbiz.Credential{Type: "key", Username: "jump", PrivateKey: string(pem.EncodeToMemory(block))}
+	gateway := func(id uint, ip string) *ansibleGateway {
+		return &ansibleGateway{
+			host:       &assetbiz.Host{Model: gorm.Model{ID: id}, IP: ip},
+			credential: credential,
+			knownHost:  ip + " ssh-ed25519 AAAA",
+		}
+	}
+	bastion, inner1, inner2 := gateway(5, "10.0.0.5"), gateway(6, "10.0.0.6"), gateway(7, "10.0.0.7")
+
+	ws := &ansibleWorkspace{}
+	defer ws.Close()
+	jumps := &ansibleJumps{entries: make(map[string]bool)}
+	knownHostsFile := filepath.Join(workDir, "known_hosts")
+	keyDir := filepath.Join(workDir, "keys")
+
+	tests := []struct {
+		name     string
+		gateways []*ansibleGateway
+		want     string
+	}{
+		{name: "两跳链路", gateways: []*ansibleGateway{bastion, inner1}, want: "opshub-gw-5-6"},
+		{name: "共用第一跳", gateways: []*ansibleGateway{bastion, inner2}, want: "opshub-gw-5-7"},
+		{name: "重复链路复用条目", gateways: []*ansibleGateway{bastion, inner1}, want: "opshub-gw-5-6"},
+		{name: "单跳", gateways: []*ansibleGateway{bastion}, want: "opshub-gw-5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jumps.add(workDir, keyDir, knownHostsFile, tt.gateways, ws)
+			if err != nil || got != tt.want {
+				t.Errorf("add() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	config := jumps.config.String()
+	if n := strings.Count(config, "Host opshub-gw-"); n != 3 {
+		t.Errorf("entries = %d, want 3:\n%s", n, config)
+	}
+	for _, want := range []string{
+		"Host opshub-gw-5-6\n  HostName 10.0.0.6\n  Port 22\n  User jump\n",
+		"ProxyJump opshub-gw-5\n",
+		"UserKnownHostsFile \"" + knownHostsFile + "\"",
+		"IdentityFile \"" + filepath.Join(keyDir, "host_5.pub") + "\"",
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config missing %q:\n%s", want, config)
+		}
+	}
+	if len(jumps.knownHosts) != 3 {
+		t.Errorf("knownHosts = %v, want 3 lines", jumps.knownHosts)
+	}
+
+}
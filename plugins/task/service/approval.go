@@ -0,0 +1,567 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	auditbiz "github.com/ydcloud-dy/opshub/internal/biz/audit"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrApprovalNotFound 审批单不存在
+	ErrApprovalNotFound = errors.New("审批单不存在")
+	// ErrApprovalDecided 审批单已处理
+	ErrApprovalDecided = errors.New("审批单已处理，不能重复操作")
+	// ErrNotApprover 当前用户不是该审批单的审批人
+	ErrNotApprover = errors.New("您不是该任务的审批人")
+	// ErrSelfApproval 不能审批自己提交的任务
+	ErrSelfApproval = errors.New("不能审批自己提交的任务")
+	// ErrCommandDenied 命令被策略拦截
+	ErrCommandDenied = errors.New("命令被安全策略拦截")
+)
+
+// ApprovalService 任务审批服务
+type ApprovalService struct {
+	db       *gorm.DB
+	executor *Executor
+}
+
+// NewApprovalService 创建任务审批服务
+func NewApprovalService(db *gorm.DB, executor *Executor) *ApprovalService {
+	return &ApprovalService{db: db, executor: executor}
+}
+
+// ApprovalRequirement 任务需要审批的原因及审批人
+type ApprovalRequirement struct {
+	Reasons []string
+	Matches []PolicyMatch
+	RuleIDs []uint
+	RoleIDs []uint
+	DeptIDs []uint
+}
+
+// PendingExecution 审批通过后执行所需的参数
+type PendingExecution struct {
+	ScriptType   string
+	Content      string
+	Display      string // 供审批人查看的内容，为空时使用脱敏后的 Content
+	Fork         int
+	Timeout      int // 单台主机超时（秒）
+	BatchTimeout int // 整批任务超时（秒）
+	Rolling      *RollingStrategy
+	Secrets      []string
+}
+
+// Requirement 根据策略评估结果和审批规则判断任务是否需要审批
+// 命中需审批策略，或目标主机属于审批规则指定的分组时需要审批，不需要时返回 nil
+func (a *ApprovalService) Requirement(ctx context.Context, decision *PolicyDecision) (*ApprovalRequirement, error) {
+	var rules []model.ApprovalRule
+	if err := a.db.WithContext(ctx).
+		Where("status = 1 AND deleted_at IS NULL").
+		Order("id ASC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("加载审批规则失败: %w", err)
+	}
+
+	req := &ApprovalRequirement{}
+	roles := make(map[uint]bool)
+	depts := make(map[uint]bool)
+	use := func(rule *model.ApprovalRule) {
+		req.RuleIDs = append(req.RuleIDs, rule.ID)
+		ids, _ := parseIDSet(rule.ApproverRoleIDs)
+		for id := range ids {
+			roles[id] = true
+		}
+		ids, _ = parseIDSet(rule.ApproverDeptIDs)
+		for id := range ids {
+			depts[id] = true
+		}
+	}
+
+	scope := toSet(decision.GroupIDs)
+	var defaults []*model.ApprovalRule
+	for i := range rules {
+		rule := &rules[i]
+		groups, err := parseIDSet(rule.AssetGroupIDs)
+		if err != nil {
+			continue
+		}
+		if len(groups) == 0 {
+			defaults = append(defaults, rule)
+			continue
+		}
+		if len(scope) > 0 && intersects(groups, scope) {
+			req.Reasons = append(req.Reasons, fmt.Sprintf("目标主机属于需审批的分组（规则【%s】）", rule.Name))
+			use(rule)
+		}
+	}
+
+	if decision.Action == model.PolicyActionRequireApproval {
+		req.Reasons = append([]string{decision.Reason}, req.Reasons...)
+		for _, m := range decision.Matches {
+			if m.Action == model.PolicyActionRequireApproval {
+				req.Matches = append(req.Matches, m)
+			}
+		}
+		for _, rule := range defaults {
+			use(rule)
+		}
+	}
+	if len(req.Reasons) == 0 {
+		return nil, nil
+	}
+
+	req.RoleIDs = sortedIDs(roles)
+	req.DeptIDs = sortedIDs(depts)
+	return req, nil
+}
+
+// Submit 为已以 pending_approval 状态创建的任务生成审批单
+func (a *ApprovalService) Submit(ctx context.Context, jobTask *model.JobTask, req *ApprovalRequirement, exec PendingExecution) (*model.JobApproval, error) {
+	reason := ""
+	for i, r := range req.Reasons {
+		if i > 0 {
+			reason += "；"
+		}
+		reason += r
+	}
+	matchesJSON, _ := json.Marshal(req.Matches)
+	ruleIDsJSON, _ := json.Marshal(nonNilIDs(req.RuleIDs))
+	roleIDsJSON, _ := json.Marshal(nonNilIDs(req.RoleIDs))
+	deptIDsJSON, _ := json.Marshal(nonNilIDs(req.DeptIDs))
+	if exec.Secrets == nil {
+		exec.Secrets = []string{}
+	}
+	secretsJSON, _ := json.Marshal(exec.Secrets)
+	display := exec.Display
+	if display == "" {
+		display = RedactSecrets(exec.Content, exec.Secrets)
+	}
+	// 待执行的脚本包含 secret 变量取值，与取值列表一起加密保存
+	content, err := sealValue(exec.Content)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := sealValue(string(secretsJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	username := a.username(ctx, jobTask.CreatedBy)
+	approval := &model.JobApproval{
+		JobID:           jobTask.ID,
+		Status:          model.ApprovalStatusPending,
+		Reason:          reason,
+		PolicyMatches:   string(matchesJSON),
+		RuleIDs:         string(ruleIDsJSON),
+		ApproverRoleIDs: string(roleIDsJSON),
+		ApproverDeptIDs: string(deptIDsJSON),
+		ScriptType:      exec.ScriptType,
+		Content:         content,
+		DisplayContent:  display,
+		Secrets:         secrets,
+		Fork:            exec.Fork,
+		Timeout:         exec.Timeout,
+		BatchTimeout:    exec.BatchTimeout,
+		Rolling:         encodeRolling(exec.Rolling),
+		RequestedBy:     jobTask.CreatedBy,
+		RequestedByName: username,
+	}
+	if err := a.db.WithContext(ctx).Create(approval).Error; err != nil {
+		return nil, fmt.Errorf("创建审批单失败: %w", err)
+	}
+	a.record(approval, model.ApprovalActionSubmit, jobTask.CreatedBy, username, reason)
+	a.audit(approval, jobTask.CreatedBy, username, "提交审批", fmt.Sprintf("任务【%s】提交审批：%s", jobTask.Name, reason))
+	return approval, nil
+}
+
+// CanApprove 判断用户是否可以审批
+// 审批单指定了角色或部门时按角色、部门（含下级部门）匹配，未指定时由管理员审批；提交人不能审批自己的任务
+func (a *ApprovalService) CanApprove(ctx context.Context, approval *model.JobApproval, userID uint) (bool, error) {
+	if userID == 0 {
+		return false, nil
+	}
+	if userID == approval.RequestedBy {
+		return false, ErrSelfApproval
+	}
+	if admin, err := IsAdmin(ctx, a.db, userID); err != nil || admin {
+		return admin, err
+	}
+
+	roleScope, _ := parseIDSet(approval.ApproverRoleIDs)
+	deptScope, _ := parseIDSet(approval.ApproverDeptIDs)
+	if len(roleScope) > 0 {
+		roleIDs, err := UserRoleIDs(ctx, a.db, userID)
+		if err != nil {
+			return false, err
+		}
+		for _, id := range roleIDs {
+			if roleScope[id] {
+				return true, nil
+			}
+		}
+	}
+	if len(deptScope) > 0 {
+		var user rbacbiz.SysUser
+		if err := a.db.WithContext(ctx).Select("id, department_id").First(&user, userID).Error; err != nil {
+			return false, nil
+		}
+		deptID := user.DepartmentID
+		for depth := 0; deptID != 0 && depth < 32; depth++ {
+			if deptScope[deptID] {
+				return true, nil
+			}
+			var dept rbacbiz.SysDepartment
+			if err := a.db.WithContext(ctx).Select("id, parent_id").First(&dept, deptID).Error; err != nil {
+				break
+			}
+			deptID = dept.ParentID
+		}
+	}
+	return false, nil
+}
+
+// Approve 审批通过并自动执行任务
+func (a *ApprovalService) Approve(ctx context.Context, approvalID, userID uint, username, comment string) (*model.JobApproval, error) {
+	approval, err := a.checkDecision(ctx, approvalID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var jobTask model.JobTask
+	err = a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.JobApproval{}).
+			Where("id = ? AND status = ?", approval.ID, model.ApprovalStatusPending).
+			Updates(map[string]interface{}{
+				"status":           model.ApprovalStatusApproved,
+				"approved_by":      userID,
+				"approved_by_name": username,
+				"comment":          comment,
+				"decided_at":       &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrApprovalDecided
+		}
+		// 任务可能已被提交人取消
+		result = tx.Model(&model.JobTask{}).
+			Where("id = ? AND status = ?", approval.JobID, model.JobStatusPendingApproval).
+			Updates(map[string]interface{}{
+				"status":       model.JobStatusRunning,
+				"execute_time": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrApprovalDecided
+		}
+		return tx.First(&jobTask, approval.JobID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.record(approval, model.ApprovalActionApprove, userID, username, comment)
+	a.audit(approval, userID, username, "审批通过", fmt.Sprintf("审批通过任务【%s】：%s", jobTask.Name, comment))
+
+	a.execute(approval, &jobTask)
+	a.record(approval, model.ApprovalActionExecute, 0, "system", "审批通过后自动执行")
+	a.audit(approval, userID, username, "执行", fmt.Sprintf("任务【%s】审批通过后自动执行", jobTask.Name))
+
+	a.db.First(approval, approval.ID)
+	return approval, nil
+}
+
+// Reject 审批拒绝，任务状态置为 rejected
+func (a *ApprovalService) Reject(ctx context.Context, approvalID, userID uint, username, comment string) (*model.JobApproval, error) {
+	approval, err := a.checkDecision(ctx, approvalID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.JobApproval{}).
+			Where("id = ? AND status = ?", approval.ID, model.ApprovalStatusPending).
+			Updates(map[string]interface{}{
+				"status":           model.ApprovalStatusRejected,
+				"approved_by":      userID,
+				"approved_by_name": username,
+				"comment":          comment,
+				"decided_at":       &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrApprovalDecided
+		}
+		message := fmt.Sprintf("审批被 %s 拒绝", username)
+		if comment != "" {
+			message += "：" + comment
+		}
+		return tx.Model(&model.JobTask{}).
+			Where("id = ? AND status = ?", approval.JobID, model.JobStatusPendingApproval).
+			Updates(map[string]interface{}{
+				"status":        model.JobStatusRejected,
+				"error_message": message,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.record(approval, model.ApprovalActionReject, userID, username, comment)
+	a.audit(approval, userID, username, "审批拒绝", fmt.Sprintf("拒绝任务 #%d：%s", approval.JobID, comment))
+	a.syncSchedule(approval.JobID, model.JobStatusRejected)
+	a.syncAnsible(approval.JobID, model.JobStatusRejected)
+
+	a.db.First(approval, approval.ID)
+	return approval, nil
+}
+
+// Comment 提交人或审批人添加评论
+func (a *ApprovalService) Comment(ctx context.Context, approvalID, userID uint, username, comment string) (*model.JobApprovalRecord, error) {
+	var approval model.JobApproval
+	if err := a.db.WithContext(ctx).First(&approval, approvalID).Error; err != nil {
+		return nil, ErrApprovalNotFound
+	}
+	if userID != approval.RequestedBy {
+		if ok, err := a.CanApprove(ctx, &approval, userID); err != nil || !ok {
+			return nil, ErrNotApprover
+		}
+	}
+	record := a.record(&approval, model.ApprovalActionComment, userID, username, comment)
+	a.audit(&approval, userID, username, "审批评论", fmt.Sprintf("评论任务 #%d 的审批：%s", approval.JobID, comment))
+	return record, nil
+}
+
+// Cancel 任务在审批前被取消时撤销审批单
+func (a *ApprovalService) Cancel(ctx context.Context, jobID, userID uint, username string) {
+	var approval model.JobApproval
+	if err := a.db.WithContext(ctx).Where("job_id = ?", jobID).First(&approval).Error; err != nil {
+		return
+	}
+	now := time.Now()
+	result := a.db.WithContext(ctx).Model(&model.JobApproval{}).
+		Where("id = ? AND status = ?", approval.ID, model.ApprovalStatusPending).
+		Updates(map[string]interface{}{"status": model.ApprovalStatusCancelled, "decided_at": &now})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+	a.record(&approval, model.ApprovalActionCancel, userID, username, "任务已取消")
+	a.audit(&approval, userID, username, "撤销审批", fmt.Sprintf("取消待审批任务 #%d", jobID))
+	a.syncSchedule(jobID, model.JobStatusCancelled)
+	a.syncAnsible(jobID, model.JobStatusCancelled)
+}
+
+// checkDecision 加载待审批的审批单并校验审批人
+func (a *ApprovalService) checkDecision(ctx context.Context, approvalID, userID uint) (*model.JobApproval, error) {
+	var approval model.JobApproval
+	if err := a.db.WithContext(ctx).First(&approval, approvalID).Error; err != nil {
+		return nil, ErrApprovalNotFound
+	}
+	if approval.Status != model.ApprovalStatusPending {
+		return nil, ErrApprovalDecided
+	}
+	ok, err := a.CanApprove(ctx, &approval, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotApprover
+	}
+	return &approval, nil
+}
+
+// execute 按审批单保存的参数提交执行
+func (a *ApprovalService) execute(approval *model.JobApproval, jobTask *model.JobTask) {
+	content, err := openSecret(approval.Content)
+	if err != nil {
+		a.fail(approval, jobTask, err.Error())
+		return
+	}
+	if approval.ScriptType == AnsibleScriptType {
+		if a.executor.ansible == nil {
+			a.fail(approval, jobTask, "Ansible执行器未启用")
+			return
+		}
+		a.executor.ansible.resume(jobTask, content)
+		return
+	}
+
+	secretsJSON, err := openSecret(approval.Secrets)
+	if err != nil {
+		a.fail(approval, jobTask, err.Error())
+		return
+	}
+	var hostIDs []uint
+	json.Unmarshal([]byte(jobTask.TargetHosts), &hostIDs)
+	var secrets []string
+	json.Unmarshal([]byte(secretsJSON), &secrets)
+
+	opts := ExecuteOptions{
+		ScriptType:   approval.ScriptType,
+		Content:      content,
+		Fork:         approval.Fork,
+		HostTimeout:  time.Duration(approval.Timeout) * time.Second,
+		BatchTimeout: time.Duration(approval.BatchTimeout) * time.Second,
+		Secrets:      secrets,
+		Rolling:      decodeRolling(approval.Rolling),
+	}
+	if jobTask.ScheduleID != nil {
+		opts.OnComplete = func(jobID uint, status string) {
+			a.syncSchedule(jobID, status)
+		}
+	}
+	a.syncSchedule(jobTask.ID, model.JobStatusRunning)
+	a.executor.Submit(jobTask, hostIDs, opts)
+}
+
+// fail 审批通过后无法提交执行时将任务标记为失败
+func (a *ApprovalService) fail(approval *model.JobApproval, jobTask *model.JobTask, message string) {
+	a.db.Model(jobTask).Updates(map[string]interface{}{"status": model.JobStatusFailed, "error_message": message})
+	if approval.ScriptType == AnsibleScriptType {
+		a.syncAnsible(jobTask.ID, model.JobStatusFailed)
+	} else {
+		a.syncSchedule(jobTask.ID, model.JobStatusFailed)
+	}
+}
+
+// EncryptApprovalSecrets 加密历史审批单中明文保存的待执行脚本和 secret 取值
+func EncryptApprovalSecrets(db *gorm.DB) error {
+	var approvals []model.JobApproval
+	if err := db.Select("id, content, secrets").
+		Where("content NOT LIKE ? OR secrets NOT LIKE ?", encryptedPrefix+"%", encryptedPrefix+"%").
+		Find(&approvals).Error; err != nil {
+		return err
+	}
+	for _, approval := range approvals {
+		updates := map[string]interface{}{}
+		for column, value := range map[string]string{"content": approval.Content, "secrets": approval.Secrets} {
+			if isSealed(value) {
+				continue
+			}
+			sealed, err := sealValue(value)
+			if err != nil {
+				return err
+			}
+			updates[column] = sealed
+		}
+		if err := db.Model(&model.JobApproval{}).Where("id = ?", approval.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncSchedule 定时任务触发的任务状态变化时同步定时任务的最近执行状态
+func (a *ApprovalService) syncSchedule(jobID uint, status string) {
+	a.db.Model(&model.JobSchedule{}).
+		Where("last_job_id = ?", jobID).
+		Update("last_status", status)
+}
+
+// syncAnsible 待审批的Ansible任务被拒绝或取消时同步Ansible任务状态
+func (a *ApprovalService) syncAnsible(jobID uint, status string) {
+	a.db.Model(&model.AnsibleTask{}).
+		Where("last_job_id = ? AND status = ?", jobID, model.JobStatusPendingApproval).
+		Update("status", status)
+}
+
+// record 写入审批流转记录
+func (a *ApprovalService) record(approval *model.JobApproval, action string, userID uint, username, comment string) *model.JobApprovalRecord {
+	record := &model.JobApprovalRecord{
+		ApprovalID: approval.ID,
+		JobID:      approval.JobID,
+		Action:     action,
+		UserID:     userID,
+		Username:   username,
+		Comment:    comment,
+	}
+	if err := a.db.Create(record).Error; err != nil {
+		logger.Error("保存审批记录失败", zap.Uint("approvalId", approval.ID), zap.Error(err))
+	}
+	return record
+}
+
+// audit 写入操作审计日志，审批流程中由系统触发的动作不经过HTTP审计中间件，需要单独记录
+func (a *ApprovalService) audit(approval *model.JobApproval, userID uint, username, action, description string) {
+	params, _ := json.Marshal(map[string]interface{}{
+		"approvalId": approval.ID,
+		"jobId":      approval.JobID,
+	})
+	if len([]rune(description)) > 200 {
+		description = string([]rune(description)[:197]) + "..."
+	}
+	log := &auditbiz.SysOperationLog{
+		UserID:      userID,
+		Username:    username,
+		Module:      "任务中心",
+		Action:      action,
+		Description: description,
+		Path:        fmt.Sprintf("/api/v1/plugins/task/approvals/%d", approval.ID),
+		Params:      string(params),
+		Status:      200,
+	}
+	if err := a.db.Create(log).Error; err != nil {
+		logger.Error("保存审批审计日志失败", zap.Uint("approvalId", approval.ID), zap.Error(err))
+	}
+}
+
+// username 查询用户名
+func (a *ApprovalService) username(ctx context.Context, userID uint) string {
+	var user rbacbiz.SysUser
+	if err := a.db.WithContext(ctx).Select("id, username").First(&user, userID).Error; err != nil {
+		return ""
+	}
+	return user.Username
+}
+
+func sortedIDs(set map[uint]bool) []uint {
+	ids := make([]uint, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func nonNilIDs(ids []uint) []uint {
+	if ids == nil {
+		return []uint{}
+	}
+	return ids
+}
@@ -0,0 +1,400 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// 嵌套解析的最大深度，防止 bash -c "eval ..." 之类的层层嵌套
+const maxParseDepth = 5
+
+// CommandEvent 从脚本语法树中提取出的一次可被策略匹配的行为
+type CommandEvent struct {
+	Kind    string   `json:"kind"`              // 与策略匹配类型对应：command, redirect, pipe_shell, dynamic, env
+	Command string   `json:"command,omitempty"` // 命令名（去掉路径）、管道上游命令或变量名
+	Args    []string `json:"args,omitempty"`    // 命令参数，无法静态确定的参数保留原文
+	Target  string   `json:"target,omitempty"`  // 重定向目标路径或变量值
+	Line    uint     `json:"line"`              // 所在行号
+	Text    string   `json:"text"`              // 对应的脚本片段
+}
+
+// 包装命令：真正执行的是其参数中的命令
+var wrapperCommands = map[string]bool{
+	"sudo": true, "env": true, "nohup": true, "timeout": true, "nice": true, "ionice": true,
+	"command": true, "exec": true, "xargs": true, "time": true, "stdbuf": true, "setsid": true,
+	"builtin": true, "doas": true, "chroot": true, "watch": true, "flock": true, "busybox": true,
+}
+
+// 包装命令中需要跳过取值的选项
+var wrapperValueOptions = map[string]map[string]bool{
+	"sudo":    {"-u": true, "-g": true, "-C": true, "-h": true, "-p": true, "-U": true, "-r": true, "-t": true, "-T": true, "-D": true},
+	"doas":    {"-u": true, "-C": true},
+	"env":     {"-u": true, "-C": true, "-S": true},
+	"timeout": {"-s": true, "-k": true, "--signal": true, "--kill-after": true},
+	"nice":    {"-n": true, "--adjustment": true},
+	"ionice":  {"-c": true, "-n": true, "-p": true},
+	"xargs":   {"-I": true, "-n": true, "-P": true, "-d": true, "-L": true, "-s": true, "-E": true, "-a": true},
+	"watch":   {"-n": true, "-d": true},
+	"flock":   {"-w": true, "-E": true},
+}
+
+// 可以从参数或标准输入读取脚本执行的 shell 解释器
+var shellInterpreters = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true, "ash": true, "fish": true,
+	"python": true, "python2": true, "python3": true, "perl": true, "ruby": true, "php": true, "node": true,
+}
+
+// ParseScript 将脚本解析为可被策略匹配的行为列表
+// Shell 脚本按 bash 语法解析为语法树，展开 sudo/env/xargs 等包装命令及 bash -c、eval 的静态内容；
+// Python 等其他脚本无法逐条分析，整体视为一次解释器调用，细粒度控制依赖 script 类型的策略
+func ParseScript(scriptType, content string) ([]CommandEvent, error) {
+	if !strings.EqualFold(scriptType, "shell") && scriptType != "" {
+		interpreter := strings.ToLower(scriptType)
+		if interpreter == "python" {
+			interpreter = "python3"
+		}
+		return []CommandEvent{{Kind: "command", Command: interpreter, Line: 1, Text: interpreter}}, nil
+	}
+	p := &scriptParser{}
+	if err := p.parse(content, 0, 0); err != nil {
+		return nil, err
+	}
+	return p.events, nil
+}
+
+type scriptParser struct {
+	events []CommandEvent
+}
+
+// parse 解析一段脚本，嵌套解析时 baseLine 为外层所在行号
+func (p *scriptParser) parse(content string, baseLine uint, depth int) error {
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(content), "")
+	if err != nil {
+		return fmt.Errorf("脚本语法解析失败: %w", err)
+	}
+	var walkErr error
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if walkErr != nil {
+			return false
+		}
+		line := baseLine
+		if line == 0 && node != nil && node.Pos().IsValid() {
+			line = node.Pos().Line()
+		}
+		switch n := node.(type) {
+		case *syntax.Stmt:
+			p.redirects(n, line)
+		case *syntax.BinaryCmd:
+			p.pipeline(n, line)
+		case *syntax.DeclClause:
+			for _, assign := range n.Args {
+				if assign.Name != nil {
+					p.add(CommandEvent{Kind: "env", Command: assign.Name.Value, Target: wordText(assign.Value), Line: line, Text: nodeText(n)})
+				}
+			}
+		case *syntax.CallExpr:
+			for _, assign := range n.Assigns {
+				if assign.Name != nil {
+					p.add(CommandEvent{Kind: "env", Command: assign.Name.Value, Target: wordText(assign.Value), Line: line, Text: nodeText(n)})
+				}
+			}
+			if len(n.Args) > 0 {
+				walkErr = p.call(n, n.Args, line, depth)
+			}
+		}
+		return true
+	})
+	return walkErr
+}
+
+// call 处理一次命令调用，展开包装命令和可静态确定的嵌套脚本
+func (p *scriptParser) call(node syntax.Node, args []*syntax.Word, line uint, depth int) error {
+	text := nodeText(node)
+	for len(args) > 0 {
+		name, ok := wordValue(args[0])
+		if !ok || name == "" || (name != "[" && strings.ContainsAny(name, "{}*?[")) {
+			p.add(CommandEvent{Kind: "dynamic", Command: wordText(args[0]), Line: line, Text: text})
+			return nil
+		}
+		name = path.Base(name)
+		rest := args[1:]
+		values := make([]string, len(rest))
+		static := true
+		for i, arg := range rest {
+			if v, ok := wordValue(arg); ok {
+				values[i] = v
+			} else {
+				values[i] = wordText(arg)
+				static = false
+			}
+		}
+		p.add(CommandEvent{Kind: "command", Command: name, Args: values, Line: line, Text: text})
+
+		switch {
+		case name == "eval":
+			if !static {
+				p.add(CommandEvent{Kind: "dynamic", Command: name, Args: values, Line: line, Text: text})
+				return nil
+			}
+			return p.nested(strings.Join(values, " "), line, depth)
+		case shellInterpreters[name]:
+			for i, v := range values {
+				if v != "-c" {
+					continue
+				}
+				if i+1 >= len(values) {
+					return nil
+				}
+				if _, ok := wordValue(rest[i+1]); !ok {
+					p.add(CommandEvent{Kind: "dynamic", Command: name, Args: values, Line: line, Text: text})
+					return nil
+				}
+				if name == "sh" || name == "bash" || name == "zsh" || name == "dash" || name == "ksh" || name == "ash" {
+					return p.nested(values[i+1], line, depth)
+				}
+				return nil
+			}
+			return nil
+		case wrapperCommands[name]:
+			args = p.unwrap(name, rest, line, text)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// unwrap 跳过包装命令自身的选项，返回被包装的命令及其参数
+func (p *scriptParser) unwrap(name string, args []*syntax.Word, line uint, text string) []*syntax.Word {
+	valueOptions := wrapperValueOptions[name]
+	positional := 0
+	if name == "timeout" {
+		positional = 1 // timeout DURATION COMMAND
+	}
+	if name == "chroot" {
+		positional = 1 // chroot NEWROOT COMMAND
+	}
+	for len(args) > 0 {
+		v, ok := wordValue(args[0])
+		if !ok {
+			return args
+		}
+		switch {
+		case v == "--":
+			args = args[1:]
+			continue
+		case strings.HasPrefix(v, "-") && len(v) > 1:
+			args = args[1:]
+			if valueOptions[v] && len(args) > 0 {
+				args = args[1:]
+			}
+			continue
+		case name == "env" && strings.Contains(v, "="):
+			kv := strings.SplitN(v, "=", 2)
+			p.add(CommandEvent{Kind: "env", Command: kv[0], Target: kv[1], Line: line, Text: text})
+			args = args[1:]
+			continue
+		case positional > 0:
+			positional--
+			args = args[1:]
+			continue
+		}
+		break
+	}
+	return args
+}
+
+// nested 解析 eval、bash -c 中可静态确定的脚本内容
+func (p *scriptParser) nested(content string, line uint, depth int) error {
+	if depth+1 >= maxParseDepth {
+		p.add(CommandEvent{Kind: "dynamic", Command: "eval", Line: line, Text: content})
+		return nil
+	}
+	if err := p.parse(content, line, depth+1); err != nil {
+		// 内层无法解析时无法判断其行为，按动态命令处理
+		p.add(CommandEvent{Kind: "dynamic", Command: "eval", Line: line, Text: content})
+	}
+	return nil
+}
+
+// redirects 提取写入型重定向
+func (p *scriptParser) redirects(stmt *syntax.Stmt, line uint) {
+	for _, r := range stmt.Redirs {
+		switch r.Op {
+		case syntax.RdrOut, syntax.AppOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll, syntax.RdrInOut:
+		default:
+			continue
+		}
+		if r.Word == nil {
+			continue
+		}
+		target, ok := wordValue(r.Word)
+		if !ok {
+			target = wordText(r.Word)
+		}
+		p.add(CommandEvent{Kind: "redirect", Target: target, Line: line, Text: nodeText(stmt)})
+	}
+}
+
+// pipeline 识别把上游输出交给解释器执行的管道，如 curl ... | bash、base64 -d | sh
+func (p *scriptParser) pipeline(cmd *syntax.BinaryCmd, line uint) {
+	if cmd.Op != syntax.Pipe && cmd.Op != syntax.PipeAll {
+		return
+	}
+	call, ok := cmd.Y.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return
+	}
+	name, ok := wordValue(call.Args[0])
+	if !ok {
+		return
+	}
+	// 跳过 sudo 等包装命令，找到真正接收管道输入的程序
+	args := call.Args
+	for wrapperCommands[path.Base(name)] {
+		args = p.unwrapSilently(path.Base(name), args[1:])
+		if len(args) == 0 {
+			return
+		}
+		if name, ok = wordValue(args[0]); !ok {
+			return
+		}
+	}
+	name = path.Base(name)
+	if !shellInterpreters[name] {
+		return
+	}
+	// 带脚本文件或 -c 参数时并不从标准输入读取脚本，-s 和 -- 之后是传给脚本的参数
+	for _, arg := range args[1:] {
+		v, ok := wordValue(arg)
+		if v == "-s" || v == "--" {
+			break
+		}
+		if !ok || v == "-c" || (!strings.HasPrefix(v, "-") && v != "") {
+			return
+		}
+	}
+
+	upstream := ""
+	x := cmd.X
+	for {
+		if bin, ok := x.Cmd.(*syntax.BinaryCmd); ok && (bin.Op == syntax.Pipe || bin.Op == syntax.PipeAll) {
+			x = bin.Y
+			continue
+		}
+		break
+	}
+	if c, ok := x.Cmd.(*syntax.CallExpr); ok && len(c.Args) > 0 {
+		if v, ok := wordValue(c.Args[0]); ok {
+			upstream = path.Base(v)
+		} else {
+			upstream = wordText(c.Args[0])
+		}
+	}
+	p.add(CommandEvent{Kind: "pipe_shell", Command: upstream, Target: name, Line: line, Text: nodeText(cmd)})
+}
+
+// unwrapSilently 与 unwrap 相同但不记录环境变量行为，用于管道分析时的重复遍历
+func (p *scriptParser) unwrapSilently(name string, args []*syntax.Word) []*syntax.Word {
+	saved := p.events
+	rest := p.unwrap(name, args, 0, "")
+	p.events = saved
+	return rest
+}
+
+func (p *scriptParser) add(event CommandEvent) {
+	p.events = append(p.events, event)
+}
+
+// wordValue 返回不含变量、命令替换等动态部分的单词的字面值，去除引号和转义
+// 如 \rm、'rm'、"r"m 均得到 rm
+func wordValue(w *syntax.Word) (string, bool) {
+	if w == nil {
+		return "", true
+	}
+	var sb strings.Builder
+	for _, part := range w.Parts {
+		switch v := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(unescape(v.Value, false))
+		case *syntax.SglQuoted:
+			sb.WriteString(v.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range v.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				sb.WriteString(unescape(lit.Value, true))
+			}
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
+// unescape 去除反斜杠转义，双引号内只有 $ ` " \ 和换行可以被转义
+func unescape(s string, quoted bool) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		next := s[i+1]
+		switch {
+		case next == '\n':
+			i++
+		case !quoted || strings.IndexByte("$`\"\\", next) >= 0:
+			sb.WriteByte(next)
+			i++
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// wordText 返回单词在脚本中的原文
+func wordText(w *syntax.Word) string {
+	if w == nil {
+		return ""
+	}
+	return nodeText(w)
+}
+
+// nodeText 将语法树节点格式化为脚本片段
+func nodeText(node syntax.Node) string {
+	var buf bytes.Buffer
+	if err := syntax.NewPrinter(syntax.SingleLine(true)).Print(&buf, node); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,630 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+const (
+	// DistributeStatusSuccess 主机上的文件已全部更新
+	DistributeStatusSuccess = "success"
+	// DistributeStatusUnchanged 主机上的文件与待分发文件一致，未重新上传
+	DistributeStatusUnchanged = "unchanged"
+	// DistributeStatusFailed 至少一个文件分发失败
+	DistributeStatusFailed = "failed"
+
+	// FileStatusUploaded 文件已上传并校验通过
+	FileStatusUploaded = "uploaded"
+	// FileStatusUnchanged 远程文件校验值一致，跳过上传
+	FileStatusUnchanged = "unchanged"
+	// FileStatusFailed 文件分发失败
+	FileStatusFailed = "failed"
+
+	// DefaultDistributeTimeout 单台主机分发的默认超时时间
+	DefaultDistributeTimeout = 30 * time.Minute
+	// stageRetention 暂存文件的保留时间，超过后无法再重试分发
+	stageRetention = 24 * time.Hour
+)
+
+// ErrStageExpired 暂存文件已被清理
+var ErrStageExpired = errors.New("分发文件已过期清理，请重新上传分发")
+
+var ownerPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*(:[A-Za-z0-9_][A-Za-z0-9_.-]*)?$`)
+
+// DistributeFile 已暂存的待分发文件
+type DistributeFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// DistributeParams 文件分发参数，保存在 JobTask.Parameters 中，重试时据此找回暂存文件
+type DistributeParams struct {
+	Files      []DistributeFile `json:"files"`
+	TargetPath string           `json:"targetPath"`
+	Owner      string           `json:"owner,omitempty"` // 属主，user 或 user:group
+	Mode       string           `json:"mode,omitempty"`  // 八进制权限，如 0644
+	Fork       int              `json:"fork"`            // 并发数
+	Timeout    int              `json:"timeout"`         // 单台主机超时（秒）
+	Stage      string           `json:"stage"`           // 暂存目录名
+}
+
+// FileTransferResult 单个文件的分发结果
+type FileTransferResult struct {
+	Name       string `json:"name"`
+	RemotePath string `json:"remotePath"`
+	Status     string `json:"status"` // uploaded, unchanged, failed
+	Error      string `json:"error,omitempty"`
+}
+
+// FileDistributionResult 单台主机的分发结果
+type FileDistributionResult struct {
+	HostID    uint                 `json:"hostId"`
+	HostName  string               `json:"hostName"`
+	HostIP    string               `json:"hostIp"`
+	Status    string               `json:"status"` // success, unchanged, failed
+	Error     string               `json:"error,omitempty"`
+	Files     []FileTransferResult `json:"files,omitempty"`
+	Attempts  int                  `json:"attempts"` // 分发次数，重试时递增
+	StartTime *time.Time           `json:"startTime,omitempty"`
+	EndTime   *time.Time           `json:"endTime,omitempty"`
+}
+
+// Distributor 文件分发器
+// 上传的文件先暂存到本地并计算 SHA-256，再以有限并发推送到各主机：远程文件校验值一致时跳过上传，
+// 否则写入同目录下的临时文件，校验通过并设置属主、权限后原子重命名为目标文件。
+// 暂存文件保留一段时间，重试时只向失败的主机重新分发
+type Distributor struct {
+	db        *gorm.DB
+	executor  *Executor
+	stageRoot string
+
+	runsMu sync.Mutex
+	runs   map[uint]context.CancelFunc // 正在分发的任务，便于取消
+}
+
+// NewDistributor 创建文件分发器
+// 可通过环境变量 OPSHUB_DISTRIBUTE_DIR 指定暂存目录
+func NewDistributor(db *gorm.DB, executor *Executor) *Distributor {
+	stageRoot := os.Getenv("OPSHUB_DISTRIBUTE_DIR")
+	if stageRoot == "" {
+		stageRoot = filepath.Join(os.TempDir(), "opshub-distribute")
+	}
+	return &Distributor{
+		db:        db,
+		executor:  executor,
+		stageRoot: stageRoot,
+		runs:      make(map[uint]context.CancelFunc),
+	}
+}
+
+// ValidateDistributeParams 校验分发参数
+func ValidateDistributeParams(params *DistributeParams) error {
+	if strings.TrimSpace(params.TargetPath) == "" {
+		return fmt.Errorf("请指定目标路径")
+	}
+	if params.Owner != "" && !ownerPattern.MatchString(params.Owner) {
+		return fmt.Errorf("属主格式错误，应为 user 或 user:group")
+	}
+	if params.Mode != "" {
+		mode, err := strconv.ParseUint(params.Mode, 8, 32)
+		if err != nil || mode > 0o7777 {
+			return fmt.Errorf("权限格式错误，应为八进制，如 0644")
+		}
+	}
+	if params.Fork < 0 || params.Timeout < 0 {
+		return fmt.Errorf("并发数和超时时间不能为负数")
+	}
+	return nil
+}
+
+// Stage 暂存上传的文件并计算 SHA-256，返回暂存目录名及文件信息
+func (d *Distributor) Stage(files []*multipart.FileHeader) (string, []DistributeFile, error) {
+	d.purgeStale()
+
+	if err := os.MkdirAll(d.stageRoot, 0o700); err != nil {
+		return "", nil, fmt.Errorf("创建暂存目录失败: %w", err)
+	}
+	dir, err := os.MkdirTemp(d.stageRoot, "stage-")
+	if err != nil {
+		return "", nil, fmt.Errorf("创建暂存目录失败: %w", err)
+	}
+
+	staged := make([]DistributeFile, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, fileHeader := range files {
+		name := filepath.Base(fileHeader.Filename)
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			os.RemoveAll(dir)
+			return "", nil, fmt.Errorf("文件名 %q 不合法", fileHeader.Filename)
+		}
+		if seen[name] {
+			os.RemoveAll(dir)
+			return "", nil, fmt.Errorf("文件名 %s 重复", name)
+		}
+		seen[name] = true
+
+		file, err := stageFile(fileHeader, filepath.Join(dir, name))
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", nil, err
+		}
+		file.Name = name
+		staged = append(staged, file)
+	}
+	return filepath.Base(dir), staged, nil
+}
+
+// stageFile 将上传的文件写入暂存路径并计算 SHA-256
+func stageFile(fileHeader *multipart.FileHeader, dst string) (DistributeFile, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return DistributeFile{}, fmt.Errorf("打开文件 %s 失败: %w", fileHeader.Filename, err)
+	}
+	defer src.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return DistributeFile{}, fmt.Errorf("暂存文件 %s 失败: %w", fileHeader.Filename, err)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(out, hash), src)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return DistributeFile{}, fmt.Errorf("暂存文件 %s 失败: %w", fileHeader.Filename, err)
+	}
+	return DistributeFile{Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// StageExists 暂存文件是否仍然存在
+func (d *Distributor) StageExists(stage string) bool {
+	dir, ok := d.stageDir(stage)
+	if !ok {
+		return false
+	}
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// Cleanup 删除暂存文件，分发全部成功或任务记录删除后调用
+func (d *Distributor) Cleanup(stage string) {
+	if dir, ok := d.stageDir(stage); ok {
+		os.RemoveAll(dir)
+	}
+}
+
+// stageDir 暂存目录的完整路径，拒绝越出暂存根目录的名称
+func (d *Distributor) stageDir(stage string) (string, bool) {
+	if stage == "" || stage != filepath.Base(stage) || !strings.HasPrefix(stage, "stage-") {
+		return "", false
+	}
+	return filepath.Join(d.stageRoot, stage), true
+}
+
+// purgeStale 清理超过保留时间的暂存目录
+func (d *Distributor) purgeStale() {
+	entries, err := os.ReadDir(d.stageRoot)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-stageRetention)
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "stage-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(d.stageRoot, entry.Name())); err != nil {
+			logger.Warn("清理过期的分发暂存文件失败", zap.String("dir", entry.Name()), zap.Error(err))
+		}
+	}
+}
+
+// Submit 异步分发，立即返回，分发结束后将结果写回任务
+// previous 为重试前的结果，不为空时与本次结果合并；分发不依赖请求上下文，客户端断开不会中断分发
+func (d *Distributor) Submit(jobTask *model.JobTask, params *DistributeParams, hostIDs []uint, previous []FileDistributionResult) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.runsMu.Lock()
+	d.runs[jobTask.ID] = cancel
+	d.runsMu.Unlock()
+
+	go func() {
+		defer func() {
+			d.runsMu.Lock()
+			delete(d.runs, jobTask.ID)
+			d.runsMu.Unlock()
+			cancel()
+		}()
+
+		results, err := d.Distribute(ctx, params, hostIDs)
+		if err != nil {
+			d.db.Model(&model.JobTask{}).
+				Where("id = ? AND status = ?", jobTask.ID, model.JobStatusRunning).
+				Updates(map[string]interface{}{"status": model.JobStatusFailed, "error_message": err.Error()})
+			return
+		}
+		if previous != nil {
+			results = MergeDistributionResults(previous, results)
+		}
+		d.save(jobTask.ID, params, results)
+	}()
+}
+
+// Cancel 取消正在分发的任务，正在进行的传输随连接关闭而中断；任务不在本实例分发时返回 false
+func (d *Distributor) Cancel(jobID uint) bool {
+	d.runsMu.Lock()
+	cancel, ok := d.runs[jobID]
+	d.runsMu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// save 写入分发结果，全部成功后清理暂存文件
+// 结果始终写入以便重试，任务已被取消时保留取消状态
+func (d *Distributor) save(jobID uint, params *DistributeParams, results []FileDistributionResult) {
+	resultJSON, _ := json.Marshal(results)
+	status := DistributionStatus(results)
+	if err := d.db.Model(&model.JobTask{}).Where("id = ?", jobID).Update("result", string(resultJSON)).Error; err != nil {
+		logger.Error("保存分发结果失败", zap.Uint("jobId", jobID), zap.Error(err))
+		return
+	}
+	d.db.Model(&model.JobTask{}).
+		Where("id = ? AND status = ?", jobID, model.JobStatusRunning).
+		Updates(map[string]interface{}{"status": status, "error_message": ""})
+	if status == model.JobStatusSuccess {
+		d.Cleanup(params.Stage)
+	}
+}
+
+// Distribute 以有限并发将暂存文件分发到各主机，结果顺序与 hostIDs 一致
+func (d *Distributor) Distribute(ctx context.Context, params *DistributeParams, hostIDs []uint) ([]FileDistributionResult, error) {
+	dir, ok := d.stageDir(params.Stage)
+	if !ok || !d.StageExists(params.Stage) {
+		return nil, ErrStageExpired
+	}
+
+	fork := params.Fork
+	if fork <= 0 {
+		fork = DefaultFork
+	}
+	if fork > MaxFork {
+		fork = MaxFork
+	}
+	timeout := time.Duration(params.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultDistributeTimeout
+	}
+
+	results := make([]FileDistributionResult, len(hostIDs))
+	sem := make(chan struct{}, fork)
+	var wg sync.WaitGroup
+	for i, hostID := range hostIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, hostID uint) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			hostCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			results[i] = d.distributeToHost(hostCtx, hostID, dir, params)
+		}(i, hostID)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// distributeToHost 分发文件到单个主机
+func (d *Distributor) distributeToHost(ctx context.Context, hostID uint, dir string, params *DistributeParams) FileDistributionResult {
+	start := time.Now()
+	result := FileDistributionResult{
+		HostID:    hostID,
+		Status:    DistributeStatusFailed,
+		Attempts:  1,
+		StartTime: &start,
+	}
+	defer func() {
+		end := time.Now()
+		result.EndTime = &end
+	}()
+
+	host, lease, err := d.executor.ConnectHost(ctx, hostID)
+	if host != nil {
+		result.HostName = host.Name
+		result.HostIP = host.IP
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer lease.Release()
+	client := lease.Client()
+
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		result.Error = fmt.Sprintf("创建SFTP客户端失败: %v", err)
+		return result
+	}
+	defer sftpClient.Close()
+
+	// 超时或取消时关闭SFTP会话，中断正在进行的传输；连接由连接池复用，不在此关闭
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			sftpClient.Close()
+		case <-done:
+		}
+	}()
+
+	if err := sftpClient.MkdirAll(params.TargetPath); err != nil {
+		result.Error = fmt.Sprintf("创建目标目录 %s 失败: %v", params.TargetPath, err)
+		return result
+	}
+
+	uploaded, failed := 0, 0
+	for _, file := range params.Files {
+		transfer := d.transferFile(client, sftpClient, filepath.Join(dir, file.Name), file, params)
+		if ctx.Err() != nil && transfer.Status == FileStatusFailed {
+			transfer.Error = fmt.Sprintf("分发超时或已取消: %s", transfer.Error)
+		}
+		switch transfer.Status {
+		case FileStatusUploaded:
+			uploaded++
+		case FileStatusFailed:
+			failed++
+		}
+		result.Files = append(result.Files, transfer)
+	}
+
+	switch {
+	case failed > 0:
+		result.Error = fmt.Sprintf("%d 个文件分发失败", failed)
+	case uploaded == 0:
+		result.Status = DistributeStatusUnchanged
+	default:
+		result.Status = DistributeStatusSuccess
+	}
+	return result
+}
+
+// transferFile 上传单个文件：写入临时文件，校验 SHA-256 并设置属主、权限后重命名为目标文件
+func (d *Distributor) transferFile(client *ssh.Client, sftpClient *sftp.Client, localPath string, file DistributeFile, params *DistributeParams) FileTransferResult {
+	remotePath := path.Join(params.TargetPath, file.Name)
+	transfer := FileTransferResult{
+		Name:       file.Name,
+		RemotePath: remotePath,
+		Status:     FileStatusFailed,
+	}
+
+	// 远程文件已一致时只同步属主和权限
+	if sum, err := remoteSHA256(client, remotePath); err == nil && sum == file.SHA256 {
+		if err := applyAttributes(client, sftpClient, remotePath, params); err != nil {
+			transfer.Error = err.Error()
+			return transfer
+		}
+		transfer.Status = FileStatusUnchanged
+		return transfer
+	}
+
+	tmpPath := path.Join(params.TargetPath, fmt.Sprintf(".%s.opshub-%d.tmp", file.Name, time.Now().UnixNano()))
+	if err := uploadFile(sftpClient, localPath, tmpPath); err != nil {
+		sftpClient.Remove(tmpPath)
+		transfer.Error = err.Error()
+		return transfer
+	}
+
+	sum, err := remoteSHA256(client, tmpPath)
+	if err != nil {
+		sftpClient.Remove(tmpPath)
+		transfer.Error = fmt.Sprintf("计算远程文件校验值失败: %v", err)
+		return transfer
+	}
+	if sum != file.SHA256 {
+		sftpClient.Remove(tmpPath)
+		transfer.Error = fmt.Sprintf("校验失败: 期望 %s，实际 %s", file.SHA256, sum)
+		return transfer
+	}
+
+	// 覆盖已有文件时，未指定的权限和属主沿用原文件，避免重命名后变为上传用户的默认值
+	if existing, err := sftpClient.Stat(remotePath); err == nil && existing.Mode().IsRegular() {
+		if err := preserveAttributes(sftpClient, existing, tmpPath, params); err != nil {
+			sftpClient.Remove(tmpPath)
+			transfer.Error = err.Error()
+			return transfer
+		}
+	}
+	if err := applyAttributes(client, sftpClient, tmpPath, params); err != nil {
+		sftpClient.Remove(tmpPath)
+		transfer.Error = err.Error()
+		return transfer
+	}
+
+	// 优先使用 posix-rename 扩展原子覆盖目标文件，服务端不支持时回退到 mv
+	if err := sftpClient.PosixRename(tmpPath, remotePath); err != nil {
+		if _, mvErr := runRemote(client, fmt.Sprintf("mv -f -- %s %s", shellescape(tmpPath), shellescape(remotePath))); mvErr != nil {
+			sftpClient.Remove(tmpPath)
+			transfer.Error = fmt.Sprintf("重命名为 %s 失败: %v", remotePath, mvErr)
+			return transfer
+		}
+	}
+
+	transfer.Status = FileStatusUploaded
+	return transfer
+}
+
+// uploadFile 通过SFTP上传本地文件
+func uploadFile(sftpClient *sftp.Client, localPath, remotePath string) error {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("读取暂存文件失败: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := sftpClient.OpenFile(remotePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("创建远程文件 %s 失败: %w", remotePath, err)
+	}
+	_, err = dst.ReadFrom(src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("传输文件失败: %w", err)
+	}
+	return nil
+}
+
+// applyAttributes 设置远程文件的属主和权限
+// 先修改属主再设置权限，chown 会清除 setuid/setgid 位
+func applyAttributes(client *ssh.Client, sftpClient *sftp.Client, remotePath string, params *DistributeParams) error {
+	if params.Owner != "" {
+		if _, err := runRemote(client, fmt.Sprintf("chown -- %s %s", shellescape(params.Owner), shellescape(remotePath))); err != nil {
+			return fmt.Errorf("设置属主失败: %w", err)
+		}
+	}
+	if params.Mode != "" {
+		mode, _ := strconv.ParseUint(params.Mode, 8, 32)
+		if err := sftpClient.Chmod(remotePath, os.FileMode(mode)); err != nil {
+			return fmt.Errorf("设置权限失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// preserveAttributes 将未指定的属主和权限设置为与被覆盖的文件一致
+// 无法保留原属主时（如以普通用户覆盖其他用户的文件）分发失败，而不是静默改变属主
+func preserveAttributes(sftpClient *sftp.Client, existing os.FileInfo, tmpPath string, params *DistributeParams) error {
+	if params.Owner == "" {
+		if stat, ok := existing.Sys().(*sftp.FileStat); ok {
+			if err := sftpClient.Chown(tmpPath, int(stat.UID), int(stat.GID)); err != nil {
+				return fmt.Errorf("保留原文件属主 %d:%d 失败，请指定属主或使用有权限的账号: %w", stat.UID, stat.GID, err)
+			}
+		}
+	}
+	if params.Mode == "" {
+		mode := existing.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+		if err := sftpClient.Chmod(tmpPath, mode); err != nil {
+			return fmt.Errorf("保留原文件权限 %#o 失败: %w", uint32(mode.Perm()), err)
+		}
+	}
+	return nil
+}
+
+// remoteSHA256 计算远程文件的 SHA-256，兼容没有 sha256sum 的系统
+func remoteSHA256(client *ssh.Client, remotePath string) (string, error) {
+	p := shellescape(remotePath)
+	out, err := runRemote(client, fmt.Sprintf("sha256sum -- %s 2>/dev/null || shasum -a 256 -- %s", p, p))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("无法解析校验值输出")
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// runRemote 在远程主机执行命令，返回标准输出
+func runRemote(client *ssh.Client, cmd string) (string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("创建SSH会话失败: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr strings.Builder
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := session.Run(cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// MergeDistributionResults 用重试结果替换原结果中对应主机的记录，分发次数累加
+func MergeDistributionResults(previous, retried []FileDistributionResult) []FileDistributionResult {
+	attempts := make(map[uint]int, len(previous))
+	for _, r := range previous {
+		attempts[r.HostID] = r.Attempts
+	}
+	byHost := make(map[uint]FileDistributionResult, len(retried))
+	for _, r := range retried {
+		r.Attempts += attempts[r.HostID]
+		byHost[r.HostID] = r
+	}
+	merged := make([]FileDistributionResult, 0, len(previous)+len(retried))
+	for _, r := range previous {
+		if retry, ok := byHost[r.HostID]; ok {
+			r = retry
+			delete(byHost, r.HostID)
+		}
+		merged = append(merged, r)
+	}
+	// 上次未写入结果的主机（如服务中断）追加在末尾
+	for _, r := range retried {
+		if _, ok := byHost[r.HostID]; ok {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// DistributionStatus 根据各主机结果计算任务状态
+func DistributionStatus(results []FileDistributionResult) string {
+	for _, r := range results {
+		if r.Status == DistributeStatusFailed {
+			return model.JobStatusFailed
+		}
+	}
+	return model.JobStatusSuccess
+}
@@ -0,0 +1,820 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	assetdata "github.com/ydcloud-dy/opshub/internal/data/asset"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	sshclient "github.com/ydcloud-dy/opshub/pkg/ssh"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultFork 默认并发数
+	DefaultFork = 10
+	// MaxFork 最大并发数
+	MaxFork = 100
+	// DefaultHostTimeout 单台主机默认超时时间
+	DefaultHostTimeout = 300 * time.Second
+	// DefaultBatchTimeout 整批任务默认超时时间
+	DefaultBatchTimeout = time.Hour
+	// killGracePeriod 发送 SIGTERM 后等待远程进程退出的时间，超时后发送 SIGKILL
+	killGracePeriod = 5 * time.Second
+)
+
+// ErrJobFinished 任务已结束
+var ErrJobFinished = errors.New("任务已结束，无法取消")
+
+// ExecuteOptions 执行参数
+type ExecuteOptions struct {
+	ScriptType   string           // Shell, Python
+	Content      string           // 脚本内容
+	Fork         int              // 并发数
+	HostTimeout  time.Duration    // 单台主机超时时间
+	BatchTimeout time.Duration    // 整批任务超时时间
+	Secrets      []string         // 敏感值，推送和保存输出前替换为脱敏值
+	Rolling      *RollingStrategy // 滚动执行策略，为空时所有主机作为一批执行
+
+	// OnComplete 整批任务结束后回调，参数为任务ID与最终状态
+	OnComplete func(jobID uint, status string)
+}
+
+// normalize 填充默认值并限制并发上限
+func (o *ExecuteOptions) normalize() {
+	if o.Fork <= 0 {
+		o.Fork = DefaultFork
+	}
+	if o.Fork > MaxFork {
+		o.Fork = MaxFork
+	}
+	if o.HostTimeout <= 0 {
+		o.HostTimeout = DefaultHostTimeout
+	}
+	if o.BatchTimeout <= 0 {
+		o.BatchTimeout = DefaultBatchTimeout
+	}
+}
+
+// Executor 任务执行器
+// 以有限并发将脚本分发到多台主机执行，每台主机完成后立即把结果写回 JobTask.Result，
+// 执行过程中各主机的输出按行推送到任务输出流，供 WebSocket 客户端实时订阅
+type Executor struct {
+	db        *gorm.DB
+	connector *assetbiz.SSHConnector
+	ansible   *AnsibleRunner // 审批通过后执行Ansible任务
+
+	streamsMu sync.RWMutex
+	streams   map[uint]*jobStream
+
+	runsMu sync.Mutex
+	runs   map[uint]*jobRun
+}
+
+// NewExecutor 创建任务执行器
+func NewExecutor(db *gorm.DB) *Executor {
+	return &Executor{
+		db:        db,
+		connector: assetdata.NewSSHConnector(db),
+		streams:   make(map[uint]*jobStream),
+		runs:      make(map[uint]*jobRun),
+	}
+}
+
+// Cancel 取消正在执行的任务
+// 取消上下文后，正在执行的远程命令会先收到 SIGTERM，超过宽限期仍未退出则发送 SIGKILL，
+// 尚未开始及仍在执行的主机都会被标记为已取消
+func (e *Executor) Cancel(jobID uint, userID uint, username string) error {
+	e.runsMu.Lock()
+	run, ok := e.runs[jobID]
+	e.runsMu.Unlock()
+
+	if ok {
+		run.markCancelled(userID, username)
+		run.cancel()
+		return nil
+	}
+
+	// 执行协程不在本实例中（例如服务重启后遗留的记录或待审批的任务），直接更新任务状态
+	now := time.Now()
+	result := e.db.Model(&model.JobTask{}).
+		Where("id = ? AND status IN ?", jobID, []string{model.JobStatusPending, model.JobStatusPendingApproval, model.JobStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":            model.JobStatusCancelled,
+			"cancelled_by":      userID,
+			"cancelled_by_name": username,
+			"cancelled_at":      &now,
+			"error_message":     fmt.Sprintf("任务已被 %s 取消", username),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobFinished
+	}
+	return nil
+}
+
+// Subscribe 订阅任务输出
+// 返回 seq 大于 since 的已缓存事件及后续事件通道；任务已结束时通道为 nil。
+// ok 为 false 表示内存中没有该任务的输出流（任务未在本实例执行或已过保留期）
+func (e *Executor) Subscribe(jobID uint, since uint64) (replay []OutputEvent, events <-chan OutputEvent, unsubscribe func(), ok bool) {
+	e.streamsMu.RLock()
+	stream, ok := e.streams[jobID]
+	e.streamsMu.RUnlock()
+	if !ok {
+		return nil, nil, func() {}, false
+	}
+
+	replay, ch := stream.subscribe(since)
+	if ch == nil {
+		return replay, nil, func() {}, true
+	}
+	return replay, ch, func() { stream.unsubscribe(ch) }, true
+}
+
+// openStream 创建任务输出流
+func (e *Executor) openStream(jobID uint) *jobStream {
+	stream := newJobStream(jobID)
+	e.streamsMu.Lock()
+	e.streams[jobID] = stream
+	e.streamsMu.Unlock()
+	return stream
+}
+
+// closeStream 结束任务输出流，保留一段时间供客户端重连回放
+// 同时保存输出事件，输出流移出内存后按相同的 seq 回放
+func (e *Executor) closeStream(stream *jobStream, status string) {
+	stream.close(status)
+	if data, err := json.Marshal(stream.snapshot()); err == nil {
+		if err := e.db.Model(&model.JobTask{}).Where("id = ?", stream.jobID).
+			Update("output_events", string(data)).Error; err != nil {
+			logger.Error("保存任务输出失败", zap.Uint("jobId", stream.jobID), zap.Error(err))
+		}
+	}
+	time.AfterFunc(streamRetention, func() {
+		e.streamsMu.Lock()
+		if e.streams[stream.jobID] == stream {
+			delete(e.streams, stream.jobID)
+		}
+		e.streamsMu.Unlock()
+	})
+}
+
+// RecoverStuckTasks 清理因服务重启而中断的任务（状态为 running 但执行协程已丢失）
+func (e *Executor) RecoverStuckTasks() {
+	result := e.db.Model(&model.JobTask{}).
+		Where("status = ? AND deleted_at IS NULL", model.JobStatusRunning).
+		Updates(map[string]interface{}{
+			"status":        model.JobStatusFailed,
+			"error_message": "任务因服务重启而中断，请重新执行",
+		})
+	if result.Error != nil {
+		logger.Error("清理中断的任务失败", zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("已清理中断的任务", zap.Int64("count", result.RowsAffected))
+	}
+}
+
+// Submit 异步执行任务
+// 立即返回各主机的初始结果（pending），实际执行在后台协程中进行
+func (e *Executor) Submit(jobTask *model.JobTask, hostIDs []uint, opts ExecuteOptions) []model.HostExecutionResult {
+	opts.normalize()
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.BatchTimeout)
+	run := &jobRun{
+		db:      e.db,
+		jobID:   jobTask.ID,
+		stream:  e.openStream(jobTask.ID),
+		cancel:  cancel,
+		results: e.initialResults(hostIDs),
+	}
+	batches := planBatches(len(hostIDs), opts.Rolling)
+	if opts.Rolling != nil {
+		run.rollout = newRollout(opts.Rolling, hostIDs, batches)
+		for b, batch := range batches {
+			for _, idx := range batch {
+				run.results[idx].Batch = b + 1
+			}
+		}
+	}
+	run.persist()
+	e.registerRun(run)
+
+	go e.run(ctx, run, hostIDs, batches, opts)
+
+	initial := make([]model.HostExecutionResult, len(run.results))
+	copy(initial, run.results)
+	return initial
+}
+
+// registerRun 登记正在执行的任务，便于取消
+func (e *Executor) registerRun(run *jobRun) {
+	e.runsMu.Lock()
+	e.runs[run.jobID] = run
+	e.runsMu.Unlock()
+}
+
+// unregisterRun 移除执行结束的任务
+func (e *Executor) unregisterRun(run *jobRun) {
+	e.runsMu.Lock()
+	if e.runs[run.jobID] == run {
+		delete(e.runs, run.jobID)
+	}
+	e.runsMu.Unlock()
+}
+
+// initialResults 构造所有主机的初始结果
+func (e *Executor) initialResults(hostIDs []uint) []model.HostExecutionResult {
+	type hostInfo struct {
+		ID   uint   `gorm:"column:id"`
+		Name string `gorm:"column:name"`
+		IP   string `gorm:"column:ip"`
+	}
+	var hosts []hostInfo
+	e.db.Table("hosts").Select("id, name, ip").Where("id IN ?", hostIDs).Find(&hosts)
+	hostMap := make(map[uint]hostInfo, len(hosts))
+	for _, h := range hosts {
+		hostMap[h.ID] = h
+	}
+
+	results := make([]model.HostExecutionResult, 0, len(hostIDs))
+	for _, hostID := range hostIDs {
+		h := hostMap[hostID]
+		results = append(results, model.HostExecutionResult{
+			HostID:   hostID,
+			HostName: h.Name,
+			HostIP:   h.IP,
+			Status:   model.HostStatusPending,
+		})
+	}
+	return results
+}
+
+// run 按批次依次执行，批次内以有限并发执行
+// 滚动执行时每批结束后检查失败率，超过阈值则停止后续批次，否则暂停指定时间后继续下一批
+func (e *Executor) run(ctx context.Context, run *jobRun, hostIDs []uint, batches [][]int, opts ExecuteOptions) {
+	defer func() {
+		run.cancel()
+		e.unregisterRun(run)
+	}()
+
+	for b, batch := range batches {
+		run.startBatch(b)
+		e.runBatch(ctx, run, hostIDs, batch, opts)
+		halted, reason := run.finishBatch(b, opts.Rolling)
+
+		if ctx.Err() != nil || halted {
+			// 任务被取消、批次超时或失败率超限，剩余批次不再执行
+			for rest := b + 1; rest < len(batches); rest++ {
+				for _, idx := range batches[rest] {
+					switch {
+					case halted:
+						run.skip(idx, model.HostStatusSkipped, reason)
+					case run.isCancelled():
+						run.skip(idx, model.HostStatusCancelled, "任务已取消，主机未执行")
+					default:
+						run.skip(idx, model.HostStatusFailed, "批次执行超时，主机未执行")
+					}
+				}
+				run.finishBatch(rest, nil)
+			}
+			break
+		}
+
+		if opts.Rolling != nil && opts.Rolling.PauseSeconds > 0 && b < len(batches)-1 {
+			timer := time.NewTimer(opts.Rolling.pause())
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+			}
+		}
+	}
+
+	status := run.complete()
+	e.closeStream(run.stream, status)
+
+	if opts.OnComplete != nil {
+		opts.OnComplete(run.jobID, status)
+	}
+}
+
+// runBatch 以有限并发执行一个批次的主机，返回时批次内所有主机均已结束
+func (e *Executor) runBatch(ctx context.Context, run *jobRun, hostIDs []uint, batch []int, opts ExecuteOptions) {
+	sem := make(chan struct{}, opts.Fork)
+	var wg sync.WaitGroup
+
+	for i, idx := range batch {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			// 任务被取消或批次超时，剩余主机不再执行
+			for _, rest := range batch[i:] {
+				if run.isCancelled() {
+					run.skip(rest, model.HostStatusCancelled, "任务已取消，主机未执行")
+				} else {
+					run.skip(rest, model.HostStatusFailed, "批次执行超时，主机未执行")
+				}
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(idx int, hostID uint) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error("主机执行异常", zap.Uint("jobId", run.jobID), zap.Uint("hostId", hostID), zap.Any("panic", r))
+					run.skip(idx, model.HostStatusFailed, fmt.Sprintf("执行异常: %v", r))
+				}
+			}()
+
+			hostName := run.start(idx)
+			output := newHostOutput(run.stream, hostID, hostName, opts.Secrets)
+			hostCtx, hostCancel := context.WithTimeout(ctx, opts.HostTimeout)
+			result := e.executeOnHost(hostCtx, hostID, opts.ScriptType, opts.Content, output)
+			hostCancel()
+			run.finish(idx, result)
+		}(idx, hostIDs[idx])
+	}
+
+	wg.Wait()
+}
+
+// executeOnHost 在单个主机上执行任务
+func (e *Executor) executeOnHost(ctx context.Context, hostID uint, scriptType, content string, output *hostOutput) model.HostExecutionResult {
+	result := model.HostExecutionResult{
+		HostID: hostID,
+		Status: model.HostStatusFailed,
+	}
+
+	host, lease, err := e.ConnectHost(ctx, hostID)
+	if host != nil {
+		result.HostName = host.Name
+		result.HostIP = host.IP
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer lease.Release()
+
+	// 在借用的连接上创建SSH会话
+	session, err := lease.Client().NewSession()
+	if err != nil {
+		result.Error = fmt.Sprintf("创建SSH会话失败: %v", err)
+		return result
+	}
+	defer session.Close()
+
+	// stdout 与 stderr 按行推送到输出流，同时合并保存到结果中
+	session.Stdout = output.stdout
+	session.Stderr = output.stderr
+	defer output.flush()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(buildCommand(scriptType, content))
+	}()
+
+	select {
+	case <-ctx.Done():
+		// 超时或被取消，终止远程进程
+		stopRemoteCommand(session, done)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			result.Status = model.HostStatusCancelled
+			result.Error = "任务已取消"
+		} else {
+			result.Status = model.HostStatusTimeout
+			result.Error = "执行超时"
+		}
+		output.flush()
+		result.Output = output.Output()
+		return result
+	case err := <-done:
+		output.flush()
+		result.Output = output.Output()
+		if err != nil {
+			result.Error = fmt.Sprintf("执行失败: %v", err)
+			return result
+		}
+	}
+
+	result.Status = model.HostStatusSuccess
+	return result
+}
+
+// stopRemoteCommand 终止远程命令：先发送 SIGTERM，宽限期内未退出则发送 SIGKILL，最后关闭会话
+func stopRemoteCommand(session *ssh.Session, done <-chan error) {
+	session.Signal(ssh.SIGTERM)
+	select {
+	case <-done:
+	case <-time.After(killGracePeriod):
+		session.Signal(ssh.SIGKILL)
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}
+	session.Close()
+}
+
+// buildCommand 根据脚本类型构造执行命令
+func buildCommand(scriptType, content string) string {
+	if scriptType == "Python" {
+		return fmt.Sprintf("python3 -c %s", shellescape(content))
+	}
+	// Shell 脚本
+	return content
+}
+
+// ConnectHost 查询主机并从连接池借用SSH连接，使用完毕后调用 Release 归还
+// 即使连接失败，只要主机存在也会返回主机信息，便于调用方填充结果
+func (e *Executor) ConnectHost(ctx context.Context, hostID uint) (*assetbiz.Host, *sshclient.Lease, error) {
+	return e.connector.Connect(ctx, hostID)
+}
+
+// knownHostsLine 返回主机已信任公钥的 known_hosts 行，尚未记录时先建立一次连接完成记录（TOFU）
+func (e *Executor) knownHostsLine(ctx context.Context, host *assetbiz.Host) (string, error) {
+	key, err := e.connector.HostKeys().Get(ctx, host.ID)
+	if err != nil {
+		return "", fmt.Errorf("读取主机密钥失败: %w", err)
+	}
+	if key == nil {
+		_, lease, err := e.ConnectHost(ctx, host.ID)
+		if err != nil {
+			return "", err
+		}
+		lease.Release()
+		if key, err = e.connector.HostKeys().Get(ctx, host.ID); err != nil || key == nil {
+			return "", fmt.Errorf("读取主机密钥失败: %v", err)
+		}
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key.PublicKey))
+	if err != nil {
+		return "", fmt.Errorf("已记录的主机密钥无效: %w", err)
+	}
+	port := host.Port
+	if port == 0 {
+		port = 22
+	}
+	addr := knownhosts.Normalize(net.JoinHostPort(host.IP, strconv.Itoa(port)))
+	return knownhosts.Line([]string{addr}, pub), nil
+}
+
+// LoadCredential 获取主机关联的凭证并解密
+func (e *Executor) LoadCredential(ctx context.Context, host *assetbiz.Host) (*assetbiz.Credential, error) {
+	return e.connector.Credential(ctx, host)
+}
+
+// shellescape 转义shell命令
+func shellescape(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "'\"'\"'") + "'"
+}
+
+// combinedOutput 并发安全的输出缓冲（stdout 与 stderr 同时写入）
+type combinedOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *combinedOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *combinedOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// hostOutput 单台主机的输出
+type hostOutput struct {
+	combinedOutput
+	stdout  *lineWriter
+	stderr  *lineWriter
+	secrets []string
+}
+
+func newHostOutput(stream *jobStream, hostID uint, hostName string, secrets []string) *hostOutput {
+	o := &hostOutput{secrets: secrets}
+	o.stdout = &lineWriter{stream: stream, hostID: hostID, hostName: hostName, typ: EventStdout, combined: &o.combinedOutput, secrets: secrets}
+	o.stderr = &lineWriter{stream: stream, hostID: hostID, hostName: hostName, typ: EventStderr, combined: &o.combinedOutput, secrets: secrets}
+	return o
+}
+
+// Output 返回脱敏后的完整输出
+func (o *hostOutput) Output() string {
+	return RedactSecrets(o.String(), o.secrets)
+}
+
+// flush 推送剩余不足一行的输出
+func (o *hostOutput) flush() {
+	o.stdout.Flush()
+	o.stderr.Flush()
+}
+
+// jobRun 一次批量执行的运行状态
+type jobRun struct {
+	db      *gorm.DB
+	jobID   uint
+	stream  *jobStream
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	results []model.HostExecutionResult
+	rollout *model.RolloutStatus // 滚动执行进度，非滚动执行时为 nil
+
+	cancelled       bool
+	cancelledBy     uint
+	cancelledByName string
+	cancelledAt     time.Time
+}
+
+// markCancelled 记录取消操作
+func (r *jobRun) markCancelled(userID uint, username string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancelled {
+		return
+	}
+	r.cancelled = true
+	r.cancelledBy = userID
+	r.cancelledByName = username
+	r.cancelledAt = time.Now()
+}
+
+// isCancelled 任务是否已被取消
+func (r *jobRun) isCancelled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cancelled
+}
+
+// start 标记主机开始执行，返回主机名称
+func (r *jobRun) start(idx int) string {
+	now := time.Now()
+	r.mu.Lock()
+	r.results[idx].Status = model.HostStatusRunning
+	r.results[idx].StartTime = &now
+	hostID, hostName := r.results[idx].HostID, r.results[idx].HostName
+	r.mu.Unlock()
+	r.persist()
+	r.publishStatus(hostID, hostName, model.HostStatusRunning)
+	return hostName
+}
+
+// publishStatus 推送主机状态变化
+func (r *jobRun) publishStatus(hostID uint, hostName, status string) {
+	r.stream.publish(OutputEvent{
+		HostID:   hostID,
+		HostName: hostName,
+		Type:     EventStatus,
+		Data:     status,
+	})
+}
+
+// finish 记录主机执行结果
+func (r *jobRun) finish(idx int, result model.HostExecutionResult) {
+	now := time.Now()
+	r.mu.Lock()
+	prev := r.results[idx]
+	if result.HostName == "" {
+		result.HostName = prev.HostName
+	}
+	if result.HostIP == "" {
+		result.HostIP = prev.HostIP
+	}
+	result.StartTime = prev.StartTime
+	result.EndTime = &now
+	if r.cancelled && result.Status != model.HostStatusSuccess {
+		// 取消过程中建立连接等步骤的失败统一视为已取消
+		result.Status = model.HostStatusCancelled
+		if result.Error == "" {
+			result.Error = "任务已取消"
+		}
+	}
+	r.results[idx] = result
+	r.mu.Unlock()
+	r.persist()
+	r.publishStatus(result.HostID, result.HostName, result.Status)
+}
+
+// skip 将未执行的主机标记为指定状态
+func (r *jobRun) skip(idx int, status, reason string) {
+	now := time.Now()
+	r.mu.Lock()
+	r.results[idx].Status = status
+	r.results[idx].Error = reason
+	r.results[idx].EndTime = &now
+	hostID, hostName := r.results[idx].HostID, r.results[idx].HostName
+	r.mu.Unlock()
+	r.persist()
+	r.publishStatus(hostID, hostName, status)
+}
+
+// persist 将当前结果写回 JobTask.Result
+// 持锁写库，保证较新的结果不会被较旧的快照覆盖
+func (r *jobRun) persist() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resultJSON, _ := json.Marshal(r.results)
+	updates := map[string]interface{}{"result": string(resultJSON)}
+	if r.rollout != nil {
+		rolloutJSON, _ := json.Marshal(r.rollout)
+		updates["rollout"] = string(rolloutJSON)
+	}
+	if err := r.db.Model(&model.JobTask{}).Where("id = ?", r.jobID).
+		Updates(updates).Error; err != nil {
+		logger.Error("更新任务结果失败", zap.Uint("jobId", r.jobID), zap.Error(err))
+	}
+}
+
+// complete 汇总结果并更新任务最终状态，返回任务状态
+func (r *jobRun) complete() string {
+	r.mu.Lock()
+	failed, cancelled, skipped := 0, 0, 0
+	for _, result := range r.results {
+		switch result.Status {
+		case model.HostStatusSuccess:
+		case model.HostStatusCancelled:
+			cancelled++
+		case model.HostStatusSkipped:
+			skipped++
+		default:
+			failed++
+		}
+	}
+	resultJSON, _ := json.Marshal(r.results)
+	total := len(r.results)
+	var rolloutJSON []byte
+	var haltReason string
+	if r.rollout != nil {
+		rolloutJSON, _ = json.Marshal(r.rollout)
+		haltReason = r.rollout.HaltReason
+	}
+	// 取消信息可能被 Cancel 并发写入，持锁复制
+	cancelledBy, cancelledByName, cancelledAt := r.cancelledBy, r.cancelledByName, r.cancelledAt
+	r.mu.Unlock()
+
+	updates := map[string]interface{}{
+		"status": model.JobStatusSuccess,
+		"result": string(resultJSON),
+	}
+	if rolloutJSON != nil {
+		updates["rollout"] = string(rolloutJSON)
+	}
+	if failed > 0 {
+		updates["status"] = model.JobStatusFailed
+		updates["error_message"] = fmt.Sprintf("%d/%d 台主机执行失败", failed, total)
+	}
+	if skipped > 0 {
+		updates["status"] = model.JobStatusFailed
+		updates["error_message"] = fmt.Sprintf("%s，%d/%d 台主机执行失败，%d 台未执行", haltReason, failed, total, skipped)
+	}
+	if cancelled > 0 {
+		updates["status"] = model.JobStatusCancelled
+		updates["cancelled_by"] = cancelledBy
+		updates["cancelled_by_name"] = cancelledByName
+		updates["cancelled_at"] = &cancelledAt
+		updates["error_message"] = fmt.Sprintf("任务已被 %s 取消", cancelledByName)
+	}
+
+	if err := r.db.Model(&model.JobTask{}).Where("id = ?", r.jobID).Updates(updates).Error; err != nil {
+		logger.Error("更新任务状态失败", zap.Uint("jobId", r.jobID), zap.Error(err))
+	}
+	return updates["status"].(string)
+}
+
+// newRollout 构造滚动执行的初始进度
+func newRollout(strategy *RollingStrategy, hostIDs []uint, batches [][]int) *model.RolloutStatus {
+	rollout := &model.RolloutStatus{
+		BatchSize:         strategy.BatchSize,
+		PauseSeconds:      strategy.PauseSeconds,
+		MaxFailPercentage: strategy.MaxFailPercentage,
+		Batches:           make([]model.RolloutBatch, 0, len(batches)),
+	}
+	for b, batch := range batches {
+		ids := make([]uint, 0, len(batch))
+		for _, idx := range batch {
+			ids = append(ids, hostIDs[idx])
+		}
+		rollout.Batches = append(rollout.Batches, model.RolloutBatch{
+			Index:   b + 1,
+			HostIDs: ids,
+			Status:  model.HostStatusPending,
+		})
+	}
+	return rollout
+}
+
+// startBatch 标记批次开始执行
+func (r *jobRun) startBatch(b int) {
+	r.mu.Lock()
+	if r.rollout == nil {
+		r.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	r.rollout.CurrentBatch = b + 1
+	r.rollout.Batches[b].Status = model.HostStatusRunning
+	r.rollout.Batches[b].StartTime = &now
+	r.mu.Unlock()
+	r.persist()
+	r.stream.publish(OutputEvent{Type: EventBatch, Data: fmt.Sprintf("第%d/%d批开始执行，共 %d 台主机", b+1, len(r.rollout.Batches), len(r.rollout.Batches[b].HostIDs))})
+}
+
+// finishBatch 汇总批次结果，strategy 不为空时按失败率判断是否停止后续批次
+func (r *jobRun) finishBatch(b int, strategy *RollingStrategy) (bool, string) {
+	r.mu.Lock()
+	if r.rollout == nil {
+		r.mu.Unlock()
+		return false, ""
+	}
+	batch := &r.rollout.Batches[b]
+	failed, cancelled, skipped := 0, 0, 0
+	for _, result := range r.results {
+		if result.Batch != b+1 {
+			continue
+		}
+		switch result.Status {
+		case model.HostStatusSuccess:
+		case model.HostStatusCancelled:
+			cancelled++
+		case model.HostStatusSkipped:
+			skipped++
+		default:
+			failed++
+		}
+	}
+	now := time.Now()
+	batch.Failed = failed
+	batch.FailureRate = float64(failed) * 100 / float64(len(batch.HostIDs))
+	batch.EndTime = &now
+	switch {
+	case skipped == len(batch.HostIDs):
+		batch.Status = model.HostStatusSkipped
+	case cancelled > 0:
+		batch.Status = model.HostStatusCancelled
+	case failed > 0:
+		batch.Status = model.HostStatusFailed
+	default:
+		batch.Status = model.HostStatusSuccess
+	}
+
+	var halted bool
+	var reason string
+	if strategy != nil && b < len(r.rollout.Batches)-1 {
+		if halted, reason = strategy.shouldHalt(batch); halted {
+			r.rollout.Halted = true
+			r.rollout.HaltedBatch = b + 1
+			r.rollout.HaltReason = reason
+		}
+	}
+	r.mu.Unlock()
+	r.persist()
+	if halted {
+		r.stream.publish(OutputEvent{Type: EventBatch, Data: reason})
+	}
+	return halted, reason
+}
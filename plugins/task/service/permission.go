@@ -0,0 +1,105 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	rbacdata "github.com/ydcloud-dy/opshub/internal/data/rbac"
+	"gorm.io/gorm"
+)
+
+// ErrHostPermissionDenied 缺少目标主机的操作权限
+var ErrHostPermissionDenied = errors.New("没有目标主机的操作权限")
+
+// HostPermission 按资产权限位检查任务目标主机，与资产模块共用角色的资产授权
+type HostPermission struct {
+	db    *gorm.DB
+	perms *rbacbiz.AssetPermissionUseCase
+}
+
+// NewHostPermission 创建任务目标主机权限检查
+func NewHostPermission(db *gorm.DB) *HostPermission {
+	return &HostPermission{
+		db:    db,
+		perms: rbacbiz.NewAssetPermissionUseCase(rbacdata.NewAssetPermissionRepo(db)),
+	}
+}
+
+// Check 检查用户对全部目标主机拥有指定操作权限，缺少权限时返回包含 ErrHostPermissionDenied 的错误，列出无权限的主机
+func (p *HostPermission) Check(ctx context.Context, userID uint, hostIDs []uint, operation uint) error {
+	denied, err := p.perms.FilterDeniedHosts(ctx, userID, hostIDs, operation)
+	if err != nil {
+		return fmt.Errorf("查询主机权限失败: %w", err)
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+
+	var hosts []assetbiz.Host
+	p.db.WithContext(ctx).Select("id, name, ip").Where("id IN ?", denied).Find(&hosts)
+	names := make(map[uint]string, len(hosts))
+	for _, h := range hosts {
+		names[h.ID] = fmt.Sprintf("%s(%s)", h.Name, h.IP)
+	}
+	labels := make([]string, 0, len(denied))
+	for _, id := range denied {
+		if len(labels) == 10 {
+			labels = append(labels, fmt.Sprintf("等%d台", len(denied)))
+			break
+		}
+		if name, ok := names[id]; ok {
+			labels = append(labels, name)
+		} else {
+			labels = append(labels, fmt.Sprintf("#%d", id))
+		}
+	}
+	return fmt.Errorf("%w【%s】：%s", ErrHostPermissionDenied, rbacbiz.GetPermissionName(operation), strings.Join(labels, "、"))
+}
+
+// JobScope 返回用户可见的任务记录查询条件：自己创建的任务，以及目标主机全部有查看权限的任务；管理员不限制
+func (p *HostPermission) JobScope(ctx context.Context, userID uint) (func(*gorm.DB) *gorm.DB, error) {
+	admin, err := IsAdmin(ctx, p.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if admin {
+		return func(db *gorm.DB) *gorm.DB { return db }, nil
+	}
+
+	viewable, err := p.perms.GetUserOperableHostIDs(ctx, userID, rbacbiz.PermissionView)
+	if err != nil {
+		return nil, fmt.Errorf("查询主机权限失败: %w", err)
+	}
+	if viewable == nil {
+		viewable = []uint{}
+	}
+	viewableJSON, _ := json.Marshal(viewable)
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(`created_by = ? OR (CASE WHEN JSON_VALID(target_hosts) AND target_hosts <> '[]'
+			THEN JSON_CONTAINS(CAST(? AS JSON), target_hosts) ELSE 0 END)`, userID, string(viewableJSON))
+	}, nil
+}
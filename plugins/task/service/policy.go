@@ -0,0 +1,461 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"gorm.io/gorm"
+)
+
+// PolicyEngine 命令策略引擎
+// 策略保存在数据库中，每次评估都重新加载，修改后立即生效
+type PolicyEngine struct {
+	db *gorm.DB
+}
+
+// NewPolicyEngine 创建命令策略引擎
+func NewPolicyEngine(db *gorm.DB) *PolicyEngine {
+	return &PolicyEngine{db: db}
+}
+
+// PolicySubject 策略评估对象：执行人及目标主机
+type PolicySubject struct {
+	UserID  uint
+	HostIDs []uint
+}
+
+// PolicyMatch 单个行为命中的策略
+type PolicyMatch struct {
+	PolicyID   uint         `json:"policyId"`
+	PolicyName string       `json:"policyName"`
+	Action     string       `json:"action"`
+	Event      CommandEvent `json:"event"`
+}
+
+// PolicyDecision 策略评估结果
+type PolicyDecision struct {
+	Action   string         `json:"action"`            // 最终结果：allow, deny, require_approval
+	Reason   string         `json:"reason"`            // 结果说明，包含命中的命令和策略
+	Matched  *PolicyMatch   `json:"matched,omitempty"` // 决定最终结果的命中
+	Matches  []PolicyMatch  `json:"matches"`           // 全部命中记录
+	Events   []CommandEvent `json:"events"`            // 从脚本中解析出的全部行为
+	RoleIDs  []uint         `json:"roleIds"`           // 参与评估的执行人角色
+	GroupIDs []uint         `json:"groupIds"`          // 参与评估的目标主机分组（含上级分组）
+}
+
+// Allowed 是否允许直接执行
+func (d *PolicyDecision) Allowed() bool {
+	return d.Action == model.PolicyActionAllow
+}
+
+// 动作严重程度，多个命中时取最严格的结果
+var actionSeverity = map[string]int{
+	model.PolicyActionAllow:           0,
+	model.PolicyActionRequireApproval: 1,
+	model.PolicyActionDeny:            2,
+}
+
+// ValidPolicyActions 支持的策略动作
+var ValidPolicyActions = []string{model.PolicyActionAllow, model.PolicyActionDeny, model.PolicyActionRequireApproval}
+
+// ValidPolicyMatchTypes 支持的策略匹配类型
+var ValidPolicyMatchTypes = []string{
+	model.PolicyMatchCommand, model.PolicyMatchRedirect, model.PolicyMatchPipeShell,
+	model.PolicyMatchDynamic, model.PolicyMatchEnv, model.PolicyMatchScript,
+}
+
+// Evaluate 解析脚本并按执行人角色和目标主机分组评估策略
+// 每个行为按优先级匹配第一条生效的策略，最终结果取所有命中中最严格的动作；未命中任何策略时允许执行
+func (e *PolicyEngine) Evaluate(ctx context.Context, scriptType, content string, subject PolicySubject) (*PolicyDecision, error) {
+	policies, err := e.loadPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs, err := UserRoleIDs(ctx, e.db, subject.UserID)
+	if err != nil {
+		return nil, err
+	}
+	groupIDs, err := HostGroupScope(ctx, e.db, subject.HostIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluatePolicies(policies, roleIDs, groupIDs, scriptType, content), nil
+}
+
+// CheckTerminalCommand 检查交互式终端中提交的单条命令，注册为资产终端的命令检查器
+// 终端中无法发起审批，需审批的命令以及无法解析的命令改为由输入者当场确认
+func (e *PolicyEngine) CheckTerminalCommand(ctx context.Context, userID, hostID uint, command string) (*assetbiz.CommandCheck, error) {
+	decision, err := e.Evaluate(ctx, "shell", command, PolicySubject{UserID: userID, HostIDs: []uint{hostID}})
+	if err != nil {
+		return nil, err
+	}
+	check := &assetbiz.CommandCheck{Action: assetbiz.CommandCheckAllow}
+	switch {
+	case decision.Allowed():
+		return check, nil
+	case decision.Matched == nil:
+		check.Action = assetbiz.CommandCheckConfirm
+		check.Reason = decision.Reason
+	case decision.Action == model.PolicyActionDeny:
+		check.Action = assetbiz.CommandCheckDeny
+		check.PolicyName = decision.Matched.PolicyName
+		check.Reason = fmt.Sprintf("命中策略【%s】", decision.Matched.PolicyName)
+	default:
+		check.Action = assetbiz.CommandCheckConfirm
+		check.PolicyName = decision.Matched.PolicyName
+		check.Reason = fmt.Sprintf("命中需审批策略【%s】", decision.Matched.PolicyName)
+	}
+	return check, nil
+}
+
+// evaluatePolicies 按已加载的策略评估脚本，与数据库无关的评估逻辑
+func evaluatePolicies(policies []*compiledPolicy, roleIDs, groupIDs []uint, scriptType, content string) *PolicyDecision {
+	decision := &PolicyDecision{
+		Action:   model.PolicyActionAllow,
+		Matches:  []PolicyMatch{},
+		RoleIDs:  roleIDs,
+		GroupIDs: groupIDs,
+	}
+
+	events, err := ParseScript(scriptType, content)
+	if err != nil {
+		// 无法解析的脚本无法判断其行为，一律拦截
+		decision.Action = model.PolicyActionDeny
+		decision.Reason = err.Error() + "，无法进行安全分析，已被系统拦截"
+		decision.Events = []CommandEvent{}
+		return decision
+	}
+	decision.Events = events
+
+	roles := toSet(roleIDs)
+	groups := toSet(groupIDs)
+	applicable := make([]*compiledPolicy, 0, len(policies))
+	for _, p := range policies {
+		if p.appliesTo(roles, groups) {
+			applicable = append(applicable, p)
+		}
+	}
+
+	for _, event := range events {
+		for _, p := range applicable {
+			if p.MatchType != model.PolicyMatchScript && p.matchEvent(event) {
+				decision.record(p, event)
+				break
+			}
+		}
+	}
+	for _, p := range applicable {
+		if p.MatchType != model.PolicyMatchScript || p.pattern == nil {
+			continue
+		}
+		if loc := p.pattern.FindStringIndex(content); loc != nil {
+			line := uint(strings.Count(content[:loc[0]], "\n") + 1)
+			decision.record(p, CommandEvent{Kind: model.PolicyMatchScript, Line: line, Text: content[loc[0]:loc[1]]})
+		}
+	}
+
+	decision.explain()
+	return decision
+}
+
+// record 记录一次命中，严格程度更高时更新最终结果
+func (d *PolicyDecision) record(p *compiledPolicy, event CommandEvent) {
+	d.Matches = append(d.Matches, PolicyMatch{PolicyID: p.ID, PolicyName: p.Name, Action: p.Action, Event: event})
+	if actionSeverity[p.Action] > actionSeverity[d.Action] {
+		d.Action = p.Action
+	}
+}
+
+// explain 定位决定最终结果的第一条命中并生成结果说明
+func (d *PolicyDecision) explain() {
+	if d.Action != model.PolicyActionAllow {
+		for i := range d.Matches {
+			if d.Matches[i].Action == d.Action {
+				d.Matched = &d.Matches[i]
+				break
+			}
+		}
+	}
+	if d.Matched == nil {
+		d.Reason = "未命中拦截或审批策略，允许执行"
+		return
+	}
+	m := d.Matched
+	snippet := m.Event.Text
+	if len([]rune(snippet)) > 80 {
+		snippet = string([]rune(snippet)[:80]) + "..."
+	}
+	switch d.Action {
+	case model.PolicyActionDeny:
+		d.Reason = fmt.Sprintf("第%d行【%s】命中策略【%s】，已被系统拦截", m.Event.Line, snippet, m.PolicyName)
+	case model.PolicyActionRequireApproval:
+		d.Reason = fmt.Sprintf("第%d行【%s】命中策略【%s】，需要审批后才能执行", m.Event.Line, snippet, m.PolicyName)
+	}
+}
+
+// compiledPolicy 预处理后的策略
+type compiledPolicy struct {
+	model.CommandPolicy
+	names   []string
+	pattern *regexp.Regexp
+	roles   map[uint]bool // 为空表示所有角色
+	groups  map[uint]bool // 为空表示所有分组
+}
+
+// loadPolicies 加载启用的策略并按优先级排序
+func (e *PolicyEngine) loadPolicies(ctx context.Context) ([]*compiledPolicy, error) {
+	var policies []model.CommandPolicy
+	if err := e.db.WithContext(ctx).
+		Where("status = 1 AND deleted_at IS NULL").
+		Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("加载命令策略失败: %w", err)
+	}
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].Priority != policies[j].Priority {
+			return policies[i].Priority < policies[j].Priority
+		}
+		return policies[i].ID < policies[j].ID
+	})
+
+	compiled := make([]*compiledPolicy, 0, len(policies))
+	for _, p := range policies {
+		cp, err := compilePolicy(&p)
+		if err != nil {
+			// 单条策略配置错误不影响其他策略，保存时已校验，这里只可能来自直接改库
+			continue
+		}
+		compiled = append(compiled, cp)
+	}
+	return compiled, nil
+}
+
+// ValidatePolicy 校验策略配置，未指定匹配类型时默认为 command
+func ValidatePolicy(p *model.CommandPolicy) error {
+	_, err := compilePolicy(p)
+	return err
+}
+
+// CheckBuiltinPolicyUpdate 校验对内置策略的修改
+// 内置策略是系统的安全底线，只允许修改名称、描述和优先级，不能禁用或改变匹配规则和生效范围；
+// 需要放行时应创建优先级更高的自定义策略，便于审计
+func CheckBuiltinPolicyUpdate(saved, updated *model.CommandPolicy) error {
+	if !saved.Builtin {
+		return nil
+	}
+	if updated.Status != 1 {
+		return errors.New("内置策略不能禁用，如需放行请创建优先级更高的自定义策略")
+	}
+	if updated.Action != saved.Action || updated.MatchType != saved.MatchType ||
+		updated.Command != saved.Command || updated.Pattern != saved.Pattern {
+		return errors.New("内置策略不能修改动作和匹配规则")
+	}
+	if !sameIDSet(updated.RoleIDs, saved.RoleIDs) || !sameIDSet(updated.AssetGroupIDs, saved.AssetGroupIDs) {
+		return errors.New("内置策略不能修改生效的角色和分组范围")
+	}
+	return nil
+}
+
+// compilePolicy 校验并预处理策略
+func compilePolicy(p *model.CommandPolicy) (*compiledPolicy, error) {
+	if !containsString(ValidPolicyActions, p.Action) {
+		return nil, fmt.Errorf("不支持的策略动作: %s", p.Action)
+	}
+	if p.MatchType == "" {
+		p.MatchType = model.PolicyMatchCommand
+	}
+	if !containsString(ValidPolicyMatchTypes, p.MatchType) {
+		return nil, fmt.Errorf("不支持的匹配类型: %s", p.MatchType)
+	}
+	cp := &compiledPolicy{CommandPolicy: *p}
+	for _, name := range strings.Split(p.Command, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, fmt.Errorf("命令通配符格式错误: %s", name)
+		}
+		cp.names = append(cp.names, name)
+	}
+	if p.Pattern != "" {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("正则表达式格式错误: %w", err)
+		}
+		cp.pattern = re
+	}
+	if p.MatchType == model.PolicyMatchScript && cp.pattern == nil {
+		return nil, errors.New("script 类型的策略必须填写正则表达式")
+	}
+	if p.MatchType == model.PolicyMatchCommand && len(cp.names) == 0 && cp.pattern == nil {
+		return nil, errors.New("command 类型的策略至少需要填写命令名或参数正则")
+	}
+
+	var err error
+	if cp.roles, err = parseIDSet(p.RoleIDs); err != nil {
+		return nil, fmt.Errorf("角色范围格式错误: %w", err)
+	}
+	if cp.groups, err = parseIDSet(p.AssetGroupIDs); err != nil {
+		return nil, fmt.Errorf("分组范围格式错误: %w", err)
+	}
+	return cp, nil
+}
+
+// appliesTo 策略是否对当前执行人和目标分组生效
+func (p *compiledPolicy) appliesTo(roles, groups map[uint]bool) bool {
+	return intersects(p.roles, roles) && intersects(p.groups, groups)
+}
+
+// matchEvent 策略是否匹配某个行为
+func (p *compiledPolicy) matchEvent(event CommandEvent) bool {
+	if event.Kind != p.MatchType {
+		return false
+	}
+	if len(p.names) > 0 {
+		matched := false
+		for _, name := range p.names {
+			if ok, _ := path.Match(name, event.Command); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if p.pattern == nil {
+		return true
+	}
+	var subject string
+	switch event.Kind {
+	case model.PolicyMatchCommand:
+		subject = strings.Join(event.Args, " ")
+	case model.PolicyMatchDynamic:
+		subject = event.Text
+	default:
+		subject = event.Target
+	}
+	return p.pattern.MatchString(subject)
+}
+
+// UserRoleIDs 查询用户拥有的角色ID
+func UserRoleIDs(ctx context.Context, db *gorm.DB, userID uint) ([]uint, error) {
+	roleIDs := []uint{}
+	if userID == 0 {
+		return roleIDs, nil
+	}
+	if err := db.WithContext(ctx).Model(&rbacbiz.SysUserRole{}).
+		Where("user_id = ?", userID).
+		Pluck("role_id", &roleIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询用户角色失败: %w", err)
+	}
+	return roleIDs, nil
+}
+
+// IsAdmin 判断用户是否拥有管理员角色
+func IsAdmin(ctx context.Context, db *gorm.DB, userID uint) (bool, error) {
+	if userID == 0 {
+		return false, nil
+	}
+	var count int64
+	if err := db.WithContext(ctx).Table("sys_user_role ur").
+		Joins("JOIN sys_role r ON ur.role_id = r.id").
+		Where("ur.user_id = ? AND r.code = ? AND r.deleted_at IS NULL", userID, "admin").
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询用户角色失败: %w", err)
+	}
+	return count > 0, nil
+}
+
+// parseIDSet 解析JSON数组形式的ID列表，为空时返回nil表示不限制
+func parseIDSet(raw string) (map[uint]bool, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return nil, nil
+	}
+	var ids []uint
+	if err := json.Unmarshal([]byte(raw), &ids); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return toSet(ids), nil
+}
+
+// sameIDSet 比较两个JSON数组形式的ID列表是否相同，"" 与 "[]" 视为相同
+func sameIDSet(a, b string) bool {
+	x, errA := parseIDSet(a)
+	y, errB := parseIDSet(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	if len(x) != len(y) {
+		return false
+	}
+	for id := range x {
+		if !y[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// intersects scope 为空表示不限制，否则要求与 actual 有交集
+func intersects(scope, actual map[uint]bool) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	for id := range actual {
+		if scope[id] {
+			return true
+		}
+	}
+	return false
+}
+
+func toSet(ids []uint) map[uint]bool {
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"fmt"
+
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"gorm.io/gorm"
+)
+
+// 内置策略的优先级，低于自定义策略的默认优先级，便于按角色或分组放行
+const builtinPolicyPriority = 1000
+
+// defaultPolicies 内置命令策略，覆盖原先黑名单中的危险操作
+var defaultPolicies = []model.CommandPolicy{
+	// ============ 拦截 ============
+	{Name: "删除文件", Action: model.PolicyActionDeny, Command: "rm,unlink,shred,wipe,srm",
+		Description: "禁止任何形式的文件删除操作"},
+	{Name: "磁盘与文件系统操作", Action: model.PolicyActionDeny,
+		Command:     "dd,mkfs,mkfs.*,fdisk,parted,gdisk,cfdisk,sfdisk,mkswap,swapoff,fsck,fsck.*,e2fsck,xfs_repair,tune2fs,resize2fs,wipefs,badblocks,hdparm,sdparm",
+		Description: "磁盘复制、格式化、分区及文件系统修复"},
+	{Name: "关机重启", Action: model.PolicyActionDeny, Command: "shutdown,reboot,halt,poweroff,telinit"},
+	{Name: "切换运行级别关机重启", Action: model.PolicyActionDeny, Command: "init", Pattern: `^\s*[06]\b`},
+	{Name: "systemctl 关机重启", Action: model.PolicyActionDeny, Command: "systemctl",
+		Pattern: `(^|\s)(reboot|poweroff|halt|kexec)(\s|$)`},
+	{Name: "批量终止进程", Action: model.PolicyActionDeny, Command: "killall,killall5,pkill,skill"},
+	{Name: "强制终止进程", Action: model.PolicyActionDeny, Command: "kill",
+		Pattern: `(?i)(^|\s)-(9|KILL|SIGKILL|s\s+(9|KILL|SIGKILL))(\s|$)`},
+	{Name: "危险权限设置", Action: model.PolicyActionDeny, Command: "chmod",
+		Pattern: `(?i)(^|\s)(777|666|000|a\+w|u\+s|g\+s)(\s|$)`},
+	{Name: "递归修改所有者", Action: model.PolicyActionDeny, Command: "chown,chgrp", Pattern: `(^|\s)-[a-zA-Z]*R`},
+	{Name: "修改文件属性和ACL", Action: model.PolicyActionDeny, Command: "chattr,setfacl"},
+	{Name: "删除或锁定用户", Action: model.PolicyActionDeny, Command: "userdel,groupdel,visudo"},
+	{Name: "修改用户密码状态", Action: model.PolicyActionDeny, Command: "passwd", Pattern: `(^|\s)-[dl](\s|$)`},
+	{Name: "修改用户名或提升UID", Action: model.PolicyActionDeny, Command: "usermod", Pattern: `(^|\s)-(l|u\s+0)(\s|$)`},
+	{Name: "内核模块操作", Action: model.PolicyActionDeny, Command: "insmod,rmmod,depmod"},
+	{Name: "移除内核模块", Action: model.PolicyActionDeny, Command: "modprobe", Pattern: `(^|\s)-r`},
+	{Name: "修改内核参数", Action: model.PolicyActionDeny, Command: "sysctl", Pattern: `(^|\s)-w`},
+	{Name: "引导程序操作", Action: model.PolicyActionDeny, Command: "grub-install,grub2-install,lilo"},
+	{Name: "关闭SELinux", Action: model.PolicyActionDeny, Command: "setenforce,semanage"},
+	{Name: "网络扫描和攻击工具", Action: model.PolicyActionDeny,
+		Command: "nmap,masscan,arpspoof,ettercap,hydra,john,hashcat,msfconsole,sqlmap,tcpdump,tshark,wireshark"},
+	{Name: "别名劫持", Action: model.PolicyActionDeny, Command: "alias", Description: "别名可能导致命令劫持"},
+	{Name: "写入系统关键路径", Action: model.PolicyActionDeny, MatchType: model.PolicyMatchRedirect,
+		Pattern:     `^(/etc/|/usr/|/boot/|/bin/|/sbin/|/lib(64)?/|/var/(lib|log|run)/|/proc/|/sys/|/root/\.|~/\.bash_history|/dev/(sd|hd|vd|nvme|xvd))`,
+		Description: "重定向写入系统配置、二进制、日志目录或磁盘设备"},
+	{Name: "管道执行远程脚本", Action: model.PolicyActionDeny, MatchType: model.PolicyMatchPipeShell,
+		Description: "将命令输出直接交给解释器执行，如 curl ... | bash、base64 -d | sh"},
+	{Name: "动态库注入", Action: model.PolicyActionDeny, MatchType: model.PolicyMatchEnv, Command: "LD_PRELOAD,LD_LIBRARY_PATH"},
+
+	// ============ 需要审批 ============
+	{Name: "无法静态分析的命令", Action: model.PolicyActionRequireApproval, MatchType: model.PolicyMatchDynamic,
+		Description: "命令名来自变量或命令替换，或 eval/bash -c 执行动态内容"},
+	{Name: "修改PATH", Action: model.PolicyActionRequireApproval, MatchType: model.PolicyMatchEnv, Command: "PATH"},
+	{Name: "服务启停", Action: model.PolicyActionRequireApproval, Command: "systemctl",
+		Pattern: `(^|\s)(stop|disable|mask|kill|restart)(\s|$)`},
+	{Name: "service 启停", Action: model.PolicyActionRequireApproval, Command: "service", Pattern: `(^|\s)(stop|restart)(\s|$)`},
+	{Name: "防火墙规则变更", Action: model.PolicyActionRequireApproval, Command: "iptables,ip6tables", Pattern: `(^|\s)-[FXD]`},
+	{Name: "防火墙开关", Action: model.PolicyActionRequireApproval, Command: "ufw", Pattern: `(^|\s)(disable|reset)(\s|$)`},
+	{Name: "防火墙重载", Action: model.PolicyActionRequireApproval, Command: "firewall-cmd", Pattern: `--reload`},
+	{Name: "关闭网络接口", Action: model.PolicyActionRequireApproval, Command: "ifconfig", Pattern: `(^|\s)down(\s|$)`},
+	{Name: "停用网络接口", Action: model.PolicyActionRequireApproval, Command: "ifdown"},
+	{Name: "删除地址和路由", Action: model.PolicyActionRequireApproval, Command: "ip",
+		Pattern: `(link\s+set\s.*\bdown\b|(addr|address|route)\s+(del|flush))`},
+	{Name: "卸载软件包", Action: model.PolicyActionRequireApproval, Command: "apt,apt-get,yum,dnf,zypper",
+		Pattern: `(^|\s)(remove|purge|erase|autoremove)(\s|$)`},
+	{Name: "RPM/DPKG 卸载", Action: model.PolicyActionRequireApproval, Command: "rpm,dpkg",
+		Pattern: `(^|\s)(-e|-r|-P|--erase|--remove|--purge)(\s|$)`},
+	{Name: "语言包卸载", Action: model.PolicyActionRequireApproval, Command: "pip,pip3,npm,gem", Pattern: `(^|\s)uninstall(\s|$)`},
+	{Name: "删除容器和镜像", Action: model.PolicyActionRequireApproval, Command: "docker,podman,nerdctl,crictl",
+		Pattern: `(^|\s)(rm|rmi|prune|kill)(\s|$)`},
+	{Name: "删除K8s资源", Action: model.PolicyActionRequireApproval, Command: "kubectl", Pattern: `(^|\s)(delete|drain)(\s|$)`},
+	{Name: "销毁虚拟机", Action: model.PolicyActionRequireApproval, Command: "virsh,lxc", Pattern: `(^|\s)(destroy|undefine|delete)(\s|$)`},
+	{Name: "修改定时任务", Action: model.PolicyActionRequireApproval, Command: "crontab", Pattern: `(^|\s)-[re](\s|$)`},
+	{Name: "重新挂载", Action: model.PolicyActionRequireApproval, Command: "mount", Pattern: `(remount|--bind)`},
+	{Name: "强制卸载", Action: model.PolicyActionRequireApproval, Command: "umount", Pattern: `(^|\s)-[a-zA-Z]*[fl]`},
+	{Name: "清除历史和截断文件", Action: model.PolicyActionRequireApproval, Command: "history,truncate"},
+	{Name: "数据库删除操作", Action: model.PolicyActionRequireApproval, MatchType: model.PolicyMatchScript,
+		Pattern: `(?i)\b(drop\s+(database|table|user)|truncate\s+table|delete\s+from)\b`},
+	{Name: "内联代码执行", Action: model.PolicyActionRequireApproval,
+		Command: "python,python2,python3,perl,ruby,php,node", Pattern: `(^|\s)-[a-zA-Z]*[cer](\s|$)`},
+}
+
+// SeedDefaultPolicies 首次启用时写入内置策略，已写入过的不再重复写入
+// 内置策略不允许禁用，旧版本中被禁用的内置策略在启用插件时重新启用
+func SeedDefaultPolicies(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&model.CommandPolicy{}).Where("builtin = ?", true).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询内置策略失败: %w", err)
+	}
+	if count > 0 {
+		if err := db.Model(&model.CommandPolicy{}).
+			Where("builtin = ? AND status <> 1 AND deleted_at IS NULL", true).
+			Update("status", 1).Error; err != nil {
+			return fmt.Errorf("重新启用内置策略失败: %w", err)
+		}
+		return nil
+	}
+
+	policies := make([]model.CommandPolicy, 0, len(defaultPolicies))
+	for _, p := range defaultPolicies {
+		if p.MatchType == "" {
+			p.MatchType = model.PolicyMatchCommand
+		}
+		p.Priority = builtinPolicyPriority
+		p.Status = 1
+		p.Builtin = true
+		policies = append(policies, p)
+	}
+	if err := db.Create(&policies).Error; err != nil {
+		return fmt.Errorf("写入内置策略失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+)
+
+func TestParseScript(t *testing.T) {
+	type want struct {
+		kind, command string
+	}
+	tests := []struct {
+		name       string
+		scriptType string
+		content    string
+		want       []want // 需要出现的行为
+		wantErr    bool
+	}{
+		{"简单命令", "Shell", "ls -l /tmp", []want{{"command", "ls"}}, false},
+		{"引号和转义", "Shell", `\rm -f a; 'r'm b; "r"m c`, []want{{"command", "rm"}}, false},
+		{"绝对路径", "Shell", "/bin/rm -rf /", []want{{"command", "rm"}}, false},
+		{"sudo包装", "Shell", "sudo -u root rm -rf /", []want{{"command", "sudo"}, {"command", "rm"}}, false},
+		{"env包装和变量", "Shell", "env LD_PRELOAD=/x.so ls", []want{{"env", "LD_PRELOAD"}, {"command", "ls"}}, false},
+		{"timeout包装", "Shell", "timeout 10 reboot", []want{{"command", "reboot"}}, false},
+		{"bash -c 嵌套", "Shell", `bash -c "shutdown -h now"`, []want{{"command", "shutdown"}}, false},
+		{"eval静态内容", "Shell", "eval 'halt'", []want{{"command", "halt"}}, false},
+		{"eval动态内容", "Shell", `eval "$cmd"`, []want{{"dynamic", "eval"}}, false},
+		{"变量作为命令", "Shell", `$cmd -rf /`, []want{{"dynamic", "$cmd"}}, false},
+		{"命令替换中的命令", "Shell", "echo $(reboot)", []want{{"command", "echo"}, {"command", "reboot"}}, false},
+		{"管道交给解释器", "Shell", "curl -s http://x | sudo bash", []want{{"pipe_shell", "curl"}}, false},
+		{"重定向", "Shell", "echo x > /etc/passwd", []want{{"redirect", ""}}, false},
+		{"赋值", "Shell", "PATH=/tmp:$PATH", []want{{"env", "PATH"}}, false},
+		{"Python整体视为解释器", "Python", "import os", []want{{"command", "python3"}}, false},
+		{"语法错误", "Shell", "if then fi (", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := ParseScript(tt.scriptType, tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got events %+v", events)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, w := range tt.want {
+				found := false
+				for _, e := range events {
+					if e.Kind == w.kind && (w.command == "" || e.Command == w.command) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("missing event %s/%s in %+v", w.kind, w.command, events)
+				}
+			}
+		})
+	}
+}
+
+func TestParseScriptPipeWithArgs(t *testing.T) {
+	// 带脚本文件参数时 bash 不从标准输入读取脚本
+	events, err := ParseScript("Shell", "cat list | bash run.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if e.Kind == model.PolicyMatchPipeShell {
+			t.Errorf("unexpected pipe_shell event: %+v", e)
+		}
+	}
+}
+
+// compileAll 按给定顺序编译策略，顺序即优先级
+func compileAll(t *testing.T, policies ...model.CommandPolicy) []*compiledPolicy {
+	t.Helper()
+	compiled := make([]*compiledPolicy, 0, len(policies))
+	for i := range policies {
+		p := policies[i]
+		p.ID = uint(i + 1)
+		cp, err := compilePolicy(&p)
+		if err != nil {
+			t.Fatalf("compile %s: %v", p.Name, err)
+		}
+		compiled = append(compiled, cp)
+	}
+	return compiled
+}
+
+func TestEvaluatePolicies(t *testing.T) {
+	builtin := append([]model.CommandPolicy{
+		{Name: "运维组放行重启", Action: model.PolicyActionAllow, Command: "reboot", RoleIDs: "[2]"},
+		{Name: "生产分组禁止重启服务", Action: model.PolicyActionDeny, Command: "systemctl", Pattern: `restart`, AssetGroupIDs: "[10]"},
+	}, defaultPolicies...)
+	policies := compileAll(t, builtin...)
+
+	tests := []struct {
+		name       string
+		scriptType string
+		content    string
+		roles      []uint
+		groups     []uint
+		want       string
+		wantPolicy string
+	}{
+		{"普通命令放行", "Shell", "ls -l\ndf -h", nil, nil, model.PolicyActionAllow, ""},
+		{"删除文件拦截", "Shell", "echo start\nrm -rf /data", nil, nil, model.PolicyActionDeny, "删除文件"},
+		{"sudo包装仍被拦截", "Shell", "sudo -u root /bin/rm x", nil, nil, model.PolicyActionDeny, "删除文件"},
+		{"bash -c 嵌套仍被拦截", "Shell", `bash -c 'reboot'`, nil, nil, model.PolicyActionDeny, "关机重启"},
+		{"角色放行优先于内置策略", "Shell", "reboot", []uint{2}, nil, model.PolicyActionAllow, ""},
+		{"其他角色不受放行影响", "Shell", "reboot", []uint{3}, nil, model.PolicyActionDeny, "关机重启"},
+		{"分组策略", "Shell", "systemctl restart nginx", nil, []uint{10}, model.PolicyActionDeny, "生产分组禁止重启服务"},
+		{"分组外需要审批", "Shell", "systemctl restart nginx", nil, []uint{11}, model.PolicyActionRequireApproval, "服务启停"},
+		{"取最严格的结果", "Shell", "systemctl stop a\nrm b", nil, nil, model.PolicyActionDeny, "删除文件"},
+		{"管道执行远程脚本", "Shell", "curl http://x | sh", nil, nil, model.PolicyActionDeny, "管道执行远程脚本"},
+		{"写入系统路径", "Shell", "echo x >> /etc/hosts", nil, nil, model.PolicyActionDeny, "写入系统关键路径"},
+		{"写入普通路径", "Shell", "echo x > /tmp/a", nil, nil, model.PolicyActionAllow, ""},
+		{"动态命令需要审批", "Shell", `$cmd`, nil, nil, model.PolicyActionRequireApproval, "无法静态分析的命令"},
+		{"脚本原文匹配", "Python", "cursor.execute('DROP TABLE users')", nil, nil, model.PolicyActionRequireApproval, "数据库删除操作"},
+		{"无法解析一律拦截", "Shell", "if then fi (", nil, nil, model.PolicyActionDeny, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := evaluatePolicies(policies, tt.roles, tt.groups, tt.scriptType, tt.content)
+			if d.Action != tt.want {
+				t.Fatalf("action = %s, want %s (%s)", d.Action, tt.want, d.Reason)
+			}
+			if tt.wantPolicy != "" && (d.Matched == nil || d.Matched.PolicyName != tt.wantPolicy) {
+				t.Errorf("matched = %+v, want %s", d.Matched, tt.wantPolicy)
+			}
+			if d.Action != model.PolicyActionAllow && d.Reason == "" {
+				t.Error("reason should not be empty")
+			}
+		})
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  model.CommandPolicy
+		wantErr string
+	}{
+		{"默认匹配类型", model.CommandPolicy{Action: model.PolicyActionDeny, Command: "rm"}, ""},
+		{"不支持的动作", model.CommandPolicy{Action: "block", Command: "rm"}, "不支持的策略动作"},
+		{"不支持的匹配类型", model.CommandPolicy{Action: model.PolicyActionDeny, MatchType: "file"}, "不支持的匹配类型"},
+		{"正则错误", model.CommandPolicy{Action: model.PolicyActionDeny, Command: "rm", Pattern: "("}, "正则表达式格式错误"},
+		{"通配符错误", model.CommandPolicy{Action: model.PolicyActionDeny, Command: "rm["}, "命令通配符格式错误"},
+		{"script缺少正则", model.CommandPolicy{Action: model.PolicyActionDeny, MatchType: model.PolicyMatchScript}, "必须填写正则表达式"},
+		{"command缺少条件", model.CommandPolicy{Action: model.PolicyActionDeny}, "至少需要填写"},
+		{"角色范围错误", model.CommandPolicy{Action: model.PolicyActionDeny, Command: "rm", RoleIDs: "[a]"}, "角色范围格式错误"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.policy
+			err := ValidatePolicy(&p)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if p.MatchType != model.PolicyMatchCommand {
+					t.Errorf("matchType = %q, want command", p.MatchType)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckBuiltinPolicyUpdate(t *testing.T) {
+	saved := model.CommandPolicy{
+		Name: "删除文件", Action: model.PolicyActionDeny, MatchType: model.PolicyMatchCommand,
+		Command: "rm", Priority: builtinPolicyPriority, Status: 1, Builtin: true,
+	}
+	tests := []struct {
+		name    string
+		modify  func(p *model.CommandPolicy)
+		builtin bool
+		wantErr bool
+	}{
+		{"修改名称描述和优先级", func(p *model.CommandPolicy) { p.Name = "x"; p.Description = "y"; p.Priority = 10 }, true, false},
+		{"空范围等价", func(p *model.CommandPolicy) { p.RoleIDs = "[]" }, true, false},
+		{"禁用", func(p *model.CommandPolicy) { p.Status = 0 }, true, true},
+		{"修改动作", func(p *model.CommandPolicy) { p.Action = model.PolicyActionAllow }, true, true},
+		{"修改命令", func(p *model.CommandPolicy) { p.Command = "rmdir" }, true, true},
+		{"修改正则", func(p *model.CommandPolicy) { p.Pattern = "^$" }, true, true},
+		{"限定角色", func(p *model.CommandPolicy) { p.RoleIDs = "[999]" }, true, true},
+		{"限定分组", func(p *model.CommandPolicy) { p.AssetGroupIDs = "[999]" }, true, true},
+		{"自定义策略可以禁用", func(p *model.CommandPolicy) { p.Status = 0 }, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := saved
+			old.Builtin = tt.builtin
+			updated := old
+			tt.modify(&updated)
+			err := CheckBuiltinPolicyUpdate(&old, &updated)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+)
+
+// RollingStrategy 滚动执行策略，语义与 Ansible 的 serial、max_fail_percentage 一致
+type RollingStrategy struct {
+	// BatchSize 每批主机数，支持数量（"5"）或百分比（"20%"），
+	// 多个值以逗号分隔时逐批使用，最后一个值用于剩余的所有批次，如 "1,25%,50%"
+	BatchSize string `json:"batchSize"`
+	// PauseSeconds 批次之间的暂停时间（秒）
+	PauseSeconds int `json:"pauseSeconds"`
+	// MaxFailPercentage 单批失败率超过该百分比时停止后续批次；为 nil 时只有整批都失败才停止
+	MaxFailPercentage *int `json:"maxFailPercentage,omitempty"`
+}
+
+// pause 批次间暂停时间
+func (s *RollingStrategy) pause() time.Duration {
+	return time.Duration(s.PauseSeconds) * time.Second
+}
+
+// batchStep 批次大小的一项配置
+type batchStep struct {
+	value   int
+	percent bool
+}
+
+// ValidateRollingStrategy 校验滚动执行参数
+func ValidateRollingStrategy(strategy *RollingStrategy) error {
+	if _, err := parseBatchSize(strategy.BatchSize); err != nil {
+		return err
+	}
+	if strategy.PauseSeconds < 0 {
+		return fmt.Errorf("批次间暂停时间不能为负数")
+	}
+	if p := strategy.MaxFailPercentage; p != nil && (*p < 0 || *p > 100) {
+		return fmt.Errorf("最大失败百分比需在 0-100 之间")
+	}
+	return nil
+}
+
+// parseBatchSize 解析批次大小配置
+func parseBatchSize(spec string) ([]batchStep, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("批次大小不能为空")
+	}
+	var steps []batchStep
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		step := batchStep{}
+		if strings.HasSuffix(item, "%") {
+			step.percent = true
+			item = strings.TrimSpace(strings.TrimSuffix(item, "%"))
+		}
+		n, err := strconv.Atoi(item)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("批次大小格式错误: %s", spec)
+		}
+		if step.percent && n > 100 {
+			return nil, fmt.Errorf("批次百分比不能超过100%%: %s", spec)
+		}
+		step.value = n
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// planBatches 按批次大小将主机下标划分为多个批次，百分比向下取整且每批至少一台
+// 未配置滚动策略时所有主机作为一个批次
+func planBatches(total int, strategy *RollingStrategy) [][]int {
+	if total == 0 {
+		return nil
+	}
+	var steps []batchStep
+	if strategy != nil {
+		steps, _ = parseBatchSize(strategy.BatchSize)
+	}
+	if len(steps) == 0 {
+		steps = []batchStep{{value: 100, percent: true}}
+	}
+
+	var batches [][]int
+	next := 0
+	for i := 0; next < total; i++ {
+		step := steps[len(steps)-1]
+		if i < len(steps) {
+			step = steps[i]
+		}
+		size := step.value
+		if step.percent {
+			size = total * step.value / 100
+		}
+		if size < 1 {
+			size = 1
+		}
+		end := next + size
+		if end > total {
+			end = total
+		}
+		batch := make([]int, 0, end-next)
+		for idx := next; idx < end; idx++ {
+			batch = append(batch, idx)
+		}
+		batches = append(batches, batch)
+		next = end
+	}
+	return batches
+}
+
+// shouldHalt 根据批次结果判断是否停止后续批次，返回停止原因
+func (s *RollingStrategy) shouldHalt(batch *model.RolloutBatch) (bool, string) {
+	if batch.Failed == 0 {
+		return false, ""
+	}
+	if s.MaxFailPercentage == nil {
+		if batch.Failed == len(batch.HostIDs) {
+			return true, fmt.Sprintf("第%d批 %d 台主机全部执行失败，停止后续批次", batch.Index, batch.Failed)
+		}
+		return false, ""
+	}
+	if batch.FailureRate > float64(*s.MaxFailPercentage) {
+		return true, fmt.Sprintf("第%d批失败率 %.1f%%（%d/%d）超过阈值 %d%%，停止后续批次",
+			batch.Index, batch.FailureRate, batch.Failed, len(batch.HostIDs), *s.MaxFailPercentage)
+	}
+	return false, ""
+}
+
+// encodeRolling 序列化滚动策略用于持久化，未指定时返回空串
+func encodeRolling(strategy *RollingStrategy) string {
+	if strategy == nil {
+		return ""
+	}
+	data, _ := json.Marshal(strategy)
+	return string(data)
+}
+
+// decodeRolling 反序列化持久化的滚动策略
+func decodeRolling(data string) *RollingStrategy {
+	if data == "" {
+		return nil
+	}
+	var strategy RollingStrategy
+	if err := json.Unmarshal([]byte(data), &strategy); err != nil {
+		return nil
+	}
+	return &strategy
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+)
+
+func intPtr(n int) *int { return &n }
+
+// batchSizes 返回各批次的主机数，并校验批次覆盖全部主机且顺序连续
+func batchSizes(t *testing.T, total int, batches [][]int) []int {
+	t.Helper()
+	sizes := make([]int, 0, len(batches))
+	next := 0
+	for _, batch := range batches {
+		for _, idx := range batch {
+			if idx != next {
+				t.Fatalf("batches %v are not contiguous", batches)
+			}
+			next++
+		}
+		sizes = append(sizes, len(batch))
+	}
+	if next != total {
+		t.Fatalf("batches cover %d hosts, want %d", next, total)
+	}
+	return sizes
+}
+
+func TestPlanBatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    int
+		strategy *RollingStrategy
+		want     []int
+	}{
+		{"无主机", 0, &RollingStrategy{BatchSize: "2"}, []int{}},
+		{"未配置滚动策略", 7, nil, []int{7}},
+		{"固定数量", 7, &RollingStrategy{BatchSize: "3"}, []int{3, 3, 1}},
+		{"数量大于总数", 2, &RollingStrategy{BatchSize: "5"}, []int{2}},
+		{"百分比", 10, &RollingStrategy{BatchSize: "30%"}, []int{3, 3, 3, 1}},
+		{"百分比向下取整", 7, &RollingStrategy{BatchSize: "50%"}, []int{3, 3, 1}},
+		{"百分比至少一台", 3, &RollingStrategy{BatchSize: "10%"}, []int{1, 1, 1}},
+		{"逐批使用", 10, &RollingStrategy{BatchSize: "1,25%,50%"}, []int{1, 2, 5, 2}},
+		{"最后一个值用于剩余批次", 6, &RollingStrategy{BatchSize: "1, 2"}, []int{1, 2, 2, 1}},
+		{"100%", 4, &RollingStrategy{BatchSize: "100%"}, []int{4}},
+		{"非法配置视为单批", 4, &RollingStrategy{BatchSize: "abc"}, []int{4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := batchSizes(t, tt.total, planBatches(tt.total, tt.strategy))
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("batch sizes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRollingStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy RollingStrategy
+		wantErr  string
+	}{
+		{"合法", RollingStrategy{BatchSize: "1,25%", PauseSeconds: 10, MaxFailPercentage: intPtr(20)}, ""},
+		{"为空", RollingStrategy{}, "不能为空"},
+		{"零", RollingStrategy{BatchSize: "0"}, "格式错误"},
+		{"负数", RollingStrategy{BatchSize: "-1"}, "格式错误"},
+		{"空项", RollingStrategy{BatchSize: "1,,2"}, "格式错误"},
+		{"百分比超过100", RollingStrategy{BatchSize: "150%"}, "不能超过100%"},
+		{"暂停时间为负", RollingStrategy{BatchSize: "1", PauseSeconds: -1}, "不能为负数"},
+		{"失败百分比越界", RollingStrategy{BatchSize: "1", MaxFailPercentage: intPtr(101)}, "0-100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRollingStrategy(&tt.strategy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShouldHalt(t *testing.T) {
+	batch := func(hosts, failed int) *model.RolloutBatch {
+		b := &model.RolloutBatch{Index: 2, HostIDs: make([]uint, hosts), Failed: failed}
+		b.FailureRate = float64(failed) * 100 / float64(hosts)
+		return b
+	}
+	tests := []struct {
+		name       string
+		maxFail    *int
+		batch      *model.RolloutBatch
+		wantHalt   bool
+		wantReason string
+	}{
+		{"全部成功", nil, batch(4, 0), false, ""},
+		{"未配置阈值部分失败", nil, batch(4, 3), false, ""},
+		{"未配置阈值全部失败", nil, batch(4, 4), true, "全部执行失败"},
+		{"阈值为0时任意失败即停止", intPtr(0), batch(4, 1), true, "超过阈值 0%"},
+		{"等于阈值不停止", intPtr(25), batch(4, 1), false, ""},
+		{"超过阈值停止", intPtr(25), batch(4, 2), true, "第2批失败率 50.0%（2/4）"},
+		{"阈值为100时不停止", intPtr(100), batch(4, 4), false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &RollingStrategy{BatchSize: "1", MaxFailPercentage: tt.maxFail}
+			halt, reason := s.shouldHalt(tt.batch)
+			if halt != tt.wantHalt {
+				t.Fatalf("halt = %v, want %v (%s)", halt, tt.wantHalt, reason)
+			}
+			if !strings.Contains(reason, tt.wantReason) {
+				t.Errorf("reason = %q, want containing %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestEncodeRolling(t *testing.T) {
+	if encodeRolling(nil) != "" || decodeRolling("") != nil || decodeRolling("{") != nil {
+		t.Fatal("empty or invalid strategy should round trip to nil")
+	}
+	s := &RollingStrategy{BatchSize: "1,50%", PauseSeconds: 30, MaxFailPercentage: intPtr(10)}
+	got := decodeRolling(encodeRolling(s))
+	if got == nil || got.BatchSize != s.BatchSize || got.PauseSeconds != s.PauseSeconds || *got.MaxFailPercentage != 10 {
+		t.Errorf("decoded = %+v, want %+v", got, s)
+	}
+}
@@ -0,0 +1,468 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	rbacbiz "github.com/ydcloud-dy/opshub/internal/biz/rbac"
+	"github.com/ydcloud-dy/opshub/pkg/logger"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultTimezone 定时任务默认时区
+	DefaultTimezone = "Asia/Shanghai"
+	// maxPreviewRuns 下次执行时间预览的最大条数
+	maxPreviewRuns = 50
+)
+
+// cronParser 支持标准5段表达式及 @every/@daily 等描述符
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ParseSchedule 解析带时区的cron表达式
+func ParseSchedule(expr, timezone string) (cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.New("cron表达式不能为空")
+	}
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		return nil, errors.New("请通过时区字段设置时区，cron表达式中不能包含 TZ= 前缀")
+	}
+	loc, err := loadTimezone(timezone)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("cron表达式无效: %w", err)
+	}
+	if spec, ok := schedule.(*cron.SpecSchedule); ok {
+		spec.Location = loc
+	}
+	return schedule, nil
+}
+
+// PreviewSchedule 预览cron表达式接下来 n 次的执行时间
+func PreviewSchedule(expr, timezone string, n int) ([]time.Time, error) {
+	schedule, err := ParseSchedule(expr, timezone)
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		n = 5
+	}
+	if n > maxPreviewRuns {
+		n = maxPreviewRuns
+	}
+	loc, _ := loadTimezone(timezone)
+
+	runs := make([]time.Time, 0, n)
+	next := time.Now().In(loc)
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next)
+	}
+	return runs, nil
+}
+
+// loadTimezone 加载时区，为空时使用默认时区
+func loadTimezone(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		timezone = DefaultTimezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区: %s", timezone)
+	}
+	return loc, nil
+}
+
+// Scheduler 定时任务调度器
+// 按各定时任务的cron表达式触发执行，每次触发都会创建一条 JobTask 并交由执行器异步执行；
+// 上一次触发的任务尚未结束时按重叠策略跳过、排队或并行执行
+type Scheduler struct {
+	db        *gorm.DB
+	executor  *Executor
+	policy    *PolicyEngine
+	approvals *ApprovalService
+	hostPerms *HostPermission
+
+	cron    *cron.Cron
+	entries map[uint]cron.EntryID
+	active  map[uint]int  // 各定时任务正在执行的任务数
+	queued  map[uint]bool // 各定时任务是否有排队等待的触发
+	running bool
+	mu      sync.Mutex
+}
+
+// NewScheduler 创建定时任务调度器
+func NewScheduler(db *gorm.DB, executor *Executor) *Scheduler {
+	return &Scheduler{
+		db:        db,
+		executor:  executor,
+		policy:    NewPolicyEngine(db),
+		approvals: NewApprovalService(db, executor),
+		hostPerms: NewHostPermission(db),
+		entries:   make(map[uint]cron.EntryID),
+		active:    make(map[uint]int),
+		queued:    make(map[uint]bool),
+	}
+}
+
+// Start 启动调度器，加载所有启用的定时任务
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.cron = cron.New(cron.WithParser(cronParser))
+	s.entries = make(map[uint]cron.EntryID)
+	s.mu.Unlock()
+
+	var schedules []model.JobSchedule
+	if err := s.db.Where("status = ? AND deleted_at IS NULL", 1).Find(&schedules).Error; err != nil {
+		logger.Error("加载定时任务失败", zap.Error(err))
+	}
+	for i := range schedules {
+		if err := s.add(&schedules[i]); err != nil {
+			logger.Error("注册定时任务失败", zap.Uint("scheduleId", schedules[i].ID), zap.Error(err))
+		}
+	}
+
+	s.cron.Start()
+	logger.Info("定时任务调度器已启动", zap.Int("count", len(schedules)))
+}
+
+// Stop 停止调度器
+// 已提交给执行器的任务不受影响，会继续执行完成
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	c := s.cron
+	s.mu.Unlock()
+
+	<-c.Stop().Done()
+	logger.Info("定时任务调度器已停止")
+}
+
+// Reload 重新加载定时任务，创建、更新、启停后调用
+func (s *Scheduler) Reload(id uint) error {
+	s.Remove(id)
+
+	var schedule model.JobSchedule
+	if err := s.db.Where("id = ? AND deleted_at IS NULL", id).First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if schedule.Status != 1 {
+		s.db.Model(&model.JobSchedule{}).Where("id = ?", id).Update("next_run_time", nil)
+		return nil
+	}
+	return s.add(&schedule)
+}
+
+// Remove 移除定时任务
+func (s *Scheduler) Remove(id uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entryID, ok := s.entries[id]; ok {
+		s.cron.Remove(entryID)
+		delete(s.entries, id)
+	}
+	delete(s.queued, id)
+}
+
+// Trigger 立即触发一次定时任务，同样遵循重叠策略
+func (s *Scheduler) Trigger(id uint) (*model.JobTask, error) {
+	return s.fire(id)
+}
+
+// add 注册定时任务并更新下次执行时间
+func (s *Scheduler) add(schedule *model.JobSchedule) error {
+	sched, err := ParseSchedule(schedule.CronExpr, schedule.Timezone)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return nil
+	}
+	id := schedule.ID
+	s.entries[id] = s.cron.Schedule(sched, cron.FuncJob(func() {
+		if _, err := s.fire(id); err != nil {
+			logger.Error("定时任务触发失败", zap.Uint("scheduleId", id), zap.Error(err))
+		}
+	}))
+	s.mu.Unlock()
+
+	s.updateNextRun(id, sched)
+	return nil
+}
+
+// updateNextRun 记录下次执行时间
+func (s *Scheduler) updateNextRun(id uint, sched cron.Schedule) {
+	next := sched.Next(time.Now())
+	s.db.Model(&model.JobSchedule{}).Where("id = ?", id).Update("next_run_time", &next)
+}
+
+// fire 触发一次定时任务
+func (s *Scheduler) fire(id uint) (*model.JobTask, error) {
+	var schedule model.JobSchedule
+	if err := s.db.Where("id = ? AND deleted_at IS NULL", id).First(&schedule).Error; err != nil {
+		s.Remove(id)
+		return nil, fmt.Errorf("定时任务不存在: %w", err)
+	}
+
+	if sched, err := ParseSchedule(schedule.CronExpr, schedule.Timezone); err == nil {
+		s.updateNextRun(id, sched)
+	}
+
+	// 按重叠策略处理仍在执行中的上一次任务
+	s.mu.Lock()
+	if s.active[id] > 0 {
+		switch schedule.OverlapPolicy {
+		case model.OverlapQueue:
+			s.queued[id] = true
+			s.mu.Unlock()
+			logger.Info("上一次任务仍在执行，本次触发已排队", zap.Uint("scheduleId", id))
+			return nil, nil
+		case model.OverlapParallel:
+		default:
+			s.mu.Unlock()
+			now := time.Now()
+			s.db.Model(&model.JobSchedule{}).Where("id = ?", id).Updates(map[string]interface{}{
+				"last_run_time": &now,
+				"last_status":   model.ScheduleStatusSkipped,
+			})
+			logger.Info("上一次任务仍在执行，本次触发已跳过", zap.Uint("scheduleId", id))
+			return nil, nil
+		}
+	}
+	s.active[id]++
+	s.mu.Unlock()
+
+	jobTask, err := s.dispatch(&schedule)
+	if err != nil {
+		s.release(id)
+	}
+	return jobTask, err
+}
+
+// dispatch 渲染模板、解析目标主机并创建任务记录，然后提交执行器执行
+// 准备阶段出错时同样会生成一条失败的任务记录，便于在执行记录中排查
+func (s *Scheduler) dispatch(schedule *model.JobSchedule) (*model.JobTask, error) {
+	ctx := context.Background()
+	now := time.Now()
+	scheduleID := schedule.ID
+	templateID := schedule.TemplateID
+	rolling := decodeRolling(schedule.Rolling)
+
+	params := map[string]interface{}{
+		"scriptType":   schedule.ScriptType,
+		"fork":         schedule.Fork,
+		"timeout":      schedule.Timeout,
+		"batchTimeout": schedule.BatchTimeout,
+		"scheduleId":   schedule.ID,
+		"templateId":   schedule.TemplateID,
+	}
+	if rolling != nil {
+		params["rolling"] = rolling
+	}
+	jobTask := &model.JobTask{
+		Name:        fmt.Sprintf("定时任务[%s] - %s", schedule.Name, now.Format("2006-01-02 15:04:05")),
+		TemplateID:  &templateID,
+		ScheduleID:  &scheduleID,
+		TaskType:    "cron",
+		Status:      model.JobStatusRunning,
+		TargetHosts: "[]",
+		CreatedBy:   schedule.CreatedBy,
+		ExecuteTime: &now,
+	}
+
+	var requirement *ApprovalRequirement
+	rendered, hostIDs, decision, prepareErr := s.prepare(ctx, schedule)
+	if prepareErr == nil {
+		requirement, prepareErr = s.approvals.Requirement(ctx, decision)
+	}
+	if prepareErr != nil {
+		jobTask.Status = model.JobStatusFailed
+		jobTask.ErrorMessage = prepareErr.Error()
+	} else {
+		hostIDsJSON, _ := json.Marshal(hostIDs)
+		jobTask.TargetHosts = string(hostIDsJSON)
+		rendered.RecordParameters(params, schedule.TemplateID)
+		if requirement != nil {
+			jobTask.Status = model.JobStatusPendingApproval
+			jobTask.ExecuteTime = nil
+		}
+	}
+	paramsJSON, _ := json.Marshal(params)
+	jobTask.Parameters = string(paramsJSON)
+
+	if err := s.db.Create(jobTask).Error; err != nil {
+		return nil, fmt.Errorf("创建任务记录失败: %w", err)
+	}
+	s.db.Model(&model.JobSchedule{}).Where("id = ?", schedule.ID).Updates(map[string]interface{}{
+		"last_run_time": &now,
+		"last_job_id":   jobTask.ID,
+		"last_status":   jobTask.Status,
+	})
+
+	if prepareErr != nil {
+		return jobTask, prepareErr
+	}
+
+	// 需要审批时不占用执行名额，审批通过后由审批服务执行
+	if requirement != nil {
+		_, err := s.approvals.Submit(ctx, jobTask, requirement, PendingExecution{
+			ScriptType:   schedule.ScriptType,
+			Content:      rendered.Content,
+			Fork:         schedule.Fork,
+			Timeout:      schedule.Timeout,
+			BatchTimeout: schedule.BatchTimeout,
+			Rolling:      rolling,
+			Secrets:      rendered.Secrets,
+		})
+		if err != nil {
+			s.db.Model(jobTask).Updates(map[string]interface{}{"status": model.JobStatusFailed, "error_message": err.Error()})
+		}
+		s.release(scheduleID)
+		return jobTask, err
+	}
+
+	s.executor.Submit(jobTask, hostIDs, ExecuteOptions{
+		ScriptType:   schedule.ScriptType,
+		Content:      rendered.Content,
+		Fork:         schedule.Fork,
+		HostTimeout:  time.Duration(schedule.Timeout) * time.Second,
+		BatchTimeout: time.Duration(schedule.BatchTimeout) * time.Second,
+		Rolling:      rolling,
+		Secrets:      rendered.Secrets,
+		OnComplete: func(jobID uint, status string) {
+			s.db.Model(&model.JobSchedule{}).
+				Where("id = ? AND last_job_id = ?", scheduleID, jobID).
+				Update("last_status", status)
+			s.release(scheduleID)
+		},
+	})
+	return jobTask, nil
+}
+
+// prepare 渲染模板内容、解析目标主机并评估命令策略，命中拦截策略时返回错误
+func (s *Scheduler) prepare(ctx context.Context, schedule *model.JobSchedule) (*RenderedTemplate, []uint, *PolicyDecision, error) {
+	var template model.JobTemplate
+	if err := s.db.Where("id = ? AND deleted_at IS NULL", schedule.TemplateID).First(&template).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("任务模板不存在")
+	}
+	if template.Status != 1 {
+		return nil, nil, nil, fmt.Errorf("任务模板【%s】已禁用", template.Name)
+	}
+
+	values := make(map[string]interface{})
+	if schedule.Variables != "" {
+		if err := json.Unmarshal([]byte(schedule.Variables), &values); err != nil {
+			return nil, nil, nil, fmt.Errorf("变量取值格式错误: %w", err)
+		}
+	}
+	rendered, err := RenderTemplate(ctx, s.db, &template, schedule.ScriptType, values)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var hostIDs, groupIDs []uint
+	if schedule.HostIDs != "" {
+		if err := json.Unmarshal([]byte(schedule.HostIDs), &hostIDs); err != nil {
+			return nil, nil, nil, fmt.Errorf("目标主机格式错误: %w", err)
+		}
+	}
+	if schedule.GroupIDs != "" {
+		if err := json.Unmarshal([]byte(schedule.GroupIDs), &groupIDs); err != nil {
+			return nil, nil, nil, fmt.Errorf("目标分组格式错误: %w", err)
+		}
+	}
+	targets, err := ResolveTargetHosts(ctx, s.db, hostIDs, groupIDs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(targets) == 0 {
+		return nil, nil, nil, errors.New("没有可执行的目标主机")
+	}
+	// 创建人的资产授权、分组成员都可能变化，每次触发都重新检查执行任务权限
+	if err := s.hostPerms.Check(ctx, schedule.CreatedBy, targets, rbacbiz.PermissionExecute); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 模板、策略和分组成员都可能在定时任务创建后变化，每次触发都以创建人身份重新评估策略
+	decision, err := s.policy.Evaluate(ctx, schedule.ScriptType, rendered.Content, PolicySubject{UserID: schedule.CreatedBy, HostIDs: targets})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if decision.Action == model.PolicyActionDeny {
+		return nil, nil, nil, errors.New(decision.Reason)
+	}
+	return rendered, targets, decision, nil
+}
+
+// release 任务结束后释放占用，如有排队的触发则立即补执行一次
+func (s *Scheduler) release(id uint) {
+	s.mu.Lock()
+	if s.active[id] > 0 {
+		s.active[id]--
+	}
+	if s.active[id] == 0 {
+		delete(s.active, id)
+	}
+	runQueued := s.queued[id] && s.active[id] == 0 && s.running
+	if runQueued {
+		delete(s.queued, id)
+	}
+	s.mu.Unlock()
+
+	if runQueued {
+		go func() {
+			if _, err := s.fire(id); err != nil {
+				logger.Error("执行排队的定时任务失败", zap.Uint("scheduleId", id), zap.Error(err))
+			}
+		}()
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		timezone string
+		wantErr  string
+	}{
+		{"标准5段表达式", "0 2 * * *", "Asia/Shanghai", ""},
+		{"默认时区", "*/5 * * * *", "", ""},
+		{"描述符", "@daily", "UTC", ""},
+		{"every描述符", "@every 30m", "UTC", ""},
+		{"空表达式", "  ", "UTC", "不能为空"},
+		{"TZ前缀", "TZ=UTC 0 2 * * *", "UTC", "TZ="},
+		{"CRON_TZ前缀", "CRON_TZ=UTC 0 2 * * *", "UTC", "TZ="},
+		{"6段表达式", "0 0 2 * * *", "UTC", "cron表达式无效"},
+		{"非法字段", "61 * * * *", "UTC", "cron表达式无效"},
+		{"无效时区", "0 2 * * *", "Mars/Olympus", "无效的时区"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchedule(tt.expr, tt.timezone)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseScheduleTimezone(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		timezone string
+		want     time.Time
+	}{
+		// 上海 02:00 即 UTC 前一天 18:00，从 UTC 0 点起算下一次为当天 18:00
+		{"Asia/Shanghai", time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC)},
+		{"UTC", time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)},
+		{"America/New_York", time.Date(2024, 1, 1, 7, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			schedule, err := ParseSchedule("0 2 * * *", tt.timezone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("next = %s, want %s", got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewSchedule(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		want    int
+		wantErr bool
+	}{
+		{"默认条数", 0, 5, false},
+		{"指定条数", 3, 3, false},
+		{"超过上限", 100, maxPreviewRuns, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs, err := PreviewSchedule("*/10 * * * *", "Asia/Shanghai", tt.n)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(runs) != tt.want {
+				t.Fatalf("runs = %d, want %d", len(runs), tt.want)
+			}
+			for i, run := range runs {
+				if run.Location().String() != "Asia/Shanghai" {
+					t.Errorf("run %d location = %s, want Asia/Shanghai", i, run.Location())
+				}
+				if run.Minute()%10 != 0 {
+					t.Errorf("run %d = %s, want minute multiple of 10", i, run)
+				}
+				if i > 0 && run.Sub(runs[i-1]) != 10*time.Minute {
+					t.Errorf("run %d interval = %s, want 10m", i, run.Sub(runs[i-1]))
+				}
+			}
+		})
+	}
+
+	if _, err := PreviewSchedule("bad", "UTC", 5); err == nil {
+		t.Error("expected error for invalid expression")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"gorm.io/gorm"
+)
+
+// encryptedPrefix 任务中心加密保存的敏感值前缀，用于区分历史明文数据
+const encryptedPrefix = "enc:"
+
+// sealSecret 加密保存的敏感值，空值及已加密的值原样返回
+func sealSecret(plaintext string) (string, error) {
+	if plaintext == "" || isSealed(plaintext) {
+		return plaintext, nil
+	}
+	return sealValue(plaintext)
+}
+
+// sealValue 无条件加密，用于内容可能恰好以加密前缀开头的场景，如待执行的脚本
+func sealValue(plaintext string) (string, error) {
+	ciphertext, err := crypto.Encrypt(plaintext)
+	if err != nil {
+		return "", fmt.Errorf("加密敏感数据失败: %w", err)
+	}
+	return encryptedPrefix + ciphertext, nil
+}
+
+// openSecret 解密 sealSecret 的结果，未加密的历史数据原样返回
+func openSecret(value string) (string, error) {
+	if !isSealed(value) {
+		return value, nil
+	}
+	plaintext, err := crypto.Decrypt(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("解密敏感数据失败: %w", err)
+	}
+	return plaintext, nil
+}
+
+// isSealed 是否为 sealSecret 加密的值
+func isSealed(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// RotateSecrets 使用密钥环的主用密钥重新加密任务中心保存的敏感数据，返回重新加密的记录数，
+// dryRun 为 true 时只统计不写入
+func RotateSecrets(ctx context.Context, db *gorm.DB, k *crypto.Keyring, dryRun bool) (int, error) {
+	table := model.JobApproval{}.TableName()
+	total, err := crypto.RotateColumns(ctx, db, k, []crypto.Column{
+		{Table: table, Column: "content", Prefix: encryptedPrefix},
+		{Table: table, Column: "secrets", Prefix: encryptedPrefix},
+	}, dryRun)
+	if err != nil {
+		return total, err
+	}
+
+	// secret 变量的默认值保存在模板的变量定义中
+	var templates []model.JobTemplate
+	if err := db.WithContext(ctx).Select("id, variables").
+		Where("variables LIKE ?", "%"+encryptedPrefix+"%").
+		Find(&templates).Error; err != nil {
+		return total, err
+	}
+	for _, template := range templates {
+		vars, err := ParseTemplateVariables(template.Variables)
+		if err != nil {
+			return total, fmt.Errorf("模板 %d: %w", template.ID, err)
+		}
+		changed := false
+		for i := range vars {
+			if vars[i].NormalizedType() != model.VarTypeSecret || !isSealed(vars[i].DefaultValue) {
+				continue
+			}
+			rotated, ok, err := k.Rotate(strings.TrimPrefix(vars[i].DefaultValue, encryptedPrefix), false)
+			if err != nil {
+				return total, fmt.Errorf("重新加密模板 %d 的变量 %s 失败: %w", template.ID, vars[i].VarName, err)
+			}
+			if ok {
+				vars[i].DefaultValue = encryptedPrefix + rotated
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		total++
+		if dryRun {
+			continue
+		}
+		data, _ := json.Marshal(vars)
+		if err := db.WithContext(ctx).Model(&model.JobTemplate{}).
+			Where("id = ? AND variables = ?", template.ID, template.Variables).
+			UpdateColumn("variables", string(data)).Error; err != nil {
+			return total, fmt.Errorf("更新模板 %d 失败: %w", template.ID, err)
+		}
+	}
+	return total, nil
+}
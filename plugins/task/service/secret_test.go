@@ -0,0 +1,83 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ydcloud-dy/opshub/pkg/crypto"
+)
+
+func TestMain(m *testing.M) {
+	keyring, err := crypto.NewKeyring("test", map[string][]byte{"test": []byte("opshub-task-test-key-32-bytes!!!")}, true)
+	if err != nil {
+		panic(err)
+	}
+	crypto.SetDefault(keyring)
+	os.Exit(m.Run())
+}
+
+func TestSealSecret(t *testing.T) {
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{"普通值", "p@ssw0rd"},
+		{"多行脚本", "#!/bin/bash\necho 'token'\n"},
+		{"以加密前缀开头的脚本", "enc: not really encrypted"},
+		{"空值", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := sealValue(tt.plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !isSealed(sealed) || (tt.plaintext != "" && strings.Contains(sealed, tt.plaintext)) {
+				t.Fatalf("sealed = %q", sealed)
+			}
+			opened, err := openSecret(sealed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opened != tt.plaintext {
+				t.Errorf("opened = %q, want %q", opened, tt.plaintext)
+			}
+		})
+	}
+
+	// sealSecret 对已加密的值和空值保持不变
+	sealed, _ := sealSecret("value")
+	if again, _ := sealSecret(sealed); again != sealed {
+		t.Error("sealSecret should be idempotent")
+	}
+	if empty, _ := sealSecret(""); empty != "" {
+		t.Errorf("sealSecret(\"\") = %q", empty)
+	}
+	// 未加密的历史数据原样返回
+	if plain, err := openSecret(`["a"]`); err != nil || plain != `["a"]` {
+		t.Errorf("openSecret(plain) = %q, %v", plain, err)
+	}
+	if _, err := openSecret(encryptedPrefix + "broken"); err == nil {
+		t.Error("expected error for corrupted ciphertext")
+	}
+}
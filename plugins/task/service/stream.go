@@ -0,0 +1,264 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+const (
+	// maxBufferedEvents 每个任务在内存中保留的输出事件上限，超出后丢弃最早的事件
+	maxBufferedEvents = 20000
+	// maxLineLength 单行最大长度，超出后强制切分
+	maxLineLength = 4096
+	// subscriberBuffer 订阅者通道缓冲大小，消费过慢的订阅者会被断开，由客户端携带 seq 重连补齐
+	subscriberBuffer = 512
+	// streamRetention 任务结束后输出流的保留时间，便于客户端重连回放
+	streamRetention = 10 * time.Minute
+)
+
+// 输出事件类型
+const (
+	EventStdout = "stdout" // 标准输出行
+	EventStderr = "stderr" // 标准错误行
+	EventStatus = "status" // 主机状态变化
+	EventDone   = "done"   // 任务结束
+	EventBatch  = "batch"  // 滚动执行批次变化
+)
+
+// OutputEvent 任务输出事件
+type OutputEvent struct {
+	Seq      uint64    `json:"seq"`
+	JobID    uint      `json:"jobId"`
+	HostID   uint      `json:"hostId,omitempty"`
+	HostName string    `json:"hostName,omitempty"`
+	Type     string    `json:"type"` // stdout, stderr, status, done
+	Data     string    `json:"data"`
+	Time     time.Time `json:"time"`
+}
+
+// jobStream 单个任务的输出流
+// 缓存已产生的事件用于回放，并向所有订阅者广播新事件
+type jobStream struct {
+	jobID uint
+
+	mu      sync.Mutex
+	events  []OutputEvent
+	nextSeq uint64
+	subs    map[chan OutputEvent]struct{}
+	done    bool
+}
+
+func newJobStream(jobID uint) *jobStream {
+	return &jobStream{
+		jobID:   jobID,
+		nextSeq: 1,
+		subs:    make(map[chan OutputEvent]struct{}),
+	}
+}
+
+// publish 追加事件并广播给订阅者
+func (s *jobStream) publish(ev OutputEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done {
+		return
+	}
+
+	ev.Seq = s.nextSeq
+	ev.JobID = s.jobID
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	s.nextSeq++
+
+	s.events = append(s.events, ev)
+	if len(s.events) > maxBufferedEvents {
+		s.events = s.events[len(s.events)-maxBufferedEvents:]
+	}
+
+	for ch := range s.subs {
+		select {
+		case ch <- ev:
+		default:
+			// 订阅者消费过慢，断开连接，客户端可根据 seq 重连补齐
+			delete(s.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe 订阅输出流
+// 返回 seq 大于 since 的已缓存事件，以及后续事件的通道；任务已结束时通道为 nil
+func (s *jobStream) subscribe(since uint64) ([]OutputEvent, chan OutputEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replay := make([]OutputEvent, 0)
+	for _, ev := range s.events {
+		if ev.Seq > since {
+			replay = append(replay, ev)
+		}
+	}
+
+	if s.done {
+		return replay, nil
+	}
+
+	ch := make(chan OutputEvent, subscriberBuffer)
+	s.subs[ch] = struct{}{}
+	return replay, ch
+}
+
+// snapshot 返回当前缓存的全部事件
+func (s *jobStream) snapshot() []OutputEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := make([]OutputEvent, len(s.events))
+	copy(events, s.events)
+	return events
+}
+
+// unsubscribe 取消订阅
+func (s *jobStream) unsubscribe(ch chan OutputEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[ch]; ok {
+		delete(s.subs, ch)
+		close(ch)
+	}
+}
+
+// close 发布结束事件并关闭所有订阅
+func (s *jobStream) close(status string) {
+	s.publish(OutputEvent{Type: EventDone, Data: status})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.done = true
+	for ch := range s.subs {
+		close(ch)
+	}
+	s.subs = make(map[chan OutputEvent]struct{})
+}
+
+// lineWriter 将写入的数据按行切分后发布到输出流
+type lineWriter struct {
+	stream   *jobStream
+	hostID   uint
+	hostName string
+	typ      string
+	combined *combinedOutput
+	secrets  []string
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	if w.combined != nil {
+		w.combined.Write(p)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	// 超长行强制切分前多保留最长敏感值的长度，保证跨越切分点的敏感值完整可见，先脱敏再切分
+	hold := maxSecretLength(w.secrets)
+	for {
+		data := w.buf.Bytes()
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			if len(data) >= maxLineLength+hold {
+				cut := safeCut(data, maxLineLength, w.secrets)
+				w.emit(string(data[:cut]))
+				w.buf.Next(cut)
+				continue
+			}
+			break
+		}
+		w.emit(string(bytes.TrimSuffix(data[:idx], []byte("\r"))))
+		w.buf.Next(idx + 1)
+	}
+	return len(p), nil
+}
+
+// Flush 输出剩余不足一行的数据
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buf.Len() > 0 {
+		w.emit(w.buf.String())
+		w.buf.Reset()
+	}
+}
+
+func (w *lineWriter) emit(line string) {
+	if w.stream == nil {
+		return
+	}
+	w.stream.publish(OutputEvent{
+		HostID:   w.hostID,
+		HostName: w.hostName,
+		Type:     w.typ,
+		Data:     RedactSecrets(line, w.secrets),
+	})
+}
+
+// maxSecretLength 返回最长敏感值的长度
+func maxSecretLength(secrets []string) int {
+	n := 0
+	for _, secret := range secrets {
+		if len(secret) > n {
+			n = len(secret)
+		}
+	}
+	return n
+}
+
+// safeCut 调整切分位置，避免把敏感值切成两段导致各自无法被脱敏
+// 切分点落在某个敏感值中间时后移到该敏感值末尾，调用方需保证 data 包含切分点之后至少最长敏感值长度的数据
+func safeCut(data []byte, cut int, secrets []string) int {
+	for moved := true; moved; {
+		moved = false
+		for _, secret := range secrets {
+			if secret == "" {
+				continue
+			}
+			start := cut - len(secret) + 1
+			if start < 0 {
+				start = 0
+			}
+			i := bytes.Index(data[start:], []byte(secret))
+			if i >= 0 && start+i < cut && start+i+len(secret) > cut {
+				cut = start + i + len(secret)
+				moved = true
+			}
+		}
+	}
+	return cut
+}
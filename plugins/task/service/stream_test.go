@@ -0,0 +1,198 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJobStreamSubscribeSince(t *testing.T) {
+	s := newJobStream(7)
+	for i := 0; i < 5; i++ {
+		s.publish(OutputEvent{Type: EventStdout, Data: "line"})
+	}
+
+	tests := []struct {
+		name   string
+		since  uint64
+		first  uint64
+		replay int
+	}{
+		{"全部回放", 0, 1, 5},
+		{"从中间续传", 3, 4, 2},
+		{"已是最新", 5, 0, 0},
+		{"超过最新", 9, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay, ch := s.subscribe(tt.since)
+			defer s.unsubscribe(ch)
+			if len(replay) != tt.replay {
+				t.Fatalf("replay = %d, want %d", len(replay), tt.replay)
+			}
+			if tt.replay > 0 && replay[0].Seq != tt.first {
+				t.Errorf("first seq = %d, want %d", replay[0].Seq, tt.first)
+			}
+			for _, ev := range replay {
+				if ev.JobID != 7 {
+					t.Errorf("jobId = %d, want 7", ev.JobID)
+				}
+			}
+		})
+	}
+}
+
+func TestJobStreamRingBuffer(t *testing.T) {
+	s := newJobStream(1)
+	total := maxBufferedEvents + 10
+	for i := 0; i < total; i++ {
+		s.publish(OutputEvent{Type: EventStdout})
+	}
+
+	replay, ch := s.subscribe(0)
+	s.unsubscribe(ch)
+	if len(replay) != maxBufferedEvents {
+		t.Fatalf("buffered = %d, want %d", len(replay), maxBufferedEvents)
+	}
+	// 丢弃最早的事件，seq 保持连续递增
+	if replay[0].Seq != 11 || replay[len(replay)-1].Seq != uint64(total) {
+		t.Errorf("seq range = [%d, %d], want [11, %d]", replay[0].Seq, replay[len(replay)-1].Seq, total)
+	}
+}
+
+func TestJobStreamClose(t *testing.T) {
+	s := newJobStream(1)
+	_, ch := s.subscribe(0)
+	s.publish(OutputEvent{Type: EventStdout, Data: "a"})
+	s.close("success")
+
+	var got []OutputEvent
+	for ev := range ch {
+		got = append(got, ev)
+	}
+	if len(got) != 2 || got[1].Type != EventDone || got[1].Data != "success" {
+		t.Fatalf("events = %+v, want stdout then done", got)
+	}
+
+	// 结束后订阅只返回回放，不再返回通道
+	replay, ch := s.subscribe(0)
+	if ch != nil || len(replay) != 2 {
+		t.Errorf("subscribe after close = (%d, %v), want (2, nil)", len(replay), ch)
+	}
+	// 结束后发布的事件被丢弃
+	s.publish(OutputEvent{Type: EventStdout})
+	if n := len(s.snapshot()); n != 2 {
+		t.Errorf("snapshot = %d, want 2", n)
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	secret := "s3cr3t-token-value"
+	long := strings.Repeat("x", maxLineLength-5) + secret + strings.Repeat("y", 10)
+
+	tests := []struct {
+		name    string
+		writes  []string
+		secrets []string
+		want    []string
+	}{
+		{
+			name:   "按行切分并去掉回车",
+			writes: []string{"a\r\nb\n", "c"},
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name:   "跨多次写入拼接一行",
+			writes: []string{"hel", "lo\nwor", "ld\n"},
+			want:   []string{"hello", "world"},
+		},
+		{
+			name:    "逐行脱敏",
+			writes:  []string{"token=" + secret + "\n"},
+			secrets: []string{secret},
+			want:    []string{"token=" + MaskedValue},
+		},
+		{
+			name:    "跨越强制切分点的敏感值仍被脱敏",
+			writes:  []string{long},
+			secrets: []string{secret},
+			want: []string{
+				strings.Repeat("x", maxLineLength-5) + MaskedValue,
+				strings.Repeat("y", 10),
+			},
+		},
+		{
+			name:    "敏感值分多次写入跨越切分点",
+			writes:  []string{long[:maxLineLength], long[maxLineLength:]},
+			secrets: []string{secret},
+			want: []string{
+				strings.Repeat("x", maxLineLength-5) + MaskedValue,
+				strings.Repeat("y", 10),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newJobStream(1)
+			w := &lineWriter{stream: s, typ: EventStdout, secrets: tt.secrets}
+			for _, data := range tt.writes {
+				w.Write([]byte(data))
+			}
+			w.Flush()
+
+			var got []string
+			for _, ev := range s.snapshot() {
+				got = append(got, ev.Data)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("lines = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("line %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSafeCut(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		cut     int
+		secrets []string
+		want    int
+	}{
+		{"无敏感值", "abcdef", 3, nil, 3},
+		{"切分点不在敏感值内", "abcSECRETdef", 3, []string{"SECRET"}, 3},
+		{"切分点在敏感值内后移到末尾", "abcSECRETdef", 5, []string{"SECRET"}, 9},
+		{"切分点在敏感值末尾", "abcSECRETdef", 9, []string{"SECRET"}, 9},
+		{"后移后落入另一个敏感值", "abAAAABBBBcd", 4, []string{"AAAA", "ABBBB"}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := safeCut([]byte(tt.data), tt.cut, tt.secrets); got != tt.want {
+				t.Errorf("safeCut = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
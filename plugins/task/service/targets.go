@@ -0,0 +1,127 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"fmt"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"gorm.io/gorm"
+)
+
+// ResolveTargetHosts 合并直接指定的主机与分组（含所有子孙分组）下的主机，去重后按出现顺序返回
+func ResolveTargetHosts(ctx context.Context, db *gorm.DB, hostIDs, groupIDs []uint) ([]uint, error) {
+	seen := make(map[uint]bool)
+	result := make([]uint, 0, len(hostIDs))
+	add := func(ids []uint) {
+		for _, id := range ids {
+			if id != 0 && !seen[id] {
+				seen[id] = true
+				result = append(result, id)
+			}
+		}
+	}
+	add(hostIDs)
+
+	if len(groupIDs) > 0 {
+		allGroups, err := expandGroups(ctx, db, groupIDs)
+		if err != nil {
+			return nil, err
+		}
+		var groupHosts []uint
+		if err := db.WithContext(ctx).Model(&assetbiz.Host{}).
+			Where("group_id IN ?", allGroups).
+			Order("id ASC").
+			Pluck("id", &groupHosts).Error; err != nil {
+			return nil, fmt.Errorf("查询分组主机失败: %w", err)
+		}
+		add(groupHosts)
+	}
+
+	return result, nil
+}
+
+// expandGroups 返回指定分组及其所有子孙分组ID
+func expandGroups(ctx context.Context, db *gorm.DB, groupIDs []uint) ([]uint, error) {
+	seen := make(map[uint]bool)
+	all := make([]uint, 0, len(groupIDs))
+	queue := append([]uint(nil), groupIDs...)
+	for len(queue) > 0 {
+		var next []uint
+		for _, id := range queue {
+			if !seen[id] {
+				seen[id] = true
+				all = append(all, id)
+				next = append(next, id)
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		var children []uint
+		if err := db.WithContext(ctx).Model(&assetbiz.AssetGroup{}).
+			Where("parent_id IN ?", next).
+			Pluck("id", &children).Error; err != nil {
+			return nil, fmt.Errorf("查询子分组失败: %w", err)
+		}
+		queue = children
+	}
+	return all, nil
+}
+
+// HostGroupScope 返回主机所属分组及其所有上级分组ID，用于按分组生效的策略匹配
+func HostGroupScope(ctx context.Context, db *gorm.DB, hostIDs []uint) ([]uint, error) {
+	groupIDs := []uint{}
+	if len(hostIDs) == 0 {
+		return groupIDs, nil
+	}
+	var direct []uint
+	if err := db.WithContext(ctx).Model(&assetbiz.Host{}).
+		Where("id IN ? AND group_id > 0", hostIDs).
+		Distinct().
+		Pluck("group_id", &direct).Error; err != nil {
+		return nil, fmt.Errorf("查询主机分组失败: %w", err)
+	}
+
+	seen := make(map[uint]bool)
+	queue := direct
+	for len(queue) > 0 {
+		var next []uint
+		for _, id := range queue {
+			if id != 0 && !seen[id] {
+				seen[id] = true
+				groupIDs = append(groupIDs, id)
+				next = append(next, id)
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		var parents []uint
+		if err := db.WithContext(ctx).Model(&assetbiz.AssetGroup{}).
+			Where("id IN ? AND parent_id > 0", next).
+			Pluck("parent_id", &parents).Error; err != nil {
+			return nil, fmt.Errorf("查询上级分组失败: %w", err)
+		}
+		queue = parents
+	}
+	return groupIDs, nil
+}
@@ -0,0 +1,521 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	assetbiz "github.com/ydcloud-dy/opshub/internal/biz/asset"
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaskedValue 脱敏后的显示值
+	MaskedValue = "******"
+	// minSecretLength secret 变量取值的最小长度，过短的值在输出脱敏时会误伤正常内容，也容易从脱敏结果中推断
+	minSecretLength = 6
+)
+
+var (
+	// varNameRe 合法的变量名
+	varNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// placeholderRe 模板内容中的变量占位符
+	placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// RenderedTemplate 模板渲染结果
+type RenderedTemplate struct {
+	Content string                 // 渲染后的脚本内容
+	Masked  map[string]interface{} // 变量取值，secret 已脱敏，可直接记录到任务参数中
+	Secrets []string               // secret 原值，用于对执行输出脱敏
+
+	secretNames []string
+}
+
+// RecordParameters 将模板ID及脱敏后的变量取值写入任务参数
+func (r *RenderedTemplate) RecordParameters(params map[string]interface{}, templateID uint) {
+	params["templateId"] = templateID
+	params["variables"] = r.Masked
+	if len(r.secretNames) > 0 {
+		params["secretVariables"] = r.secretNames
+	}
+}
+
+// ParseTemplateVariables 解析模板变量定义
+func ParseTemplateVariables(raw string) ([]model.TemplateVariable, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "null" {
+		return nil, nil
+	}
+	var vars []model.TemplateVariable
+	if err := json.Unmarshal([]byte(raw), &vars); err != nil {
+		return nil, fmt.Errorf("模板变量定义格式错误: %w", err)
+	}
+	return vars, nil
+}
+
+// ValidateTemplateVariables 校验模板变量定义
+func ValidateTemplateVariables(raw string) error {
+	vars, err := ParseTemplateVariables(raw)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range vars {
+		if !varNameRe.MatchString(v.VarName) {
+			return fmt.Errorf("变量名【%s】不合法，只能包含字母、数字和下划线，且不能以数字开头", v.VarName)
+		}
+		if seen[v.VarName] {
+			return fmt.Errorf("变量名【%s】重复", v.VarName)
+		}
+		seen[v.VarName] = true
+
+		switch v.NormalizedType() {
+		case model.VarTypeString, model.VarTypeSecret, model.VarTypeHostList:
+		case model.VarTypeInt:
+			if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+				return fmt.Errorf("变量【%s】的最小值不能大于最大值", variableLabel(v))
+			}
+		case model.VarTypeEnum:
+			if len(v.Options) == 0 {
+				return fmt.Errorf("枚举变量【%s】必须设置可选值", variableLabel(v))
+			}
+		default:
+			return fmt.Errorf("变量【%s】的类型【%s】不支持", variableLabel(v), v.Type)
+		}
+
+		// secret 变量的默认值加密保存，编辑时回传脱敏值表示沿用原值
+		if v.NormalizedType() == model.VarTypeSecret && (v.DefaultValue == MaskedValue || isSealed(v.DefaultValue)) {
+			continue
+		}
+		if v.DefaultValue != "" && v.NormalizedType() != model.VarTypeHostList {
+			if _, err := renderValue(v, v.DefaultValue, "Shell"); err != nil {
+				return fmt.Errorf("变量【%s】的默认值无效: %w", variableLabel(v), err)
+			}
+		}
+	}
+	return nil
+}
+
+// ProtectTemplateSecrets 加密模板变量定义中 secret 变量的默认值
+// 默认值为脱敏值时沿用 saved 中同名变量已加密的默认值
+func ProtectTemplateSecrets(raw, saved string) (string, error) {
+	vars, err := ParseTemplateVariables(raw)
+	if err != nil || len(vars) == 0 {
+		return raw, err
+	}
+	previous := make(map[string]string)
+	if savedVars, err := ParseTemplateVariables(saved); err == nil {
+		for _, v := range savedVars {
+			previous[v.VarName] = v.DefaultValue
+		}
+	}
+
+	for i := range vars {
+		if vars[i].NormalizedType() != model.VarTypeSecret {
+			continue
+		}
+		if vars[i].DefaultValue == MaskedValue {
+			vars[i].DefaultValue = previous[vars[i].VarName]
+			if vars[i].DefaultValue == MaskedValue {
+				vars[i].DefaultValue = ""
+			}
+		}
+		if vars[i].DefaultValue, err = sealSecret(vars[i].DefaultValue); err != nil {
+			return "", err
+		}
+	}
+	data, _ := json.Marshal(vars)
+	return string(data), nil
+}
+
+// MaskTemplateSecrets 对模板中 secret 变量的默认值脱敏
+func MaskTemplateSecrets(template *model.JobTemplate) {
+	vars, err := ParseTemplateVariables(template.Variables)
+	if err != nil || len(vars) == 0 {
+		return
+	}
+	changed := false
+	for i := range vars {
+		if vars[i].NormalizedType() == model.VarTypeSecret && vars[i].DefaultValue != "" {
+			vars[i].DefaultValue = MaskedValue
+			changed = true
+		}
+	}
+	if changed {
+		data, _ := json.Marshal(vars)
+		template.Variables = string(data)
+	}
+}
+
+// EncryptTemplateSecrets 加密历史模板中以明文保存的 secret 变量默认值
+func EncryptTemplateSecrets(db *gorm.DB) error {
+	var templates []model.JobTemplate
+	if err := db.Select("id, variables").Where("variables LIKE ?", "%defaultValue%").Find(&templates).Error; err != nil {
+		return err
+	}
+	for _, t := range templates {
+		protected, err := ProtectTemplateSecrets(t.Variables, "")
+		if err != nil || protected == t.Variables {
+			continue
+		}
+		if err := db.Model(&model.JobTemplate{}).Where("id = ?", t.ID).Update("variables", protected).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderTemplate 校验变量取值并替换模板内容中的 {{varName}} 占位符
+// 未提供取值的变量使用默认值，必填变量缺少取值时返回错误。
+// 替换后的值按脚本类型转义为字面量：Shell 为单引号字符串，Python 为字符串字面量；
+// int 为校验后的数字，host-list 在 Shell 中为转义后以空格分隔的主机IP、在 Python 中为主机IP列表。
+// 因此模板中应直接引用占位符，而不要再用引号包裹
+func RenderTemplate(ctx context.Context, db *gorm.DB, template *model.JobTemplate, scriptType string, values map[string]interface{}) (*RenderedTemplate, error) {
+	if _, err := quoteLiteral(scriptType, ""); err != nil {
+		return nil, err
+	}
+	vars, err := ParseTemplateVariables(template.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RenderedTemplate{Masked: make(map[string]interface{})}
+	rendered := make(map[string]string, len(vars))
+	for _, v := range vars {
+		if v.VarName == "" {
+			continue
+		}
+		value, ok := values[v.VarName]
+		if !ok || isEmptyValue(value) {
+			value = v.DefaultValue
+			if v.NormalizedType() == model.VarTypeSecret {
+				plain, err := openSecret(v.DefaultValue)
+				if err != nil {
+					return nil, fmt.Errorf("模板变量【%s】%w", variableLabel(v), err)
+				}
+				value = plain
+			}
+		}
+		if isEmptyValue(value) {
+			if v.Required {
+				return nil, fmt.Errorf("模板变量【%s】为必填项", variableLabel(v))
+			}
+			rendered[v.VarName] = emptyLiteral(v, scriptType)
+			result.Masked[v.VarName] = ""
+			continue
+		}
+
+		if v.NormalizedType() == model.VarTypeHostList {
+			ids, err := toHostIDs(value)
+			if err != nil {
+				return nil, fmt.Errorf("模板变量【%s】%w", variableLabel(v), err)
+			}
+			ips, err := lookupHostIPs(ctx, db, ids)
+			if err != nil {
+				return nil, fmt.Errorf("模板变量【%s】%w", variableLabel(v), err)
+			}
+			quoted := make([]string, 0, len(ips))
+			for _, ip := range ips {
+				q, _ := quoteLiteral(scriptType, ip)
+				quoted = append(quoted, q)
+			}
+			if isPython(scriptType) {
+				rendered[v.VarName] = "[" + strings.Join(quoted, ", ") + "]"
+			} else {
+				rendered[v.VarName] = strings.Join(quoted, " ")
+			}
+			result.Masked[v.VarName] = ids
+			continue
+		}
+
+		str, err := toString(value)
+		if err != nil {
+			return nil, fmt.Errorf("模板变量【%s】%w", variableLabel(v), err)
+		}
+		out, err := renderValue(v, str, scriptType)
+		if err != nil {
+			return nil, fmt.Errorf("模板变量【%s】%w", variableLabel(v), err)
+		}
+		rendered[v.VarName] = out
+		if v.NormalizedType() == model.VarTypeSecret {
+			result.Masked[v.VarName] = MaskedValue
+			result.Secrets = append(result.Secrets, str)
+			result.secretNames = append(result.secretNames, v.VarName)
+		} else {
+			result.Masked[v.VarName] = str
+		}
+	}
+
+	// 只替换已声明的变量，替换结果不会被再次扫描
+	result.Content = placeholderRe.ReplaceAllStringFunc(template.Content, func(match string) string {
+		name := placeholderRe.FindStringSubmatch(match)[1]
+		if out, ok := rendered[name]; ok {
+			return out
+		}
+		return match
+	})
+	return result, nil
+}
+
+// MaskVariableValues 对变量取值JSON中的 secret 变量脱敏
+func MaskVariableValues(template *model.JobTemplate, valuesJSON string) string {
+	values := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(valuesJSON), &values); err != nil {
+		return valuesJSON
+	}
+	vars, _ := ParseTemplateVariables(template.Variables)
+	for _, v := range vars {
+		if v.NormalizedType() == model.VarTypeSecret {
+			if val, ok := values[v.VarName]; ok && !isEmptyValue(val) {
+				values[v.VarName] = MaskedValue
+			}
+		}
+	}
+	masked, _ := json.Marshal(values)
+	return string(masked)
+}
+
+// MaskParameters 对任务参数JSON中记录的 secret 变量脱敏
+// 任务参数中 secretVariables 列出了属于 secret 类型的变量名
+func MaskParameters(parameters string) string {
+	if parameters == "" {
+		return parameters
+	}
+	var params map[string]interface{}
+	if err := json.Unmarshal([]byte(parameters), &params); err != nil {
+		return parameters
+	}
+	names, _ := params["secretVariables"].([]interface{})
+	variables, _ := params["variables"].(map[string]interface{})
+	if len(names) == 0 || variables == nil {
+		return parameters
+	}
+	for _, n := range names {
+		if name, ok := n.(string); ok {
+			if val, exists := variables[name]; exists && !isEmptyValue(val) {
+				variables[name] = MaskedValue
+			}
+		}
+	}
+	masked, _ := json.Marshal(params)
+	return string(masked)
+}
+
+// RedactSecrets 将文本中出现的 secret 原值替换为脱敏值
+func RedactSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, MaskedValue)
+		}
+	}
+	return s
+}
+
+// renderValue 按类型校验单个取值并返回按脚本类型转义后替换到脚本中的内容
+func renderValue(v model.TemplateVariable, value, scriptType string) (string, error) {
+	switch v.NormalizedType() {
+	case model.VarTypeInt:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("必须为整数")
+		}
+		if v.Min != nil && n < *v.Min {
+			return "", fmt.Errorf("不能小于 %d", *v.Min)
+		}
+		if v.Max != nil && n > *v.Max {
+			return "", fmt.Errorf("不能大于 %d", *v.Max)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case model.VarTypeEnum:
+		for _, opt := range v.Options {
+			if opt == value {
+				return quoteLiteral(scriptType, value)
+			}
+		}
+		return "", fmt.Errorf("取值必须为 %s 之一", strings.Join(v.Options, "、"))
+	case model.VarTypeSecret:
+		if utf8.RuneCountInString(value) < minSecretLength {
+			return "", fmt.Errorf("长度不能少于 %d 个字符", minSecretLength)
+		}
+		return quoteLiteral(scriptType, value)
+	case model.VarTypeString:
+		return quoteLiteral(scriptType, value)
+	}
+	return "", fmt.Errorf("类型【%s】不支持", v.Type)
+}
+
+// emptyLiteral 变量未取值时替换到脚本中的内容
+func emptyLiteral(v model.TemplateVariable, scriptType string) string {
+	switch v.NormalizedType() {
+	case model.VarTypeInt:
+		if isPython(scriptType) {
+			return "None"
+		}
+		return ""
+	case model.VarTypeHostList:
+		if isPython(scriptType) {
+			return "[]"
+		}
+		return ""
+	}
+	out, _ := quoteLiteral(scriptType, "")
+	return out
+}
+
+// quoteLiteral 按脚本类型将取值转义为字符串字面量
+func quoteLiteral(scriptType, s string) (string, error) {
+	switch {
+	case scriptType == "" || strings.EqualFold(scriptType, "Shell"):
+		return shellescape(s), nil
+	case isPython(scriptType):
+		return pythonString(s), nil
+	}
+	return "", fmt.Errorf("不支持的脚本类型: %s", scriptType)
+}
+
+// isPython 是否为 Python 脚本
+func isPython(scriptType string) bool {
+	return strings.EqualFold(scriptType, "Python")
+}
+
+// pythonString 转义为 Python 字符串字面量
+// JSON 字符串的转义规则是 Python 字符串字面量的子集，且不会产生跨行内容
+func pythonString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// toString 将标量取值转换为字符串
+func toString(value interface{}) (string, error) {
+	switch val := value.(type) {
+	case string:
+		return val, nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case json.Number:
+		return val.String(), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case int:
+		return strconv.Itoa(val), nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	}
+	return "", fmt.Errorf("取值类型不正确")
+}
+
+// toHostIDs 解析主机列表取值：数字数组、JSON数组字符串或逗号分隔的字符串
+func toHostIDs(value interface{}) ([]uint, error) {
+	var items []interface{}
+	switch val := value.(type) {
+	case []interface{}:
+		items = val
+	case []uint:
+		return val, nil
+	case string:
+		val = strings.TrimSpace(val)
+		if strings.HasPrefix(val, "[") {
+			if err := json.Unmarshal([]byte(val), &items); err != nil {
+				return nil, fmt.Errorf("主机列表格式错误")
+			}
+		} else {
+			for _, part := range strings.Split(val, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					items = append(items, part)
+				}
+			}
+		}
+	default:
+		items = []interface{}{val}
+	}
+
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		str, err := toString(item)
+		if err != nil {
+			return nil, fmt.Errorf("主机列表格式错误")
+		}
+		id, err := strconv.ParseUint(strings.TrimSpace(str), 10, 64)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("主机ID【%s】无效", str)
+		}
+		ids = append(ids, uint(id))
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("至少选择一台主机")
+	}
+	return ids, nil
+}
+
+// lookupHostIPs 按顺序返回主机IP，主机不存在时返回错误
+func lookupHostIPs(ctx context.Context, db *gorm.DB, ids []uint) ([]string, error) {
+	var hosts []assetbiz.Host
+	if err := db.WithContext(ctx).Select("id, ip").Where("id IN ?", ids).Find(&hosts).Error; err != nil {
+		return nil, fmt.Errorf("查询主机失败: %w", err)
+	}
+	ipByID := make(map[uint]string, len(hosts))
+	for _, h := range hosts {
+		ipByID[h.ID] = h.IP
+	}
+
+	ips := make([]string, 0, len(ids))
+	for _, id := range ids {
+		ip, ok := ipByID[id]
+		if !ok {
+			return nil, fmt.Errorf("主机【%d】不存在", id)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// isEmptyValue 判断取值是否为空
+func isEmptyValue(value interface{}) bool {
+	switch val := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(val) == ""
+	case []interface{}:
+		return len(val) == 0
+	}
+	return false
+}
+
+// variableLabel 返回变量的显示名称
+func variableLabel(v model.TemplateVariable) string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return v.VarName
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2026 DYCloud J.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ydcloud-dy/opshub/plugins/task/model"
+)
+
+func int64Ptr(n int64) *int64 { return &n }
+
+func TestRenderValue(t *testing.T) {
+	str := model.TemplateVariable{VarName: "s", Type: model.VarTypeString}
+	secret := model.TemplateVariable{VarName: "p", Type: model.VarTypeSecret}
+	num := model.TemplateVariable{VarName: "n", Type: model.VarTypeInt, Min: int64Ptr(1), Max: int64Ptr(10)}
+	enum := model.TemplateVariable{VarName: "e", Type: model.VarTypeEnum, Options: []string{"start", "it's"}}
+
+	tests := []struct {
+		name       string
+		v          model.TemplateVariable
+		value      string
+		scriptType string
+		want       string
+		wantErr    string
+	}{
+		{"Shell字符串", str, "hello world", "Shell", "'hello world'", ""},
+		{"Shell单引号", str, "it's; rm -rf /", "Shell", `'it'"'"'s; rm -rf /'`, ""},
+		{"Shell命令替换", str, "$(id)`id`", "Shell", "'$(id)`id`'", ""},
+		{"Python字符串", str, "hello", "Python", `"hello"`, ""},
+		{"Python引号和换行", str, "a\"b'c\nd\\", "Python", `"a\"b'c\nd\\"`, ""},
+		{"Python代码注入", str, `"); import os; os.system("id`, "Python", `"\"); import os; os.system(\"id"`, ""},
+		{"Python不转义HTML", str, "<a>&", "Python", `"<a>&"`, ""},
+		{"整数", num, " 5 ", "Python", "5", ""},
+		{"整数非法", num, "5; id", "Shell", "", "必须为整数"},
+		{"整数过小", num, "0", "Shell", "", "不能小于 1"},
+		{"整数过大", num, "11", "Shell", "", "不能大于 10"},
+		{"枚举Shell", enum, "it's", "Shell", `'it'"'"'s'`, ""},
+		{"枚举Python", enum, "start", "Python", `"start"`, ""},
+		{"枚举非法", enum, "stop", "Shell", "", "取值必须为"},
+		{"secret", secret, "p@ssw0rd", "Shell", "'p@ssw0rd'", ""},
+		{"secret过短", secret, "12345", "Shell", "", "长度不能少于 6"},
+		{"secret按字符计算长度", secret, "密码密码密码", "Python", `"密码密码密码"`, ""},
+		{"不支持的脚本类型", str, "x", "Ruby", "", "不支持的脚本类型"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderValue(tt.v, tt.value, tt.scriptType)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderValue = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	vars, _ := json.Marshal([]model.TemplateVariable{
+		{VarName: "name", Type: model.VarTypeString, Required: true},
+		{VarName: "count", Type: model.VarTypeInt, DefaultValue: "3"},
+		{VarName: "token", Type: model.VarTypeSecret, DefaultValue: "default-token"},
+		{VarName: "note", Type: model.VarTypeString},
+	})
+	protected, err := ProtectTemplateSecrets(string(vars), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &model.JobTemplate{
+		Content:   "echo {{name}} {{ count }} {{token}} {{note}} {{undeclared}}",
+		Variables: protected,
+	}
+
+	tests := []struct {
+		name        string
+		scriptType  string
+		values      map[string]interface{}
+		want        string
+		wantSecrets []string
+		wantErr     string
+	}{
+		{
+			name:        "Shell使用默认值",
+			scriptType:  "Shell",
+			values:      map[string]interface{}{"name": "a b"},
+			want:        "echo 'a b' 3 'default-token' '' {{undeclared}}",
+			wantSecrets: []string{"default-token"},
+		},
+		{
+			name:        "Python覆盖默认值",
+			scriptType:  "Python",
+			values:      map[string]interface{}{"name": "x", "count": float64(7), "token": "override-token"},
+			want:        `echo "x" 7 "override-token" "" {{undeclared}}`,
+			wantSecrets: []string{"override-token"},
+		},
+		{
+			name:       "替换结果不再被扫描",
+			scriptType: "Shell",
+			values:     map[string]interface{}{"name": "{{token}}"},
+			want:       "echo '{{token}}' 3 'default-token' '' {{undeclared}}",
+		},
+		{name: "缺少必填项", scriptType: "Shell", values: nil, wantErr: "为必填项"},
+		{name: "secret过短", scriptType: "Shell", values: map[string]interface{}{"name": "x", "token": "abc"}, wantErr: "长度不能少于"},
+		{name: "不支持的脚本类型", scriptType: "Perl", values: map[string]interface{}{"name": "x"}, wantErr: "不支持的脚本类型"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := RenderTemplate(context.Background(), nil, template, tt.scriptType, tt.values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rendered.Content != tt.want {
+				t.Errorf("content = %s, want %s", rendered.Content, tt.want)
+			}
+			if tt.wantSecrets != nil && strings.Join(rendered.Secrets, ",") != strings.Join(tt.wantSecrets, ",") {
+				t.Errorf("secrets = %v, want %v", rendered.Secrets, tt.wantSecrets)
+			}
+			if rendered.Masked["token"] != MaskedValue {
+				t.Errorf("masked token = %v, want %s", rendered.Masked["token"], MaskedValue)
+			}
+		})
+	}
+}
+
+func TestTemplateSecretDefaults(t *testing.T) {
+	vars, _ := json.Marshal([]model.TemplateVariable{
+		{VarName: "token", Type: model.VarTypeSecret, DefaultValue: "default-token"},
+		{VarName: "name", Type: model.VarTypeString, DefaultValue: "plain"},
+	})
+
+	protected, err := ProtectTemplateSecrets(string(vars), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(protected, "default-token") || !strings.Contains(protected, `"defaultValue":"plain"`) {
+		t.Fatalf("protected = %s, want only secret default encrypted", protected)
+	}
+	// 已加密的值不会被重复加密
+	if again, _ := ProtectTemplateSecrets(protected, ""); again != protected {
+		t.Errorf("protect is not idempotent: %s", again)
+	}
+
+	template := &model.JobTemplate{Variables: protected}
+	MaskTemplateSecrets(template)
+	if !strings.Contains(template.Variables, `"defaultValue":"`+MaskedValue+`"`) || !strings.Contains(template.Variables, `"defaultValue":"plain"`) {
+		t.Fatalf("masked = %s", template.Variables)
+	}
+
+	// 编辑时回传脱敏值沿用原值
+	kept, err := ProtectTemplateSecrets(template.Variables, protected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept != protected {
+		t.Errorf("kept = %s, want %s", kept, protected)
+	}
+	if err := ValidateTemplateVariables(template.Variables); err != nil {
+		t.Errorf("masked secret default should pass validation: %v", err)
+	}
+
+	short, _ := json.Marshal([]model.TemplateVariable{{VarName: "token", Type: model.VarTypeSecret, DefaultValue: "123"}})
+	if err := ValidateTemplateVariables(string(short)); err == nil {
+		t.Error("short secret default should fail validation")
+	}
+}
@@ -0,0 +1,70 @@
+import request from '@/utils/request'
+
+// 临时权限申请状态
+export type AccessRequestStatus = 'pending' | 'approved' | 'rejected' | 'cancelled' | 'revoked' | 'expired'
+
+// 临时权限申请资源类型
+export type AccessResourceType = 'host_group' | 'k8s_cluster'
+
+// 提交临时权限申请的参数，duration 单位为分钟
+export interface AccessRequestForm {
+  resourceType: AccessResourceType
+  assetGroupId?: number
+  hostIds?: number[]
+  permissions?: number
+  clusterId?: number
+  roleName?: string
+  roleNamespace?: string
+  roleType?: 'ClusterRole' | 'Role'
+  duration: number
+  reason: string
+}
+
+// 申请列表查询参数
+export interface AccessRequestQuery {
+  page: number
+  pageSize: number
+  status?: string
+  resourceType?: string
+  keyword?: string
+}
+
+// 获取我的申请
+export const getMyAccessRequests = (params: AccessRequestQuery) => {
+  return request.get('/api/v1/access-requests', { params })
+}
+
+// 获取所有人的申请（审批）
+export const getReviewAccessRequests = (params: AccessRequestQuery) => {
+  return request.get('/api/v1/access-requests/review', { params })
+}
+
+// 获取可申请的资源类型和最长时长
+export const getAccessResourceTypes = () => {
+  return request.get('/api/v1/access-requests/resource-types')
+}
+
+// 提交申请
+export const createAccessRequest = (data: AccessRequestForm) => {
+  return request.post('/api/v1/access-requests', data)
+}
+
+// 撤回申请或提前归还授权
+export const cancelAccessRequest = (id: number) => {
+  return request.post(`/api/v1/access-requests/${id}/cancel`)
+}
+
+// 批准申请
+export const approveAccessRequest = (id: number, comment?: string) => {
+  return request.post(`/api/v1/access-requests/${id}/approve`, { comment })
+}
+
+// 驳回申请
+export const rejectAccessRequest = (id: number, comment?: string) => {
+  return request.post(`/api/v1/access-requests/${id}/reject`, { comment })
+}
+
+// 收回已授权的申请
+export const revokeAccessRequest = (id: number, comment?: string) => {
+  return request.post(`/api/v1/access-requests/${id}/revoke`, { comment })
+}
@@ -1,4 +1,5 @@
 import request from '@/utils/request'
+import { getCurrentProjectId } from '@/utils/project'
 
 const BASE_URL = '/api/v1/plugins/kubernetes/arthas'
 
@@ -198,6 +199,10 @@ export function createArthasWebSocket(params: ArthasBaseParams): WebSocket {
     processId: params.processId || '',
     token: token || ''
   })
+  const projectId = getCurrentProjectId()
+  if (projectId) {
+    queryParams.set('projectId', projectId)
+  }
 
   return new WebSocket(`${protocol}//${host}${BASE_URL}/ws?${queryParams.toString()}`)
 }
@@ -1,5 +1,33 @@
 import request from '@/utils/request'
 
+// 授权规则时间段，weekdays 1-7 表示周一到周日
+export interface AccessTimeWindow {
+  weekdays?: number[]
+  start: string
+  end: string
+}
+
+// 授权规则生效条件
+export interface AccessConditions {
+  hostTags?: string[]
+  environments?: string[]
+  timeWindows?: AccessTimeWindow[]
+  timezone?: string
+  sourceCidrs?: string[]
+}
+
+// 创建/更新授权规则的参数
+export interface AssetPermissionForm {
+  roleId: number
+  assetGroupId: number
+  hostIds: number[]
+  permissions?: number
+  effect?: 'allow' | 'deny'
+  conditions?: AccessConditions
+  expiresAt?: string | null
+  remark?: string
+}
+
 // 获取资产权限列表
 export const getAssetPermissions = (params: {
   page: number
@@ -11,12 +39,7 @@ export const getAssetPermissions = (params: {
 }
 
 // 创建资产权限
-export const createAssetPermission = (data: {
-  roleId: number
-  assetGroupId: number
-  hostIds: number[]
-  permissions?: number
-}) => {
+export const createAssetPermission = (data: AssetPermissionForm) => {
   return request.post('/api/v1/asset-permissions', data)
 }
 
@@ -31,12 +54,7 @@ export const getAssetPermissionDetail = (id: number) => {
 }
 
 // 更新资产权限
-export const updateAssetPermission = (id: number, data: {
-  roleId: number
-  assetGroupId: number
-  hostIds: number[]
-  permissions?: number
-}) => {
+export const updateAssetPermission = (id: number, data: AssetPermissionForm) => {
   return request.put(`/api/v1/asset-permissions/${id}`, data)
 }
 
@@ -143,3 +143,28 @@ export const downloadHostFile = (hostId: number, path: string) => {
 export const deleteHostFile = (hostId: number, path: string) => {
   return request.delete(`/api/v1/hosts/${hostId}/files`, { data: { path } })
 }
+
+// 主机密钥（known_hosts）列表，status=mismatch 时只返回检测到密钥变更的主机
+export const getHostKeyList = (params: { page?: number; pageSize?: number; keyword?: string; status?: string }) => {
+  return request.get('/api/v1/host-keys', { params })
+}
+
+// 获取主机已信任的公钥及待确认的新公钥
+export const getHostKey = (hostId: number) => {
+  return request.get(`/api/v1/hosts/${hostId}/host-key`)
+}
+
+// 预置主机公钥，支持 authorized_keys 格式或 ssh-keyscan 输出的 known_hosts 行
+export const pinHostKey = (hostId: number, publicKey: string) => {
+  return request.put(`/api/v1/hosts/${hostId}/host-key`, { publicKey })
+}
+
+// 确认检测到的新公钥，fingerprint 需与待确认密钥的指纹一致
+export const acceptHostKey = (hostId: number, fingerprint: string) => {
+  return request.post(`/api/v1/hosts/${hostId}/host-key/accept`, { fingerprint })
+}
+
+// 删除主机公钥记录，下次连接时重新记录
+export const deleteHostKey = (hostId: number) => {
+  return request.delete(`/api/v1/hosts/${hostId}/host-key`)
+}
@@ -29,3 +29,13 @@ export const updateMenu = (id: number, data: any) => {
 export const deleteMenu = (id: number) => {
   return request.delete(`/api/v1/menus/${id}`)
 }
+
+// 获取已登记的接口权限
+export const getRoutePermissions = () => {
+  return request.get('/api/v1/route-permissions')
+}
+
+// 获取未登记权限的接口
+export const getUnmappedRoutes = () => {
+  return request.get('/api/v1/route-permissions/unmapped')
+}
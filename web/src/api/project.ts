@@ -0,0 +1,56 @@
+import request from '@/utils/request'
+
+// 获取当前用户可切换的项目
+export const getMyProjects = () => {
+  return request.get('/api/v1/projects/mine')
+}
+
+// 获取项目列表
+export const getProjectList = (params?: { keyword?: string }) => {
+  return request.get('/api/v1/projects', { params })
+}
+
+// 获取项目详情（含资源数量）
+export const getProject = (id: number) => {
+  return request.get(`/api/v1/projects/${id}`)
+}
+
+// 创建项目
+export const createProject = (data: any) => {
+  return request.post('/api/v1/projects', data)
+}
+
+// 更新项目
+export const updateProject = (id: number, data: any) => {
+  return request.put(`/api/v1/projects/${id}`, data)
+}
+
+// 删除项目
+export const deleteProject = (id: number) => {
+  return request.delete(`/api/v1/projects/${id}`)
+}
+
+// 获取项目成员
+export const getProjectMembers = (id: number) => {
+  return request.get(`/api/v1/projects/${id}/members`)
+}
+
+// 添加项目成员并设置项目角色
+export const saveProjectMembers = (id: number, data: { userIds: number[]; roleId: number }) => {
+  return request.post(`/api/v1/projects/${id}/members`, data)
+}
+
+// 移除项目成员
+export const removeProjectMember = (id: number, userId: number) => {
+  return request.delete(`/api/v1/projects/${id}/members/${userId}`)
+}
+
+// 获取按项目隔离的资源类型
+export const getProjectResourceTypes = () => {
+  return request.get('/api/v1/projects/resource-types')
+}
+
+// 迁移资源到项目
+export const assignProjectResources = (id: number, data: { resourceType: string; ids: number[] }) => {
+  return request.post(`/api/v1/projects/${id}/resources`, data)
+}
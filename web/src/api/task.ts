@@ -1,4 +1,5 @@
 import request from '@/utils/request'
+import { getCurrentProjectId } from '@/utils/project'
 
 // ==================== 任务执行 ====================
 
@@ -6,40 +7,110 @@ export interface ExecuteTaskRequest {
   hostIds: number[]
   scriptType: string // Shell, Python
   content: string
+  templateId?: number // 指定模板时由后端按变量类型校验、转义并渲染
+  variables?: Record<string, any>
   name?: string
+  fork?: number // 并发数
+  timeout?: number // 单台主机超时（秒）
+  batchTimeout?: number // 整批任务超时（秒）
+  rolling?: RollingStrategy // 滚动执行策略
+}
+
+// 滚动执行策略，语义与 Ansible 的 serial、max_fail_percentage 一致
+export interface RollingStrategy {
+  batchSize: string // 每批主机数："5"、"20%"，或逐批使用 "1,25%,50%"
+  pauseSeconds?: number // 批次间暂停（秒）
+  maxFailPercentage?: number // 单批失败率超过该百分比时停止后续批次
+}
+
+export interface RolloutBatch {
+  index: number
+  hostIds: number[]
+  status: string
+  failed: number
+  failureRate: number
+  startTime?: string
+  endTime?: string
+}
+
+export interface RolloutStatus {
+  batchSize: string
+  pauseSeconds: number
+  maxFailPercentage?: number
+  batches: RolloutBatch[]
+  currentBatch: number
+  halted: boolean
+  haltedBatch?: number
+  haltReason?: string
 }
 
 export interface HostExecutionResult {
   hostId: number
   hostName: string
   hostIp: string
-  status: string // success, failed
+  status: string // pending, running, success, failed, timeout, cancelled, skipped
+  batch?: number // 滚动执行时所在批次（从1开始）
   output: string
   error?: string
+  startTime?: string
+  endTime?: string
 }
 
 export interface ExecuteTaskResponse {
   taskId: number
+  status: string
   results: HostExecutionResult[]
+  approvalId?: number
+  reason?: string
 }
 
 export const executeTask = (data: ExecuteTaskRequest) => {
   return request.post<any, ExecuteTaskResponse>('/api/v1/plugins/task/execute', data)
 }
 
+// 任务输出事件
+export interface JobOutputEvent {
+  seq: number
+  jobId: number
+  hostId?: number
+  hostName?: string
+  type: string // stdout, stderr, status, batch, done
+  data: string
+  time: string
+}
+
+// 订阅任务实时输出，since 为已收到的最后一条事件序号（重连时只推送之后的事件）
+export const createJobOutputWebSocket = (taskId: number, since: number = 0): WebSocket => {
+  const token = localStorage.getItem('token') || ''
+  const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
+  const params = new URLSearchParams({ since: String(since), token })
+  const projectId = getCurrentProjectId()
+  if (projectId) {
+    params.set('projectId', projectId)
+  }
+  return new WebSocket(
+    `${protocol}//${window.location.host}/api/v1/plugins/task/jobs/${taskId}/output?${params.toString()}`
+  )
+}
+
 // ==================== 任务作业 ====================
 
 export interface JobTask {
   id: number
   name: string
   templateId?: number
+  scheduleId?: number
   taskType: string
   status: string
   targetHosts?: string
   parameters?: string
   executeTime?: string
   result?: string
+  rollout?: string // JSON，RolloutStatus
   errorMessage?: string
+  cancelledBy?: number
+  cancelledByName?: string
+  cancelledAt?: string
   createdBy: number
   createdAt: string
   updatedAt: string
@@ -69,6 +140,10 @@ export const updateJobTask = (id: number, data: any) => {
   return request.put<any, JobTask>(`/api/v1/plugins/task/jobs/${id}`, data)
 }
 
+export const cancelJobTask = (id: number) => {
+  return request.post<any, any>(`/api/v1/plugins/task/jobs/${id}/cancel`)
+}
+
 export const deleteJobTask = (id: number) => {
   return request.delete<any, any>(`/api/v1/plugins/task/jobs/${id}`)
 }
@@ -125,6 +200,304 @@ export const deleteJobTemplate = (id: number) => {
   return request.delete<any, any>(`/api/v1/plugins/task/templates/${id}`)
 }
 
+// ==================== 定时任务 ====================
+
+export interface JobSchedule {
+  id: number
+  name: string
+  description?: string
+  cronExpr: string
+  timezone: string
+  templateId: number
+  hostIds?: string
+  groupIds?: string
+  variables?: string
+  scriptType: string
+  overlapPolicy: 'skip' | 'queue' | 'parallel'
+  fork: number
+  timeout: number
+  batchTimeout: number
+  rolling?: string // JSON，滚动执行策略
+  status: number
+  lastRunTime?: string
+  lastJobId?: number
+  lastStatus?: string
+  nextRunTime?: string
+  createdBy: number
+  createdAt: string
+  updatedAt: string
+}
+
+export interface JobScheduleRequest {
+  name: string
+  description?: string
+  cronExpr: string
+  timezone?: string
+  templateId: number
+  hostIds?: number[]
+  groupIds?: number[]
+  variables?: Record<string, string>
+  scriptType?: string
+  overlapPolicy?: 'skip' | 'queue' | 'parallel'
+  fork?: number
+  timeout?: number
+  batchTimeout?: number // 整批任务超时（秒）
+  rolling?: RollingStrategy // 滚动执行策略
+  status?: number
+}
+
+export interface JobScheduleListParams {
+  page?: number
+  pageSize?: number
+  keyword?: string
+  status?: number
+}
+
+export const getJobScheduleList = (params: JobScheduleListParams) => {
+  return request.get<any, any>('/api/v1/plugins/task/schedules', { params })
+}
+
+export const getJobScheduleDetail = (id: number) => {
+  return request.get<any, JobSchedule>(`/api/v1/plugins/task/schedules/${id}`)
+}
+
+export const createJobSchedule = (data: JobScheduleRequest) => {
+  return request.post<any, JobSchedule>('/api/v1/plugins/task/schedules', data)
+}
+
+export const updateJobSchedule = (id: number, data: JobScheduleRequest) => {
+  return request.put<any, JobSchedule>(`/api/v1/plugins/task/schedules/${id}`, data)
+}
+
+export const deleteJobSchedule = (id: number) => {
+  return request.delete<any, any>(`/api/v1/plugins/task/schedules/${id}`)
+}
+
+export const runJobSchedule = (id: number) => {
+  return request.post<any, any>(`/api/v1/plugins/task/schedules/${id}/run`)
+}
+
+export const getJobScheduleNextRuns = (id: number, count: number = 5) => {
+  return request.get<any, string[]>(`/api/v1/plugins/task/schedules/${id}/next-runs`, { params: { count } })
+}
+
+export const previewJobSchedule = (cronExpr: string, timezone?: string, count: number = 5) => {
+  return request.post<any, string[]>('/api/v1/plugins/task/schedules/preview', { cronExpr, timezone, count })
+}
+
+// ==================== 命令策略 ====================
+
+export type PolicyAction = 'allow' | 'deny' | 'require_approval'
+export type PolicyMatchType = 'command' | 'redirect' | 'pipe_shell' | 'dynamic' | 'env' | 'script'
+
+export interface CommandPolicy {
+  id: number
+  name: string
+  description?: string
+  action: PolicyAction
+  matchType: PolicyMatchType
+  command?: string
+  pattern?: string
+  roleIds?: string
+  assetGroupIds?: string
+  priority: number
+  status: number
+  builtin: boolean
+  createdBy: number
+  createdAt: string
+  updatedAt: string
+}
+
+export interface CommandPolicyRequest {
+  name: string
+  description?: string
+  action: PolicyAction
+  matchType?: PolicyMatchType
+  command?: string
+  pattern?: string
+  roleIds?: number[]
+  assetGroupIds?: number[]
+  priority?: number
+  status?: number
+}
+
+export interface CommandPolicyListParams {
+  page?: number
+  pageSize?: number
+  keyword?: string
+  action?: PolicyAction
+  matchType?: PolicyMatchType
+  status?: number
+}
+
+export interface CommandEvent {
+  kind: string
+  command?: string
+  args?: string[]
+  target?: string
+  line: number
+  text: string
+}
+
+export interface PolicyMatch {
+  policyId: number
+  policyName: string
+  action: PolicyAction
+  event: CommandEvent
+}
+
+export interface PolicyDecision {
+  action: PolicyAction
+  reason: string
+  matched?: PolicyMatch
+  matches: PolicyMatch[]
+  events: CommandEvent[]
+  roleIds: number[]
+  groupIds: number[]
+}
+
+export interface PolicyEvaluateRequest {
+  content: string
+  scriptType?: string
+  hostIds?: number[]
+  userId?: number
+}
+
+export const getCommandPolicyList = (params: CommandPolicyListParams) => {
+  return request.get<any, any>('/api/v1/plugins/task/policies', { params })
+}
+
+export const getCommandPolicyDetail = (id: number) => {
+  return request.get<any, CommandPolicy>(`/api/v1/plugins/task/policies/${id}`)
+}
+
+export const createCommandPolicy = (data: CommandPolicyRequest) => {
+  return request.post<any, CommandPolicy>('/api/v1/plugins/task/policies', data)
+}
+
+export const updateCommandPolicy = (id: number, data: CommandPolicyRequest) => {
+  return request.put<any, CommandPolicy>(`/api/v1/plugins/task/policies/${id}`, data)
+}
+
+export const deleteCommandPolicy = (id: number) => {
+  return request.delete<any, any>(`/api/v1/plugins/task/policies/${id}`)
+}
+
+export const evaluateCommandPolicy = (data: PolicyEvaluateRequest) => {
+  return request.post<any, PolicyDecision>('/api/v1/plugins/task/policies/evaluate', data)
+}
+
+// ==================== 任务审批 ====================
+
+export type ApprovalStatus = 'pending' | 'approved' | 'rejected' | 'cancelled'
+
+export interface JobApproval {
+  id: number
+  jobId: number
+  status: ApprovalStatus
+  reason: string
+  policyMatches?: string
+  ruleIds?: string
+  approverRoleIds?: string
+  approverDeptIds?: string
+  scriptType: string
+  content: string
+  fork: number
+  timeout: number
+  batchTimeout: number
+  requestedBy: number
+  requestedByName: string
+  approvedBy?: number
+  approvedByName?: string
+  comment?: string
+  decidedAt?: string
+  createdAt: string
+  updatedAt: string
+}
+
+export interface JobApprovalRecord {
+  id: number
+  approvalId: number
+  jobId: number
+  action: 'submit' | 'approve' | 'reject' | 'comment' | 'cancel' | 'execute'
+  userId: number
+  username: string
+  comment?: string
+  createdAt: string
+}
+
+export interface JobApprovalDetail extends JobApproval {
+  jobName: string
+  jobStatus: string
+  canApprove: boolean
+  records: JobApprovalRecord[]
+}
+
+export interface JobApprovalListParams {
+  page?: number
+  pageSize?: number
+  status?: ApprovalStatus
+  scope?: 'todo' | 'mine'
+}
+
+export interface ApprovalRule {
+  id: number
+  name: string
+  description?: string
+  assetGroupIds?: string
+  approverRoleIds?: string
+  approverDeptIds?: string
+  status: number
+  createdBy: number
+  createdAt: string
+  updatedAt: string
+}
+
+export interface ApprovalRuleRequest {
+  name: string
+  description?: string
+  assetGroupIds?: number[]
+  approverRoleIds?: number[]
+  approverDeptIds?: number[]
+  status?: number
+}
+
+export const getJobApprovalList = (params: JobApprovalListParams) => {
+  return request.get<any, any>('/api/v1/plugins/task/approvals', { params })
+}
+
+export const getJobApprovalDetail = (id: number) => {
+  return request.get<any, JobApprovalDetail>(`/api/v1/plugins/task/approvals/${id}`)
+}
+
+export const approveJobApproval = (id: number, comment?: string) => {
+  return request.post<any, JobApproval>(`/api/v1/plugins/task/approvals/${id}/approve`, { comment })
+}
+
+export const rejectJobApproval = (id: number, comment: string) => {
+  return request.post<any, JobApproval>(`/api/v1/plugins/task/approvals/${id}/reject`, { comment })
+}
+
+export const commentJobApproval = (id: number, comment: string) => {
+  return request.post<any, JobApprovalRecord>(`/api/v1/plugins/task/approvals/${id}/comments`, { comment })
+}
+
+export const getApprovalRuleList = () => {
+  return request.get<any, ApprovalRule[]>('/api/v1/plugins/task/approval-rules')
+}
+
+export const createApprovalRule = (data: ApprovalRuleRequest) => {
+  return request.post<any, ApprovalRule>('/api/v1/plugins/task/approval-rules', data)
+}
+
+export const updateApprovalRule = (id: number, data: ApprovalRuleRequest) => {
+  return request.put<any, ApprovalRule>(`/api/v1/plugins/task/approval-rules/${id}`, data)
+}
+
+export const deleteApprovalRule = (id: number) => {
+  return request.delete<any, any>(`/api/v1/plugins/task/approval-rules/${id}`)
+}
+
 // ==================== Ansible任务 ====================
 
 export interface AnsibleTask {
@@ -132,7 +505,7 @@ export interface AnsibleTask {
   name: string
   playbookContent?: string
   playbookPath?: string
-  inventory?: string
+  inventory?: string // JSON: { hostIds: number[], groupIds: number[] }
   extraVars?: string
   tags?: string
   fork: number
@@ -141,6 +514,7 @@ export interface AnsibleTask {
   status: string
   lastRunTime?: string
   lastRunResult?: string
+  lastJobId?: number
   createdBy: number
   createdAt: string
   updatedAt: string
@@ -169,6 +543,11 @@ export const updateAnsibleTask = (id: number, data: any) => {
   return request.put<any, AnsibleTask>(`/api/v1/plugins/task/ansible/${id}`, data)
 }
 
+// 执行Ansible任务，返回的 taskId 可用于订阅输出和取消
+export const runAnsibleTask = (id: number) => {
+  return request.post<any, ExecuteTaskResponse>(`/api/v1/plugins/task/ansible/${id}/run`)
+}
+
 export const deleteAnsibleTask = (id: number) => {
   return request.delete<any, any>(`/api/v1/plugins/task/ansible/${id}`)
 }
@@ -222,10 +601,41 @@ export const exportExecutionHistory = (ids?: number[]) => {
 
 // ==================== 文件分发 ====================
 
+export interface FileTransferResult {
+  name: string
+  remotePath: string
+  status: string // uploaded, unchanged, failed
+  error?: string
+}
+
+export interface FileDistributionResult {
+  hostId: number
+  hostName: string
+  hostIp: string
+  status: string // success, unchanged, failed
+  error?: string
+  files?: FileTransferResult[]
+  attempts: number
+  startTime?: string
+  endTime?: string
+}
+
+// 分发在后台进行，通过 getJobTaskDetail 查询任务状态，结束后 result 为 FileDistributionResult[] 的 JSON
+export interface FileDistributionResponse {
+  taskId: number
+  status: string
+}
+
+// formData: files, targetPath, hostIds(JSON数组)，可选 owner、mode、fork、timeout
 export const distributeFiles = (formData: FormData) => {
-  return request.post<any, any>('/api/v1/plugins/task/distribute', formData, {
+  return request.post<any, FileDistributionResponse>('/api/v1/plugins/task/distribute', formData, {
     headers: {
       'Content-Type': 'multipart/form-data'
     }
   })
 }
+
+// 使用暂存文件向上次失败的主机重新分发
+export const retryDistribution = (taskId: number) => {
+  return request.post<any, FileDistributionResponse>(`/api/v1/plugins/task/distribute/${taskId}/retry`)
+}
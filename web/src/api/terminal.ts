@@ -16,8 +16,9 @@ export const getTerminalSessions = (params: {
 /**
  * 播放终端会话录制
  */
-export const playTerminalSession = (id: number) => {
+export const playTerminalSession = (id: number, source?: 'host' | 'k8s') => {
   return request.get(`/api/v1/terminal-sessions/${id}/play`, {
+    params: source ? { source } : undefined,
     responseType: 'text'
   })
 }
@@ -28,3 +29,57 @@ export const playTerminalSession = (id: number) => {
 export const deleteTerminalSession = (id: number) => {
   return request.delete(`/api/v1/terminal-sessions/${id}`)
 }
+
+/**
+ * 获取在线终端会话
+ */
+export const getLiveTerminalSessions = () => {
+  return request.get('/api/v1/asset/terminal/sessions')
+}
+
+/**
+ * 强制终止在线终端会话（仅限管理员）
+ */
+export const terminateLiveTerminalSession = (id: string) => {
+  return request.delete(`/api/v1/asset/terminal/sessions/${id}`)
+}
+
+/**
+ * 检索终端命令
+ */
+export const searchTerminalCommands = (params: {
+  page: number
+  pageSize: number
+  keyword?: string
+  username?: string
+  hostId?: number
+  status?: string
+  startTime?: string
+  endTime?: string
+}) => {
+  return request.get('/api/v1/terminal-sessions/commands', { params })
+}
+
+/**
+ * 获取终端会话中执行的命令
+ */
+export const getSessionCommands = (id: number) => {
+  return request.get(`/api/v1/terminal-sessions/${id}/commands`)
+}
+
+/**
+ * 全文检索终端录制（主机终端和 Pod 终端）
+ */
+export const searchTerminalRecordings = (params: {
+  keyword: string
+  page: number
+  pageSize: number
+  source?: string
+  stream?: string
+  username?: string
+  target?: string
+  startTime?: string
+  endTime?: string
+}) => {
+  return request.get('/api/v1/terminal-sessions/search', { params })
+}
@@ -49,3 +49,8 @@ export const unlockUser = (id: number) => {
 export const changePassword = (oldPassword: string, newPassword: string) => {
   return request.put('/api/v1/profile/password', { oldPassword, newPassword })
 }
+
+// 修改个人资料
+export const updateProfile = (data: { realName?: string; email?: string; phone?: string; avatar?: string }) => {
+  return request.put('/api/v1/profile', data)
+}
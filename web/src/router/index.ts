@@ -65,6 +65,12 @@ const router = createRouter({
           component: () => import('@/views/system/SystemConfig.vue'),
           meta: { title: '系统配置' }
         },
+        {
+          path: 'projects',
+          name: 'Projects',
+          component: () => import('@/views/system/Projects.vue'),
+          meta: { title: '项目管理' }
+        },
         {
           path: 'audit/operation-logs',
           name: 'OperationLogs',
@@ -119,6 +125,12 @@ const router = createRouter({
           component: () => import('@/views/asset/AssetPermission.vue'),
           meta: { title: '权限配置' }
         },
+        {
+          path: 'asset/access-requests',
+          name: 'AssetAccessRequests',
+          component: () => import('@/views/asset/AccessRequests.vue'),
+          meta: { title: '临时权限' }
+        },
         {
           path: 'profile',
           name: 'Profile',
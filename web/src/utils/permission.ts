@@ -6,7 +6,9 @@ export const PERMISSION = {
   TERMINAL: 1 << 3, // 8 - 终端
   FILE: 1 << 4,     // 16 - 文件管理
   COLLECT: 1 << 5,  // 32 - 采集信息
-  ALL: 0x3F,        // 63 - 所有权限
+  EXECUTE: 1 << 6,  // 64 - 执行任务
+  DISTRIBUTE: 1 << 7, // 128 - 文件分发
+  ALL: 0xFF,        // 255 - 所有权限
 } as const
 
 /**
@@ -38,6 +40,10 @@ export function getPermissionName(permission: number): string {
       return '文件管理'
     case PERMISSION.COLLECT:
       return '采集信息'
+    case PERMISSION.EXECUTE:
+      return '执行任务'
+    case PERMISSION.DISTRIBUTE:
+      return '文件分发'
     default:
       return '未知'
   }
@@ -56,6 +62,8 @@ export function getPermissionNames(permissions: number): string[] {
   if ((permissions & PERMISSION.TERMINAL) > 0) names.push('终端')
   if ((permissions & PERMISSION.FILE) > 0) names.push('文件管理')
   if ((permissions & PERMISSION.COLLECT) > 0) names.push('采集信息')
+  if ((permissions & PERMISSION.EXECUTE) > 0) names.push('执行任务')
+  if ((permissions & PERMISSION.DISTRIBUTE) > 0) names.push('文件分发')
   return names
 }
 
@@ -86,6 +94,12 @@ export function permissionNamesToMask(permissionNames: string[]): number {
       case '采集信息':
         mask |= PERMISSION.COLLECT
         break
+      case '执行任务':
+        mask |= PERMISSION.EXECUTE
+        break
+      case '文件分发':
+        mask |= PERMISSION.DISTRIBUTE
+        break
     }
   }
   return mask
@@ -121,4 +135,6 @@ export const PERMISSION_OPTIONS = [
   { label: '连接终端', value: PERMISSION.TERMINAL, description: 'SSH连接到主机' },
   { label: '文件管理', value: PERMISSION.FILE, description: '文件上传、下载、删除' },
   { label: '采集信息', value: PERMISSION.COLLECT, description: '采集主机系统信息' },
+  { label: '执行任务', value: PERMISSION.EXECUTE, description: '在主机上执行脚本任务、Ansible' },
+  { label: '文件分发', value: PERMISSION.DISTRIBUTE, description: '向主机批量分发文件' },
 ]
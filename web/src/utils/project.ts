@@ -0,0 +1,31 @@
+// 当前项目保存在本地，所有请求通过 X-Project-ID 请求头传递，WebSocket 连接使用 projectId 查询参数
+const PROJECT_KEY = 'currentProjectId'
+
+/**
+ * 获取当前项目ID
+ * @returns 项目ID，未选择时返回空字符串（平台管理员表示查看全部项目）
+ */
+export function getCurrentProjectId(): string {
+  return localStorage.getItem(PROJECT_KEY) || ''
+}
+
+/**
+ * 切换当前项目
+ * @param projectId 项目ID，传空值表示查看全部项目
+ */
+export function setCurrentProjectId(projectId: number | string | null | undefined) {
+  if (projectId) {
+    localStorage.setItem(PROJECT_KEY, String(projectId))
+  } else {
+    localStorage.removeItem(PROJECT_KEY)
+  }
+}
+
+/**
+ * 拼接到 WebSocket 地址后的项目参数
+ * @returns 形如 &projectId=1 的查询参数，未选择项目时返回空字符串
+ */
+export function projectQuery(): string {
+  const projectId = getCurrentProjectId()
+  return projectId ? `&projectId=${projectId}` : ''
+}
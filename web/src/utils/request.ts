@@ -1,5 +1,6 @@
 import axios from 'axios'
 import { ElMessage } from 'element-plus'
+import { getCurrentProjectId, setCurrentProjectId } from '@/utils/project'
 
 const request = axios.create({
   baseURL: '/',
@@ -16,6 +17,11 @@ request.interceptors.request.use(
     if (token) {
       config.headers.Authorization = `Bearer ${token}`
     }
+    // 携带当前项目，后端按项目过滤资源
+    const projectId = getCurrentProjectId()
+    if (projectId) {
+      config.headers['X-Project-ID'] = projectId
+    }
     return config
   },
   (error) => {
@@ -67,6 +73,11 @@ request.interceptors.response.use(
         })
       }
 
+      // 已被移出当前项目或项目已禁用时清除选择，下次请求回到默认项目
+      if (res.code === 403 && res.message === '无权访问该项目') {
+        setCurrentProjectId(null)
+      }
+
       // 只在非登录接口的情况下自动显示错误消息
       // 登录接口和验证码接口的错误由调用方处理,避免重复提示
       if (!url.includes('/login') && !url.includes('/captcha')) {
@@ -0,0 +1,90 @@
+import type { AccessConditions, AccessTimeWindow } from '@/api/assetPermission'
+
+// 授权规则表单中的条件和效果部分
+export interface AccessRuleForm {
+  effect: 'allow' | 'deny'
+  hostTags: string[]
+  environments: string[]
+  timeWindows: AccessTimeWindow[]
+  timezone: string
+  sourceCidrs: string[]
+  expiresAt: string | null
+  remark: string
+}
+
+export const weekdayOptions = [
+  { value: 1, label: '周一' },
+  { value: 2, label: '周二' },
+  { value: 3, label: '周三' },
+  { value: 4, label: '周四' },
+  { value: 5, label: '周五' },
+  { value: 6, label: '周六' },
+  { value: 7, label: '周日' }
+]
+
+// 常用时区，也可以输入其他 IANA 时区名称
+export const timezoneOptions = [
+  'Asia/Shanghai',
+  'Asia/Tokyo',
+  'Asia/Singapore',
+  'Europe/London',
+  'Europe/Berlin',
+  'America/New_York',
+  'America/Los_Angeles',
+  'UTC'
+]
+
+export const emptyAccessRule = (): AccessRuleForm => ({
+  effect: 'allow',
+  hostTags: [],
+  environments: [],
+  timeWindows: [],
+  timezone: '',
+  sourceCidrs: [],
+  expiresAt: null,
+  remark: ''
+})
+
+// 从权限详情填充表单
+export const accessRuleFromDetail = (detail: any): AccessRuleForm => {
+  const conditions: AccessConditions = detail.conditions || {}
+  return {
+    effect: detail.effect === 'deny' ? 'deny' : 'allow',
+    hostTags: [...(conditions.hostTags || [])],
+    environments: [...(conditions.environments || [])],
+    timeWindows: (conditions.timeWindows || []).map(w => ({ ...w, weekdays: [...(w.weekdays || [])] })),
+    timezone: conditions.timezone || '',
+    sourceCidrs: [...(conditions.sourceCidrs || [])],
+    expiresAt: detail.expiresAt || null,
+    remark: detail.remark || ''
+  }
+}
+
+// 转换为提交参数
+export const accessRulePayload = (rule: AccessRuleForm) => ({
+  effect: rule.effect,
+  conditions: {
+    hostTags: rule.hostTags,
+    environments: rule.environments,
+    timeWindows: rule.timeWindows.filter(w => w.start && w.end),
+    timezone: rule.timezone || undefined,
+    sourceCidrs: rule.sourceCidrs
+  },
+  expiresAt: rule.expiresAt || null,
+  remark: rule.remark
+})
+
+// 生成条件摘要，用于列表展示
+export const describeConditions = (conditions?: AccessConditions): string[] => {
+  if (!conditions) return []
+  const result: string[] = []
+  if (conditions.hostTags?.length) result.push('标签: ' + conditions.hostTags.join(', '))
+  if (conditions.environments?.length) result.push('环境: ' + conditions.environments.join('/'))
+  for (const w of conditions.timeWindows || []) {
+    const days = (w.weekdays || []).map(d => weekdayOptions[d - 1]?.label).join('、')
+    const zone = conditions.timezone ? ` (${conditions.timezone})` : ''
+    result.push(`时间: ${days ? days + ' ' : ''}${w.start}-${w.end}${zone}`)
+  }
+  if (conditions.sourceCidrs?.length) result.push('来源: ' + conditions.sourceCidrs.join(', '))
+  return result
+}